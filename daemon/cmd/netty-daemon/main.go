@@ -1,76 +1,422 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"syscall"
+	"time"
 
-	"github.com/google/gopacket/pcap"
+	"github.com/iolloyd/netty/daemon/internal/alerts"
+	"github.com/iolloyd/netty/daemon/internal/arp"
+	"github.com/iolloyd/netty/daemon/internal/arpwatch"
+	"github.com/iolloyd/netty/daemon/internal/beacon"
+	"github.com/iolloyd/netty/daemon/internal/certs"
+	"github.com/iolloyd/netty/daemon/internal/blocker"
 	"github.com/iolloyd/netty/daemon/internal/capture"
+	"github.com/iolloyd/netty/daemon/internal/clock"
+	"github.com/iolloyd/netty/daemon/internal/config"
+	"github.com/iolloyd/netty/daemon/internal/conversation"
+	"github.com/iolloyd/netty/daemon/internal/dhcp"
+	"github.com/iolloyd/netty/daemon/internal/discovery"
+	"github.com/iolloyd/netty/daemon/internal/elastic"
+	"github.com/iolloyd/netty/daemon/internal/eventlog"
+	"github.com/iolloyd/netty/daemon/internal/export"
+	"github.com/iolloyd/netty/daemon/internal/firewall"
+	"github.com/iolloyd/netty/daemon/internal/geoip"
+	"github.com/iolloyd/netty/daemon/internal/history"
+	"github.com/iolloyd/netty/daemon/internal/kafka"
+	"github.com/iolloyd/netty/daemon/internal/inventory"
+	"github.com/iolloyd/netty/daemon/internal/models"
+	"github.com/iolloyd/netty/daemon/internal/process"
+	"github.com/iolloyd/netty/daemon/internal/ndp"
+	"github.com/iolloyd/netty/daemon/internal/parser"
+	"github.com/iolloyd/netty/daemon/internal/pcapwriter"
+	"github.com/iolloyd/netty/daemon/internal/retention"
+	"github.com/iolloyd/netty/daemon/internal/sink"
+	"github.com/iolloyd/netty/daemon/internal/store"
+	"github.com/iolloyd/netty/daemon/internal/summary"
+	"github.com/iolloyd/netty/daemon/internal/tap"
+	"github.com/iolloyd/netty/daemon/internal/version"
+	"github.com/iolloyd/netty/daemon/internal/watch"
+	"github.com/iolloyd/netty/daemon/internal/webhook"
 	"github.com/iolloyd/netty/daemon/internal/websocket"
 )
 
 func main() {
 	var (
 		iface       = flag.String("i", "", "Network interface to monitor (required)")
-		wsPort      = flag.String("port", "8080", "WebSocket server port")
+		wsPort      = flag.String("port", "8080", "WebSocket server port, on the loopback interface unless -listen is given")
+		listenAddr  = flag.String("listen", "", "Address to serve the WebSocket and HTTP API on, e.g. 0.0.0.0:8080 for remote clients (default 127.0.0.1:<port>)")
+		listenUnix  = flag.String("listen-unix", "", "Also serve on this Unix domain socket, e.g. /var/run/netty.sock; alone, serves only on it")
+		tlsCert     = flag.String("tls-cert", "", "PEM certificate to serve the WebSocket and HTTP API over TLS (wss/https), requires -tls-key")
+		tlsKey      = flag.String("tls-key", "", "PEM private key for -tls-cert")
+		apiToken    = flag.String("api-token", "", "Token clients must present to use /ws and /api/* (unset leaves the API open)")
+		apiTokenFile = flag.String("api-token-file", "", "Read the API token from this file, keeping it out of the process list")
+		readOnly    = flag.Bool("read-only", false, "Refuse every client command and API request that changes state, e.g. pausing the capture or applying firewall rules")
+		auditLog    = flag.String("audit-log", "", "Append a JSON line to this file for every client command and API request")
+		allowedOrigins = flag.String("allowed-origins", "", "Comma-separated web page origins allowed to use the API from a browser, e.g. https://dashboard.lan (* for any; default the daemon's own host only)")
+		allowedHosts = flag.String("allowed-hosts", "", "Comma-separated host names clients may reach the daemon by, e.g. netty.lan, besides IP addresses, localhost, this machine's hostname and the -listen host; guards against DNS rebinding")
 		filter      = flag.String("f", "", "BPF filter expression")
+		backend     = flag.String("backend", capture.DefaultBackend, "Live capture backend: pcap, or afpacket for an AF_PACKET ring filtered in the kernel on busy Linux hosts where libpcap drops packets (the default in builds without cgo). ebpf is an alias of afpacket: the kernel filter is classic BPF, not an eBPF program or AF_XDP")
+		snaplen     = flag.Int("snaplen", capture.DefaultSnaplen, "Bytes captured per packet; less keeps headers but cuts payloads short")
+		promisc     = flag.Bool("promisc", true, "Put the interface in promiscuous mode, capturing traffic not addressed to this host (-promisc=false captures only this host's)")
+		bufferSize  = flag.Int("buffer-size", 0, "Bytes of kernel buffer packets wait in to be read, more rides out bursts on busy interfaces (0 keeps the backend's default)")
+		immediate   = flag.Bool("immediate", false, "Hand packets over as they arrive rather than in batches, for lower latency at a higher CPU cost")
 		verbose     = flag.Bool("v", false, "Enable verbose logging")
 		listIfaces  = flag.Bool("list", false, "List available network interfaces")
+		allowFirewall = flag.Bool("allow-firewall", false, "Allow clients with the API token to apply generated firewall block rules (needs -api-token)")
+		adminToken    = flag.String("admin-token", "", "Token clients must send to reset statistics, conversations or the resolver cache (unset disables resets)")
+		blockBytes    = flag.Uint64("block-bytes", 0, "Block a remote host after this many bytes per window (0 disables)")
+		blockPackets  = flag.Uint64("block-packets", 0, "Block a remote host after this many packets per window (0 disables)")
+		blockWindow   = flag.Duration("block-window", time.Minute, "Window over which block thresholds are counted")
+		blockScope    = flag.String("block-scope", "host", "What block thresholds apply to: host or conversation")
+		blockCooldown = flag.Duration("block-cooldown", 10*time.Minute, "How long an automatic block lasts")
+		blockScript   = flag.String("block-script", "", "Script invoked as: <script> block|unblock <host> [port] [protocol]")
+		blockFirewall = flag.Bool("block-firewall", false, "Install automatic blocks as host firewall rules")
+		summaryPeriod  = flag.String("summary", "", "Produce traffic summaries: daily or weekly (empty disables)")
+		summaryDir     = flag.String("summary-dir", "", "Directory summaries are written to")
+		summaryWebhook = flag.String("summary-webhook", "", "URL summaries are POSTed to as JSON")
+		retentionAge      = flag.Duration("retention-age", 0, "Prune stored data older than this (0 disables)")
+		retentionBytes    = flag.Int64("retention-bytes", 0, "Prune the oldest stored data while a store exceeds this many bytes (0 disables)")
+		retentionInterval = flag.Duration("retention-interval", 10*time.Minute, "How often stored data is pruned")
+		parquetDir        = flag.String("parquet-dir", "", "Export finished flows as hourly-partitioned Parquet files into this directory")
+		parquetFlush      = flag.Duration("parquet-flush", 5*time.Minute, "How often buffered flows are written to Parquet")
+		historyDB         = flag.String("history-db", "", "Store conversations (and sampled events) in this SQLite database, queried at /api/history and /api/query")
+		historySample     = flag.Int("history-sample", 0, "Also store one in this many events in -history-db (0 stores none)")
+		historyFlush      = flag.Duration("history-flush", 30*time.Second, "How often conversations and sampled events are written to -history-db")
+		timeZone          = flag.String("tz", "local", "Time zone for timestamps in events and APIs: local, utc or an IANA name (e.g. Europe/London)")
+		replayFile        = flag.String("r", "", "Replay packets from a pcap file instead of capturing live")
+		replaySpeed       = flag.String("speed", "1x", "Replay speed relative to the original timing: e.g. 1x, 10x or max")
+		replayStart       = flag.String("start", "", "Replay only packets captured at or after this time (RFC 3339, or 2006-01-02T15:04:05 in the -tz zone)")
+		replayEnd         = flag.String("end", "", "Stop the replay at packets captured after this time (same formats as -start)")
+		localIPFlag       = flag.String("local-ip", "", "Addresses treated as this host, comma-separated, IPv4 or IPv6 (defaults to the interface's addresses; set it when replaying)")
+		arpWatch          = flag.Bool("arp-watch", true, "Alert on duplicate IPs and gateway MAC changes seen in ARP traffic")
+		trackDevices      = flag.Bool("devices", true, "Learn LAN devices from ARP and IPv6 neighbor discovery traffic and announce MAC addresses not seen before")
+		devicesFile       = flag.String("devices-file", "", "Persist known LAN devices to this JSON file so they aren't announced again after a restart")
+		trackLeases       = flag.Bool("dhcp-leases", true, "Keep a table of DHCP clients and name local addresses by the hostnames they give, served at /api/leases")
+		trackServices     = flag.Bool("service-discovery", true, "Keep an inventory of the devices and services announced over mDNS and SSDP and name local addresses by them, served at /api/services")
+		trackEndpoints    = flag.Bool("endpoints", true, "Keep an inventory of every remote endpoint contacted, served at /api/endpoints")
+		endpointsFile     = flag.String("endpoints-file", "", "Persist the endpoint inventory to this JSON file (saved every minute and on shutdown)")
+		geoipDB           = flag.String("geoip-db", "", "MaxMind format GeoIP City or Country database (e.g. GeoLite2-City.mmdb) to locate remote addresses by")
+		geoipASNDB        = flag.String("geoip-asn-db", "", "MaxMind format ASN database (e.g. GeoLite2-ASN.mmdb) naming the networks of remote addresses")
+		trackProcesses    = flag.Bool("processes", true, "Attribute conversations to the local programs owning their sockets (Linux only), totals served at /api/processes")
+		watchNetwork      = flag.Bool("watch-network", true, "Restart the capture after the system wakes from sleep or the network changes (Wi-Fi roaming, VPN up/down)")
+		gatewayIP         = flag.String("gateway", "", "Default gateway IP whose MAC is watched (auto-detected on Linux)")
+		ndpWatch          = flag.Bool("ndp-watch", true, "Learn the IPv6 neighbor table and alert on router advertisements from unexpected routers")
+		ipv6Routers       = flag.String("ipv6-routers", "", "Comma-separated MACs or addresses of the LAN's legitimate IPv6 routers (defaults to trusting the first router seen)")
+		webhooksFile      = flag.String("webhooks", "", "YAML file of webhooks POSTed signed JSON when their rules fire (new conversations to a CIDR, bandwidth, daemon start/stop)")
+		detectBeacons     = flag.Bool("beacons", true, "Alert on hosts connecting to a destination at a regular interval (possible command and control check-ins), listed at /api/beacons")
+		beaconMinConns    = flag.Int("beacon-min-connections", beacon.DefaultMinConnections, "Connections at a regular interval needed before a beacon is reported")
+		beaconJitter      = flag.Float64("beacon-jitter", beacon.DefaultMaxJitter, "Largest typical deviation from a beacon's interval, as a fraction of it (0.1 allows 6s either way of 60s)")
+		trackCerts        = flag.Bool("certs", true, "Keep an inventory of the server certificates seen in TLS 1.2 and older handshakes, listed at /api/certificates")
+		certExpiryWindow  = flag.Duration("cert-expiry-window", certs.DefaultWindow, "Alert on certificates seen expiring within this long, or expired")
+		alertsFile        = flag.String("alerts-file", "", "Persist alerts and their acknowledged/resolved state to this JSON file")
+		internetOnly      = flag.Bool("internet-only", false, "Ignore traffic between local addresses (loopback, LAN, link-local, multicast), keeping only flows to or from the internet")
+		sampleRate        = flag.String("sample", "", "Process only this fraction of packets on links too busy for all of them, e.g. 1/100; every packet still counts towards the totals and events and conversations carry the rate to scale by")
+		sampleMode        = flag.String("sample-mode", capture.SampleCount, "How -sample picks packets: count (every Nth) or random (each with a chance of 1 in N)")
+		payloadBytes      = flag.Int("payload-bytes", 0, "Keep the first N bytes of each packet's payload in its event, shown as a hex dump in the TUI (0 keeps none, at most 4096)")
+		dedupWindow       = flag.Duration("dedup-window", 0, "Discard packets identical to one seen within this window, e.g. 10ms for SPAN ports that mirror both directions (0 disables)")
+		watchHosts        = flag.String("watch", "", "Comma-separated IPs to watch: report every new destination, port and service they use")
+		pcapHistory       = flag.Int("pcap-history", 200, "Raw packets kept per conversation for pcap export (0 disables)")
+		replayEvents      = flag.Int("replay-events", websocket.DefaultReplayEvents, "Recent network events kept and sent to clients when they connect (0 disables)")
+		wsPing            = flag.Duration("ws-ping", websocket.KeepaliveInterval, "How often WebSocket clients are pinged")
+		wsTimeout         = flag.Duration("ws-timeout", websocket.KeepaliveTimeout, "Drop a WebSocket client that sends nothing, not even a pong, for this long")
+		recentPackets     = flag.Int("packet-history", conversation.DefaultPacketHistory, "Decoded packets kept per conversation, served at /api/conversations/{id}/packets (0 disables)")
+		disableDecoders   = flag.String("disable-decoders", "", "Comma-separated application protocol decoders to turn off, e.g. tls,http")
+		pcapOutPath       = flag.String("w", "", "Also write every captured packet to this pcap file")
+		pcapRotateSize    = flag.Int64("w-rotate-size", 0, "Start a new -w file before it exceeds this many bytes (0 disables)")
+		pcapRotateEvery   = flag.Duration("w-rotate-interval", 0, "Start a new -w file after this long, e.g. 1h (0 disables)")
+		jsonlPath         = flag.String("jsonl", "", "Also append every event and ended conversation to this JSON Lines file")
+		jsonlRotateSize   = flag.Int64("jsonl-rotate-size", 0, "Start a new -jsonl file before it exceeds this many bytes (0 disables)")
+		jsonlRotateEvery  = flag.Duration("jsonl-rotate-interval", 0, "Start a new -jsonl file after this long, e.g. 1h (0 disables)")
+		jsonlCompress     = flag.Bool("jsonl-compress", true, "Gzip -jsonl files once they're rotated out")
+		kafkaBrokers      = flag.String("kafka-brokers", "", "Comma-separated Kafka brokers (host:port) to publish events and conversations to")
+		kafkaEvents       = flag.String("kafka-events-topic", "netty.events", "Kafka topic for events (empty publishes none)")
+		kafkaConvs        = flag.String("kafka-conversations-topic", "netty.conversations", "Kafka topic for conversation updates and closes (empty publishes none)")
+		esURL             = flag.String("es-url", "", "Elasticsearch or OpenSearch URL to index events and conversations in, e.g. https://localhost:9200")
+		esIndex           = flag.String("es-index", "netty", "Prefix of the -es-url indices (<prefix>-events-YYYY.MM.DD and <prefix>-conversations-YYYY.MM.DD)")
+		esUser            = flag.String("es-user", "", "Username for -es-url basic auth")
+		esPassword        = flag.String("es-password", "", "Password for -es-url basic auth")
+		esAPIKey          = flag.String("es-api-key", "", "Base64 API key for -es-url, used instead of basic auth")
+		esFlush           = flag.Duration("es-flush", elastic.DefaultFlushInterval, "Longest a document waits before a bulk request to -es-url")
+		sinkUpdates       = flag.Duration("sink-updates", 0, "Also send summaries of tracked conversations to -jsonl, Kafka and Elasticsearch this often, e.g. 30s (0 sends them only once they end)")
+		tcpTimeout        = flag.Duration("tcp-timeout", conversation.DefaultTCPTimeout, "Close TCP conversations idle for this long")
+		udpTimeout        = flag.Duration("udp-timeout", conversation.DefaultUDPTimeout, "Close UDP flows idle for this long")
+		convRetention     = flag.Duration("conversation-retention", conversation.DefaultRetention, "Forget closed conversations this long after their last packet")
+		cleanupInterval   = flag.Duration("cleanup-interval", conversation.DefaultCleanupInterval, "How often idle conversations are closed and old ones forgotten")
+		reverseDNS        = flag.Bool("reverse-dns", true, "Look up names of addresses not seen answered in DNS traffic (off sends no DNS queries)")
+		reverseDNSTTL     = flag.Duration("reverse-dns-ttl", 5*time.Minute, "How long reverse DNS answers are cached")
+		configFile        = flag.String("config", "", "YAML config file of flag values, e.g. /etc/netty/config.yaml (flags given override it)")
+		showVersion       = flag.Bool("version", false, "Print the daemon version and API protocol, then exit")
 	)
 	flag.Parse()
 
+	if *configFile != "" {
+		values, err := config.Load(*configFile)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if err := config.Apply(flag.CommandLine, values); err != nil {
+			log.Fatalf("Invalid config %s: %v", *configFile, err)
+		}
+	}
+
+	if *showVersion {
+		fmt.Printf("netty-daemon %s (protocol %d)\n", version.Version, version.Protocol)
+		return
+	}
+
 	// Handle interface listing
 	if *listIfaces {
 		listInterfaces()
 		return
 	}
 
-	if *iface == "" {
-		log.Println("ERROR: Network interface is required. Use -i flag to specify interface.")
+	if *iface == "" && *replayFile == "" {
+		log.Println("ERROR: Network interface is required. Use -i flag to specify interface, or -r to replay a capture file.")
 		log.Println("\nAvailable interfaces:")
 		listInterfaces()
 		os.Exit(1)
 	}
 
+	// Only the loopback interface unless told otherwise, and only the socket
+	// when that is all that was asked for
+	setFlags := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	listen := *listenAddr
+	if listen == "" && (*listenUnix == "" || setFlags["port"]) {
+		listen = net.JoinHostPort("127.0.0.1", *wsPort)
+	}
+	if listen != "" {
+		if _, _, err := net.SplitHostPort(listen); err != nil {
+			log.Fatalf("-listen must be host:port: %v", err)
+		}
+	}
+
+	location, err := clock.ParseLocation(*timeZone)
+	if err != nil {
+		log.Fatalf("Invalid -tz: %v", err)
+	}
+	clock.SetLocation(location)
+	liveOptions := capture.LiveOptions{Snaplen: *snaplen, Promiscuous: *promisc, BufferSize: *bufferSize, Immediate: *immediate}
+	if *replayFile == "" {
+		if err := capture.ValidateBackend(*backend); err != nil {
+			log.Fatalf("Invalid -backend: %v", err)
+		}
+		if err := liveOptions.Validate(); err != nil {
+			log.Fatalf("Invalid capture options: %v", err)
+		}
+	}
+
 	// Always show startup information
-	log.Println("Starting Netty daemon...")
-	log.Printf("Interface: %s", *iface)
-	log.Printf("WebSocket port: %s", *wsPort)
+	log.Printf("Starting Netty daemon %s (protocol %d)...", version.Version, version.Protocol)
+	if *replayFile != "" {
+		log.Printf("Replaying: %s (speed: %s)", *replayFile, *replaySpeed)
+	} else {
+		log.Printf("Interface: %s", *iface)
+	}
+	if listen != "" {
+		log.Printf("Listening on: %s", listen)
+	}
+	if *listenUnix != "" {
+		log.Printf("Listening on: unix:%s", *listenUnix)
+	}
+	log.Printf("Time zone: %s", location)
 	if *filter != "" {
 		log.Printf("Filter: %s", *filter)
 	}
 	log.Println("")
 
 	// List available interfaces for debugging
-	if *verbose {
+	if *verbose && *replayFile == "" {
 		log.Println("Available interfaces:")
 		listInterfaces()
 		log.Println("")
 	}
 
-	// Get local IP address for the specified interface
-	localIP, err := getLocalIP(*iface)
-	if err != nil {
-		log.Fatalf("Failed to get local IP for interface %s: %v", *iface, err)
+	// Create packet capture instance
+	var capturer *capture.PacketCapture
+	var localIP string
+	var localAddrs []string
+	for _, ip := range strings.Split(*localIPFlag, ",") {
+		if ip = strings.TrimSpace(ip); ip != "" {
+			localAddrs = append(localAddrs, ip)
+		}
+	}
+	if *replayFile != "" {
+		replayOptions, err := parseReplayOptions(*replaySpeed, *replayStart, *replayEnd, location)
+		if err != nil {
+			log.Fatalf("Invalid replay options: %v", err)
+		}
+		if len(localAddrs) > 0 {
+			localIP = localAddrs[0]
+		}
+		capturer, err = capture.NewPacketCaptureFromFile(*replayFile, *filter, localIP, replayOptions)
+		if err != nil {
+			log.Fatalf("Failed to open replay: %v", err)
+		}
+	} else {
+		// Resolve the capture device, accepting friendly names such as "Ethernet" on Windows
+		device, err := capture.ResolveDevice(*iface)
+		if err != nil {
+			log.Println("ERROR:", err)
+			log.Println("\nAvailable interfaces:")
+			listInterfaces()
+			os.Exit(1)
+		}
+		if device.Name != *iface {
+			log.Printf("Capture device: %s", device.DisplayName())
+		}
+
+		// Get the local addresses, IPv4 and IPv6, of the specified interface
+		if len(localAddrs) == 0 {
+			localAddrs = device.LocalAddresses()
+		}
+		if len(localAddrs) == 0 {
+			log.Fatalf("Failed to get local IP for interface %s: no address found", device.DisplayName())
+		}
+		localIP = localAddrs[0]
+
+		capturer, err = capture.NewPacketCaptureWithOptions(device.Name, *filter, localIP, *backend, liveOptions)
+		if err != nil {
+			log.Fatalf("Failed to create packet capture: %v", err)
+		}
 	}
+	capturer.SetLocalAddresses(localAddrs...)
 	if *verbose {
-		log.Printf("Local IP: %s", localIP)
+		log.Printf("Local IP: %s", strings.Join(localAddrs, ", "))
+	}
+	defer capturer.Close()
+	
+	if *disableDecoders != "" {
+		decoders := parser.DefaultRegistry()
+		if err := decoders.Disable(strings.Split(*disableDecoders, ",")...); err != nil {
+			log.Fatalf("Invalid -disable-decoders: %v", err)
+		}
+		capturer.SetDecoders(decoders)
+		log.Printf("Protocol decoders enabled: %s", strings.Join(decoders.Enabled(), ", "))
+	}
+	
+	var packetHistory *history.History
+	if *pcapHistory > 0 {
+		packetHistory = history.NewHistory(capturer.LinkType(), *pcapHistory, history.DefaultMaxBytes)
+		capturer.SetHistory(packetHistory)
+	}
+	
+	// Keep raw evidence on disk alongside the live event stream
+	pcapOutConfig := pcapwriter.Config{
+		Path:           *pcapOutPath,
+		RotateSize:     *pcapRotateSize,
+		RotateInterval: *pcapRotateEvery,
+	}
+	var pcapOut *pcapwriter.Writer
+	if *pcapOutPath != "" {
+		pcapOut, err = pcapwriter.NewWriter(pcapOutConfig, capturer.LinkType(), capturer.SnapLen())
+		if err != nil {
+			log.Fatalf("Invalid -w: %v", err)
+		}
+		capturer.SetPcapWriter(pcapOut)
+		if pcapOutConfig.Rotating() {
+			log.Printf("Writing packets to %s, rotating files", filepath.Join(filepath.Dir(*pcapOutPath), pcapOutConfig.Pattern()))
+		} else {
+			log.Printf("Writing packets to %s", *pcapOutPath)
+		}
+	} else if pcapOutConfig.Rotating() {
+		log.Fatalf("-w-rotate-size and -w-rotate-interval require -w")
 	}
+	
+	// Live pcap downloads cost nothing until one starts
+	packetTap := tap.New(capturer.LinkType(), capturer.SnapLen())
+	capturer.SetTap(packetTap)
 
-	// Create packet capture instance
-	capturer, err := capture.NewPacketCapture(*iface, *filter, localIP)
+	if *dedupWindow > 0 {
+		capturer.SetDedupWindow(*dedupWindow)
+		log.Printf("Deduplicating packets repeated within %s", *dedupWindow)
+	}
+	if *tcpTimeout <= 0 || *udpTimeout <= 0 || *convRetention <= 0 || *cleanupInterval <= 0 {
+		log.Fatalf("-tcp-timeout, -udp-timeout, -conversation-retention and -cleanup-interval must be positive")
+	}
+	capturer.GetConversationManager().SetTimeouts(*tcpTimeout, *udpTimeout)
+	capturer.GetConversationManager().SetRetention(*convRetention, *cleanupInterval)
+	capturer.GetConversationManager().SetPacketHistory(*recentPackets)
+	capturer.SetReverseDNS(*reverseDNS, *reverseDNSTTL)
+	if *internetOnly {
+		capturer.SetInternetOnly(true)
+		log.Printf("Ignoring local traffic, only flows to or from the internet are tracked")
+	}
+	rate, err := capture.ParseSampleRate(*sampleRate)
 	if err != nil {
-		log.Fatalf("Failed to create packet capture: %v", err)
+		log.Fatalf("Invalid -sample: %v", err)
+	}
+	if err := capturer.SetSampling(rate, *sampleMode); err != nil {
+		log.Fatalf("Invalid -sample-mode: %v", err)
+	}
+	if rate > 1 {
+		log.Printf("Sampling 1 in %d packets (%s)", rate, *sampleMode)
+	}
+	if err := capturer.SetPayloadBytes(*payloadBytes); err != nil {
+		log.Fatalf("Invalid -payload-bytes: %v", err)
 	}
-	defer capturer.Close()
 
 	// Create WebSocket server
 	wsServer := websocket.NewServer(*wsPort)
+	wsServer.SetListen(listen, *listenUnix)
+	wsServer.SetTap(packetTap)
+	if *tlsCert != "" || *tlsKey != "" {
+		if *tlsCert == "" || *tlsKey == "" {
+			log.Fatalf("-tls-cert and -tls-key must be given together")
+		}
+		if err := wsServer.SetTLS(*tlsCert, *tlsKey); err != nil {
+			log.Fatalf("Invalid -tls-cert/-tls-key: %v", err)
+		}
+	}
+	wsServer.SetReadOnly(*readOnly)
+	if *readOnly {
+		log.Printf("Read-only mode: commands changing state are refused")
+	}
+	if *auditLog != "" {
+		if err := wsServer.SetAuditLog(*auditLog); err != nil {
+			log.Fatalf("Invalid -audit-log: %v", err)
+		}
+	}
+	if *allowedOrigins != "" {
+		if err := wsServer.SetAllowedOrigins(strings.Split(*allowedOrigins, ",")); err != nil {
+			log.Fatalf("Invalid -allowed-origins: %v", err)
+		}
+	}
+	if *allowedHosts != "" {
+		wsServer.SetAllowedHosts(strings.Split(*allowedHosts, ","))
+	}
+	if *apiTokenFile != "" {
+		if *apiToken != "" {
+			log.Fatalf("-api-token and -api-token-file can't be used together")
+		}
+		data, err := os.ReadFile(*apiTokenFile)
+		if err != nil {
+			log.Fatalf("Failed to read -api-token-file: %v", err)
+		}
+		if *apiToken = strings.TrimSpace(string(data)); *apiToken == "" {
+			log.Fatalf("-api-token-file %s is empty", *apiTokenFile)
+		}
+	}
+	if *apiToken != "" {
+		wsServer.SetAPIToken(*apiToken)
+		if !wsServer.UsesTLS() && listen != "" && !loopbackAddress(listen) {
+			log.Printf("[WARNING] The API token is sent in cleartext without -tls-cert, anyone on the network path can read it")
+		}
+	} else if listen != "" && !loopbackAddress(listen) {
+		log.Printf("[WARNING] Serving %s without -api-token, anyone who can reach it can watch the traffic", listen)
+	}
 	
 	// Connect conversation manager to WebSocket server
 	wsServer.SetConversationManager(capturer.GetConversationManager())
@@ -78,19 +424,631 @@ func main() {
 	// Connect capture statistics to WebSocket server
 	wsServer.SetStatsFunction(capturer.GetStats)
 	
+	// Report what was dropped before events reached the server, for clients' loss reports
+	wsServer.SetLossFunction(capturer.Loss)
+	wsServer.SetCaptureControl(capturer)
+	
+	if packetHistory != nil {
+		wsServer.SetPacketHistory(packetHistory)
+	}
+	wsServer.SetReplayEvents(*replayEvents)
+	if *wsPing <= 0 || *wsTimeout <= *wsPing {
+		log.Fatalf("-ws-ping must be positive and shorter than -ws-timeout")
+	}
+	wsServer.SetKeepalive(*wsPing, *wsTimeout)
+	if pcapOut != nil {
+		wsServer.SetPcapOutputStatsFunction(pcapOut.GetStats)
+	}
+	
+	// Resetting state at runtime is opt-in and needs the admin token
+	if *adminToken != "" {
+		wsServer.SetReset(*adminToken, map[string]websocket.ResetFunc{
+			websocket.ResetStats: capturer.ResetStats,
+			websocket.ResetConversations: func() int {
+				if packetHistory != nil {
+					packetHistory.Clear()
+				}
+				return capturer.GetConversationManager().Flush()
+			},
+			websocket.ResetResolver: capturer.ClearResolverCache,
+		})
+		log.Printf("Reset commands enabled")
+	}
+	
+	// Firewall rules can always be previewed, applying them is opt-in and
+	// only for clients with the API token
+	if *allowFirewall && *apiToken == "" {
+		log.Fatalf("-allow-firewall needs -api-token or -api-token-file, so only clients with the token can change the firewall")
+	}
+	wsServer.EnableFirewallApply(*allowFirewall)
+	
+	// Set up scheduled summary reports if a period and destination are configured
+	var summarizer *summary.Summarizer
+	if *summaryPeriod != "" {
+		period, err := summary.ParsePeriod(*summaryPeriod)
+		if err != nil {
+			log.Fatalf("Invalid -summary: %v", err)
+		}
+		summaryConfig := summary.Config{
+			Period:  period,
+			Dir:     *summaryDir,
+			Webhook: *summaryWebhook,
+		}
+		if !summaryConfig.Enabled() {
+			log.Fatalf("-summary requires -summary-dir and/or -summary-webhook")
+		}
+		summarizer = summary.NewSummarizer(summaryConfig, localIP)
+		summarizer.StartScheduleRoutine()
+		wsServer.SetSummarizer(summarizer)
+		log.Printf("%s summaries enabled", period)
+	}
+	
+	// Export flows to Parquet as they leave the conversation table
+	var flowExporter *export.ParquetExporter
+	if *parquetDir != "" {
+		flowExporter, err = export.NewParquetExporter(*parquetDir, localIP)
+		if err != nil {
+			log.Fatalf("Failed to set up Parquet export: %v", err)
+		}
+		capturer.GetConversationManager().OnRemove = flowExporter.Add
+		flowExporter.StartFlushRoutine(*parquetFlush)
+		log.Printf("Exporting flows to Parquet in %s", *parquetDir)
+	}
+	
+	// Store conversations, and sampled events, in SQLite so history survives restarts
+	var historyStore *store.Store
+	if *historyDB != "" {
+		historyStore, err = store.Open(store.Config{Path: *historyDB, EventSample: *historySample}, localIP)
+		if err != nil {
+			log.Fatalf("Failed to open the history store: %v", err)
+		}
+		convMgr := capturer.GetConversationManager()
+		exportFlow := convMgr.OnRemove
+		convMgr.OnRemove = func(conv *models.Conversation) {
+			if exportFlow != nil {
+				exportFlow(conv)
+			}
+			historyStore.AddConversation(conv)
+		}
+		historyStore.SetLocalAddresses(capturer.LocalAddresses)
+		// Conversations still tracked are stored as they go, not only once they end
+		historyStore.StartFlushRoutine(*historyFlush, convMgr.GetConversationSummaries)
+		wsServer.SetHistoryStore(historyStore)
+		wsServer.SetQueryer(historyStore)
+		if *historySample > 0 {
+			log.Printf("Storing history in %s (one in %d events sampled)", *historyDB, *historySample)
+		} else {
+			log.Printf("Storing history in %s", *historyDB)
+		}
+	}
+	
+	// Send events and conversations to outputs outside the daemon
+	outputs := sink.NewSet()
+	
+	// Append them to JSON Lines files for other tools
+	jsonlConfig := eventlog.Config{
+		Path:           *jsonlPath,
+		RotateSize:     *jsonlRotateSize,
+		RotateInterval: *jsonlRotateEvery,
+		Compress:       *jsonlCompress,
+	}
+	var eventLog *eventlog.Writer
+	if *jsonlPath != "" {
+		eventLog, err = eventlog.NewWriter(jsonlConfig)
+		if err != nil {
+			log.Fatalf("Invalid -jsonl: %v", err)
+		}
+		outputs.Add(eventLog)
+		if jsonlConfig.Rotating() {
+			log.Printf("Writing events to %s, rotating files", filepath.Join(filepath.Dir(*jsonlPath), jsonlConfig.Pattern()))
+		} else {
+			log.Printf("Writing events to %s", *jsonlPath)
+		}
+	} else if jsonlConfig.Rotating() {
+		log.Fatalf("-jsonl-rotate-size and -jsonl-rotate-interval require -jsonl")
+	}
+	
+	// Publish them to Kafka for an existing pipeline
+	if *kafkaBrokers != "" {
+		config := kafka.Config{
+			Brokers:            kafka.ParseBrokers(*kafkaBrokers),
+			EventsTopic:        *kafkaEvents,
+			ConversationsTopic: *kafkaConvs,
+		}
+		publisher, err := kafka.NewPublisher(config)
+		if err != nil {
+			log.Fatalf("Invalid -kafka-brokers: %v", err)
+		}
+		outputs.Add(publisher)
+		log.Printf("Publishing to Kafka at %s (events: %q, conversations: %q)", *kafkaBrokers, *kafkaEvents, *kafkaConvs)
+	}
+
+	// Index them in Elasticsearch or OpenSearch for Kibana and Dashboards
+	if *esURL != "" {
+		indexer, err := elastic.NewIndexer(elastic.Config{
+			URL:           *esURL,
+			IndexPrefix:   *esIndex,
+			Username:      *esUser,
+			Password:      *esPassword,
+			APIKey:        *esAPIKey,
+			FlushInterval: *esFlush,
+		})
+		if err != nil {
+			log.Fatalf("Invalid -es-url: %v", err)
+		}
+		outputs.Add(indexer)
+		log.Printf("Indexing in %s (indices: %s-events-*, %s-conversations-*)", *esURL, *esIndex, *esIndex)
+	}
+	
+	if outputs.Len() > 0 {
+		convMgr := capturer.GetConversationManager()
+		storeFlow := convMgr.OnRemove
+		convMgr.OnRemove = func(conv *models.Conversation) {
+			if storeFlow != nil {
+				storeFlow(conv)
+			}
+			outputs.WriteConversation(conv.ToSummary(capturer.LocalAddresses(), clock.Now()), true)
+		}
+		if *sinkUpdates > 0 {
+			outputs.StartUpdateRoutine(*sinkUpdates, convMgr.GetConversationSummaries)
+		}
+	}
+	
+	// Prune persisted data according to the retention policy
+	retentionConfig := retention.Config{
+		MaxAge:   *retentionAge,
+		MaxBytes: *retentionBytes,
+		Interval: *retentionInterval,
+	}
+	if retentionConfig.Enabled() {
+		pruner := retention.NewPruner(retentionConfig)
+		if *summaryDir != "" {
+			pruner.Register(retention.DirStore{Label: "summaries", Dir: *summaryDir, Pattern: "netty-summary-*.json"})
+		}
+		if *parquetDir != "" {
+			pruner.Register(retention.DirStore{Label: "flows", Dir: *parquetDir, Pattern: export.PartitionPattern})
+		}
+		if historyStore != nil {
+			pruner.Register(historyStore)
+		}
+		// A single -w file is still being written, only rotated files are pruned
+		if pcapOut != nil && pcapOutConfig.Rotating() {
+			pruner.Register(retention.DirStore{Label: "pcaps", Dir: filepath.Dir(*pcapOutPath), Pattern: pcapOutConfig.Pattern()})
+		}
+		if eventLog != nil && jsonlConfig.Rotating() {
+			pruner.Register(retention.DirStore{Label: "jsonl", Dir: filepath.Dir(*jsonlPath), Pattern: jsonlConfig.Pattern()})
+		}
+		pruner.StartPruneRoutine()
+		wsServer.SetRetentionStatsFunction(pruner.GetStats)
+		log.Printf("Retention enabled (max age: %s, max bytes: %d)", retentionConfig.MaxAge, retentionConfig.MaxBytes)
+	}
+	
+	// Alerts raised by blocking and detections, with their acknowledge/resolve state
+	alertStore, err := alerts.NewStore(*alertsFile)
+	if err != nil {
+		log.Fatalf("Failed to load alerts: %v", err)
+	}
+	alertStore.OnChange = func(alert alerts.Alert) {
+		wsServer.BroadcastMessage("alert", alert)
+		if summarizer != nil && alert.Status == alerts.StatusOpen {
+			summarizer.RecordAlert(alert.Type, alert.Message)
+		}
+	}
+	wsServer.SetAlertStore(alertStore)
+	if *alertsFile != "" {
+		alertStore.StartSaveRoutine(alerts.SaveInterval)
+	}
+	
+	// Set up rate-based blocking if thresholds and an action are configured
+	blockConfig := blocker.Config{
+		Scope:      blocker.Scope(*blockScope),
+		MaxBytes:   *blockBytes,
+		MaxPackets: *blockPackets,
+		Window:     *blockWindow,
+		Cooldown:   *blockCooldown,
+		Script:     *blockScript,
+		Firewall:   *blockFirewall,
+		Backend:    firewall.DetectBackend(),
+	}
+	var rateBlocker *blocker.Blocker
+	if blockConfig.Enabled() {
+		rateBlocker = blocker.NewBlocker(blockConfig, localIP)
+		rateBlocker.OnChange = func(action string, block blocker.Block) {
+			if action == "block" {
+				wsServer.BroadcastMessage("host_blocked", block)
+				alertStore.Raise(alerts.Alert{
+					Type:           "host_blocked",
+					Severity:       alerts.SeverityMedium,
+					Message:        fmt.Sprintf("Blocked %s: %s", block.Target.Host, block.Reason),
+					Host:           block.Target.Host,
+					ConversationID: block.ConversationID,
+				})
+			} else {
+				wsServer.BroadcastMessage("host_unblocked", block)
+			}
+		}
+		rateBlocker.StartExpiryRoutine()
+		wsServer.SetBlocker(rateBlocker)
+		log.Printf("Automatic blocking enabled (scope: %s, window: %s, cooldown: %s)", blockConfig.Scope, blockConfig.Window, blockConfig.Cooldown)
+	}
+	
+	// Watch ARP traffic for spoofing and address conflicts, and learn devices
+	// on the LAN from ARP and neighbor discovery senders, announcing ones not seen before
+	if *arpWatch || *trackDevices {
+		gateway := *gatewayIP
+		if gateway == "" && *replayFile == "" {
+			gateway = arpwatch.DefaultGateway()
+		}
+		arpWatcher := arpwatch.NewWatcher(gateway, arpwatch.DefaultWindow)
+		if *arpWatch {
+			arpWatcher.OnAlert = func(alert arpwatch.Alert) {
+				alertStore.Raise(alerts.Alert{
+					Time:     alert.Time,
+					Type:     alert.Type,
+					Severity: alerts.Severity(alert.Severity),
+					Message:  alert.Message,
+					Host:     alert.IP,
+				})
+			}
+			if gateway == "" {
+				log.Printf("ARP watch enabled (gateway unknown, set -gateway to watch its MAC)")
+			} else {
+				log.Printf("ARP watch enabled (gateway: %s)", gateway)
+			}
+			if *filter != "" {
+				log.Printf("[WARNING] ARP watch only sees ARP packets the BPF filter lets through (e.g. add \"or arp\")")
+			}
+		}
+		if *trackDevices {
+			if err := arpWatcher.TrackDevices(*devicesFile); err != nil {
+				log.Fatalf("Failed to load devices: %v", err)
+			}
+			arpWatcher.OnNewDevice = func(device arp.Device) {
+				wsServer.BroadcastMessage("new_device", device)
+				address := "no address yet"
+				if len(device.IPs) > 0 {
+					address = device.IPs[0]
+				}
+				alertStore.Raise(alerts.Alert{
+					Time:     device.FirstSeen,
+					Type:     "new_device",
+					Severity: alerts.SeverityLow,
+					Message:  fmt.Sprintf("New device %s on the LAN (%s)", device.MAC, address),
+					Host:     address,
+				})
+			}
+			log.Printf("Device tracking enabled")
+			if *filter != "" {
+				log.Printf("[WARNING] Device tracking only sees ARP and ICMPv6 packets the BPF filter lets through (e.g. add \"or arp or icmp6\")")
+			}
+		}
+		capturer.SetARPWatcher(arpWatcher)
+		wsServer.SetARPWatcher(arpWatcher)
+	}
+	
+	// Watch IPv6 neighbor discovery for the neighbor table and rogue routers
+	if *ndpWatch {
+		var trusted []string
+		if *ipv6Routers != "" {
+			trusted = strings.Split(*ipv6Routers, ",")
+		}
+		ndpMonitor := ndp.NewMonitor(trusted)
+		ndpMonitor.OnAlert = func(alert ndp.Alert) {
+			alertStore.Raise(alerts.Alert{
+				Time:     alert.Time,
+				Type:     alert.Type,
+				Severity: alerts.Severity(alert.Severity),
+				Message:  alert.Message,
+				Host:     alert.IP,
+			})
+		}
+		capturer.SetNDPMonitor(ndpMonitor)
+		wsServer.SetNDPMonitor(ndpMonitor)
+		if len(trusted) == 0 {
+			log.Printf("Neighbor discovery watch enabled (trusting the first IPv6 router seen, set -ipv6-routers to list them)")
+		} else {
+			log.Printf("Neighbor discovery watch enabled (trusted routers: %s)", *ipv6Routers)
+		}
+		if *filter != "" {
+			log.Printf("[WARNING] Neighbor discovery watch only sees ICMPv6 packets the BPF filter lets through (e.g. add \"or icmp6\")")
+		}
+	}
+	
+	// Learn DHCP clients' addresses, hostnames and vendor classes
+	if *trackLeases {
+		leaseTable := dhcp.NewTable()
+		capturer.SetLeaseTable(leaseTable)
+		wsServer.SetLeaseTable(leaseTable)
+		log.Printf("DHCP lease tracking enabled")
+		if *filter != "" {
+			log.Printf("[WARNING] DHCP lease tracking only sees DHCP packets the BPF filter lets through (e.g. add \"or port 67 or port 68\")")
+		}
+	}
+	
+	// Learn the devices and services announced over mDNS and SSDP
+	if *trackServices {
+		serviceInventory := discovery.NewInventory()
+		capturer.SetServiceInventory(serviceInventory)
+		wsServer.SetServiceInventory(serviceInventory)
+		log.Printf("Service discovery enabled")
+		if *filter != "" {
+			log.Printf("[WARNING] Service discovery only sees mDNS and SSDP packets the BPF filter lets through (e.g. add \"or port 5353 or port 1900\")")
+		}
+	}
+	
+	// Watch hosts for first-seen destinations, ports and services
+	hostWatcher := watch.NewWatcher()
+	if *watchHosts != "" {
+		for _, host := range strings.Split(*watchHosts, ",") {
+			if err := hostWatcher.Watch(strings.TrimSpace(host)); err != nil {
+				log.Fatalf("Invalid -watch: %v", err)
+			}
+		}
+		log.Printf("Watching hosts: %s", *watchHosts)
+	}
+	hostWatcher.OnActivity = func(activity watch.Activity) {
+		wsServer.BroadcastMessage("watch_activity", activity)
+		if activity.FirstSeen() {
+			alertStore.Raise(alerts.Alert{
+				Time:           activity.Time,
+				Type:           "watch_" + activity.Kind,
+				Severity:       alerts.SeverityLow,
+				Message:        activity.Message(),
+				Host:           activity.Host,
+				ConversationID: activity.ConversationID,
+			})
+		}
+	}
+	wsServer.SetHostWatcher(hostWatcher)
+	
+	// Look for hosts checking in with a destination at a regular interval
+	var beaconDetector *beacon.Detector
+	if *detectBeacons {
+		beaconDetector = beacon.NewDetector(beacon.Config{MinConnections: *beaconMinConns, MaxJitter: *beaconJitter})
+		beaconDetector.OnBeacon = func(b beacon.Beacon) {
+			alertStore.Raise(alerts.Alert{
+				Time:           b.LastSeen,
+				Type:           beacon.AlertType,
+				Severity:       alerts.SeverityMedium,
+				Message:        b.Message(),
+				Host:           b.Source,
+				ConversationID: b.ConversationID,
+			})
+		}
+		wsServer.SetBeaconDetector(beaconDetector)
+		log.Printf("Beacon detection enabled")
+	}
+	
+	// Keep the certificates servers present and warn of those about to expire
+	var certInventory *certs.Inventory
+	if *trackCerts {
+		certInventory = certs.NewInventory(*certExpiryWindow)
+		certInventory.OnExpiring = func(cert certs.Certificate) {
+			severity := alerts.SeverityMedium
+			if cert.Expired(cert.LastSeen) {
+				severity = alerts.SeverityHigh
+			}
+			alertStore.Raise(alerts.Alert{
+				Time:           cert.LastSeen,
+				Type:           certs.AlertType,
+				Severity:       severity,
+				Message:        cert.Message(cert.LastSeen),
+				Host:           cert.Name(),
+				ConversationID: cert.ConversationID,
+			})
+		}
+		wsServer.SetCertificateInventory(certInventory)
+		log.Printf("Certificate inventory enabled (expiry window: %s)", certInventory.Window())
+	}
+	
+	// Keep a ledger of every remote endpoint contacted
+	var endpointInventory *inventory.Inventory
+	if *trackEndpoints {
+		endpointInventory, err = inventory.NewInventory(*endpointsFile, localIP)
+		if err != nil {
+			log.Fatalf("Failed to load endpoint inventory: %v", err)
+		}
+		if *endpointsFile != "" {
+			endpointInventory.StartSaveRoutine(time.Minute)
+		}
+		wsServer.SetInventory(endpointInventory)
+		log.Printf("Endpoint inventory enabled")
+	}
+	
+	// Locate remote addresses and name their networks
+	var geoDB *geoip.DB
+	if *geoipDB != "" || *geoipASNDB != "" {
+		geoDB, err = geoip.Open(*geoipDB, *geoipASNDB)
+		if err != nil {
+			log.Fatalf("Invalid -geoip-db: %v", err)
+		}
+		defer geoDB.Close()
+		capturer.SetGeoLookup(geoDB.Lookup)
+		wsServer.SetGeoIP(geoDB)
+		log.Printf("GeoIP enabled (%s)", strings.Trim(*geoipDB+" "+*geoipASNDB, " "))
+	}
+	
+	// Attribute conversations to local programs, only meaningful for live capture
+	if *trackProcesses && *replayFile == "" {
+		if process.Supported() {
+			processTable := process.NewTable()
+			capturer.GetConversationManager().SetProcessLookup(processTable.Lookup)
+			wsServer.SetProcessTable(processTable)
+			log.Printf("Process attribution enabled")
+		} else {
+			log.Printf("[WARNING] Process attribution needs /proc and is unavailable on %s", runtime.GOOS)
+		}
+	}
+	
+	// Restart the capture after sleep and network changes, the local IP
+	// following the interface's unless it was set with -local-ip. The history
+	// store already asks the capture for its addresses.
+	if *watchNetwork && *replayFile == "" {
+		capturer.OnNetworkChange = func(change capture.NetworkChange) {
+			if *localIPFlag == "" && change.LocalIP != "" {
+				if summarizer != nil {
+					summarizer.SetLocalIP(change.LocalIP)
+				}
+				if flowExporter != nil {
+					flowExporter.SetLocalIP(change.LocalIP)
+				}
+				if rateBlocker != nil {
+					rateBlocker.SetLocalIP(change.LocalIP)
+				}
+				if endpointInventory != nil {
+					endpointInventory.SetLocalIP(change.LocalIP)
+				}
+			}
+			wsServer.BroadcastMessage("capture_restarted", change)
+		}
+		capturer.WatchNetwork(2*time.Second, *localIPFlag == "")
+	}
+	
+	// Warn when the kernel drops packets, otherwise only the statistics show it
+	capturer.WatchKernelDrops(30 * time.Second)
+	
+	// Push state changes so clients needn't poll to see conversations close
+	capturer.GetConversationManager().OnStateChange = func(change models.ConversationStateChange) {
+		if change.Closed() {
+			wsServer.BroadcastMessage("conversation_closed", change)
+			return
+		}
+		wsServer.BroadcastMessage("conversation_state_changed", change)
+	}
+	
+	// Notify webhooks of new conversations to watched networks, bandwidth and daemon starts and stops
+	var notifier *webhook.Notifier
+	if *webhooksFile != "" {
+		hooks, err := webhook.Load(*webhooksFile)
+		if err != nil {
+			log.Fatalf("Invalid -webhooks: %v", err)
+		}
+		notifier = webhook.NewNotifier(hooks)
+		notifier.StartBandwidthRoutine(capturer.GetConversationManager().GetThroughput)
+		log.Printf("Webhooks enabled (%d hooks)", len(hooks))
+	}
+	
+	// Report the effective configuration, capture settings plus where data goes
+	wsServer.SetConfigFunction(func() map[string]interface{} {
+		sinks := map[string]interface{}{
+			"websocket_port": *wsPort,
+			"listen":         listen,
+			"listen_unix":    *listenUnix,
+			"websocket_tls":  wsServer.UsesTLS(),
+			"api_token":      *apiToken != "",
+			"allowed_origins": *allowedOrigins,
+			"allowed_hosts":   *allowedHosts,
+			"read_only":       *readOnly,
+			"audit_log":       *auditLog,
+		}
+		if *parquetDir != "" {
+			sinks["parquet_dir"] = *parquetDir
+		}
+		if historyStore != nil {
+			sinks["history"] = historyStore.GetStats()
+		}
+		if summarizer != nil {
+			sinks["summary"] = map[string]interface{}{"period": *summaryPeriod, "dir": *summaryDir, "webhook": *summaryWebhook}
+		}
+		if *alertsFile != "" {
+			sinks["alerts_file"] = *alertsFile
+		}
+		if notifier != nil {
+			sinks["webhooks"] = notifier.GetStats()
+		}
+		if *trackDevices && *devicesFile != "" {
+			sinks["devices_file"] = *devicesFile
+		}
+		if *trackEndpoints && *endpointsFile != "" {
+			sinks["endpoints_file"] = *endpointsFile
+		}
+		if pcapOut != nil {
+			sinks["pcap_output"] = map[string]interface{}{
+				"path":            *pcapOutPath,
+				"rotate_size":     *pcapRotateSize,
+				"rotate_interval": pcapRotateEvery.String(),
+			}
+		}
+		for name, stats := range outputs.GetStats() {
+			sinks[name] = stats
+		}
+		if rateBlocker != nil {
+			sinks["block_script"] = *blockScript
+			sinks["block_firewall"] = *blockFirewall
+		}
+		return map[string]interface{}{
+			"capture":              capturer.GetConfig(),
+			"local_ip":             capturer.LocalIP(),
+			"local_addresses":      capturer.LocalAddresses().List(),
+			"pcap_history_packets": *pcapHistory,
+			"packet_history":       *recentPackets,
+			"replay_events":        *replayEvents,
+			"ws_ping":              wsPing.String(),
+			"ws_timeout":           wsTimeout.String(),
+			"time_zone":            clock.Location().String(),
+			"config_file":          *configFile,
+			"reverse_dns":          *reverseDNS,
+			"geoip":                geoStats(geoDB),
+			"sinks":                sinks,
+			"retention": map[string]interface{}{
+				"max_age":   retentionConfig.MaxAge.String(),
+				"max_bytes": retentionConfig.MaxBytes,
+				"enabled":   retentionConfig.Enabled(),
+			},
+		}
+	})
+	
 	// Start WebSocket server in background
 	go func() {
-		if err := wsServer.Start(); err != nil {
+		if err := wsServer.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatalf("WebSocket server failed: %v", err)
 		}
 	}()
 
 	// Start packet capture
 	packets := capturer.Start()
+	started := time.Now()
+	if notifier != nil {
+		notifier.Notify(webhook.EventDaemonStarted, map[string]interface{}{
+			"version":   version.Version,
+			"interface": *iface,
+			"local_ip":  capturer.LocalIP(),
+		})
+	}
 	
 	// Process packets and send to WebSocket clients
+	processed := make(chan struct{})
 	go func() {
+		defer close(processed)
 		for packet := range packets {
+			outputs.WriteEvent(packet)
+			// ARP and neighbor discovery events only go to clients, they belong to no conversation or flow
+			if packet.IsNeighborTraffic() {
+				wsServer.Broadcast(packet)
+				continue
+			}
+			if rateBlocker != nil {
+				rateBlocker.Observe(packet)
+			}
+			if summarizer != nil {
+				summarizer.Observe(packet)
+			}
+			hostWatcher.Observe(packet)
+			if beaconDetector != nil {
+				beaconDetector.Observe(packet)
+			}
+			if certInventory != nil {
+				certInventory.Observe(packet)
+			}
+			if notifier != nil {
+				notifier.Observe(packet)
+			}
+			if endpointInventory != nil {
+				endpointInventory.Observe(packet)
+			}
+			if historyStore != nil {
+				historyStore.Observe(packet)
+			}
 			wsServer.Broadcast(packet)
 			// Also broadcast conversation update if packet has conversation ID
 			if packet.ConversationID != "" {
@@ -105,45 +1063,132 @@ func main() {
 	<-sigChan
 
 	log.Println("Shutting down Netty daemon...")
-}
+	go func() {
+		<-sigChan
+		log.Fatalf("Second signal, exiting without finishing the shutdown")
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
 
-// getLocalIP returns the local IP address for the specified interface
-func getLocalIP(ifaceName string) (string, error) {
-	iface, err := net.InterfaceByName(ifaceName)
-	if err != nil {
-		return "", err
+	// Stop capturing, then let the packets already captured reach clients and sinks
+	capturer.Close()
+	select {
+	case <-processed:
+	case <-ctx.Done():
+		log.Printf("[WARNING] Gave up waiting for captured packets to be processed")
+	}
+	if err := wsServer.Shutdown(ctx); err != nil {
+		log.Printf("[WARNING] WebSocket server shutdown: %v", err)
 	}
 
-	addrs, err := iface.Addrs()
-	if err != nil {
-		return "", err
+	// Write out flows still in memory so the export and history cover the whole run
+	if flowExporter != nil || historyStore != nil || outputs.Len() > 0 {
+		capturer.GetConversationManager().Flush()
+	}
+	if flowExporter != nil {
+		if err := flowExporter.Flush(); err != nil {
+			log.Printf("[WARNING] Parquet export failed: %v", err)
+		}
+	}
+	if historyStore != nil {
+		if err := historyStore.Flush(); err != nil {
+			log.Printf("[WARNING] History store write failed: %v", err)
+		}
+		historyStore.Close()
 	}
+	if endpointInventory != nil {
+		if err := endpointInventory.Save(); err != nil {
+			log.Printf("[WARNING] %v", err)
+		}
+	}
+	if err := alertStore.Save(); err != nil {
+		log.Printf("[WARNING] %v", err)
+	}
+	if pcapOut != nil {
+		if err := pcapOut.Close(); err != nil {
+			log.Printf("[WARNING] Pcap output failed: %v", err)
+		}
+	}
+	outputs.Close()
+	if notifier != nil {
+		notifier.Notify(webhook.EventDaemonStopped, map[string]interface{}{
+			"version": version.Version,
+			"uptime":  time.Since(started).Round(time.Second).String(),
+		})
+		if err := notifier.Close(ctx); err != nil {
+			log.Printf("[WARNING] %v", err)
+		}
+	}
+}
 
-	for _, addr := range addrs {
-		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
-			return ipnet.IP.String(), nil
+// geoStats returns the GeoIP databases' stats, nil when none is loaded
+func geoStats(db *geoip.DB) map[string]interface{} {
+	if db == nil {
+		return nil
+	}
+	return db.GetStats()
+}
+
+// shutdownTimeout bounds how long a graceful shutdown waits for packets and clients
+const shutdownTimeout = 10 * time.Second
+
+// parseReplayOptions parses the -speed, -start and -end flags
+func parseReplayOptions(speed, start, end string, location *time.Location) (capture.ReplayOptions, error) {
+	var options capture.ReplayOptions
+	var err error
+	if options.Speed, err = capture.ParseSpeed(speed); err != nil {
+		return options, err
+	}
+	if start != "" {
+		if options.Start, err = parseReplayTime(start, location); err != nil {
+			return options, fmt.Errorf("invalid -start: %w", err)
+		}
+	}
+	if end != "" {
+		if options.End, err = parseReplayTime(end, location); err != nil {
+			return options, fmt.Errorf("invalid -end: %w", err)
 		}
 	}
+	if !options.Start.IsZero() && !options.End.IsZero() && options.End.Before(options.Start) {
+		return options, fmt.Errorf("-end is before -start")
+	}
+	return options, nil
+}
+
+// parseReplayTime accepts RFC 3339 times, or times without an offset in the configured zone
+func parseReplayTime(value string, location *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02T15:04:05", value, location)
+}
 
-	return "", fmt.Errorf("no IPv4 address found for interface %s", ifaceName)
+// loopbackAddress reports whether a host:port only accepts connections from this machine
+func loopbackAddress(addr string) bool {
+	host, _, _ := net.SplitHostPort(addr)
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
 
 // listInterfaces lists all available network interfaces
 func listInterfaces() {
-	// Try using pcap.FindAllDevs first for more accurate results
-	devices, err := pcap.FindAllDevs()
+	// Try pcap devices first, they are the names capture actually opens
+	devices, err := capture.ListDevices()
 	if err == nil && len(devices) > 0 {
 		for _, device := range devices {
-			fmt.Printf("  %s", device.Name)
+			fmt.Printf("  %s", device.DisplayName())
 			if device.Description != "" {
 				fmt.Printf(" - %s", device.Description)
 			}
 			
 			// Show IP addresses
 			var ips []string
-			for _, addr := range device.Addresses {
-				if addr.IP.To4() != nil {
-					ips = append(ips, addr.IP.String())
+			for _, ip := range device.Addresses {
+				if ip.To4() != nil {
+					ips = append(ips, ip.String())
 				}
 			}
 			if len(ips) > 0 {
@@ -185,7 +1230,11 @@ func listInterfaces() {
 	}
 	
 	fmt.Println("\nCommon interface names:")
+	if runtime.GOOS == "windows" {
+		fmt.Println("  Ethernet, Wi-Fi: pass the friendly name, the adapter GUID or the full \\Device\\NPF_{GUID} name")
+		return
+	}
 	fmt.Println("  en0: Wi-Fi (macOS)")
 	fmt.Println("  en1: Ethernet (macOS)")
 	fmt.Println("  lo0: Loopback")
-}
\ No newline at end of file
+}
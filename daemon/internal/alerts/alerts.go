@@ -0,0 +1,260 @@
+package alerts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iolloyd/netty/daemon/internal/atomicfile"
+	"github.com/iolloyd/netty/daemon/internal/clock"
+)
+
+// Severity ranks how urgently an alert needs attention
+type Severity string
+
+const (
+	SeverityLow    Severity = "low"
+	SeverityMedium Severity = "medium"
+	SeverityHigh   Severity = "high"
+)
+
+// Status is where an alert is in the acknowledge/resolve workflow
+type Status string
+
+const (
+	StatusOpen         Status = "open"
+	StatusAcknowledged Status = "acknowledged"
+	StatusResolved     Status = "resolved"
+)
+
+// ParseStatus validates a status name, "" matches every status
+func ParseStatus(name string) (Status, error) {
+	switch Status(name) {
+	case "", StatusOpen, StatusAcknowledged, StatusResolved:
+		return Status(name), nil
+	}
+	return "", fmt.Errorf("unknown alert status %q (use open, acknowledged or resolved)", name)
+}
+
+// MaxAlerts is how many alerts are kept, the oldest are dropped first
+const MaxAlerts = 1000
+
+// SaveInterval is how often changed alerts are written to the store's file
+const SaveInterval = 5 * time.Second
+
+// ErrNotFound is returned when acting on an alert that doesn't exist
+var ErrNotFound = errors.New("alert not found")
+
+// Alert is a rule match or detection raised by the daemon
+type Alert struct {
+	ID             string     `json:"id"`
+	Time           time.Time  `json:"time"`
+	Type           string     `json:"type"`
+	Severity       Severity   `json:"severity"`
+	Message        string     `json:"message"`
+	Host           string     `json:"host,omitempty"`
+	ConversationID string     `json:"conversation_id,omitempty"`
+	Status         Status     `json:"status"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+}
+
+// Store keeps alerts and their workflow state, optionally persisted to a JSON
+// file. Changes are saved by StartSaveRoutine and Save rather than as they
+// happen, so raising an alert never waits on the disk.
+type Store struct {
+	path   string
+	alerts []*Alert // Oldest first
+	dirty  bool     // Changed since the last save
+	mu     sync.Mutex
+	saveMu sync.Mutex // Held while writing the file, so saves land in order
+
+	// OnChange is called (outside the lock) when an alert is raised, acknowledged or resolved
+	OnChange func(alert Alert)
+}
+
+// NewStore creates a store, loading previously persisted alerts from path if set
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alerts: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.alerts); err != nil {
+		return nil, fmt.Errorf("failed to parse alerts file %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Raise adds a new open alert, filling in its ID, time and status
+func (s *Store) Raise(alert Alert) Alert {
+	alert.ID = uuid.New().String()
+	if alert.Time.IsZero() {
+		alert.Time = clock.Now()
+	}
+	alert.Status = StatusOpen
+	alert.AcknowledgedAt = nil
+	alert.ResolvedAt = nil
+
+	s.mu.Lock()
+	s.alerts = append(s.alerts, &alert)
+	if len(s.alerts) > MaxAlerts {
+		s.alerts = s.alerts[len(s.alerts)-MaxAlerts:]
+	}
+	s.dirty = true
+	s.mu.Unlock()
+
+	if s.OnChange != nil {
+		s.OnChange(alert)
+	}
+	return alert
+}
+
+// Acknowledge marks an open alert as seen
+func (s *Store) Acknowledge(id string) (Alert, error) {
+	return s.update(id, func(alert *Alert, now time.Time) {
+		if alert.Status == StatusOpen {
+			alert.Status = StatusAcknowledged
+			alert.AcknowledgedAt = &now
+		}
+	})
+}
+
+// Resolve closes an alert, acknowledging it too if it wasn't already
+func (s *Store) Resolve(id string) (Alert, error) {
+	return s.update(id, func(alert *Alert, now time.Time) {
+		if alert.AcknowledgedAt == nil {
+			alert.AcknowledgedAt = &now
+		}
+		if alert.Status != StatusResolved {
+			alert.Status = StatusResolved
+			alert.ResolvedAt = &now
+		}
+	})
+}
+
+func (s *Store) update(id string, change func(alert *Alert, now time.Time)) (Alert, error) {
+	s.mu.Lock()
+	var found *Alert
+	for _, alert := range s.alerts {
+		if alert.ID == id {
+			found = alert
+			break
+		}
+	}
+	if found == nil {
+		s.mu.Unlock()
+		return Alert{}, ErrNotFound
+	}
+	change(found, clock.Now())
+	updated := *found
+	s.dirty = true
+	s.mu.Unlock()
+
+	if s.OnChange != nil {
+		s.OnChange(updated)
+	}
+	return updated, nil
+}
+
+// List returns alerts with the given status ("" for all), newest first
+func (s *Store) List(status Status) []Alert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]Alert, 0, len(s.alerts))
+	for i := len(s.alerts) - 1; i >= 0; i-- {
+		if status == "" || s.alerts[i].Status == status {
+			list = append(list, *s.alerts[i])
+		}
+	}
+	return list
+}
+
+// GetStats returns alert counts by status and severity
+func (s *Store) GetStats() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byStatus := make(map[Status]int)
+	openBySeverity := make(map[Severity]int)
+	for _, alert := range s.alerts {
+		byStatus[alert.Status]++
+		if alert.Status != StatusResolved {
+			openBySeverity[alert.Severity]++
+		}
+	}
+	return map[string]interface{}{
+		"total":                  len(s.alerts),
+		"open":                   byStatus[StatusOpen],
+		"acknowledged":           byStatus[StatusAcknowledged],
+		"resolved":               byStatus[StatusResolved],
+		"unresolved_by_severity": openBySeverity,
+	}
+}
+
+// Save writes the alerts to the store's file if they have changed since the last save
+func (s *Store) Save() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.Lock()
+	if s.path == "" || !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	alerts := make([]Alert, len(s.alerts))
+	for i, alert := range s.alerts {
+		alerts[i] = *alert
+	}
+	s.dirty = false
+	s.mu.Unlock()
+
+	if err := s.write(alerts); err != nil {
+		// Try again on the next save
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// write replaces the store's file with the given alerts
+func (s *Store) write(alerts []Alert) error {
+	data, err := json.MarshalIndent(alerts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode alerts: %w", err)
+	}
+
+	if err := atomicfile.Write(s.path, data); err != nil {
+		return fmt.Errorf("failed to save alerts: %w", err)
+	}
+	return nil
+}
+
+// StartSaveRoutine starts a goroutine that saves changed alerts every interval
+func (s *Store) StartSaveRoutine(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := s.Save(); err != nil {
+				log.Printf("[WARNING] %v", err)
+			}
+		}
+	}()
+}
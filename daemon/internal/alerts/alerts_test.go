@@ -0,0 +1,78 @@
+package alerts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore_Workflow(t *testing.T) {
+	s, err := NewStore("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var changes []Alert
+	s.OnChange = func(alert Alert) { changes = append(changes, alert) }
+
+	first := s.Raise(Alert{Type: "host_blocked", Severity: SeverityMedium, Message: "first"})
+	second := s.Raise(Alert{Type: "duplicate_ip", Severity: SeverityHigh, Message: "second"})
+	if first.ID == "" || first.Status != StatusOpen || first.Time.IsZero() {
+		t.Errorf("Expected an open alert with an ID and time, got %+v", first)
+	}
+
+	if list := s.List(""); len(list) != 2 || list[0].ID != second.ID {
+		t.Errorf("Expected alerts newest first, got %+v", list)
+	}
+
+	acked, err := s.Acknowledge(first.ID)
+	if err != nil || acked.Status != StatusAcknowledged || acked.AcknowledgedAt == nil {
+		t.Errorf("Expected an acknowledged alert, got %+v (%v)", acked, err)
+	}
+	resolved, err := s.Resolve(second.ID)
+	if err != nil || resolved.Status != StatusResolved || resolved.ResolvedAt == nil || resolved.AcknowledgedAt == nil {
+		t.Errorf("Expected a resolved (and acknowledged) alert, got %+v (%v)", resolved, err)
+	}
+
+	// Acknowledging a resolved alert doesn't reopen it
+	if again, _ := s.Acknowledge(second.ID); again.Status != StatusResolved {
+		t.Errorf("Expected alert to stay resolved, got %s", again.Status)
+	}
+	if _, err := s.Resolve("missing"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if open := s.List(StatusOpen); len(open) != 0 {
+		t.Errorf("Expected no open alerts, got %+v", open)
+	}
+	if len(changes) != 5 {
+		t.Errorf("Expected 5 change notifications, got %d", len(changes))
+	}
+}
+
+func TestStore_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	alert := s.Raise(Alert{Type: "duplicate_ip", Severity: SeverityHigh, Message: "conflict"})
+	if _, err := s.Acknowledge(alert.ID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Nothing is written until the store is saved
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected raising an alert not to write the file, got %v", err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("Unexpected error saving: %v", err)
+	}
+
+	s, err = NewStore(path)
+	if err != nil {
+		t.Fatalf("Unexpected error reloading: %v", err)
+	}
+	list := s.List("")
+	if len(list) != 1 || list[0].ID != alert.ID || list[0].Status != StatusAcknowledged {
+		t.Errorf("Expected the acknowledged alert to survive a restart, got %+v", list)
+	}
+}
@@ -0,0 +1,180 @@
+// Package arp keeps the table of devices on the LAN: each MAC address seen
+// and the IP addresses it has claimed
+package arp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/atomicfile"
+)
+
+// MaxIPs bounds the addresses remembered per device, oldest are forgotten first
+const MaxIPs = 16
+
+// Device is a MAC address seen on the LAN and the addresses it has claimed,
+// IPv4 from ARP and IPv6 from neighbor discovery
+type Device struct {
+	MAC       string    `json:"mac"`
+	IPs       []string  `json:"ips"` // Oldest first
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Packets   uint64    `json:"packets"`
+}
+
+// Table maps the MACs seen on the LAN to their addresses
+type Table struct {
+	devices    map[string]*Device
+	path       string // Where devices are persisted, "" for nowhere
+	newDevices uint64
+	mu         sync.Mutex
+}
+
+// NewTable creates a table, loading the devices persisted in path if set and
+// saving them there as they change, so devices known before a restart aren't
+// new again
+func NewTable(path string) (*Table, error) {
+	t := &Table{devices: make(map[string]*Device), path: path}
+	if path == "" {
+		return t, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read devices: %w", err)
+	}
+	var saved []*Device
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse devices file %s: %w", path, err)
+	}
+	for _, device := range saved {
+		t.devices[device.MAC] = device
+	}
+	return t, nil
+}
+
+// Learn records that mac was seen using ip, or just that it was seen if ip
+// is "". It returns the device and whether its MAC was seen for the first
+// time; nothing is learned from a missing, zero or broadcast MAC.
+func (t *Table) Learn(mac, ip string, at time.Time) (Device, bool) {
+	if !ValidMAC(mac) {
+		return Device{}, false
+	}
+
+	t.mu.Lock()
+	device, known := t.devices[mac]
+	if !known {
+		device = &Device{MAC: mac, FirstSeen: at}
+		t.devices[mac] = device
+		t.newDevices++
+	}
+	device.LastSeen = at
+	device.Packets++
+	changed := !known
+	if ip != "" && !contains(device.IPs, ip) {
+		device.IPs = append(device.IPs, ip)
+		if len(device.IPs) > MaxIPs {
+			device.IPs = device.IPs[len(device.IPs)-MaxIPs:]
+		}
+		changed = true
+	}
+	var err error
+	if changed {
+		err = t.save()
+	}
+	snapshot := device.copy()
+	t.mu.Unlock()
+
+	if err != nil {
+		log.Printf("[WARNING] %v", err)
+	}
+	if !known {
+		log.Printf("[INFO] New device %s (%s)", mac, orUnknown(ip))
+	}
+	return snapshot, !known
+}
+
+// Devices returns every device seen, most recently seen first
+func (t *Table) Devices() []Device {
+	t.mu.Lock()
+	devices := make([]Device, 0, len(t.devices))
+	for _, device := range t.devices {
+		devices = append(devices, device.copy())
+	}
+	t.mu.Unlock()
+
+	sort.Slice(devices, func(i, j int) bool {
+		if !devices[i].LastSeen.Equal(devices[j].LastSeen) {
+			return devices[i].LastSeen.After(devices[j].LastSeen)
+		}
+		return devices[i].MAC < devices[j].MAC
+	})
+	return devices
+}
+
+// GetStats returns table metrics
+func (t *Table) GetStats() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return map[string]interface{}{
+		"devices":     len(t.devices),
+		"new_devices": t.newDevices,
+	}
+}
+
+// save writes the devices to the table's file, must be called with the lock held
+func (t *Table) save() error {
+	if t.path == "" {
+		return nil
+	}
+	devices := make([]*Device, 0, len(t.devices))
+	for _, device := range t.devices {
+		devices = append(devices, device)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].MAC < devices[j].MAC })
+	data, err := json.MarshalIndent(devices, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode devices: %w", err)
+	}
+
+	if err := atomicfile.Write(t.path, data); err != nil {
+		return fmt.Errorf("failed to save devices: %w", err)
+	}
+	return nil
+}
+
+func (d *Device) copy() Device {
+	device := *d
+	device.IPs = append([]string(nil), d.IPs...)
+	return device
+}
+
+// ValidMAC reports whether mac can belong to a device, rather than being
+// missing, zero in a request or broadcast
+func ValidMAC(mac string) bool {
+	return mac != "" && mac != "00:00:00:00:00:00" && mac != "ff:ff:ff:ff:ff:ff"
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func orUnknown(ip string) string {
+	if ip == "" {
+		return "no address yet"
+	}
+	return ip
+}
@@ -0,0 +1,75 @@
+package arp
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTable_Learn(t *testing.T) {
+	table, err := NewTable("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	start := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	var announced []Device
+	learn := func(mac, ip string, at time.Time) {
+		if device, isNew := table.Learn(mac, ip, at); isNew {
+			announced = append(announced, device)
+		}
+	}
+	learn("aa:aa:aa:aa:aa:aa", "", start)
+	learn("aa:aa:aa:aa:aa:aa", "192.168.1.20", start.Add(time.Second))
+	learn("aa:aa:aa:aa:aa:aa", "192.168.1.20", start.Add(2*time.Second))
+	learn("bb:bb:bb:bb:bb:bb", "192.168.1.30", start.Add(3*time.Second))
+	learn("00:00:00:00:00:00", "192.168.1.40", start.Add(4*time.Second))
+	learn("ff:ff:ff:ff:ff:ff", "192.168.1.255", start.Add(4*time.Second))
+	learn("bb:bb:bb:bb:bb:bb", "fe80::1", start.Add(5*time.Second))
+
+	if len(announced) != 2 || announced[0].MAC != "aa:aa:aa:aa:aa:aa" || len(announced[0].IPs) != 0 {
+		t.Fatalf("Expected each real MAC new once, on first sight, got %+v", announced)
+	}
+	devices := table.Devices()
+	if len(devices) != 2 || devices[0].MAC != "bb:bb:bb:bb:bb:bb" || len(devices[0].IPs) != 2 || devices[0].IPs[1] != "fe80::1" {
+		t.Fatalf("Expected the most recently seen device first with both addresses, got %+v", devices)
+	}
+	if a := devices[1]; len(a.IPs) != 1 || a.IPs[0] != "192.168.1.20" || a.Packets != 3 || !a.FirstSeen.Equal(start) {
+		t.Errorf("Unexpected device: %+v", a)
+	}
+	if stats := table.GetStats(); stats["devices"] != 2 || stats["new_devices"] != uint64(2) {
+		t.Errorf("Unexpected stats: %v", stats)
+	}
+}
+
+func TestTable_MaxIPs(t *testing.T) {
+	table, _ := NewTable("")
+	for i := 0; i <= MaxIPs; i++ {
+		table.Learn("aa:aa:aa:aa:aa:aa", fmt.Sprintf("192.168.1.%d", i+1), time.Now())
+	}
+	ips := table.Devices()[0].IPs
+	if len(ips) != MaxIPs || ips[0] != "192.168.1.2" {
+		t.Errorf("Expected the oldest address forgotten, got %v", ips)
+	}
+}
+
+func TestTable_Persist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.json")
+	table, err := NewTable(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	table.Learn("aa:aa:aa:aa:aa:aa", "192.168.1.20", time.Now())
+
+	reloaded, err := NewTable(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, isNew := reloaded.Learn("aa:aa:aa:aa:aa:aa", "192.168.1.20", time.Now()); isNew {
+		t.Error("Expected a known device not to be new again")
+	}
+	if devices := reloaded.Devices(); len(devices) != 1 || len(devices[0].IPs) != 1 || devices[0].IPs[0] != "192.168.1.20" {
+		t.Errorf("Expected the device's addresses to be restored, got %+v", devices)
+	}
+}
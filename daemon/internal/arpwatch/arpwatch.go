@@ -0,0 +1,263 @@
+package arpwatch
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/arp"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Alert types
+const (
+	AlertDuplicateIP       = "duplicate_ip"        // Several MACs claim the same IP at once
+	AlertGatewayMACChanged = "gateway_mac_changed" // The gateway's IP moved to another MAC
+)
+
+// SeverityHigh is the severity of every ARP alert: both are how LAN MITM attacks look
+const SeverityHigh = "high"
+
+// DefaultWindow is how long a MAC's claim on an IP counts as current
+const DefaultWindow = 5 * time.Minute
+
+// Alert describes a suspicious change in IP to MAC bindings
+type Alert struct {
+	Time        time.Time `json:"time"`
+	Type        string    `json:"type"`
+	Severity    string    `json:"severity"`
+	IP          string    `json:"ip"`
+	MAC         string    `json:"mac"`
+	PreviousMAC string    `json:"previous_mac"`
+	Message     string    `json:"message"`
+}
+
+// Binding is the MAC an IP currently resolves to
+type Binding struct {
+	IP        string    `json:"ip"`
+	MAC       string    `json:"mac"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Watcher learns IP to MAC bindings from ARP traffic and raises alerts on
+// conflicts. With TrackDevices it also feeds the same traffic to an arp.Table
+// of the devices on the LAN.
+type Watcher struct {
+	gateway  string
+	window   time.Duration
+	bindings map[string]*Binding
+	claims   map[string]map[string]time.Time // IP -> MAC -> last claim
+	alerted  map[string]time.Time            // IP and alert type -> last alert, to avoid repeats
+	alerts   uint64
+	devices  *arp.Table // nil unless tracking devices
+	mu       sync.Mutex
+
+	// OnAlert is called (outside the lock) for every alert raised
+	OnAlert func(alert Alert)
+	// OnNewDevice is called (outside the lock) the first time a MAC is seen
+	OnNewDevice func(device arp.Device)
+}
+
+// Decode returns the operation and addresses of an IPv4 over Ethernet ARP
+// packet, or false for any other kind
+func Decode(packet *layers.ARP) (models.ARPInfo, bool) {
+	if packet.AddrType != layers.LinkTypeEthernet || packet.Protocol != layers.EthernetTypeIPv4 ||
+		len(packet.SourceHwAddress) != 6 || len(packet.DstHwAddress) != 6 ||
+		len(packet.SourceProtAddress) != 4 || len(packet.DstProtAddress) != 4 {
+		return models.ARPInfo{}, false
+	}
+	info := models.ARPInfo{
+		SenderMAC: net.HardwareAddr(packet.SourceHwAddress).String(),
+		SenderIP:  net.IP(packet.SourceProtAddress).String(),
+		TargetMAC: net.HardwareAddr(packet.DstHwAddress).String(),
+		TargetIP:  net.IP(packet.DstProtAddress).String(),
+	}
+	switch packet.Operation {
+	case layers.ARPRequest:
+		info.Operation = models.ARPRequest
+	case layers.ARPReply:
+		info.Operation = models.ARPReply
+	default:
+		return models.ARPInfo{}, false
+	}
+	return info, true
+}
+
+// NewWatcher creates a watcher. gateway is the default gateway's IP, or "" if unknown.
+func NewWatcher(gateway string, window time.Duration) *Watcher {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &Watcher{
+		gateway:  gateway,
+		window:   window,
+		bindings: make(map[string]*Binding),
+		claims:   make(map[string]map[string]time.Time),
+		alerted:  make(map[string]time.Time),
+	}
+}
+
+// Observe records that mac claimed ip at the given time, as the sender of an
+// ARP request or reply. Probes, which are sent from 0.0.0.0 by a device
+// checking its new address is free, claim nothing but still reveal the MAC.
+func (w *Watcher) Observe(ip, mac string, at time.Time) {
+	if ip == "0.0.0.0" {
+		ip = ""
+	}
+	w.Learn(mac, ip, at)
+	if ip == "" || !arp.ValidMAC(mac) {
+		return
+	}
+
+	w.mu.Lock()
+	claims, exists := w.claims[ip]
+	if !exists {
+		claims = make(map[string]time.Time)
+		w.claims[ip] = claims
+	}
+	claims[mac] = at
+	for other, last := range claims {
+		if at.Sub(last) > w.window {
+			delete(claims, other)
+		}
+	}
+
+	binding, exists := w.bindings[ip]
+	if !exists {
+		w.bindings[ip] = &Binding{IP: ip, MAC: mac, FirstSeen: at, LastSeen: at}
+		w.mu.Unlock()
+		return
+	}
+	if binding.MAC == mac {
+		binding.LastSeen = at
+		w.mu.Unlock()
+		return
+	}
+
+	previous := binding.MAC
+	w.bindings[ip] = &Binding{IP: ip, MAC: mac, FirstSeen: at, LastSeen: at}
+
+	var alert *Alert
+	switch {
+	case ip == w.gateway:
+		alert = &Alert{Type: AlertGatewayMACChanged, Message: fmt.Sprintf("Gateway %s moved from %s to %s: possible ARP spoofing", ip, previous, mac)}
+	case len(claims) > 1:
+		alert = &Alert{Type: AlertDuplicateIP, Message: fmt.Sprintf("%s is claimed by %s", ip, strings.Join(sortedMACs(claims), " and "))}
+	default:
+		log.Printf("[INFO] %s moved from %s to %s", ip, previous, mac)
+	}
+	if alert != nil {
+		key := ip + "|" + alert.Type
+		if last, seen := w.alerted[key]; seen && at.Sub(last) < w.window {
+			alert = nil
+		} else {
+			w.alerted[key] = at
+			w.alerts++
+		}
+	}
+	w.mu.Unlock()
+
+	if alert == nil {
+		return
+	}
+	alert.Time = at
+	alert.Severity = SeverityHigh
+	alert.IP = ip
+	alert.MAC = mac
+	alert.PreviousMAC = previous
+	log.Printf("[WARNING] %s", alert.Message)
+	if w.OnAlert != nil {
+		w.OnAlert(*alert)
+	}
+}
+
+// Gateway returns the gateway IP whose MAC is watched
+func (w *Watcher) Gateway() string {
+	return w.gateway
+}
+
+// GetBindings returns the current IP to MAC bindings ordered by IP
+func (w *Watcher) GetBindings() []Binding {
+	w.mu.Lock()
+	bindings := make([]Binding, 0, len(w.bindings))
+	for _, binding := range w.bindings {
+		bindings = append(bindings, *binding)
+	}
+	w.mu.Unlock()
+
+	sort.Slice(bindings, func(i, j int) bool {
+		a, b := net.ParseIP(bindings[i].IP).To4(), net.ParseIP(bindings[j].IP).To4()
+		if a != nil && b != nil {
+			return binary.BigEndian.Uint32(a) < binary.BigEndian.Uint32(b)
+		}
+		return bindings[i].IP < bindings[j].IP
+	})
+	return bindings
+}
+
+// GetStats returns watcher metrics
+func (w *Watcher) GetStats() map[string]interface{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stats := map[string]interface{}{
+		"gateway":      w.gateway,
+		"bindings":     len(w.bindings),
+		"alerts_total": w.alerts,
+	}
+	if w.devices != nil {
+		for key, value := range w.devices.GetStats() {
+			stats[key] = value
+		}
+	}
+	return stats
+}
+
+func sortedMACs(claims map[string]time.Time) []string {
+	macs := make([]string, 0, len(claims))
+	for mac := range claims {
+		macs = append(macs, mac)
+	}
+	sort.Strings(macs)
+	return macs
+}
+
+// DefaultGateway returns the IPv4 default gateway from /proc/net/route, or ""
+// where that isn't available (macOS, Windows); pass it explicitly there
+func DefaultGateway() string {
+	file, err := os.Open("/proc/net/route")
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	return parseRouteTable(bufio.NewScanner(file))
+}
+
+// parseRouteTable finds the default route in /proc/net/route, whose addresses are little-endian hex
+func parseRouteTable(scanner *bufio.Scanner) string {
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+		raw, err := hex.DecodeString(fields[2])
+		if err != nil || len(raw) != 4 {
+			continue
+		}
+		gateway := net.IPv4(raw[3], raw[2], raw[1], raw[0])
+		if gateway.IsUnspecified() {
+			continue
+		}
+		return gateway.String()
+	}
+	return ""
+}
@@ -0,0 +1,103 @@
+package arpwatch
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func TestDecode(t *testing.T) {
+	packet := &layers.ARP{
+		AddrType:          layers.LinkTypeEthernet,
+		Protocol:          layers.EthernetTypeIPv4,
+		Operation:         layers.ARPReply,
+		SourceHwAddress:   []byte{0xaa, 0xbb, 0xcc, 0x00, 0x00, 0x01},
+		SourceProtAddress: net.IPv4(192, 168, 1, 1).To4(),
+		DstHwAddress:      []byte{0xaa, 0xbb, 0xcc, 0x00, 0x00, 0x02},
+		DstProtAddress:    net.IPv4(192, 168, 1, 20).To4(),
+	}
+	info, ok := Decode(packet)
+	if !ok {
+		t.Fatal("Expected an IPv4 ARP reply to decode")
+	}
+	want := models.ARPInfo{Operation: models.ARPReply, SenderMAC: "aa:bb:cc:00:00:01", SenderIP: "192.168.1.1", TargetMAC: "aa:bb:cc:00:00:02", TargetIP: "192.168.1.20"}
+	if info != want {
+		t.Errorf("Expected %+v, got %+v", want, info)
+	}
+
+	packet.Protocol = layers.EthernetTypeIPv6
+	if _, ok := Decode(packet); ok {
+		t.Error("Expected non-IPv4 ARP to be ignored")
+	}
+}
+
+func TestWatcher_DuplicateIP(t *testing.T) {
+	w := NewWatcher("192.168.1.1", time.Minute)
+	var alerts []Alert
+	w.OnAlert = func(alert Alert) { alerts = append(alerts, alert) }
+
+	start := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	w.Observe("192.168.1.20", "aa:aa:aa:aa:aa:aa", start)
+	w.Observe("192.168.1.20", "aa:aa:aa:aa:aa:aa", start.Add(10*time.Second))
+	if len(alerts) != 0 {
+		t.Fatalf("Expected no alerts for a stable binding, got %+v", alerts)
+	}
+
+	// A second MAC claims the address while the first is still active
+	w.Observe("192.168.1.20", "bb:bb:bb:bb:bb:bb", start.Add(20*time.Second))
+	if len(alerts) != 1 || alerts[0].Type != AlertDuplicateIP || alerts[0].Severity != SeverityHigh {
+		t.Fatalf("Expected a high-severity duplicate IP alert, got %+v", alerts)
+	}
+	if alerts[0].PreviousMAC != "aa:aa:aa:aa:aa:aa" || alerts[0].MAC != "bb:bb:bb:bb:bb:bb" {
+		t.Errorf("Unexpected MACs in alert: %+v", alerts[0])
+	}
+
+	// Flapping back and forth is reported once per window
+	w.Observe("192.168.1.20", "aa:aa:aa:aa:aa:aa", start.Add(30*time.Second))
+	if len(alerts) != 1 {
+		t.Errorf("Expected repeated conflicts to be suppressed, got %d alerts", len(alerts))
+	}
+}
+
+func TestWatcher_ReassignmentIsNotAnAlert(t *testing.T) {
+	w := NewWatcher("", time.Minute)
+	w.OnAlert = func(alert Alert) { t.Errorf("Unexpected alert: %+v", alert) }
+
+	start := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	w.Observe("192.168.1.20", "aa:aa:aa:aa:aa:aa", start)
+	w.Observe("192.168.1.20", "bb:bb:bb:bb:bb:bb", start.Add(time.Hour))
+	w.Observe("0.0.0.0", "cc:cc:cc:cc:cc:cc", start.Add(time.Hour))
+
+	bindings := w.GetBindings()
+	if len(bindings) != 1 || bindings[0].MAC != "bb:bb:bb:bb:bb:bb" {
+		t.Errorf("Expected the binding to follow the new MAC, got %+v", bindings)
+	}
+}
+
+func TestWatcher_GatewayMACChanged(t *testing.T) {
+	w := NewWatcher("192.168.1.1", time.Minute)
+	var alerts []Alert
+	w.OnAlert = func(alert Alert) { alerts = append(alerts, alert) }
+
+	start := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	w.Observe("192.168.1.1", "aa:aa:aa:aa:aa:aa", start)
+	// Even long after the old MAC was last seen, a gateway change is suspicious
+	w.Observe("192.168.1.1", "bb:bb:bb:bb:bb:bb", start.Add(time.Hour))
+	if len(alerts) != 1 || alerts[0].Type != AlertGatewayMACChanged {
+		t.Fatalf("Expected a gateway MAC change alert, got %+v", alerts)
+	}
+}
+
+func TestParseRouteTable(t *testing.T) {
+	table := "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\n" +
+		"eth0\t0001A8C0\t00000000\t0001\t0\t0\t0\t00FFFFFF\n" +
+		"eth0\t00000000\t0101A8C0\t0003\t0\t0\t0\t00000000\n"
+	if gateway := parseRouteTable(bufio.NewScanner(strings.NewReader(table))); gateway != "192.168.1.1" {
+		t.Errorf("Expected gateway 192.168.1.1, got %q", gateway)
+	}
+}
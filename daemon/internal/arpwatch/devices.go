@@ -0,0 +1,55 @@
+package arpwatch
+
+import (
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/arp"
+)
+
+// TrackDevices makes the watcher also keep the devices on the LAN in an
+// arp.Table, persisted in path if set, see arp.NewTable
+func (w *Watcher) TrackDevices(path string) error {
+	devices, err := arp.NewTable(path)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.devices = devices
+	return nil
+}
+
+// TracksDevices reports whether the watcher keeps devices, see TrackDevices
+func (w *Watcher) TracksDevices() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.devices != nil
+}
+
+// Learn records that mac was seen using ip, or just that it was seen if ip
+// is "". ARP senders are learned as they're observed; neighbor discovery
+// teaches the IPv6 addresses. Nothing is kept unless tracking devices.
+func (w *Watcher) Learn(mac, ip string, at time.Time) {
+	w.mu.Lock()
+	devices := w.devices
+	w.mu.Unlock()
+	if devices == nil {
+		return
+	}
+
+	if device, isNew := devices.Learn(mac, ip, at); isNew && w.OnNewDevice != nil {
+		w.OnNewDevice(device)
+	}
+}
+
+// Devices returns every device seen, most recently seen first
+func (w *Watcher) Devices() []arp.Device {
+	w.mu.Lock()
+	devices := w.devices
+	w.mu.Unlock()
+	if devices == nil {
+		return []arp.Device{}
+	}
+	return devices.Devices()
+}
@@ -0,0 +1,78 @@
+package arpwatch
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/arp"
+)
+
+func TestWatcher_NewDevices(t *testing.T) {
+	w := NewWatcher("", time.Minute)
+	if err := w.TrackDevices(""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var announced []arp.Device
+	w.OnNewDevice = func(device arp.Device) { announced = append(announced, device) }
+
+	start := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	// A joining device probes from 0.0.0.0 before it claims its address
+	w.Observe("0.0.0.0", "aa:aa:aa:aa:aa:aa", start)
+	w.Observe("192.168.1.20", "aa:aa:aa:aa:aa:aa", start.Add(time.Second))
+	w.Observe("192.168.1.20", "aa:aa:aa:aa:aa:aa", start.Add(2*time.Second))
+	w.Observe("192.168.1.30", "bb:bb:bb:bb:bb:bb", start.Add(3*time.Second))
+	w.Observe("192.168.1.40", "00:00:00:00:00:00", start.Add(4*time.Second))
+	// Neighbor discovery teaches a known device its IPv6 address
+	w.Learn("bb:bb:bb:bb:bb:bb", "fe80::1", start.Add(5*time.Second))
+
+	if len(announced) != 2 || announced[0].MAC != "aa:aa:aa:aa:aa:aa" || len(announced[0].IPs) != 0 {
+		t.Fatalf("Expected each real MAC announced once, on first sight, got %+v", announced)
+	}
+	devices := w.Devices()
+	if len(devices) != 2 || devices[0].MAC != "bb:bb:bb:bb:bb:bb" || len(devices[0].IPs) != 2 || devices[0].IPs[1] != "fe80::1" {
+		t.Fatalf("Expected the most recently seen device first with both addresses, got %+v", devices)
+	}
+	if a := devices[1]; len(a.IPs) != 1 || a.IPs[0] != "192.168.1.20" || a.Packets != 3 || !a.FirstSeen.Equal(start) {
+		t.Errorf("Unexpected device: %+v", a)
+	}
+	if bindings := w.GetBindings(); len(bindings) != 2 {
+		t.Errorf("Expected the probe and the IPv6 address to bind nothing, got %+v", bindings)
+	}
+	if stats := w.GetStats(); stats["devices"] != 2 || stats["new_devices"] != uint64(2) {
+		t.Errorf("Unexpected stats: %v", stats)
+	}
+}
+
+func TestWatcher_DevicesOffByDefault(t *testing.T) {
+	w := NewWatcher("", time.Minute)
+	w.OnNewDevice = func(device arp.Device) { t.Errorf("Unexpected device: %+v", device) }
+	w.Observe("192.168.1.20", "aa:aa:aa:aa:aa:aa", time.Now())
+	if w.TracksDevices() || len(w.Devices()) != 0 {
+		t.Error("Expected no devices without TrackDevices")
+	}
+	if _, ok := w.GetStats()["devices"]; ok {
+		t.Error("Expected no device stats without TrackDevices")
+	}
+}
+
+func TestWatcher_DevicesPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.json")
+	w := NewWatcher("", time.Minute)
+	if err := w.TrackDevices(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	w.Observe("192.168.1.20", "aa:aa:aa:aa:aa:aa", time.Now())
+
+	reloaded := NewWatcher("", time.Minute)
+	if err := reloaded.TrackDevices(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	reloaded.OnNewDevice = func(device arp.Device) {
+		t.Errorf("Expected a known device not to be announced again, got %+v", device)
+	}
+	reloaded.Observe("192.168.1.20", "aa:aa:aa:aa:aa:aa", time.Now())
+	if devices := reloaded.Devices(); len(devices) != 1 || len(devices[0].IPs) != 1 || devices[0].IPs[0] != "192.168.1.20" {
+		t.Errorf("Expected the device's addresses to be restored, got %+v", devices)
+	}
+}
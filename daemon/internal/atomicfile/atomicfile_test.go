@@ -0,0 +1,28 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "devices.json")
+	if err := Write(path, []byte("[]")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := Write(path, []byte(`[{"mac": "aa:aa:aa:aa:aa:aa"}]`)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != `[{"mac": "aa:aa:aa:aa:aa:aa"}]` {
+		t.Errorf("Expected the file replaced, got %q (%v)", data, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected no temporary file left behind, got %v", entries)
+	}
+
+	if err := Write(filepath.Join(dir, "missing", "alerts.json"), []byte("[]")); err == nil {
+		t.Error("Expected a missing directory to fail")
+	}
+}
@@ -0,0 +1,271 @@
+package beacon
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Detection defaults
+const (
+	DefaultMinConnections = 6   // Connections needed before a pattern counts
+	DefaultMaxJitter      = 0.1 // Largest typical deviation from the interval, as a fraction of it
+	DefaultMinInterval    = 5 * time.Second
+)
+
+// Table limits
+const (
+	maxStarts               = 50    // Start times kept per pair, the newest
+	maxPairs                = 10000 // Source, destination and port pairs tracked
+	maxTrackedConversations = 10000 // Conversation IDs remembered to spot new conversations
+	pairIdle                = time.Hour
+)
+
+// AlertType is the alert raised when a beacon is first detected
+const AlertType = "beacon"
+
+// Config sets how regular connections must be to count as a beacon
+type Config struct {
+	MinConnections int           // DefaultMinConnections when 0
+	MaxJitter      float64       // DefaultMaxJitter when 0
+	MinInterval    time.Duration // Faster repeats are ignored, DefaultMinInterval when 0
+}
+
+// Beacon is a host connecting to the same destination at a regular interval
+type Beacon struct {
+	Source         string    `json:"source"` // Host opening the connections
+	Dest           string    `json:"dest"`
+	DestName       string    `json:"dest_name,omitempty"`
+	Port           int       `json:"port"`
+	Protocol       string    `json:"protocol"`
+	Interval       float64   `json:"interval_seconds"` // Median time between connections
+	Jitter         float64   `json:"jitter_seconds"`   // Median deviation from the interval
+	Score          float64   `json:"score"`            // 1 for a perfectly regular beacon, lower with jitter
+	Connections    int       `json:"connections"`      // Connections the interval is measured over
+	FirstSeen      time.Time `json:"first_seen"`
+	LastSeen       time.Time `json:"last_seen"`
+	ConversationID string    `json:"conversation_id"` // The latest connection
+}
+
+// Message describes the beacon for alerts and logs
+func (b Beacon) Message() string {
+	dest := b.Dest
+	if b.DestName != "" {
+		dest = fmt.Sprintf("%s (%s)", b.DestName, b.Dest)
+	}
+	return fmt.Sprintf("%s connects to %s port %s/%d every %s (±%s over %d connections)",
+		b.Source, dest, b.Protocol, b.Port, seconds(b.Interval), seconds(b.Jitter), b.Connections)
+}
+
+// seconds formats a number of seconds as a duration rounded to a tenth of a second
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second)).Round(100 * time.Millisecond)
+}
+
+// pair is the connection history from a source to a destination port
+type pair struct {
+	source, dest, destName string
+	port                   int
+	protocol               string
+	starts                 []time.Time
+	conversationID         string
+	beacon                 *Beacon // Set while the starts are regular
+	alerted                bool
+}
+
+// Detector watches when conversations start and finds hosts connecting to a
+// destination at a regular interval, the "phone home" pattern of malware
+// checking in with its command and control server
+type Detector struct {
+	config        Config
+	pairs         map[string]*pair
+	conversations map[string]time.Time // Conversation IDs already seen, with when they were last seen
+	mu            sync.Mutex
+
+	// OnBeacon is called (outside the lock) the first time a pair is found beaconing
+	OnBeacon func(beacon Beacon)
+}
+
+// NewDetector creates a detector
+func NewDetector(config Config) *Detector {
+	if config.MinConnections < 3 {
+		config.MinConnections = DefaultMinConnections
+	}
+	if config.MaxJitter <= 0 {
+		config.MaxJitter = DefaultMaxJitter
+	}
+	if config.MinInterval <= 0 {
+		config.MinInterval = DefaultMinInterval
+	}
+	return &Detector{
+		config:        config,
+		pairs:         make(map[string]*pair),
+		conversations: make(map[string]time.Time),
+	}
+}
+
+// Observe records the start of each conversation from its first packet,
+// whose sender is taken as the side connecting
+func (d *Detector) Observe(event *models.NetworkEvent) {
+	if event.ConversationID == "" {
+		return
+	}
+
+	d.mu.Lock()
+	if _, seen := d.conversations[event.ConversationID]; seen {
+		d.conversations[event.ConversationID] = event.Timestamp
+		d.mu.Unlock()
+		return
+	}
+	d.trackConversation(event.ConversationID, event.Timestamp)
+
+	key := event.SourceIP + "|" + event.DestIP + "|" + event.TransportProtocol + "|" + strconv.Itoa(event.DestPort)
+	p, exists := d.pairs[key]
+	if !exists {
+		if !d.makeRoom(event.Timestamp) {
+			d.mu.Unlock()
+			return
+		}
+		p = &pair{source: event.SourceIP, dest: event.DestIP, port: event.DestPort, protocol: event.TransportProtocol}
+		d.pairs[key] = p
+	}
+	if name := destName(event); name != "" {
+		p.destName = name
+	}
+	p.conversationID = event.ConversationID
+	p.starts = append(p.starts, event.Timestamp)
+	if len(p.starts) > maxStarts {
+		p.starts = p.starts[len(p.starts)-maxStarts:]
+	}
+
+	p.beacon = d.analyze(p)
+	var found *Beacon
+	if p.beacon != nil && !p.alerted {
+		p.alerted = true
+		found = p.beacon
+	}
+	d.mu.Unlock()
+
+	if found != nil && d.OnBeacon != nil {
+		d.OnBeacon(*found)
+	}
+}
+
+// analyze returns the pair's beacon if its connections start at a regular
+// interval: the median absolute deviation of the intervals from their median
+// is within the jitter allowed. The median keeps a missed or extra check-in
+// from hiding an otherwise regular pattern.
+func (d *Detector) analyze(p *pair) *Beacon {
+	if len(p.starts) < d.config.MinConnections {
+		return nil
+	}
+	intervals := make([]float64, 0, len(p.starts)-1)
+	for i := 1; i < len(p.starts); i++ {
+		intervals = append(intervals, p.starts[i].Sub(p.starts[i-1]).Seconds())
+	}
+	interval := median(intervals)
+	if interval < d.config.MinInterval.Seconds() {
+		return nil
+	}
+	deviations := make([]float64, len(intervals))
+	for i, value := range intervals {
+		deviations[i] = math.Abs(value - interval)
+	}
+	jitter := median(deviations)
+	if jitter > d.config.MaxJitter*interval {
+		return nil
+	}
+
+	first := p.starts[0]
+	if p.beacon != nil {
+		first = p.beacon.FirstSeen
+	}
+	return &Beacon{
+		Source:         p.source,
+		Dest:           p.dest,
+		DestName:       p.destName,
+		Port:           p.port,
+		Protocol:       p.protocol,
+		Interval:       math.Round(interval*10) / 10,
+		Jitter:         math.Round(jitter*10) / 10,
+		Score:          math.Round((1-jitter/interval)*100) / 100,
+		Connections:    len(p.starts),
+		FirstSeen:      first,
+		LastSeen:       p.starts[len(p.starts)-1],
+		ConversationID: p.conversationID,
+	}
+}
+
+// median returns the middle of values, which it sorts
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	middle := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[middle-1] + values[middle]) / 2
+	}
+	return values[middle]
+}
+
+// makeRoom forgets idle pairs once the table is full, reporting whether a
+// new one fits, must be called with the lock held
+func (d *Detector) makeRoom(now time.Time) bool {
+	if len(d.pairs) < maxPairs {
+		return true
+	}
+	for key, p := range d.pairs {
+		if now.Sub(p.starts[len(p.starts)-1]) > pairIdle {
+			delete(d.pairs, key)
+		}
+	}
+	return len(d.pairs) < maxPairs
+}
+
+// trackConversation remembers a seen conversation, forgetting idle ones when
+// the table is full, must be called with the lock held
+func (d *Detector) trackConversation(id string, at time.Time) {
+	if len(d.conversations) >= maxTrackedConversations {
+		for other, last := range d.conversations {
+			if at.Sub(last) > 10*time.Minute {
+				delete(d.conversations, other)
+			}
+		}
+	}
+	d.conversations[id] = at
+}
+
+// Beacons returns the pairs currently connecting at a regular interval, the
+// most regular first
+func (d *Detector) Beacons() []Beacon {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	beacons := []Beacon{}
+	for _, p := range d.pairs {
+		if p.beacon != nil {
+			beacons = append(beacons, *p.beacon)
+		}
+	}
+	sort.Slice(beacons, func(i, j int) bool {
+		if beacons[i].Score != beacons[j].Score {
+			return beacons[i].Score > beacons[j].Score
+		}
+		return beacons[i].LastSeen.After(beacons[j].LastSeen)
+	})
+	return beacons
+}
+
+// destName returns the best known name of the destination
+func destName(event *models.NetworkEvent) string {
+	if event.TLSServerName != "" {
+		return event.TLSServerName
+	}
+	if event.DestHostname != event.DestIP {
+		return event.DestHostname
+	}
+	return ""
+}
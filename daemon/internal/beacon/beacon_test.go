@@ -0,0 +1,69 @@
+package beacon
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// connect observes the packets of a new conversation from source to dest:443 at a time
+func connect(d *Detector, id int, source, dest string, at time.Time) {
+	for i := 0; i < 3; i++ {
+		d.Observe(&models.NetworkEvent{
+			Timestamp:         at.Add(time.Duration(i) * 10 * time.Millisecond),
+			ConversationID:    fmt.Sprintf("%s-%d", source, id),
+			SourceIP:          source,
+			DestIP:            dest,
+			DestPort:          443,
+			TransportProtocol: "TCP",
+			DestHostname:      dest,
+			TLSServerName:     "updates.example.com",
+		})
+	}
+}
+
+func TestDetector(t *testing.T) {
+	d := NewDetector(Config{})
+	var found []Beacon
+	d.OnBeacon = func(beacon Beacon) { found = append(found, beacon) }
+
+	start := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	// Every 60s give or take two seconds, with one missed check-in
+	jitter := []int{0, 2, -1, 1, -2, 0, 1, 0, -1, 2}
+	for i, j := range jitter {
+		if i == 4 {
+			continue
+		}
+		connect(d, i, "192.168.1.10", "203.0.113.9", start.Add(time.Duration(60*i+j)*time.Second))
+	}
+	// Browsing the same site at irregular times
+	for i, offset := range []int{0, 5, 95, 130, 400, 410, 900, 1300} {
+		connect(d, i, "192.168.1.20", "203.0.113.9", start.Add(time.Duration(offset)*time.Second))
+	}
+	// Fast repeats, like a page loading its assets
+	for i := 0; i < 10; i++ {
+		connect(d, i, "192.168.1.30", "203.0.113.9", start.Add(time.Duration(i)*time.Second))
+	}
+
+	if len(found) != 1 {
+		t.Fatalf("Expected a single beacon reported once, got %+v", found)
+	}
+	beacon := found[0]
+	if beacon.Source != "192.168.1.10" || beacon.Dest != "203.0.113.9" || beacon.Port != 443 || beacon.DestName != "updates.example.com" {
+		t.Errorf("Expected the regular pair, got %+v", beacon)
+	}
+	if beacon.Interval < 58 || beacon.Interval > 62 || beacon.Jitter > 2 || beacon.Score < 0.95 || beacon.Connections != DefaultMinConnections {
+		t.Errorf("Expected a 60s interval, give or take, detected after %d connections, got %+v", DefaultMinConnections, beacon)
+	}
+	if want := "192.168.1.10 connects to updates.example.com (203.0.113.9) port TCP/443 every 1m"; !strings.HasPrefix(beacon.Message(), want) {
+		t.Errorf("Unexpected message %q", beacon.Message())
+	}
+
+	beacons := d.Beacons()
+	if len(beacons) != 1 || beacons[0].Connections != 9 || !beacons[0].FirstSeen.Equal(beacon.FirstSeen) || beacons[0].ConversationID != "192.168.1.10-9" {
+		t.Errorf("Expected the beacon updated with every connection, got %+v", beacons)
+	}
+}
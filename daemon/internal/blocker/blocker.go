@@ -0,0 +1,270 @@
+package blocker
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/clock"
+	"github.com/iolloyd/netty/daemon/internal/firewall"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Scope selects what traffic is counted against the thresholds
+type Scope string
+
+const (
+	ScopeHost         Scope = "host"         // All traffic to/from a remote host
+	ScopeConversation Scope = "conversation" // A single 5-tuple conversation
+)
+
+// Config describes when to block and how
+type Config struct {
+	Scope      Scope
+	MaxBytes   uint64        // Bytes per window before blocking (0 disables)
+	MaxPackets uint64        // Packets per window before blocking (0 disables)
+	Window     time.Duration // Length of the counting window
+	Cooldown   time.Duration // How long a block stays in place
+	Script     string        // Invoked as: <script> block|unblock <host> [port] [protocol]
+	Firewall   bool          // Install rules through the firewall package
+	Backend    firewall.Backend
+}
+
+// Enabled returns true if the config has a threshold and an action
+func (c Config) Enabled() bool {
+	return (c.MaxBytes > 0 || c.MaxPackets > 0) && (c.Script != "" || c.Firewall)
+}
+
+// Block describes an active block
+type Block struct {
+	Target         firewall.Target `json:"target"`
+	Reason         string          `json:"reason"`
+	Since          time.Time       `json:"since"`
+	Expires        time.Time       `json:"expires"`
+	ConversationID string          `json:"conversation_id,omitempty"` // Conversation whose packet crossed the threshold
+}
+
+type counter struct {
+	bytes   uint64
+	packets uint64
+	target  firewall.Target
+}
+
+// Blocker counts traffic per host or conversation and blocks offenders
+type Blocker struct {
+	config      Config
+	localIP     string
+	counters    map[string]*counter
+	windowStart time.Time
+	active      map[string]*Block
+	mu          sync.Mutex
+
+	// OnChange is called (outside the lock) when a block is added or lifted
+	OnChange func(action string, block Block)
+
+	// runAction performs the block/unblock, replaceable in tests
+	runAction func(action string, target firewall.Target) error
+}
+
+// NewBlocker creates a new rate-based blocker
+func NewBlocker(config Config, localIP string) *Blocker {
+	if config.Window <= 0 {
+		config.Window = time.Minute
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = 10 * time.Minute
+	}
+	if config.Scope == "" {
+		config.Scope = ScopeHost
+	}
+
+	b := &Blocker{
+		config:      config,
+		localIP:     localIP,
+		counters:    make(map[string]*counter),
+		windowStart: time.Now(),
+		active:      make(map[string]*Block),
+	}
+	b.runAction = b.execute
+	return b
+}
+
+// SetLocalIP changes the address treated as this host, when the interface's address changes
+func (b *Blocker) SetLocalIP(ip string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.localIP = ip
+}
+
+// Observe counts an event and blocks its remote end if a threshold is crossed
+func (b *Blocker) Observe(event *models.NetworkEvent) {
+	b.mu.Lock()
+	target, key := b.targetFor(event)
+	if key == "" {
+		b.mu.Unlock()
+		return
+	}
+
+	now := clock.Now()
+	if now.Sub(b.windowStart) >= b.config.Window {
+		b.counters = make(map[string]*counter)
+		b.windowStart = now
+	}
+
+	if _, blocked := b.active[key]; blocked {
+		b.mu.Unlock()
+		return
+	}
+
+	c, exists := b.counters[key]
+	if !exists {
+		c = &counter{target: target}
+		b.counters[key] = c
+	}
+	c.bytes += uint64(event.Size)
+	c.packets++
+
+	var reason string
+	if b.config.MaxBytes > 0 && c.bytes > b.config.MaxBytes {
+		reason = fmt.Sprintf("%d bytes in %s exceeds limit of %d", c.bytes, b.config.Window, b.config.MaxBytes)
+	} else if b.config.MaxPackets > 0 && c.packets > b.config.MaxPackets {
+		reason = fmt.Sprintf("%d packets in %s exceeds limit of %d", c.packets, b.config.Window, b.config.MaxPackets)
+	}
+	if reason == "" {
+		b.mu.Unlock()
+		return
+	}
+
+	block := &Block{
+		Target:         target,
+		Reason:         reason,
+		Since:          now,
+		Expires:        now.Add(b.config.Cooldown),
+		ConversationID: event.ConversationID,
+	}
+	b.active[key] = block
+	delete(b.counters, key)
+	b.mu.Unlock()
+
+	// Run the action outside the capture path
+	go b.apply("block", *block)
+}
+
+// targetFor returns the remote end of the event and the counter key for it,
+// must be called with the lock held
+func (b *Blocker) targetFor(event *models.NetworkEvent) (firewall.Target, string) {
+	remoteIP, remotePort := event.DestIP, event.DestPort
+	if event.DestIP == b.localIP {
+		remoteIP, remotePort = event.SourceIP, event.SourcePort
+	}
+	if remoteIP == "" {
+		return firewall.Target{}, ""
+	}
+
+	if b.config.Scope == ScopeConversation {
+		target := firewall.Target{
+			Host:     remoteIP,
+			Port:     remotePort,
+			Protocol: strings.ToLower(event.TransportProtocol),
+		}
+		return target, fmt.Sprintf("%s:%s:%d", target.Protocol, remoteIP, remotePort)
+	}
+	return firewall.Target{Host: remoteIP}, remoteIP
+}
+
+// apply runs a block or unblock action and reports the outcome
+func (b *Blocker) apply(action string, block Block) {
+	if err := b.runAction(action, block.Target); err != nil {
+		log.Printf("[WARNING] Failed to %s %s: %v", action, block.Target.Host, err)
+		return
+	}
+
+	if action == "block" {
+		log.Printf("[INFO] Blocked %s until %s: %s", block.Target.Host, block.Expires.Format(time.RFC3339), block.Reason)
+	} else {
+		log.Printf("[INFO] Lifted block on %s", block.Target.Host)
+	}
+
+	if b.OnChange != nil {
+		b.OnChange(action, block)
+	}
+}
+
+// execute invokes the configured script and/or firewall integration
+func (b *Blocker) execute(action string, target firewall.Target) error {
+	if b.config.Script != "" {
+		args := []string{action, target.Host}
+		if target.Port > 0 {
+			args = append(args, strconv.Itoa(target.Port), target.Protocol)
+		}
+		if output, err := exec.Command(b.config.Script, args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("block script failed: %w: %s", err, strings.TrimSpace(string(output)))
+		}
+	}
+
+	if b.config.Firewall {
+		var rules []firewall.Rule
+		var err error
+		if action == "block" {
+			rules, err = firewall.GenerateRules(b.config.Backend, target)
+		} else {
+			rules, err = firewall.GenerateUnblockRules(b.config.Backend, target)
+		}
+		if err != nil {
+			return err
+		}
+		return firewall.Apply(rules)
+	}
+	return nil
+}
+
+// ExpireBlocks lifts blocks whose cooldown has passed
+func (b *Blocker) ExpireBlocks() {
+	now := time.Now()
+	var expired []Block
+
+	b.mu.Lock()
+	for key, block := range b.active {
+		if now.After(block.Expires) {
+			expired = append(expired, *block)
+			delete(b.active, key)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, block := range expired {
+		b.apply("unblock", block)
+	}
+}
+
+// StartExpiryRoutine starts a goroutine that lifts expired blocks
+func (b *Blocker) StartExpiryRoutine() {
+	go func() {
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			b.ExpireBlocks()
+		}
+	}()
+}
+
+// ActiveBlocks returns the blocks currently in place, oldest first
+func (b *Blocker) ActiveBlocks() []Block {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	blocks := make([]Block, 0, len(b.active))
+	for _, block := range b.active {
+		blocks = append(blocks, *block)
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].Since.Before(blocks[j].Since)
+	})
+	return blocks
+}
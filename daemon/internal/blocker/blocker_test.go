@@ -0,0 +1,104 @@
+package blocker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/firewall"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func newTestBlocker(config Config) (*Blocker, chan string) {
+	actions := make(chan string, 10)
+	b := NewBlocker(config, "192.168.1.10")
+	b.runAction = func(action string, target firewall.Target) error {
+		actions <- action + " " + target.Host
+		return nil
+	}
+	return b, actions
+}
+
+func outgoing(size int) *models.NetworkEvent {
+	return &models.NetworkEvent{
+		SourceIP:          "192.168.1.10",
+		SourcePort:        50000,
+		DestIP:            "203.0.113.7",
+		DestPort:          443,
+		TransportProtocol: "TCP",
+		Size:              size,
+	}
+}
+
+func waitForAction(t *testing.T, actions chan string) string {
+	t.Helper()
+	select {
+	case action := <-actions:
+		return action
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for block action")
+	}
+	return ""
+}
+
+func TestBlocker_BlocksWhenByteThresholdExceeded(t *testing.T) {
+	b, actions := newTestBlocker(Config{MaxBytes: 1000, Script: "unused"})
+
+	b.Observe(outgoing(600))
+	if len(b.ActiveBlocks()) != 0 {
+		t.Fatal("Expected no block below threshold")
+	}
+
+	b.Observe(outgoing(600))
+	if action := waitForAction(t, actions); action != "block 203.0.113.7" {
+		t.Errorf("Expected remote host to be blocked, got '%s'", action)
+	}
+
+	blocks := b.ActiveBlocks()
+	if len(blocks) != 1 || blocks[0].Target.Host != "203.0.113.7" {
+		t.Fatalf("Expected one active block for 203.0.113.7, got %+v", blocks)
+	}
+}
+
+func TestBlocker_ExpiresBlocks(t *testing.T) {
+	b, actions := newTestBlocker(Config{MaxPackets: 1, Cooldown: time.Millisecond, Script: "unused"})
+
+	b.Observe(outgoing(10))
+	b.Observe(outgoing(10))
+	waitForAction(t, actions)
+
+	time.Sleep(5 * time.Millisecond)
+	b.ExpireBlocks()
+
+	if action := waitForAction(t, actions); action != "unblock 203.0.113.7" {
+		t.Errorf("Expected block to be lifted, got '%s'", action)
+	}
+	if len(b.ActiveBlocks()) != 0 {
+		t.Error("Expected no active blocks after expiry")
+	}
+}
+
+func TestBlocker_ConversationScopeIncludesPort(t *testing.T) {
+	b, actions := newTestBlocker(Config{Scope: ScopeConversation, MaxPackets: 1, Script: "unused"})
+
+	b.Observe(outgoing(10))
+	b.Observe(outgoing(10))
+	waitForAction(t, actions)
+
+	blocks := b.ActiveBlocks()
+	if len(blocks) != 1 || blocks[0].Target.Port != 443 || blocks[0].Target.Protocol != "tcp" {
+		t.Fatalf("Expected conversation-scoped block on tcp/443, got %+v", blocks)
+	}
+}
+
+func TestBlocker_SetLocalIP(t *testing.T) {
+	b, actions := newTestBlocker(Config{MaxPackets: 1, Script: "unused"})
+	b.SetLocalIP("203.0.113.7")
+
+	// Replies to the new local address are from the remote end
+	reply := &models.NetworkEvent{SourceIP: "198.51.100.1", SourcePort: 443, DestIP: "203.0.113.7", DestPort: 50000, TransportProtocol: "TCP", Size: 10}
+	b.Observe(reply)
+	b.Observe(reply)
+	if action := waitForAction(t, actions); action != "block 198.51.100.1" {
+		t.Errorf("Expected the remote host to be blocked, not this host, got '%s'", action)
+	}
+}
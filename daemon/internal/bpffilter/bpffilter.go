@@ -0,0 +1,87 @@
+// Package bpffilter turns capture filters into classic BPF programs, to attach
+// to a socket or run over captured packets in userspace. Filters are either
+// expressions such as "tcp port 443", which need libpcap to compile, or the
+// program tcpdump -ddd prints for one, which doesn't.
+package bpffilter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/gopacket/layers"
+	"golang.org/x/net/bpf"
+)
+
+// Compile returns the program for a filter on packets of the given link type
+func Compile(linkType layers.LinkType, snaplen int, filter string) ([]bpf.RawInstruction, error) {
+	if program, ok, err := parseProgram(filter); ok {
+		return program, err
+	}
+	return compileExpression(linkType, snaplen, filter)
+}
+
+// Filter matches packets against a filter in userspace
+type Filter struct {
+	vm *bpf.VM
+}
+
+// New compiles a filter to match packets of the given link type against
+func New(linkType layers.LinkType, snaplen int, filter string) (*Filter, error) {
+	program, err := Compile(linkType, snaplen, filter)
+	if err != nil {
+		return nil, err
+	}
+	instructions := make([]bpf.Instruction, len(program))
+	for i, raw := range program {
+		instructions[i] = raw.Disassemble()
+	}
+	vm, err := bpf.NewVM(instructions)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported program: %w", err)
+	}
+	return &Filter{vm: vm}, nil
+}
+
+// Matches reports whether the filter accepts a packet
+func (f *Filter) Matches(data []byte) bool {
+	kept, err := f.vm.Run(data)
+	return err == nil && kept > 0
+}
+
+// parseProgram parses tcpdump -ddd output: the instruction count followed by
+// one "code jt jf k" instruction per line, or separated by commas as
+// iptables takes it. ok is false when filter doesn't start with a count.
+func parseProgram(filter string) (program []bpf.RawInstruction, ok bool, err error) {
+	lines := strings.FieldsFunc(filter, func(r rune) bool { return r == '\n' || r == ',' })
+	if len(lines) == 0 {
+		return nil, false, nil
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(lines[0]))
+	if err != nil {
+		return nil, false, nil
+	}
+	if count < 1 || count != len(lines)-1 {
+		return nil, true, fmt.Errorf("program announces %d instructions but has %d", count, len(lines)-1)
+	}
+
+	for i, line := range lines[1:] {
+		fields := strings.Fields(line)
+		var values [4]uint64
+		if len(fields) != len(values) {
+			return nil, true, fmt.Errorf("program instruction %d isn't \"code jt jf k\"", i+1)
+		}
+		for j, bits := range []int{16, 8, 8, 32} {
+			if values[j], err = strconv.ParseUint(fields[j], 10, bits); err != nil {
+				return nil, true, fmt.Errorf("program instruction %d: %w", i+1, err)
+			}
+		}
+		program = append(program, bpf.RawInstruction{
+			Op: uint16(values[0]),
+			Jt: uint8(values[1]),
+			Jf: uint8(values[2]),
+			K:  uint32(values[3]),
+		})
+	}
+	return program, true, nil
+}
@@ -0,0 +1,42 @@
+package bpffilter
+
+import (
+	"testing"
+
+	"github.com/google/gopacket/layers"
+)
+
+// ipv4Program is tcpdump -ddd ip on Ethernet
+const ipv4Program = "4\n40 0 0 12\n21 0 1 2048\n6 0 0 262144\n6 0 0 0\n"
+
+func frame(etherType uint16) []byte {
+	data := make([]byte, 14)
+	data[12], data[13] = byte(etherType>>8), byte(etherType)
+	return data
+}
+
+func TestFilter_Program(t *testing.T) {
+	for _, program := range []string{ipv4Program, "4,40 0 0 12,21 0 1 2048,6 0 0 262144,6 0 0 0"} {
+		filter, err := New(layers.LinkTypeEthernet, 65535, program)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !filter.Matches(frame(0x0800)) {
+			t.Error("Expected an IPv4 frame to match")
+		}
+		if filter.Matches(frame(0x0806)) || filter.Matches([]byte{1, 2}) {
+			t.Error("Expected ARP and truncated frames not to match")
+		}
+	}
+}
+
+func TestCompile_InvalidProgram(t *testing.T) {
+	for _, program := range []string{"3\n40 0 0 12\n6 0 0 0", "2\n40 0 0\n6 0 0 0", "1\n6 0 0 x"} {
+		if _, err := Compile(layers.LinkTypeEthernet, 65535, program); err == nil {
+			t.Errorf("Expected %q to be rejected", program)
+		}
+	}
+	if _, err := New(layers.LinkTypeEthernet, 65535, "not a (filter"); err == nil {
+		t.Error("Expected an invalid expression to be rejected")
+	}
+}
@@ -0,0 +1,22 @@
+//go:build cgo || windows
+
+package bpffilter
+
+import (
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+	"golang.org/x/net/bpf"
+)
+
+// compileExpression compiles a filter expression with libpcap
+func compileExpression(linkType layers.LinkType, snaplen int, filter string) ([]bpf.RawInstruction, error) {
+	instructions, err := pcap.CompileBPFFilter(linkType, snaplen, filter)
+	if err != nil {
+		return nil, err
+	}
+	program := make([]bpf.RawInstruction, len(instructions))
+	for i, ins := range instructions {
+		program[i] = bpf.RawInstruction{Op: ins.Code, Jt: ins.Jt, Jf: ins.Jf, K: ins.K}
+	}
+	return program, nil
+}
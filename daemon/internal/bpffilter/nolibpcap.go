@@ -0,0 +1,15 @@
+//go:build !cgo && !windows
+
+package bpffilter
+
+import (
+	"fmt"
+
+	"github.com/google/gopacket/layers"
+	"golang.org/x/net/bpf"
+)
+
+// compileExpression fails, builds without cgo have no libpcap to compile with
+func compileExpression(linkType layers.LinkType, snaplen int, filter string) ([]bpf.RawInstruction, error) {
+	return nil, fmt.Errorf("this build has no libpcap to compile expressions, give the output of tcpdump -ddd %q instead", filter)
+}
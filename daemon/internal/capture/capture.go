@@ -1,46 +1,96 @@
 package capture
 
 import (
-	"fmt"
+	"encoding/binary"
 	"log"
+	"sync"
 	"time"
 
-	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
-	"github.com/google/gopacket/pcap"
+	"github.com/iolloyd/netty/daemon/internal/arpwatch"
+	"github.com/iolloyd/netty/daemon/internal/clock"
 	"github.com/iolloyd/netty/daemon/internal/conversation"
+	"github.com/iolloyd/netty/daemon/internal/dhcp"
+	"github.com/iolloyd/netty/daemon/internal/discovery"
+	"github.com/iolloyd/netty/daemon/internal/history"
 	"github.com/iolloyd/netty/daemon/internal/models"
+	"github.com/iolloyd/netty/daemon/internal/ndp"
 	"github.com/iolloyd/netty/daemon/internal/parser"
+	"github.com/iolloyd/netty/daemon/internal/pcapwriter"
+	"github.com/iolloyd/netty/daemon/internal/reassembly"
 	"github.com/iolloyd/netty/daemon/internal/resolver"
+	"github.com/iolloyd/netty/daemon/internal/tap"
 )
 
 type PacketCapture struct {
-	handle      *pcap.Handle
+	handle      packetHandle
+	handleMu    sync.Mutex // Guards handle, which is replaced when a failed interface is reopened
+	packetMu    sync.Mutex // Held while a packet is processed, closing the handle takes it first
+	backend     string     // Live capture backend, BackendPcap or BackendAFPacket
+	live        LiveOptions // How the interface is opened, and reopened
+	stop        chan struct{}
+	stopOnce    sync.Once
 	iface       string
 	filter      string
 	convMgr     *conversation.Manager
 	dnsResolver *resolver.DNSResolver
 	stats       *PacketStats
+	replay      *replayer // Set when replaying a capture file
+	arpWatcher  *arpwatch.Watcher
+	ndpMonitor  *ndp.Monitor
+	leases      *dhcp.Table // Set when DHCP leases are tracked, see SetLeaseTable
+	services    *discovery.Inventory // Set when mDNS and SSDP services are tracked, see SetServiceInventory
+	dedup       *deduplicator // Set when duplicate packets are discarded
+	decoders    *parser.Registry
+	streams     *reassembly.Assembler // Holds TCP messages spanning several segments for the decoders
+	history     *history.History // Set when raw packets are kept for pcap export
+	tap         *tap.Tap         // Set when packets can be streamed live, see SetTap
+	pcapOut     *pcapwriter.Writer // Set when every packet is written to disk
+	geoLookup   func(ip string) *models.GeoInfo // Set when addresses are located with a GeoIP database
+	internetOnly bool // Discard events between local addresses
+	sampler      *sampler // Set when only a fraction of packets is processed
+	payloadBytes int      // How much of each packet's payload events keep
+	kernelDropsRetired dropCounts // Kernel drops counted by handles since replaced, guarded by handleMu
+	kernelDropsBase    dropCounts // Kernel drops at the last counter reset, guarded by handleMu
+	restartReason      string // Why the handle was closed for a restart, guarded by handleMu
+	pause              *pauseState // Set while paused, guarded by handleMu
+	
+	// OnNetworkChange is called when the capture restarts after a wake or network change
+	OnNetworkChange func(change NetworkChange)
 }
 
+// NewPacketCapture creates a live capture reading packets through DefaultBackend
 func NewPacketCapture(iface, filter, localIP string) (*PacketCapture, error) {
-	log.Printf("[DEBUG] Opening packet capture on interface: %s", iface)
-	handle, err := pcap.OpenLive(iface, 65536, true, pcap.BlockForever)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open interface %s: %w", iface, err)
-	}
-	log.Printf("[DEBUG] Successfully opened interface %s", iface)
+	return NewPacketCaptureWithBackend(iface, filter, localIP, DefaultBackend)
+}
 
+// NewPacketCaptureWithBackend creates a live capture reading packets through
+// backend, BackendPcap or BackendAFPacket
+func NewPacketCaptureWithBackend(iface, filter, localIP, backend string) (*PacketCapture, error) {
+	return NewPacketCaptureWithOptions(iface, filter, localIP, backend, DefaultLiveOptions())
+}
+
+// NewPacketCaptureWithOptions creates a live capture reading packets through
+// backend with the snaplen, promiscuous mode and buffering of options
+func NewPacketCaptureWithOptions(iface, filter, localIP, backend string, options LiveOptions) (*PacketCapture, error) {
+	if err := ValidateBackend(backend); err != nil {
+		return nil, err
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	backend = canonicalBackend(backend)
+	log.Printf("[DEBUG] Opening packet capture on interface %s with the %s backend", iface, backend)
 	if filter != "" {
 		log.Printf("[DEBUG] Setting BPF filter: %s", filter)
-		if err := handle.SetBPFFilter(filter); err != nil {
-			handle.Close()
-			return nil, fmt.Errorf("failed to set BPF filter: %w", err)
-		}
-		log.Printf("[DEBUG] BPF filter set successfully")
 	} else {
 		log.Printf("[DEBUG] No BPF filter specified, capturing all traffic")
 	}
+	handle, err := openLive(backend, iface, filter, options)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[DEBUG] Successfully opened interface %s", iface)
 
 	// Create conversation manager with local IP
 	convMgr := conversation.NewManager(localIP)
@@ -52,11 +102,16 @@ func NewPacketCapture(iface, filter, localIP string) (*PacketCapture, error) {
 
 	return &PacketCapture{
 		handle:      handle,
+		backend:     backend,
+		live:        options,
 		iface:       iface,
 		filter:      filter,
 		convMgr:     convMgr,
 		dnsResolver: dnsResolver,
 		stats:       NewPacketStats(),
+		decoders:    parser.DefaultRegistry(),
+		streams:     reassembly.NewAssembler(),
+		stop:        make(chan struct{}),
 	}, nil
 }
 
@@ -65,17 +120,21 @@ func (pc *PacketCapture) Start() <-chan *models.NetworkEvent {
 	
 	go func() {
 		defer close(events)
-		packetSource := gopacket.NewPacketSource(pc.handle, pc.handle.LinkType())
+		pc.handleMu.Lock()
+		handle := pc.handle
+		pc.handleMu.Unlock()
+		// Packets are decoded into layers reused from one packet to the next
+		decoder := newDecoder(handle.LinkType())
 		log.Printf("[DEBUG] Starting packet capture loop on interface %s", pc.iface)
 		
 		// Start a timer to check if we're receiving packets
-		noPacketTimer := time.NewTimer(10 * time.Second)
-		defer noPacketTimer.Stop()
+		loop := &captureLoop{noPacketTimer: time.NewTimer(10 * time.Second)}
+		defer loop.noPacketTimer.Stop()
 		
 		go func() {
-			<-noPacketTimer.C
+			<-loop.noPacketTimer.C
 			stats := pc.stats.GetStats()
-			if stats["total_packets"].(uint64) == 0 {
+			if stats["total_packets"].(uint64) == 0 && pc.replay == nil {
 				log.Printf("[WARNING] No packets captured after 10 seconds on interface %s", pc.iface)
 				log.Printf("[WARNING] Possible issues:")
 				log.Printf("[WARNING]   - Wrong interface (use -list to see available interfaces)")
@@ -86,80 +145,214 @@ func (pc *PacketCapture) Start() <-chan *models.NetworkEvent {
 			}
 		}()
 		
-		packetCount := 0
-		for packet := range packetSource.Packets() {
-			packetCount++
-			pc.stats.IncrementPackets()
-			pc.stats.IncrementBytes(uint64(len(packet.Data())))
-			pc.stats.UpdateLastPacketTime()
-			
-			// Reset timer on first packet
-			if packetCount == 1 {
-				noPacketTimer.Stop()
-				log.Printf("[INFO] Successfully capturing packets on interface %s", pc.iface)
+		for {
+			data, info, err := handle.ZeroCopyReadPacketData()
+			if err != nil {
+				// Retry, reopen the interface or stop, depending on the error
+				if handle = pc.handleReadError(err, handle); handle == nil {
+					break
+				}
+				if handle.LinkType() != decoder.linkType {
+					decoder = newDecoder(handle.LinkType())
+				}
+				continue
 			}
-			
-			if packetCount%100 == 0 {
-				log.Printf("[DEBUG] Captured %d packets so far", packetCount)
+			if waited := pc.waitIfPaused(); waited > 0 && pc.replay != nil {
+				pc.replay.delay(waited)
 			}
-			event := pc.processPacket(packet)
-			if event != nil {
-				if packetCount <= 10 {
-					log.Printf("[DEBUG] Processed packet #%d: %s:%d -> %s:%d (%s)", 
-						packetCount, event.SourceIP, event.SourcePort, 
-						event.DestIP, event.DestPort, event.TransportProtocol)
+			if pc.replay != nil {
+				replay, done := pc.replay.admit(info.Timestamp)
+				if done {
+					break
 				}
-				// Process packet through conversation manager
-				pc.convMgr.ProcessEvent(event)
-				
-				select {
-				case events <- event:
-					pc.stats.IncrementProcessed()
-					if packetCount <= 10 {
-						log.Printf("[DEBUG] Event sent to channel successfully")
-					}
-				default:
-					pc.stats.IncrementDropped()
-					log.Println("[WARNING] Event channel full, dropping packet")
+				if !replay {
+					continue
 				}
-			} else {
-				if packetCount <= 10 {
-					log.Printf("[DEBUG] Packet #%d: No network/transport layer found", packetCount)
+			}
+			if !pc.lockPacket(handle) {
+				continue
+			}
+			event := pc.handleFrame(decoder.decode(data, info), loop)
+			pc.packetMu.Unlock()
+			if event == nil {
+				continue
+			}
+			
+			// Replays wait for consumers instead of dropping events
+			if pc.replay != nil {
+				events <- event
+				pc.stats.IncrementProcessed()
+				continue
+			}
+			
+			select {
+			case events <- event:
+				pc.stats.IncrementProcessed()
+				if loop.packets <= 10 {
+					log.Printf("[DEBUG] Event sent to channel successfully")
 				}
+			default:
+				pc.stats.IncrementDropped()
+				log.Println("[WARNING] Event channel full, dropping packet")
 			}
 		}
+		if pc.replay != nil {
+			log.Printf("[INFO] Replay of %s finished after %d packets", pc.iface, loop.packets)
+		}
 	}()
 	
 	return events
 }
 
-func (pc *PacketCapture) processPacket(packet gopacket.Packet) *models.NetworkEvent {
+// captureLoop is the state the capture loop keeps between packets
+type captureLoop struct {
+	packets        int
+	pcapOutFailing bool
+	noPacketTimer  *time.Timer
+}
+
+// lockPacket holds off closing a handle while a packet read from it is
+// processed, as the packet's data is only valid until then. It reports false,
+// holding nothing, if the handle was closed or replaced since the read.
+func (pc *PacketCapture) lockPacket(handle packetHandle) bool {
+	pc.packetMu.Lock()
+	pc.handleMu.Lock()
+	current := pc.handle == handle && !pc.isClosed()
+	pc.handleMu.Unlock()
+	if !current {
+		pc.packetMu.Unlock()
+	}
+	return current
+}
+
+// handleFrame counts, records and processes a packet, returning the event
+// for it or nil. The frame's data isn't kept past the call, what holds on to
+// it keeps a copy.
+func (pc *PacketCapture) handleFrame(f *frame, loop *captureLoop) *models.NetworkEvent {
+	// Mirrored copies, and the any device's second copy of loopback packets,
+	// are dropped before they count towards any statistics
+	if f.echo || pc.dedup != nil && f.network != nil && pc.dedup.duplicate(f.network, f.info.Timestamp) {
+		pc.stats.IncrementDuplicates()
+		return nil
+	}
+	loop.packets++
+	pc.stats.IncrementPackets()
+	pc.stats.IncrementBytes(uint64(f.wireLength()))
+	pc.stats.UpdateLastPacketTime()
+	
+	// Keep the raw packet on disk, reporting write failures once until they clear
+	if pc.pcapOut != nil {
+		if err := pc.pcapOut.WritePacket(f.info, f.data); err != nil {
+			if !loop.pcapOutFailing {
+				log.Printf("[WARNING] Pcap output failed: %v", err)
+			}
+			loop.pcapOutFailing = true
+		} else if loop.pcapOutFailing {
+			log.Printf("[INFO] Pcap output recovered")
+			loop.pcapOutFailing = false
+		}
+	}
+	
+	if pc.tap != nil {
+		pc.tap.Add(f.info, f.data)
+	}
+	
+	// Packets left out of the sample are counted, recorded and tapped but not
+	// decoded into events. The ARP, neighbor discovery and DHCP watchers see
+	// every packet, they're few and each one matters.
+	if pc.sampler != nil && !f.watched() && !pc.sampler.sample() {
+		pc.stats.CountUnsampled()
+		return nil
+	}
+	
+	// Reset timer on first packet
+	if loop.packets == 1 {
+		loop.noPacketTimer.Stop()
+		log.Printf("[INFO] Successfully capturing packets on interface %s", pc.iface)
+	}
+	
+	if loop.packets%100 == 0 {
+		log.Printf("[DEBUG] Captured %d packets so far", loop.packets)
+	}
+	event := pc.processPacket(f)
+	if event == nil {
+		if loop.packets <= 10 {
+			log.Printf("[DEBUG] Packet #%d: No network/transport layer found", loop.packets)
+		}
+		return nil
+	}
+	if pc.internetOnly && !crossesInternet(event) {
+		pc.stats.IncrementLocalFiltered()
+		return nil
+	}
+	if pc.sampler != nil {
+		event.SampleRate = pc.sampler.rate
+	}
+	setVLANs(event, f.vlans)
+	event.Encapsulation = f.tunnel
+	if loop.packets <= 10 {
+		log.Printf("[DEBUG] Processed packet #%d: %s:%d -> %s:%d (%s)", 
+			loop.packets, event.SourceIP, event.SourcePort, 
+			event.DestIP, event.DestPort, event.TransportProtocol)
+	}
+	// Process packet through conversation manager
+	if !event.IsNeighborTraffic() {
+		pc.convMgr.ProcessEvent(event)
+		if pc.history != nil {
+			pc.history.Add(event.ConversationID, f.info, append([]byte(nil), f.data...))
+		}
+	}
+	return event
+}
+
+func (pc *PacketCapture) processPacket(f *frame) *models.NetworkEvent {
+	// ARP carries no IP traffic but tells us which MAC claims which address
+	if f.arp != nil {
+		return pc.processARP(f.arp, f)
+	}
+	// Neighbor discovery is IPv6's ARP, it has no transport layer either
+	if f.ndp != nil {
+		if info, ok := ndp.DecodeLayers(f.ip6, f.eth, f.ndp); ok {
+			return pc.processNDP(info, f)
+		}
+	}
+	
+	// Count every IP fragment, including those without a transport header
+	if f.fragment {
+		pc.stats.IncrementFragments()
+	}
+	
 	// Only return nil if packet has no network or transport layer
-	if packet.NetworkLayer() == nil || packet.TransportLayer() == nil {
+	if f.network == nil || f.transport == nil {
 		return nil
 	}
 	
 	event := &models.NetworkEvent{
-		Timestamp: time.Now(),
+		Timestamp: pc.eventTime(f),
 		Interface: pc.iface,
 	}
 
 	// Extract network layer
-	if netLayer := packet.NetworkLayer(); netLayer != nil {
+	if netLayer := f.network; netLayer != nil {
 		switch net := netLayer.(type) {
 		case *layers.IPv4:
 			event.Protocol = "IPv4"
 			event.SourceIP = net.SrcIP.String()
 			event.DestIP = net.DstIP.String()
+			event.DontFragment = net.Flags&layers.IPv4DontFragment != 0
+			event.DSCP, event.ECN = splitTOS(net.TOS)
+			event.DSCPClass = models.DSCPClass(event.DSCP)
 		case *layers.IPv6:
 			event.Protocol = "IPv6"
 			event.SourceIP = net.SrcIP.String()
 			event.DestIP = net.DstIP.String()
+			event.DSCP, event.ECN = splitTOS(net.TrafficClass)
+			event.DSCPClass = models.DSCPClass(event.DSCP)
 		}
 	}
 
 	// Extract transport layer
-	if transLayer := packet.TransportLayer(); transLayer != nil {
+	if transLayer := f.transport; transLayer != nil {
 		switch trans := transLayer.(type) {
 		case *layers.TCP:
 			event.TransportProtocol = "TCP"
@@ -180,66 +373,90 @@ func (pc *PacketCapture) processPacket(packet gopacket.Packet) *models.NetworkEv
 			// Extract sequence and acknowledgment numbers
 			event.SequenceNumber = trans.Seq
 			event.AckNumber = trans.Ack
-			
-			// Determine direction based on SYN/ACK flags
-			if trans.SYN && !trans.ACK {
-				event.Direction = "outgoing"
-			} else if trans.SYN && trans.ACK {
-				event.Direction = "incoming"
-			} else {
-				// For established connections, use port heuristics
-				if trans.DstPort < 1024 || isCommonPort(int(trans.DstPort)) {
-					event.Direction = "outgoing"
-				} else if trans.SrcPort < 1024 || isCommonPort(int(trans.SrcPort)) {
-					event.Direction = "incoming"
-				} else {
-					event.Direction = "unknown"
-				}
-			}
-			
-			// Try to extract TLS SNI if this is HTTPS traffic
-			if trans.DstPort == 443 || trans.SrcPort == 443 {
-				if payload := trans.LayerPayload(); len(payload) > 0 {
-					if sni := parser.ExtractSNI(payload); sni != "" {
-						event.TLSServerName = sni
-					}
-				}
+			event.PayloadSize = len(trans.Payload)
+			if trans.SYN {
+				event.MSS = tcpMSS(trans)
 			}
+
 		case *layers.UDP:
 			event.TransportProtocol = "UDP"
 			event.SourcePort = int(trans.SrcPort)
 			event.DestPort = int(trans.DstPort)
+			event.PayloadSize = len(trans.Payload)
 			pc.stats.IncrementUDP()
-			
-			// Use port heuristics for UDP
-			if trans.DstPort < 1024 || isCommonPort(int(trans.DstPort)) {
-				event.Direction = "outgoing"
-			} else if trans.SrcPort < 1024 || isCommonPort(int(trans.SrcPort)) {
-				event.Direction = "incoming"
-			} else {
-				event.Direction = "unknown"
-			}
 		}
 	}
+	event.Direction = packetDirection(pc.convMgr.LocalAddresses(), f, event.SourceIP, event.DestIP)
 
 	// Calculate packet size
-	event.Size = len(packet.Data())
+	event.Size = f.wireLength()
+	event.CapturedSize = len(f.data)
+	event.Fragmented = f.fragment
+
+	// Decode the application layer if present. This is the transport payload
+	// rather than gopacket's application layer, which is empty for protocols
+	// gopacket decodes itself such as DNS. TCP segments go through the
+	// reassembler so a message spanning several is decoded whole.
+	payload := f.transport.LayerPayload()
+	event.Payload = pc.keepPayload(payload)
+	if tcp, ok := f.transport.(*layers.TCP); ok {
+		payload = pc.reassemble(tcp, payload, event)
+	}
+	if len(payload) > 0 {
+		pc.decoders.Decode(payload, event)
+		event.Encryption = parser.ClassifyEncryption(payload, event)
+	}
+	
+	// Remember the names DNS responses answer so later flows to the addresses are named by them
+	if dns := event.DNS; dns != nil && dns.Response && dns.RCode == "NOERROR" {
+		for _, ip := range dns.Answers {
+			pc.dnsResolver.Learn(ip, dns.Query)
+		}
+	}
 
-	// Extract application layer if present
-	if appLayer := packet.ApplicationLayer(); appLayer != nil {
-		event.AppProtocol = guessAppProtocol(event.SourcePort, event.DestPort)
+	if event.DHCP != nil && pc.leases != nil {
+		pc.leases.Observe(event.DHCP, clock.In(f.info.Timestamp))
+	}
+	if event.Discovery != nil && pc.services != nil {
+		pc.services.Observe(event)
 	}
 
 	// Perform DNS resolution (using cached results when available)
 	if event.SourceIP != "" && event.DestIP != "" {
-		event.SourceHostname = pc.dnsResolver.ResolveIP(event.SourceIP)
-		event.DestHostname = pc.dnsResolver.ResolveIP(event.DestIP)
+		event.SourceHostname = pc.hostname(event.SourceIP)
+		event.DestHostname = pc.hostname(event.DestIP)
+		if pc.geoLookup != nil {
+			event.SourceGeo = pc.geoLookup(event.SourceIP)
+			event.DestGeo = pc.geoLookup(event.DestIP)
+		}
 	}
 
 	return event
 }
 
+// reassemble returns what the decoders should see of a TCP segment: the
+// message it completes when earlier segments began one, otherwise the segment
+func (pc *PacketCapture) reassemble(tcp *layers.TCP, payload []byte, event *models.NetworkEvent) []byte {
+	key := reassembly.Key(event.SourceIP, event.SourcePort, event.DestIP, event.DestPort)
+	data := pc.streams.Add(key, tcp.Seq, payload, event.Timestamp, func(data []byte) bool {
+		return pc.decoders.Complete(data, event)
+	})
+	switch {
+	case tcp.RST:
+		pc.streams.Close(key, reassembly.Key(event.DestIP, event.DestPort, event.SourceIP, event.SourcePort))
+	case tcp.FIN:
+		pc.streams.Close(key)
+	}
+	return data
+}
+
 func (pc *PacketCapture) Close() {
+	pc.stopOnce.Do(func() { close(pc.stop) })
+	// Wait for the packet being processed, its data goes with the handle
+	pc.packetMu.Lock()
+	defer pc.packetMu.Unlock()
+	pc.handleMu.Lock()
+	defer pc.handleMu.Unlock()
 	if pc.handle != nil {
 		pc.handle.Close()
 	}
@@ -251,46 +468,257 @@ func (pc *PacketCapture) GetConversationManager() *conversation.Manager {
 	return pc.convMgr
 }
 
-func isCommonPort(port int) bool {
-	commonPorts := map[int]bool{
-		80:   true, // HTTP
-		443:  true, // HTTPS
-		22:   true, // SSH
-		21:   true, // FTP
-		25:   true, // SMTP
-		53:   true, // DNS
-		3306: true, // MySQL
-		5432: true, // PostgreSQL
-		6379: true, // Redis
-		27017: true, // MongoDB
-	}
-	return commonPorts[port]
-}
-
-func guessAppProtocol(srcPort, dstPort int) string {
-	portMap := map[int]string{
-		80:   "HTTP",
-		443:  "HTTPS",
-		22:   "SSH",
-		21:   "FTP",
-		25:   "SMTP",
-		53:   "DNS",
-		3306: "MySQL",
-		5432: "PostgreSQL",
-		6379: "Redis",
-		27017: "MongoDB",
+// SetReverseDNS turns reverse DNS lookups of addresses without a name seen on
+// the wire on or off, caching their answers for ttl
+func (pc *PacketCapture) SetReverseDNS(enabled bool, ttl time.Duration) {
+	pc.dnsResolver.SetReverseLookups(enabled, ttl)
+}
+
+// SetGeoLookup locates the addresses of every event with lookup, such as a
+// GeoIP database's, call it before Start
+func (pc *PacketCapture) SetGeoLookup(lookup func(ip string) *models.GeoInfo) {
+	pc.geoLookup = lookup
+}
+
+// SetDedupWindow discards packets identical to one seen within the window (0 disables)
+func (pc *PacketCapture) SetDedupWindow(window time.Duration) {
+	if window <= 0 {
+		pc.dedup = nil
+		return
 	}
-	
-	if proto, ok := portMap[dstPort]; ok {
-		return proto
+	pc.dedup = newDeduplicator(window)
+}
+
+// SetHistory keeps each conversation's raw packets in h for pcap export, call it before Start
+func (pc *PacketCapture) SetHistory(h *history.History) {
+	pc.history = h
+}
+
+// SetTap offers every captured packet to t's subscribers, call it before Start
+func (pc *PacketCapture) SetTap(t *tap.Tap) {
+	pc.tap = t
+}
+
+// SetPcapWriter writes every captured packet to w, call it before Start
+func (pc *PacketCapture) SetPcapWriter(w *pcapwriter.Writer) {
+	pc.pcapOut = w
+}
+
+// SnapLen returns the maximum bytes captured per packet
+func (pc *PacketCapture) SnapLen() int {
+	pc.handleMu.Lock()
+	defer pc.handleMu.Unlock()
+	return pc.handle.SnapLen()
+}
+
+// LinkType returns the link layer type of the captured packets
+func (pc *PacketCapture) LinkType() layers.LinkType {
+	pc.handleMu.Lock()
+	defer pc.handleMu.Unlock()
+	return pc.handle.LinkType()
+}
+
+// SetDecoders replaces the application protocol decoders, call it before Start
+func (pc *PacketCapture) SetDecoders(r *parser.Registry) {
+	pc.decoders = r
+}
+
+// SetARPWatcher sets the watcher that ARP packets are passed to, and that
+// learns devices from ARP and neighbor discovery senders
+func (pc *PacketCapture) SetARPWatcher(w *arpwatch.Watcher) {
+	pc.arpWatcher = w
+}
+
+// processARP passes the sender of an ARP packet to the watcher and returns
+// an event for it. ARP events belong to no conversation.
+func (pc *PacketCapture) processARP(arpLayer *layers.ARP, f *frame) *models.NetworkEvent {
+	info, ok := arpwatch.Decode(arpLayer)
+	if !ok {
+		return nil
+	}
+	if pc.arpWatcher != nil {
+		pc.arpWatcher.Observe(info.SenderIP, info.SenderMAC, clock.In(f.info.Timestamp))
+	}
+	pc.stats.IncrementARP()
+
+	event := &models.NetworkEvent{
+		Timestamp:    pc.eventTime(f),
+		Interface:    pc.iface,
+		Direction:    models.DirectionUnknown,
+		Protocol:     "ARP",
+		SourceIP:     info.SenderIP,
+		DestIP:       info.TargetIP,
+		Size:         f.wireLength(),
+		CapturedSize: len(f.data),
+		ARP:          &info,
+	}
+	return event
+}
+
+// SetLeaseTable sets the table that learns DHCP leases, whose hostnames name
+// the local addresses DNS has no name for
+func (pc *PacketCapture) SetLeaseTable(t *dhcp.Table) {
+	pc.leases = t
+}
+
+// SetServiceInventory sets the inventory that learns the services devices
+// advertise over mDNS and SSDP, whose names name the local addresses DNS
+// and DHCP have no name for
+func (pc *PacketCapture) SetServiceInventory(inv *discovery.Inventory) {
+	pc.services = inv
+}
+
+// hostname returns the name of an address: the one DNS gives it, or failing
+// that the hostname its DHCP client gave, or the name it announces over mDNS
+func (pc *PacketCapture) hostname(ip string) string {
+	name := pc.dnsResolver.ResolveIP(ip)
+	if name == ip && pc.leases != nil {
+		if leased := pc.leases.Hostname(ip); leased != "" {
+			return leased
+		}
 	}
-	if proto, ok := portMap[srcPort]; ok {
-		return proto
+	if name == ip && pc.services != nil {
+		if announced := pc.services.Name(ip); announced != "" {
+			return announced
+		}
 	}
-	return ""
+	return name
+}
+
+// SetNDPMonitor sets the monitor that neighbor discovery messages are passed to
+func (pc *PacketCapture) SetNDPMonitor(m *ndp.Monitor) {
+	pc.ndpMonitor = m
+}
+
+// processNDP passes a neighbor discovery message to the monitor and teaches
+// the ARP watcher's devices the IPv6 addresses it binds, then returns an event for it
+func (pc *PacketCapture) processNDP(info models.NDPInfo, f *frame) *models.NetworkEvent {
+	at := clock.In(f.info.Timestamp)
+	if pc.ndpMonitor != nil {
+		pc.ndpMonitor.Observe(info, at)
+	}
+	if pc.arpWatcher != nil {
+		for ip, mac := range ndp.Bindings(info) {
+			pc.arpWatcher.Learn(mac, ip, at)
+		}
+	}
+	pc.stats.IncrementNDP()
+
+	event := &models.NetworkEvent{
+		Timestamp:         pc.eventTime(f),
+		Interface:         pc.iface,
+		Direction:         models.DirectionUnknown,
+		Protocol:          "IPv6",
+		TransportProtocol: "ICMPv6",
+		Size:              f.wireLength(),
+		CapturedSize:      len(f.data),
+		NDP:               &info,
+	}
+	if f.ip6 != nil {
+		event.SourceIP = f.ip6.SrcIP.String()
+		event.DestIP = f.ip6.DstIP.String()
+	}
+	return event
+}
+
+// eventTime returns the time stamped on a packet's event: the time it was
+// captured, so replays keep the recording's times and inter-packet timing
+// isn't skewed by how long packets waited to be processed. Packets without a
+// capture time are stamped now.
+func (pc *PacketCapture) eventTime(f *frame) time.Time {
+	if f.info.Timestamp.IsZero() {
+		return clock.Now()
+	}
+	return clock.In(f.info.Timestamp)
+}
+
+// tcpMSS returns the MSS option of a SYN segment, or 0 if absent
+func tcpMSS(tcp *layers.TCP) int {
+	for _, opt := range tcp.Options {
+		if opt.OptionType == layers.TCPOptionKindMSS && len(opt.OptionData) == 2 {
+			return int(binary.BigEndian.Uint16(opt.OptionData))
+		}
+	}
+	return 0
+}
+
+// splitTOS splits an IPv4 ToS or IPv6 traffic class byte into its DSCP and ECN fields
+func splitTOS(tos uint8) (dscp, ecn int) {
+	return int(tos >> 2), int(tos & 0x03)
+}
+
+// GetConfig returns the effective capture configuration
+func (pc *PacketCapture) GetConfig() map[string]interface{} {
+	tcpTimeout, udpTimeout := pc.convMgr.Timeouts()
+	retention, cleanupInterval := pc.convMgr.Retention()
+	config := map[string]interface{}{
+		"interface":         pc.iface,
+		"source":            "live",
+		"backend":           pc.backend,
+		"bpf_filter":        pc.filter,
+		"snaplen":           pc.live.Snaplen,
+		"promiscuous":       pc.live.Promiscuous,
+		"buffer_size":       pc.live.BufferSize,
+		"immediate":         pc.live.Immediate,
+		"read_timeout":      "block",
+		"tcp_idle_timeout":  tcpTimeout.String(),
+		"udp_idle_timeout":  udpTimeout.String(),
+		"conversation_retention": retention.String(),
+		"cleanup_interval":  cleanupInterval.String(),
+		"decoders":          pc.decoders.Enabled(),
+		"disabled_decoders": pc.decoders.Disabled(),
+		"dedup_window":      "0s",
+		"arp_watch":         pc.arpWatcher != nil,
+		"arp_devices":       pc.arpWatcher != nil && pc.arpWatcher.TracksDevices(),
+		"ndp_watch":         pc.ndpMonitor != nil,
+		"dhcp_leases":       pc.leases != nil,
+		"service_discovery": pc.services != nil,
+		"internet_only":     pc.internetOnly,
+		"sample_rate":       pc.SampleRate(),
+		"payload_bytes":     pc.payloadBytes,
+	}
+	if pc.dedup != nil {
+		config["dedup_window"] = pc.dedup.window.String()
+	}
+	if pc.replay != nil {
+		// A file keeps the snaplen it was recorded with and has no live read settings
+		pc.handleMu.Lock()
+		config["snaplen"] = pc.handle.SnapLen()
+		pc.handleMu.Unlock()
+		delete(config, "promiscuous")
+		delete(config, "buffer_size")
+		delete(config, "immediate")
+		delete(config, "read_timeout")
+		delete(config, "backend")
+		config["source"] = "file"
+		config["replay"] = pc.replay.config()
+	}
+	return config
+}
+
+// ResetStats zeroes the capture counters, returning the packets counted before
+func (pc *PacketCapture) ResetStats() int {
+	pc.resetKernelDrops()
+	return int(pc.stats.Reset())
+}
+
+// ClearResolverCache forgets every hostname resolved or learned so far,
+// returning how many addresses were named
+func (pc *PacketCapture) ClearResolverCache() int {
+	return pc.dnsResolver.Clear()
 }
 
 // GetStats returns packet capture statistics
 func (pc *PacketCapture) GetStats() map[string]interface{} {
-	return pc.stats.GetStats()
+	stats := pc.stats.GetStats()
+	stats["passive_dns_names"] = pc.dnsResolver.PassiveNames()
+	drops := pc.kernelDropped()
+	stats["kernel_dropped"] = drops.total()
+	stats["kernel_buffer_dropped"] = drops.buffer
+	stats["interface_dropped"] = drops.iface
+	stats["reassembly"] = pc.streams.GetStats()
+	if pc.decoders != nil {
+		stats["decoder_panics"] = pc.decoders.Panics()
+	}
+	return stats
 }
\ No newline at end of file
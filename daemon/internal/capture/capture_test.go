@@ -0,0 +1,66 @@
+package capture
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/conversation"
+	"github.com/iolloyd/netty/daemon/internal/parser"
+	"github.com/iolloyd/netty/daemon/internal/reassembly"
+	"github.com/iolloyd/netty/daemon/internal/resolver"
+)
+
+func TestProcessPacket_CaptureTimeAndLengths(t *testing.T) {
+	pc := &PacketCapture{
+		iface:       "eth0",
+		stats:       NewPacketStats(),
+		convMgr:     conversation.NewManager("10.0.0.1"),
+		dnsResolver: resolver.NewDNSResolver(time.Minute),
+		decoders:    parser.DefaultRegistry(),
+		streams:     reassembly.NewAssembler(),
+	}
+	data := tcpPacket(t, 1, 64, 1000).Data()
+	captured := time.Date(2025, 7, 1, 12, 0, 0, 123456789, time.UTC)
+
+	// A packet cut short by the snap length keeps its wire length
+	info := gopacket.CaptureInfo{Timestamp: captured, CaptureLength: len(data) - 2, Length: len(data) + 100}
+	event := pc.processPacket(newDecoder(layers.LinkTypeEthernet).decode(data[:len(data)-2], info))
+	if event == nil {
+		t.Fatal("Expected an event")
+	}
+	if !event.Timestamp.Equal(captured) {
+		t.Errorf("Expected the capture time %s, got %s", captured, event.Timestamp)
+	}
+	if event.Size != len(data)+100 || event.CapturedSize != len(data)-2 {
+		t.Errorf("Expected %d bytes on the wire and %d captured, got %d and %d", len(data)+100, len(data)-2, event.Size, event.CapturedSize)
+	}
+
+	// Packets without a capture time are stamped when processed
+	before := time.Now()
+	event = pc.processPacket(newDecoder(layers.LinkTypeEthernet).decode(data, gopacket.CaptureInfo{}))
+	if event.Timestamp.Before(before) || event.Size != len(data) || event.CapturedSize != len(data) {
+		t.Errorf("Expected a processing time and the captured length, got %s and %d/%d", event.Timestamp, event.Size, event.CapturedSize)
+	}
+}
+
+func BenchmarkProcessPacket(b *testing.B) {
+	pc := &PacketCapture{
+		iface:       "eth0",
+		stats:       NewPacketStats(),
+		convMgr:     conversation.NewManager("192.168.1.10"),
+		dnsResolver: resolver.NewDNSResolver(time.Minute),
+		decoders:    parser.DefaultRegistry(),
+		streams:     reassembly.NewAssembler(),
+	}
+	data := tcpPacket(b, 1, 64, 1000).Data()
+	info := gopacket.CaptureInfo{Timestamp: time.Now(), CaptureLength: len(data), Length: len(data)}
+	d := newDecoder(layers.LinkTypeEthernet)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pc.processPacket(d.decode(data, info))
+	}
+}
@@ -0,0 +1,45 @@
+package capture
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/conversation"
+	"github.com/iolloyd/netty/daemon/internal/parser"
+)
+
+func TestGetConfig(t *testing.T) {
+	decoders := parser.DefaultRegistry()
+	if err := decoders.Disable("http"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	pc := &PacketCapture{
+		iface:    "eth0",
+		filter:   "tcp port 443",
+		live:     LiveOptions{Snaplen: 1500, BufferSize: 8 << 20},
+		convMgr:  conversation.NewManager("10.0.0.1"),
+		decoders: decoders,
+	}
+	pc.SetDedupWindow(10 * time.Millisecond)
+
+	config := pc.GetConfig()
+	if config["interface"] != "eth0" || config["bpf_filter"] != "tcp port 443" || config["source"] != "live" {
+		t.Errorf("Unexpected capture settings: %v", config)
+	}
+	if config["snaplen"] != 1500 || config["promiscuous"] != false || config["buffer_size"] != 8<<20 || config["dedup_window"] != "10ms" || config["tcp_idle_timeout"] != "5m0s" || config["conversation_retention"] != "1h0m0s" {
+		t.Errorf("Unexpected limits and timeouts: %v", config)
+	}
+	if disabled := config["disabled_decoders"].([]string); len(disabled) != 1 || disabled[0] != "http" {
+		t.Errorf("Expected http to be reported as disabled, got %v", disabled)
+	}
+}
+
+func TestReplayerConfig(t *testing.T) {
+	config := newReplayer(ReplayOptions{Speed: 10}).config()
+	if config["speed"] != "10x" {
+		t.Errorf("Expected speed 10x, got %v", config["speed"])
+	}
+	if config := newReplayer(ReplayOptions{}).config(); config["speed"] != "max" {
+		t.Errorf("Expected speed max, got %v", config["speed"])
+	}
+}
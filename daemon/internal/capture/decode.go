@@ -0,0 +1,269 @@
+package capture
+
+import (
+	"errors"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/models"
+	"github.com/iolloyd/netty/daemon/internal/ndp"
+)
+
+// frame is a decoded packet: the layers found in it, nil when absent. Its
+// data and layers are only valid until the next packet is read.
+type frame struct {
+	data      []byte
+	info      gopacket.CaptureInfo
+	eth       *layers.Ethernet
+	arp       *layers.ARP
+	ip4       *layers.IPv4
+	ip6       *layers.IPv6
+	network   gopacket.NetworkLayer
+	transport gopacket.TransportLayer
+	ndp       gopacket.Layer        // A neighbor discovery message
+	fragment  bool                  // An IPv4 or IPv6 fragment
+	vlans     []uint16              // IDs of the frame's 802.1Q tags, outermost first
+	tunnel    *models.Encapsulation // The innermost tunnel the layers were decapsulated from
+	echo      bool                  // The sent copy of a loopback packet the any device also sees received
+}
+
+// watched reports whether the frame is ARP, neighbor discovery or DHCP, which
+// are decoded whether or not it's sampled
+func (f *frame) watched() bool {
+	if f.arp != nil || f.ndp != nil {
+		return true
+	}
+	udp, ok := f.transport.(*layers.UDP)
+	return ok && (udp.SrcPort == 67 || udp.SrcPort == 68 || udp.DstPort == 67 || udp.DstPort == 68)
+}
+
+// wireLength returns the packet's length on the wire, of which data may only
+// be the start
+func (f *frame) wireLength() int {
+	return wireLength(f.data, f.info)
+}
+
+// wireLength returns the length on the wire of a packet read as data
+func wireLength(data []byte, info gopacket.CaptureInfo) int {
+	if info.Length > len(data) {
+		return info.Length
+	}
+	return len(data)
+}
+
+// decoder decodes packets into layers it reuses from one packet to the next,
+// rather than allocating a gopacket.Packet and its layers for each. Link types
+// it has no parser for are decoded by gopacket.NewPacket.
+type decoder struct {
+	linkType layers.LinkType
+	parsers  map[gopacket.LayerType]*gopacket.DecodingLayerParser // By first layer
+	decoded  []gopacket.LayerType
+	frame    frame
+
+	eth     layers.Ethernet
+	dot1q   vlanTag
+	loop    layers.Loopback
+	sll     layers.LinuxSLL
+	arp     layers.ARP
+	ip4     ipv4Layer
+	ip6     ipv6Layer
+	ip6ext  layers.IPv6ExtensionSkipper
+	ip6frag ipv6Fragment
+	tcp     layers.TCP
+	udp     layers.UDP
+	sctp    layers.SCTP
+	gre     greLayer
+	vxlan   vxlanLayer
+	icmp6   layers.ICMPv6
+	ra      layers.ICMPv6RouterAdvertisement
+	rs      layers.ICMPv6RouterSolicitation
+	ns      layers.ICMPv6NeighborSolicitation
+	na      layers.ICMPv6NeighborAdvertisement
+	payload gopacket.Payload
+}
+
+func newDecoder(linkType layers.LinkType) *decoder {
+	return &decoder{linkType: linkType, parsers: make(map[gopacket.LayerType]*gopacket.DecodingLayerParser)}
+}
+
+// decode decodes a packet read from the capture
+func (d *decoder) decode(data []byte, info gopacket.CaptureInfo) *frame {
+	f := &d.frame
+	*f = frame{data: data, info: info}
+	d.dot1q.ids = d.dot1q.ids[:0]
+	first := d.firstLayer(data)
+	if first == gopacket.LayerTypeZero {
+		d.fromPacket(gopacket.NewPacket(data, d.linkType, gopacket.NoCopy))
+		return f
+	}
+
+	// A tunnel ends a decode and its payload is decoded as a packet of its
+	// own, the frame's layers being those of the innermost packet
+	for depth := 0; ; depth++ {
+		d.decodeLayers(first, data)
+		tunnel, inner, payload := d.tunnel()
+		if tunnel == nil || depth == maxTunnelDepth {
+			break
+		}
+		*f = frame{data: f.data, info: info, vlans: f.vlans, tunnel: tunnel}
+		first, data = inner, payload
+	}
+	return f
+}
+
+// decodeLayers decodes data starting with a layer type into the frame
+func (d *decoder) decodeLayers(first gopacket.LayerType, data []byte) {
+	f := &d.frame
+	// A decoding error leaves the layers decoded before it, as gopacket.NewPacket does
+	d.parser(first).DecodeLayers(data, &d.decoded)
+	sll := false
+	for _, layerType := range d.decoded {
+		switch layerType {
+		case layers.LayerTypeEthernet:
+			f.eth = &d.eth
+		case layers.LayerTypeLinuxSLL:
+			sll = true
+		case layers.LayerTypeDot1Q:
+			f.vlans = d.dot1q.ids
+		case layers.LayerTypeARP:
+			f.arp = &d.arp
+		case layers.LayerTypeIPv4:
+			f.ip4, f.network = &d.ip4.IPv4, &d.ip4.IPv4
+			f.fragment = d.ip4.Flags&layers.IPv4MoreFragments != 0 || d.ip4.FragOffset > 0
+		case layers.LayerTypeIPv6:
+			f.ip6, f.network = &d.ip6.IPv6, &d.ip6.IPv6
+		case layers.LayerTypeIPv6Fragment:
+			f.fragment = true
+		case layers.LayerTypeTCP:
+			f.transport = &d.tcp
+		case layers.LayerTypeUDP:
+			f.transport = &d.udp
+		case layers.LayerTypeSCTP:
+			f.transport = &d.sctp
+		case layers.LayerTypeICMPv6RouterAdvertisement:
+			f.ndp = &d.ra
+		case layers.LayerTypeICMPv6RouterSolicitation:
+			f.ndp = &d.rs
+		case layers.LayerTypeICMPv6NeighborSolicitation:
+			f.ndp = &d.ns
+		case layers.LayerTypeICMPv6NeighborAdvertisement:
+			f.ndp = &d.na
+		}
+	}
+	if sll && d.sll.PacketType == layers.LinuxSLLPacketTypeOutgoing {
+		f.echo = f.ip4 != nil && f.ip4.DstIP.IsLoopback() || f.ip6 != nil && f.ip6.DstIP.IsLoopback()
+	}
+}
+
+// firstLayer returns the layer a packet starts with, or LayerTypeZero when
+// there's no parser for its link type
+func (d *decoder) firstLayer(data []byte) gopacket.LayerType {
+	switch d.linkType {
+	case layers.LinkTypeEthernet:
+		return layers.LayerTypeEthernet
+	case layers.LinkTypeNull, layers.LinkTypeLoop:
+		return layers.LayerTypeLoopback
+	case layers.LinkTypeLinuxSLL:
+		return layers.LayerTypeLinuxSLL
+	case layers.LinkTypeRaw, layers.LinkTypeIPv4, layers.LinkTypeIPv6:
+		if len(data) > 0 && data[0]>>4 == 6 {
+			return layers.LayerTypeIPv6
+		}
+		return layers.LayerTypeIPv4
+	}
+	return gopacket.LayerTypeZero
+}
+
+// parser returns the parser for packets starting with a layer type
+func (d *decoder) parser(first gopacket.LayerType) *gopacket.DecodingLayerParser {
+	if parser, ok := d.parsers[first]; ok {
+		return parser
+	}
+	parser := gopacket.NewDecodingLayerParser(first,
+		&d.eth, &d.dot1q, &d.loop, &d.sll, &d.arp, &d.ip4, &d.ip6, &d.ip6ext,
+		&d.tcp, &d.udp, &d.sctp, &d.gre, &d.vxlan, &d.icmp6, &d.ra, &d.rs, &d.ns, &d.na, &d.payload)
+	// Fragments replace the extension skipper, what follows one is only part of a packet
+	parser.AddDecodingLayer(&d.ip6frag)
+	parser.IgnoreUnsupported = true
+	d.parsers[first] = parser
+	return parser
+}
+
+// fromPacket fills the frame in from a packet decoded by gopacket.NewPacket
+func (d *decoder) fromPacket(packet gopacket.Packet) {
+	f := &d.frame
+	f.eth, _ = packet.Layer(layers.LayerTypeEthernet).(*layers.Ethernet)
+	f.arp, _ = packet.Layer(layers.LayerTypeARP).(*layers.ARP)
+	f.ip4, _ = packet.Layer(layers.LayerTypeIPv4).(*layers.IPv4)
+	f.ip6, _ = packet.Layer(layers.LayerTypeIPv6).(*layers.IPv6)
+	f.network = packet.NetworkLayer()
+	f.transport = packet.TransportLayer()
+	for _, layer := range packet.Layers() {
+		if tag, ok := layer.(*layers.Dot1Q); ok {
+			d.dot1q.ids = append(d.dot1q.ids, tag.VLANIdentifier)
+			f.vlans = d.dot1q.ids
+		}
+	}
+	for _, t := range ndp.MessageTypes {
+		if msg := packet.Layer(t); msg != nil {
+			f.ndp = msg
+			break
+		}
+	}
+	if f.ip4 != nil {
+		f.fragment = f.ip4.Flags&layers.IPv4MoreFragments != 0 || f.ip4.FragOffset > 0
+	} else {
+		f.fragment = packet.Layer(layers.LayerTypeIPv6Fragment) != nil
+	}
+}
+
+// setVLANs records a frame's VLAN tags on its event: the innermost as the
+// VLAN, and the outermost too when there are more
+func setVLANs(event *models.NetworkEvent, vlans []uint16) {
+	if len(vlans) == 0 {
+		return
+	}
+	event.VLAN = int(vlans[len(vlans)-1])
+	if len(vlans) > 1 {
+		event.OuterVLAN = int(vlans[0])
+	}
+}
+
+// vlanTag decodes 802.1Q tags for a DecodingLayerParser, which decodes each
+// tag of a QinQ frame into the same layer. It keeps the ID of every tag.
+type vlanTag struct {
+	layers.Dot1Q
+	ids []uint16 // Outermost first
+}
+
+func (v *vlanTag) DecodeFromBytes(data []byte, df gopacket.DecodeFeedback) error {
+	if err := v.Dot1Q.DecodeFromBytes(data, df); err != nil {
+		return err
+	}
+	v.ids = append(v.ids, v.VLANIdentifier)
+	return nil
+}
+
+// ipv6Fragment decodes an IPv6 fragment header for a DecodingLayerParser,
+// which gopacket only decodes in gopacket.NewPacket
+type ipv6Fragment struct {
+	layers.BaseLayer
+}
+
+func (f *ipv6Fragment) DecodeFromBytes(data []byte, df gopacket.DecodeFeedback) error {
+	if len(data) < 8 {
+		df.SetTruncated()
+		return errors.New("IPv6 fragment header too short")
+	}
+	f.BaseLayer = layers.BaseLayer{Contents: data[:8], Payload: data[8:]}
+	return nil
+}
+
+func (f *ipv6Fragment) CanDecode() gopacket.LayerClass {
+	return layers.LayerTypeIPv6Fragment
+}
+
+// NextLayerType ends the decode, a fragment's payload can't be decoded alone
+func (f *ipv6Fragment) NextLayerType() gopacket.LayerType {
+	return gopacket.LayerTypeFragment
+}
@@ -0,0 +1,176 @@
+package capture
+
+import (
+	"net"
+	"testing"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func TestDecoder_Ethernet(t *testing.T) {
+	data := tcpPacket(t, 1, 64, 1000).Data()
+	d := newDecoder(layers.LinkTypeEthernet)
+	for i := 0; i < 2; i++ {
+		f := d.decode(data, gopacket.CaptureInfo{})
+		if f.eth == nil || f.ip4 == nil || f.network != f.ip4 || f.fragment {
+			t.Fatalf("Expected an unfragmented IPv4 packet over Ethernet, got %+v", f)
+		}
+		tcp, ok := f.transport.(*layers.TCP)
+		if !ok || tcp.DstPort != 443 || string(tcp.LayerPayload()) != "hello" {
+			t.Fatalf("Expected a TCP segment to port 443, got %+v", f.transport)
+		}
+	}
+
+	// Link types without a parser are decoded by gopacket
+	f := newDecoder(layers.LinkTypeFDDI).decode([]byte{0x50}, gopacket.CaptureInfo{})
+	if f.network != nil || f.transport != nil {
+		t.Errorf("Expected no layers in an undecodable packet, got %+v", f)
+	}
+}
+
+func TestDecoder_IPv6Fragment(t *testing.T) {
+	ip := &layers.IPv6{Version: 6, NextHeader: layers.IPProtocolIPv6Fragment, HopLimit: 64, SrcIP: net.ParseIP("2001:db8::1"), DstIP: net.ParseIP("2001:db8::2")}
+	frag := gopacket.Payload{byte(layers.IPProtocolUDP), 0, 0, 1, 0, 0, 0, 7, 0x13, 0x88, 0x00, 0x35}
+	buf := gopacket.NewSerializeBuffer()
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true}, ip, frag); err != nil {
+		t.Fatalf("Failed to build packet: %v", err)
+	}
+
+	f := newDecoder(layers.LinkTypeRaw).decode(buf.Bytes(), gopacket.CaptureInfo{})
+	if f.ip6 == nil || !f.fragment {
+		t.Fatalf("Expected an IPv6 fragment, got %+v", f)
+	}
+	if f.transport != nil {
+		t.Errorf("Expected a fragment's payload not to be decoded, got %+v", f.transport)
+	}
+}
+
+func TestDecoder_QinQ(t *testing.T) {
+	// Tag a packet with a service VLAN 100 around a customer VLAN 20
+	plain := tcpPacket(t, 1, 64, 1000).Data()
+	data := append([]byte(nil), plain[:12]...)
+	data = append(data, 0x88, 0xa8, 0x00, 100, 0x81, 0x00, 0x00, 20)
+	data = append(data, plain[12:]...)
+
+	d := newDecoder(layers.LinkTypeEthernet)
+	for i := 0; i < 2; i++ {
+		f := d.decode(data, gopacket.CaptureInfo{})
+		if f.ip4 == nil || f.transport == nil {
+			t.Fatalf("Expected the tagged TCP segment decoded, got %+v", f)
+		}
+		if len(f.vlans) != 2 || f.vlans[0] != 100 || f.vlans[1] != 20 {
+			t.Fatalf("Expected VLANs 100 and 20, got %v", f.vlans)
+		}
+		event := &models.NetworkEvent{}
+		setVLANs(event, f.vlans)
+		if event.VLAN != 20 || event.OuterVLAN != 100 {
+			t.Errorf("Expected VLAN 20 inside 100, got %d inside %d", event.VLAN, event.OuterVLAN)
+		}
+	}
+
+	// Untagged frames have none
+	if f := d.decode(plain, gopacket.CaptureInfo{}); len(f.vlans) != 0 {
+		t.Errorf("Expected no VLANs, got %v", f.vlans)
+	}
+}
+
+func TestDecoder_Tunnels(t *testing.T) {
+	inner := tcpPacket(t, 1, 64, 1000).Data() // Ethernet, 192.168.1.10 -> 203.0.113.7
+	outer4 := func(protocol layers.IPProtocol) *layers.IPv4 {
+		return &layers.IPv4{Version: 4, IHL: 5, TTL: 64, Protocol: protocol, SrcIP: net.IPv4(10, 0, 0, 1), DstIP: net.IPv4(10, 0, 0, 2)}
+	}
+	eth := &layers.Ethernet{SrcMAC: net.HardwareAddr{0, 1, 2, 3, 4, 5}, DstMAC: net.HardwareAddr{0, 1, 2, 3, 4, 6}, EthernetType: layers.EthernetTypeIPv4}
+	udp := &layers.UDP{SrcPort: 50000, DstPort: 4789}
+	udp.SetNetworkLayerForChecksum(outer4(layers.IPProtocolUDP))
+
+	tests := []struct {
+		name   string
+		layers []gopacket.SerializableLayer
+		want   models.Encapsulation
+	}{
+		{"vxlan", []gopacket.SerializableLayer{eth, outer4(layers.IPProtocolUDP), udp,
+			&layers.VXLAN{ValidIDFlag: true, VNI: 4242}, gopacket.Payload(inner)},
+			models.Encapsulation{Type: models.EncapsulationVXLAN, ID: 4242, OuterSource: "10.0.0.1", OuterDest: "10.0.0.2"}},
+		{"gre", []gopacket.SerializableLayer{eth, outer4(layers.IPProtocolGRE),
+			&layers.GRE{KeyPresent: true, Key: 7, Protocol: layers.EthernetTypeIPv4}, gopacket.Payload(inner[14:])},
+			models.Encapsulation{Type: models.EncapsulationGRE, ID: 7, OuterSource: "10.0.0.1", OuterDest: "10.0.0.2"}},
+		{"ipip", []gopacket.SerializableLayer{eth, outer4(layers.IPProtocolIPv4), gopacket.Payload(inner[14:])},
+			models.Encapsulation{Type: models.EncapsulationIPIP, OuterSource: "10.0.0.1", OuterDest: "10.0.0.2"}},
+	}
+	d := newDecoder(layers.LinkTypeEthernet)
+	for _, tt := range tests {
+		buf := gopacket.NewSerializeBuffer()
+		if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true}, tt.layers...); err != nil {
+			t.Fatalf("%s: failed to build packet: %v", tt.name, err)
+		}
+		f := d.decode(buf.Bytes(), gopacket.CaptureInfo{})
+		if f.tunnel == nil || *f.tunnel != tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.want, f.tunnel)
+			continue
+		}
+		tcp, ok := f.transport.(*layers.TCP)
+		if f.ip4 == nil || !f.ip4.SrcIP.Equal(net.IPv4(192, 168, 1, 10)) || !ok || tcp.DstPort != 443 {
+			t.Errorf("%s: expected the inner TCP segment, got %+v", tt.name, f)
+		}
+	}
+
+	// Plain traffic isn't tunnelled
+	if f := d.decode(inner, gopacket.CaptureInfo{}); f.tunnel != nil {
+		t.Errorf("Expected no tunnel, got %+v", f.tunnel)
+	}
+}
+
+func TestDecoder_Loopback(t *testing.T) {
+	ip4 := tcpPacket(t, 1, 64, 1000).Data()[14:]
+	udp := &layers.UDP{SrcPort: 40000, DstPort: 8080}
+	ip6 := &layers.IPv6{Version: 6, NextHeader: layers.IPProtocolUDP, HopLimit: 64, SrcIP: net.IPv6loopback, DstIP: net.IPv6loopback}
+	udp.SetNetworkLayerForChecksum(ip6)
+	buf := gopacket.NewSerializeBuffer()
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}, ip6, udp, gopacket.Payload("hello")); err != nil {
+		t.Fatalf("Failed to build packet: %v", err)
+	}
+
+	// BSD loopback headers carry the address family, in host order for NULL
+	// and network order for LOOP, and macOS numbers AF_INET6 30
+	tests := []struct {
+		linkType layers.LinkType
+		header   []byte
+		packet   []byte
+	}{
+		{layers.LinkTypeNull, []byte{2, 0, 0, 0}, ip4},
+		{layers.LinkTypeNull, []byte{30, 0, 0, 0}, buf.Bytes()},
+		{layers.LinkTypeLoop, []byte{0, 0, 0, 2}, ip4},
+	}
+	for _, tt := range tests {
+		f := newDecoder(tt.linkType).decode(append(append([]byte(nil), tt.header...), tt.packet...), gopacket.CaptureInfo{})
+		if f.network == nil || f.transport == nil {
+			t.Errorf("Expected %s framing to be decoded, got %+v", tt.linkType, f)
+		}
+	}
+}
+
+func TestDecoder_AnyDeviceEcho(t *testing.T) {
+	udp := &layers.UDP{SrcPort: 40000, DstPort: 8080}
+	ip := &layers.IPv4{Version: 4, IHL: 5, TTL: 64, Protocol: layers.IPProtocolUDP, SrcIP: net.IPv4(127, 0, 0, 1), DstIP: net.IPv4(127, 0, 0, 1)}
+	udp.SetNetworkLayerForChecksum(ip)
+	buf := gopacket.NewSerializeBuffer()
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}, ip, udp, gopacket.Payload("hello")); err != nil {
+		t.Fatalf("Failed to build packet: %v", err)
+	}
+	// A Linux cooked header: packet type, ARPHRD_LOOPBACK, no address, IPv4
+	sll := func(packetType byte) []byte {
+		header := []byte{0, packetType, 0x03, 0x04, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x08, 0x00}
+		return append(header, buf.Bytes()...)
+	}
+
+	d := newDecoder(layers.LinkTypeLinuxSLL)
+	if f := d.decode(sll(byte(layers.LinuxSLLPacketTypeHost)), gopacket.CaptureInfo{}); f.transport == nil || f.echo {
+		t.Errorf("Expected the received copy to be kept, got %+v", f)
+	}
+	if f := d.decode(sll(byte(layers.LinuxSLLPacketTypeOutgoing)), gopacket.CaptureInfo{}); !f.echo {
+		t.Errorf("Expected the sent copy to be an echo, got %+v", f)
+	}
+}
@@ -0,0 +1,91 @@
+package capture
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// dedupPayloadBytes is how much of the transport segment is hashed, enough to
+// tell packets apart without hashing whole jumbo frames
+const dedupPayloadBytes = 128
+
+// deduplicator drops copies of a packet seen again within a short window, as
+// produced by SPAN/mirror ports that see both directions of a switch or by
+// overlapping capture points. It is only used from the capture loop.
+type deduplicator struct {
+	window time.Duration
+	seen   map[uint64]time.Time
+	queue  []dedupEntry // Hashes in arrival order, for expiry
+}
+
+type dedupEntry struct {
+	hash uint64
+	at   time.Time
+}
+
+func newDeduplicator(window time.Duration) *deduplicator {
+	return &deduplicator{
+		window: window,
+		seen:   make(map[uint64]time.Time),
+	}
+}
+
+// duplicate reports whether the packet, given by its network layer, is a copy
+// of one seen within the window
+func (d *deduplicator) duplicate(network gopacket.NetworkLayer, at time.Time) bool {
+	// Forget hashes that fell out of the window
+	expired := 0
+	for expired < len(d.queue) && at.Sub(d.queue[expired].at) > d.window {
+		entry := d.queue[expired]
+		if d.seen[entry.hash].Equal(entry.at) {
+			delete(d.seen, entry.hash)
+		}
+		expired++
+	}
+	d.queue = d.queue[expired:]
+
+	hash, ok := packetHash(network)
+	if !ok {
+		return false
+	}
+	if last, seen := d.seen[hash]; seen && at.Sub(last) <= d.window {
+		return true
+	}
+	d.seen[hash] = at
+	d.queue = append(d.queue, dedupEntry{hash: hash, at: at})
+	return false
+}
+
+// packetHash identifies a packet by its addresses, IPv4 ID and the start of
+// its transport segment. Fields that change hop by hop (TTL, IP checksum, link
+// layer headers and VLAN tags) are left out so copies from different capture
+// points still match.
+func packetHash(network gopacket.NetworkLayer) (uint64, bool) {
+	h := fnv.New64a()
+	switch ip := network.(type) {
+	case *layers.IPv4:
+		h.Write(ip.SrcIP)
+		h.Write(ip.DstIP)
+		var id [3]byte
+		binary.BigEndian.PutUint16(id[:2], ip.Id)
+		id[2] = byte(ip.Protocol)
+		h.Write(id[:])
+	case *layers.IPv6:
+		h.Write(ip.SrcIP)
+		h.Write(ip.DstIP)
+		h.Write([]byte{byte(ip.NextHeader)})
+	default:
+		return 0, false
+	}
+
+	segment := network.LayerPayload()
+	if len(segment) > dedupPayloadBytes {
+		segment = segment[:dedupPayloadBytes]
+	}
+	h.Write(segment)
+	return h.Sum64(), true
+}
@@ -0,0 +1,48 @@
+package capture
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func tcpPacket(t testing.TB, id uint16, ttl uint8, seq uint32) gopacket.Packet {
+	t.Helper()
+	eth := &layers.Ethernet{SrcMAC: net.HardwareAddr{0, 1, 2, 3, 4, 5}, DstMAC: net.HardwareAddr{0, 1, 2, 3, 4, 6}, EthernetType: layers.EthernetTypeIPv4}
+	ip := &layers.IPv4{Version: 4, IHL: 5, Id: id, TTL: ttl, Protocol: layers.IPProtocolTCP, SrcIP: net.IPv4(192, 168, 1, 10), DstIP: net.IPv4(203, 0, 113, 7)}
+	tcp := &layers.TCP{SrcPort: 50000, DstPort: 443, Seq: seq, ACK: true, Window: 1024}
+	tcp.SetNetworkLayerForChecksum(ip)
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, eth, ip, tcp, gopacket.Payload("hello")); err != nil {
+		t.Fatalf("Failed to build packet: %v", err)
+	}
+	return gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+}
+
+func TestDeduplicator(t *testing.T) {
+	d := newDeduplicator(10 * time.Millisecond)
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	if d.duplicate(tcpPacket(t, 1, 64, 1000).NetworkLayer(), now) {
+		t.Fatal("Expected the first packet to be kept")
+	}
+	// The mirrored copy one hop later has a lower TTL but is the same packet
+	if !d.duplicate(tcpPacket(t, 1, 63, 1000).NetworkLayer(), now.Add(time.Millisecond)) {
+		t.Error("Expected a copy within the window to be a duplicate")
+	}
+	if d.duplicate(tcpPacket(t, 2, 64, 1000).NetworkLayer(), now.Add(2*time.Millisecond)) {
+		t.Error("Expected a packet with a different IP ID to be kept")
+	}
+	// A retransmission after the window is real traffic
+	if d.duplicate(tcpPacket(t, 1, 64, 1000).NetworkLayer(), now.Add(time.Second)) {
+		t.Error("Expected a repeat outside the window to be kept")
+	}
+	if len(d.queue) != 1 || len(d.seen) != 1 {
+		t.Errorf("Expected expired hashes to be forgotten, have %d queued and %d seen", len(d.queue), len(d.seen))
+	}
+}
@@ -0,0 +1,37 @@
+package capture
+
+import (
+	"bytes"
+	"net"
+
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// packetDirection returns which way a packet goes relative to this host's
+// addresses: from one of them it is outgoing, to one incoming. A packet
+// from another host to a multicast group or the broadcast address is
+// multicast or broadcast, anything else passes between two other hosts,
+// e.g. on a SPAN port, and its direction is unknown.
+func packetDirection(local models.LocalAddresses, f *frame, src, dst string) string {
+	switch {
+	case local.Contains(src):
+		return models.DirectionOutgoing
+	case local.Contains(dst):
+		return models.DirectionIncoming
+	}
+	var dstIP net.IP
+	switch {
+	case f.ip4 != nil:
+		dstIP = f.ip4.DstIP
+	case f.ip6 != nil:
+		dstIP = f.ip6.DstIP
+	}
+	switch {
+	case dstIP.IsMulticast():
+		return models.DirectionMulticast
+	case dstIP.Equal(net.IPv4bcast), f.eth != nil && bytes.Equal(f.eth.DstMAC, layers.EthernetBroadcast):
+		return models.DirectionBroadcast
+	}
+	return models.DirectionUnknown
+}
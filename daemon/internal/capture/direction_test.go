@@ -0,0 +1,62 @@
+package capture
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// udpFrame decodes a UDP packet between two addresses, IPv4 or IPv6
+func udpFrame(t *testing.T, src, dst string, dstMAC net.HardwareAddr) *frame {
+	t.Helper()
+	eth := &layers.Ethernet{SrcMAC: net.HardwareAddr{0, 1, 2, 3, 4, 5}, DstMAC: dstMAC, EthernetType: layers.EthernetTypeIPv4}
+	udp := &layers.UDP{SrcPort: 40000, DstPort: 40001}
+	var ip gopacket.SerializableLayer
+	if srcIP := net.ParseIP(src); srcIP.To4() != nil {
+		ip4 := &layers.IPv4{Version: 4, IHL: 5, TTL: 64, Protocol: layers.IPProtocolUDP, SrcIP: srcIP, DstIP: net.ParseIP(dst)}
+		udp.SetNetworkLayerForChecksum(ip4)
+		ip = ip4
+	} else {
+		eth.EthernetType = layers.EthernetTypeIPv6
+		ip6 := &layers.IPv6{Version: 6, HopLimit: 64, NextHeader: layers.IPProtocolUDP, SrcIP: srcIP, DstIP: net.ParseIP(dst)}
+		udp.SetNetworkLayerForChecksum(ip6)
+		ip = ip6
+	}
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, eth, ip, udp, gopacket.Payload("hello")); err != nil {
+		t.Fatalf("Failed to build packet: %v", err)
+	}
+	return newDecoder(layers.LinkTypeEthernet).decode(buf.Bytes(), gopacket.CaptureInfo{})
+}
+
+func TestPacketDirection(t *testing.T) {
+	local := models.NewLocalAddresses("192.168.1.10", "2001:db8::10")
+	unicast := net.HardwareAddr{0, 1, 2, 3, 4, 6}
+	tests := []struct {
+		src, dst string
+		dstMAC   net.HardwareAddr
+		want     string
+	}{
+		// High ports both ends, which the old port heuristics called unknown
+		{"192.168.1.10", "203.0.113.7", unicast, models.DirectionOutgoing},
+		{"203.0.113.7", "192.168.1.10", unicast, models.DirectionIncoming},
+		{"2606:4700::1111", "2001:db8::10", unicast, models.DirectionIncoming},
+		{"192.168.1.20", "224.0.0.251", unicast, models.DirectionMulticast},
+		{"fe80::1", "ff02::fb", unicast, models.DirectionMulticast},
+		{"192.168.1.20", "255.255.255.255", unicast, models.DirectionBroadcast},
+		{"192.168.1.20", "192.168.1.255", layers.EthernetBroadcast, models.DirectionBroadcast},
+		// This host's own multicast is outgoing
+		{"192.168.1.10", "224.0.0.251", unicast, models.DirectionOutgoing},
+		{"192.168.1.20", "192.168.1.30", unicast, models.DirectionUnknown},
+	}
+	for _, tt := range tests {
+		f := udpFrame(t, tt.src, tt.dst, tt.dstMAC)
+		if got := packetDirection(local, f, tt.src, tt.dst); got != tt.want {
+			t.Errorf("%s -> %s: expected %s, got %s", tt.src, tt.dst, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package capture
+
+import (
+	"fmt"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// Live capture backends, chosen with -backend. DefaultBackend is pcap, or
+// afpacket in builds without cgo and so without libpcap.
+const (
+	BackendPcap     = "pcap"     // libpcap, available everywhere but in builds without cgo
+	BackendAFPacket = "afpacket" // A TPACKET_V3 ring with a classic BPF filter attached to the socket, Linux only
+
+	// BackendEBPF is an alias of afpacket, the name the backend was asked
+	// for under. It loads no eBPF program and doesn't use AF_XDP: the filter
+	// is classic BPF.
+	BackendEBPF = "ebpf"
+)
+
+// canonicalBackend resolves a backend alias to the backend it names
+func canonicalBackend(backend string) string {
+	if backend == BackendEBPF {
+		return BackendAFPacket
+	}
+	return backend
+}
+
+// packetHandle is an open capture the loop reads packets from. The data of a
+// packet read is only valid until the next read or Close.
+type packetHandle interface {
+	gopacket.ZeroCopyPacketDataSource
+	LinkType() layers.LinkType
+	SnapLen() int
+	Drops() dropCounts // Packets dropped before they could be read, since opened
+	Close()
+}
+
+// ValidateBackend checks a -backend value
+func ValidateBackend(backend string) error {
+	switch canonicalBackend(backend) {
+	case BackendPcap:
+		return pcapAvailable()
+	case BackendAFPacket:
+		return ringAvailable()
+	}
+	return fmt.Errorf("unknown capture backend %q (use %s, or %s, also called %s)", backend, BackendPcap, BackendAFPacket, BackendEBPF)
+}
+
+// DefaultSnaplen is the bytes captured per packet unless -snaplen says otherwise
+const DefaultSnaplen = 65536
+
+// LiveOptions controls how an interface is opened for live capture
+type LiveOptions struct {
+	Snaplen     int  // Bytes captured per packet
+	Promiscuous bool // Also capture traffic not addressed to this host
+	BufferSize  int  // Bytes of kernel buffer packets wait in, 0 for the backend's default
+	Immediate   bool // Hand packets over as they arrive rather than in batches
+}
+
+// DefaultLiveOptions returns the settings used unless flags say otherwise
+func DefaultLiveOptions() LiveOptions {
+	return LiveOptions{Snaplen: DefaultSnaplen, Promiscuous: true}
+}
+
+// Validate checks the options' sizes
+func (o LiveOptions) Validate() error {
+	if o.Snaplen <= 0 || o.Snaplen > DefaultSnaplen {
+		return fmt.Errorf("snaplen %d out of range (1-%d)", o.Snaplen, DefaultSnaplen)
+	}
+	if o.BufferSize < 0 {
+		return fmt.Errorf("buffer size %d is negative", o.BufferSize)
+	}
+	return nil
+}
+
+// openLive opens an interface for capture with a backend and applies the BPF filter
+func openLive(backend, iface, filter string, options LiveOptions) (packetHandle, error) {
+	if backend == BackendAFPacket {
+		return openRing(iface, filter, options)
+	}
+	return openPcap(iface, filter, options)
+}
@@ -0,0 +1,72 @@
+package capture
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/iolloyd/netty/daemon/internal/conversation"
+	"github.com/iolloyd/netty/daemon/internal/parser"
+)
+
+func TestValidateBackend(t *testing.T) {
+	if err := ValidateBackend(DefaultBackend); err != nil && runtime.GOOS == "linux" {
+		t.Errorf("Expected the default backend to be valid, got %v", err)
+	}
+	if err := ValidateBackend("xdp"); err == nil {
+		t.Error("Expected an unknown backend to be refused")
+	}
+	err := ValidateBackend(BackendAFPacket)
+	if runtime.GOOS == "linux" && err != nil {
+		t.Errorf("Expected afpacket to be valid on Linux, got %v", err)
+	}
+	if runtime.GOOS != "linux" && err == nil {
+		t.Error("Expected afpacket to be refused off Linux")
+	}
+	if alias := ValidateBackend(BackendEBPF); (alias == nil) != (err == nil) {
+		t.Errorf("Expected ebpf to be validated as afpacket, got %v and %v", alias, err)
+	}
+	if backend := canonicalBackend(BackendEBPF); backend != BackendAFPacket {
+		t.Errorf("Expected ebpf to name afpacket, got %s", backend)
+	}
+}
+
+func TestNewPacketCaptureWithBackendErrors(t *testing.T) {
+	if _, err := NewPacketCaptureWithBackend("eth0", "", "10.0.0.1", "xdp"); err == nil {
+		t.Error("Expected an unknown backend to fail")
+	}
+	if _, err := NewPacketCaptureWithBackend("netty-missing0", "", "10.0.0.1", BackendAFPacket); err == nil {
+		t.Error("Expected a missing interface to fail")
+	}
+
+	// Without a backend the default is used, so builds without libpcap open the ring
+	_, err := NewPacketCapture("netty-missing0", "", "10.0.0.1")
+	if unavailable := ValidateBackend(BackendPcap); err == nil || unavailable != nil && err.Error() == unavailable.Error() {
+		t.Errorf("Expected the %s backend to fail on a missing interface, got %v", DefaultBackend, err)
+	}
+}
+
+func TestLiveOptionsValidate(t *testing.T) {
+	if err := DefaultLiveOptions().Validate(); err != nil {
+		t.Errorf("Expected the defaults to be valid, got %v", err)
+	}
+	for _, options := range []LiveOptions{
+		{Snaplen: 0},
+		{Snaplen: DefaultSnaplen + 1},
+		{Snaplen: 96, BufferSize: -1},
+	} {
+		if err := options.Validate(); err == nil {
+			t.Errorf("Expected %+v to be refused", options)
+		}
+	}
+}
+
+func TestGetConfigBackend(t *testing.T) {
+	pc := &PacketCapture{
+		backend:  BackendAFPacket,
+		convMgr:  conversation.NewManager("10.0.0.1"),
+		decoders: parser.DefaultRegistry(),
+	}
+	if config := pc.GetConfig(); config["backend"] != BackendAFPacket {
+		t.Errorf("Expected backend afpacket, got %v", config["backend"])
+	}
+}
@@ -0,0 +1,142 @@
+package capture
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// AnyDevice is Linux's pseudo-device capturing on every interface at once,
+// in Linux cooked (SLL) framing
+const AnyDevice = "any"
+
+// Device is a capture device together with the names users know it by
+type Device struct {
+	Name         string   // pcap device name, e.g. en0 or \Device\NPF_{GUID} on Windows
+	FriendlyName string   // OS interface name, e.g. en0 or "Ethernet" on Windows
+	Description  string   // Adapter description reported by pcap
+	Addresses    []net.IP // Addresses assigned to the device
+	Up           bool
+}
+
+// osInterface is the subset of net.Interface used to name pcap devices
+type osInterface struct {
+	Name  string
+	Addrs []net.IP
+	Up    bool
+}
+
+// ResolveDevice finds the capture device for a pcap name, OS interface name or
+// (on Windows) the bare adapter GUID. Names pcap doesn't know are used as-is.
+func ResolveDevice(name string) (Device, error) {
+	// The any device has no addresses of its own, every interface's are this host's
+	if name == AnyDevice {
+		device := Device{Name: name, FriendlyName: name, Description: "All interfaces", Up: true}
+		for _, iface := range osInterfaces() {
+			device.Addresses = append(device.Addresses, iface.Addrs...)
+		}
+		return device, nil
+	}
+
+	devices, err := ListDevices()
+	if err == nil {
+		if device, ok := findDevice(devices, name); ok {
+			return device, nil
+		}
+	}
+
+	// Fall back to the OS interface, which shares its name with the pcap device on Unix
+	for _, iface := range osInterfaces() {
+		if iface.Name == name {
+			return Device{Name: name, FriendlyName: name, Addresses: iface.Addrs, Up: iface.Up}, nil
+		}
+	}
+	return Device{}, fmt.Errorf("interface %s not found", name)
+}
+
+// IPv4 returns the device's first IPv4 address, or an empty string
+func (d Device) IPv4() string {
+	for _, ip := range d.Addresses {
+		if ip.To4() != nil {
+			return ip.String()
+		}
+	}
+	return ""
+}
+
+// LocalAddresses returns the device's IPv4 addresses, then its IPv6 ones,
+// including temporary addresses, the addresses treated as this host.
+// Loopback addresses come last, only first on a loopback device.
+func (d Device) LocalAddresses() []string {
+	var v4, v6, loopback []string
+	for _, ip := range d.Addresses {
+		switch {
+		case ip.IsLoopback():
+			loopback = append(loopback, ip.String())
+		case ip.To4() != nil:
+			v4 = append(v4, ip.String())
+		default:
+			v6 = append(v6, ip.String())
+		}
+	}
+	return append(append(v4, v6...), loopback...)
+}
+
+// DisplayName returns the pcap name with the friendly name when they differ
+func (d Device) DisplayName() string {
+	if d.FriendlyName != "" && d.FriendlyName != d.Name {
+		return fmt.Sprintf("%s (%s)", d.FriendlyName, d.Name)
+	}
+	return d.Name
+}
+
+// findDevice looks a device up by pcap name, friendly name or adapter GUID
+func findDevice(devices []Device, name string) (Device, bool) {
+	for _, device := range devices {
+		if device.Name == name || strings.EqualFold(device.FriendlyName, name) {
+			return device, true
+		}
+	}
+
+	guid := strings.Trim(name, "{}")
+	if guid == "" {
+		return Device{}, false
+	}
+	for _, device := range devices {
+		if strings.Contains(strings.ToUpper(device.Name), "{"+strings.ToUpper(guid)+"}") {
+			return device, true
+		}
+	}
+	return Device{}, false
+}
+
+func sharesAddress(a, b []net.IP) bool {
+	for _, ipA := range a {
+		for _, ipB := range b {
+			if ipA.Equal(ipB) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func osInterfaces() []osInterface {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+
+	result := make([]osInterface, 0, len(ifaces))
+	for _, iface := range ifaces {
+		entry := osInterface{Name: iface.Name, Up: iface.Flags&net.FlagUp != 0}
+		addrs, _ := iface.Addrs()
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok {
+				entry.Addrs = append(entry.Addrs, ipnet.IP)
+			}
+		}
+		result = append(result, entry)
+	}
+	return result
+}
@@ -0,0 +1,64 @@
+//go:build cgo || windows
+
+package capture
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/google/gopacket/pcap"
+)
+
+func TestMatchDevices_WindowsNaming(t *testing.T) {
+	pcapDevices := []pcap.Interface{
+		{
+			Name:        `\Device\NPF_{5A1B2C3D-0000-4E5F-8A9B-0123456789AB}`,
+			Description: "Intel(R) Ethernet Connection",
+			Addresses:   []pcap.InterfaceAddress{{IP: net.ParseIP("192.168.1.10")}},
+		},
+		{Name: `\Device\NPF_Loopback`, Description: "Adapter for loopback traffic capture"},
+	}
+	ifaces := []osInterface{
+		{Name: "Ethernet", Addrs: []net.IP{net.ParseIP("192.168.1.10")}, Up: true},
+	}
+
+	devices := matchDevices(pcapDevices, ifaces)
+	if devices[0].FriendlyName != "Ethernet" || !devices[0].Up || devices[0].IPv4() != "192.168.1.10" {
+		t.Errorf("Expected the NPF device to be named after its adapter, got %+v", devices[0])
+	}
+	if devices[1].FriendlyName != "" {
+		t.Errorf("Expected no friendly name without a shared address, got %q", devices[1].FriendlyName)
+	}
+
+	for _, name := range []string{"ethernet", "{5a1b2c3d-0000-4e5f-8a9b-0123456789ab}", "5A1B2C3D-0000-4E5F-8A9B-0123456789AB", pcapDevices[0].Name} {
+		device, ok := findDevice(devices, name)
+		if !ok || device.Name != pcapDevices[0].Name {
+			t.Errorf("Expected %q to resolve to the NPF device, got %+v", name, device)
+		}
+	}
+	if _, ok := findDevice(devices, "Wi-Fi"); ok {
+		t.Error("Expected unknown names not to resolve")
+	}
+}
+
+func TestMatchDevices_UnixNaming(t *testing.T) {
+	devices := matchDevices(
+		[]pcap.Interface{{Name: "en0"}},
+		[]osInterface{{Name: "en0", Up: true}},
+	)
+	if devices[0].FriendlyName != "en0" || devices[0].DisplayName() != "en0" {
+		t.Errorf("Expected matching names to stay as-is, got %+v", devices[0])
+	}
+}
+
+func TestDeviceLocalAddresses(t *testing.T) {
+	device := Device{Addresses: []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("fe80::1"), net.ParseIP("192.168.1.10"), net.ParseIP("::1")}}
+	if got := strings.Join(device.LocalAddresses(), ","); got != "192.168.1.10,fe80::1,127.0.0.1,::1" {
+		t.Errorf("Expected IPv4, IPv6 then loopback addresses, got %s", got)
+	}
+	loopback := Device{Addresses: []net.IP{net.ParseIP("::1"), net.ParseIP("127.0.0.1")}}
+	if got := loopback.LocalAddresses(); got[0] != "::1" {
+		t.Errorf("Expected a loopback device's own addresses, got %v", got)
+	}
+}
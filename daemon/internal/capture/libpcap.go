@@ -0,0 +1,130 @@
+//go:build cgo || windows
+
+package capture
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/gopacket/pcap"
+)
+
+// DefaultBackend is the live capture backend used unless -backend says otherwise
+const DefaultBackend = BackendPcap
+
+// pcapHandle is a libpcap handle, live or reading a file
+type pcapHandle struct {
+	*pcap.Handle
+}
+
+// Drops returns the packets the kernel buffer and interface dropped
+func (h pcapHandle) Drops() dropCounts {
+	stats, err := h.Stats()
+	if err != nil {
+		return dropCounts{}
+	}
+	return dropCounts{buffer: uint64(stats.PacketsDropped), iface: uint64(stats.PacketsIfDropped)}
+}
+
+// pcapAvailable reports whether the pcap backend can be used
+func pcapAvailable() error {
+	return nil
+}
+
+// openPcap opens an interface with libpcap and applies the BPF filter
+func openPcap(iface, filter string, options LiveOptions) (packetHandle, error) {
+	handle, err := activatePcap(iface, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open interface %s: %w", iface, err)
+	}
+	if filter != "" {
+		if err := handle.SetBPFFilter(filter); err != nil {
+			handle.Close()
+			return nil, fmt.Errorf("failed to set BPF filter: %w", err)
+		}
+	}
+	return pcapHandle{handle}, nil
+}
+
+// activatePcap configures an inactive handle with the options before
+// activating it, as the buffer size and immediate mode can't be set after
+func activatePcap(iface string, options LiveOptions) (*pcap.Handle, error) {
+	inactive, err := pcap.NewInactiveHandle(iface)
+	if err != nil {
+		return nil, err
+	}
+	defer inactive.CleanUp()
+
+	if err := inactive.SetSnapLen(options.Snaplen); err != nil {
+		return nil, err
+	}
+	if err := inactive.SetPromisc(options.Promiscuous); err != nil {
+		return nil, err
+	}
+	if err := inactive.SetTimeout(pcap.BlockForever); err != nil {
+		return nil, err
+	}
+	if options.BufferSize > 0 {
+		if err := inactive.SetBufferSize(options.BufferSize); err != nil {
+			return nil, err
+		}
+	}
+	if options.Immediate {
+		if err := inactive.SetImmediateMode(true); err != nil {
+			return nil, err
+		}
+	}
+	return inactive.Activate()
+}
+
+// openOffline opens a capture file and applies the BPF filter
+func openOffline(path, filter string) (packetHandle, error) {
+	handle, err := pcap.OpenOffline(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open capture file %s: %w", path, err)
+	}
+	if filter != "" {
+		if err := handle.SetBPFFilter(filter); err != nil {
+			handle.Close()
+			return nil, fmt.Errorf("failed to set BPF filter: %w", err)
+		}
+	}
+	return pcapHandle{handle}, nil
+}
+
+// isPcapTimeout reports whether a libpcap read returned without a packet
+func isPcapTimeout(err error) bool {
+	return errors.Is(err, pcap.NextErrorTimeoutExpired)
+}
+
+// ListDevices returns the capture devices pcap can open, annotated with OS interface names
+func ListDevices() ([]Device, error) {
+	pcapDevices, err := pcap.FindAllDevs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list capture devices: %w", err)
+	}
+	return matchDevices(pcapDevices, osInterfaces()), nil
+}
+
+// matchDevices names pcap devices after the OS interface sharing their addresses.
+// On Windows pcap devices are called \Device\NPF_{GUID} while the OS knows
+// them by names like "Ethernet", and addresses are the only common ground.
+func matchDevices(pcapDevices []pcap.Interface, ifaces []osInterface) []Device {
+	devices := make([]Device, 0, len(pcapDevices))
+	for _, pd := range pcapDevices {
+		device := Device{Name: pd.Name, Description: pd.Description}
+		for _, addr := range pd.Addresses {
+			device.Addresses = append(device.Addresses, addr.IP)
+		}
+
+		for _, iface := range ifaces {
+			if iface.Name == pd.Name || sharesAddress(device.Addresses, iface.Addrs) {
+				device.FriendlyName = iface.Name
+				device.Up = iface.Up
+				break
+			}
+		}
+		devices = append(devices, device)
+	}
+	return devices
+}
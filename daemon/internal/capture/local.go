@@ -0,0 +1,39 @@
+package capture
+
+import (
+	"net"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), also used by
+// overlay VPNs such as Tailscale; it isn't routed on the internet
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// SetInternetOnly discards events between local addresses (loopback, private,
+// link-local, multicast and broadcast), so only flows to or from the internet
+// reach conversations and clients
+func (pc *PacketCapture) SetInternetOnly(enabled bool) {
+	pc.internetOnly = enabled
+}
+
+// isInternetAddress reports whether ip is routed on the public internet
+func isInternetAddress(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || !parsed.IsGlobalUnicast() || parsed.IsPrivate() {
+		return false
+	}
+	if parsed.Equal(net.IPv4bcast) || sharedAddressSpace.Contains(parsed) {
+		return false
+	}
+	return true
+}
+
+// crossesInternet reports whether either end of an event is an internet address.
+// ARP and neighbor discovery never leave the LAN.
+func crossesInternet(event *models.NetworkEvent) bool {
+	if event.IsNeighborTraffic() {
+		return false
+	}
+	return isInternetAddress(event.SourceIP) || isInternetAddress(event.DestIP)
+}
@@ -0,0 +1,48 @@
+package capture
+
+import (
+	"testing"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func TestIsInternetAddress(t *testing.T) {
+	tests := map[string]bool{
+		"203.0.113.7":     true,
+		"2606:4700::1111": true,
+		"192.168.1.10":    false,
+		"10.0.0.1":        false,
+		"172.16.5.4":      false,
+		"127.0.0.1":       false,
+		"169.254.1.1":     false,
+		"100.101.102.103": false,
+		"224.0.0.251":     false,
+		"255.255.255.255": false,
+		"::1":             false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"ff02::fb":        false,
+		"":                false,
+	}
+	for ip, want := range tests {
+		if got := isInternetAddress(ip); got != want {
+			t.Errorf("isInternetAddress(%q) = %v, want %v", ip, got, want)
+		}
+	}
+}
+
+func TestCrossesInternet(t *testing.T) {
+	if !crossesInternet(&models.NetworkEvent{SourceIP: "192.168.1.10", DestIP: "203.0.113.7"}) {
+		t.Error("Expected a flow to a public address to cross the internet")
+	}
+	if crossesInternet(&models.NetworkEvent{SourceIP: "192.168.1.10", DestIP: "192.168.1.1"}) {
+		t.Error("Expected a LAN flow not to cross the internet")
+	}
+	if crossesInternet(&models.NetworkEvent{SourceIP: "192.168.1.10", DestIP: "192.168.1.1", ARP: &models.ARPInfo{}}) {
+		t.Error("Expected ARP never to cross the internet")
+	}
+	// Routers advertise from their global address too
+	if crossesInternet(&models.NetworkEvent{SourceIP: "2001:db8::1", DestIP: "ff02::1", NDP: &models.NDPInfo{}}) {
+		t.Error("Expected neighbor discovery never to cross the internet")
+	}
+}
@@ -0,0 +1,127 @@
+package capture
+
+import (
+	"log"
+	"time"
+)
+
+// dropCounts are packets lost before the capture could read them
+type dropCounts struct {
+	buffer uint64 // No room in the kernel's capture buffer or ring, libpcap's ps_drop
+	iface  uint64 // Dropped by the interface or its driver, libpcap's ps_ifdrop
+}
+
+func (d dropCounts) total() uint64 {
+	return d.buffer + d.iface
+}
+
+func (d dropCounts) add(o dropCounts) dropCounts {
+	return dropCounts{buffer: d.buffer + o.buffer, iface: d.iface + o.iface}
+}
+
+// since returns the drops after an earlier count, none for a counter that went back
+func (d dropCounts) since(earlier dropCounts) dropCounts {
+	var delta dropCounts
+	if d.buffer > earlier.buffer {
+		delta.buffer = d.buffer - earlier.buffer
+	}
+	if d.iface > earlier.iface {
+		delta.iface = d.iface - earlier.iface
+	}
+	return delta
+}
+
+// kernelDrops returns the packets a live handle's kernel buffer and interface
+// dropped before they could be read
+func kernelDrops(handle packetHandle) dropCounts {
+	if handle == nil {
+		return dropCounts{}
+	}
+	return handle.Drops()
+}
+
+// KernelDropped returns the packets the kernel dropped before the capture read
+// them since the counters were last reset. Replays read a file and drop nothing.
+func (pc *PacketCapture) KernelDropped() uint64 {
+	return pc.kernelDropped().total()
+}
+
+// KernelDrops splits KernelDropped into the packets the kernel's capture
+// buffer had no room for and those the interface dropped
+func (pc *PacketCapture) KernelDrops() (buffer, iface uint64) {
+	drops := pc.kernelDropped()
+	return drops.buffer, drops.iface
+}
+
+func (pc *PacketCapture) kernelDropped() dropCounts {
+	if pc.replay != nil {
+		return dropCounts{}
+	}
+	pc.handleMu.Lock()
+	defer pc.handleMu.Unlock()
+	total := pc.totalKernelDrops()
+	if pc.pause != nil {
+		// Drops while paused are discounted on resume
+		total = pc.pause.drops
+	}
+	return total.since(pc.kernelDropsBase)
+}
+
+// Loss returns what was lost before events left the capture: packets dropped
+// by the kernel and events dropped because the event channel was full
+func (pc *PacketCapture) Loss() (kernelDropped, channelDropped uint64) {
+	return pc.KernelDropped(), pc.stats.Dropped()
+}
+
+// WatchKernelDrops checks the kernel's drop counters every interval and logs a
+// warning when packets were dropped since the last check, as nothing else
+// shows them. Replays drop nothing.
+func (pc *PacketCapture) WatchKernelDrops(interval time.Duration) {
+	if pc.replay != nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		last := pc.kernelDropped()
+		for {
+			select {
+			case <-pc.stop:
+				return
+			case <-ticker.C:
+				drops := pc.kernelDropped()
+				if delta := drops.since(last); delta.total() > 0 {
+					log.Printf("[WARNING] Kernel dropped %d packets on %s in the last %s (%d for lack of buffer space, %d by the interface); events are a sample",
+						delta.total(), pc.iface, interval, delta.buffer, delta.iface)
+				}
+				last = drops
+			}
+		}
+	}()
+}
+
+// resetKernelDrops makes the kernel's drops so far the new zero
+func (pc *PacketCapture) resetKernelDrops() {
+	if pc.replay != nil {
+		return
+	}
+	pc.handleMu.Lock()
+	defer pc.handleMu.Unlock()
+	pc.kernelDropsBase = pc.totalKernelDrops()
+	if pc.pause != nil {
+		pc.pause.drops = pc.kernelDropsBase
+	}
+}
+
+// totalKernelDrops returns the kernel drops of every handle the capture has
+// used, must be called with handleMu held
+func (pc *PacketCapture) totalKernelDrops() dropCounts {
+	if pc.replay != nil {
+		return dropCounts{}
+	}
+	total := pc.kernelDropsRetired
+	if !pc.isClosed() {
+		total = total.add(kernelDrops(pc.handle))
+	}
+	return total
+}
@@ -0,0 +1,44 @@
+package capture
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/reassembly"
+	"github.com/iolloyd/netty/daemon/internal/resolver"
+)
+
+// dropHandle is a live handle that only reports drops
+type dropHandle struct {
+	packetHandle
+	drops dropCounts
+}
+
+func (h *dropHandle) Drops() dropCounts {
+	return h.drops
+}
+
+func TestKernelDrops(t *testing.T) {
+	handle := &dropHandle{drops: dropCounts{buffer: 5, iface: 2}}
+	pc := &PacketCapture{
+		stats:       NewPacketStats(),
+		stop:        make(chan struct{}),
+		handle:      handle,
+		dnsResolver: resolver.NewDNSResolver(time.Minute),
+		streams:     reassembly.NewAssembler(),
+	}
+	if buffer, iface := pc.KernelDrops(); buffer != 5 || iface != 2 || pc.KernelDropped() != 7 {
+		t.Errorf("Expected 5 buffer and 2 interface drops, got %d and %d", buffer, iface)
+	}
+	stats := pc.GetStats()
+	if stats["kernel_buffer_dropped"] != uint64(5) || stats["interface_dropped"] != uint64(2) || stats["kernel_dropped"] != uint64(7) {
+		t.Errorf("Expected the drops in the statistics, got %v", stats)
+	}
+
+	// A reset counts from the drops so far
+	pc.resetKernelDrops()
+	handle.drops.buffer = 8
+	if buffer, iface := pc.KernelDrops(); buffer != 3 || iface != 0 {
+		t.Errorf("Expected 3 buffer drops since the reset, got %d and %d", buffer, iface)
+	}
+}
@@ -0,0 +1,263 @@
+package capture
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Network change kinds, the reason a capture restarts
+const (
+	ChangeWake       = "wake"       // The system resumed from sleep
+	ChangeAddress    = "address"    // The capture interface's IPv4 address changed, e.g. roaming to another Wi-Fi network
+	ChangeInterfaces = "interfaces" // An interface with an address came up or went down, e.g. a VPN tunnel
+)
+
+// sleepThreshold is how far the wall clock must run ahead of the monotonic
+// clock, which stands still while the system sleeps, for a gap to be sleep
+const sleepThreshold = 5 * time.Second
+
+// NetworkChange describes why the capture restarted
+type NetworkChange struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Detail  string    `json:"detail"`             // e.g. "asleep for 2h5m0s" or "utun3 up"
+	LocalIP string    `json:"local_ip,omitempty"` // The capture interface's IPv4 address afterwards
+
+	// Every address of the capture interface afterwards, IPv4 then IPv6
+	LocalAddresses []string `json:"local_addresses,omitempty"`
+}
+
+// networkState is what the watch compares from one poll to the next
+type networkState struct {
+	addrs []string // IPv4 addresses of the capture interface
+	ipv6  []string // Its IPv6 addresses, which change without disturbing the capture
+	up    []string // Other interfaces that are up with an IPv4 address
+}
+
+// local returns the capture interface's addresses, IPv4 then IPv6
+func (s networkState) local() []string {
+	return append(append([]string(nil), s.addrs...), s.ipv6...)
+}
+
+// networkWatch notices the system waking and the network changing under the capture
+type networkWatch struct {
+	name  string // OS name of the capture interface
+	last  time.Time
+	state networkState
+	list  func() []osInterface
+}
+
+// WatchNetwork polls every interval for the system waking from sleep and
+// for network changes, and restarts the capture when one happens: pcap
+// handles often stop delivering packets after a wake or when the interface's
+// address changes. With followLocalIP the addresses treated as this host
+// follow the interface's, IPv6 ones such as rotating temporary addresses
+// included. Replays have no network to watch.
+func (pc *PacketCapture) WatchNetwork(interval time.Duration, followLocalIP bool) {
+	if pc.replay != nil {
+		return
+	}
+	name := pc.iface
+	if device, err := ResolveDevice(pc.iface); err == nil && device.FriendlyName != "" {
+		name = device.FriendlyName
+	}
+	w := newNetworkWatch(name, osInterfaces, time.Now())
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-pc.stop:
+				return
+			case now := <-ticker.C:
+				change, ok := w.poll(now, sleptFor(w.last, now))
+				if followLocalIP {
+					pc.followAddresses(w.state.local())
+				}
+				if ok {
+					pc.networkChanged(change)
+				}
+			}
+		}
+	}()
+}
+
+// followAddresses makes the interface's addresses the ones treated as this
+// host. Addresses are kept while the interface has none, e.g. Wi-Fi dropping.
+func (pc *PacketCapture) followAddresses(addrs []string) {
+	if len(addrs) == 0 || models.NewLocalAddresses(addrs...).Equal(pc.convMgr.LocalAddresses()) {
+		return
+	}
+	if previous := pc.convMgr.LocalIP(); addrs[0] != previous {
+		log.Printf("[INFO] Local IP changed from %s to %s", previous, addrs[0])
+	}
+	pc.convMgr.SetLocalAddresses(addrs...)
+}
+
+// networkChanged restarts the capture after a change and tells OnNetworkChange
+func (pc *PacketCapture) networkChanged(change NetworkChange) {
+	log.Printf("[INFO] Network change (%s: %s), restarting packet capture on %s", change.Kind, change.Detail, pc.iface)
+	pc.Restart(change.Kind)
+	if pc.OnNetworkChange != nil {
+		pc.OnNetworkChange(change)
+	}
+}
+
+// Restart closes the live handle so the capture loop reopens the interface
+func (pc *PacketCapture) Restart(reason string) {
+	if pc.replay != nil {
+		return
+	}
+	pc.packetMu.Lock()
+	defer pc.packetMu.Unlock()
+	pc.handleMu.Lock()
+	defer pc.handleMu.Unlock()
+	if pc.isClosed() || pc.handle == nil || pc.restartReason != "" {
+		return
+	}
+	pc.restartReason = reason
+	pc.kernelDropsRetired = pc.kernelDropsRetired.add(kernelDrops(pc.handle))
+	pc.handle.Close()
+	// A closed handle mustn't be asked for statistics, the loop replaces it
+	pc.handle = nil
+	pc.stats.IncrementRestarts()
+}
+
+// LocalIP returns the primary address treated as this host
+func (pc *PacketCapture) LocalIP() string {
+	return pc.convMgr.LocalIP()
+}
+
+// LocalAddresses returns every address treated as this host
+func (pc *PacketCapture) LocalAddresses() models.LocalAddresses {
+	return pc.convMgr.LocalAddresses()
+}
+
+// SetLocalAddresses sets the addresses treated as this host, IPv4 and IPv6,
+// the first being the primary one
+func (pc *PacketCapture) SetLocalAddresses(ips ...string) {
+	pc.convMgr.SetLocalAddresses(ips...)
+}
+
+// takeRestart returns why the handle was closed for a restart, or "" if it wasn't
+func (pc *PacketCapture) takeRestart() string {
+	pc.handleMu.Lock()
+	defer pc.handleMu.Unlock()
+	reason := pc.restartReason
+	pc.restartReason = ""
+	return reason
+}
+
+func newNetworkWatch(name string, list func() []osInterface, now time.Time) *networkWatch {
+	w := &networkWatch{name: name, last: now, list: list}
+	w.state = w.snapshot()
+	return w
+}
+
+// poll compares the network with the last poll, given how long the system
+// slept since, and returns the change if there was one
+func (w *networkWatch) poll(now time.Time, slept time.Duration) (NetworkChange, bool) {
+	w.last = now
+	previous := w.state
+	w.state = w.snapshot()
+
+	change := NetworkChange{Time: now}
+	if len(w.state.addrs) > 0 {
+		change.LocalIP = w.state.addrs[0]
+	}
+	change.LocalAddresses = w.state.local()
+	switch {
+	case slept > sleepThreshold:
+		change.Kind, change.Detail = ChangeWake, fmt.Sprintf("asleep for %s", slept.Round(time.Second))
+	case !equalStrings(previous.addrs, w.state.addrs):
+		change.Kind = ChangeAddress
+		change.Detail = fmt.Sprintf("%s address %s -> %s", w.name, orNone(previous.addrs), orNone(w.state.addrs))
+	case !equalStrings(previous.up, w.state.up):
+		change.Kind, change.Detail = ChangeInterfaces, interfaceChanges(previous.up, w.state.up)
+	default:
+		return NetworkChange{}, false
+	}
+	return change, true
+}
+
+// snapshot reads the interfaces' current state
+func (w *networkWatch) snapshot() networkState {
+	var state networkState
+	for _, iface := range w.list() {
+		var addrs, ipv6 []string
+		for _, ip := range iface.Addrs {
+			switch {
+			case ip.IsLoopback():
+			case ip.To4() != nil:
+				addrs = append(addrs, ip.String())
+			default:
+				ipv6 = append(ipv6, ip.String())
+			}
+		}
+		// The any device captures on every interface that is up
+		switch {
+		case iface.Name == w.name, w.name == AnyDevice && iface.Up:
+			state.addrs = append(state.addrs, addrs...)
+			state.ipv6 = append(state.ipv6, ipv6...)
+		case iface.Up && len(addrs) > 0:
+			state.up = append(state.up, iface.Name)
+		}
+	}
+	sort.Strings(state.addrs)
+	sort.Strings(state.ipv6)
+	sort.Strings(state.up)
+	return state
+}
+
+// sleptFor returns how much longer the wall clock ran than the monotonic clock
+// between two readings, the time the system spent asleep
+func sleptFor(last, now time.Time) time.Duration {
+	wall := now.Round(0).Sub(last.Round(0))
+	return wall - now.Sub(last)
+}
+
+// interfaceChanges describes interfaces coming up and going down, e.g. "utun3 up, en5 down"
+func interfaceChanges(before, after []string) string {
+	was := make(map[string]bool, len(before))
+	for _, name := range before {
+		was[name] = true
+	}
+	var changes []string
+	for _, name := range after {
+		if !was[name] {
+			changes = append(changes, name+" up")
+		}
+		delete(was, name)
+	}
+	for _, name := range before {
+		if was[name] {
+			changes = append(changes, name+" down")
+		}
+	}
+	return strings.Join(changes, ", ")
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func orNone(addrs []string) string {
+	if len(addrs) == 0 {
+		return "none"
+	}
+	return strings.Join(addrs, ",")
+}
@@ -0,0 +1,91 @@
+package capture
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/conversation"
+)
+
+func TestNetworkWatch_Poll(t *testing.T) {
+	ifaces := []osInterface{
+		{Name: "lo0", Addrs: []net.IP{net.ParseIP("127.0.0.1")}, Up: true},
+		{Name: "en0", Addrs: []net.IP{net.ParseIP("192.168.1.10"), net.ParseIP("fe80::1")}, Up: true},
+		{Name: "awdl0", Addrs: []net.IP{net.ParseIP("fe80::2")}, Up: true},
+	}
+	start := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	w := newNetworkWatch("en0", func() []osInterface { return ifaces }, start)
+
+	// Nothing changed, and IPv6 or loopback churn doesn't count
+	if change, ok := w.poll(start.Add(2*time.Second), 0); ok {
+		t.Errorf("Expected no change, got %+v", change)
+	}
+	ifaces[1].Addrs = append(ifaces[1].Addrs, net.ParseIP("2001:db8::5"))
+	if change, ok := w.poll(start.Add(3*time.Second), 0); ok {
+		t.Errorf("Expected a new IPv6 address not to restart the capture, got %+v", change)
+	}
+	if local := w.state.local(); strings.Join(local, ",") != "192.168.1.10,2001:db8::5,fe80::1" {
+		t.Errorf("Expected the IPv4 address first, then the IPv6 ones, got %v", local)
+	}
+
+	// Sleeping outweighs everything else
+	change, ok := w.poll(start.Add(4*time.Second), 90*time.Minute)
+	if !ok || change.Kind != ChangeWake || change.Detail != "asleep for 1h30m0s" || change.LocalIP != "192.168.1.10" {
+		t.Errorf("Expected a wake, got %+v", change)
+	}
+
+	// Roaming to another network changes the interface's address
+	ifaces[1].Addrs = []net.IP{net.ParseIP("10.0.0.7")}
+	change, ok = w.poll(start.Add(6*time.Second), 0)
+	if !ok || change.Kind != ChangeAddress || change.Detail != "en0 address 192.168.1.10 -> 10.0.0.7" || change.LocalIP != "10.0.0.7" {
+		t.Errorf("Expected an address change, got %+v", change)
+	}
+
+	// A VPN tunnel coming up, then going down
+	ifaces = append(ifaces, osInterface{Name: "utun3", Addrs: []net.IP{net.ParseIP("100.64.0.5")}, Up: true})
+	change, ok = w.poll(start.Add(8*time.Second), 0)
+	if !ok || change.Kind != ChangeInterfaces || change.Detail != "utun3 up" {
+		t.Errorf("Expected the tunnel to come up, got %+v", change)
+	}
+	ifaces[3].Up = false
+	change, ok = w.poll(start.Add(10*time.Second), 0)
+	if !ok || change.Kind != ChangeInterfaces || change.Detail != "utun3 down" {
+		t.Errorf("Expected the tunnel to go down, got %+v", change)
+	}
+
+	// Losing the address altogether, e.g. Wi-Fi dropping
+	ifaces[1].Addrs = nil
+	change, ok = w.poll(start.Add(12*time.Second), 0)
+	if !ok || change.Detail != "en0 address 10.0.0.7 -> none" || change.LocalIP != "" {
+		t.Errorf("Expected the address to be lost, got %+v", change)
+	}
+}
+
+func TestFollowAddresses(t *testing.T) {
+	pc := &PacketCapture{convMgr: conversation.NewManager("192.168.1.10")}
+	pc.followAddresses([]string{"192.168.1.10", "2001:db8::5", "2001:db8::6"})
+	if pc.LocalIP() != "192.168.1.10" || !pc.LocalAddresses().Contains("2001:db8::6") {
+		t.Errorf("Expected the IPv6 addresses followed, got %v", pc.LocalAddresses().List())
+	}
+
+	// The addresses are kept while the interface has none
+	pc.followAddresses(nil)
+	if len(pc.LocalAddresses()) != 3 {
+		t.Errorf("Expected the addresses kept, got %v", pc.LocalAddresses().List())
+	}
+}
+
+func TestSleptFor(t *testing.T) {
+	// Without sleep the wall and monotonic clocks agree
+	last := time.Now()
+	if slept := sleptFor(last, last.Add(2*time.Second)); slept != 0 {
+		t.Errorf("Expected no sleep, got %s", slept)
+	}
+	// Readings without a monotonic clock can't tell
+	wall := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	if slept := sleptFor(wall, wall.Add(time.Hour)); slept != 0 {
+		t.Errorf("Expected no sleep without a monotonic reading, got %s", slept)
+	}
+}
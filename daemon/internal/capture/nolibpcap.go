@@ -0,0 +1,139 @@
+//go:build !cgo && !windows
+
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/iolloyd/netty/daemon/internal/bpffilter"
+)
+
+// DefaultBackend is the live capture backend used unless -backend says
+// otherwise. Builds without cgo have no libpcap.
+const DefaultBackend = BackendAFPacket
+
+// pcapngMagic starts a pcapng file's section header block
+var pcapngMagic = []byte{0x0a, 0x0d, 0x0d, 0x0a}
+
+// fileHandle reads a pcap or pcapng file in pure Go, applying the filter in
+// userspace
+type fileHandle struct {
+	file     *os.File
+	source   gopacket.ZeroCopyPacketDataSource
+	linkType layers.LinkType
+	snaplen  int
+	filter   *bpffilter.Filter // nil passes every packet
+}
+
+// pcapAvailable reports whether the pcap backend can be used
+func pcapAvailable() error {
+	return fmt.Errorf("the %s capture backend needs libpcap, which builds without cgo lack", BackendPcap)
+}
+
+// openPcap fails, there is no libpcap without cgo
+func openPcap(iface, filter string, options LiveOptions) (packetHandle, error) {
+	return nil, pcapAvailable()
+}
+
+// openOffline opens a capture file and compiles the BPF filter
+func openOffline(path, filter string) (packetHandle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open capture file %s: %w", path, err)
+	}
+	h := &fileHandle{file: file}
+	if err := h.open(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open capture file %s: %w", path, err)
+	}
+	if filter != "" {
+		if h.filter, err = bpffilter.New(h.linkType, h.snaplen, filter); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to set BPF filter: %w", err)
+		}
+	}
+	return h, nil
+}
+
+// open reads the file header, telling pcap from pcapng by its magic number
+func (h *fileHandle) open() error {
+	buffered := bufio.NewReader(h.file)
+	magic, err := buffered.Peek(len(pcapngMagic))
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(magic, pcapngMagic) {
+		reader, err := pcapgo.NewNgReader(buffered, pcapgo.DefaultNgReaderOptions)
+		if err != nil {
+			return err
+		}
+		iface, err := reader.Interface(0)
+		if err != nil {
+			return err
+		}
+		h.source, h.linkType, h.snaplen = reader, reader.LinkType(), int(iface.SnapLength)
+		if h.snaplen == 0 {
+			h.snaplen = DefaultSnaplen // Unlimited
+		}
+		return nil
+	}
+	reader, err := pcapgo.NewReader(buffered)
+	if err != nil {
+		return err
+	}
+	h.source, h.linkType, h.snaplen = reader, reader.LinkType(), int(reader.Snaplen())
+	return nil
+}
+
+// ZeroCopyReadPacketData reads the next packet the filter accepts into a
+// buffer reused by the next read
+func (h *fileHandle) ZeroCopyReadPacketData() ([]byte, gopacket.CaptureInfo, error) {
+	for {
+		data, ci, err := h.source.ZeroCopyReadPacketData()
+		if err != nil || h.filter == nil || h.filter.Matches(data) {
+			return data, ci, err
+		}
+	}
+}
+
+// LinkType returns the link layer type of the file's packets
+func (h *fileHandle) LinkType() layers.LinkType {
+	return h.linkType
+}
+
+// SnapLen returns the snap length the file was recorded with
+func (h *fileHandle) SnapLen() int {
+	return h.snaplen
+}
+
+// Drops returns 0, files drop nothing
+func (h *fileHandle) Drops() dropCounts {
+	return dropCounts{}
+}
+
+// Close closes the file
+func (h *fileHandle) Close() {
+	h.file.Close()
+}
+
+// isPcapTimeout reports false, there are no libpcap reads without cgo
+func isPcapTimeout(err error) bool {
+	return false
+}
+
+// ListDevices returns the OS network interfaces, which the afpacket backend opens
+// by name
+func ListDevices() ([]Device, error) {
+	ifaces := osInterfaces()
+	devices := make([]Device, 0, len(ifaces))
+	for _, iface := range ifaces {
+		devices = append(devices, Device{Name: iface.Name, FriendlyName: iface.Name, Addresses: iface.Addrs, Up: iface.Up})
+	}
+	return devices, nil
+}
@@ -0,0 +1,71 @@
+package capture
+
+import (
+	"log"
+	"time"
+)
+
+// pauseState is set while the capture is paused
+type pauseState struct {
+	since  time.Time
+	resume chan struct{} // Closed by Resume
+	drops  dropCounts    // Kernel drops when the capture paused
+}
+
+// Pause stops the capture loop reading packets, freezing conversations and
+// statistics for investigation, without closing the handle. Packets arriving
+// meanwhile wait in the kernel's buffer, read on resume, until it fills and
+// the kernel drops the rest; those drops aren't counted as loss. It reports
+// false if already paused.
+func (pc *PacketCapture) Pause() bool {
+	pc.handleMu.Lock()
+	defer pc.handleMu.Unlock()
+	if pc.pause != nil || pc.isClosed() {
+		return false
+	}
+	pc.pause = &pauseState{since: time.Now(), resume: make(chan struct{}), drops: pc.totalKernelDrops()}
+	pc.stats.SetState(StatePaused)
+	log.Printf("[INFO] Packet capture on %s paused", pc.iface)
+	return true
+}
+
+// Resume lets a paused capture read packets again, reporting false if it wasn't paused
+func (pc *PacketCapture) Resume() bool {
+	pc.handleMu.Lock()
+	defer pc.handleMu.Unlock()
+	if pc.pause == nil {
+		return false
+	}
+	// Drops while paused were asked for, keep them out of the loss counters
+	pc.kernelDropsBase = pc.kernelDropsBase.add(pc.totalKernelDrops().since(pc.pause.drops))
+	close(pc.pause.resume)
+	log.Printf("[INFO] Packet capture on %s resumed after %s", pc.iface, time.Since(pc.pause.since).Round(time.Second))
+	pc.pause = nil
+	pc.stats.SetState(StateRunning)
+	return true
+}
+
+// Paused returns when the capture was paused, and whether it is
+func (pc *PacketCapture) Paused() (time.Time, bool) {
+	pc.handleMu.Lock()
+	defer pc.handleMu.Unlock()
+	if pc.pause == nil {
+		return time.Time{}, false
+	}
+	return pc.pause.since, true
+}
+
+// waitIfPaused holds the capture loop while paused, returning how long it waited
+func (pc *PacketCapture) waitIfPaused() time.Duration {
+	pc.handleMu.Lock()
+	pause := pc.pause
+	pc.handleMu.Unlock()
+	if pause == nil {
+		return 0
+	}
+	select {
+	case <-pause.resume:
+	case <-pc.stop:
+	}
+	return time.Since(pause.since)
+}
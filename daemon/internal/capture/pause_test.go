@@ -0,0 +1,50 @@
+package capture
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPauseAndResume(t *testing.T) {
+	pc := &PacketCapture{stats: NewPacketStats(), stop: make(chan struct{}), replay: newReplayer(ReplayOptions{Speed: 1})}
+	if waited := pc.waitIfPaused(); waited != 0 {
+		t.Errorf("Expected a running capture not to wait, waited %s", waited)
+	}
+
+	if !pc.Pause() || pc.Pause() {
+		t.Error("Expected only the first pause to take effect")
+	}
+	if _, paused := pc.Paused(); !paused || pc.stats.GetStats()["capture_state"] != StatePaused {
+		t.Errorf("Expected the capture to report paused, got %v", pc.stats.GetStats()["capture_state"])
+	}
+
+	resumed := make(chan time.Duration)
+	go func() { resumed <- pc.waitIfPaused() }()
+	select {
+	case <-resumed:
+		t.Fatal("Expected the capture loop to wait while paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if !pc.Resume() || pc.Resume() {
+		t.Error("Expected only the first resume to take effect")
+	}
+	if waited := <-resumed; waited < 20*time.Millisecond {
+		t.Errorf("Expected the wait to cover the pause, got %s", waited)
+	}
+	if pc.stats.GetStats()["capture_state"] != StateRunning {
+		t.Errorf("Expected the capture running again, got %v", pc.stats.GetStats()["capture_state"])
+	}
+
+	// A replay picks up where it left off instead of rushing through the pause
+	start := time.Now()
+	pc.replay.firstWall = start
+	pc.replay.delay(time.Minute)
+	if !pc.replay.firstWall.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected the replay schedule to move back a minute, got %s", pc.replay.firstWall.Sub(start))
+	}
+
+	// Closing releases a paused loop
+	pc.Pause()
+	pc.stopOnce.Do(func() { close(pc.stop) })
+	pc.waitIfPaused()
+}
@@ -0,0 +1,27 @@
+package capture
+
+import "fmt"
+
+// MaxPayloadBytes caps the payload kept per event, as every client is sent it
+const MaxPayloadBytes = 4096
+
+// SetPayloadBytes keeps the first n bytes of each packet's transport payload
+// in its event, to tell what an unknown flow carries. 0, the default, keeps
+// none. Call it before Start.
+func (pc *PacketCapture) SetPayloadBytes(n int) error {
+	if n < 0 || n > MaxPayloadBytes {
+		return fmt.Errorf("payload bytes must be between 0 and %d, got %d", MaxPayloadBytes, n)
+	}
+	pc.payloadBytes = n
+	return nil
+}
+
+// keepPayload copies the start of a packet's transport payload into its event.
+// The packet's data is reused for the next one, so the bytes are copied.
+func (pc *PacketCapture) keepPayload(payload []byte) []byte {
+	n := min(pc.payloadBytes, len(payload))
+	if n == 0 {
+		return nil
+	}
+	return append([]byte(nil), payload[:n]...)
+}
@@ -0,0 +1,50 @@
+package capture
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/conversation"
+	"github.com/iolloyd/netty/daemon/internal/parser"
+	"github.com/iolloyd/netty/daemon/internal/reassembly"
+	"github.com/iolloyd/netty/daemon/internal/resolver"
+)
+
+func TestPayloadBytes(t *testing.T) {
+	pc := &PacketCapture{
+		iface:       "eth0",
+		stats:       NewPacketStats(),
+		convMgr:     conversation.NewManager("192.168.1.10"),
+		dnsResolver: resolver.NewDNSResolver(time.Minute),
+		decoders:    parser.DefaultRegistry(),
+		streams:     reassembly.NewAssembler(),
+	}
+	for _, n := range []int{-1, MaxPayloadBytes + 1} {
+		if err := pc.SetPayloadBytes(n); err == nil {
+			t.Errorf("Expected %d payload bytes to be refused", n)
+		}
+	}
+	data := tcpPacket(t, 1, 64, 1000).Data()
+
+	// Off by default
+	if event := pc.processPacket(newDecoder(layers.LinkTypeEthernet).decode(data, gopacket.CaptureInfo{})); event.Payload != nil {
+		t.Errorf("Expected no payload kept by default, got %q", event.Payload)
+	}
+
+	if err := pc.SetPayloadBytes(3); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	d := newDecoder(layers.LinkTypeEthernet)
+	event := pc.processPacket(d.decode(data, gopacket.CaptureInfo{}))
+	if string(event.Payload) != "hel" || event.PayloadSize != 5 {
+		t.Fatalf("Expected the first 3 of 5 payload bytes, got %q of %d", event.Payload, event.PayloadSize)
+	}
+	// The packet's buffer is reused, the event keeps its own copy
+	copy(data[len(data)-5:], "xxxxx")
+	d.decode(data, gopacket.CaptureInfo{})
+	if string(event.Payload) != "hel" {
+		t.Errorf("Expected the payload copied, got %q", event.Payload)
+	}
+}
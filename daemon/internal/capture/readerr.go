@@ -0,0 +1,152 @@
+package capture
+
+import (
+	"errors"
+	"io"
+	"log"
+	"syscall"
+	"time"
+)
+
+// Capture loop states reported in the statistics
+const (
+	StateRunning    = "running"    // Reading packets
+	StateRecovering = "recovering" // The live handle failed and is being reopened
+	StateFinished   = "finished"   // A replay reached the end of its file
+	StateStopped    = "stopped"    // The capture was closed
+	StatePaused     = "paused"     // Reading was paused on request
+	StateFailed     = "failed"     // A replay hit an unrecoverable read error
+)
+
+// errReadTimeout is returned by handles whose reads wait a limited time, when
+// no packet arrived
+var errReadTimeout = errors.New("packet read timed out")
+
+// maxRecoveryBackoff caps the wait between attempts to reopen a failed interface
+const maxRecoveryBackoff = 30 * time.Second
+
+type readErrorKind int
+
+const (
+	readTimeout   readErrorKind = iota // No packet arrived in time, not an error
+	readTransient                      // Worth retrying on the same handle
+	readEOF                            // End of a capture file, or a live handle that was shut down
+	readFatal                          // The handle is unusable
+)
+
+// classifyReadError sorts a packet read error into how the capture loop should react
+func classifyReadError(err error) readErrorKind {
+	var netErr interface{ Temporary() bool }
+	switch {
+	case errors.Is(err, errReadTimeout), isPcapTimeout(err):
+		return readTimeout
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return readEOF
+	case errors.Is(err, syscall.EAGAIN), errors.Is(err, syscall.EINTR):
+		return readTransient
+	case errors.As(err, &netErr) && netErr.Temporary():
+		return readTransient
+	}
+	return readFatal
+}
+
+// handleReadError decides how the capture loop carries on after a failed read.
+// It returns the handle to keep reading from, or nil to stop.
+func (pc *PacketCapture) handleReadError(err error, handle packetHandle) packetHandle {
+	if pc.isClosed() {
+		pc.stats.SetState(StateStopped)
+		return nil
+	}
+	if reason := pc.takeRestart(); reason != "" {
+		// The handle was closed on purpose, reopen it without counting an error
+		log.Printf("[INFO] Reopening %s after %s", pc.iface, reason)
+		return pc.recoverHandle()
+	}
+
+	switch classifyReadError(err) {
+	case readTimeout:
+		return handle
+	case readTransient:
+		pc.stats.RecordReadError(err)
+		time.Sleep(10 * time.Millisecond)
+		return handle
+	case readEOF:
+		if pc.replay != nil {
+			pc.stats.SetState(StateFinished)
+			return nil
+		}
+		// A live handle only reaches EOF when it breaks underneath us
+	}
+
+	pc.stats.RecordReadError(err)
+	if pc.replay != nil {
+		log.Printf("[WARNING] Reading %s failed, stopping the replay: %v", pc.iface, err)
+		pc.stats.SetState(StateFailed)
+		return nil
+	}
+	log.Printf("[WARNING] Packet capture on %s failed: %v", pc.iface, err)
+	return pc.recoverHandle()
+}
+
+// recoverHandle reopens the live interface with a growing backoff until it
+// succeeds or the capture is closed
+func (pc *PacketCapture) recoverHandle() packetHandle {
+	pc.stats.SetState(StateRecovering)
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-pc.stop:
+			pc.stats.SetState(StateStopped)
+			return nil
+		case <-time.After(backoff):
+		}
+
+		handle, err := openLive(pc.backend, pc.iface, pc.filter, pc.live)
+		if err != nil {
+			pc.stats.RecordReadError(err)
+			log.Printf("[WARNING] Reopening %s failed (attempt %d, retrying in %s): %v", pc.iface, attempt, backoff, err)
+			if backoff *= 2; backoff > maxRecoveryBackoff {
+				backoff = maxRecoveryBackoff
+			}
+			continue
+		}
+		if !pc.swapHandle(handle) {
+			pc.stats.SetState(StateStopped)
+			return nil
+		}
+
+		pc.stats.IncrementRecoveries()
+		pc.stats.SetState(StateRunning)
+		log.Printf("[INFO] Packet capture on %s recovered after %d attempt(s)", pc.iface, attempt)
+		return handle
+	}
+}
+
+// swapHandle replaces the failed handle, unless the capture was closed meanwhile
+func (pc *PacketCapture) swapHandle(handle packetHandle) bool {
+	pc.packetMu.Lock()
+	defer pc.packetMu.Unlock()
+	pc.handleMu.Lock()
+	defer pc.handleMu.Unlock()
+	if pc.isClosed() {
+		handle.Close()
+		return false
+	}
+	if pc.handle != nil {
+		// The new handle's counters start from zero
+		pc.kernelDropsRetired = pc.kernelDropsRetired.add(kernelDrops(pc.handle))
+		pc.handle.Close()
+	}
+	pc.handle = handle
+	return true
+}
+
+// isClosed reports whether Close has been called
+func (pc *PacketCapture) isClosed() bool {
+	select {
+	case <-pc.stop:
+		return true
+	default:
+		return false
+	}
+}
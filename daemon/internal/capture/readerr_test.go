@@ -0,0 +1,65 @@
+//go:build cgo || windows
+
+package capture
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+
+	"github.com/google/gopacket/pcap"
+)
+
+func TestClassifyReadError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected readErrorKind
+	}{
+		{pcap.NextErrorTimeoutExpired, readTimeout},
+		{io.EOF, readEOF},
+		{fmt.Errorf("read: %w", syscall.EAGAIN), readTransient},
+		{pcap.NextErrorReadError, readFatal},
+		{errors.New("network is down"), readFatal},
+	}
+	for _, tt := range tests {
+		if kind := classifyReadError(tt.err); kind != tt.expected {
+			t.Errorf("%v: expected kind %d, got %d", tt.err, tt.expected, kind)
+		}
+	}
+}
+
+func TestHandleReadError(t *testing.T) {
+	var handle packetHandle = pcapHandle{}
+
+	replay := &PacketCapture{stats: NewPacketStats(), stop: make(chan struct{}), replay: newReplayer(ReplayOptions{})}
+	if next := replay.handleReadError(syscall.EAGAIN, handle); next != handle {
+		t.Error("Expected a transient error to keep reading from the same handle")
+	}
+	if next := replay.handleReadError(io.EOF, handle); next != nil {
+		t.Error("Expected the end of a replay to stop the loop")
+	}
+	stats := replay.stats.GetStats()
+	if stats["capture_state"] != StateFinished || stats["read_errors"].(uint64) != 1 {
+		t.Errorf("Expected a finished replay with one read error, got %v", stats)
+	}
+
+	failed := &PacketCapture{stats: NewPacketStats(), stop: make(chan struct{}), replay: newReplayer(ReplayOptions{})}
+	if next := failed.handleReadError(pcap.NextErrorReadError, handle); next != nil {
+		t.Error("Expected a fatal replay error to stop the loop")
+	}
+	if state := failed.stats.GetStats()["capture_state"]; state != StateFailed {
+		t.Errorf("Expected state %s, got %v", StateFailed, state)
+	}
+
+	// A closed live capture stops without trying to recover
+	closed := &PacketCapture{stats: NewPacketStats(), stop: make(chan struct{})}
+	closed.Close()
+	if next := closed.handleReadError(pcap.NextErrorReadError, handle); next != nil {
+		t.Error("Expected a closed capture to stop the loop")
+	}
+	if state := closed.stats.GetStats()["capture_state"]; state != StateStopped {
+		t.Errorf("Expected state %s, got %v", StateStopped, state)
+	}
+}
@@ -0,0 +1,142 @@
+package capture
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/clock"
+	"github.com/iolloyd/netty/daemon/internal/conversation"
+	"github.com/iolloyd/netty/daemon/internal/parser"
+	"github.com/iolloyd/netty/daemon/internal/reassembly"
+	"github.com/iolloyd/netty/daemon/internal/resolver"
+)
+
+// ReplayOptions controls how a capture file is replayed
+type ReplayOptions struct {
+	Speed float64   // Playback rate relative to the original timing, 0 replays as fast as possible
+	Start time.Time // Skip packets captured before this time (zero means from the beginning)
+	End   time.Time // Stop at the first packet captured after this time (zero means to the end)
+}
+
+// ParseSpeed parses a replay speed such as "1x", "10x", "0.5x" or "max"
+func ParseSpeed(value string) (float64, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "max" {
+		return 0, nil
+	}
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(value, "x"), 64)
+	if err != nil || speed <= 0 {
+		return 0, fmt.Errorf("invalid replay speed %q (use e.g. 1x, 10x or max)", value)
+	}
+	return speed, nil
+}
+
+// replayer paces packets from a capture file according to their original timestamps
+type replayer struct {
+	options   ReplayOptions
+	firstTS   time.Time // Timestamp of the first replayed packet
+	firstWall time.Time // Wall-clock time the first packet was replayed
+	current   atomic.Int64
+	sleep     func(time.Duration)
+}
+
+func newReplayer(options ReplayOptions) *replayer {
+	return &replayer{options: options, sleep: time.Sleep}
+}
+
+// admit decides what to do with a packet captured at ts: skip it, stop the
+// replay, or wait until it is due and replay it
+func (r *replayer) admit(ts time.Time) (replay bool, done bool) {
+	if !r.options.Start.IsZero() && ts.Before(r.options.Start) {
+		return false, false
+	}
+	if !r.options.End.IsZero() && ts.After(r.options.End) {
+		return false, true
+	}
+
+	if r.firstTS.IsZero() {
+		r.firstTS = ts
+		r.firstWall = time.Now()
+	} else if r.options.Speed > 0 {
+		due := r.firstWall.Add(time.Duration(float64(ts.Sub(r.firstTS)) / r.options.Speed))
+		if wait := time.Until(due); wait > 0 {
+			r.sleep(wait)
+		}
+	}
+
+	r.current.Store(ts.UnixNano())
+	return true, false
+}
+
+// delay moves the replay's schedule back by d, so a paused replay carries on
+// where it left off rather than rushing to catch up
+func (r *replayer) delay(d time.Duration) {
+	if !r.firstWall.IsZero() {
+		r.firstWall = r.firstWall.Add(d)
+	}
+}
+
+// config describes the replay options for the configuration API
+func (r *replayer) config() map[string]interface{} {
+	speed := "max"
+	if r.options.Speed > 0 {
+		speed = strconv.FormatFloat(r.options.Speed, 'g', -1, 64) + "x"
+	}
+	config := map[string]interface{}{"speed": speed}
+	if !r.options.Start.IsZero() {
+		config["start"] = clock.In(r.options.Start).Format(time.RFC3339)
+	}
+	if !r.options.End.IsZero() {
+		config["end"] = clock.In(r.options.End).Format(time.RFC3339)
+	}
+	return config
+}
+
+// now returns the capture time of the packet being replayed, so conversation
+// timeouts and durations follow the recording rather than the wall clock
+func (r *replayer) now() time.Time {
+	nanos := r.current.Load()
+	if nanos == 0 {
+		return clock.Now()
+	}
+	return clock.In(time.Unix(0, nanos))
+}
+
+// NewPacketCaptureFromFile creates a capture that replays packets from a pcap file
+func NewPacketCaptureFromFile(path, filter, localIP string, options ReplayOptions) (*PacketCapture, error) {
+	log.Printf("[DEBUG] Opening capture file: %s", path)
+	handle, err := openOffline(path, filter)
+	if err != nil {
+		return nil, err
+	}
+	return newReplayCapture(handle, path, filter, localIP, options), nil
+}
+
+// newReplayCapture creates a capture that replays packets read from handle
+func newReplayCapture(handle packetHandle, path, filter, localIP string, options ReplayOptions) *PacketCapture {
+	replay := newReplayer(options)
+
+	convMgr := conversation.NewManager(localIP)
+	convMgr.SetClock(replay.now)
+	convMgr.StartCleanupRoutine()
+
+	dnsResolver := resolver.NewDNSResolver(5 * time.Minute)
+	dnsResolver.StartCleanup(time.Minute)
+
+	return &PacketCapture{
+		handle:      handle,
+		iface:       path,
+		filter:      filter,
+		convMgr:     convMgr,
+		dnsResolver: dnsResolver,
+		stats:       NewPacketStats(),
+		replay:      replay,
+		decoders:    parser.DefaultRegistry(),
+		streams:     reassembly.NewAssembler(),
+		stop:        make(chan struct{}),
+	}
+}
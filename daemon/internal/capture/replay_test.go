@@ -0,0 +1,140 @@
+package capture
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+)
+
+// pcapgoHandle reads a capture file with pcapgo, so the test runs with or
+// without libpcap
+type pcapgoHandle struct {
+	packetHandle
+	file   *os.File
+	reader *pcapgo.Reader
+}
+
+func (h *pcapgoHandle) ZeroCopyReadPacketData() ([]byte, gopacket.CaptureInfo, error) {
+	return h.reader.ZeroCopyReadPacketData()
+}
+
+func (h *pcapgoHandle) LinkType() layers.LinkType { return h.reader.LinkType() }
+func (h *pcapgoHandle) SnapLen() int              { return int(h.reader.Snaplen()) }
+func (h *pcapgoHandle) Drops() dropCounts         { return dropCounts{} }
+func (h *pcapgoHandle) Close()                    { h.file.Close() }
+
+func TestParseSpeed(t *testing.T) {
+	for input, expected := range map[string]float64{"1x": 1, "10x": 10, "0.5x": 0.5, "4": 4, "max": 0, "MAX": 0} {
+		speed, err := ParseSpeed(input)
+		if err != nil || speed != expected {
+			t.Errorf("ParseSpeed(%q) = %v, %v; expected %v", input, speed, err, expected)
+		}
+	}
+	for _, input := range []string{"", "fast", "0x", "-2x"} {
+		if _, err := ParseSpeed(input); err == nil {
+			t.Errorf("Expected ParseSpeed(%q) to fail", input)
+		}
+	}
+}
+
+func TestReplayer_WindowAndPacing(t *testing.T) {
+	base := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	r := newReplayer(ReplayOptions{Speed: 10, Start: base.Add(time.Second), End: base.Add(time.Minute)})
+	var slept time.Duration
+	r.sleep = func(d time.Duration) { slept += d }
+
+	if replay, done := r.admit(base); replay || done {
+		t.Error("Expected packets before the window to be skipped")
+	}
+	if replay, _ := r.admit(base.Add(time.Second)); !replay {
+		t.Error("Expected the first packet in the window to be replayed")
+	}
+
+	// 20s of recording at 10x should wait about 2s
+	if replay, _ := r.admit(base.Add(21 * time.Second)); !replay {
+		t.Error("Expected packets inside the window to be replayed")
+	}
+	if slept < 1900*time.Millisecond || slept > 2*time.Second {
+		t.Errorf("Expected a ~2s wait at 10x, got %s", slept)
+	}
+	if !r.now().Equal(base.Add(21 * time.Second)) {
+		t.Errorf("Expected the replay clock to follow packet timestamps, got %s", r.now())
+	}
+
+	if _, done := r.admit(base.Add(2 * time.Minute)); !done {
+		t.Error("Expected the replay to stop after the window")
+	}
+}
+
+func TestReplayer_MaxSpeedNeverSleeps(t *testing.T) {
+	r := newReplayer(ReplayOptions{})
+	r.sleep = func(d time.Duration) { t.Errorf("Unexpected sleep of %s", d) }
+
+	base := time.Now()
+	r.admit(base)
+	r.admit(base.Add(time.Hour))
+}
+
+func TestReplay_FileToEvents(t *testing.T) {
+	base := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "capture.pcap")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := pcapgo.NewWriter(file)
+	if err := writer.WriteFileHeader(65535, layers.LinkTypeEthernet); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		data := tcpPacket(t, uint16(i+1), 64, uint32(1000+5*i)).Data()
+		info := gopacket.CaptureInfo{Timestamp: base.Add(time.Duration(i) * time.Second), CaptureLength: len(data), Length: len(data)}
+		if err := writer.WritePacket(info, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file.Close()
+
+	file, err = os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := pcapgo.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pc := newReplayCapture(&pcapgoHandle{file: file, reader: reader}, path, "", "192.168.1.10", ReplayOptions{})
+	defer pc.Close()
+
+	// The channel closes once the file is read to the end
+	var timestamps []time.Time
+	conversations := make(map[string]bool)
+	for event := range pc.Start() {
+		if event.SourceIP != "192.168.1.10" || event.DestIP != "203.0.113.7" || event.SourcePort != 50000 || event.DestPort != 443 ||
+			event.TransportProtocol != "TCP" || event.Direction != "outgoing" || event.PayloadSize != 5 || event.DSCPClass != "CS0" {
+			t.Errorf("Unexpected event: %+v", event)
+		}
+		timestamps = append(timestamps, event.Timestamp)
+		conversations[event.ConversationID] = true
+	}
+
+	if len(timestamps) != 3 {
+		t.Fatalf("Expected an event per packet, got %d", len(timestamps))
+	}
+	for i, ts := range timestamps {
+		if !ts.Equal(base.Add(time.Duration(i) * time.Second)) {
+			t.Errorf("Expected event %d at its capture time, got %s", i, ts)
+		}
+	}
+	if len(conversations) != 1 || conversations[""] {
+		t.Errorf("Expected the packets in one conversation, got %v", conversations)
+	}
+	if stats := pc.GetStats(); stats["total_packets"] != uint64(3) {
+		t.Errorf("Expected 3 packets counted, got %v", stats["total_packets"])
+	}
+}
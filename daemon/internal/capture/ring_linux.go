@@ -0,0 +1,308 @@
+package capture
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/bpffilter"
+	"golang.org/x/net/bpf"
+	"golang.org/x/sys/unix"
+)
+
+// Ring settings for the afpacket backend: by default 64 blocks of 1MiB hold
+// about a second of a busy 1Gbit/s link while the loop catches up
+const (
+	ringFrameSize    = DefaultSnaplen
+	ringBlockSize    = 1 << 20
+	ringBlocks       = 64
+	ringBlockTimeout = 10 * time.Millisecond  // How long the kernel fills a block before handing it over
+	ringPollTimeout  = 100 * time.Millisecond // How long a read waits, so Close isn't held up
+)
+
+// Offsets into the TPACKET_V3 block descriptor and packet headers
+const (
+	blockStatusOffset    = 8  // tpacket_block_desc.hdr.bh1.block_status
+	blockPacketsOffset   = 12 // num_pkts
+	blockFirstOffset     = 16 // offset_to_first_pkt
+	packetNextOffset     = 0  // tpacket3_hdr.tp_next_offset
+	packetSecOffset      = 4  // tp_sec
+	packetNsecOffset     = 8  // tp_nsec
+	packetSnapOffset     = 12 // tp_snaplen
+	packetLenOffset      = 16 // tp_len
+	packetStatusOffset   = 20 // tp_status
+	packetMacOffset      = 24 // tp_mac
+	packetVLANOffset     = 32 // hv1.tp_vlan_tci
+	packetVLANTPIDOffset = 36 // hv1.tp_vlan_tpid
+)
+
+// ringHandle reads packets from a memory-mapped TPACKET_V3 AF_PACKET ring,
+// in pure Go so it works in builds without cgo. The BPF filter is attached
+// to the socket, so the kernel discards unwanted packets before they are
+// copied into the ring.
+type ringHandle struct {
+	mu       sync.RWMutex // Held for reading while the ring is in use, Close waits for it
+	fd       int          // -1 once closed
+	ring     []byte
+	ifindex  int
+	linkType layers.LinkType
+	snaplen  int
+	blocks   int    // Blocks in the ring
+	drops    uint64 // Kernel drops so far; reading the counters resets them
+
+	// Position in the ring, only used by the reading goroutine
+	block     int    // Block being read
+	remaining int    // Packets left in it, 0 when waiting for the kernel
+	offset    int    // Offset of the next packet in the block
+	release   bool   // The block is done with once the last packet read is
+	buf       []byte // Holds packets whose VLAN tag is put back
+}
+
+// ringAvailable reports whether the afpacket backend can be used
+func ringAvailable() error {
+	return nil
+}
+
+// openRing opens an AF_PACKET ring on an interface and attaches the filter
+func openRing(iface, filter string, options LiveOptions) (packetHandle, error) {
+	// Packets from every interface would arrive with different link layers
+	if iface == AnyDevice {
+		return nil, fmt.Errorf("the %s device needs the %s backend", AnyDevice, BackendPcap)
+	}
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open interface %s: %w", iface, err)
+	}
+	// Interfaces without a link layer, such as tunnels, deliver bare IP packets
+	linkType := layers.LinkTypeEthernet
+	if len(ifi.HardwareAddr) == 0 && ifi.Flags&net.FlagLoopback == 0 {
+		linkType = layers.LinkTypeRaw
+	}
+
+	// The socket receives nothing until it is bound, so no packet reaches
+	// the ring before the filter is attached
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open interface %s: %w", iface, err)
+	}
+	h := &ringHandle{fd: fd, ifindex: ifi.Index, linkType: linkType, snaplen: options.Snaplen, blocks: ringBlocks}
+	if options.BufferSize > 0 {
+		h.blocks = (options.BufferSize + ringBlockSize - 1) / ringBlockSize
+	}
+	if err := h.setup(filter, options); err != nil {
+		h.Close()
+		return nil, fmt.Errorf("failed to open interface %s: %w", iface, err)
+	}
+	return h, nil
+}
+
+// setup attaches the filter, maps the ring and binds the socket to the interface
+func (h *ringHandle) setup(filter string, options LiveOptions) error {
+	// The kernel captures as many bytes of a packet as the filter returns, so
+	// without a filter a program accepting every packet cuts them at the snaplen
+	program, err := bpf.Assemble([]bpf.Instruction{bpf.RetConstant{Val: uint32(h.snaplen)}})
+	if filter != "" {
+		program, err = bpffilter.Compile(h.linkType, h.snaplen, filter)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set BPF filter: %w", err)
+	}
+	if h.snaplen < DefaultSnaplen || filter != "" {
+		prog := unix.SockFprog{
+			Len:    uint16(len(program)),
+			Filter: (*unix.SockFilter)(unsafe.Pointer(&program[0])),
+		}
+		if err := unix.SetsockoptSockFprog(h.fd, unix.SOL_SOCKET, unix.SO_ATTACH_FILTER, &prog); err != nil {
+			return fmt.Errorf("failed to set BPF filter: %w", err)
+		}
+	}
+
+	if err := unix.SetsockoptInt(h.fd, unix.SOL_PACKET, unix.PACKET_VERSION, unix.TPACKET_V3); err != nil {
+		return fmt.Errorf("TPACKET_V3 not supported: %w", err)
+	}
+	// Immediate mode hands blocks over after the shortest timeout the kernel takes
+	timeout := ringBlockTimeout
+	if options.Immediate {
+		timeout = time.Millisecond
+	}
+	req := unix.TpacketReq3{
+		Block_size:     ringBlockSize,
+		Block_nr:       uint32(h.blocks),
+		Frame_size:     ringFrameSize,
+		Frame_nr:       uint32(ringBlockSize / ringFrameSize * h.blocks),
+		Retire_blk_tov: uint32(timeout / time.Millisecond),
+	}
+	if err := unix.SetsockoptTpacketReq3(h.fd, unix.SOL_PACKET, unix.PACKET_RX_RING, &req); err != nil {
+		return fmt.Errorf("failed to create the packet ring: %w", err)
+	}
+	ring, err := unix.Mmap(h.fd, 0, ringBlockSize*h.blocks, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+	if err != nil {
+		return fmt.Errorf("failed to map the packet ring: %w", err)
+	}
+	h.ring = ring
+
+	if options.Promiscuous {
+		mreq := unix.PacketMreq{Ifindex: int32(h.ifindex), Type: unix.PACKET_MR_PROMISC}
+		if err := unix.SetsockoptPacketMreq(h.fd, unix.SOL_PACKET, unix.PACKET_ADD_MEMBERSHIP, &mreq); err != nil {
+			return fmt.Errorf("failed to enable promiscuous mode: %w", err)
+		}
+	}
+	return unix.Bind(h.fd, &unix.SockaddrLinklayer{Protocol: htons(unix.ETH_P_ALL), Ifindex: h.ifindex})
+}
+
+// ZeroCopyReadPacketData reads the next packet, returning data in the ring
+// that is only valid until the next read, or errReadTimeout when none arrived
+// within ringPollTimeout
+func (h *ringHandle) ZeroCopyReadPacketData() ([]byte, gopacket.CaptureInfo, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.fd == -1 {
+		return nil, gopacket.CaptureInfo{}, io.EOF
+	}
+
+	// The kernel may overwrite a block once it's handed back, so the block
+	// holding the previous packet is only released now
+	if h.release {
+		h.release = false
+		h.releaseBlock()
+	}
+	for h.remaining == 0 {
+		status := h.blockStatus()
+		if atomic.LoadUint32(status)&unix.TP_STATUS_USER != 0 {
+			base := h.block * ringBlockSize
+			h.remaining = int(h.uint32At(base + blockPacketsOffset))
+			h.offset = int(h.uint32At(base + blockFirstOffset))
+			if h.remaining == 0 {
+				h.releaseBlock()
+			}
+			continue
+		}
+		if err := h.poll(); err != nil {
+			return nil, gopacket.CaptureInfo{}, err
+		}
+	}
+
+	packet := h.block*ringBlockSize + h.offset
+	caplen := int(h.uint32At(packet + packetSnapOffset))
+	start := packet + int(binary.NativeEndian.Uint16(h.ring[packet+packetMacOffset:]))
+	info := gopacket.CaptureInfo{
+		Timestamp:      time.Unix(int64(h.uint32At(packet+packetSecOffset)), int64(h.uint32At(packet+packetNsecOffset))),
+		CaptureLength:  caplen,
+		Length:         int(h.uint32At(packet + packetLenOffset)),
+		InterfaceIndex: h.ifindex,
+	}
+	data := h.packetData(packet, h.ring[start:start+caplen], &info)
+
+	h.offset += int(h.uint32At(packet + packetNextOffset))
+	h.remaining--
+	h.release = h.remaining == 0
+	return data, info, nil
+}
+
+// packetData returns a packet in the ring, putting back the VLAN tag the
+// kernel moved into the packet header as libpcap does
+func (h *ringHandle) packetData(packet int, frame []byte, info *gopacket.CaptureInfo) []byte {
+	status := h.uint32At(packet + packetStatusOffset)
+	if status&unix.TP_STATUS_VLAN_VALID == 0 || h.linkType != layers.LinkTypeEthernet || len(frame) < 12 {
+		return frame
+	}
+	tpid := uint16(layers.EthernetTypeDot1Q)
+	if status&unix.TP_STATUS_VLAN_TPID_VALID != 0 {
+		tpid = binary.NativeEndian.Uint16(h.ring[packet+packetVLANTPIDOffset:])
+	}
+	tci := uint16(h.uint32At(packet + packetVLANOffset))
+
+	data := append(h.buf[:0], frame[:12]...)
+	data = binary.BigEndian.AppendUint16(data, tpid)
+	data = binary.BigEndian.AppendUint16(data, tci)
+	data = append(data, frame[12:]...)
+	h.buf = data
+	info.CaptureLength += 4
+	info.Length += 4
+	return data
+}
+
+// poll waits for the kernel to hand over the current block
+func (h *ringHandle) poll() error {
+	fds := []unix.PollFd{{Fd: int32(h.fd), Events: unix.POLLIN | unix.POLLERR}}
+	n, err := unix.Poll(fds, int(ringPollTimeout/time.Millisecond))
+	switch {
+	case errors.Is(err, unix.EINTR):
+		return nil
+	case err != nil:
+		return err
+	case n == 0:
+		return errReadTimeout
+	case fds[0].Revents&unix.POLLERR != 0:
+		return errors.New("packet ring poll failed")
+	}
+	return nil
+}
+
+// blockStatus returns the status word of the current block, shared with the kernel
+func (h *ringHandle) blockStatus() *uint32 {
+	return (*uint32)(unsafe.Pointer(&h.ring[h.block*ringBlockSize+blockStatusOffset]))
+}
+
+// releaseBlock hands the current block back to the kernel and moves to the next
+func (h *ringHandle) releaseBlock() {
+	atomic.StoreUint32(h.blockStatus(), 0) // TP_STATUS_KERNEL
+	h.block = (h.block + 1) % h.blocks
+}
+
+func (h *ringHandle) uint32At(offset int) uint32 {
+	return binary.NativeEndian.Uint32(h.ring[offset:])
+}
+
+// LinkType returns the link layer type of the packets read
+func (h *ringHandle) LinkType() layers.LinkType {
+	return h.linkType
+}
+
+// SnapLen returns the maximum bytes captured per packet
+func (h *ringHandle) SnapLen() int {
+	return h.snaplen
+}
+
+// Drops returns the packets the kernel couldn't fit in the ring. The socket
+// doesn't count the interface's drops.
+func (h *ringHandle) Drops() dropCounts {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.fd == -1 {
+		return dropCounts{buffer: atomic.LoadUint64(&h.drops)}
+	}
+	stats, err := unix.GetsockoptTpacketStatsV3(h.fd, unix.SOL_PACKET, unix.PACKET_STATISTICS)
+	if err != nil {
+		return dropCounts{buffer: atomic.LoadUint64(&h.drops)}
+	}
+	return dropCounts{buffer: atomic.AddUint64(&h.drops, uint64(stats.Drops))}
+}
+
+// Close releases the ring once any read in progress has returned
+func (h *ringHandle) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.fd == -1 {
+		return
+	}
+	if h.ring != nil {
+		unix.Munmap(h.ring)
+		h.ring = nil
+	}
+	unix.Close(h.fd)
+	h.fd = -1
+}
+
+// htons converts a short to network byte order
+func htons(v uint16) uint16 {
+	return binary.NativeEndian.Uint16(binary.BigEndian.AppendUint16(nil, v))
+}
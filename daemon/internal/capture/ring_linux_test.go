@@ -0,0 +1,17 @@
+package capture
+
+import (
+	"io"
+	"testing"
+)
+
+func TestRingHandleClosed(t *testing.T) {
+	h := &ringHandle{fd: -1}
+	if _, _, err := h.ZeroCopyReadPacketData(); err != io.EOF {
+		t.Errorf("Expected EOF from a closed ring, got %v", err)
+	}
+	if drops := h.Drops(); drops != (dropCounts{}) {
+		t.Errorf("Expected no drops from a closed ring, got %+v", drops)
+	}
+	h.Close()
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package capture
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// ringAvailable reports whether the afpacket backend can be used
+func ringAvailable() error {
+	return fmt.Errorf("the %s capture backend needs Linux, not %s", BackendAFPacket, runtime.GOOS)
+}
+
+// openRing fails, AF_PACKET rings only exist on Linux
+func openRing(iface, filter string, options LiveOptions) (packetHandle, error) {
+	return nil, ringAvailable()
+}
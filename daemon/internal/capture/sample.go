@@ -0,0 +1,76 @@
+package capture
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Sampling modes, chosen with -sample-mode
+const (
+	SampleCount  = "count"  // Every Nth packet, the same packets each run of a replay
+	SampleRandom = "random" // Each packet with a chance of one in N, immune to periodic traffic
+)
+
+// sampler picks the packets processed when only a fraction of a busy link
+// can be. It is only used by the capture loop.
+type sampler struct {
+	rate int // One in rate packets is processed
+	mode string
+	seen uint64
+	rand *rand.Rand
+}
+
+// ParseSampleRate parses a sample rate such as "1/100" or "100", both meaning
+// one packet in a hundred. "" and "1/1" sample every packet and return 1.
+func ParseSampleRate(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 1, nil
+	}
+	rate := strings.TrimPrefix(value, "1/")
+	n, err := strconv.Atoi(rate)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid sample rate %q (use e.g. 1/100)", value)
+	}
+	return n, nil
+}
+
+// SetSampling processes one in rate packets, picked by mode, SampleCount or
+// SampleRandom. The rest are counted in the statistics, written with -w and
+// tapped, but not decoded into events or conversations. ARP, neighbor
+// discovery and DHCP are always processed. A rate of 1 processes every
+// packet. Call it before Start.
+func (pc *PacketCapture) SetSampling(rate int, mode string) error {
+	if mode != SampleCount && mode != SampleRandom {
+		return fmt.Errorf("unknown sample mode %q (use %s or %s)", mode, SampleCount, SampleRandom)
+	}
+	if rate < 1 {
+		return fmt.Errorf("invalid sample rate %d", rate)
+	}
+	if rate == 1 {
+		pc.sampler = nil
+		return nil
+	}
+	pc.sampler = &sampler{rate: rate, mode: mode, rand: rand.New(rand.NewSource(rand.Int63()))}
+	return nil
+}
+
+// SampleRate returns the one in how many packets are processed, 1 for all of them
+func (pc *PacketCapture) SampleRate() int {
+	if pc.sampler == nil {
+		return 1
+	}
+	return pc.sampler.rate
+}
+
+// sample reports whether the next packet is processed
+func (s *sampler) sample() bool {
+	if s.mode == SampleRandom {
+		return s.rand.Intn(s.rate) == 0
+	}
+	processed := s.seen%uint64(s.rate) == 0
+	s.seen++
+	return processed
+}
@@ -0,0 +1,143 @@
+package capture
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/conversation"
+	"github.com/iolloyd/netty/daemon/internal/parser"
+	"github.com/iolloyd/netty/daemon/internal/reassembly"
+	"github.com/iolloyd/netty/daemon/internal/resolver"
+	"github.com/iolloyd/netty/daemon/internal/tap"
+)
+
+func TestParseSampleRate(t *testing.T) {
+	for value, want := range map[string]int{"": 1, "1/1": 1, "1/100": 100, " 100 ": 100} {
+		if rate, err := ParseSampleRate(value); err != nil || rate != want {
+			t.Errorf("ParseSampleRate(%q) = %d, %v, want %d", value, rate, err, want)
+		}
+	}
+	for _, value := range []string{"1/0", "2/100", "0.01", "-5"} {
+		if _, err := ParseSampleRate(value); err == nil {
+			t.Errorf("Expected %q to be refused", value)
+		}
+	}
+}
+
+func TestSetSampling(t *testing.T) {
+	pc := &PacketCapture{}
+	if err := pc.SetSampling(10, "hash"); err == nil {
+		t.Error("Expected an unknown mode to be refused")
+	}
+	if err := pc.SetSampling(1, SampleCount); err != nil || pc.sampler != nil || pc.SampleRate() != 1 {
+		t.Errorf("Expected a rate of 1 to process every packet, got %v", err)
+	}
+
+	if err := pc.SetSampling(4, SampleCount); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var processed []int
+	for i := 0; i < 10; i++ {
+		if pc.sampler.sample() {
+			processed = append(processed, i)
+		}
+	}
+	if len(processed) != 3 || processed[0] != 0 || processed[1] != 4 || processed[2] != 8 {
+		t.Errorf("Expected every 4th packet from the first, got %v", processed)
+	}
+
+	// Random sampling keeps about the rate's share
+	if err := pc.SetSampling(4, SampleRandom); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	n := 0
+	for i := 0; i < 10000; i++ {
+		if pc.sampler.sample() {
+			n++
+		}
+	}
+	if n < 2000 || n > 3000 {
+		t.Errorf("Expected about 2500 of 10000 packets sampled, got %d", n)
+	}
+}
+
+func TestHandleFrame_MarksSampled(t *testing.T) {
+	pc := &PacketCapture{
+		iface:       "eth0",
+		stats:       NewPacketStats(),
+		convMgr:     conversation.NewManager("10.0.0.1"),
+		dnsResolver: resolver.NewDNSResolver(time.Minute),
+		decoders:    parser.DefaultRegistry(),
+		streams:     reassembly.NewAssembler(),
+	}
+	if err := pc.SetSampling(100, SampleCount); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	loop := &captureLoop{noPacketTimer: time.NewTimer(time.Hour)}
+	defer loop.noPacketTimer.Stop()
+
+	data := tcpPacket(t, 1, 64, 1000).Data()
+	event := pc.handleFrame(newDecoder(layers.LinkTypeEthernet).decode(data, gopacket.CaptureInfo{}), loop)
+	if event == nil || event.SampleRate != 100 {
+		t.Fatalf("Expected an event sampled 1 in 100, got %+v", event)
+	}
+	conv, ok := pc.convMgr.GetConversation(event.ConversationID)
+	if !ok || conv.SampleRate != 100 {
+		t.Errorf("Expected the conversation marked as sampled, got %+v", conv)
+	}
+}
+
+func TestHandleFrame_UnsampledStillTappedAndWatched(t *testing.T) {
+	pc := &PacketCapture{
+		iface:       "eth0",
+		stats:       NewPacketStats(),
+		convMgr:     conversation.NewManager("10.0.0.1"),
+		dnsResolver: resolver.NewDNSResolver(time.Minute),
+		decoders:    parser.DefaultRegistry(),
+		streams:     reassembly.NewAssembler(),
+		tap:         tap.New(layers.LinkTypeEthernet, DefaultSnaplen),
+	}
+	sub, err := pc.tap.Subscribe("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer sub.Close()
+	if err := pc.SetSampling(100, SampleCount); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	loop := &captureLoop{noPacketTimer: time.NewTimer(time.Hour)}
+	defer loop.noPacketTimer.Stop()
+	decoder := newDecoder(layers.LinkTypeEthernet)
+
+	// The first packet is sampled, the second isn't
+	for seq := uint32(1000); seq < 1010; seq += 5 {
+		data := tcpPacket(t, 1, 64, seq).Data()
+		pc.handleFrame(decoder.decode(data, gopacket.CaptureInfo{CaptureLength: len(data), Length: len(data)}), loop)
+	}
+	stats := pc.stats.GetStats()
+	if stats["total_packets"] != uint64(2) || stats["unsampled_packets"] != uint64(1) {
+		t.Errorf("Expected both packets counted and one unsampled, got %v", stats)
+	}
+	if conv := pc.convMgr.GetAllConversations(); len(conv) != 1 || conv[0].TotalPackets() != 1 {
+		t.Errorf("Expected only the sampled packet tracked, got %+v", conv)
+	}
+	if len(sub.Packets()) != 2 {
+		t.Errorf("Expected the unsampled packet tapped too, got %d", len(sub.Packets()))
+	}
+
+	// ARP isn't sampled, the watchers need every packet
+	eth := &layers.Ethernet{SrcMAC: net.HardwareAddr{0, 1, 2, 3, 4, 5}, DstMAC: layers.EthernetBroadcast, EthernetType: layers.EthernetTypeARP}
+	arp := &layers.ARP{AddrType: layers.LinkTypeEthernet, Protocol: layers.EthernetTypeIPv4, HwAddressSize: 6, ProtAddressSize: 4,
+		Operation: layers.ARPRequest, SourceHwAddress: eth.SrcMAC, SourceProtAddress: []byte{10, 0, 0, 2},
+		DstHwAddress: make([]byte, 6), DstProtAddress: []byte{10, 0, 0, 1}}
+	buf := gopacket.NewSerializeBuffer()
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true}, eth, arp); err != nil {
+		t.Fatalf("Failed to build ARP: %v", err)
+	}
+	if event := pc.handleFrame(decoder.decode(buf.Bytes(), gopacket.CaptureInfo{}), loop); event == nil || event.ARP == nil {
+		t.Errorf("Expected an ARP event whatever the sample, got %+v", event)
+	}
+}
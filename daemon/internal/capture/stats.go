@@ -4,28 +4,66 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/clock"
 )
 
 // PacketStats tracks packet capture statistics
 type PacketStats struct {
 	startTime       time.Time
+	resetTime       time.Time // When the counters were last zeroed, the start time until then
 	totalPackets    uint64
 	totalBytes      uint64
 	tcpPackets      uint64
 	udpPackets      uint64
+	arpPackets      uint64
+	ndpPackets      uint64
 	droppedPackets  uint64
+	fragments       uint64
+	duplicates      uint64
+	localFiltered   uint64
+	unsampled       uint64
 	processedEvents uint64
+	readErrors      uint64
+	recoveries      uint64
+	restarts        uint64
 	lastPacketTime  time.Time
+	state           string
+	lastError       string
+	lastErrorTime   time.Time
 	mu              sync.RWMutex
 }
 
 // NewPacketStats creates a new statistics tracker
 func NewPacketStats() *PacketStats {
+	now := time.Now()
 	return &PacketStats{
-		startTime: time.Now(),
+		startTime: now,
+		resetTime: now,
+		state:     StateRunning,
 	}
 }
 
+// Reset zeroes the counters and forgets the last read error, returning the
+// packets counted before. The capture state and uptime are kept, they
+// describe the capture rather than traffic.
+func (ps *PacketStats) Reset() uint64 {
+	packets := atomic.SwapUint64(&ps.totalPackets, 0)
+	for _, counter := range []*uint64{
+		&ps.totalBytes, &ps.tcpPackets, &ps.udpPackets, &ps.arpPackets,
+		&ps.ndpPackets, &ps.droppedPackets, &ps.fragments, &ps.duplicates, &ps.localFiltered, &ps.unsampled,
+		&ps.processedEvents, &ps.readErrors, &ps.recoveries, &ps.restarts,
+	} {
+		atomic.StoreUint64(counter, 0)
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.resetTime = time.Now()
+	ps.lastError = ""
+	ps.lastErrorTime = time.Time{}
+	return packets
+}
+
 // IncrementPackets increments the packet counter
 func (ps *PacketStats) IncrementPackets() {
 	atomic.AddUint64(&ps.totalPackets, 1)
@@ -46,16 +84,78 @@ func (ps *PacketStats) IncrementUDP() {
 	atomic.AddUint64(&ps.udpPackets, 1)
 }
 
+// IncrementARP increments ARP packet counter
+func (ps *PacketStats) IncrementARP() {
+	atomic.AddUint64(&ps.arpPackets, 1)
+}
+
+// IncrementNDP increments IPv6 neighbor discovery packet counter
+func (ps *PacketStats) IncrementNDP() {
+	atomic.AddUint64(&ps.ndpPackets, 1)
+}
+
 // IncrementDropped increments dropped packet counter
 func (ps *PacketStats) IncrementDropped() {
 	atomic.AddUint64(&ps.droppedPackets, 1)
 }
 
+// Dropped returns how many events were dropped because the event channel was full
+func (ps *PacketStats) Dropped() uint64 {
+	return atomic.LoadUint64(&ps.droppedPackets)
+}
+
+// IncrementFragments increments the IP fragment counter
+func (ps *PacketStats) IncrementFragments() {
+	atomic.AddUint64(&ps.fragments, 1)
+}
+
+// IncrementDuplicates increments the counter of duplicate packets discarded by deduplication
+func (ps *PacketStats) IncrementDuplicates() {
+	atomic.AddUint64(&ps.duplicates, 1)
+}
+
+// IncrementLocalFiltered counts an event discarded for not crossing the internet
+func (ps *PacketStats) IncrementLocalFiltered() {
+	atomic.AddUint64(&ps.localFiltered, 1)
+}
+
+// CountUnsampled counts a packet left out of the sample, already counted
+// towards the totals
+func (ps *PacketStats) CountUnsampled() {
+	atomic.AddUint64(&ps.unsampled, 1)
+}
+
 // IncrementProcessed increments processed events counter
 func (ps *PacketStats) IncrementProcessed() {
 	atomic.AddUint64(&ps.processedEvents, 1)
 }
 
+// RecordReadError counts a failed packet read and remembers it as the last error
+func (ps *PacketStats) RecordReadError(err error) {
+	atomic.AddUint64(&ps.readErrors, 1)
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.lastError = err.Error()
+	ps.lastErrorTime = time.Now()
+}
+
+// IncrementRecoveries counts a successful reopen of a failed interface
+func (ps *PacketStats) IncrementRecoveries() {
+	atomic.AddUint64(&ps.recoveries, 1)
+}
+
+// IncrementRestarts counts a restart after the system woke or the network changed
+func (ps *PacketStats) IncrementRestarts() {
+	atomic.AddUint64(&ps.restarts, 1)
+}
+
+// SetState records what the capture loop is doing
+func (ps *PacketStats) SetState(state string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.state = state
+}
+
 // UpdateLastPacketTime updates the last packet timestamp
 func (ps *PacketStats) UpdateLastPacketTime() {
 	ps.mu.Lock()
@@ -67,9 +167,12 @@ func (ps *PacketStats) UpdateLastPacketTime() {
 func (ps *PacketStats) GetStats() map[string]interface{} {
 	ps.mu.RLock()
 	lastPacket := ps.lastPacketTime
+	state, lastError, lastErrorTime := ps.state, ps.lastError, ps.lastErrorTime
+	resetTime := ps.resetTime
 	ps.mu.RUnlock()
 
 	uptime := time.Since(ps.startTime).Seconds()
+	counting := time.Since(resetTime).Seconds()
 	totalPackets := atomic.LoadUint64(&ps.totalPackets)
 	
 	stats := map[string]interface{}{
@@ -78,14 +181,29 @@ func (ps *PacketStats) GetStats() map[string]interface{} {
 		"total_bytes":        atomic.LoadUint64(&ps.totalBytes),
 		"tcp_packets":        atomic.LoadUint64(&ps.tcpPackets),
 		"udp_packets":        atomic.LoadUint64(&ps.udpPackets),
+		"arp_packets":        atomic.LoadUint64(&ps.arpPackets),
+		"ndp_packets":        atomic.LoadUint64(&ps.ndpPackets),
 		"dropped_packets":    atomic.LoadUint64(&ps.droppedPackets),
+		"ip_fragments":       atomic.LoadUint64(&ps.fragments),
+		"duplicate_packets":  atomic.LoadUint64(&ps.duplicates),
+		"local_filtered":     atomic.LoadUint64(&ps.localFiltered),
+		"unsampled_packets":  atomic.LoadUint64(&ps.unsampled),
 		"processed_events":   atomic.LoadUint64(&ps.processedEvents),
-		"packets_per_second": float64(totalPackets) / uptime,
+		"packets_per_second": float64(totalPackets) / counting,
+		"counters_since":     clock.In(resetTime).Format(time.RFC3339),
+		"capture_state":      state,
+		"read_errors":        atomic.LoadUint64(&ps.readErrors),
+		"capture_recoveries": atomic.LoadUint64(&ps.recoveries),
+		"capture_restarts":   atomic.LoadUint64(&ps.restarts),
+	}
+	if lastError != "" {
+		stats["last_read_error"] = lastError
+		stats["last_read_error_time"] = clock.In(lastErrorTime).Format(time.RFC3339)
 	}
 
 	if !lastPacket.IsZero() {
 		stats["last_packet_ago_seconds"] = time.Since(lastPacket).Seconds()
-		stats["last_packet_time"] = lastPacket.Format(time.RFC3339)
+		stats["last_packet_time"] = clock.In(lastPacket).Format(time.RFC3339)
 	} else {
 		stats["last_packet_ago_seconds"] = -1
 		stats["last_packet_time"] = "never"
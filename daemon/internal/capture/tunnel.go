@@ -0,0 +1,102 @@
+package capture
+
+import (
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// maxTunnelDepth is how many tunnels inside each other are decapsulated
+const maxTunnelDepth = 3
+
+// The layers below end a decode where a tunnel starts, as decoding on would
+// decode the inner packet into the same layers as the outer one. The decoder
+// notes the tunnel and decodes its payload as a packet of its own.
+
+// ipv4Layer is an IPv4 header that ends the decode when it carries another IP packet
+type ipv4Layer struct {
+	layers.IPv4
+}
+
+func (ip *ipv4Layer) NextLayerType() gopacket.LayerType {
+	if isIPinIP(ip.Protocol) {
+		return gopacket.LayerTypeZero
+	}
+	return ip.IPv4.NextLayerType()
+}
+
+// ipv6Layer is an IPv6 header that ends the decode when it carries another IP packet
+type ipv6Layer struct {
+	layers.IPv6
+}
+
+func (ip *ipv6Layer) NextLayerType() gopacket.LayerType {
+	if isIPinIP(ip.NextHeader) {
+		return gopacket.LayerTypeZero
+	}
+	return ip.IPv6.NextLayerType()
+}
+
+// greLayer is a GRE header that ends the decode
+type greLayer struct {
+	layers.GRE
+}
+
+func (g *greLayer) NextLayerType() gopacket.LayerType {
+	return gopacket.LayerTypeZero
+}
+
+// vxlanLayer is a VXLAN header that ends the decode
+type vxlanLayer struct {
+	layers.VXLAN
+}
+
+func (v *vxlanLayer) NextLayerType() gopacket.LayerType {
+	return gopacket.LayerTypeZero
+}
+
+// isIPinIP reports whether an IP protocol number is IPv4 or IPv6 carried directly
+func isIPinIP(protocol layers.IPProtocol) bool {
+	return protocol == layers.IPProtocolIPv4 || protocol == layers.IPProtocolIPv6
+}
+
+// tunnel returns the tunnel the last decode ended at, the layer its payload
+// starts with and the payload, or nil when the packet isn't tunnelled
+func (d *decoder) tunnel() (*models.Encapsulation, gopacket.LayerType, []byte) {
+	f := &d.frame
+	if len(d.decoded) == 0 || f.network == nil || f.fragment {
+		return nil, gopacket.LayerTypeZero, nil
+	}
+	var encapsulation models.Encapsulation
+	var inner gopacket.LayerType
+	var payload []byte
+	switch d.decoded[len(d.decoded)-1] {
+	case layers.LayerTypeVXLAN:
+		encapsulation = models.Encapsulation{Type: models.EncapsulationVXLAN, ID: d.vxlan.VNI}
+		inner, payload = layers.LayerTypeEthernet, d.vxlan.Payload
+	case layers.LayerTypeGRE:
+		encapsulation.Type = models.EncapsulationGRE
+		if d.gre.KeyPresent {
+			encapsulation.ID = d.gre.Key
+		}
+		inner, payload = d.gre.Protocol.LayerType(), d.gre.Payload
+	case layers.LayerTypeIPv4:
+		encapsulation.Type = models.EncapsulationIPIP
+		inner, payload = d.ip4.Protocol.LayerType(), d.ip4.Payload
+	case layers.LayerTypeIPv6:
+		encapsulation.Type = models.EncapsulationIPIP
+		inner, payload = d.ip6.NextHeader.LayerType(), d.ip6.Payload
+	}
+	// Only tunnels carrying Ethernet or IP are decapsulated, e.g. not ERSPAN
+	switch inner {
+	case layers.LayerTypeEthernet, layers.LayerTypeIPv4, layers.LayerTypeIPv6:
+	default:
+		return nil, gopacket.LayerTypeZero, nil
+	}
+	if len(payload) == 0 {
+		return nil, gopacket.LayerTypeZero, nil
+	}
+	flow := f.network.NetworkFlow()
+	encapsulation.OuterSource, encapsulation.OuterDest = flow.Src().String(), flow.Dst().String()
+	return &encapsulation, inner, payload
+}
@@ -0,0 +1,181 @@
+package certs
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// DefaultWindow is how close to expiry a certificate is alerted on
+const DefaultWindow = 30 * 24 * time.Hour
+
+// AlertType is the alert raised for a certificate expiring within the window, or expired
+const AlertType = "cert_expiring"
+
+// Table limits
+const (
+	maxCertificates = 10000 // Certificates kept, the least recently seen are forgotten first
+	maxServers      = 16    // Servers listed per certificate, the first seen
+)
+
+// Certificate is a server certificate seen in TLS handshakes
+type Certificate struct {
+	models.Certificate
+	Servers        []string  `json:"servers"` // Addresses and ports presenting it, e.g. "140.82.112.3:443"
+	FirstSeen      time.Time `json:"first_seen"`
+	LastSeen       time.Time `json:"last_seen"`
+	ConversationID string    `json:"conversation_id"` // The latest conversation it was seen in
+	alerted        bool
+}
+
+// Expired reports whether the certificate had expired at a time
+func (c Certificate) Expired(at time.Time) bool {
+	return at.After(c.NotAfter)
+}
+
+// Message describes the certificate's expiry for alerts and logs
+func (c Certificate) Message(at time.Time) string {
+	server := ""
+	if len(c.Servers) > 0 {
+		server = " on " + c.Servers[0]
+	}
+	if c.Expired(at) {
+		return fmt.Sprintf("Certificate for %s%s expired %s (%s)", c.Name(), server, c.NotAfter.Format("2006-01-02"), days(at.Sub(c.NotAfter))+" ago")
+	}
+	return fmt.Sprintf("Certificate for %s%s expires %s (in %s), issued by %s", c.Name(), server, c.NotAfter.Format("2006-01-02"), days(c.NotAfter.Sub(at)), c.Issuer)
+}
+
+// days formats a duration in whole days, or hours under a day
+func days(d time.Duration) string {
+	if d < 24*time.Hour {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%d days", int(d.Hours()/24))
+}
+
+// Inventory keeps the server certificates seen in TLS handshakes and warns
+// of those close to expiry. Only certificates sent in the clear, up to TLS
+// 1.2, are seen; TLS 1.3 encrypts them.
+type Inventory struct {
+	window       time.Duration
+	certificates map[string]*Certificate // By fingerprint
+	mu           sync.Mutex
+
+	// OnExpiring is called (outside the lock) the first time a certificate is
+	// seen within the window of its expiry, or after it
+	OnExpiring func(cert Certificate)
+}
+
+// NewInventory creates an inventory alerting on certificates expiring within
+// window, DefaultWindow when 0
+func NewInventory(window time.Duration) *Inventory {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &Inventory{
+		window:       window,
+		certificates: make(map[string]*Certificate),
+	}
+}
+
+// Window returns how close to expiry a certificate is alerted on
+func (inv *Inventory) Window() time.Duration {
+	return inv.window
+}
+
+// Observe records the certificate an event carries. Expiry is judged at the
+// event's time, so a replayed capture is alerted on as it was then.
+func (inv *Inventory) Observe(event *models.NetworkEvent) {
+	if event.TLS == nil || event.TLS.Certificate == nil {
+		return
+	}
+	seen := event.TLS.Certificate
+	server := serverAddress(event)
+
+	inv.mu.Lock()
+	cert, exists := inv.certificates[seen.Fingerprint]
+	if !exists {
+		if len(inv.certificates) >= maxCertificates {
+			inv.forgetOldest()
+		}
+		cert = &Certificate{Certificate: *seen, FirstSeen: event.Timestamp}
+		inv.certificates[seen.Fingerprint] = cert
+	}
+	cert.LastSeen = event.Timestamp
+	cert.ConversationID = event.ConversationID
+	if !contains(cert.Servers, server) && len(cert.Servers) < maxServers {
+		cert.Servers = append(cert.Servers, server)
+	}
+	var expiring *Certificate
+	if !cert.alerted && cert.NotAfter.Sub(event.Timestamp) < inv.window {
+		cert.alerted = true
+		found := *cert
+		expiring = &found
+	}
+	inv.mu.Unlock()
+
+	if expiring != nil && inv.OnExpiring != nil {
+		inv.OnExpiring(*expiring)
+	}
+}
+
+// serverAddress returns the address and port of the server, which sends its certificate
+func serverAddress(event *models.NetworkEvent) string {
+	return net.JoinHostPort(event.SourceIP, strconv.Itoa(event.SourcePort))
+}
+
+// forgetOldest drops the least recently seen certificate, must be called with the lock held
+func (inv *Inventory) forgetOldest() {
+	var oldest string
+	for fingerprint, cert := range inv.certificates {
+		if oldest == "" || cert.LastSeen.Before(inv.certificates[oldest].LastSeen) {
+			oldest = fingerprint
+		}
+	}
+	delete(inv.certificates, oldest)
+}
+
+// Certificates returns the certificates seen, the soonest to expire first
+func (inv *Inventory) Certificates() []Certificate {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	certs := make([]Certificate, 0, len(inv.certificates))
+	for _, cert := range inv.certificates {
+		c := *cert
+		c.Servers = append([]string(nil), cert.Servers...)
+		certs = append(certs, c)
+	}
+	sort.Slice(certs, func(i, j int) bool {
+		if !certs[i].NotAfter.Equal(certs[j].NotAfter) {
+			return certs[i].NotAfter.Before(certs[j].NotAfter)
+		}
+		return certs[i].Fingerprint < certs[j].Fingerprint
+	})
+	return certs
+}
+
+// Expiring returns the certificates that expire within the window of at, or have expired
+func (inv *Inventory) Expiring(at time.Time) []Certificate {
+	var expiring []Certificate
+	for _, cert := range inv.Certificates() {
+		if cert.NotAfter.Sub(at) < inv.window {
+			expiring = append(expiring, cert)
+		}
+	}
+	return expiring
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
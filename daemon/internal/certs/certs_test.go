@@ -0,0 +1,85 @@
+package certs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// certificateEvent is a server at source presenting a certificate expiring at notAfter
+func certificateEvent(source, fingerprint string, notAfter, at time.Time) *models.NetworkEvent {
+	return &models.NetworkEvent{
+		Timestamp:         at,
+		ConversationID:    source + "-" + fingerprint,
+		SourceIP:          source,
+		SourcePort:        443,
+		DestIP:            "192.168.1.10",
+		DestPort:          51000,
+		TransportProtocol: "TCP",
+		TLS: &models.TLSInfo{
+			Handshake: models.TLSServerHello,
+			Certificate: &models.Certificate{
+				Subject:     "CN=" + fingerprint + ".example.com",
+				Issuer:      "CN=Example CA",
+				SANs:        []string{fingerprint + ".example.com"},
+				NotAfter:    notAfter,
+				Fingerprint: fingerprint,
+			},
+		},
+	}
+}
+
+func TestInventory(t *testing.T) {
+	inv := NewInventory(0)
+	var alerts []Certificate
+	inv.OnExpiring = func(cert Certificate) { alerts = append(alerts, cert) }
+
+	now := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	inv.Observe(certificateEvent("203.0.113.1", "fresh", now.Add(200*24*time.Hour), now))
+	inv.Observe(certificateEvent("203.0.113.2", "soon", now.Add(10*24*time.Hour), now))
+	inv.Observe(certificateEvent("203.0.113.3", "soon", now.Add(10*24*time.Hour), now.Add(time.Minute)))
+	inv.Observe(certificateEvent("203.0.113.4", "expired", now.Add(-3*24*time.Hour), now))
+	inv.Observe(&models.NetworkEvent{Timestamp: now, SourceIP: "203.0.113.5", TLS: &models.TLSInfo{Handshake: models.TLSServerHello}})
+
+	// Each certificate within the window is alerted on once
+	if len(alerts) != 2 || alerts[0].Fingerprint != "soon" || alerts[1].Fingerprint != "expired" {
+		t.Fatalf("Expected alerts for the expiring and expired certificates, got %+v", alerts)
+	}
+	if msg := alerts[0].Message(now); !strings.Contains(msg, "soon.example.com on 203.0.113.2:443 expires 2025-07-11 (in 10 days)") {
+		t.Errorf("Unexpected message: %s", msg)
+	}
+	if msg := alerts[1].Message(now); !strings.Contains(msg, "expired 2025-06-28 (3 days ago)") || !alerts[1].Expired(now) {
+		t.Errorf("Unexpected message: %s", msg)
+	}
+
+	// Listed soonest to expire first, with every server presenting them
+	certs := inv.Certificates()
+	if len(certs) != 3 || certs[0].Fingerprint != "expired" || certs[2].Fingerprint != "fresh" {
+		t.Fatalf("Expected three certificates by expiry, got %+v", certs)
+	}
+	if soon := certs[1]; len(soon.Servers) != 2 || !soon.FirstSeen.Equal(now) || !soon.LastSeen.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expected the certificate seen on two servers, got %+v", soon)
+	}
+	if expiring := inv.Expiring(now); len(expiring) != 2 {
+		t.Errorf("Expected two certificates expiring, got %d", len(expiring))
+	}
+}
+
+func TestInventory_Window(t *testing.T) {
+	inv := NewInventory(7 * 24 * time.Hour)
+	alerted := 0
+	inv.OnExpiring = func(cert Certificate) { alerted++ }
+
+	now := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	inv.Observe(certificateEvent("203.0.113.2", "soon", now.Add(10*24*time.Hour), now))
+	if alerted != 0 {
+		t.Error("Expected no alert outside a 7 day window")
+	}
+	// Seen again once it is within the window
+	inv.Observe(certificateEvent("203.0.113.2", "soon", now.Add(10*24*time.Hour), now.Add(4*24*time.Hour)))
+	if alerted != 1 {
+		t.Errorf("Expected an alert once within the window, got %d", alerted)
+	}
+}
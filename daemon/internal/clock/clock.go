@@ -0,0 +1,50 @@
+package clock
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// location is the zone timestamps are reported in
+var location atomic.Pointer[time.Location]
+
+func init() {
+	location.Store(time.Local)
+}
+
+// ParseLocation resolves "local", "utc" or an IANA zone name such as "Europe/London"
+func ParseLocation(name string) (*time.Location, error) {
+	switch strings.ToLower(name) {
+	case "", "local":
+		return time.Local, nil
+	case "utc":
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// SetLocation sets the zone timestamps are reported in
+func SetLocation(loc *time.Location) {
+	location.Store(loc)
+}
+
+// Location returns the zone timestamps are reported in
+func Location() *time.Location {
+	return location.Load()
+}
+
+// Now returns the current time in the reporting zone
+func Now() time.Time {
+	return time.Now().In(Location())
+}
+
+// In converts t to the reporting zone
+func In(t time.Time) time.Time {
+	return t.In(Location())
+}
@@ -0,0 +1,27 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLocation(t *testing.T) {
+	if loc, err := ParseLocation("UTC"); err != nil || loc != time.UTC {
+		t.Errorf("Expected UTC, got %v (%v)", loc, err)
+	}
+	if loc, err := ParseLocation("local"); err != nil || loc != time.Local {
+		t.Errorf("Expected Local, got %v (%v)", loc, err)
+	}
+	if _, err := ParseLocation("Not/AZone"); err == nil {
+		t.Error("Expected an error for an unknown zone")
+	}
+}
+
+func TestNow_UsesConfiguredLocation(t *testing.T) {
+	defer SetLocation(time.Local)
+
+	SetLocation(time.UTC)
+	if Now().Location() != time.UTC {
+		t.Errorf("Expected UTC timestamps, got %v", Now().Location())
+	}
+}
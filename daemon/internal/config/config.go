@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// aliases name the single-letter flags in a config file
+var aliases = map[string]string{
+	"interface": "i",
+	"filter":    "f",
+	"verbose":   "v",
+	"replay":    "r",
+	"write":     "w",
+}
+
+// Load reads a YAML config file into flag values by flag name. Keys are the
+// daemon's flag names, or the aliases for the single-letter ones, with
+// underscores accepted for hyphens. Nested sections join their keys with a
+// hyphen, so
+//
+//	tls:
+//	  cert: /etc/netty/cert.pem
+//
+// sets -tls-cert. Lists become comma-separated values, as -watch expects.
+func Load(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := flatten("", doc, values); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// flatten adds the values of a config section under prefix
+func flatten(prefix string, section map[string]interface{}, values map[string]string) error {
+	for key, value := range section {
+		name := strings.ReplaceAll(strings.ToLower(key), "_", "-")
+		if prefix != "" {
+			name = prefix + "-" + name
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if err := flatten(name, v, values); err != nil {
+				return err
+			}
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				if _, nested := item.(map[string]interface{}); nested {
+					return fmt.Errorf("%s: lists can only hold plain values", name)
+				}
+				items = append(items, fmt.Sprint(item))
+			}
+			values[name] = strings.Join(items, ",")
+		case nil:
+			values[name] = ""
+		default:
+			values[name] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// Apply sets the flags of fs from config values, except those given on the
+// command line, which override the file. Unknown names are an error so a
+// typo doesn't silently leave a setting at its default.
+func Apply(fs *flag.FlagSet, values map[string]string) error {
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		flagName := name
+		if alias, ok := aliases[name]; ok {
+			flagName = alias
+		}
+		if fs.Lookup(flagName) == nil || flagName == "config" {
+			errs = append(errs, fmt.Errorf("unknown setting %q", name))
+			continue
+		}
+		if given[flagName] {
+			continue
+		}
+		if err := fs.Set(flagName, values[name]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
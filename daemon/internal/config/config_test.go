@@ -0,0 +1,83 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadAndApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte(`
+interface: eth0
+filter: tcp port 443
+port: 9090
+verbose: true
+tls:
+  cert: /etc/netty/cert.pem
+  key: /etc/netty/key.pem
+block_window: 5m
+watch:
+  - 192.168.1.20
+  - 192.168.1.21
+`), 0o644)
+
+	fs := flag.NewFlagSet("netty-daemon", flag.ContinueOnError)
+	iface := fs.String("i", "", "")
+	filter := fs.String("f", "", "")
+	port := fs.String("port", "8080", "")
+	verbose := fs.Bool("v", false, "")
+	cert := fs.String("tls-cert", "", "")
+	key := fs.String("tls-key", "", "")
+	window := fs.Duration("block-window", time.Minute, "")
+	watch := fs.String("watch", "", "")
+	fs.String("config", "", "")
+	if err := fs.Parse([]string{"-port", "7070", "-config", path}); err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := Apply(fs, values); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *iface != "eth0" || *filter != "tcp port 443" || !*verbose {
+		t.Errorf("Expected the aliased flags set, got -i %q -f %q -v %v", *iface, *filter, *verbose)
+	}
+	if *port != "7070" {
+		t.Errorf("Expected the command line to override the file, got port %s", *port)
+	}
+	if *cert != "/etc/netty/cert.pem" || *key != "/etc/netty/key.pem" || *window != 5*time.Minute {
+		t.Errorf("Expected nested and underscored keys set, got %q %q %v", *cert, *key, *window)
+	}
+	if *watch != "192.168.1.20,192.168.1.21" {
+		t.Errorf("Expected a list joined with commas, got %q", *watch)
+	}
+}
+
+func TestApply_Errors(t *testing.T) {
+	fs := flag.NewFlagSet("netty-daemon", flag.ContinueOnError)
+	fs.Duration("block-window", time.Minute, "")
+	fs.String("config", "", "")
+
+	err := Apply(fs, map[string]string{"block-windw": "5m", "block-window": "soon", "config": "other.yaml"})
+	if err == nil {
+		t.Fatal("Expected errors")
+	}
+	for _, want := range []string{`unknown setting "block-windw"`, "block-window:", `unknown setting "config"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %v", want, err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte("interface: [eth0\n"), 0o644)
+	if _, err := Load(path); err == nil {
+		t.Error("Expected an error for invalid YAML")
+	}
+}
@@ -0,0 +1,90 @@
+package conversation
+
+import (
+	"sort"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// UnknownCountry is the code grouping conversations whose remote end has no
+// country: local addresses and those the GeoIP database doesn't list
+const UnknownCountry = "--"
+
+// maxCountryASOrgs is how many networks are listed per country
+const maxCountryASOrgs = 5
+
+// GetCountryStats totals tracked conversations per country of their remote
+// end, busiest (by bytes) first
+func (m *Manager) GetCountryStats() []models.CountryStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := m.now()
+	groups := make(map[string]*models.CountryStats)
+	hosts := make(map[string]map[string]bool)
+	orgBytes := make(map[string]map[string]uint64)
+
+	m.eachConversation(func(conv *models.Conversation) {
+		code, name := UnknownCountry, "Unknown"
+		if conv.Geo != nil && conv.Geo.CountryCode != "" {
+			code, name = conv.Geo.CountryCode, conv.Geo.Country
+		}
+		group, exists := groups[code]
+		if !exists {
+			group = &models.CountryStats{CountryCode: code, Country: name, ASOrgs: []string{}}
+			groups[code] = group
+			hosts[code] = make(map[string]bool)
+			orgBytes[code] = make(map[string]uint64)
+		}
+
+		group.Conversations++
+		if conv.IsActive() {
+			group.Active++
+		}
+		group.PacketsIn += conv.Stats.PacketsIn
+		group.PacketsOut += conv.Stats.PacketsOut
+		group.BytesIn += conv.Stats.BytesIn
+		group.BytesOut += conv.Stats.BytesOut
+		group.BytesPerSecond += throughput(conv.Rate.Series(now))
+		if conv.Stats.LastActivity.After(group.LastActivity) {
+			group.LastActivity = conv.Stats.LastActivity
+		}
+
+		remoteIP := conv.Key.SrcIP
+		if m.local.Contains(remoteIP) {
+			remoteIP = conv.Key.DstIP
+		}
+		hosts[code][remoteIP] = true
+		if conv.Geo != nil && conv.Geo.ASOrg != "" {
+			orgBytes[code][conv.Geo.ASOrg] += conv.TotalBytes()
+		}
+	})
+
+	list := make([]models.CountryStats, 0, len(groups))
+	for code, group := range groups {
+		group.Hosts = len(hosts[code])
+		for org := range orgBytes[code] {
+			group.ASOrgs = append(group.ASOrgs, org)
+		}
+		orgs := orgBytes[code]
+		sort.Slice(group.ASOrgs, func(i, j int) bool {
+			bi, bj := orgs[group.ASOrgs[i]], orgs[group.ASOrgs[j]]
+			if bi != bj {
+				return bi > bj
+			}
+			return group.ASOrgs[i] < group.ASOrgs[j]
+		})
+		if len(group.ASOrgs) > maxCountryASOrgs {
+			group.ASOrgs = group.ASOrgs[:maxCountryASOrgs]
+		}
+		list = append(list, *group)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		bi, bj := list[i].BytesIn+list[i].BytesOut, list[j].BytesIn+list[j].BytesOut
+		if bi != bj {
+			return bi > bj
+		}
+		return list[i].CountryCode < list[j].CountryCode
+	})
+	return list
+}
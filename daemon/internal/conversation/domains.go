@@ -0,0 +1,242 @@
+package conversation
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/clock"
+	"github.com/iolloyd/netty/daemon/internal/models"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Limits on the domain accounting
+const (
+	maxDomains        = 10000 // Further names are counted under OtherDomain
+	domainDays        = 7     // Daily totals kept
+	domainNamePackets = 10    // Packets to wait for a TLS or HTTP name before settling for the resolved hostname
+)
+
+// OtherDomain collects the traffic of names beyond maxDomains
+const OtherDomain = "other"
+
+// domainStats accumulates each domain name's traffic, in total and per day.
+// Like the encryption statistics nothing expires with the conversations.
+// Guarded by the manager's totalsMu.
+type domainStats struct {
+	since  time.Time
+	totals map[string]*models.DomainTraffic
+	days   []domainDay // Oldest first
+}
+
+// domainDay is the traffic per domain name of one day in the reporting zone
+type domainDay struct {
+	day     string // YYYY-MM-DD
+	start   time.Time
+	domains map[string]*models.DomainTraffic
+}
+
+func newDomainStats() *domainStats {
+	return &domainStats{totals: make(map[string]*models.DomainTraffic)}
+}
+
+// updateDomain counts the conversation's bytes since the last packet under its
+// domain name. The name is the TLS server name or HTTP Host as soon as one is
+// seen; the resolved hostname only once domainNamePackets passed without
+// either, or the conversation is settled as it's removed. Bytes sent before
+// the name was known are counted when it is.
+func (m *Manager) updateDomain(conv *models.Conversation, at time.Time, settle bool) {
+	if conv.Domain == "" {
+		name := conv.ServerName
+		if name == "" && conv.HTTP != nil {
+			name = conv.HTTP.Host
+		}
+		if name == "" && (settle || conv.TotalPackets() >= domainNamePackets) {
+			name = conv.Hostname
+		}
+		name = normalizeDomain(name)
+		if name == "" {
+			return
+		}
+		m.totalsMu.Lock()
+		conv.Domain = m.domains.add(name, at, 1, 0, 0)
+		m.totalsMu.Unlock()
+	}
+
+	in, out := conv.Stats.BytesIn-conv.DomainBytes[0], conv.Stats.BytesOut-conv.DomainBytes[1]
+	if in+out > 0 {
+		m.totalsMu.Lock()
+		m.domains.add(conv.Domain, at, 0, in, out)
+		m.totalsMu.Unlock()
+		conv.DomainBytes = [2]uint64{conv.Stats.BytesIn, conv.Stats.BytesOut}
+	}
+}
+
+// normalizeDomain lowercases a name and drops a port or trailing dot,
+// returning "" for IP addresses
+func normalizeDomain(name string) string {
+	if host, _, err := net.SplitHostPort(name); err == nil {
+		name = host
+	}
+	name = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+	if net.ParseIP(name) != nil {
+		return ""
+	}
+	return name
+}
+
+// add counts connections and bytes under a name, today and in total,
+// returning the name they were counted under
+func (d *domainStats) add(name string, at time.Time, connections, in, out uint64) string {
+	if d.since.IsZero() {
+		d.since = at
+	}
+	if _, exists := d.totals[name]; !exists && len(d.totals) >= maxDomains {
+		name = OtherDomain
+	}
+	count(d.totals, name, at, connections, in, out)
+
+	local := at.In(clock.Location())
+	day := local.Format("2006-01-02")
+	if n := len(d.days); n == 0 || d.days[n-1].day < day {
+		start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+		d.days = append(d.days, domainDay{day: day, start: start, domains: make(map[string]*models.DomainTraffic)})
+		if len(d.days) > domainDays {
+			d.days = d.days[len(d.days)-domainDays:]
+		}
+	}
+	// A packet from an earlier day, which only a replay delivers this late, counts in the latest
+	count(d.days[len(d.days)-1].domains, name, at, connections, in, out)
+	return name
+}
+
+// count adds to a name's traffic in a table
+func count(table map[string]*models.DomainTraffic, name string, at time.Time, connections, in, out uint64) {
+	traffic, exists := table[name]
+	if !exists {
+		traffic = &models.DomainTraffic{Domain: name, FirstSeen: at}
+		table[name] = traffic
+	}
+	traffic.Connections += connections
+	traffic.BytesIn += in
+	traffic.BytesOut += out
+	traffic.Bytes += in + out
+	if at.After(traffic.LastSeen) {
+		traffic.LastSeen = at
+	}
+}
+
+// GetDomainStats returns the traffic per domain name on a day ("YYYY-MM-DD"
+// or "today" in the reporting zone) or since accounting started (""), most
+// bytes first. With group, subdomains are totalled under their registered
+// domain as "*.example.com". match keeps only the names equal to it or, for
+// "*.example.com", example.com and its subdomains.
+func (m *Manager) GetDomainStats(day string, group bool, match string) (models.DomainReport, error) {
+	m.totalsMu.Lock()
+	defer m.totalsMu.Unlock()
+
+	report := models.DomainReport{
+		Since:   m.domains.since,
+		Group:   group,
+		Match:   strings.ToLower(match),
+		Domains: []models.DomainTraffic{},
+		Days:    make([]string, 0, len(m.domains.days)),
+	}
+	for _, d := range m.domains.days {
+		report.Days = append(report.Days, d.day)
+	}
+
+	table := m.domains.totals
+	if day != "" {
+		if day == "today" {
+			day = m.now().In(clock.Location()).Format("2006-01-02")
+		}
+		if _, err := time.Parse("2006-01-02", day); err != nil {
+			return models.DomainReport{}, fmt.Errorf("invalid day %q, want YYYY-MM-DD or today", day)
+		}
+		report.Day = day
+		table = nil
+		for _, d := range m.domains.days {
+			if d.day == day {
+				table, report.Since = d.domains, d.start
+			}
+		}
+	}
+
+	grouped := make(map[string]*models.DomainTraffic)
+	for name, traffic := range table {
+		if report.Match != "" && !matchDomain(name, report.Match) {
+			continue
+		}
+		key := name
+		if group {
+			key = wildcard(name)
+		}
+		entry, exists := grouped[key]
+		if !exists {
+			entry = &models.DomainTraffic{Domain: key, FirstSeen: traffic.FirstSeen}
+			grouped[key] = entry
+		}
+		entry.Connections += traffic.Connections
+		entry.BytesIn += traffic.BytesIn
+		entry.BytesOut += traffic.BytesOut
+		entry.Bytes += traffic.Bytes
+		if group {
+			entry.Names++
+		}
+		if traffic.FirstSeen.Before(entry.FirstSeen) {
+			entry.FirstSeen = traffic.FirstSeen
+		}
+		if traffic.LastSeen.After(entry.LastSeen) {
+			entry.LastSeen = traffic.LastSeen
+		}
+	}
+
+	for _, entry := range grouped {
+		report.Domains = append(report.Domains, *entry)
+		report.Connections += entry.Connections
+		report.BytesIn += entry.BytesIn
+		report.BytesOut += entry.BytesOut
+		report.Bytes += entry.Bytes
+	}
+	sort.Slice(report.Domains, func(i, j int) bool {
+		a, b := report.Domains[i], report.Domains[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return a.Domain < b.Domain
+	})
+	return report, nil
+}
+
+// wildcard returns the registered domain a name belongs to as "*.example.com",
+// going by the ICANN part of the public suffix list so "a.b.example.co.uk"
+// gives "*.example.co.uk" and "storage.googleapis.com" "*.googleapis.com".
+// Single labels are kept as they are.
+func wildcard(name string) string {
+	suffix, icann := publicsuffix.PublicSuffix(name)
+	for !icann {
+		// Privately registered suffixes, such as googleapis.com, are domains here
+		dot := strings.IndexByte(suffix, '.')
+		if dot < 0 {
+			break
+		}
+		suffix, icann = publicsuffix.PublicSuffix(suffix[dot+1:])
+	}
+	if suffix == name || name == OtherDomain {
+		return name
+	}
+	rest := strings.TrimSuffix(name, "."+suffix)
+	return "*." + rest[strings.LastIndexByte(rest, '.')+1:] + "." + suffix
+}
+
+// matchDomain reports whether name is pattern or, for "*.example.com",
+// example.com or one of its subdomains
+func matchDomain(name, pattern string) bool {
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return name == suffix || strings.HasSuffix(name, "."+suffix)
+	}
+	return name == pattern
+}
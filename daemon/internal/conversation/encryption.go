@@ -0,0 +1,135 @@
+package conversation
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Limits on the encryption statistics
+const (
+	maxEncryptionServices = 1024 // Further service ports are counted under OtherService
+	encryptionHours       = 24   // Hourly slots kept
+)
+
+// OtherService collects the traffic of services beyond maxEncryptionServices
+const OtherService = "other"
+
+// encryptionStats accumulates each service's encrypted and cleartext bytes.
+// Unlike the conversations nothing expires, so the shares cover all traffic
+// since the daemon started. Guarded by the manager's totalsMu.
+type encryptionStats struct {
+	since    time.Time
+	services map[string]*models.ServiceEncryption // By service, e.g. "TCP/443"
+	hourly   []models.EncryptionSlot              // Oldest first
+}
+
+func newEncryptionStats() *encryptionStats {
+	return &encryptionStats{services: make(map[string]*models.ServiceEncryption)}
+}
+
+// updateEncryption classifies the conversation by the event's payload and
+// counts the packet under its service. Once encrypted a conversation stays so;
+// a cleartext one becomes encrypted when it upgrades, e.g. with STARTTLS.
+func (m *Manager) updateEncryption(conv *models.Conversation, event *models.NetworkEvent) {
+	if event.Encryption != "" && !models.IsEncrypted(conv.Encryption) {
+		conv.Encryption = event.Encryption
+	}
+	port := int(conv.Key.DstPort)
+	if conv.Key.SrcPort > 0 && (port == 0 || int(conv.Key.SrcPort) < port) {
+		port = int(conv.Key.SrcPort)
+	}
+	if port == 0 {
+		// ICMP and the like have no service
+		return
+	}
+	m.totalsMu.Lock()
+	defer m.totalsMu.Unlock()
+	m.encryption.add(fmt.Sprintf("%s/%d", conv.Key.Protocol, port), conv.Service, conv.Encryption, uint64(event.Size), event.Timestamp)
+}
+
+// add counts bytes of a service's traffic in the given class
+func (e *encryptionStats) add(service, name, class string, bytes uint64, at time.Time) {
+	if e.since.IsZero() {
+		e.since = at
+	}
+	stats, exists := e.services[service]
+	if !exists {
+		if len(e.services) >= maxEncryptionServices {
+			service, name = OtherService, ""
+			stats, exists = e.services[service]
+		}
+		if !exists {
+			stats = &models.ServiceEncryption{Service: service}
+			e.services[service] = stats
+		}
+	}
+	if name != "" {
+		stats.Name = name
+	}
+	if at.After(stats.LastSeen) {
+		stats.LastSeen = at
+	}
+
+	hour := at.Truncate(time.Hour)
+	if n := len(e.hourly); n == 0 || e.hourly[n-1].Start.Before(hour) {
+		e.hourly = append(e.hourly, models.EncryptionSlot{Start: hour})
+		if len(e.hourly) > encryptionHours {
+			e.hourly = e.hourly[len(e.hourly)-encryptionHours:]
+		}
+	}
+	// A packet from an earlier hour, which only a replay delivers this late, counts in the latest
+	slot := &e.hourly[len(e.hourly)-1]
+
+	switch {
+	case models.IsEncrypted(class):
+		stats.EncryptedBytes += bytes
+		slot.EncryptedBytes += bytes
+	case class == models.EncryptionPlaintext:
+		stats.PlaintextBytes += bytes
+		slot.PlaintextBytes += bytes
+	default:
+		stats.UnknownBytes += bytes
+		slot.UnknownBytes += bytes
+	}
+}
+
+// GetEncryptionStats returns the encrypted and cleartext share of the traffic
+// since start, per service with the most cleartext first, and per hour
+func (m *Manager) GetEncryptionStats() models.EncryptionReport {
+	m.totalsMu.Lock()
+	defer m.totalsMu.Unlock()
+
+	report := models.EncryptionReport{
+		Since:    m.encryption.since,
+		Services: make([]models.ServiceEncryption, 0, len(m.encryption.services)),
+		Hourly:   make([]models.EncryptionSlot, 0, len(m.encryption.hourly)),
+	}
+	for _, stats := range m.encryption.services {
+		service := *stats
+		service.EncryptedRatio = models.EncryptedRatio(service.EncryptedBytes, service.PlaintextBytes)
+		report.Services = append(report.Services, service)
+		report.EncryptedBytes += service.EncryptedBytes
+		report.PlaintextBytes += service.PlaintextBytes
+		report.UnknownBytes += service.UnknownBytes
+	}
+	report.EncryptedRatio = models.EncryptedRatio(report.EncryptedBytes, report.PlaintextBytes)
+	for _, slot := range m.encryption.hourly {
+		slot.EncryptedRatio = models.EncryptedRatio(slot.EncryptedBytes, slot.PlaintextBytes)
+		report.Hourly = append(report.Hourly, slot)
+	}
+
+	sort.Slice(report.Services, func(i, j int) bool {
+		a, b := report.Services[i], report.Services[j]
+		if a.PlaintextBytes != b.PlaintextBytes {
+			return a.PlaintextBytes > b.PlaintextBytes
+		}
+		if ta, tb := a.EncryptedBytes+a.UnknownBytes, b.EncryptedBytes+b.UnknownBytes; ta != tb {
+			return ta > tb
+		}
+		return a.Service < b.Service
+	})
+	return report
+}
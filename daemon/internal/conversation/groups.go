@@ -0,0 +1,75 @@
+package conversation
+
+import (
+	"sort"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// GetServiceGroups groups tracked conversations by remote name and service,
+// largest groups (by bytes) first
+func (m *Manager) GetServiceGroups() []models.ServiceGroup {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	type groupKey struct{ name, service string }
+	groups := make(map[groupKey]*models.ServiceGroup)
+	addrs := make(map[groupKey]map[string]bool)
+
+	m.eachConversation(func(conv *models.Conversation) {
+		key := groupKey{name: conv.RemoteName(m.local), service: conv.Service}
+		if key.service == "" {
+			key.service = conv.Key.Protocol
+		}
+		group, exists := groups[key]
+		if !exists {
+			group = &models.ServiceGroup{Name: key.name, Service: key.service, FirstSeen: conv.StartTime}
+			groups[key] = group
+			addrs[key] = make(map[string]bool)
+		}
+
+		summary := conv.ToSummary(m.local, m.now())
+		group.Conversations++
+		if conv.IsActive() {
+			group.Active++
+		}
+		group.PacketsIn += summary.PacketsIn
+		group.PacketsOut += summary.PacketsOut
+		group.BytesIn += summary.BytesIn
+		group.BytesOut += summary.BytesOut
+		if conv.StartTime.Before(group.FirstSeen) {
+			group.FirstSeen = conv.StartTime
+		}
+		if conv.Stats.LastActivity.After(group.LastActivity) {
+			group.LastActivity = conv.Stats.LastActivity
+		}
+		group.ConversationIDs = append(group.ConversationIDs, conv.ID)
+
+		remoteIP := conv.Key.SrcIP
+		if m.local.Contains(remoteIP) {
+			remoteIP = conv.Key.DstIP
+		}
+		if !addrs[key][remoteIP] {
+			addrs[key][remoteIP] = true
+			group.RemoteAddrs = append(group.RemoteAddrs, remoteIP)
+		}
+	})
+
+	list := make([]models.ServiceGroup, 0, len(groups))
+	for _, group := range groups {
+		sort.Strings(group.RemoteAddrs)
+		sort.Strings(group.ConversationIDs)
+		list = append(list, *group)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		bi, bj := list[i].BytesIn+list[i].BytesOut, list[j].BytesIn+list[j].BytesOut
+		if bi != bj {
+			return bi > bj
+		}
+		if list[i].Name != list[j].Name {
+			return list[i].Name < list[j].Name
+		}
+		return list[i].Service < list[j].Service
+	})
+	return list
+}
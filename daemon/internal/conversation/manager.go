@@ -1,40 +1,198 @@
 package conversation
 
 import (
+	"log"
 	"sync"
 	"time"
 	
-	"github.com/google/uuid"
+	"github.com/iolloyd/netty/daemon/internal/clock"
 	"github.com/iolloyd/netty/daemon/internal/models"
 )
 
+// Defaults for how long conversations are kept
+const (
+	DefaultTCPTimeout      = 5 * time.Minute  // TCP connections time out after 5 minutes of inactivity
+	DefaultUDPTimeout      = 30 * time.Second // UDP flows time out after 30 seconds
+	DefaultRetention       = time.Hour        // Timed out conversations are forgotten an hour after their last packet
+	DefaultCleanupInterval = 30 * time.Second
+)
+
 // Manager manages network conversations
 type Manager struct {
-	conversations map[string]*models.Conversation
-	keyToID       map[string]string // Maps normalized conversation keys to IDs
-	mu            sync.RWMutex
+	shards []*shard // Conversations by hash of their normalized key
+	
+	// Guards the settings, taken before a shard's lock
+	mu sync.RWMutex
 	
 	// Configuration
-	tcpTimeout time.Duration
-	udpTimeout time.Duration
-	localIP    string
+	tcpTimeout      time.Duration
+	udpTimeout      time.Duration
+	retention       time.Duration // How long after their last packet timed out conversations are forgotten
+	cleanupInterval time.Duration
+	cleanupReset    chan struct{} // Wakes the cleanup routine when the interval changes
+	localIP         string                // This host's primary address, as reported
+	local           models.LocalAddresses // Every address treated as this host
+	now             func() time.Time // Current time, the recording's clock during replays
+	
+	// Finds the local program owning a port, nil when attribution is off
+	processLookup func(protocol string, port int) (models.ProcessInfo, bool)
+	
+	// Encrypted and cleartext bytes per service since start, kept past expiry
+	encryption *encryptionStats
+	
+	// Traffic per domain name since start and per day, kept past expiry
+	domains *domainStats
+	
+	// Bytes each way per second across every conversation
+	throughput models.Throughput
+	
+	// Guards throughput, encryption and domains, which every shard adds to
+	totalsMu sync.Mutex
+	
+	// Events kept per conversation
+	packetHistory int
+	
+	// OnRemove is called (outside the lock) with conversations dropped from memory
+	OnRemove func(conv *models.Conversation)
+	
+	// OnStateChange is called (outside the lock) when a packet or the cleanup
+	// routine moves a conversation to another state
+	OnStateChange func(change models.ConversationStateChange)
 }
 
 // NewManager creates a new conversation manager
 func NewManager(localIP string) *Manager {
-	return &Manager{
-		conversations: make(map[string]*models.Conversation),
-		keyToID:       make(map[string]string),
-		tcpTimeout:    5 * time.Minute,  // TCP connections timeout after 5 minutes of inactivity
-		udpTimeout:    30 * time.Second, // UDP flows timeout after 30 seconds
-		localIP:       localIP,
+	return newManager(localIP, conversationShards)
+}
+
+// newManager creates a manager splitting conversations into shards
+func newManager(localIP string, shards int) *Manager {
+	m := &Manager{
+		tcpTimeout:      DefaultTCPTimeout,
+		udpTimeout:      DefaultUDPTimeout,
+		retention:       DefaultRetention,
+		cleanupInterval: DefaultCleanupInterval,
+		cleanupReset:    make(chan struct{}, 1),
+		localIP:         localIP,
+		local:           models.NewLocalAddresses(localIP),
+		now:           clock.Now,
+		encryption:    newEncryptionStats(),
+		domains:       newDomainStats(),
+		packetHistory: DefaultPacketHistory,
+	}
+	for i := 0; i < shards; i++ {
+		m.shards = append(m.shards, newShard())
 	}
+	return m
 }
 
-// ProcessEvent processes a network event and updates conversations
-func (m *Manager) ProcessEvent(event *models.NetworkEvent) {
+// Timeouts returns how long idle TCP and UDP conversations are kept
+func (m *Manager) Timeouts() (tcp, udp time.Duration) {
+	return m.tcpTimeout, m.udpTimeout
+}
+
+// SetTimeouts sets how long idle TCP and UDP conversations are kept, zero
+// leaving a timeout unchanged. Call it before the capture starts.
+func (m *Manager) SetTimeouts(tcp, udp time.Duration) {
+	if tcp > 0 {
+		m.tcpTimeout = tcp
+	}
+	if udp > 0 {
+		m.udpTimeout = udp
+	}
+}
+
+// Retention returns how long after their last packet timed out conversations
+// are forgotten, and how often the cleanup routine looks for them
+func (m *Manager) Retention() (retention, interval time.Duration) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.retention, m.cleanupInterval
+}
+
+// SetRetention sets how long after their last packet timed out conversations
+// are forgotten, and how often the cleanup routine times out idle ones and
+// forgets old ones, zero leaving a setting unchanged
+func (m *Manager) SetRetention(retention, interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if retention > 0 {
+		m.retention = retention
+	}
+	if interval > 0 && interval != m.cleanupInterval {
+		m.cleanupInterval = interval
+		select {
+		case m.cleanupReset <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// SetClock sets the function used as the current time for timeouts and durations
+func (m *Manager) SetClock(now func() time.Time) {
+	m.now = now
+}
+
+// LocalIP returns the primary address treated as this host
+func (m *Manager) LocalIP() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.localIP
+}
+
+// LocalAddresses returns every address treated as this host
+func (m *Manager) LocalAddresses() models.LocalAddresses {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.local
+}
+
+// IsLocal reports whether ip is one of this host's addresses
+func (m *Manager) IsLocal(ip string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.local.Contains(ip)
+}
+
+// SetLocalIP changes the address treated as this host, when the interface's
+// address changes. Conversations already tracked keep their key, only which
+// side is local is decided anew.
+func (m *Manager) SetLocalIP(ip string) {
+	m.SetLocalAddresses(ip)
+}
+
+// SetLocalAddresses changes the addresses treated as this host, IPv4 and
+// IPv6, the first being the primary one reported as the local IP
+func (m *Manager) SetLocalAddresses(ips ...string) {
+	local := models.NewLocalAddresses(ips...)
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.localIP = ""
+	if len(ips) > 0 {
+		m.localIP = ips[0]
+	}
+	m.local = local
+}
+
+// SetProcessLookup sets the function attributing conversations to the local
+// program owning their port
+func (m *Manager) SetProcessLookup(lookup func(protocol string, port int) (models.ProcessInfo, bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.processLookup = lookup
+}
+
+// ProcessEvent processes a network event and updates conversations
+func (m *Manager) ProcessEvent(event *models.NetworkEvent) {
+	var change *models.ConversationStateChange
+	defer func() {
+		// Runs after the unlock below
+		if change != nil && m.OnStateChange != nil {
+			m.OnStateChange(*change)
+		}
+	}()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	
 	// Create conversation key from event
 	key := models.ConversationKey{
@@ -48,21 +206,25 @@ func (m *Manager) ProcessEvent(event *models.NetworkEvent) {
 	// Normalize the key for bidirectional matching
 	normalizedKey := key.Normalize()
 	normalizedKeyStr := normalizedKey.String()
+	s, shardIndex := m.shardFor(normalizedKeyStr)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	
 	// Check if conversation exists
-	conversationID, exists := m.keyToID[normalizedKeyStr]
+	conversationID, exists := s.keyToID[normalizedKeyStr]
 	var conv *models.Conversation
 	
 	if exists {
-		conv = m.conversations[conversationID]
+		conv = s.conversations[conversationID]
 	} else {
 		// Create new conversation
-		conversationID = uuid.New().String()
+		conversationID = newConversationID(shardIndex)
 		conv = &models.Conversation{
-			ID:        conversationID,
-			Key:       normalizedKey,
-			State:     models.ConversationStateNew,
-			StartTime: event.Timestamp,
+			ID:         conversationID,
+			Key:        normalizedKey,
+			State:      models.ConversationStateNew,
+			StartTime:  event.Timestamp,
+			SampleRate: event.SampleRate,
 			Stats: models.ConversationStats{
 				FirstPacket: event.Timestamp,
 			},
@@ -73,12 +235,13 @@ func (m *Manager) ProcessEvent(event *models.NetworkEvent) {
 			conv.TCPState = &models.TCPConversationState{}
 		}
 		
-		m.conversations[conversationID] = conv
-		m.keyToID[normalizedKeyStr] = conversationID
+		s.conversations[conversationID] = conv
+		s.keyToID[normalizedKeyStr] = conversationID
 	}
 	
 	// Update event with conversation ID
 	event.ConversationID = conversationID
+	state := conv.State
 	
 	// Update conversation statistics
 	m.updateConversationStats(conv, event, key)
@@ -88,16 +251,83 @@ func (m *Manager) ProcessEvent(event *models.NetworkEvent) {
 		m.updateTCPState(conv, event, key)
 	}
 	
+	// Track segment sizes and fragmentation
+	m.updatePathStats(conv, event, key)
+	
+	// Track QoS markings
+	m.updateQoS(conv, event)
+	
 	// Detect service/application
 	m.detectService(conv, event)
+	
+	// Remember the remote end's names for grouping
+	m.updateNames(conv, event, key)
+	
+	// Summarise plaintext HTTP
+	if event.HTTP != nil {
+		if conv.HTTP == nil {
+			conv.HTTP = &models.HTTPStats{}
+		}
+		conv.HTTP.Observe(event.HTTP)
+	}
+	
+	// Remember the TLS handshake's versions, ciphers and ALPN
+	if event.TLS != nil {
+		if conv.TLS == nil {
+			conv.TLS = &models.TLSStats{}
+		}
+		conv.TLS.Observe(event.TLS)
+	}
+	
+	// Count the packet as encrypted or cleartext traffic of its service
+	m.updateEncryption(conv, event)
+	
+	// Count the traffic under the domain name it went to
+	m.updateDomain(conv, event.Timestamp, false)
+	
+	// Find the program the conversation belongs to
+	m.attributeProcess(conv, key)
+	
+	// Keep the packet for the conversation's recent history
+	m.recordPacket(s, conversationID, event)
+	
+	if conv.State != state {
+		change = &models.ConversationStateChange{
+			Conversation: conv.ToSummary(m.local, m.now()),
+			From:         state,
+			To:           conv.State,
+			Reason:       stateReason(conv),
+			Time:         event.Timestamp,
+		}
+	}
+}
+
+// stateReason names the TCP event behind a conversation's latest state
+func stateReason(conv *models.Conversation) string {
+	switch conv.State {
+	case models.ConversationStateEstablished:
+		return models.StateReasonHandshake
+	case models.ConversationStateClosing:
+		return models.StateReasonFIN
+	case models.ConversationStateClosed:
+		return models.StateReasonRST
+	}
+	return models.StateReasonSYN
 }
 
 // updateConversationStats updates conversation statistics based on the event
 func (m *Manager) updateConversationStats(conv *models.Conversation, event *models.NetworkEvent, key models.ConversationKey) {
 	conv.Stats.LastActivity = event.Timestamp
+	conv.Rate.Add(event.Timestamp, uint64(event.Size))
+	
+	// Determine direction based on the local addresses
+	isOutgoing := m.local.Contains(key.SrcIP)
 	
-	// Determine direction based on local IP
-	isOutgoing := key.SrcIP == m.localIP
+	conv.Throughput.Add(event.Timestamp, uint64(event.Size), isOutgoing)
+	conv.Minutes.Add(event.Timestamp, uint64(event.Size), isOutgoing)
+	m.totalsMu.Lock()
+	m.throughput.Add(event.Timestamp, uint64(event.Size), isOutgoing)
+	m.totalsMu.Unlock()
 	
 	if isOutgoing {
 		conv.Stats.PacketsOut++
@@ -123,6 +353,9 @@ func (m *Manager) updateTCPState(conv *models.Conversation, event *models.Networ
 	// Handle SYN flag
 	if flags.SYN && !flags.ACK {
 		tcpState.SYNSeen = true
+		if tcpState.SYNTime.IsZero() {
+			tcpState.SYNTime = event.Timestamp
+		}
 		if isClient {
 			tcpState.InitialSeqClient = event.SequenceNumber
 		} else {
@@ -134,6 +367,12 @@ func (m *Manager) updateTCPState(conv *models.Conversation, event *models.Networ
 	// Handle SYN-ACK
 	if flags.SYN && flags.ACK {
 		tcpState.SYNACKSeen = true
+		if tcpState.SYNACKTime.IsZero() {
+			tcpState.SYNACKTime = event.Timestamp
+			if rtt := event.Timestamp.Sub(tcpState.SYNTime); !tcpState.SYNTime.IsZero() && rtt > 0 {
+				tcpState.SYNACKRTT = rtt
+			}
+		}
 		if !isClient {
 			tcpState.InitialSeqServer = event.SequenceNumber
 		}
@@ -143,6 +382,26 @@ func (m *Manager) updateTCPState(conv *models.Conversation, event *models.Networ
 	if flags.ACK && !flags.SYN && tcpState.SYNSeen && tcpState.SYNACKSeen && !tcpState.ACKSeen {
 		tcpState.ACKSeen = true
 		conv.State = models.ConversationStateEstablished
+		// SYN to final ACK covers both legs of the path wherever the capture point is
+		if rtt := event.Timestamp.Sub(tcpState.SYNTime); rtt > 0 {
+			tcpState.HandshakeRTT = rtt
+		}
+		if rtt := event.Timestamp.Sub(tcpState.SYNACKTime); rtt > 0 {
+			tcpState.ACKRTT = rtt
+		}
+	}
+	
+	// Time data segments against the ACKs answering them
+	if isClient {
+		tcpState.DataRTTClient.Sent(event.SequenceNumber, event.PayloadSize, event.Timestamp)
+		if flags.ACK {
+			tcpState.DataRTTServer.Acked(event.AckNumber, event.Timestamp)
+		}
+	} else {
+		tcpState.DataRTTServer.Sent(event.SequenceNumber, event.PayloadSize, event.Timestamp)
+		if flags.ACK {
+			tcpState.DataRTTClient.Acked(event.AckNumber, event.Timestamp)
+		}
 	}
 	
 	// Update sequence numbers
@@ -177,6 +436,60 @@ func (m *Manager) updateTCPState(conv *models.Conversation, event *models.Networ
 	}
 }
 
+// updatePathStats tracks MSS, segment sizes and fragmentation for the conversation
+func (m *Manager) updatePathStats(conv *models.Conversation, event *models.NetworkEvent, key models.ConversationKey) {
+	path := &conv.Path
+	isClient := key.SrcIP == conv.Key.SrcIP && key.SrcPort == conv.Key.SrcPort
+	
+	if event.Fragmented {
+		path.Fragments++
+	}
+	
+	if event.MSS > 0 {
+		if isClient {
+			path.ClientMSS = event.MSS
+		} else {
+			path.ServerMSS = event.MSS
+		}
+	}
+	
+	// Segments are bounded by the MSS the receiving side advertised
+	peerMSS := path.ServerMSS
+	if isClient {
+		if event.PayloadSize > path.MaxSegmentClient {
+			path.MaxSegmentClient = event.PayloadSize
+		}
+	} else {
+		peerMSS = path.ClientMSS
+		if event.PayloadSize > path.MaxSegmentServer {
+			path.MaxSegmentServer = event.PayloadSize
+		}
+	}
+	
+	// Full-size segments that can't be fragmented and keep being resent
+	// suggest an ICMP-filtered path with a smaller MTU
+	if event.TransportProtocol != "TCP" || (!event.DontFragment && event.Protocol != "IPv6") {
+		return
+	}
+	if peerMSS == 0 || peerMSS > 1460 {
+		peerMSS = 1460
+	}
+	if event.PayloadSize*10 < peerMSS*9 {
+		return
+	}
+	if path.ObserveLargeSegment(isClient, event.SequenceNumber) {
+		log.Printf("[WARNING] Possible PMTU blackhole on %s: %d-byte segments repeatedly retransmitted", conv.Key, event.PayloadSize)
+	}
+}
+
+// updateQoS records the packet's DSCP and ECN markings, logging the first congestion mark
+func (m *Manager) updateQoS(conv *models.Conversation, event *models.NetworkEvent) {
+	conv.QoS.Observe(event.DSCP, event.ECN)
+	if event.ECN == models.ECNCE && conv.QoS.ECNCongested == 1 {
+		log.Printf("[INFO] ECN congestion experienced on %s", conv.Key)
+	}
+}
+
 // detectService attempts to identify the service based on port and protocol
 func (m *Manager) detectService(conv *models.Conversation, event *models.NetworkEvent) {
 	// Skip if service already detected
@@ -225,51 +538,117 @@ func (m *Manager) detectService(conv *models.Conversation, event *models.Network
 	}
 }
 
-// GetConversation returns a conversation by ID
+// updateNames records the TLS server name and the remote end's resolved
+// hostname and location
+func (m *Manager) updateNames(conv *models.Conversation, event *models.NetworkEvent, key models.ConversationKey) {
+	if conv.ServerName == "" && event.TLSServerName != "" {
+		conv.ServerName = event.TLSServerName
+	}
+	if conv.Hostname != "" && conv.Geo != nil {
+		return
+	}
+	remoteIP, remoteHostname, remoteGeo := event.SourceIP, event.SourceHostname, event.SourceGeo
+	if m.local.Contains(key.SrcIP) {
+		remoteIP, remoteHostname, remoteGeo = event.DestIP, event.DestHostname, event.DestGeo
+	}
+	if conv.Hostname == "" && remoteHostname != remoteIP {
+		conv.Hostname = remoteHostname
+	}
+	if conv.Geo == nil {
+		conv.Geo = remoteGeo
+	}
+}
+
+// attributeProcess looks up the program owning the conversation's local port
+// until found. Conversations between two other hosts have no local port.
+func (m *Manager) attributeProcess(conv *models.Conversation, key models.ConversationKey) {
+	if m.processLookup == nil || conv.Process != nil {
+		return
+	}
+	var port uint16
+	switch {
+	case m.local.Contains(key.SrcIP):
+		port = key.SrcPort
+	case m.local.Contains(key.DstIP):
+		port = key.DstPort
+	default:
+		return
+	}
+	if info, ok := m.processLookup(key.Protocol, int(port)); ok {
+		conv.Process = &info
+	}
+}
+
+// GetConversation returns a copy of a conversation by ID, taken under its
+// shard's lock so it can be read while packets keep updating the original
 func (m *Manager) GetConversation(id string) (*models.Conversation, bool) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	s := m.shardByID(id)
+	if s == nil {
+		return nil, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	
-	conv, exists := m.conversations[id]
-	return conv, exists
+	conv, exists := s.conversations[id]
+	if !exists {
+		return nil, false
+	}
+	return conv.Clone(), true
 }
 
-// GetActiveConversations returns all active conversations
+// GetActiveConversations returns copies of all active conversations
 func (m *Manager) GetActiveConversations() []*models.Conversation {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	
 	var active []*models.Conversation
-	for _, conv := range m.conversations {
+	m.eachConversation(func(conv *models.Conversation) {
 		if conv.IsActive() {
-			active = append(active, conv)
+			active = append(active, conv.Clone())
 		}
-	}
+	})
 	
 	return active
 }
 
-// GetAllConversations returns all conversations
+// GetAllConversations returns copies of all conversations
 func (m *Manager) GetAllConversations() []*models.Conversation {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	
 	var all []*models.Conversation
-	for _, conv := range m.conversations {
-		all = append(all, conv)
-	}
+	m.eachConversation(func(conv *models.Conversation) {
+		all = append(all, conv.Clone())
+	})
 	
 	return all
 }
 
 // CleanupStaleConversations removes conversations that have been inactive
 func (m *Manager) CleanupStaleConversations() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	var removed []*models.Conversation
+	var changes []models.ConversationStateChange
+	m.mu.RLock()
+	
+	now := m.now()
 	
-	now := time.Now()
+	for _, s := range m.shards {
+		s.mu.Lock()
+		removed, changes = m.cleanupShard(s, now, removed, changes)
+		s.mu.Unlock()
+	}
+	m.mu.RUnlock()
 	
-	for id, conv := range m.conversations {
+	if m.OnStateChange != nil {
+		for _, change := range changes {
+			m.OnStateChange(change)
+		}
+	}
+	if m.OnRemove != nil {
+		for _, conv := range removed {
+			m.OnRemove(conv)
+		}
+	}
+}
+
+// cleanupShard closes a shard's timed out conversations and removes the
+// oldest, adding them to removed and changes
+func (m *Manager) cleanupShard(s *shard, now time.Time, removed []*models.Conversation, changes []models.ConversationStateChange) ([]*models.Conversation, []models.ConversationStateChange) {
+	for id, conv := range s.conversations {
 		var timeout time.Duration
 		if conv.Key.Protocol == "TCP" {
 			timeout = m.tcpTimeout
@@ -281,40 +660,92 @@ func (m *Manager) CleanupStaleConversations() {
 		if now.Sub(conv.Stats.LastActivity) > timeout {
 			// Mark as closed if not already
 			if conv.State != models.ConversationStateClosed {
+				from := conv.State
 				conv.State = models.ConversationStateClosed
 				conv.EndTime = &now
+				changes = append(changes, models.ConversationStateChange{
+					Conversation: conv.ToSummary(m.local, now),
+					From:         from,
+					To:           conv.State,
+					Reason:       models.StateReasonTimeout,
+					Time:         now,
+				})
 			}
 			
-			// Remove very old conversations (>1 hour)
-			if now.Sub(conv.Stats.LastActivity) > time.Hour {
-				delete(m.conversations, id)
-				delete(m.keyToID, conv.Key.Normalize().String())
+			// Forget conversations idle for longer than the retention
+			if now.Sub(conv.Stats.LastActivity) > m.retention {
+				m.updateDomain(conv, conv.Stats.LastActivity, true)
+				delete(s.conversations, id)
+				delete(s.keyToID, conv.Key.Normalize().String())
+				delete(s.recent, id)
+				removed = append(removed, conv)
 			}
 		}
 	}
+	return removed, changes
 }
 
-// StartCleanupRoutine starts a goroutine to periodically clean up stale conversations
+// Flush removes every conversation, active or not, returning how many there
+// were. OnRemove is called for each so exports still see them.
+func (m *Manager) Flush() int {
+	var removed []*models.Conversation
+	for _, s := range m.shards {
+		s.mu.Lock()
+		for _, conv := range s.conversations {
+			m.updateDomain(conv, conv.Stats.LastActivity, true)
+			removed = append(removed, conv)
+		}
+		s.conversations = make(map[string]*models.Conversation)
+		s.keyToID = make(map[string]string)
+		s.recent = make(map[string]*eventRing)
+		s.mu.Unlock()
+	}
+	
+	if m.OnRemove != nil {
+		for _, conv := range removed {
+			m.OnRemove(conv)
+		}
+	}
+	return len(removed)
+}
+
+// StartCleanupRoutine starts a goroutine to periodically clean up stale
+// conversations, every cleanup interval
 func (m *Manager) StartCleanupRoutine() {
 	go func() {
-		ticker := time.NewTicker(30 * time.Second)
+		_, interval := m.Retention()
+		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 		
-		for range ticker.C {
-			m.CleanupStaleConversations()
+		for {
+			select {
+			case <-ticker.C:
+				m.CleanupStaleConversations()
+			case <-m.cleanupReset:
+				_, interval = m.Retention()
+				ticker.Reset(interval)
+			}
 		}
 	}()
 }
 
+// GetThroughput returns the bytes received and sent per second across every
+// conversation, now and over the last minute
+func (m *Manager) GetThroughput() models.ThroughputSummary {
+	m.totalsMu.Lock()
+	defer m.totalsMu.Unlock()
+	return m.throughput.Summary(m.now())
+}
+
 // GetConversationSummaries returns summaries of all conversations
 func (m *Manager) GetConversationSummaries() []models.ConversationSummary {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	
-	summaries := make([]models.ConversationSummary, 0, len(m.conversations))
-	for _, conv := range m.conversations {
-		summaries = append(summaries, conv.ToSummary(m.localIP))
-	}
+	summaries := make([]models.ConversationSummary, 0)
+	m.eachConversation(func(conv *models.Conversation) {
+		summaries = append(summaries, conv.ToSummary(m.local, m.now()))
+	})
 	
 	return summaries
 }
\ No newline at end of file
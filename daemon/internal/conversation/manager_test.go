@@ -0,0 +1,987 @@
+package conversation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func tcpEvent(srcIP string, srcPort int, dstIP string, dstPort int, flags models.TCPPacketFlags) *models.NetworkEvent {
+	return &models.NetworkEvent{
+		Timestamp:         time.Now(),
+		Protocol:          "IPv4",
+		TransportProtocol: "TCP",
+		SourceIP:          srcIP,
+		SourcePort:        srcPort,
+		DestIP:            dstIP,
+		DestPort:          dstPort,
+		TCPFlags:          &flags,
+		DontFragment:      true,
+	}
+}
+
+func TestPathStats_MSSAndPMTUBlackhole(t *testing.T) {
+	m := NewManager("192.168.1.10")
+
+	syn := tcpEvent("192.168.1.10", 50000, "203.0.113.7", 443, models.TCPPacketFlags{SYN: true})
+	syn.MSS = 1460
+	m.ProcessEvent(syn)
+	synAck := tcpEvent("203.0.113.7", 443, "192.168.1.10", 50000, models.TCPPacketFlags{SYN: true, ACK: true})
+	synAck.MSS = 1400
+	m.ProcessEvent(synAck)
+
+	// The local side keeps resending the same full-size segment
+	for i := 0; i < 4; i++ {
+		data := tcpEvent("192.168.1.10", 50000, "203.0.113.7", 443, models.TCPPacketFlags{ACK: true, PSH: true})
+		data.SequenceNumber = 1000
+		data.PayloadSize = 1400
+		m.ProcessEvent(data)
+	}
+
+	summaries := m.GetConversationSummaries()
+	if len(summaries) != 1 {
+		t.Fatalf("Expected 1 conversation, got %d", len(summaries))
+	}
+	summary := summaries[0]
+	if summary.LocalMSS != 1460 || summary.RemoteMSS != 1400 {
+		t.Errorf("Unexpected MSS: local %d, remote %d", summary.LocalMSS, summary.RemoteMSS)
+	}
+	if summary.MaxSegmentOut != 1400 || summary.MaxSegmentIn != 0 {
+		t.Errorf("Unexpected max segments: out %d, in %d", summary.MaxSegmentOut, summary.MaxSegmentIn)
+	}
+	if !summary.PMTUSuspect {
+		t.Error("Expected repeated full-size retransmissions to flag a PMTU blackhole")
+	}
+}
+
+func TestPathStats_SmallRetransmitsAreNotSuspect(t *testing.T) {
+	m := NewManager("192.168.1.10")
+	for i := 0; i < 5; i++ {
+		data := tcpEvent("192.168.1.10", 50000, "203.0.113.7", 443, models.TCPPacketFlags{ACK: true})
+		data.SequenceNumber = 1000
+		data.PayloadSize = 200
+		data.Fragmented = i == 0
+		m.ProcessEvent(data)
+	}
+
+	summary := m.GetConversationSummaries()[0]
+	if summary.PMTUSuspect {
+		t.Error("Expected small retransmissions not to flag a PMTU blackhole")
+	}
+	if summary.Fragments != 1 {
+		t.Errorf("Expected 1 fragment, got %d", summary.Fragments)
+	}
+}
+
+func TestQoS_DSCPAndECN(t *testing.T) {
+	m := NewManager("192.168.1.10")
+	for i := 0; i < 3; i++ {
+		event := tcpEvent("192.168.1.10", 50000, "203.0.113.7", 443, models.TCPPacketFlags{ACK: true})
+		event.DSCP = 46
+		event.ECN = models.ECNECT0
+		m.ProcessEvent(event)
+	}
+	reply := tcpEvent("203.0.113.7", 443, "192.168.1.10", 50000, models.TCPPacketFlags{ACK: true})
+	reply.ECN = models.ECNCE
+	m.ProcessEvent(reply)
+
+	summary := m.GetConversationSummaries()[0]
+	if len(summary.DSCP) != 2 || summary.DSCP[0] != "EF" || summary.DSCP[1] != "CS0" {
+		t.Errorf("Expected DSCP classes [EF CS0], got %v", summary.DSCP)
+	}
+	if summary.ECNCapable != 3 || summary.ECNCongested != 1 {
+		t.Errorf("Unexpected ECN counts: capable %d, congested %d", summary.ECNCapable, summary.ECNCongested)
+	}
+
+	// A conversation handed out keeps its counts while packets arrive, so it
+	// can be encoded outside the shard lock
+	conv, _ := m.GetConversation(reply.ConversationID)
+	marked := tcpEvent("192.168.1.10", 50000, "203.0.113.7", 443, models.TCPPacketFlags{ACK: true})
+	marked.DSCP = 10
+	m.ProcessEvent(marked)
+	if len(conv.QoS.DSCPPackets) != 2 || conv.QoS.DSCPPackets[46] != 3 {
+		t.Errorf("Expected the copy's DSCP counts unchanged, got %v", conv.QoS.DSCPPackets)
+	}
+}
+
+func TestHandshakeRTT(t *testing.T) {
+	m := NewManager("192.168.1.10")
+	start := time.Now()
+
+	syn := tcpEvent("192.168.1.10", 50000, "203.0.113.7", 443, models.TCPPacketFlags{SYN: true})
+	syn.Timestamp = start
+	m.ProcessEvent(syn)
+	synAck := tcpEvent("203.0.113.7", 443, "192.168.1.10", 50000, models.TCPPacketFlags{SYN: true, ACK: true})
+	synAck.Timestamp = start.Add(30 * time.Millisecond)
+	m.ProcessEvent(synAck)
+
+	if rtt := m.GetConversationSummaries()[0].HandshakeRTT; rtt != 0 {
+		t.Errorf("Expected no RTT before the handshake completes, got %v", rtt)
+	}
+
+	ack := tcpEvent("192.168.1.10", 50000, "203.0.113.7", 443, models.TCPPacketFlags{ACK: true})
+	ack.Timestamp = start.Add(42500 * time.Microsecond)
+	m.ProcessEvent(ack)
+
+	summary := m.GetConversationSummaries()[0]
+	if summary.HandshakeRTT != 42.5 {
+		t.Errorf("Expected a 42.5ms handshake RTT, got %v", summary.HandshakeRTT)
+	}
+	if summary.SYNACKRTT != 30 || summary.ACKRTT != 12.5 {
+		t.Errorf("Expected 30ms to the SYN-ACK and 12.5ms to the ACK, got %v and %v", summary.SYNACKRTT, summary.ACKRTT)
+	}
+	if !summary.StartTime.Equal(start) {
+		t.Errorf("Expected start time %v, got %v", start, summary.StartTime)
+	}
+
+	// The remote end closing is reported from the local side's point of view
+	fin := tcpEvent("203.0.113.7", 443, "192.168.1.10", 50000, models.TCPPacketFlags{FIN: true, ACK: true})
+	fin.Timestamp = start.Add(time.Second)
+	m.ProcessEvent(fin)
+	flags := m.GetConversationSummaries()[0].TCPFlags
+	if strings.Join(flags, " ") != "SYN SYN-ACK ACK FIN remote" {
+		t.Errorf("Expected the handshake and the remote FIN, got %v", flags)
+	}
+}
+
+func TestDataRTT(t *testing.T) {
+	m := NewManager("192.168.1.10")
+	start := time.Now()
+
+	send := func(at time.Duration, seq uint32, payload int) {
+		event := tcpEvent("192.168.1.10", 50000, "203.0.113.7", 443, models.TCPPacketFlags{ACK: true, PSH: true})
+		event.Timestamp, event.SequenceNumber, event.PayloadSize = start.Add(at), seq, payload
+		m.ProcessEvent(event)
+	}
+	ack := func(at time.Duration, ack uint32) {
+		event := tcpEvent("203.0.113.7", 443, "192.168.1.10", 50000, models.TCPPacketFlags{ACK: true})
+		event.Timestamp, event.AckNumber = start.Add(at), ack
+		m.ProcessEvent(event)
+	}
+
+	// Only the first segment in flight is timed, and an ACK short of it doesn't count
+	send(0, 1000, 100)
+	send(time.Millisecond, 1100, 100)
+	ack(20*time.Millisecond, 1050)
+	ack(40*time.Millisecond, 1200)
+	summary := m.GetConversationSummaries()[0]
+	if summary.DataRTT != 40 || summary.DataRTTSamples != 1 {
+		t.Fatalf("Expected one 40ms sample, got %vms from %d", summary.DataRTT, summary.DataRTTSamples)
+	}
+
+	// Later samples are smoothed in
+	send(time.Second, 1200, 100)
+	ack(time.Second+120*time.Millisecond, 1300)
+	if summary := m.GetConversationSummaries()[0]; summary.DataRTT != 50 || summary.DataRTTSamples != 2 {
+		t.Errorf("Expected the average to move 1/8 of the way to 120ms, got %vms from %d", summary.DataRTT, summary.DataRTTSamples)
+	}
+
+	// A retransmitted segment isn't sampled
+	send(2*time.Second, 1300, 100)
+	send(3*time.Second, 1300, 100)
+	ack(3*time.Second+10*time.Millisecond, 1400)
+	if summary := m.GetConversationSummaries()[0]; summary.DataRTTSamples != 2 {
+		t.Errorf("Expected the retransmission to be skipped, got %d samples", summary.DataRTTSamples)
+	}
+}
+
+func TestRateHistory(t *testing.T) {
+	m := NewManager("192.168.1.10")
+	start := time.Unix(1751371200, 0)
+	now := start
+	m.SetClock(func() time.Time { return now })
+
+	send := func(at time.Duration, size int) {
+		event := tcpEvent("192.168.1.10", 50000, "203.0.113.7", 443, models.TCPPacketFlags{ACK: true})
+		event.Timestamp = start.Add(at)
+		event.Size = size
+		m.ProcessEvent(event)
+	}
+	send(0, 100)
+	send(time.Second, 50)
+	send(3*time.Second, 1000)
+	// Old enough to fall outside the history, and ignored
+	send(-time.Minute, 5000)
+
+	now = start.Add(3 * time.Second)
+	series := m.GetConversationSummaries()[0].RateHistory
+	if len(series) != models.RateHistoryLen {
+		t.Fatalf("Expected %d slots, got %v", models.RateHistoryLen, series)
+	}
+	if series[len(series)-2] != 150 || series[len(series)-1] != 1000 {
+		t.Errorf("Expected the last two slots to be 150 and 1000, got %v", series)
+	}
+
+	// The series slides with time even while the conversation is idle
+	now = start.Add(5 * time.Second)
+	series = m.GetConversationSummaries()[0].RateHistory
+	if series[len(series)-3] != 150 || series[len(series)-2] != 1000 || series[len(series)-1] != 0 {
+		t.Errorf("Expected the series to slide, got %v", series)
+	}
+
+	// Traffic after a long gap starts from a clean history
+	send(time.Hour, 10)
+	now = start.Add(time.Hour)
+	series = m.GetConversationSummaries()[0].RateHistory
+	total := uint64(0)
+	for _, bytes := range series {
+		total += bytes
+	}
+	if total != 10 {
+		t.Errorf("Expected only the latest packet in the history, got %v", series)
+	}
+}
+
+func TestThroughput(t *testing.T) {
+	m := NewManager("192.168.1.10")
+	start := time.Unix(1751371200, 0)
+	now := start
+	m.SetClock(func() time.Time { return now })
+
+	send := func(at time.Duration, src, dst string, size int) {
+		event := tcpEvent(src, 50000, dst, 443, models.TCPPacketFlags{ACK: true})
+		if src != "192.168.1.10" {
+			event = tcpEvent(src, 443, dst, 50000, models.TCPPacketFlags{ACK: true})
+		}
+		event.Timestamp = start.Add(at)
+		event.Size = size
+		m.ProcessEvent(event)
+	}
+	for i := 0; i < 3; i++ {
+		send(time.Duration(i)*time.Second, "192.168.1.10", "203.0.113.7", 100)
+		send(time.Duration(i)*time.Second, "203.0.113.7", "192.168.1.10", 1000)
+	}
+	// Still filling, so left out of the current rates
+	send(3*time.Second, "203.0.113.7", "192.168.1.10", 9000)
+
+	now = start.Add(3 * time.Second)
+	summary := m.GetConversationSummaries()[0]
+	if summary.BytesInPerSec != 1000 || summary.BytesOutPerSec != 100 {
+		t.Errorf("Expected 1000 B/s in and 100 B/s out, got %v and %v", summary.BytesInPerSec, summary.BytesOutPerSec)
+	}
+
+	total := m.GetThroughput()
+	if len(total.In) != models.ThroughputWindow || len(total.Out) != models.ThroughputWindow {
+		t.Fatalf("Expected %d seconds each way, got %d and %d", models.ThroughputWindow, len(total.In), len(total.Out))
+	}
+	if total.In[len(total.In)-1] != 9000 || total.Out[len(total.Out)-2] != 100 || total.BytesInPerSec != 1000 {
+		t.Errorf("Unexpected throughput: %+v", total)
+	}
+
+	// Rates fall to nothing once the traffic stops
+	now = start.Add(10 * time.Second)
+	if total := m.GetThroughput(); total.BytesInPerSec != 0 || total.BytesOutPerSec != 0 {
+		t.Errorf("Expected no throughput after the traffic stopped, got %+v", total)
+	}
+}
+
+func TestLocalAddresses_IPv6(t *testing.T) {
+	m := NewManager("192.168.1.10")
+	m.SetLocalAddresses("192.168.1.10", "2001:DB8::10", "2001:db8::abcd")
+	if m.LocalIP() != "192.168.1.10" || !m.IsLocal("2001:db8::10") {
+		t.Fatalf("Expected the IPv4 address primary and the IPv6 ones local, got %v", m.LocalAddresses().List())
+	}
+
+	// A temporary IPv6 address's flow is outgoing like the IPv4 ones
+	out := tcpEvent("2001:db8::abcd", 50000, "2606:4700::1111", 443, models.TCPPacketFlags{ACK: true})
+	out.Protocol, out.Size = "IPv6", 100
+	m.ProcessEvent(out)
+	in := tcpEvent("2606:4700::1111", 443, "2001:db8::abcd", 50000, models.TCPPacketFlags{ACK: true})
+	in.Protocol, in.Size = "IPv6", 1000
+	m.ProcessEvent(in)
+
+	summary := m.GetConversationSummaries()[0]
+	if summary.LocalAddr != "2001:db8::abcd:50000" || summary.BytesOut != 100 || summary.BytesIn != 1000 {
+		t.Errorf("Expected the IPv6 address as the local side, got %+v", summary)
+	}
+}
+
+func TestGetServiceGroups(t *testing.T) {
+	m := NewManager("192.168.1.10")
+
+	for i, ip := range []string{"140.82.112.5", "140.82.112.6"} {
+		hello := tcpEvent("192.168.1.10", 50000+i, ip, 443, models.TCPPacketFlags{ACK: true, PSH: true})
+		hello.TLSServerName = "api.github.com"
+		hello.Size = 500
+		m.ProcessEvent(hello)
+		reply := tcpEvent(ip, 443, "192.168.1.10", 50000+i, models.TCPPacketFlags{ACK: true})
+		reply.Size = 1500
+		m.ProcessEvent(reply)
+	}
+	other := tcpEvent("192.168.1.10", 51000, "203.0.113.7", 22, models.TCPPacketFlags{ACK: true})
+	other.Size = 100
+	m.ProcessEvent(other)
+
+	groups := m.GetServiceGroups()
+	if len(groups) != 2 {
+		t.Fatalf("Expected 2 groups, got %+v", groups)
+	}
+	github := groups[0]
+	if github.Name != "api.github.com" || github.Service != "HTTPS" || github.Conversations != 2 {
+		t.Errorf("Unexpected group: %+v", github)
+	}
+	if github.BytesOut != 1000 || github.BytesIn != 3000 || len(github.RemoteAddrs) != 2 {
+		t.Errorf("Unexpected group totals: %+v", github)
+	}
+	if groups[1].Name != "203.0.113.7" || groups[1].Service != "SSH" {
+		t.Errorf("Expected ungrouped hosts to be named by IP, got %+v", groups[1])
+	}
+}
+
+func TestGetProcessStats(t *testing.T) {
+	m := NewManager("192.168.1.10")
+	start := time.Unix(1751371200, 0)
+	m.SetClock(func() time.Time { return start.Add(2 * time.Second) })
+	owners := map[int]models.ProcessInfo{
+		50000: {PID: 100, Name: "firefox"},
+		50001: {PID: 101, Name: "firefox"},
+	}
+	var lookups int
+	m.SetProcessLookup(func(protocol string, port int) (models.ProcessInfo, bool) {
+		lookups++
+		info, ok := owners[port]
+		return info, ok
+	})
+
+	send := func(srcIP string, srcPort int, dstIP string, dstPort int, size int) {
+		event := tcpEvent(srcIP, srcPort, dstIP, dstPort, models.TCPPacketFlags{ACK: true})
+		event.Timestamp = start
+		event.Size = size
+		m.ProcessEvent(event)
+	}
+	send("192.168.1.10", 50000, "140.82.112.5", 443, 600)
+	send("140.82.112.5", 443, "192.168.1.10", 50000, 1500)
+	send("192.168.1.10", 50001, "140.82.112.6", 443, 300)
+	send("192.168.1.10", 51000, "203.0.113.7", 22, 100)
+	// Between two other hosts, so there's no local program to look for
+	send("192.168.1.20", 52000, "203.0.113.7", 22, 100)
+
+	stats := m.GetProcessStats()
+	if len(stats) != 2 {
+		t.Fatalf("Expected firefox and unknown, got %+v", stats)
+	}
+	firefox := stats[0]
+	if firefox.Name != "firefox" || firefox.Conversations != 2 || len(firefox.PIDs) != 2 || firefox.PIDs[0] != 100 {
+		t.Errorf("Unexpected firefox totals: %+v", firefox)
+	}
+	if firefox.BytesOut != 900 || firefox.BytesIn != 1500 || firefox.PacketsOut != 2 || firefox.PacketsIn != 1 {
+		t.Errorf("Unexpected firefox traffic: %+v", firefox)
+	}
+	// 2400 bytes in the last completed slot, averaged over three of them
+	if firefox.BytesPerSecond != 400 {
+		t.Errorf("Expected 400 bytes/s, got %v", firefox.BytesPerSecond)
+	}
+	if stats[1].Name != UnknownProcess || stats[1].Conversations != 2 || len(stats[1].PIDs) != 0 {
+		t.Errorf("Expected unattributed conversations under unknown, got %+v", stats[1])
+	}
+	// The reply isn't looked up, its conversation was attributed by the first packet
+	if lookups != 3 {
+		t.Errorf("Expected 3 lookups, got %d", lookups)
+	}
+}
+
+func TestGetCountryStats(t *testing.T) {
+	m := NewManager("192.168.1.10")
+	github := &models.GeoInfo{CountryCode: "US", Country: "United States", ASN: 36459, ASOrg: "GitHub, Inc."}
+	hetzner := &models.GeoInfo{CountryCode: "DE", Country: "Germany", City: "Falkenstein", ASN: 24940, ASOrg: "Hetzner Online GmbH"}
+
+	send := func(srcIP string, srcPort int, dstIP string, dstPort int, size int, srcGeo, dstGeo *models.GeoInfo) {
+		event := tcpEvent(srcIP, srcPort, dstIP, dstPort, models.TCPPacketFlags{ACK: true})
+		event.Size = size
+		event.SourceGeo, event.DestGeo = srcGeo, dstGeo
+		m.ProcessEvent(event)
+	}
+	send("192.168.1.10", 50000, "140.82.112.5", 443, 600, nil, github)
+	send("140.82.112.5", 443, "192.168.1.10", 50000, 1500, github, nil)
+	send("192.168.1.10", 50001, "140.82.112.6", 443, 300, nil, github)
+	send("192.168.1.10", 50002, "88.99.0.1", 22, 100, nil, hetzner)
+	send("192.168.1.10", 50003, "192.168.1.1", 53, 80, nil, nil)
+
+	summaries := m.GetConversationSummaries()
+	for _, summary := range summaries {
+		if summary.RemoteAddr == "88.99.0.1:22" && (summary.Geo == nil || summary.Geo.City != "Falkenstein") {
+			t.Errorf("Expected the remote end's location on the summary, got %+v", summary.Geo)
+		}
+	}
+
+	stats := m.GetCountryStats()
+	if len(stats) != 3 {
+		t.Fatalf("Expected the US, Germany and unknown, got %+v", stats)
+	}
+	us := stats[0]
+	if us.CountryCode != "US" || us.Conversations != 2 || us.Hosts != 2 || us.BytesIn != 1500 || us.BytesOut != 900 {
+		t.Errorf("Unexpected US totals: %+v", us)
+	}
+	if len(us.ASOrgs) != 1 || us.ASOrgs[0] != "GitHub, Inc." {
+		t.Errorf("Expected the networks reached, got %v", us.ASOrgs)
+	}
+	if stats[1].CountryCode != "DE" || stats[2].CountryCode != UnknownCountry || stats[2].Conversations != 1 {
+		t.Errorf("Expected Germany then local traffic, got %+v", stats[1:])
+	}
+}
+
+func TestGetTopTalkers(t *testing.T) {
+	m := NewManager("192.168.1.10")
+	now := time.Date(2025, 7, 1, 10, 30, 30, 0, time.UTC)
+	m.SetClock(func() time.Time { return now })
+	if top, err := m.GetTopTalkers(TopByHost, DefaultTopWindow, 0); err != nil || top.Talkers == nil || len(top.Talkers) != 0 {
+		t.Errorf("Expected an empty list before any traffic, got %+v (%v)", top, err)
+	}
+
+	send := func(srcIP string, srcPort int, dstIP string, dstPort int, size int, ago time.Duration) {
+		event := tcpEvent(srcIP, srcPort, dstIP, dstPort, models.TCPPacketFlags{ACK: true})
+		event.Timestamp = now.Add(-ago)
+		event.Size = size
+		m.ProcessEvent(event)
+	}
+	// A large download twenty minutes ago, then a steady stream to another port
+	send("140.82.112.5", 443, "192.168.1.10", 50000, 9000, 20*time.Minute)
+	send("192.168.1.10", 50000, "140.82.112.5", 443, 100, 2*time.Minute)
+	send("192.168.1.10", 50001, "203.0.113.7", 22, 300, 3*time.Minute)
+	send("203.0.113.7", 22, "192.168.1.10", 50001, 500, time.Minute)
+	send("192.168.1.10", 50002, "203.0.113.7", 8022, 200, 0)
+
+	top, err := m.GetTopTalkers(TopByHost, DefaultTopWindow, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if top.TotalBytes != 1100 || len(top.Talkers) != 2 {
+		t.Fatalf("Expected only the last five minutes counted, got %+v", top)
+	}
+	ssh := top.Talkers[0]
+	if ssh.Key != "203.0.113.7" || ssh.Conversations != 2 || ssh.BytesIn != 500 || ssh.BytesOut != 500 || ssh.Share < 0.9 || ssh.Share > 0.91 {
+		t.Errorf("Expected the SSH host first, got %+v", ssh)
+	}
+
+	top, _ = m.GetTopTalkers(TopByPort, time.Hour, 1)
+	if len(top.Talkers) != 1 || top.Talkers[0].Key != "TCP/443" || top.Talkers[0].Bytes != 9100 || top.TotalBytes != 10100 {
+		t.Errorf("Expected the download within the hour, got %+v", top)
+	}
+	top, _ = m.GetTopTalkers(TopByProcess, DefaultTopWindow, 0)
+	if len(top.Talkers) != 1 || top.Talkers[0].Key != UnknownProcess || top.Talkers[0].Conversations != 3 {
+		t.Errorf("Expected unattributed traffic grouped together, got %+v", top)
+	}
+
+	if _, err := m.GetTopTalkers("country", DefaultTopWindow, 0); err == nil {
+		t.Error("Expected an error for an unknown grouping")
+	}
+	if _, err := m.GetTopTalkers(TopByHost, 2*time.Hour, 0); err == nil {
+		t.Error("Expected an error for a window beyond the kept minutes")
+	}
+}
+
+func TestGetDomainStats(t *testing.T) {
+	m := NewManager("192.168.1.10")
+	day := time.Date(2025, 7, 1, 10, 0, 0, 0, time.Local)
+	now := day
+	m.SetClock(func() time.Time { return now })
+
+	send := func(srcIP string, srcPort int, dstIP string, dstPort int, size int, edit func(*models.NetworkEvent)) {
+		event := tcpEvent(srcIP, srcPort, dstIP, dstPort, models.TCPPacketFlags{ACK: true})
+		event.Timestamp = now
+		event.Size = size
+		if edit != nil {
+			edit(event)
+		}
+		m.ProcessEvent(event)
+	}
+	resolved := func(event *models.NetworkEvent) { event.DestHostname = "lhr25s34-in-f10.1e100.net" }
+	// The handshake comes before the server name and still counts under it
+	send("192.168.1.10", 50000, "142.250.1.1", 443, 100, resolved)
+	send("192.168.1.10", 50000, "142.250.1.1", 443, 300, func(event *models.NetworkEvent) {
+		resolved(event)
+		event.TLSServerName = "Storage.GoogleAPIs.com"
+	})
+	send("142.250.1.1", 443, "192.168.1.10", 50000, 5000, nil)
+	send("192.168.1.10", 50001, "142.250.1.2", 443, 200, func(event *models.NetworkEvent) { event.TLSServerName = "www.googleapis.com" })
+	send("192.168.1.10", 50002, "93.184.216.34", 80, 400, func(event *models.NetworkEvent) {
+		event.HTTP = &models.HTTPInfo{Method: "GET", Host: "example.com:80"}
+	})
+	// Only a resolved hostname: counted once enough packets passed without a better name
+	for i := 0; i < domainNamePackets; i++ {
+		send("192.168.1.10", 50003, "203.0.113.7", 22, 10, func(event *models.NetworkEvent) { event.DestHostname = "git.example.org" })
+	}
+
+	report, err := m.GetDomainStats("", false, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(report.Domains) != 4 || report.Bytes != 6100 || report.Connections != 4 {
+		t.Fatalf("Expected four domains, got %+v", report)
+	}
+	storage := report.Domains[0]
+	if storage.Domain != "storage.googleapis.com" || storage.BytesOut != 400 || storage.BytesIn != 5000 || storage.Connections != 1 {
+		t.Errorf("Expected the storage download first, handshake included, got %+v", storage)
+	}
+
+	report, _ = m.GetDomainStats("today", true, "*.googleapis.com")
+	if report.Day != "2025-07-01" || !report.Since.Equal(time.Date(2025, 7, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("Expected today from midnight, got %s since %s", report.Day, report.Since)
+	}
+	if len(report.Domains) != 1 || report.Domains[0].Domain != "*.googleapis.com" || report.Domains[0].Names != 2 ||
+		report.Domains[0].BytesOut != 600 || report.Domains[0].Connections != 2 {
+		t.Errorf("Expected both googleapis.com names grouped, got %+v", report.Domains)
+	}
+
+	// A new day starts from nothing while the totals carry on
+	now = day.Add(24 * time.Hour)
+	send("192.168.1.10", 50002, "93.184.216.34", 80, 50, nil)
+	report, _ = m.GetDomainStats("today", false, "example.com")
+	if len(report.Domains) != 1 || report.Domains[0].Bytes != 50 || report.Domains[0].Connections != 0 || len(report.Days) != 2 {
+		t.Errorf("Expected only today's bytes, got %+v", report)
+	}
+	if report, _ := m.GetDomainStats("2025-07-01", false, "example.com"); len(report.Domains) != 1 || report.Domains[0].Bytes != 400 {
+		t.Errorf("Expected yesterday's bytes kept, got %+v", report.Domains)
+	}
+	if _, err := m.GetDomainStats("yesterday", false, ""); err == nil {
+		t.Error("Expected an error for an invalid day")
+	}
+}
+
+func TestGetTrafficMatrix(t *testing.T) {
+	m := NewManager("192.168.1.10")
+
+	send := func(srcIP string, srcPort int, dstIP string, dstPort int, size int, sni string) {
+		event := tcpEvent(srcIP, srcPort, dstIP, dstPort, models.TCPPacketFlags{ACK: true})
+		event.Size = size
+		event.TLSServerName = sni
+		m.ProcessEvent(event)
+	}
+	send("192.168.1.10", 50000, "140.82.112.5", 443, 500, "github.com")
+	send("140.82.112.5", 443, "192.168.1.10", 50000, 1500, "")
+	send("192.168.1.10", 50001, "203.0.113.7", 22, 100, "")
+	// Mirrored traffic between other hosts, the first packet seen being the reply
+	send("140.82.112.6", 443, "192.168.1.20", 52000, 300, "github.com")
+	send("192.168.1.20", 53000, "198.51.100.1", 80, 50, "")
+
+	matrix := m.GetTrafficMatrix(0, 1)
+	if len(matrix.Locals) != 2 || matrix.Locals[0] != "192.168.1.10" || matrix.Locals[1] != "192.168.1.20" {
+		t.Fatalf("Expected both local hosts, busiest first, got %v", matrix.Locals)
+	}
+	if len(matrix.Remotes) != 1 || matrix.Remotes[0] != "github.com" {
+		t.Fatalf("Expected github.com as the top remote, got %v", matrix.Remotes)
+	}
+	if matrix.Bytes[0][0] != 2000 || matrix.Bytes[1][0] != 300 {
+		t.Errorf("Unexpected matrix cells: %v", matrix.Bytes)
+	}
+	if matrix.Other[0] != 100 || matrix.Other[1] != 50 {
+		t.Errorf("Expected traffic with unlisted remotes under other, got %v", matrix.Other)
+	}
+}
+
+func TestStateChanges(t *testing.T) {
+	m := NewManager("192.168.1.10")
+	start := time.Now()
+	var changes []models.ConversationStateChange
+	m.OnStateChange = func(change models.ConversationStateChange) {
+		// Called outside the lock, so the manager can be asked about it
+		if len(m.GetConversationSummaries()) == 0 {
+			t.Errorf("Expected the changed conversation to be tracked")
+		}
+		changes = append(changes, change)
+	}
+
+	syn := tcpEvent("192.168.1.10", 50000, "203.0.113.7", 443, models.TCPPacketFlags{SYN: true})
+	syn.Timestamp = start
+	m.ProcessEvent(syn)
+	synAck := tcpEvent("203.0.113.7", 443, "192.168.1.10", 50000, models.TCPPacketFlags{SYN: true, ACK: true})
+	synAck.Timestamp = start.Add(10 * time.Millisecond)
+	m.ProcessEvent(synAck)
+	if len(changes) != 0 {
+		t.Fatalf("Expected no change before the handshake completes, got %+v", changes)
+	}
+
+	ack := tcpEvent("192.168.1.10", 50000, "203.0.113.7", 443, models.TCPPacketFlags{ACK: true})
+	ack.Timestamp = start.Add(20 * time.Millisecond)
+	m.ProcessEvent(ack)
+	m.ProcessEvent(ack)
+	rst := tcpEvent("203.0.113.7", 443, "192.168.1.10", 50000, models.TCPPacketFlags{RST: true})
+	rst.Timestamp = start.Add(time.Second)
+	m.ProcessEvent(rst)
+
+	if len(changes) != 2 {
+		t.Fatalf("Expected the handshake and the reset, got %+v", changes)
+	}
+	if c := changes[0]; c.From != models.ConversationStateNew || c.To != models.ConversationStateEstablished || c.Reason != models.StateReasonHandshake {
+		t.Errorf("Expected NEW to ESTABLISHED on the handshake, got %+v", c)
+	}
+	if c := changes[1]; !c.Closed() || c.Reason != models.StateReasonRST || !c.Time.Equal(rst.Timestamp) || c.Conversation.State != models.ConversationStateClosed {
+		t.Errorf("Expected a close on the reset with the closed summary, got %+v", c)
+	}
+
+	// The cleanup routine closes idle flows with a timeout
+	dns := &models.NetworkEvent{
+		Timestamp:         start,
+		TransportProtocol: "UDP",
+		SourceIP:          "192.168.1.10",
+		SourcePort:        53000,
+		DestIP:            "192.168.1.1",
+		DestPort:          53,
+	}
+	m.ProcessEvent(dns)
+	m.SetClock(func() time.Time { return start.Add(time.Minute) })
+	changes = nil
+	m.CleanupStaleConversations()
+	if len(changes) != 1 || changes[0].Reason != models.StateReasonTimeout || changes[0].From != models.ConversationStateNew || changes[0].Conversation.ID != dns.ConversationID {
+		t.Errorf("Expected the idle DNS flow closed on timeout, got %+v", changes)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	m := NewManager("192.168.1.10")
+	start := time.Now()
+	m.SetTimeouts(time.Minute, 10*time.Second)
+	m.SetRetention(5*time.Minute, 0)
+	if retention, interval := m.Retention(); retention != 5*time.Minute || interval != DefaultCleanupInterval {
+		t.Fatalf("Expected a 5m retention and the default interval, got %s and %s", retention, interval)
+	}
+	var removed []string
+	m.OnRemove = func(conv *models.Conversation) { removed = append(removed, conv.ID) }
+
+	event := tcpEvent("192.168.1.10", 50000, "203.0.113.7", 443, models.TCPPacketFlags{SYN: true})
+	event.Timestamp = start
+	m.ProcessEvent(event)
+
+	// Timed out conversations are kept, closed, until the retention passes
+	m.SetClock(func() time.Time { return start.Add(2 * time.Minute) })
+	m.CleanupStaleConversations()
+	if conv, ok := m.GetConversation(event.ConversationID); !ok || conv.State != models.ConversationStateClosed || len(removed) != 0 {
+		t.Fatalf("Expected the timed out conversation closed but kept, got %+v", conv)
+	}
+	m.SetClock(func() time.Time { return start.Add(6 * time.Minute) })
+	m.CleanupStaleConversations()
+	if _, ok := m.GetConversation(event.ConversationID); ok || len(removed) != 1 {
+		t.Errorf("Expected the conversation forgotten after the retention, got %v removed", removed)
+	}
+}
+
+func TestFlush(t *testing.T) {
+	m := NewManager("192.168.1.10")
+	var removed []string
+	m.OnRemove = func(conv *models.Conversation) { removed = append(removed, conv.ID) }
+
+	m.ProcessEvent(tcpEvent("192.168.1.10", 50000, "203.0.113.7", 443, models.TCPPacketFlags{SYN: true}))
+	m.ProcessEvent(tcpEvent("192.168.1.10", 50001, "203.0.113.8", 443, models.TCPPacketFlags{SYN: true}))
+	if n := m.Flush(); n != 2 || len(removed) != 2 {
+		t.Fatalf("Expected both conversations flushed and handed to OnRemove, got %d and %v", n, removed)
+	}
+	if all := m.GetAllConversations(); len(all) != 0 {
+		t.Errorf("Expected no conversations after a flush, got %d", len(all))
+	}
+
+	// The same flow starts a new conversation afterwards
+	m.ProcessEvent(tcpEvent("192.168.1.10", 50000, "203.0.113.7", 443, models.TCPPacketFlags{ACK: true}))
+	if all := m.GetAllConversations(); len(all) != 1 || all[0].ID == removed[0] || all[0].ID == removed[1] {
+		t.Errorf("Expected a fresh conversation, got %+v", all)
+	}
+}
+
+func TestHTTPSummary(t *testing.T) {
+	m := NewManager("192.168.1.10")
+
+	exchange := []*models.HTTPInfo{
+		{Method: "GET", Host: "example.com", Path: "/", UserAgent: "curl/8.4.0", Version: "HTTP/1.1"},
+		{Response: true, StatusCode: 200, Version: "HTTP/1.1"},
+		{Method: "GET", Path: "/missing", Version: "HTTP/1.1"},
+		{Response: true, StatusCode: 404, Version: "HTTP/1.1"},
+	}
+	for _, info := range exchange {
+		event := tcpEvent("192.168.1.10", 50000, "93.184.216.34", 80, models.TCPPacketFlags{ACK: true, PSH: true})
+		if info.Response {
+			event = tcpEvent("93.184.216.34", 80, "192.168.1.10", 50000, models.TCPPacketFlags{ACK: true, PSH: true})
+		}
+		event.AppProtocol = "HTTP"
+		event.HTTP = info
+		m.ProcessEvent(event)
+	}
+
+	convs := m.GetConversationSummaries()
+	if len(convs) != 1 || convs[0].HTTP == nil {
+		t.Fatalf("Expected one conversation with HTTP, got %+v", convs)
+	}
+	http := convs[0].HTTP
+	if http.Requests != 2 || http.Responses != 2 || http.Errors != 1 || http.StatusCode != 404 {
+		t.Errorf("Unexpected HTTP counts: %+v", http)
+	}
+	// The latest request's line, the host and agent from whichever request sent them
+	if http.Method != "GET" || http.Path != "/missing" || http.Host != "example.com" || http.UserAgent != "curl/8.4.0" {
+		t.Errorf("Unexpected latest request: %+v", http)
+	}
+}
+
+func TestGetEncryptionStats(t *testing.T) {
+	m := NewManager("192.168.1.10")
+
+	send := func(srcIP string, srcPort int, dstIP string, dstPort int, size int, class string) {
+		event := tcpEvent(srcIP, srcPort, dstIP, dstPort, models.TCPPacketFlags{ACK: true, PSH: true})
+		event.Size, event.Encryption = size, class
+		m.ProcessEvent(event)
+	}
+	// HTTPS: the handshake before the first record is unknown, the rest encrypted
+	send("192.168.1.10", 50000, "140.82.112.3", 443, 60, "")
+	send("192.168.1.10", 50000, "140.82.112.3", 443, 500, models.EncryptionTLS)
+	send("140.82.112.3", 443, "192.168.1.10", 50000, 1500, "")
+	// SMTP starts in the clear and upgrades with STARTTLS
+	send("192.168.1.10", 50001, "198.51.100.25", 25, 100, models.EncryptionPlaintext)
+	send("192.168.1.10", 50001, "198.51.100.25", 25, 300, models.EncryptionTLS)
+	send("198.51.100.25", 25, "192.168.1.10", 50001, 200, models.EncryptionPlaintext)
+	// Plain HTTP
+	send("192.168.1.10", 50002, "93.184.216.34", 80, 400, models.EncryptionPlaintext)
+
+	report := m.GetEncryptionStats()
+	if len(report.Services) != 3 {
+		t.Fatalf("Expected 3 services, got %+v", report.Services)
+	}
+	// Most cleartext first
+	http, smtp, https := report.Services[0], report.Services[1], report.Services[2]
+	if http.Service != "TCP/80" || http.PlaintextBytes != 400 || http.EncryptedRatio != 0 {
+		t.Errorf("Unexpected HTTP stats: %+v", http)
+	}
+	if smtp.Service != "TCP/25" || smtp.PlaintextBytes != 100 || smtp.EncryptedBytes != 500 || smtp.EncryptedRatio != 500.0/600 {
+		t.Errorf("Expected SMTP to stay encrypted after STARTTLS, got %+v", smtp)
+	}
+	if https.Service != "TCP/443" || https.UnknownBytes != 60 || https.EncryptedBytes != 2000 || https.EncryptedRatio != 1 {
+		t.Errorf("Unexpected HTTPS stats: %+v", https)
+	}
+	if report.EncryptedBytes != 2500 || report.PlaintextBytes != 500 || report.UnknownBytes != 60 {
+		t.Errorf("Unexpected totals: %+v", report)
+	}
+	if len(report.Hourly) != 1 || report.Hourly[0].EncryptedBytes != 2500 {
+		t.Errorf("Expected the traffic in one hourly slot, got %+v", report.Hourly)
+	}
+
+	// The counts outlive the conversations
+	m.Flush()
+	if after := m.GetEncryptionStats(); after.EncryptedBytes != 2500 || len(after.Services) != 3 {
+		t.Errorf("Expected the counts kept after the conversations were dropped, got %+v", after)
+	}
+	if convs := m.GetConversationSummaries(); len(convs) != 0 {
+		t.Errorf("Expected no conversations after flush, got %d", len(convs))
+	}
+}
+
+func TestTLSSummary(t *testing.T) {
+	m := NewManager("192.168.1.10")
+
+	hello := tcpEvent("192.168.1.10", 50000, "198.51.100.9", 443, models.TCPPacketFlags{ACK: true, PSH: true})
+	hello.TLS = &models.TLSInfo{
+		Handshake:    models.TLSClientHello,
+		Version:      "TLS 1.3",
+		CipherSuites: []string{"TLS_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA"},
+		ALPN:         []string{"h2", "http/1.1"},
+	}
+	m.ProcessEvent(hello)
+	reply := tcpEvent("198.51.100.9", 443, "192.168.1.10", 50000, models.TCPPacketFlags{ACK: true, PSH: true})
+	reply.TLS = &models.TLSInfo{Handshake: models.TLSServerHello, Version: "TLS 1.1", CipherSuite: "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA", ALPN: []string{"http/1.1"}}
+	m.ProcessEvent(reply)
+	// The certificate in a segment of its own leaves the ServerHello's choices alone
+	cert := tcpEvent("198.51.100.9", 443, "192.168.1.10", 50000, models.TCPPacketFlags{ACK: true, PSH: true})
+	cert.TLS = &models.TLSInfo{Handshake: models.TLSCertificate, Certificate: &models.Certificate{Subject: "CN=example.com"}}
+	m.ProcessEvent(cert)
+
+	convs := m.GetConversationSummaries()
+	if len(convs) != 1 || convs[0].TLS == nil {
+		t.Fatalf("Expected one conversation with TLS, got %+v", convs)
+	}
+	tls := convs[0].TLS
+	// The client offered 1.3 but an old server settled on 1.1
+	if tls.ClientVersion != "TLS 1.3" || tls.Version != "TLS 1.1" || !tls.Legacy {
+		t.Errorf("Expected a legacy TLS 1.1 session, got %+v", tls)
+	}
+	if tls.CipherSuite != "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA" || len(tls.OfferedCiphers) != 2 {
+		t.Errorf("Unexpected cipher suites: %+v", tls)
+	}
+	if tls.ALPN != "http/1.1" || len(tls.OfferedALPN) != 2 {
+		t.Errorf("Unexpected ALPN: %+v", tls)
+	}
+	if tls.Certificate == nil || tls.Certificate.Subject != "CN=example.com" {
+		t.Errorf("Expected the server's certificate, got %+v", tls.Certificate)
+	}
+}
+
+func TestConversationPackets(t *testing.T) {
+	m := NewManager("192.168.1.10")
+	m.SetPacketHistory(3)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		event := tcpEvent("192.168.1.10", 50000, "203.0.113.7", 443, models.TCPPacketFlags{ACK: true})
+		event.Timestamp = start.Add(time.Duration(i) * time.Second)
+		event.Size = 100 + i
+		m.ProcessEvent(event)
+	}
+	id := m.GetConversationSummaries()[0].ID
+
+	packets, ok := m.GetConversationPackets(id, 0)
+	if !ok || len(packets) != 3 || packets[0].Size != 102 || packets[2].Size != 104 {
+		t.Fatalf("Expected the last three packets oldest first, got %v %+v", ok, packets)
+	}
+	if packets, _ := m.GetConversationPackets(id, 1); len(packets) != 1 || packets[0].Size != 104 {
+		t.Errorf("Expected only the latest packet, got %+v", packets)
+	}
+	if _, ok := m.GetConversationPackets("unknown", 0); ok {
+		t.Error("Expected an unknown conversation not to be found")
+	}
+
+	// Forgetting the conversation forgets its packets
+	m.Flush()
+	kept := 0
+	for _, s := range m.shards {
+		kept += len(s.recent)
+	}
+	if _, ok := m.GetConversationPackets(id, 0); ok || kept != 0 {
+		t.Errorf("Expected no history after a flush, got %d conversations", kept)
+	}
+}
+
+func TestQueryConversations(t *testing.T) {
+	m := NewManager("192.168.1.10")
+	send := func(srcIP string, srcPort int, dstIP string, dstPort int, size int, flags models.TCPPacketFlags) {
+		event := tcpEvent(srcIP, srcPort, dstIP, dstPort, flags)
+		event.Size = size
+		m.ProcessEvent(event)
+	}
+	open := models.TCPPacketFlags{SYN: true}
+	send("192.168.1.10", 50000, "140.82.112.5", 443, 9000, open)
+	send("192.168.1.10", 50001, "203.0.113.7", 22, 800, open)
+	send("192.168.1.10", 50002, "203.0.113.7", 8022, 200, open)
+	send("192.168.1.10", 50003, "203.0.113.9", 22, 100, open)
+	send("203.0.113.9", 22, "192.168.1.10", 50003, 60, models.TCPPacketFlags{RST: true})
+
+	remotes := func(convs []*models.Conversation) []string {
+		var list []string
+		for _, conv := range convs {
+			list = append(list, m.remoteIP(conv)+" "+conv.Service)
+		}
+		return list
+	}
+
+	// The reset conversation is closed, so not among the active ones by default
+	page, total, err := m.QueryConversations(Query{Sort: SortByBytes, Descending: true})
+	if err != nil || total != 3 || fmt.Sprint(remotes(page)) != "[140.82.112.5 HTTPS 203.0.113.7 SSH 203.0.113.7 ]" {
+		t.Fatalf("Expected the active conversations busiest first, got %v of %d (%v)", remotes(page), total, err)
+	}
+	page, total, _ = m.QueryConversations(Query{Sort: SortByBytes, Descending: true, Limit: 1, Offset: 1})
+	if total != 3 || fmt.Sprint(remotes(page)) != "[203.0.113.7 SSH]" {
+		t.Errorf("Expected the second busiest alone, got %v of %d", remotes(page), total)
+	}
+	if page, total, _ = m.QueryConversations(Query{Offset: 5}); total != 3 || page == nil || len(page) != 0 {
+		t.Errorf("Expected an empty page past the end, got %v of %d", page, total)
+	}
+
+	_, network, _ := net.ParseCIDR("203.0.113.0/24")
+	if page, total, _ = m.QueryConversations(Query{Remote: network, Service: "ssh"}); total != 1 || fmt.Sprint(remotes(page)) != "[203.0.113.7 SSH]" {
+		t.Errorf("Expected the active SSH conversation in the network, got %v", remotes(page))
+	}
+	if page, total, _ = m.QueryConversations(Query{State: models.ConversationStateClosed}); total != 1 || fmt.Sprint(remotes(page)) != "[203.0.113.9 SSH]" {
+		t.Errorf("Expected the reset conversation when asking for closed ones, got %v", remotes(page))
+	}
+	if _, _, err := m.QueryConversations(Query{Sort: "size"}); err == nil {
+		t.Error("Expected an unknown sort to be refused")
+	}
+}
+
+func TestShardedLookup(t *testing.T) {
+	m := NewManager("192.168.1.10")
+	for port := 50000; port < 50200; port++ {
+		m.ProcessEvent(tcpEvent("192.168.1.10", port, "203.0.113.7", 443, models.TCPPacketFlags{SYN: true}))
+	}
+
+	used := 0
+	for _, s := range m.shards {
+		if len(s.conversations) > 0 {
+			used++
+		}
+	}
+	if used < conversationShards/2 {
+		t.Errorf("Expected conversations spread over the shards, %d of %d used", used, conversationShards)
+	}
+	for _, conv := range m.GetAllConversations() {
+		if found, ok := m.GetConversation(conv.ID); !ok || found.ID != conv.ID {
+			t.Fatalf("Expected conversation %s to be found by ID", conv.ID)
+		}
+	}
+	for _, id := range []string{"", "x", "00000000-0000-0000-0000-0000000000zz", "00000000-0000-0000-0000-0000000000ff"} {
+		if _, ok := m.GetConversation(id); ok {
+			t.Errorf("Expected ID %q not to be found", id)
+		}
+	}
+}
+
+// BenchmarkProcessEventWithReaders processes packets of many conversations
+// while an API client keeps listing them, with every conversation under one
+// lock as before sharding and with the shards used now
+func BenchmarkProcessEventWithReaders(b *testing.B) {
+	for _, shards := range []int{1, conversationShards} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			m := newManager("192.168.1.10", shards)
+			events := make([]*models.NetworkEvent, 1024)
+			for i := range events {
+				events[i] = tcpEvent("192.168.1.10", 40000+i, "203.0.113.7", 443, models.TCPPacketFlags{ACK: true})
+				m.ProcessEvent(events[i])
+			}
+
+			done := make(chan struct{})
+			defer close(done)
+			go func() {
+				for {
+					select {
+					case <-done:
+						return
+					default:
+						m.GetConversationSummaries()
+					}
+				}
+			}()
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					event := *events[i%len(events)]
+					m.ProcessEvent(&event)
+				}
+			})
+		})
+	}
+}
+
+func TestConversationsAreCopies(t *testing.T) {
+	m := NewManager("192.168.1.10")
+	event := tcpEvent("192.168.1.10", 50000, "203.0.113.7", 443, models.TCPPacketFlags{SYN: true})
+	m.ProcessEvent(event)
+
+	conv, _ := m.GetConversation(event.ConversationID)
+	all, active := m.GetAllConversations(), m.GetActiveConversations()
+	page, _, _ := m.QueryConversations(Query{})
+	m.ProcessEvent(tcpEvent("192.168.1.10", 50000, "203.0.113.7", 443, models.TCPPacketFlags{ACK: true}))
+	for _, copied := range []*models.Conversation{conv, all[0], active[0], page[0]} {
+		if copied.Stats.PacketsOut != 1 || copied.TCPState.ACKSeen {
+			t.Errorf("Expected a copy unchanged by later packets, got %+v", copied.Stats)
+		}
+	}
+
+	// Readers encode what they get while packets keep arriving, run with -race
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			reply := tcpEvent("203.0.113.7", 443, "192.168.1.10", 50000, models.TCPPacketFlags{ACK: true})
+			reply.DSCP = i % 64
+			m.ProcessEvent(reply)
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		conv, _ := m.GetConversation(event.ConversationID)
+		page, _, _ := m.QueryConversations(Query{})
+		for _, v := range []interface{}{conv, m.GetAllConversations(), m.GetActiveConversations(), page} {
+			if _, err := json.Marshal(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	<-done
+}
@@ -0,0 +1,119 @@
+package conversation
+
+import (
+	"net"
+	"sort"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Traffic matrix size when the caller doesn't choose one
+const (
+	DefaultMatrixLocals  = 20
+	DefaultMatrixRemotes = 10
+)
+
+// GetTrafficMatrix returns the bytes exchanged between the busiest maxLocals
+// local hosts and the busiest maxRemotes remote destinations
+func (m *Manager) GetTrafficMatrix(maxLocals, maxRemotes int) models.TrafficMatrix {
+	type pair struct{ local, remote string }
+	pairs := make(map[pair]uint64)
+	localTotals := make(map[string]uint64)
+	remoteTotals := make(map[string]uint64)
+
+	m.mu.RLock()
+	m.eachConversation(func(conv *models.Conversation) {
+		local, remote := m.matrixEnds(conv)
+		bytes := conv.Stats.BytesIn + conv.Stats.BytesOut
+		pairs[pair{local, remote}] += bytes
+		localTotals[local] += bytes
+		remoteTotals[remote] += bytes
+	})
+	m.mu.RUnlock()
+
+	matrix := models.TrafficMatrix{
+		Locals:  busiest(localTotals, maxLocals),
+		Remotes: busiest(remoteTotals, maxRemotes),
+	}
+	rows := make(map[string]int, len(matrix.Locals))
+	for i, local := range matrix.Locals {
+		rows[local] = i
+	}
+	columns := make(map[string]int, len(matrix.Remotes))
+	for j, remote := range matrix.Remotes {
+		columns[remote] = j
+	}
+	matrix.Bytes = make([][]uint64, len(matrix.Locals))
+	for i := range matrix.Bytes {
+		matrix.Bytes[i] = make([]uint64, len(matrix.Remotes))
+	}
+	matrix.Other = make([]uint64, len(matrix.Locals))
+
+	for p, bytes := range pairs {
+		i, ok := rows[p.local]
+		if !ok {
+			continue
+		}
+		if j, ok := columns[p.remote]; ok {
+			matrix.Bytes[i][j] += bytes
+		} else {
+			matrix.Other[i] += bytes
+		}
+	}
+	return matrix
+}
+
+// matrixEnds returns a conversation's local host and the name of its remote
+// end. Without a local address at either end (e.g. a SPAN port) the local host is
+// the private address, or failing that the client, taken to be the higher port.
+func (m *Manager) matrixEnds(conv *models.Conversation) (local, remote string) {
+	key := conv.Key
+	srcLocal := false
+	switch {
+	case m.local.Contains(key.SrcIP):
+		srcLocal = true
+	case m.local.Contains(key.DstIP):
+		srcLocal = false
+	case isLocalAddress(key.SrcIP) != isLocalAddress(key.DstIP):
+		srcLocal = isLocalAddress(key.SrcIP)
+	default:
+		srcLocal = key.SrcPort >= key.DstPort
+	}
+	local, remote = key.DstIP, key.SrcIP
+	if srcLocal {
+		local, remote = key.SrcIP, key.DstIP
+	}
+
+	// The resolved hostname is only known to be the remote's with a local address at one end
+	if conv.ServerName != "" {
+		return local, conv.ServerName
+	}
+	if conv.Hostname != "" && m.local.Contains(local) {
+		return local, conv.Hostname
+	}
+	return local, remote
+}
+
+// isLocalAddress reports whether ip is loopback, private or link-local
+func isLocalAddress(ip string) bool {
+	parsed := net.ParseIP(ip)
+	return parsed != nil && (parsed.IsLoopback() || parsed.IsPrivate() || parsed.IsLinkLocalUnicast())
+}
+
+// busiest returns up to limit keys (all for 0) with the largest totals
+func busiest(totals map[string]uint64, limit int) []string {
+	keys := make([]string, 0, len(totals))
+	for key := range totals {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if totals[keys[i]] != totals[keys[j]] {
+			return totals[keys[i]] > totals[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
@@ -0,0 +1,90 @@
+package conversation
+
+import (
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// DefaultPacketHistory is how many recent events are kept per conversation
+const DefaultPacketHistory = 50
+
+// eventRing keeps a conversation's latest events, overwriting the oldest once full
+type eventRing struct {
+	events []models.NetworkEvent
+	next   int // Where the next event goes once full
+}
+
+func (r *eventRing) add(event models.NetworkEvent, size int) {
+	if len(r.events) < size {
+		r.events = append(r.events, event)
+		return
+	}
+	r.events[r.next] = event
+	r.next = (r.next + 1) % len(r.events)
+}
+
+// latest returns up to limit of the newest events (0 for all), oldest first
+func (r *eventRing) latest(limit int) []models.NetworkEvent {
+	events := make([]models.NetworkEvent, 0, len(r.events))
+	events = append(events, r.events[r.next:]...)
+	events = append(events, r.events[:r.next]...)
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	return events
+}
+
+// SetPacketHistory sets how many recent events are kept per conversation, 0
+// keeping none. Call it before the capture starts.
+func (m *Manager) SetPacketHistory(events int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.packetHistory = events
+	if events <= 0 {
+		for _, s := range m.shards {
+			s.mu.Lock()
+			s.recent = make(map[string]*eventRing)
+			s.mu.Unlock()
+		}
+	}
+}
+
+// PacketHistory returns how many recent events are kept per conversation
+func (m *Manager) PacketHistory() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.packetHistory
+}
+
+// GetConversationPackets returns up to limit of a conversation's most recent
+// events (0 for all that are kept), oldest first, and whether the
+// conversation is tracked
+func (m *Manager) GetConversationPackets(id string, limit int) ([]models.NetworkEvent, bool) {
+	s := m.shardByID(id)
+	if s == nil {
+		return nil, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, exists := s.conversations[id]; !exists {
+		return nil, false
+	}
+	ring, ok := s.recent[id]
+	if !ok {
+		return []models.NetworkEvent{}, true
+	}
+	return ring.latest(limit), true
+}
+
+// recordPacket keeps a copy of the event in its conversation's history, must
+// be called with the manager's and the shard's locks held
+func (m *Manager) recordPacket(s *shard, id string, event *models.NetworkEvent) {
+	if m.packetHistory <= 0 {
+		return
+	}
+	ring, ok := s.recent[id]
+	if !ok {
+		ring = &eventRing{}
+		s.recent[id] = ring
+	}
+	ring.add(*event, m.packetHistory)
+}
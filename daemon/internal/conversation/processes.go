@@ -0,0 +1,81 @@
+package conversation
+
+import (
+	"sort"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// UnknownProcess names the group of conversations not attributed to a program
+const UnknownProcess = "unknown"
+
+// throughputSlots is how many completed rate buckets current throughput averages
+const throughputSlots = 3
+
+// GetProcessStats totals tracked conversations per local program, busiest
+// (by bytes) first
+func (m *Manager) GetProcessStats() []models.ProcessStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := m.now()
+	groups := make(map[string]*models.ProcessStats)
+	pids := make(map[string]map[int]bool)
+
+	m.eachConversation(func(conv *models.Conversation) {
+		name := UnknownProcess
+		if conv.Process != nil {
+			name = conv.Process.Name
+		}
+		group, exists := groups[name]
+		if !exists {
+			group = &models.ProcessStats{Name: name, PIDs: []int{}}
+			groups[name] = group
+			pids[name] = make(map[int]bool)
+		}
+
+		group.Conversations++
+		if conv.IsActive() {
+			group.Active++
+		}
+		group.PacketsIn += conv.Stats.PacketsIn
+		group.PacketsOut += conv.Stats.PacketsOut
+		group.BytesIn += conv.Stats.BytesIn
+		group.BytesOut += conv.Stats.BytesOut
+		group.BytesPerSecond += throughput(conv.Rate.Series(now))
+		if conv.Stats.LastActivity.After(group.LastActivity) {
+			group.LastActivity = conv.Stats.LastActivity
+		}
+		if conv.Process != nil && !pids[name][conv.Process.PID] {
+			pids[name][conv.Process.PID] = true
+			group.PIDs = append(group.PIDs, conv.Process.PID)
+		}
+	})
+
+	list := make([]models.ProcessStats, 0, len(groups))
+	for _, group := range groups {
+		sort.Ints(group.PIDs)
+		list = append(list, *group)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		bi, bj := list[i].BytesIn+list[i].BytesOut, list[j].BytesIn+list[j].BytesOut
+		if bi != bj {
+			return bi > bj
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// throughput returns the bytes per second over the last completed slots of a
+// rate series; the current slot is still filling so it's left out
+func throughput(series []uint64) float64 {
+	if len(series) <= throughputSlots {
+		return 0
+	}
+	var bytes uint64
+	for _, b := range series[len(series)-1-throughputSlots : len(series)-1] {
+		bytes += b
+	}
+	return float64(bytes) / (float64(throughputSlots) * models.RateBucket.Seconds())
+}
@@ -0,0 +1,144 @@
+package conversation
+
+import (
+	"cmp"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Conversation orders
+const (
+	SortByStart    = "start"    // When it started
+	SortByActivity = "activity" // When it last sent a packet
+	SortByBytes    = "bytes"    // Bytes in both directions
+	SortByPackets  = "packets"  // Packets in both directions
+	SortByDuration = "duration" // How long it has lasted
+	SortByRemote   = "remote"   // Remote address
+	SortByService  = "service"  // Detected service
+)
+
+// SortKeys lists the orders QueryConversations accepts
+var SortKeys = []string{SortByStart, SortByActivity, SortByBytes, SortByPackets, SortByDuration, SortByRemote, SortByService}
+
+// Query selects tracked conversations, orders them and picks a page
+type Query struct {
+	State      models.ConversationState // Only those in this state, "" for the active ones
+	Service    string                   // Only those with this service, any case
+	Remote     *net.IPNet               // Only those whose remote end is in this network
+	Sort       string                   // One of SortKeys, SortByStart when ""
+	Descending bool
+	Limit      int // Most returned, 0 for all
+	Offset     int // Matches skipped before the page
+}
+
+// QueryConversations returns copies of the page of conversations matching q,
+// and how many matched in all. Ties keep the order of the conversation IDs.
+func (m *Manager) QueryConversations(q Query) ([]*models.Conversation, int, error) {
+	if q.Sort == "" {
+		q.Sort = SortByStart
+	}
+	known := false
+	for _, key := range SortKeys {
+		known = known || key == q.Sort
+	}
+	if !known {
+		return nil, 0, fmt.Errorf("unknown sort %q (available: %s)", q.Sort, strings.Join(SortKeys, ", "))
+	}
+	if q.Limit < 0 || q.Offset < 0 {
+		return nil, 0, fmt.Errorf("limit and offset can't be negative")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := m.now()
+	var matches []queryMatch
+	m.eachConversation(func(conv *models.Conversation) {
+		if q.State == "" && !conv.IsActive() || q.State != "" && conv.State != q.State {
+			return
+		}
+		if q.Service != "" && !strings.EqualFold(conv.Service, q.Service) {
+			return
+		}
+		if q.Remote != nil && !q.Remote.Contains(net.ParseIP(m.remoteIP(conv))) {
+			return
+		}
+		matches = append(matches, m.newQueryMatch(conv, now))
+	})
+
+	// compare returns <0, 0 or >0 as a sorts before, with or after b
+	compare := func(a, b *queryMatch) int {
+		switch q.Sort {
+		case SortByActivity:
+			return a.activity.Compare(b.activity)
+		case SortByBytes:
+			return cmp.Compare(a.bytes, b.bytes)
+		case SortByPackets:
+			return cmp.Compare(a.packets, b.packets)
+		case SortByDuration:
+			return cmp.Compare(a.duration, b.duration)
+		case SortByRemote:
+			return strings.Compare(a.remote, b.remote)
+		case SortByService:
+			return strings.Compare(a.service, b.service)
+		}
+		return a.start.Compare(b.start)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		order := compare(&matches[i], &matches[j])
+		if order == 0 {
+			return matches[i].conv.ID < matches[j].conv.ID
+		}
+		return order < 0 != q.Descending
+	})
+
+	total := len(matches)
+	if q.Offset >= total {
+		return []*models.Conversation{}, total, nil
+	}
+	matches = matches[q.Offset:]
+	if q.Limit > 0 && len(matches) > q.Limit {
+		matches = matches[:q.Limit]
+	}
+	page := make([]*models.Conversation, len(matches))
+	for i, match := range matches {
+		page[i] = match.conv
+	}
+	return page, total, nil
+}
+
+// queryMatch is a copy of a conversation matching a query with the values
+// it's sorted by, taken under its shard's lock as packets keep changing them
+type queryMatch struct {
+	conv            *models.Conversation
+	start, activity time.Time
+	bytes, packets  uint64
+	duration        time.Duration
+	remote, service string
+}
+
+func (m *Manager) newQueryMatch(conv *models.Conversation, now time.Time) queryMatch {
+	return queryMatch{
+		conv:     conv.Clone(),
+		start:    conv.StartTime,
+		activity: conv.Stats.LastActivity,
+		bytes:    conv.TotalBytes(),
+		packets:  conv.TotalPackets(),
+		duration: conv.DurationAt(now),
+		remote:   m.remoteIP(conv),
+		service:  conv.Service,
+	}
+}
+
+// remoteIP returns the address of the conversation's remote end
+func (m *Manager) remoteIP(conv *models.Conversation) string {
+	if m.local.Contains(conv.Key.SrcIP) {
+		return conv.Key.DstIP
+	}
+	return conv.Key.SrcIP
+}
@@ -0,0 +1,74 @@
+package conversation
+
+import (
+	"encoding/hex"
+	"hash/fnv"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// conversationShards is how many shards conversations are split into
+const conversationShards = 64
+
+// shard holds the conversations whose normalized keys hash to it. Each shard
+// has its own lock, so packets and API readers only wait for each other when
+// they touch the same shard.
+type shard struct {
+	mu            sync.RWMutex
+	conversations map[string]*models.Conversation
+	keyToID       map[string]string     // Maps normalized conversation keys to IDs
+	recent        map[string]*eventRing // Latest events of each conversation, up to packetHistory each
+}
+
+func newShard() *shard {
+	return &shard{
+		conversations: make(map[string]*models.Conversation),
+		keyToID:       make(map[string]string),
+		recent:        make(map[string]*eventRing),
+	}
+}
+
+// shardFor returns the shard of a normalized conversation key, and its index
+func (m *Manager) shardFor(key string) (*shard, int) {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	i := int(h.Sum32() % uint32(len(m.shards)))
+	return m.shards[i], i
+}
+
+// shardByID returns the shard holding the conversation with an ID, or nil
+// for an ID newConversationID didn't make
+func (m *Manager) shardByID(id string) *shard {
+	if len(id) < 2 {
+		return nil
+	}
+	b, err := hex.DecodeString(id[len(id)-2:])
+	if err != nil || int(b[0]) >= len(m.shards) {
+		return nil
+	}
+	return m.shards[b[0]]
+}
+
+// newConversationID returns a random ID whose last byte is the index of the
+// conversation's shard, so it is found by ID without a global index
+func newConversationID(shard int) string {
+	id := uuid.New()
+	id[len(id)-1] = byte(shard)
+	return id.String()
+}
+
+// eachConversation calls fn with every conversation, holding each shard's
+// lock for reading in turn. Callers reading the settings hold m.mu. fn must
+// not keep conv, packets go on changing it once the lock is released; it
+// keeps conv.Clone() instead.
+func (m *Manager) eachConversation(fn func(conv *models.Conversation)) {
+	for _, s := range m.shards {
+		s.mu.RLock()
+		for _, conv := range s.conversations {
+			fn(conv)
+		}
+		s.mu.RUnlock()
+	}
+}
@@ -0,0 +1,112 @@
+package conversation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Top talker groupings
+const (
+	TopByHost    = "host"    // Remote end, by name when known
+	TopByPort    = "port"    // Transport and service port, e.g. "TCP/443"
+	TopByService = "service" // Detected service, or the transport
+	TopByProcess = "process" // Local program, UnknownProcess when not attributed
+)
+
+// TopGroupings lists the groupings GetTopTalkers accepts
+var TopGroupings = []string{TopByHost, TopByPort, TopByService, TopByProcess}
+
+// Top talker defaults and limits
+const (
+	DefaultTopWindow = 5 * time.Minute
+	MaxTopWindow     = models.MinuteWindow * time.Minute
+	DefaultTopLimit  = 10
+)
+
+// GetTopTalkers totals the traffic of tracked conversations over the window
+// ending now, grouped by host, port, service or process, and returns the
+// limit busiest groups (all for 0). Windows are counted to the minute.
+func (m *Manager) GetTopTalkers(by string, window time.Duration, limit int) (models.TopTalkers, error) {
+	known := false
+	for _, grouping := range TopGroupings {
+		known = known || grouping == by
+	}
+	if !known {
+		return models.TopTalkers{}, fmt.Errorf("unknown grouping %q (available: %s)", by, strings.Join(TopGroupings, ", "))
+	}
+	if window <= 0 || window > MaxTopWindow {
+		return models.TopTalkers{}, fmt.Errorf("window must be between 1m and %s", MaxTopWindow)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := m.now()
+	groups := make(map[string]*models.TopTalker)
+	top := models.TopTalkers{By: by, WindowSeconds: int(window.Seconds()), Talkers: []models.TopTalker{}, Time: now}
+
+	m.eachConversation(func(conv *models.Conversation) {
+		in, out := conv.Minutes.Totals(now, window)
+		if in+out == 0 {
+			return
+		}
+		key := m.topKey(conv, by)
+		group, exists := groups[key]
+		if !exists {
+			group = &models.TopTalker{Key: key}
+			groups[key] = group
+		}
+		group.Conversations++
+		group.BytesIn += in
+		group.BytesOut += out
+		group.Bytes += in + out
+		group.BytesPerSecond += throughput(conv.Rate.Series(now))
+		top.TotalBytes += in + out
+	})
+
+	for _, group := range groups {
+		group.Share = float64(group.Bytes) / float64(top.TotalBytes)
+		top.Talkers = append(top.Talkers, *group)
+	}
+	sort.Slice(top.Talkers, func(i, j int) bool {
+		if top.Talkers[i].Bytes != top.Talkers[j].Bytes {
+			return top.Talkers[i].Bytes > top.Talkers[j].Bytes
+		}
+		return top.Talkers[i].Key < top.Talkers[j].Key
+	})
+	if limit > 0 && len(top.Talkers) > limit {
+		top.Talkers = top.Talkers[:limit]
+	}
+	return top, nil
+}
+
+// topKey returns the group a conversation's traffic is counted under
+func (m *Manager) topKey(conv *models.Conversation, by string) string {
+	switch by {
+	case TopByPort:
+		port := conv.Key.DstPort
+		if conv.Key.SrcPort > 0 && (port == 0 || conv.Key.SrcPort < port) {
+			port = conv.Key.SrcPort
+		}
+		if port == 0 {
+			return conv.Key.Protocol
+		}
+		return fmt.Sprintf("%s/%d", conv.Key.Protocol, port)
+	case TopByService:
+		if conv.Service != "" {
+			return conv.Service
+		}
+		return conv.Key.Protocol
+	case TopByProcess:
+		if conv.Process != nil {
+			return conv.Process.Name
+		}
+		return UnknownProcess
+	}
+	_, remote := m.matrixEnds(conv)
+	return remote
+}
@@ -0,0 +1,201 @@
+package dhcp
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// maxLeases bounds the table, the least recently seen clients are forgotten first
+const maxLeases = 10000
+
+// Lease states, from the latest message seen for the client
+const (
+	StateDiscovering = "discovering" // Looking for a server
+	StateOffered     = "offered"     // A server offered an address
+	StateRequesting  = "requesting"  // Asking for an address, or to renew one
+	StateBound       = "bound"       // A server acknowledged the lease, or the client informed it of an address set by hand
+	StateRejected    = "rejected"    // A server refused the request
+	StateDeclined    = "declined"    // The client found the address in use
+	StateReleased    = "released"    // The client gave the address back
+)
+
+// Lease is what DHCP traffic has revealed about a client
+type Lease struct {
+	MAC          string     `json:"mac"`
+	IP           string     `json:"ip,omitempty"`
+	Hostname     string     `json:"hostname,omitempty"`
+	VendorClass  string     `json:"vendor_class,omitempty"`
+	Server       string     `json:"server,omitempty"` // The DHCP server that offered or acknowledged the lease
+	State        string     `json:"state"`
+	LeaseSeconds uint32     `json:"lease_seconds,omitempty"`
+	Expires      *time.Time `json:"expires,omitempty"` // When the acknowledged lease runs out unless renewed
+	FirstSeen    time.Time  `json:"first_seen"`
+	LastSeen     time.Time  `json:"last_seen"`
+}
+
+// Table keeps a lease per client MAC, learned from the DHCP messages the
+// capture sees. Clients broadcast theirs, so every client on the segment is
+// seen; acks are often unicast, so an address may stay offered or requested.
+type Table struct {
+	leases map[string]*Lease // By MAC
+	byIP   map[string]string // IP -> MAC holding it
+	mu     sync.Mutex
+}
+
+// NewTable creates an empty lease table
+func NewTable() *Table {
+	return &Table{
+		leases: make(map[string]*Lease),
+		byIP:   make(map[string]string),
+	}
+}
+
+// Observe records a DHCP message seen at a time
+func (t *Table) Observe(info *models.DHCPInfo, at time.Time) {
+	if info == nil || info.ClientMAC == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lease, known := t.leases[info.ClientMAC]
+	if !known {
+		if len(t.leases) >= maxLeases {
+			t.forgetOldest()
+		}
+		lease = &Lease{MAC: info.ClientMAC, FirstSeen: at}
+		t.leases[info.ClientMAC] = lease
+	}
+	lease.LastSeen = at
+	if info.Hostname != "" {
+		lease.Hostname = info.Hostname
+	}
+	if info.VendorClass != "" {
+		lease.VendorClass = info.VendorClass
+	}
+
+	switch info.Type {
+	case models.DHCPDiscover:
+		lease.State = StateDiscovering
+	case models.DHCPOffer:
+		lease.State = StateOffered
+		lease.Server = info.ServerID
+		t.assign(lease, info.YourIP)
+	case models.DHCPRequest:
+		lease.State = StateRequesting
+		if ip := first(info.RequestedIP, info.ClientIP); lease.IP == "" {
+			t.assign(lease, ip)
+		}
+	case models.DHCPAck:
+		lease.State = StateBound
+		lease.Server = info.ServerID
+		t.assign(lease, first(info.YourIP, info.ClientIP))
+		if info.LeaseSeconds > 0 && info.YourIP != "" {
+			lease.LeaseSeconds = info.LeaseSeconds
+			expires := at.Add(time.Duration(info.LeaseSeconds) * time.Second)
+			lease.Expires = &expires
+		}
+	case models.DHCPInform:
+		lease.State = StateBound
+		t.assign(lease, info.ClientIP)
+	case models.DHCPNak:
+		lease.State = StateRejected
+	case models.DHCPDecline:
+		lease.State = StateDeclined
+	case models.DHCPRelease:
+		lease.State = StateReleased
+		lease.Expires = nil
+	}
+}
+
+// assign records that a lease holds ip, must be called with the lock held
+func (t *Table) assign(lease *Lease, ip string) {
+	if ip == "" || ip == lease.IP {
+		return
+	}
+	if t.byIP[lease.IP] == lease.MAC {
+		delete(t.byIP, lease.IP)
+	}
+	lease.IP = ip
+	t.byIP[ip] = lease.MAC
+}
+
+// forgetOldest drops the least recently seen client, must be called with the lock held
+func (t *Table) forgetOldest() {
+	var oldest *Lease
+	for _, lease := range t.leases {
+		if oldest == nil || lease.LastSeen.Before(oldest.LastSeen) {
+			oldest = lease
+		}
+	}
+	if t.byIP[oldest.IP] == oldest.MAC {
+		delete(t.byIP, oldest.IP)
+	}
+	delete(t.leases, oldest.MAC)
+}
+
+// Leases returns every client seen, most recently seen first
+func (t *Table) Leases() []Lease {
+	t.mu.Lock()
+	leases := make([]Lease, 0, len(t.leases))
+	for _, lease := range t.leases {
+		leases = append(leases, lease.copy())
+	}
+	t.mu.Unlock()
+
+	sort.Slice(leases, func(i, j int) bool {
+		if !leases[i].LastSeen.Equal(leases[j].LastSeen) {
+			return leases[i].LastSeen.After(leases[j].LastSeen)
+		}
+		return leases[i].MAC < leases[j].MAC
+	})
+	return leases
+}
+
+// Hostname returns the name the client holding ip gave, or "" if none did
+func (t *Table) Hostname(ip string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if lease, ok := t.leases[t.byIP[ip]]; ok {
+		return lease.Hostname
+	}
+	return ""
+}
+
+// GetStats returns table metrics
+func (t *Table) GetStats() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	bound := 0
+	for _, lease := range t.leases {
+		if lease.State == StateBound {
+			bound++
+		}
+	}
+	return map[string]interface{}{
+		"clients": len(t.leases),
+		"bound":   bound,
+	}
+}
+
+func (l *Lease) copy() Lease {
+	lease := *l
+	if l.Expires != nil {
+		expires := *l.Expires
+		lease.Expires = &expires
+	}
+	return lease
+}
+
+// first returns the first of values that isn't ""
+func first(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package dhcp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func TestTable(t *testing.T) {
+	table := NewTable()
+	start := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	mac := "aa:bb:cc:00:11:22"
+
+	// The client names itself when discovering and requesting, the server acknowledges
+	table.Observe(&models.DHCPInfo{Type: models.DHCPDiscover, ClientMAC: mac, Hostname: "kitchen-ipad", VendorClass: "android-dhcp-14"}, start)
+	table.Observe(&models.DHCPInfo{Type: models.DHCPOffer, ClientMAC: mac, YourIP: "192.168.1.50", ServerID: "192.168.1.1"}, start.Add(time.Second))
+	if leases := table.Leases(); len(leases) != 1 || leases[0].State != StateOffered || leases[0].IP != "192.168.1.50" {
+		t.Fatalf("Expected an offered lease, got %+v", leases)
+	}
+	table.Observe(&models.DHCPInfo{Type: models.DHCPRequest, ClientMAC: mac, RequestedIP: "192.168.1.50"}, start.Add(2*time.Second))
+	table.Observe(&models.DHCPInfo{Type: models.DHCPAck, ClientMAC: mac, YourIP: "192.168.1.50", ServerID: "192.168.1.1", LeaseSeconds: 3600}, start.Add(3*time.Second))
+
+	lease := table.Leases()[0]
+	if lease.State != StateBound || lease.Hostname != "kitchen-ipad" || lease.VendorClass != "android-dhcp-14" || lease.Server != "192.168.1.1" {
+		t.Errorf("Unexpected lease: %+v", lease)
+	}
+	if lease.Expires == nil || !lease.Expires.Equal(start.Add(time.Hour+3*time.Second)) || !lease.FirstSeen.Equal(start) {
+		t.Errorf("Expected the lease to expire an hour after the ack, got %+v", lease)
+	}
+	if name := table.Hostname("192.168.1.50"); name != "kitchen-ipad" {
+		t.Errorf("Expected the address named by its client, got %q", name)
+	}
+
+	// A new address moves the name with it, and a release ends the lease
+	table.Observe(&models.DHCPInfo{Type: models.DHCPAck, ClientMAC: mac, YourIP: "192.168.1.51", LeaseSeconds: 3600}, start.Add(time.Hour))
+	if table.Hostname("192.168.1.50") != "" || table.Hostname("192.168.1.51") != "kitchen-ipad" {
+		t.Error("Expected the name to follow the new address")
+	}
+	table.Observe(&models.DHCPInfo{Type: models.DHCPRelease, ClientMAC: mac, ClientIP: "192.168.1.51"}, start.Add(2*time.Hour))
+	if lease := table.Leases()[0]; lease.State != StateReleased || lease.Expires != nil {
+		t.Errorf("Expected a released lease, got %+v", lease)
+	}
+
+	// A client with an address set by hand informs the server of it
+	table.Observe(&models.DHCPInfo{Type: models.DHCPInform, ClientMAC: "aa:bb:cc:00:11:33", ClientIP: "192.168.1.5", Hostname: "nas"}, start.Add(3*time.Hour))
+	leases := table.Leases()
+	if len(leases) != 2 || leases[0].MAC != "aa:bb:cc:00:11:33" || leases[0].State != StateBound || table.Hostname("192.168.1.5") != "nas" {
+		t.Errorf("Expected the informing client first and bound, got %+v", leases)
+	}
+	if stats := table.GetStats(); stats["clients"] != 2 || stats["bound"] != 1 {
+		t.Errorf("Unexpected stats: %v", stats)
+	}
+}
@@ -0,0 +1,199 @@
+package discovery
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Inventory limits
+const (
+	maxDevices  = 10000 // Devices kept, the least recently seen are forgotten first
+	maxServices = 64    // Services kept per device, the first advertised
+)
+
+// Service is a service a device advertises
+type Service struct {
+	Protocol  string    `json:"protocol"` // mdns or ssdp
+	Type      string    `json:"type"`
+	Instance  string    `json:"instance,omitempty"`
+	Port      int       `json:"port,omitempty"`
+	Location  string    `json:"location,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Device is a local address advertising services over mDNS or SSDP
+type Device struct {
+	IP        string    `json:"ip"`
+	Name      string    `json:"name,omitempty"`   // The friendly name given over mDNS, e.g. "Living Room TV"
+	Host      string    `json:"host,omitempty"`   // The mDNS host name, e.g. "Kims-MacBook-Air"
+	Server    string    `json:"server,omitempty"` // The SSDP SERVER header, e.g. "Roku/9.4.0 UPnP/1.0 Roku/9.4.0"
+	Services  []Service `json:"services"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Inventory keeps the devices that announce themselves over mDNS (Bonjour)
+// and SSDP (UPnP) and the services they offer: printers, speakers, TVs and
+// other gadgets that would otherwise only be known by their address. A
+// service is dropped when its device withdraws it.
+type Inventory struct {
+	devices map[string]*Device // By IP
+	mu      sync.Mutex
+}
+
+// NewInventory creates an empty inventory
+func NewInventory() *Inventory {
+	return &Inventory{devices: make(map[string]*Device)}
+}
+
+// Observe records the services an event advertises, if any
+func (inv *Inventory) Observe(event *models.NetworkEvent) {
+	info := event.Discovery
+	if info == nil || event.SourceIP == "" {
+		return
+	}
+
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	device, known := inv.devices[event.SourceIP]
+	if !known {
+		if len(inv.devices) >= maxDevices {
+			inv.forgetOldest()
+		}
+		device = &Device{IP: event.SourceIP, FirstSeen: event.Timestamp}
+		inv.devices[event.SourceIP] = device
+	}
+	device.LastSeen = event.Timestamp
+	if info.Name != "" {
+		device.Name = info.Name
+	}
+	if info.Host != "" {
+		device.Host = info.Host
+	}
+	if info.Server != "" {
+		device.Server = info.Server
+	}
+
+	for _, advertised := range info.Services {
+		i := device.find(info.Protocol, advertised)
+		if advertised.Goodbye {
+			if i >= 0 {
+				device.Services = append(device.Services[:i], device.Services[i+1:]...)
+			}
+			continue
+		}
+		if i < 0 {
+			if len(device.Services) >= maxServices {
+				continue
+			}
+			device.Services = append(device.Services, Service{
+				Protocol:  info.Protocol,
+				Type:      advertised.Type,
+				Instance:  advertised.Instance,
+				FirstSeen: event.Timestamp,
+			})
+			i = len(device.Services) - 1
+		}
+		service := &device.Services[i]
+		service.LastSeen = event.Timestamp
+		if advertised.Port != 0 {
+			service.Port = advertised.Port
+		}
+		if advertised.Location != "" {
+			service.Location = advertised.Location
+		}
+	}
+}
+
+// Label returns what the device calls itself: its friendly name, or failing
+// that its host name, or "" if it gave neither
+func (d Device) Label() string {
+	if d.Name != "" {
+		return d.Name
+	}
+	return d.Host
+}
+
+// find returns the index of a device's service, or -1
+func (d *Device) find(protocol string, advertised models.DiscoveredService) int {
+	for i, service := range d.Services {
+		if service.Protocol == protocol && service.Type == advertised.Type && service.Instance == advertised.Instance {
+			return i
+		}
+	}
+	return -1
+}
+
+// forgetOldest drops the least recently seen device, must be called with the lock held
+func (inv *Inventory) forgetOldest() {
+	var oldest *Device
+	for _, device := range inv.devices {
+		if oldest == nil || device.LastSeen.Before(oldest.LastSeen) {
+			oldest = device
+		}
+	}
+	delete(inv.devices, oldest.IP)
+}
+
+// Devices returns every device seen, named ones first in label order, then
+// the rest by address. Each device's services are sorted by type.
+func (inv *Inventory) Devices() []Device {
+	inv.mu.Lock()
+	devices := make([]Device, 0, len(inv.devices))
+	for _, device := range inv.devices {
+		copied := *device
+		copied.Services = append([]Service{}, device.Services...)
+		devices = append(devices, copied)
+	}
+	inv.mu.Unlock()
+
+	for _, device := range devices {
+		services := device.Services
+		sort.Slice(services, func(i, j int) bool {
+			if services[i].Type != services[j].Type {
+				return services[i].Type < services[j].Type
+			}
+			return services[i].Instance < services[j].Instance
+		})
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		a, b := strings.ToLower(devices[i].Label()), strings.ToLower(devices[j].Label())
+		if (a == "") != (b == "") {
+			return a != ""
+		}
+		if a != b {
+			return a < b
+		}
+		return devices[i].IP < devices[j].IP
+	})
+	return devices
+}
+
+// Name returns the label of the device at ip, or "" if it gave no name
+func (inv *Inventory) Name(ip string) string {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if device, ok := inv.devices[ip]; ok {
+		return device.Label()
+	}
+	return ""
+}
+
+// GetStats returns inventory metrics
+func (inv *Inventory) GetStats() map[string]interface{} {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	services := 0
+	for _, device := range inv.devices {
+		services += len(device.Services)
+	}
+	return map[string]interface{}{
+		"devices":  len(inv.devices),
+		"services": services,
+	}
+}
@@ -0,0 +1,72 @@
+package discovery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func TestInventory(t *testing.T) {
+	inv := NewInventory()
+	start := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	cast := models.DiscoveredService{Type: "_googlecast._tcp", Instance: "Living Room TV-1a2b", Port: 8009}
+
+	// A Chromecast announces itself over mDNS, then over SSDP
+	inv.Observe(&models.NetworkEvent{Timestamp: start, SourceIP: "192.168.1.40", Discovery: &models.DiscoveryInfo{
+		Protocol: models.DiscoveryMDNS, Name: "Living Room TV", Host: "1a2b", Services: []models.DiscoveredService{cast},
+	}})
+	inv.Observe(&models.NetworkEvent{Timestamp: start.Add(time.Second), SourceIP: "192.168.1.40", Discovery: &models.DiscoveryInfo{
+		Protocol: models.DiscoverySSDP, Server: "Linux/3.8 UPnP/1.0 GUPnP/0.20",
+		Services: []models.DiscoveredService{{Type: "urn:dial-multiscreen-org:service:dial:1", Port: 8008, Location: "http://192.168.1.40:8008/ssdp/device-desc.xml"}},
+	}})
+	// A host name alone doesn't replace the friendly name
+	inv.Observe(&models.NetworkEvent{Timestamp: start.Add(2 * time.Second), SourceIP: "192.168.1.40", Discovery: &models.DiscoveryInfo{
+		Protocol: models.DiscoveryMDNS, Host: "1a2b",
+	}})
+	// A printer gives only its host name, a router only a SERVER header
+	inv.Observe(&models.NetworkEvent{Timestamp: start, SourceIP: "192.168.1.41", Discovery: &models.DiscoveryInfo{
+		Protocol: models.DiscoveryMDNS, Host: "office-printer", Services: []models.DiscoveredService{{Type: "_ipp._tcp", Instance: "Office", Port: 631}},
+	}})
+	inv.Observe(&models.NetworkEvent{Timestamp: start, SourceIP: "192.168.1.1", Discovery: &models.DiscoveryInfo{
+		Protocol: models.DiscoverySSDP, Server: "RouterOS UPnP/1.0",
+	}})
+
+	devices := inv.Devices()
+	if len(devices) != 3 || devices[0].IP != "192.168.1.40" || devices[1].IP != "192.168.1.41" || devices[2].IP != "192.168.1.1" {
+		t.Fatalf("Expected named devices first in name order, got %+v", devices)
+	}
+	tv := devices[0]
+	if tv.Name != "Living Room TV" || tv.Host != "1a2b" || tv.Server != "Linux/3.8 UPnP/1.0 GUPnP/0.20" || !tv.FirstSeen.Equal(start) || !tv.LastSeen.Equal(start.Add(2*time.Second)) {
+		t.Errorf("Unexpected device: %+v", tv)
+	}
+	if len(tv.Services) != 2 || tv.Services[0].Type != "_googlecast._tcp" || tv.Services[0].Port != 8009 || tv.Services[1].Protocol != models.DiscoverySSDP || tv.Services[1].Port != 8008 {
+		t.Errorf("Expected both services sorted by type, got %+v", tv.Services)
+	}
+	if name := inv.Name("192.168.1.41"); name != "office-printer" {
+		t.Errorf("Expected the host name without a friendly name, got %q", name)
+	}
+	if name := inv.Name("192.168.1.99"); name != "" {
+		t.Errorf("Expected no name for an unknown address, got %q", name)
+	}
+
+	// Repeated announcements update the service, a goodbye drops it
+	cast.Goodbye = true
+	inv.Observe(&models.NetworkEvent{Timestamp: start.Add(time.Minute), SourceIP: "192.168.1.40", Discovery: &models.DiscoveryInfo{
+		Protocol: models.DiscoveryMDNS, Services: []models.DiscoveredService{cast},
+	}})
+	if tv := inv.Devices()[0]; len(tv.Services) != 1 || tv.Services[0].Protocol != models.DiscoverySSDP {
+		t.Errorf("Expected the withdrawn service dropped, got %+v", tv.Services)
+	}
+
+	stats := inv.GetStats()
+	if stats["devices"] != 3 || stats["services"] != 2 {
+		t.Errorf("Unexpected stats: %v", stats)
+	}
+
+	// Events without discovery information are ignored
+	inv.Observe(&models.NetworkEvent{Timestamp: start, SourceIP: "192.168.1.50"})
+	if len(inv.Devices()) != 3 {
+		t.Error("Expected an event without discovery information to be ignored")
+	}
+}
@@ -0,0 +1,414 @@
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Batching and retry limits
+const (
+	DefaultBatchSize     = 1000
+	DefaultFlushInterval = 5 * time.Second
+	maxQueueBatches      = 20 // Queue at most this many batches while the cluster is unreachable
+	minBackoff           = time.Second
+	maxBackoff           = time.Minute
+	requestTimeout       = 30 * time.Second
+)
+
+// Config describes the cluster and indices documents go to
+type Config struct {
+	URL           string // Cluster URL, e.g. https://localhost:9200
+	IndexPrefix   string // Indices are <prefix>-events-YYYY.MM.DD and <prefix>-conversations-YYYY.MM.DD
+	Username      string // Basic auth, unless APIKey is set
+	Password      string
+	APIKey        string        // Base64 API key, sent as "ApiKey <key>"
+	BatchSize     int           // Documents per bulk request, DefaultBatchSize when 0
+	FlushInterval time.Duration // Longest a document waits to be sent, DefaultFlushInterval when 0
+}
+
+// conversationDoc is the document kept for a conversation, replaced by each
+// update until it closes
+type conversationDoc struct {
+	models.ConversationSummary
+	Closed bool `json:"closed"`
+}
+
+// Indexer is a sink batching events and conversation summaries into bulk
+// requests. Events go to daily indices by their time; each conversation is one
+// document, with its ID, in the index of the day it started. Index templates
+// map addresses, times and counters on first use. Failed requests are retried
+// with exponential backoff while documents queue up to a limit, the oldest
+// dropped beyond it.
+type Indexer struct {
+	config Config
+	url    string
+	client *http.Client
+
+	mu        sync.Mutex
+	queue     [][]byte // Bulk action and document lines, one entry per document
+	templated bool
+	indexed   uint64
+	failed    uint64
+	dropped   uint64
+	retries   uint64
+	lastErr   string
+	closed    bool
+
+	wake    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewIndexer creates an indexer and starts sending batches in the background
+func NewIndexer(config Config) (*Indexer, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("expected an http or https cluster URL, got %q", config.URL)
+	}
+	if config.IndexPrefix == "" {
+		config.IndexPrefix = "netty"
+	}
+	if config.IndexPrefix != strings.ToLower(config.IndexPrefix) || strings.ContainsAny(config.IndexPrefix, `*\/?"<>| ,#:`) {
+		return nil, fmt.Errorf("index prefix %q must be lowercase without wildcards, separators or spaces", config.IndexPrefix)
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultFlushInterval
+	}
+
+	x := &Indexer{
+		config:  config,
+		url:     strings.TrimSuffix(config.URL, "/"),
+		client:  &http.Client{Timeout: requestTimeout},
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go x.run()
+	return x, nil
+}
+
+// Name returns the sink label
+func (x *Indexer) Name() string {
+	return "elasticsearch"
+}
+
+// WriteEvent queues an event for its day's events index
+func (x *Indexer) WriteEvent(event *models.NetworkEvent) error {
+	return x.enqueue(x.index("events", event.Timestamp), "", event)
+}
+
+// WriteConversation queues a conversation summary, replacing the document
+// of its earlier updates
+func (x *Indexer) WriteConversation(summary models.ConversationSummary, closed bool) error {
+	return x.enqueue(x.index("conversations", summary.StartTime), summary.ID, conversationDoc{summary, closed})
+}
+
+// index names the index of a kind of document for a day
+func (x *Indexer) index(kind string, at time.Time) string {
+	return x.config.IndexPrefix + "-" + kind + "-" + at.UTC().Format("2006.01.02")
+}
+
+// enqueue adds a document's bulk lines to the queue, waking the sender once
+// a batch is ready
+func (x *Indexer) enqueue(index, id string, doc interface{}) error {
+	action := map[string]map[string]string{"index": {"_index": index}}
+	if id != "" {
+		action["index"]["_id"] = id
+	}
+	var lines bytes.Buffer
+	enc := json.NewEncoder(&lines)
+	if err := enc.Encode(action); err != nil {
+		return err
+	}
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode document for %s: %w", index, err)
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.closed {
+		return nil
+	}
+	x.queue = append(x.queue, lines.Bytes())
+	x.trim()
+	if len(x.queue) >= x.config.BatchSize {
+		select {
+		case x.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// trim drops the oldest documents beyond the queue limit, must be called with the lock held
+func (x *Indexer) trim() {
+	if over := len(x.queue) - maxQueueBatches*x.config.BatchSize; over > 0 {
+		x.queue = x.queue[over:]
+		x.dropped += uint64(over)
+	}
+}
+
+// run sends a batch every flush interval, or as soon as one fills, backing
+// off while the cluster fails
+func (x *Indexer) run() {
+	defer close(x.stopped)
+	ticker := time.NewTicker(x.config.FlushInterval)
+	defer ticker.Stop()
+
+	backoff := minBackoff
+	for {
+		select {
+		case <-x.done:
+			return
+		case <-ticker.C:
+		case <-x.wake:
+		}
+		for {
+			more, err := x.flush()
+			if err == nil {
+				backoff = minBackoff
+				if !more {
+					break
+				}
+				continue
+			}
+			x.mu.Lock()
+			x.retries++
+			x.mu.Unlock()
+			select {
+			case <-x.done:
+				return
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+	}
+}
+
+// flush sends the oldest batch, reporting whether more documents are waiting. On an
+// error worth retrying the batch goes back to the front of the queue.
+func (x *Indexer) flush() (more bool, err error) {
+	x.mu.Lock()
+	n := len(x.queue)
+	if n > x.config.BatchSize {
+		n = x.config.BatchSize
+	}
+	batch := x.queue[:n:n]
+	x.queue = x.queue[n:]
+	templated := x.templated
+	x.mu.Unlock()
+	if n == 0 {
+		return false, nil
+	}
+
+	if !templated {
+		if err = x.putTemplates(); err == nil {
+			x.mu.Lock()
+			x.templated = true
+			x.mu.Unlock()
+		}
+	}
+	var retry [][]byte
+	if err == nil {
+		retry, err = x.bulk(batch)
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if err != nil {
+		x.lastErr = err.Error()
+		retry = batch
+	}
+	if len(retry) > 0 {
+		x.queue = append(retry, x.queue...)
+		x.trim()
+		if err == nil {
+			err = fmt.Errorf("%d documents rejected while the cluster is busy", len(retry))
+		}
+	}
+	return len(x.queue) > 0, err
+}
+
+// bulkResponse is the part of a bulk response needed to find failed documents
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int             `json:"status"`
+		Error  json.RawMessage `json:"error"`
+	} `json:"items"`
+}
+
+// bulk sends documents in one bulk request, returning those rejected as too
+// many requests. Other rejected documents are counted as failed.
+func (x *Indexer) bulk(batch [][]byte) ([][]byte, error) {
+	body := bytes.Join(batch, nil)
+	resp, err := x.do(http.MethodPost, "/_bulk", "application/x-ndjson", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return nil, fmt.Errorf("bulk request failed: %s", responseError(resp))
+	}
+	if resp.StatusCode != http.StatusOK {
+		// The request itself was refused, retrying won't help
+		x.mu.Lock()
+		x.failed += uint64(len(batch))
+		x.lastErr = "bulk request refused: " + responseError(resp)
+		x.mu.Unlock()
+		return nil, nil
+	}
+
+	var result bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to read bulk response: %w", err)
+	}
+	var retry [][]byte
+	var indexed, failed uint64
+	var lastErr string
+	for i, item := range result.Items {
+		for _, outcome := range item {
+			switch {
+			case outcome.Status == http.StatusTooManyRequests && i < len(batch):
+				retry = append(retry, batch[i])
+			case outcome.Status >= 300:
+				failed++
+				lastErr = fmt.Sprintf("document rejected (%d): %s", outcome.Status, outcome.Error)
+			default:
+				indexed++
+			}
+		}
+	}
+	x.mu.Lock()
+	x.indexed += indexed
+	x.failed += failed
+	if lastErr != "" {
+		x.lastErr = lastErr
+	}
+	x.mu.Unlock()
+	return retry, nil
+}
+
+// putTemplates installs the index templates. Templates the cluster refuses
+// are reported but don't stop indexing, which then uses dynamic mappings.
+func (x *Indexer) putTemplates() error {
+	for name, template := range templates(x.config.IndexPrefix) {
+		body, _ := json.Marshal(template)
+		resp, err := x.do(http.MethodPut, "/_index_template/"+name, "application/json", body)
+		if err != nil {
+			return err
+		}
+		status, message := resp.StatusCode, responseError(resp)
+		resp.Body.Close()
+		if status == http.StatusTooManyRequests || status >= 500 {
+			return fmt.Errorf("failed to install index template %s: %s", name, message)
+		}
+		if status >= 300 {
+			x.mu.Lock()
+			x.lastErr = fmt.Sprintf("index template %s refused: %s", name, message)
+			x.mu.Unlock()
+		}
+	}
+	return nil
+}
+
+// do sends a request with the configured credentials
+func (x *Indexer) do(method, path, contentType string, body []byte) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	req, err := http.NewRequestWithContext(ctx, method, x.url+path, bytes.NewReader(body))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if x.config.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+x.config.APIKey)
+	} else if x.config.Username != "" {
+		req.SetBasicAuth(x.config.Username, x.config.Password)
+	}
+	resp, err := x.client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("request to %s failed: %w", x.url, err)
+	}
+	resp.Body = cancelOnClose{resp.Body, cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's context once its response is read
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// responseError describes a failed response by its status and the start of its body
+func responseError(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return strings.TrimSpace(resp.Status + " " + string(body))
+}
+
+// Close stops the background sender and makes one last attempt to send what's queued
+func (x *Indexer) Close() error {
+	x.mu.Lock()
+	if x.closed {
+		x.mu.Unlock()
+		return nil
+	}
+	x.closed = true
+	x.mu.Unlock()
+	close(x.done)
+	<-x.stopped
+
+	for {
+		more, err := x.flush()
+		if err != nil {
+			x.mu.Lock()
+			defer x.mu.Unlock()
+			return fmt.Errorf("%d documents not sent: %w", len(x.queue), err)
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// GetStats returns where documents go and how many were indexed
+func (x *Indexer) GetStats() map[string]interface{} {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	stats := map[string]interface{}{
+		"url":               x.url,
+		"index_prefix":      x.config.IndexPrefix,
+		"documents_indexed": x.indexed,
+		"documents_failed":  x.failed,
+		"documents_dropped": x.dropped,
+		"documents_queued":  len(x.queue),
+		"retries":           x.retries,
+	}
+	if x.lastErr != "" {
+		stats["last_error"] = x.lastErr
+	}
+	return stats
+}
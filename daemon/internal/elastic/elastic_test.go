@@ -0,0 +1,142 @@
+package elastic
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// fakeCluster answers template and bulk requests, rejecting the first
+// busy bulk requests with 429
+type fakeCluster struct {
+	mu        sync.Mutex
+	busy      int
+	templates []string
+	actions   []map[string]map[string]string
+	docs      []map[string]interface{}
+	auth      string
+}
+
+func (f *fakeCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.auth = r.Header.Get("Authorization")
+
+	if strings.HasPrefix(r.URL.Path, "/_index_template/") && r.Method == http.MethodPut {
+		f.templates = append(f.templates, strings.TrimPrefix(r.URL.Path, "/_index_template/"))
+		fmt.Fprint(w, `{"acknowledged": true}`)
+		return
+	}
+	if r.URL.Path != "/_bulk" || r.Header.Get("Content-Type") != "application/x-ndjson" {
+		http.NotFound(w, r)
+		return
+	}
+	if f.busy > 0 {
+		f.busy--
+		http.Error(w, `{"error": "es_rejected_execution_exception"}`, http.StatusTooManyRequests)
+		return
+	}
+
+	var items []string
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		var action map[string]map[string]string
+		json.Unmarshal(scanner.Bytes(), &action)
+		scanner.Scan()
+		var doc map[string]interface{}
+		json.Unmarshal(scanner.Bytes(), &doc)
+		f.actions = append(f.actions, action)
+		f.docs = append(f.docs, doc)
+		items = append(items, `{"index": {"status": 201}}`)
+	}
+	fmt.Fprintf(w, `{"errors": false, "items": [%s]}`, strings.Join(items, ","))
+}
+
+func TestIndexer(t *testing.T) {
+	if _, err := NewIndexer(Config{URL: "localhost:9200"}); err == nil {
+		t.Error("Expected a URL without a scheme to be rejected")
+	}
+	if _, err := NewIndexer(Config{URL: "http://localhost:9200", IndexPrefix: "Netty"}); err == nil {
+		t.Error("Expected an uppercase index prefix to be rejected")
+	}
+
+	cluster := &fakeCluster{busy: 1}
+	server := httptest.NewServer(cluster)
+	defer server.Close()
+	x, err := NewIndexer(Config{URL: server.URL + "/", APIKey: "c2VjcmV0", BatchSize: 2, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	day := time.Date(2025, 7, 1, 23, 59, 0, 0, time.UTC)
+	x.WriteEvent(&models.NetworkEvent{Timestamp: day, SourceIP: "192.168.1.10", Size: 60})
+	x.WriteConversation(models.ConversationSummary{ID: "c1", StartTime: day, LastActivity: day.Add(time.Minute), BytesIn: 10}, false)
+
+	// A full batch is sent right away, and retried after the cluster was busy
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats := x.GetStats()
+		if stats["documents_indexed"] == uint64(2) {
+			if stats["retries"] != uint64(1) {
+				t.Errorf("Expected one retry, got %v", stats)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the batch to be indexed, got %v", stats)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// What's left is sent on Close
+	x.WriteConversation(models.ConversationSummary{ID: "c1", StartTime: day, LastActivity: day.Add(2 * time.Minute), BytesIn: 20}, true)
+	if err := x.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := x.WriteEvent(&models.NetworkEvent{Timestamp: day}); err != nil {
+		t.Errorf("Expected events after Close to be dropped quietly, got %v", err)
+	}
+
+	cluster.mu.Lock()
+	defer cluster.mu.Unlock()
+	if len(cluster.templates) != 2 || cluster.auth != "ApiKey c2VjcmV0" {
+		t.Errorf("Expected both templates installed with the API key, got %v and %q", cluster.templates, cluster.auth)
+	}
+	if len(cluster.actions) != 3 {
+		t.Fatalf("Expected three documents, got %v", cluster.actions)
+	}
+	if index := cluster.actions[0]["index"]; index["_index"] != "netty-events-2025.07.01" || index["_id"] != "" {
+		t.Errorf("Expected the event in its day's index without an ID, got %v", index)
+	}
+	// Each conversation update replaces the last, in the index of the day it started
+	for _, i := range []int{1, 2} {
+		if index := cluster.actions[i]["index"]; index["_index"] != "netty-conversations-2025.07.01" || index["_id"] != "c1" {
+			t.Errorf("Expected the conversation document c1, got %v", index)
+		}
+	}
+	if doc := cluster.docs[2]; doc["closed"] != true || doc["bytes_in"] != float64(20) || doc["id"] != "c1" {
+		t.Errorf("Expected the closed conversation's summary, got %v", doc)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	templates := templates("netty")
+	events, ok := templates["netty-events"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected an events template, got %v", templates)
+	}
+	data, _ := json.Marshal(events)
+	for _, want := range []string{`"index_patterns":["netty-events-*"]`, `"source_ip":{"ignore_malformed":true,"type":"ip"}`, `"timestamp":{"type":"date"}`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected %s in the events template, got %s", want, data)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package elastic
+
+// templates returns the index templates for events and conversations by
+// name. Strings are keywords unless mapped otherwise; addresses that aren't
+// IPs, such as an ARP event's missing ones, are ignored rather than
+// rejecting the document.
+func templates(prefix string) map[string]interface{} {
+	keywords := []interface{}{
+		map[string]interface{}{
+			"strings": map[string]interface{}{
+				"match_mapping_type": "string",
+				"mapping":            map[string]interface{}{"type": "keyword", "ignore_above": 1024},
+			},
+		},
+	}
+	ip := map[string]interface{}{"type": "ip", "ignore_malformed": true}
+	date := map[string]interface{}{"type": "date"}
+	long := map[string]interface{}{"type": "long"}
+	integer := map[string]interface{}{"type": "integer"}
+
+	template := func(pattern string, properties map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"index_patterns": []string{pattern},
+			"template": map[string]interface{}{
+				"mappings": map[string]interface{}{
+					"dynamic_templates": keywords,
+					"properties":        properties,
+				},
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		prefix + "-events": template(prefix+"-events-*", map[string]interface{}{
+			"timestamp":   date,
+			"source_ip":   ip,
+			"dest_ip":     ip,
+			"source_port": integer,
+			"dest_port":   integer,
+			"size":        long,
+		}),
+		prefix + "-conversations": template(prefix+"-conversations-*", map[string]interface{}{
+			"start_time":        date,
+			"last_activity":     date,
+			"packets_in":        long,
+			"packets_out":       long,
+			"bytes_in":          long,
+			"bytes_out":         long,
+			"bytes_in_per_sec":  map[string]interface{}{"type": "double"},
+			"bytes_out_per_sec": map[string]interface{}{"type": "double"},
+			"closed":            map[string]interface{}{"type": "boolean"},
+		}),
+	}
+}
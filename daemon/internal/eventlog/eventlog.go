@@ -0,0 +1,325 @@
+package eventlog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/clock"
+	"github.com/iolloyd/netty/daemon/internal/models"
+	"github.com/iolloyd/netty/daemon/internal/sink"
+)
+
+// flushInterval bounds how long written records sit in the buffer, so the
+// current file can be followed with tail -f
+const flushInterval = time.Second
+
+// Config describes where records are written and when files rotate
+type Config struct {
+	Path           string        // File to write, rotated files are named after it
+	RotateSize     int64         // Start a new file before this many bytes are exceeded (0 disables)
+	RotateInterval time.Duration // Start a new file after this long (0 disables)
+	Compress       bool          // Gzip files once they're rotated out
+}
+
+// Rotating returns true if a size or interval limit is configured
+func (c Config) Rotating() bool {
+	return c.RotateSize > 0 || c.RotateInterval > 0
+}
+
+// Pattern matches the files written for the config, compressed or not,
+// relative to its directory
+func (c Config) Pattern() string {
+	if !c.Rotating() {
+		return filepath.Base(c.Path)
+	}
+	base, ext := splitExt(c.Path)
+	return filepath.Base(base) + "-*" + ext + "*"
+}
+
+// Writer appends events and conversations to a newline-delimited JSON file,
+// one sink.Record per line, starting a new file when the current one reaches the size or age
+// limit. Without limits it writes to Path itself, otherwise each file is named
+// <name>-<first record time><ext> and gzipped to <ext>.gz once finished.
+type Writer struct {
+	config Config
+
+	file     *os.File
+	buf      *bufio.Writer
+	path     string
+	opened   time.Time // Time of the file's first record
+	size     int64
+	flushed  time.Time
+	files    uint64
+	records  uint64
+	bytes    uint64
+	errors   uint64
+	lastErr  string
+	closed   bool
+	mu       sync.Mutex
+	compress sync.WaitGroup // Rotated files still being compressed
+}
+
+// NewWriter creates a writer. No file is created until the first record.
+func NewWriter(config Config) (*Writer, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("no JSON Lines output path given")
+	}
+	if config.RotateSize < 0 || config.RotateInterval < 0 {
+		return nil, fmt.Errorf("rotation limits must not be negative")
+	}
+	if err := os.MkdirAll(filepath.Dir(config.Path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create JSON Lines output directory: %w", err)
+	}
+	return &Writer{config: config}, nil
+}
+
+// Name returns the sink label
+func (w *Writer) Name() string {
+	return "jsonl"
+}
+
+// WriteEvent appends a captured event
+func (w *Writer) WriteEvent(event *models.NetworkEvent) error {
+	return w.write(event.Timestamp, sink.Record{Type: sink.TypeEvent, Data: event})
+}
+
+// WriteConversation appends a conversation summary, closed once it has left
+// the conversation table
+func (w *Writer) WriteConversation(summary models.ConversationSummary, closed bool) error {
+	rec := sink.Record{Type: sink.TypeConversationUpdate, Data: summary}
+	if closed {
+		rec.Type = sink.TypeConversationClosed
+	}
+	return w.write(summary.LastActivity, rec)
+}
+
+// write appends a record as a line, rotating first if the line would take the
+// file past a limit. Records written after Close are dropped.
+func (w *Writer) write(at time.Time, rec sink.Record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s record: %w", rec.Type, err)
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+
+	if w.file != nil && w.due(at, int64(len(line))) {
+		if err := w.rotate(); err != nil {
+			return w.fail(err)
+		}
+	}
+	if w.file == nil {
+		if err := w.openFile(at); err != nil {
+			return w.fail(err)
+		}
+	}
+
+	if _, err := w.buf.Write(line); err != nil {
+		return w.fail(fmt.Errorf("failed to write to %s: %w", w.path, err))
+	}
+	w.size += int64(len(line))
+	w.records++
+	w.bytes += uint64(len(line))
+
+	if time.Since(w.flushed) >= flushInterval {
+		if err := w.buf.Flush(); err != nil {
+			return w.fail(fmt.Errorf("failed to write to %s: %w", w.path, err))
+		}
+		w.flushed = time.Now()
+	}
+	return nil
+}
+
+// due reports whether the current file must be rotated before a line is written
+func (w *Writer) due(at time.Time, line int64) bool {
+	if w.config.RotateInterval > 0 && at.Sub(w.opened) >= w.config.RotateInterval {
+		return true
+	}
+	// A file always takes at least one line, however long
+	return w.config.RotateSize > 0 && w.size > 0 && w.size+line > w.config.RotateSize
+}
+
+// openFile starts a new file, must be called with the lock held
+func (w *Writer) openFile(at time.Time) error {
+	path := w.config.Path
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if w.config.Rotating() {
+		path = w.rotatedPath(at)
+		flags = os.O_CREATE | os.O_WRONLY | os.O_EXCL
+	}
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create JSON Lines file: %w", err)
+	}
+
+	w.file, w.buf, w.path = file, bufio.NewWriterSize(file, 64<<10), path
+	w.opened = at
+	w.size = 0
+	w.flushed = time.Now()
+	w.files++
+	return nil
+}
+
+// rotatedPath names a file after the time of its first record, adding a
+// counter when several files start within the same second. Names use the -tz
+// zone and sort in the order the files were written.
+func (w *Writer) rotatedPath(at time.Time) string {
+	base, ext := splitExt(w.config.Path)
+	name := base + "-" + clock.In(at).Format("20060102-150405")
+	path := name + ext
+	for n := 1; ; n++ {
+		if !exists(path) && !exists(path+".gz") {
+			return path
+		}
+		path = fmt.Sprintf("%s_%03d%s", name, n, ext)
+	}
+}
+
+// rotate finishes the current file and, when configured, compresses it in
+// the background. Must be called with the lock held.
+func (w *Writer) rotate() error {
+	path := w.path
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+	if w.config.Compress {
+		w.compress.Add(1)
+		go func() {
+			defer w.compress.Done()
+			if err := compressFile(path); err != nil {
+				w.mu.Lock()
+				w.fail(err)
+				w.mu.Unlock()
+			}
+		}()
+	}
+	return nil
+}
+
+// closeFile flushes and closes the current file, must be called with the lock held
+func (w *Writer) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	file, path := w.file, w.path
+	flushErr := w.buf.Flush()
+	w.file, w.buf = nil, nil
+	if err := file.Close(); err != nil && flushErr == nil {
+		flushErr = err
+	}
+	if flushErr != nil {
+		return fmt.Errorf("failed to finish JSON Lines file %s: %w", path, flushErr)
+	}
+	return nil
+}
+
+// fail records a write error, must be called with the lock held
+func (w *Writer) fail(err error) error {
+	w.errors++
+	w.lastErr = err.Error()
+	return err
+}
+
+// Close flushes and closes the current file, compressing it too when files
+// rotate, and waits for earlier files to finish compressing
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	path, open := w.path, w.file != nil
+	err := w.closeFile()
+	w.mu.Unlock()
+
+	w.compress.Wait()
+	if err == nil && open && w.config.Rotating() && w.config.Compress {
+		err = compressFile(path)
+	}
+	return err
+}
+
+// GetStats returns what has been written and where
+func (w *Writer) GetStats() map[string]interface{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stats := map[string]interface{}{
+		"path":            w.config.Path,
+		"current_file":    w.path,
+		"files":           w.files,
+		"records_written": w.records,
+		"bytes_written":   w.bytes,
+		"write_errors":    w.errors,
+		"rotate_size":     w.config.RotateSize,
+		"rotate_interval": w.config.RotateInterval.String(),
+		"compress":        w.config.Compress,
+	}
+	if w.lastErr != "" {
+		stats["last_error"] = w.lastErr
+	}
+	return stats
+}
+
+// compressFile gzips path to path.gz and removes the original. The
+// compressed file only appears once it's complete.
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to compress %s: %w", path, err)
+	}
+	defer in.Close()
+
+	tmp := path + ".gz.tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to compress %s: %w", path, err)
+	}
+	zw := gzip.NewWriter(out)
+	zw.Name = filepath.Base(path)
+	_, err = io.Copy(zw, in)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+".gz")
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to compress %s: %w", path, err)
+	}
+	return os.Remove(path)
+}
+
+// exists reports whether a file exists at path
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return !os.IsNotExist(err)
+}
+
+// splitExt splits a path into everything before its extension and the
+// extension, defaulting to .jsonl when there is none
+func splitExt(path string) (string, string) {
+	ext := filepath.Ext(path)
+	if ext == "" {
+		return path, ".jsonl"
+	}
+	return strings.TrimSuffix(path, ext), ext
+}
@@ -0,0 +1,126 @@
+package eventlog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+	"github.com/iolloyd/netty/daemon/internal/sink"
+)
+
+// readRecords returns the record types in a file, gunzipping .gz files
+func readRecords(t *testing.T, path string) []string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if filepath.Ext(path) == ".gz" {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("Expected a gzip file %s: %v", path, err)
+		}
+		r = zr
+	}
+	var types []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var rec struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("Expected a JSON object per line in %s, got %q", path, scanner.Text())
+		}
+		types = append(types, rec.Type)
+	}
+	return types
+}
+
+func TestWriter_SingleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	w, err := NewWriter(Config{Path: path, Compress: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	start := time.Now()
+	w.WriteEvent(&models.NetworkEvent{Timestamp: start, SourceIP: "192.168.1.10"})
+	w.WriteConversation(models.ConversationSummary{ID: "c1", LastActivity: start}, true)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := w.WriteEvent(&models.NetworkEvent{Timestamp: start}); err != nil {
+		t.Errorf("Expected events after Close to be dropped quietly, got %v", err)
+	}
+
+	// Without rotation the file is never compressed
+	types := readRecords(t, path)
+	if len(types) != 2 || types[0] != sink.TypeEvent || types[1] != sink.TypeConversationClosed {
+		t.Errorf("Expected an event then a conversation, got %v", types)
+	}
+
+	// Restarting appends
+	w, _ = NewWriter(Config{Path: path})
+	w.WriteEvent(&models.NetworkEvent{Timestamp: start})
+	w.Close()
+	if types := readRecords(t, path); len(types) != 3 {
+		t.Errorf("Expected the restart to append, got %v", types)
+	}
+}
+
+func TestWriter_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	config := Config{Path: filepath.Join(dir, "events.jsonl"), RotateInterval: time.Hour, Compress: true}
+	w, err := NewWriter(config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	start := time.Date(2025, 7, 1, 13, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		at := start.Add(time.Duration(i) * 40 * time.Minute)
+		if err := w.WriteEvent(&models.NetworkEvent{Timestamp: at}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, config.Pattern()))
+	sort.Strings(files)
+	if len(files) != 2 {
+		t.Fatalf("Expected two files, got %v", files)
+	}
+	for i, want := range []int{2, 1} {
+		if filepath.Ext(files[i]) != ".gz" {
+			t.Errorf("Expected %s to be compressed", files[i])
+		}
+		if types := readRecords(t, files[i]); len(types) != want {
+			t.Errorf("Expected %d records in %s, got %d", want, files[i], len(types))
+		}
+	}
+	if stats := w.GetStats(); stats["files"] != uint64(2) || stats["records_written"] != uint64(3) {
+		t.Errorf("Unexpected stats: %v", stats)
+	}
+
+	// Size limits rotate before a line would overflow the file
+	config = Config{Path: filepath.Join(t.TempDir(), "events.jsonl"), RotateSize: 1}
+	w, _ = NewWriter(config)
+	for i := 0; i < 3; i++ {
+		w.WriteEvent(&models.NetworkEvent{Timestamp: start})
+	}
+	w.Close()
+	files, _ = filepath.Glob(filepath.Join(filepath.Dir(config.Path), config.Pattern()))
+	if len(files) != 3 {
+		t.Errorf("Expected a file per oversized line, uncompressed, got %v", files)
+	}
+}
@@ -0,0 +1,179 @@
+package export
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+	"github.com/parquet-go/parquet-go"
+)
+
+// FlowRecord is one row of exported flow history
+type FlowRecord struct {
+	ID         string    `parquet:"id"`
+	Protocol   string    `parquet:"protocol"`
+	LocalIP    string    `parquet:"local_ip"`
+	LocalPort  int32     `parquet:"local_port"`
+	RemoteIP   string    `parquet:"remote_ip"`
+	RemotePort int32     `parquet:"remote_port"`
+	Service    string    `parquet:"service"`
+	State      string    `parquet:"state"`
+	StartTime  time.Time `parquet:"start_time"`
+	EndTime    time.Time `parquet:"end_time"`
+	DurationMs int64     `parquet:"duration_ms"`
+	PacketsIn  int64     `parquet:"packets_in"`
+	PacketsOut int64     `parquet:"packets_out"`
+	BytesIn    int64     `parquet:"bytes_in"`
+	BytesOut   int64     `parquet:"bytes_out"`
+	TLSVersion string    `parquet:"tls_version"` // Negotiated, empty without a ServerHello
+	TLSCipher  string    `parquet:"tls_cipher"`
+	ALPN       string    `parquet:"alpn"`
+}
+
+// NewFlowRecord converts a conversation into a flow record
+func NewFlowRecord(conv *models.Conversation, localIP string) FlowRecord {
+	localAddr, localPort := conv.Key.DstIP, conv.Key.DstPort
+	remoteAddr, remotePort := conv.Key.SrcIP, conv.Key.SrcPort
+	if conv.Key.SrcIP == localIP {
+		localAddr, localPort = conv.Key.SrcIP, conv.Key.SrcPort
+		remoteAddr, remotePort = conv.Key.DstIP, conv.Key.DstPort
+	}
+
+	end := conv.Stats.LastActivity
+	if conv.EndTime != nil {
+		end = *conv.EndTime
+	}
+
+	record := FlowRecord{
+		ID:         conv.ID,
+		Protocol:   conv.Key.Protocol,
+		LocalIP:    localAddr,
+		LocalPort:  int32(localPort),
+		RemoteIP:   remoteAddr,
+		RemotePort: int32(remotePort),
+		Service:    conv.Service,
+		State:      string(conv.State),
+		StartTime:  conv.StartTime.UTC(),
+		EndTime:    end.UTC(),
+		DurationMs: end.Sub(conv.StartTime).Milliseconds(),
+		PacketsIn:  int64(conv.Stats.PacketsIn),
+		PacketsOut: int64(conv.Stats.PacketsOut),
+		BytesIn:    int64(conv.Stats.BytesIn),
+		BytesOut:   int64(conv.Stats.BytesOut),
+	}
+	if conv.TLS != nil {
+		record.TLSVersion = conv.TLS.Version
+		record.TLSCipher = conv.TLS.CipherSuite
+		record.ALPN = conv.TLS.ALPN
+	}
+	return record
+}
+
+// ParquetExporter buffers flow records and writes them to Parquet files
+// partitioned by the hour the flow started, laid out Hive-style as
+// <dir>/date=YYYY-MM-DD/hour=HH/flows-<unix-nanos>.parquet
+type ParquetExporter struct {
+	dir     string
+	localIP string
+	pending map[time.Time][]FlowRecord
+	written uint64
+	mu      sync.Mutex
+}
+
+// PartitionPattern matches exported files relative to the export directory
+const PartitionPattern = "date=*/hour=*/flows-*.parquet"
+
+// NewParquetExporter creates a new exporter writing into dir
+func NewParquetExporter(dir, localIP string) (*ParquetExporter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	return &ParquetExporter{
+		dir:     dir,
+		localIP: localIP,
+		pending: make(map[time.Time][]FlowRecord),
+	}, nil
+}
+
+// SetLocalIP changes the address treated as this host, when the interface's address changes
+func (e *ParquetExporter) SetLocalIP(ip string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.localIP = ip
+}
+
+// Add queues a finished conversation for export
+func (e *ParquetExporter) Add(conv *models.Conversation) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	record := NewFlowRecord(conv, e.localIP)
+	hour := record.StartTime.Truncate(time.Hour)
+	e.pending[hour] = append(e.pending[hour], record)
+}
+
+// Flush writes every queued record, one file per hour partition
+func (e *ParquetExporter) Flush() error {
+	e.mu.Lock()
+	pending := e.pending
+	e.pending = make(map[time.Time][]FlowRecord)
+	e.mu.Unlock()
+
+	hours := make([]time.Time, 0, len(pending))
+	for hour := range pending {
+		hours = append(hours, hour)
+	}
+	sort.Slice(hours, func(i, j int) bool { return hours[i].Before(hours[j]) })
+
+	for _, hour := range hours {
+		records := pending[hour]
+		dir := filepath.Join(e.dir, "date="+hour.Format("2006-01-02"), "hour="+hour.Format("15"))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create partition directory: %w", err)
+		}
+
+		path := filepath.Join(dir, fmt.Sprintf("flows-%d.parquet", time.Now().UnixNano()))
+		if err := parquet.WriteFile(path, records); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+
+		e.mu.Lock()
+		e.written += uint64(len(records))
+		e.mu.Unlock()
+	}
+	return nil
+}
+
+// StartFlushRoutine starts a goroutine that writes queued records on an interval
+func (e *ParquetExporter) StartFlushRoutine(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := e.Flush(); err != nil {
+				log.Printf("[WARNING] Parquet export failed: %v", err)
+			}
+		}
+	}()
+}
+
+// GetStats returns export counters
+func (e *ParquetExporter) GetStats() map[string]interface{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	pending := 0
+	for _, records := range e.pending {
+		pending += len(records)
+	}
+	return map[string]interface{}{
+		"dir":           e.dir,
+		"flows_written": e.written,
+		"flows_pending": pending,
+	}
+}
@@ -0,0 +1,69 @@
+package export
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestParquetExporter_PartitionsByHour(t *testing.T) {
+	dir := t.TempDir()
+	e, err := NewParquetExporter(dir, "192.168.1.10")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	start := time.Date(2025, 7, 1, 13, 45, 0, 0, time.UTC)
+	end := start.Add(90 * time.Second)
+	e.Add(&models.Conversation{
+		ID:        "a",
+		Key:       models.ConversationKey{Protocol: "TCP", SrcIP: "192.168.1.10", SrcPort: 50000, DstIP: "203.0.113.7", DstPort: 443},
+		State:     models.ConversationStateClosed,
+		StartTime: start,
+		EndTime:   &end,
+		Stats:     models.ConversationStats{PacketsIn: 10, PacketsOut: 5, BytesIn: 9000, BytesOut: 700},
+		Service:   "HTTPS",
+		TLS:       &models.TLSStats{Version: "TLS 1.1", CipherSuite: "TLS_RSA_WITH_AES_128_CBC_SHA", ALPN: "http/1.1"},
+	})
+	e.Add(&models.Conversation{
+		ID:        "b",
+		Key:       models.ConversationKey{Protocol: "UDP", SrcIP: "192.168.1.1", SrcPort: 53, DstIP: "192.168.1.10", DstPort: 40000},
+		StartTime: start.Add(20 * time.Minute),
+		Stats:     models.ConversationStats{LastActivity: start.Add(20 * time.Minute)},
+	})
+
+	if err := e.Flush(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, PartitionPattern))
+	if len(files) != 2 {
+		t.Fatalf("Expected one file per hour partition, got %v", files)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "date=2025-07-01", "hour=13", "*.parquet"))
+	if len(matches) != 1 {
+		t.Fatalf("Expected a file in the 13:00 partition, got %v", matches)
+	}
+	rows, err := parquet.ReadFile[FlowRecord](matches[0])
+	if err != nil {
+		t.Fatalf("Failed to read back parquet file: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("Expected 1 row, got %d", len(rows))
+	}
+	row := rows[0]
+	if row.RemoteIP != "203.0.113.7" || row.LocalPort != 50000 || row.BytesIn != 9000 || row.DurationMs != 90000 || !row.StartTime.Equal(start) {
+		t.Errorf("Unexpected row: %+v", row)
+	}
+	if row.TLSVersion != "TLS 1.1" || row.TLSCipher != "TLS_RSA_WITH_AES_128_CBC_SHA" || row.ALPN != "http/1.1" {
+		t.Errorf("Unexpected TLS columns: %+v", row)
+	}
+
+	if stats := e.GetStats(); stats["flows_written"].(uint64) != 2 || stats["flows_pending"].(int) != 0 {
+		t.Errorf("Unexpected stats: %v", stats)
+	}
+}
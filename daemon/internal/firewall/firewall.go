@@ -0,0 +1,253 @@
+package firewall
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Backend identifies the host firewall that rules are generated for
+type Backend string
+
+const (
+	BackendPF       Backend = "pf"
+	BackendNftables Backend = "nftables"
+	BackendIptables Backend = "iptables"
+)
+
+// anchorName is the pf anchor / nftables table that holds netty rules.
+// For pf, pf.conf must reference it with: anchor "netty/*"
+const anchorName = "netty"
+
+// Target describes the remote host (and optionally port) to block
+type Target struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port,omitempty"`
+	Protocol string `json:"protocol,omitempty"` // tcp or udp, required by some backends when Port is set
+}
+
+// Rule is a single command that installs part of a block
+type Rule struct {
+	Command []string `json:"command"`
+	Input   string   `json:"input,omitempty"` // Fed to the command on stdin (pf and nft rulesets)
+}
+
+// String returns a shell-style representation of the rule for display
+func (r Rule) String() string {
+	cmd := strings.Join(quoteArgs(r.Command), " ")
+	if r.Input != "" {
+		return fmt.Sprintf("echo %s | %s", quoteArg(strings.TrimSuffix(r.Input, "\n")), cmd)
+	}
+	return cmd
+}
+
+// ParseBackend validates a backend name, falling back to DetectBackend when empty
+func ParseBackend(name string) (Backend, error) {
+	switch strings.ToLower(name) {
+	case "":
+		return DetectBackend(), nil
+	case "pf":
+		return BackendPF, nil
+	case "nftables", "nft":
+		return BackendNftables, nil
+	case "iptables":
+		return BackendIptables, nil
+	}
+	return "", fmt.Errorf("unsupported firewall backend %q", name)
+}
+
+// DetectBackend picks the most likely firewall for the current host
+func DetectBackend() Backend {
+	switch runtime.GOOS {
+	case "darwin", "freebsd", "openbsd", "netbsd":
+		return BackendPF
+	}
+	if _, err := exec.LookPath("nft"); err == nil {
+		return BackendNftables
+	}
+	return BackendIptables
+}
+
+// GenerateRules returns the commands needed to block all traffic to and from the target
+func GenerateRules(backend Backend, target Target) ([]Rule, error) {
+	return generate(backend, target, true)
+}
+
+// GenerateUnblockRules returns the commands that remove a block created by GenerateRules
+func GenerateUnblockRules(backend Backend, target Target) ([]Rule, error) {
+	return generate(backend, target, false)
+}
+
+func generate(backend Backend, target Target, block bool) ([]Rule, error) {
+	ip := net.ParseIP(target.Host)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid host address %q", target.Host)
+	}
+	if target.Port < 0 || target.Port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", target.Port)
+	}
+
+	proto := strings.ToLower(target.Protocol)
+	if target.Port > 0 && proto == "" {
+		proto = "tcp"
+	}
+	if proto != "" && proto != "tcp" && proto != "udp" {
+		return nil, fmt.Errorf("unsupported protocol %q", target.Protocol)
+	}
+
+	host := ip.String()
+	isV6 := ip.To4() == nil
+
+	switch backend {
+	case BackendPF:
+		return pfRules(host, target.Port, proto, isV6, block), nil
+	case BackendNftables:
+		return nftablesRules(host, target.Port, proto, isV6, block), nil
+	case BackendIptables:
+		return iptablesRules(host, target.Port, proto, isV6, block), nil
+	}
+	return nil, fmt.Errorf("unsupported firewall backend %q", backend)
+}
+
+// Apply executes the rules in order, stopping at the first failure
+func Apply(rules []Rule) error {
+	for _, rule := range rules {
+		if len(rule.Command) == 0 {
+			continue
+		}
+		cmd := exec.Command(rule.Command[0], rule.Command[1:]...)
+		if rule.Input != "" {
+			cmd.Stdin = strings.NewReader(rule.Input)
+		}
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to run %q: %w: %s", rule.String(), err, strings.TrimSpace(stderr.String()))
+		}
+	}
+	return nil
+}
+
+func pfRules(host string, port int, proto string, isV6 bool, block bool) []Rule {
+	anchor := anchorName + "/" + subAnchor(host, port)
+	if !block {
+		return []Rule{{Command: []string{"pfctl", "-a", anchor, "-F", "rules"}}}
+	}
+
+	family := "inet"
+	if isV6 {
+		family = "inet6"
+	}
+
+	var match string
+	if proto != "" {
+		match = " proto " + proto
+	}
+	portMatch := ""
+	if port > 0 {
+		portMatch = " port " + strconv.Itoa(port)
+	}
+
+	ruleset := fmt.Sprintf("block drop out quick %s%s from any to %s%s\n", family, match, host, portMatch) +
+		fmt.Sprintf("block drop in quick %s%s from %s%s to any\n", family, match, host, portMatch)
+
+	return []Rule{{
+		Command: []string{"pfctl", "-a", anchor, "-f", "-"},
+		Input:   ruleset,
+	}}
+}
+
+// nftablesSetup creates the netty table with one set per match type and the
+// drop rules that reference them. Chains are flushed first so it is idempotent.
+const nftablesSetup = `add table inet netty
+add set inet netty hosts4 { type ipv4_addr ; }
+add set inet netty hosts6 { type ipv6_addr ; }
+add set inet netty services4 { type ipv4_addr . inet_proto . inet_service ; }
+add set inet netty services6 { type ipv6_addr . inet_proto . inet_service ; }
+add chain inet netty output { type filter hook output priority 0 ; }
+add chain inet netty input { type filter hook input priority 0 ; }
+flush chain inet netty output
+flush chain inet netty input
+add rule inet netty output ip daddr @hosts4 drop
+add rule inet netty output ip6 daddr @hosts6 drop
+add rule inet netty output ip daddr . meta l4proto . th dport @services4 drop
+add rule inet netty output ip6 daddr . meta l4proto . th dport @services6 drop
+add rule inet netty input ip saddr @hosts4 drop
+add rule inet netty input ip6 saddr @hosts6 drop
+add rule inet netty input ip saddr . meta l4proto . th sport @services4 drop
+add rule inet netty input ip6 saddr . meta l4proto . th sport @services6 drop
+`
+
+func nftablesRules(host string, port int, proto string, isV6 bool, block bool) []Rule {
+	suffix := "4"
+	if isV6 {
+		suffix = "6"
+	}
+
+	set, element := "hosts"+suffix, host
+	if port > 0 {
+		set, element = "services"+suffix, fmt.Sprintf("%s . %s . %d", host, proto, port)
+	}
+
+	if !block {
+		return []Rule{{Command: []string{"nft", "delete", "element", "inet", anchorName, set, "{ " + element + " }"}}}
+	}
+	return []Rule{{
+		Command: []string{"nft", "-f", "-"},
+		Input:   nftablesSetup + fmt.Sprintf("add element inet %s %s { %s }\n", anchorName, set, element),
+	}}
+}
+
+func iptablesRules(host string, port int, proto string, isV6 bool, block bool) []Rule {
+	binary := "iptables"
+	if isV6 {
+		binary = "ip6tables"
+	}
+	op := "-I"
+	if !block {
+		op = "-D"
+	}
+
+	out := []string{binary, op, "OUTPUT", "-d", host}
+	in := []string{binary, op, "INPUT", "-s", host}
+	if proto != "" {
+		out = append(out, "-p", proto)
+		in = append(in, "-p", proto)
+	}
+	if port > 0 {
+		out = append(out, "--dport", strconv.Itoa(port))
+		in = append(in, "--sport", strconv.Itoa(port))
+	}
+	out = append(out, "-j", "DROP")
+	in = append(in, "-j", "DROP")
+
+	return []Rule{{Command: out}, {Command: in}}
+}
+
+// subAnchor builds a pf anchor name that is unique per blocked target
+func subAnchor(host string, port int) string {
+	name := strings.NewReplacer(".", "_", ":", "_").Replace(host)
+	if port > 0 {
+		name += "_" + strconv.Itoa(port)
+	}
+	return name
+}
+
+func quoteArgs(args []string) []string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quoteArg(arg)
+	}
+	return quoted
+}
+
+func quoteArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n'\"{};|&$") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
@@ -0,0 +1,112 @@
+package firewall
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateRules_Iptables(t *testing.T) {
+	rules, err := GenerateRules(BackendIptables, Target{Host: "203.0.113.7", Port: 443})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"iptables -I OUTPUT -d 203.0.113.7 -p tcp --dport 443 -j DROP",
+		"iptables -I INPUT -s 203.0.113.7 -p tcp --sport 443 -j DROP",
+	}
+	if len(rules) != len(expected) {
+		t.Fatalf("Expected %d rules, got %d", len(expected), len(rules))
+	}
+	for i, rule := range rules {
+		if rule.String() != expected[i] {
+			t.Errorf("Rule %d: expected '%s', got '%s'", i, expected[i], rule.String())
+		}
+	}
+}
+
+func TestGenerateRules_IPv6UsesIP6Tables(t *testing.T) {
+	rules, err := GenerateRules(BackendIptables, Target{Host: "2001:db8::1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rules[0].Command[0] != "ip6tables" {
+		t.Errorf("Expected ip6tables for IPv6 host, got '%s'", rules[0].Command[0])
+	}
+}
+
+func TestGenerateRules_Nftables(t *testing.T) {
+	rules, err := GenerateRules(BackendNftables, Target{Host: "198.51.100.1", Port: 53, Protocol: "UDP"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(rules) != 1 {
+		t.Fatalf("Expected a single nft script, got %d rules", len(rules))
+	}
+	expected := "add element inet netty services4 { 198.51.100.1 . udp . 53 }"
+	if !strings.Contains(rules[0].Input, expected) {
+		t.Errorf("Expected nft script to contain '%s', got:\n%s", expected, rules[0].Input)
+	}
+}
+
+func TestGenerateUnblockRules(t *testing.T) {
+	target := Target{Host: "203.0.113.7", Port: 22}
+
+	rules, err := GenerateUnblockRules(BackendIptables, target)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "iptables -D OUTPUT -d 203.0.113.7 -p tcp --dport 22 -j DROP"
+	if rules[0].String() != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, rules[0].String())
+	}
+
+	rules, err = GenerateUnblockRules(BackendNftables, target)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected = "nft delete element inet netty services4 '{ 203.0.113.7 . tcp . 22 }'"
+	if rules[0].String() != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, rules[0].String())
+	}
+
+	rules, err = GenerateUnblockRules(BackendPF, target)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected = "pfctl -a netty/203_0_113_7_22 -F rules"
+	if rules[0].String() != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, rules[0].String())
+	}
+}
+
+func TestGenerateRules_PF(t *testing.T) {
+	rules, err := GenerateRules(BackendPF, Target{Host: "198.51.100.1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rules) != 1 {
+		t.Fatalf("Expected a single pfctl rule, got %d", len(rules))
+	}
+	if !strings.Contains(rules[0].Input, "block drop out quick inet from any to 198.51.100.1") {
+		t.Errorf("Unexpected pf ruleset: %s", rules[0].Input)
+	}
+	if rules[0].Command[2] != "netty/198_51_100_1" {
+		t.Errorf("Unexpected anchor: %s", rules[0].Command[2])
+	}
+}
+
+func TestGenerateRules_InvalidInput(t *testing.T) {
+	cases := []Target{
+		{Host: "not-an-ip"},
+		{Host: "10.0.0.1; rm -rf /"},
+		{Host: "10.0.0.1", Port: 70000},
+		{Host: "10.0.0.1", Port: 22, Protocol: "icmp"},
+	}
+	for _, target := range cases {
+		if _, err := GenerateRules(BackendIptables, target); err == nil {
+			t.Errorf("Expected error for target %+v", target)
+		}
+	}
+}
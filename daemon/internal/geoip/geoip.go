@@ -0,0 +1,153 @@
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// maxCached bounds the addresses whose answers are remembered; the cache
+// starts over when full
+const maxCached = 50000
+
+// record holds the fields read from a database. MaxMind's GeoLite2 City,
+// Country and ASN databases, and compatible ones such as DB-IP's, each fill
+// in some of them.
+type record struct {
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"registered_country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	ASN   uint   `maxminddb:"autonomous_system_number"`
+	ASOrg string `maxminddb:"autonomous_system_organization"`
+}
+
+// DB looks addresses up in one or more MaxMind format (.mmdb) databases,
+// typically a City or Country database and an ASN one, merging their answers
+type DB struct {
+	paths   []string
+	readers []*maxminddb.Reader
+
+	mu      sync.Mutex
+	cache   map[string]*models.GeoInfo
+	lookups uint64
+	found   uint64
+}
+
+// Open opens the databases at paths, skipping empty ones
+func Open(paths ...string) (*DB, error) {
+	db := &DB{cache: make(map[string]*models.GeoInfo)}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		reader, err := maxminddb.Open(path)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to open GeoIP database %s: %w", path, err)
+		}
+		db.paths = append(db.paths, path)
+		db.readers = append(db.readers, reader)
+	}
+	if len(db.readers) == 0 {
+		return nil, errors.New("no GeoIP database given")
+	}
+	return db, nil
+}
+
+// Lookup returns what the databases know of an address, or nil if they don't
+// list it, as for private addresses
+func (db *DB) Lookup(ip string) *models.GeoInfo {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if info, cached := db.cache[ip]; cached {
+		return info
+	}
+
+	info := db.lookup(ip)
+	db.lookups++
+	if info != nil {
+		db.found++
+	}
+	if len(db.cache) >= maxCached {
+		db.cache = make(map[string]*models.GeoInfo)
+	}
+	db.cache[ip] = info
+	return info
+}
+
+// lookup reads an address from every database
+func (db *DB) lookup(ip string) *models.GeoInfo {
+	addr := net.ParseIP(ip)
+	if addr == nil || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return nil
+	}
+
+	var info models.GeoInfo
+	for _, reader := range db.readers {
+		var rec record
+		if err := reader.Lookup(addr, &rec); err != nil {
+			continue
+		}
+		country, names := rec.Country.ISOCode, rec.Country.Names
+		if country == "" {
+			// Anycast and satellite networks may only have the country they're registered in
+			country, names = rec.RegisteredCountry.ISOCode, rec.RegisteredCountry.Names
+		}
+		if info.CountryCode == "" && country != "" {
+			info.CountryCode, info.Country = country, names["en"]
+		}
+		if info.City == "" {
+			info.City = rec.City.Names["en"]
+		}
+		if info.ASN == 0 && rec.ASN != 0 {
+			info.ASN, info.ASOrg = rec.ASN, rec.ASOrg
+		}
+	}
+	if info == (models.GeoInfo{}) {
+		return nil
+	}
+	return &info
+}
+
+// Close closes the databases
+func (db *DB) Close() error {
+	var errs []error
+	for _, reader := range db.readers {
+		errs = append(errs, reader.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// GetStats returns the databases loaded and how many addresses were found in them
+func (db *DB) GetStats() map[string]interface{} {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	databases := make([]map[string]interface{}, len(db.readers))
+	for i, reader := range db.readers {
+		databases[i] = map[string]interface{}{
+			"path":  db.paths[i],
+			"type":  reader.Metadata.DatabaseType,
+			"built": time.Unix(int64(reader.Metadata.BuildEpoch), 0).UTC().Format("2006-01-02"),
+		}
+	}
+	return map[string]interface{}{
+		"databases": databases,
+		"lookups":   db.lookups,
+		"found":     db.found,
+		"cached":    len(db.cache),
+	}
+}
@@ -0,0 +1,100 @@
+package geoip
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+)
+
+// writeDB writes a database of the given type mapping networks to records
+func writeDB(t *testing.T, dbType string, records map[string]mmdbtype.Map) string {
+	t.Helper()
+	tree, err := mmdbwriter.New(mmdbwriter.Options{DatabaseType: dbType, RecordSize: 24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for cidr, rec := range records {
+		_, network, _ := net.ParseCIDR(cidr)
+		if err := tree.Insert(network, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), dbType+".mmdb")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := tree.WriteTo(f); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// country builds a country or registered_country record
+func country(code, name string) mmdbtype.Map {
+	return mmdbtype.Map{
+		"iso_code": mmdbtype.String(code),
+		"names":    mmdbtype.Map{"en": mmdbtype.String(name)},
+	}
+}
+
+func TestDB(t *testing.T) {
+	city := writeDB(t, "GeoLite2-City", map[string]mmdbtype.Map{
+		"88.99.0.0/16": {
+			"country": country("DE", "Germany"),
+			"city":    mmdbtype.Map{"names": mmdbtype.Map{"en": mmdbtype.String("Falkenstein")}},
+		},
+		"2a01:4f8::/32": {"country": country("DE", "Germany")},
+		"1.1.1.0/24":    {"registered_country": country("AU", "Australia")},
+	})
+	asn := writeDB(t, "GeoLite2-ASN", map[string]mmdbtype.Map{
+		"88.99.0.0/16": {
+			"autonomous_system_number":       mmdbtype.Uint32(24940),
+			"autonomous_system_organization": mmdbtype.String("Hetzner Online GmbH"),
+		},
+		"1.1.1.0/24": {
+			"autonomous_system_number":       mmdbtype.Uint32(13335),
+			"autonomous_system_organization": mmdbtype.String("Cloudflare, Inc."),
+		},
+	})
+
+	db, err := Open(city, "", asn)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	info := db.Lookup("88.99.12.34")
+	if info == nil || info.CountryCode != "DE" || info.Country != "Germany" || info.City != "Falkenstein" || info.ASN != 24940 || info.ASOrg != "Hetzner Online GmbH" {
+		t.Errorf("Expected the answers of both databases merged, got %+v", info)
+	}
+	if info := db.Lookup("2a01:4f8:c17::1"); info == nil || info.CountryCode != "DE" || info.ASN != 0 {
+		t.Errorf("Expected an IPv6 address found in the city database only, got %+v", info)
+	}
+	if info := db.Lookup("1.1.1.1"); info == nil || info.CountryCode != "AU" || info.ASOrg != "Cloudflare, Inc." {
+		t.Errorf("Expected the registered country when there's no other, got %+v", info)
+	}
+	for _, ip := range []string{"192.168.1.10", "8.8.8.8", "not an ip"} {
+		if info := db.Lookup(ip); info != nil {
+			t.Errorf("Expected nothing for %s, got %+v", ip, info)
+		}
+	}
+
+	db.Lookup("88.99.12.34")
+	stats := db.GetStats()
+	if stats["lookups"] != uint64(6) || stats["found"] != uint64(3) || len(stats["databases"].([]map[string]interface{})) != 2 {
+		t.Errorf("Expected cached answers not looked up again, got %v", stats)
+	}
+
+	if _, err := Open(); err == nil {
+		t.Error("Expected an error without databases")
+	}
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.mmdb")); err == nil {
+		t.Error("Expected an error for a missing database")
+	}
+}
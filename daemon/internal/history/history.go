@@ -0,0 +1,158 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+)
+
+// DefaultMaxBytes bounds the raw packet data kept across all conversations
+const DefaultMaxBytes = 64 << 20
+
+// ErrNotFound is returned when no packets are buffered for a conversation
+var ErrNotFound = errors.New("no packets buffered for conversation")
+
+type packet struct {
+	info gopacket.CaptureInfo
+	data []byte
+}
+
+type buffer struct {
+	packets  []packet // Oldest first
+	bytes    int
+	lastSeen uint64 // Sequence of the latest Add, for evicting idle conversations
+}
+
+// History keeps the most recent raw packets of each conversation so they can
+// be exported as a standalone pcap file
+type History struct {
+	linkType        layers.LinkType
+	perConversation int
+	maxBytes        int
+	buffers         map[string]*buffer
+	bytes           int
+	seq             uint64
+	evicted         uint64
+	mu              sync.Mutex
+}
+
+// NewHistory keeps up to perConversation packets for each conversation and
+// maxBytes of packet data in total, dropping the least recently active
+// conversations first
+func NewHistory(linkType layers.LinkType, perConversation, maxBytes int) *History {
+	return &History{
+		linkType:        linkType,
+		perConversation: perConversation,
+		maxBytes:        maxBytes,
+		buffers:         make(map[string]*buffer),
+	}
+}
+
+// Add records a packet for a conversation. The data must not be modified afterwards.
+func (h *History) Add(conversationID string, info gopacket.CaptureInfo, data []byte) {
+	if conversationID == "" || h.perConversation <= 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	b, exists := h.buffers[conversationID]
+	if !exists {
+		b = &buffer{}
+		h.buffers[conversationID] = b
+	}
+	h.seq++
+	b.lastSeen = h.seq
+	b.packets = append(b.packets, packet{info: info, data: data})
+	b.bytes += len(data)
+	h.bytes += len(data)
+
+	if len(b.packets) > h.perConversation {
+		dropped := b.packets[0]
+		b.packets = b.packets[1:]
+		b.bytes -= len(dropped.data)
+		h.bytes -= len(dropped.data)
+	}
+	for h.maxBytes > 0 && h.bytes > h.maxBytes && len(h.buffers) > 1 {
+		h.evictIdlest(conversationID)
+	}
+}
+
+// Clear drops the packets of every conversation
+func (h *History) Clear() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.buffers = make(map[string]*buffer)
+	h.bytes = 0
+}
+
+// evictIdlest drops the least recently active conversation other than keep
+func (h *History) evictIdlest(keep string) {
+	var idlest string
+	var oldest uint64
+	for id, b := range h.buffers {
+		if id != keep && (idlest == "" || b.lastSeen < oldest) {
+			idlest, oldest = id, b.lastSeen
+		}
+	}
+	h.bytes -= h.buffers[idlest].bytes
+	delete(h.buffers, idlest)
+	h.evicted++
+}
+
+// WritePcap writes a conversation's buffered packets to w as a pcap file and
+// returns how many packets were written
+func (h *History) WritePcap(w io.Writer, conversationID string) (int, error) {
+	h.mu.Lock()
+	b, exists := h.buffers[conversationID]
+	var packets []packet
+	if exists {
+		packets = append(packets, b.packets...)
+	}
+	h.mu.Unlock()
+
+	if len(packets) == 0 {
+		return 0, ErrNotFound
+	}
+
+	writer := pcapgo.NewWriter(w)
+	snaplen := uint32(0)
+	for _, p := range packets {
+		if n := uint32(p.info.CaptureLength); n > snaplen {
+			snaplen = n
+		}
+	}
+	if err := writer.WriteFileHeader(snaplen, h.linkType); err != nil {
+		return 0, fmt.Errorf("failed to write pcap header: %w", err)
+	}
+	for i, p := range packets {
+		if err := writer.WritePacket(p.info, p.data); err != nil {
+			return i, fmt.Errorf("failed to write packet: %w", err)
+		}
+	}
+	return len(packets), nil
+}
+
+// GetStats returns how much packet data is buffered
+func (h *History) GetStats() map[string]interface{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	packets := 0
+	for _, b := range h.buffers {
+		packets += len(b.packets)
+	}
+	return map[string]interface{}{
+		"conversations":            len(h.buffers),
+		"packets":                  packets,
+		"bytes":                    h.bytes,
+		"evicted_conversations":    h.evicted,
+		"packets_per_conversation": h.perConversation,
+	}
+}
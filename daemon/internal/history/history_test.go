@@ -0,0 +1,62 @@
+package history
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+)
+
+func capture(data []byte, at time.Time) gopacket.CaptureInfo {
+	return gopacket.CaptureInfo{Timestamp: at, CaptureLength: len(data), Length: len(data)}
+}
+
+func TestHistory_WritePcap(t *testing.T) {
+	h := NewHistory(layers.LinkTypeEthernet, 2, 0)
+	start := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	for i, data := range [][]byte{{1}, {2, 2}, {3, 3, 3}} {
+		h.Add("conv", capture(data, start.Add(time.Duration(i)*time.Second)), data)
+	}
+	h.Add("other", capture([]byte{9}, start), []byte{9})
+
+	var out bytes.Buffer
+	count, err := h.WritePcap(&out, "conv")
+	if err != nil || count != 2 {
+		t.Fatalf("Expected the 2 most recent packets, got %d (%v)", count, err)
+	}
+
+	reader, err := pcapgo.NewReader(&out)
+	if err != nil {
+		t.Fatalf("Failed to read the pcap back: %v", err)
+	}
+	if reader.LinkType() != layers.LinkTypeEthernet {
+		t.Errorf("Expected an Ethernet capture, got %v", reader.LinkType())
+	}
+	data, info, err := reader.ReadPacketData()
+	if err != nil || len(data) != 2 || !info.Timestamp.Equal(start.Add(time.Second)) {
+		t.Errorf("Unexpected first packet %v at %v (%v)", data, info.Timestamp, err)
+	}
+
+	if _, err := h.WritePcap(&out, "missing"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestHistory_EvictsIdlestConversation(t *testing.T) {
+	h := NewHistory(layers.LinkTypeEthernet, 10, 10)
+	at := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	h.Add("old", capture(make([]byte, 4), at), make([]byte, 4))
+	h.Add("busy", capture(make([]byte, 4), at), make([]byte, 4))
+	h.Add("busy", capture(make([]byte, 4), at), make([]byte, 4))
+
+	stats := h.GetStats()
+	if stats["conversations"] != 1 || stats["bytes"] != 8 || stats["evicted_conversations"] != uint64(1) {
+		t.Errorf("Expected the idle conversation to be evicted, got %v", stats)
+	}
+	if _, err := h.WritePcap(&bytes.Buffer{}, "old"); err != ErrNotFound {
+		t.Errorf("Expected the evicted conversation to be gone, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package hosts
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Orders hosts can be listed in
+const (
+	SortBytes         = "bytes"         // Most traffic first, the default
+	SortLastSeen      = "last_seen"     // Most recently active first
+	SortConversations = "conversations" // Most conversations first
+)
+
+// Host aggregates the tracked conversations with one remote address
+type Host struct {
+	IP              string          `json:"ip"`
+	Names           []string        `json:"names"` // TLS server names and resolved hostnames, sorted
+	Conversations   int             `json:"conversations"`
+	Active          int             `json:"active"`
+	PacketsIn       uint64          `json:"packets_in"`
+	PacketsOut      uint64          `json:"packets_out"`
+	BytesIn         uint64          `json:"bytes_in"` // Received from the host
+	BytesOut        uint64          `json:"bytes_out"`
+	Bytes           uint64          `json:"bytes"`
+	BytesPerSecond  float64         `json:"bytes_per_second"` // Current throughput
+	Services        []string        `json:"services"`         // Detected services, or transport and port, sorted
+	FirstSeen       time.Time       `json:"first_seen"`       // Start of the earliest conversation
+	LastSeen        time.Time       `json:"last_seen"`
+	Geo             *models.GeoInfo `json:"geo,omitempty"`
+	ConversationIDs []string        `json:"conversation_ids"`
+}
+
+// Name returns the host's first name, or its IP without one
+func (h *Host) Name() string {
+	if len(h.Names) > 0 {
+		return h.Names[0]
+	}
+	return h.IP
+}
+
+// Group aggregates conversations by the IP of their remote end, most traffic first
+func Group(conversations []models.ConversationSummary) []Host {
+	byIP := make(map[string]*Host)
+	names := make(map[string]map[string]bool)
+	services := make(map[string]map[string]bool)
+
+	for _, conv := range conversations {
+		ip, remotePort := splitAddr(conv.RemoteAddr)
+		host, exists := byIP[ip]
+		if !exists {
+			host = &Host{IP: ip, FirstSeen: conv.StartTime}
+			byIP[ip] = host
+			names[ip] = make(map[string]bool)
+			services[ip] = make(map[string]bool)
+		}
+
+		host.Conversations++
+		if conv.State == models.ConversationStateNew || conv.State == models.ConversationStateEstablished {
+			host.Active++
+		}
+		host.PacketsIn += conv.PacketsIn
+		host.PacketsOut += conv.PacketsOut
+		host.BytesIn += conv.BytesIn
+		host.BytesOut += conv.BytesOut
+		host.Bytes += conv.BytesIn + conv.BytesOut
+		host.BytesPerSecond += conv.BytesInPerSec + conv.BytesOutPerSec
+		if conv.StartTime.Before(host.FirstSeen) {
+			host.FirstSeen = conv.StartTime
+		}
+		if conv.LastActivity.After(host.LastSeen) {
+			host.LastSeen = conv.LastActivity
+		}
+		if conv.Geo != nil {
+			host.Geo = conv.Geo
+		}
+		host.ConversationIDs = append(host.ConversationIDs, conv.ID)
+
+		for _, name := range []string{conv.ServerName, conv.Hostname} {
+			if name != "" && name != ip {
+				names[ip][name] = true
+			}
+		}
+		services[ip][service(conv, remotePort)] = true
+	}
+
+	list := make([]Host, 0, len(byIP))
+	for ip, host := range byIP {
+		host.Names = sortedKeys(names[ip])
+		host.Services = sortedKeys(services[ip])
+		sort.Strings(host.ConversationIDs)
+		list = append(list, *host)
+	}
+	Sort(list, SortBytes)
+	return list
+}
+
+// Sort orders hosts by one of the Sort orders, ties by IP
+func Sort(hosts []Host, order string) error {
+	var less func(a, b *Host) bool
+	switch order {
+	case SortBytes:
+		less = func(a, b *Host) bool { return a.Bytes > b.Bytes }
+	case SortLastSeen:
+		less = func(a, b *Host) bool { return a.LastSeen.After(b.LastSeen) }
+	case SortConversations:
+		less = func(a, b *Host) bool { return a.Conversations > b.Conversations }
+	default:
+		return fmt.Errorf("unknown order %q (available: %s, %s, %s)", order, SortBytes, SortLastSeen, SortConversations)
+	}
+	sort.SliceStable(hosts, func(i, j int) bool {
+		if less(&hosts[i], &hosts[j]) {
+			return true
+		}
+		if less(&hosts[j], &hosts[i]) {
+			return false
+		}
+		return hosts[i].IP < hosts[j].IP
+	})
+	return nil
+}
+
+// service names what a conversation used: its detected service, or the
+// transport and the remote port when that looks like the server's
+func service(conv models.ConversationSummary, remotePort int) string {
+	if conv.Service != "" {
+		return conv.Service
+	}
+	_, localPort := splitAddr(conv.LocalAddr)
+	if remotePort == 0 || (localPort != 0 && localPort < remotePort) {
+		return conv.Protocol
+	}
+	return fmt.Sprintf("%s/%d", conv.Protocol, remotePort)
+}
+
+// splitAddr splits an "ip:port" address as conversation summaries format it,
+// with IPv6 addresses unbracketed
+func splitAddr(addr string) (string, int) {
+	i := strings.LastIndex(addr, ":")
+	if i < 0 {
+		return addr, 0
+	}
+	port, err := strconv.Atoi(addr[i+1:])
+	if err != nil {
+		return addr, 0
+	}
+	return addr[:i], port
+}
+
+// sortedKeys returns the keys of a set in order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,47 @@
+package hosts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func TestGroup(t *testing.T) {
+	start := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	github := &models.GeoInfo{CountryCode: "US", ASOrg: "GitHub, Inc."}
+	conversations := []models.ConversationSummary{
+		{ID: "b", Protocol: "TCP", LocalAddr: "192.168.1.10:50001", RemoteAddr: "140.82.112.3:443", State: models.ConversationStateClosed,
+			BytesIn: 4000, BytesOut: 200, Service: "HTTPS", ServerName: "github.com", StartTime: start, LastActivity: start.Add(time.Minute), Geo: github},
+		{ID: "a", Protocol: "TCP", LocalAddr: "192.168.1.10:50002", RemoteAddr: "140.82.112.3:22", State: models.ConversationStateEstablished,
+			BytesIn: 500, BytesOut: 300, BytesInPerSec: 100, StartTime: start.Add(time.Hour), LastActivity: start.Add(2 * time.Hour)},
+		{ID: "c", Protocol: "UDP", LocalAddr: "192.168.1.10:53", RemoteAddr: "2001:db8::1:51000", State: models.ConversationStateNew,
+			BytesIn: 100, Hostname: "laptop.lan", StartTime: start.Add(3 * time.Hour), LastActivity: start.Add(3 * time.Hour)},
+	}
+
+	list := Group(conversations)
+	if len(list) != 2 {
+		t.Fatalf("Expected two hosts, got %+v", list)
+	}
+	gh := list[0]
+	if gh.IP != "140.82.112.3" || gh.Conversations != 2 || gh.Active != 1 || gh.BytesIn != 4500 || gh.Bytes != 5000 || gh.BytesPerSecond != 100 {
+		t.Errorf("Unexpected totals for the busiest host: %+v", gh)
+	}
+	if gh.Name() != "github.com" || len(gh.Services) != 2 || gh.Services[0] != "HTTPS" || gh.Services[1] != "TCP/22" {
+		t.Errorf("Expected the host's name and both services, got %v and %v", gh.Names, gh.Services)
+	}
+	if !gh.FirstSeen.Equal(start) || !gh.LastSeen.Equal(start.Add(2*time.Hour)) || gh.Geo != github || len(gh.ConversationIDs) != 2 || gh.ConversationIDs[0] != "a" {
+		t.Errorf("Expected first and last seen across the conversations, got %+v", gh)
+	}
+	// The remote end used an ephemeral port, so it's the client of a local service
+	if v6 := list[1]; v6.IP != "2001:db8::1" || v6.Name() != "laptop.lan" || len(v6.Services) != 1 || v6.Services[0] != "UDP" {
+		t.Errorf("Expected the IPv6 host by its name, got %+v", v6)
+	}
+
+	if err := Sort(list, SortLastSeen); err != nil || list[0].IP != "2001:db8::1" {
+		t.Errorf("Expected the most recently seen first, got %v (%v)", list[0].IP, err)
+	}
+	if err := Sort(list, "name"); err == nil {
+		t.Error("Expected an error for an unknown order")
+	}
+}
@@ -0,0 +1,293 @@
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/atomicfile"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Limits on what the inventory remembers
+const (
+	MaxEndpoints = 100000 // The least recently seen tenth is forgotten when full
+	maxServices  = 64     // Per endpoint, further ones aren't recorded
+)
+
+// Endpoint is a remote address this host has exchanged traffic with
+type Endpoint struct {
+	IP        string    `json:"ip"`
+	Hostname  string    `json:"hostname,omitempty"` // TLS server name or resolved name, the latest seen
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Packets   uint64    `json:"packets"`
+	Bytes     uint64    `json:"bytes"`     // In both directions
+	BytesIn   uint64    `json:"bytes_in"`  // Received from the endpoint
+	BytesOut  uint64    `json:"bytes_out"` // Sent to the endpoint
+	Services  []string  `json:"services"`  // Transport and port, e.g. "TCP/443"
+	Protocols []string  `json:"protocols"` // Application protocols decoded, e.g. "TLS"
+}
+
+// Inventory records every remote endpoint contacted, optionally persisted to
+// a JSON file so the ledger survives restarts. Unlike the conversation table
+// nothing expires: an endpoint stays listed however long ago it was last seen.
+type Inventory struct {
+	path      string
+	localIP   string
+	endpoints map[string]*Endpoint // By IP
+	dirty     bool                 // Changed since the last save
+	mu        sync.Mutex
+}
+
+// NewInventory creates an inventory of the endpoints localIP talks to, loading
+// the previously persisted inventory from path if set
+func NewInventory(path, localIP string) (*Inventory, error) {
+	inv := &Inventory{
+		path:      path,
+		localIP:   localIP,
+		endpoints: make(map[string]*Endpoint),
+	}
+	if path == "" {
+		return inv, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return inv, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read endpoints: %w", err)
+	}
+	var endpoints []*Endpoint
+	if err := json.Unmarshal(data, &endpoints); err != nil {
+		return nil, fmt.Errorf("failed to parse endpoints file %s: %w", path, err)
+	}
+	for _, endpoint := range endpoints {
+		inv.endpoints[endpoint.IP] = endpoint
+	}
+	return inv, nil
+}
+
+// Observe adds a packet to the inventory under its remote end. That is the end
+// that isn't the local IP or, when neither is (e.g. traffic mirrored from other
+// hosts), the one the packet's direction says is remote.
+func (inv *Inventory) Observe(event *models.NetworkEvent) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	remote, hostname, outgoing, ok := inv.remoteEnd(event)
+	if !ok {
+		return
+	}
+
+	endpoint, exists := inv.endpoints[remote]
+	if !exists {
+		if len(inv.endpoints) >= MaxEndpoints {
+			inv.evict()
+		}
+		endpoint = &Endpoint{IP: remote, FirstSeen: event.Timestamp, Services: []string{}, Protocols: []string{}}
+		inv.endpoints[remote] = endpoint
+	}
+	endpoint.LastSeen = event.Timestamp
+	endpoint.Packets++
+	endpoint.Bytes += uint64(event.Size)
+	if outgoing {
+		endpoint.BytesOut += uint64(event.Size)
+	} else {
+		endpoint.BytesIn += uint64(event.Size)
+	}
+	if hostname != "" {
+		endpoint.Hostname = hostname
+	}
+	if port := servicePort(event); port > 0 {
+		endpoint.Services = addValue(endpoint.Services, fmt.Sprintf("%s/%d", event.TransportProtocol, port))
+	}
+	if event.AppProtocol != "" {
+		endpoint.Protocols = addValue(endpoint.Protocols, event.AppProtocol)
+	}
+	inv.dirty = true
+}
+
+// SetLocalIP changes the address treated as this host, when the interface's address changes
+func (inv *Inventory) SetLocalIP(ip string) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.localIP = ip
+}
+
+// remoteEnd returns the remote address of an event, its name if known, and
+// whether the packet was sent to it, must be called with the lock held
+func (inv *Inventory) remoteEnd(event *models.NetworkEvent) (ip, hostname string, outgoing, ok bool) {
+	if event.SourceIP == "" || event.DestIP == "" {
+		return "", "", false, false
+	}
+	switch {
+	case event.SourceIP == inv.localIP:
+		outgoing = true
+	case event.DestIP == inv.localIP:
+		outgoing = false
+	case event.Direction == models.DirectionOutgoing:
+		outgoing = true
+	case event.Direction == models.DirectionIncoming:
+		outgoing = false
+	default:
+		return "", "", false, false
+	}
+
+	if outgoing {
+		hostname = event.TLSServerName
+		if hostname == "" && event.DestHostname != event.DestIP {
+			hostname = event.DestHostname
+		}
+		return event.DestIP, hostname, true, true
+	}
+	if event.SourceHostname != event.SourceIP {
+		hostname = event.SourceHostname
+	}
+	return event.SourceIP, hostname, false, true
+}
+
+// evict forgets the least recently seen tenth of the endpoints, must be called with the lock held
+func (inv *Inventory) evict() {
+	endpoints := make([]*Endpoint, 0, len(inv.endpoints))
+	for _, endpoint := range inv.endpoints {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].LastSeen.Before(endpoints[j].LastSeen) })
+	for _, endpoint := range endpoints[:len(endpoints)/10+1] {
+		delete(inv.endpoints, endpoint.IP)
+	}
+	log.Printf("[WARNING] Endpoint inventory full, forgot the %d least recently seen", len(endpoints)/10+1)
+}
+
+// Endpoints returns up to limit endpoints (0 for all), most recently seen first
+func (inv *Inventory) Endpoints(limit int) []Endpoint {
+	inv.mu.Lock()
+	endpoints := make([]Endpoint, 0, len(inv.endpoints))
+	for _, endpoint := range inv.endpoints {
+		endpoints = append(endpoints, endpoint.copy())
+	}
+	inv.mu.Unlock()
+
+	sort.Slice(endpoints, func(i, j int) bool {
+		if !endpoints[i].LastSeen.Equal(endpoints[j].LastSeen) {
+			return endpoints[i].LastSeen.After(endpoints[j].LastSeen)
+		}
+		return endpoints[i].IP < endpoints[j].IP
+	})
+	if limit > 0 && len(endpoints) > limit {
+		endpoints = endpoints[:limit]
+	}
+	return endpoints
+}
+
+// Lookup returns the endpoint with the given IP
+func (inv *Inventory) Lookup(ip string) (Endpoint, bool) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	endpoint, ok := inv.endpoints[ip]
+	if !ok {
+		return Endpoint{}, false
+	}
+	return endpoint.copy(), true
+}
+
+// GetStats returns inventory metrics
+func (inv *Inventory) GetStats() map[string]interface{} {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	return map[string]interface{}{
+		"endpoints": len(inv.endpoints),
+		"persisted": inv.path != "",
+	}
+}
+
+// Save writes the inventory to its file if it has changed since the last save
+func (inv *Inventory) Save() error {
+	inv.mu.Lock()
+	if inv.path == "" || !inv.dirty {
+		inv.mu.Unlock()
+		return nil
+	}
+	endpoints := make([]Endpoint, 0, len(inv.endpoints))
+	for _, endpoint := range inv.endpoints {
+		endpoints = append(endpoints, endpoint.copy())
+	}
+	inv.dirty = false
+	inv.mu.Unlock()
+
+	if err := inv.write(endpoints); err != nil {
+		// Try again on the next save
+		inv.mu.Lock()
+		inv.dirty = true
+		inv.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// write replaces the inventory's file with the given endpoints
+func (inv *Inventory) write(endpoints []Endpoint) error {
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].IP < endpoints[j].IP })
+	data, err := json.MarshalIndent(endpoints, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode endpoints: %w", err)
+	}
+
+	if err := atomicfile.Write(inv.path, data); err != nil {
+		return fmt.Errorf("failed to save endpoints: %w", err)
+	}
+	return nil
+}
+
+// StartSaveRoutine starts a goroutine that saves the inventory every interval.
+// Counters change with every packet, so unlike the device table it isn't
+// saved on each change.
+func (inv *Inventory) StartSaveRoutine(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := inv.Save(); err != nil {
+				log.Printf("[WARNING] %v", err)
+			}
+		}
+	}()
+}
+
+func (e *Endpoint) copy() Endpoint {
+	endpoint := *e
+	endpoint.Services = append([]string{}, e.Services...)
+	endpoint.Protocols = append([]string{}, e.Protocols...)
+	return endpoint
+}
+
+// servicePort guesses the service port of a packet as the lower of its two ports
+func servicePort(event *models.NetworkEvent) int {
+	port := event.DestPort
+	if event.SourcePort > 0 && (port == 0 || event.SourcePort < port) {
+		port = event.SourcePort
+	}
+	return port
+}
+
+// addValue adds value to a sorted list unless present or the list is full
+func addValue(values []string, value string) []string {
+	i := sort.SearchStrings(values, value)
+	if i < len(values) && values[i] == value {
+		return values
+	}
+	if len(values) >= maxServices {
+		return values
+	}
+	values = append(values, "")
+	copy(values[i+1:], values[i:])
+	values[i] = value
+	return values
+}
@@ -0,0 +1,94 @@
+package inventory
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+var start = time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+
+func packet(at time.Duration, src string, srcPort int, dst string, dstPort, size int) *models.NetworkEvent {
+	return &models.NetworkEvent{
+		Timestamp:         start.Add(at),
+		SourceIP:          src,
+		SourcePort:        srcPort,
+		DestIP:            dst,
+		DestPort:          dstPort,
+		TransportProtocol: "TCP",
+		Size:              size,
+		Direction:         "unknown",
+	}
+}
+
+func TestInventory_Observe(t *testing.T) {
+	inv, _ := NewInventory("", "192.168.1.10")
+
+	out := packet(0, "192.168.1.10", 50000, "140.82.112.3", 443, 100)
+	out.TLSServerName = "github.com"
+	out.AppProtocol = "TLS"
+	inv.Observe(out)
+	inv.Observe(packet(time.Second, "140.82.112.3", 443, "192.168.1.10", 50000, 1500))
+	inv.Observe(packet(2*time.Second, "192.168.1.10", 50001, "140.82.112.3", 22, 60))
+	inv.Observe(packet(3*time.Second, "192.168.1.10", 50002, "1.1.1.1", 53, 70))
+
+	endpoints := inv.Endpoints(0)
+	if len(endpoints) != 2 || endpoints[0].IP != "1.1.1.1" {
+		t.Fatalf("Expected two endpoints, the most recently seen first, got %+v", endpoints)
+	}
+	github := endpoints[1]
+	if github.Hostname != "github.com" || github.Packets != 3 || github.BytesOut != 160 || github.BytesIn != 1500 || github.Bytes != 1660 {
+		t.Errorf("Unexpected counters: %+v", github)
+	}
+	if !github.FirstSeen.Equal(start) || !github.LastSeen.Equal(start.Add(2*time.Second)) {
+		t.Errorf("Expected first and last seen times, got %v and %v", github.FirstSeen, github.LastSeen)
+	}
+	if len(github.Services) != 2 || github.Services[0] != "TCP/22" || github.Services[1] != "TCP/443" {
+		t.Errorf("Expected the services used, got %v", github.Services)
+	}
+	if len(github.Protocols) != 1 || github.Protocols[0] != "TLS" {
+		t.Errorf("Expected the decoded protocol, got %v", github.Protocols)
+	}
+	if limited := inv.Endpoints(1); len(limited) != 1 {
+		t.Errorf("Expected the limit to apply, got %d endpoints", len(limited))
+	}
+}
+
+func TestInventory_OtherHosts(t *testing.T) {
+	inv, _ := NewInventory("", "192.168.1.10")
+
+	// Mirrored traffic between other hosts is attributed by its direction
+	mirrored := packet(0, "192.168.1.20", 50000, "93.184.216.34", 80, 100)
+	mirrored.Direction = "outgoing"
+	inv.Observe(mirrored)
+	inv.Observe(packet(time.Second, "192.168.1.20", 50000, "192.168.1.30", 50001, 100))
+
+	endpoints := inv.Endpoints(0)
+	if len(endpoints) != 1 || endpoints[0].IP != "93.184.216.34" {
+		t.Errorf("Expected only the destination of the outgoing packet, got %+v", endpoints)
+	}
+}
+
+func TestInventory_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "endpoints.json")
+	inv, err := NewInventory(path, "192.168.1.10")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	inv.Observe(packet(0, "192.168.1.10", 50000, "140.82.112.3", 443, 100))
+	if err := inv.Save(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	reloaded, err := NewInventory(path, "192.168.1.10")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	reloaded.Observe(packet(time.Hour, "192.168.1.10", 50000, "140.82.112.3", 443, 100))
+	endpoint, ok := reloaded.Lookup("140.82.112.3")
+	if !ok || !endpoint.FirstSeen.Equal(start) || endpoint.Packets != 2 || endpoint.Bytes != 200 {
+		t.Errorf("Expected the endpoint to carry on from the saved inventory, got %+v", endpoint)
+	}
+}
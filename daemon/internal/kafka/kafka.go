@@ -0,0 +1,183 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+	"github.com/iolloyd/netty/daemon/internal/sink"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// batchTimeout bounds how long a message waits to be sent with others
+const batchTimeout = time.Second
+
+// Config describes the brokers and topics records are published to
+type Config struct {
+	Brokers            []string // host:port of at least one broker
+	EventsTopic        string   // Topic for events, "" publishes none
+	ConversationsTopic string   // Topic for conversation updates and closes, "" publishes none
+}
+
+// ParseBrokers splits a comma-separated broker list
+func ParseBrokers(list string) []string {
+	var brokers []string
+	for _, broker := range strings.Split(list, ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			brokers = append(brokers, broker)
+		}
+	}
+	return brokers
+}
+
+// Publisher is a sink publishing records to Kafka. Each message's value is a
+// JSON sink.Record, its key the conversation ID so a conversation's messages
+// stay in order on one partition, and its "type" header the record type.
+// Messages are sent asynchronously in batches, failures only show in the
+// stats.
+type Publisher struct {
+	config Config
+	writer *kafkago.Writer
+
+	closing sync.RWMutex // Held to write, so Close waits for writes under way
+	closed  bool
+
+	mu        sync.Mutex
+	published uint64
+	failed    uint64
+	lastErr   string
+}
+
+// NewPublisher creates a publisher. Brokers are connected to on the first message.
+func NewPublisher(config Config) (*Publisher, error) {
+	if len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("no Kafka brokers given")
+	}
+	if config.EventsTopic == "" && config.ConversationsTopic == "" {
+		return nil, fmt.Errorf("no Kafka topic given for events or conversations")
+	}
+	p := &Publisher{config: config}
+	p.writer = &kafkago.Writer{
+		Addr:         kafkago.TCP(config.Brokers...),
+		Balancer:     &kafkago.Hash{},
+		BatchTimeout: batchTimeout,
+		RequiredAcks: kafkago.RequireOne,
+		Async:        true,
+		Completion:   p.completed,
+	}
+	return p, nil
+}
+
+// Name returns the sink label
+func (p *Publisher) Name() string {
+	return "kafka"
+}
+
+// WriteEvent publishes an event to the events topic
+func (p *Publisher) WriteEvent(event *models.NetworkEvent) error {
+	if p.config.EventsTopic == "" {
+		return nil
+	}
+	// Events outside any conversation, such as ARP, go by their sender
+	key := event.ConversationID
+	if key == "" {
+		key = event.SourceIP
+	}
+	return p.publish(p.config.EventsTopic, key, sink.Record{Type: sink.TypeEvent, Data: event})
+}
+
+// WriteConversation publishes a conversation summary to the conversations topic
+func (p *Publisher) WriteConversation(summary models.ConversationSummary, closed bool) error {
+	if p.config.ConversationsTopic == "" {
+		return nil
+	}
+	rec := sink.Record{Type: sink.TypeConversationUpdate, Data: summary}
+	if closed {
+		rec.Type = sink.TypeConversationClosed
+	}
+	return p.publish(p.config.ConversationsTopic, summary.ID, rec)
+}
+
+// publish queues a record, dropping it once the publisher is closed
+func (p *Publisher) publish(topic, key string, rec sink.Record) error {
+	msg, err := message(topic, key, rec)
+	if err != nil {
+		return p.fail(1, err)
+	}
+	p.closing.RLock()
+	defer p.closing.RUnlock()
+	if p.closed {
+		return nil
+	}
+	if err := p.writer.WriteMessages(context.Background(), msg); err != nil {
+		return p.fail(1, err)
+	}
+	return nil
+}
+
+// message builds the Kafka message for a record
+func message(topic, key string, rec sink.Record) (kafkago.Message, error) {
+	value, err := json.Marshal(rec)
+	if err != nil {
+		return kafkago.Message{}, fmt.Errorf("failed to encode %s record: %w", rec.Type, err)
+	}
+	return kafkago.Message{
+		Topic:   topic,
+		Key:     []byte(key),
+		Value:   value,
+		Headers: []kafkago.Header{{Key: "type", Value: []byte(rec.Type)}},
+	}, nil
+}
+
+// completed counts a batch the brokers accepted or that failed
+func (p *Publisher) completed(messages []kafkago.Message, err error) {
+	if err != nil {
+		p.fail(len(messages), err)
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.published += uint64(len(messages))
+}
+
+// fail records messages that couldn't be published
+func (p *Publisher) fail(messages int, err error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failed += uint64(messages)
+	p.lastErr = err.Error()
+	return err
+}
+
+// Close sends what's still queued and closes the connections
+func (p *Publisher) Close() error {
+	p.closing.Lock()
+	defer p.closing.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	return p.writer.Close()
+}
+
+// GetStats returns where records go and how many were published
+func (p *Publisher) GetStats() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := map[string]interface{}{
+		"brokers":             p.config.Brokers,
+		"events_topic":        p.config.EventsTopic,
+		"conversations_topic": p.config.ConversationsTopic,
+		"messages_published":  p.published,
+		"messages_failed":     p.failed,
+	}
+	if p.lastErr != "" {
+		stats["last_error"] = p.lastErr
+	}
+	return stats
+}
@@ -0,0 +1,56 @@
+package kafka
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+	"github.com/iolloyd/netty/daemon/internal/sink"
+)
+
+func TestNewPublisher(t *testing.T) {
+	if _, err := NewPublisher(Config{EventsTopic: "netty.events"}); err == nil {
+		t.Error("Expected an error without brokers")
+	}
+	if _, err := NewPublisher(Config{Brokers: []string{"localhost:9092"}}); err == nil {
+		t.Error("Expected an error without topics")
+	}
+	if brokers := ParseBrokers(" kafka-1:9092, ,kafka-2:9092"); len(brokers) != 2 || brokers[1] != "kafka-2:9092" {
+		t.Errorf("Unexpected brokers: %q", brokers)
+	}
+
+	// Nothing is sent to a disabled topic, and closing without messages never connects
+	p, err := NewPublisher(Config{Brokers: []string{"localhost:1"}, ConversationsTopic: "netty.conversations"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := p.WriteEvent(&models.NetworkEvent{ConversationID: "c1"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := p.WriteConversation(models.ConversationSummary{ID: "c1"}, true); err != nil {
+		t.Errorf("Expected writes after Close to be dropped quietly, got %v", err)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	msg, err := message("netty.conversations", "c1", sink.Record{Type: sink.TypeConversationClosed, Data: models.ConversationSummary{ID: "c1", BytesIn: 42}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if msg.Topic != "netty.conversations" || string(msg.Key) != "c1" {
+		t.Errorf("Unexpected topic or key: %q %q", msg.Topic, msg.Key)
+	}
+	if len(msg.Headers) != 1 || msg.Headers[0].Key != "type" || string(msg.Headers[0].Value) != sink.TypeConversationClosed {
+		t.Errorf("Expected the record type as a header, got %+v", msg.Headers)
+	}
+	var rec struct {
+		Type string                     `json:"type"`
+		Data models.ConversationSummary `json:"data"`
+	}
+	if err := json.Unmarshal(msg.Value, &rec); err != nil || rec.Type != sink.TypeConversationClosed || rec.Data.BytesIn != 42 {
+		t.Errorf("Unexpected value %s: %v", msg.Value, err)
+	}
+}
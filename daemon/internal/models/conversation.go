@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 	"net"
+	"sort"
 	"time"
 )
 
@@ -78,13 +79,35 @@ type Conversation struct {
 	StartTime   time.Time         // When the conversation started
 	EndTime     *time.Time        // When the conversation ended (if closed)
 	Stats       ConversationStats // Traffic statistics
+	SampleRate  int               // One in this many packets was counted in Stats, 0 when all were
 	
 	// TCP-specific fields
 	TCPState    *TCPConversationState // TCP state tracking
 	
+	// Segment size and fragmentation tracking
+	Path        PathStats
+	
+	// DSCP and ECN markings
+	QoS         QoSStats
+	
+	// Recent throughput
+	Rate        RateHistory
+	Throughput  Throughput // Per second and direction
+	Minutes     MinuteTraffic // Per minute and direction, for top talkers
+	
 	// Application layer info
 	Service     string            // Detected service/application
-	Hostname    string            // Resolved hostname if available
+	Hostname    string            // Resolved hostname of the remote end if available
+	ServerName  string            // TLS SNI seen in the conversation
+	Geo         *GeoInfo          // Location and network of the remote end, nil if unknown
+	Domain      string            // Name the traffic is accounted under, "" until one is known
+	DomainBytes [2]uint64         // Bytes in and out already accounted under Domain
+	HTTP        *HTTPStats        // Plaintext HTTP exchanged, nil if none was decoded
+	TLS         *TLSStats         // TLS handshake offers and choices, nil if none was decoded
+	Encryption  string            // Encryption class, "" until a payload said
+	
+	// Local program owning the socket, nil until attributed
+	Process     *ProcessInfo
 }
 
 // TCPConversationState tracks TCP-specific conversation state
@@ -93,6 +116,15 @@ type TCPConversationState struct {
 	SYNSeen      bool
 	SYNACKSeen   bool
 	ACKSeen      bool
+	SYNTime      time.Time     // When the first SYN was seen
+	SYNACKTime   time.Time     // When the first SYN-ACK was seen
+	HandshakeRTT time.Duration // SYN to the ACK completing the handshake, zero until established
+	SYNACKRTT    time.Duration // SYN to SYN-ACK
+	ACKRTT       time.Duration // SYN-ACK to the ACK completing the handshake
+	
+	// Round trips of data segments, per sender
+	DataRTTClient RTTEstimate // Sent by the client, acknowledged by the server
+	DataRTTServer RTTEstimate // Sent by the server, acknowledged by the client
 	
 	// Sequence tracking
 	InitialSeqClient uint32
@@ -110,12 +142,320 @@ type TCPConversationState struct {
 	WindowServer uint16
 }
 
+// FlagsSeen lists the handshake and teardown flags seen, in the order a
+// connection normally sends them: SYN, SYN-ACK, ACK, then "FIN local",
+// "FIN remote" and RST. localIsClient says which side FINs are attributed to.
+func (s *TCPConversationState) FlagsSeen(localIsClient bool) []string {
+	finLocal, finRemote := s.FINSeenServer, s.FINSeenClient
+	if localIsClient {
+		finLocal, finRemote = s.FINSeenClient, s.FINSeenServer
+	}
+	var flags []string
+	for _, flag := range []struct {
+		seen bool
+		name string
+	}{
+		{s.SYNSeen, "SYN"},
+		{s.SYNACKSeen, "SYN-ACK"},
+		{s.ACKSeen, "ACK"},
+		{finLocal, "FIN local"},
+		{finRemote, "FIN remote"},
+		{s.RSTSeen, "RST"},
+	} {
+		if flag.seen {
+			flags = append(flags, flag.name)
+		}
+	}
+	return flags
+}
+
+// PathStats tracks segment sizes and fragmentation, used to spot path MTU problems.
+// Like TCPConversationState, "client" is the conversation key's source side.
+type PathStats struct {
+	ClientMSS        int    // MSS advertised by the client side
+	ServerMSS        int    // MSS advertised by the server side
+	MaxSegmentClient int    // Largest payload the client side sent
+	MaxSegmentServer int    // Largest payload the server side sent
+	Fragments        uint64 // Packets that were IP fragments
+	PMTUSuspect      bool   // Full-size segments keep being retransmitted: likely a PMTU blackhole
+
+	// Retransmission tracking for full-size segments, per sender
+	lastLargeSeqClient uint32
+	lastLargeSeqServer uint32
+	largeRetransClient int
+	largeRetransServer int
+}
+
+// pmtuRetransmitThreshold is how many times a full-size segment is resent before a blackhole is suspected
+const pmtuRetransmitThreshold = 3
+
+// ObserveLargeSegment records a full-size segment with DF set and returns true
+// when it newly makes the conversation a PMTU blackhole suspect
+func (p *PathStats) ObserveLargeSegment(fromClient bool, seq uint32) bool {
+	lastSeq, retrans := &p.lastLargeSeqServer, &p.largeRetransServer
+	if fromClient {
+		lastSeq, retrans = &p.lastLargeSeqClient, &p.largeRetransClient
+	}
+
+	if *lastSeq == seq {
+		*retrans++
+	} else {
+		*lastSeq = seq
+		*retrans = 0
+	}
+
+	if *retrans >= pmtuRetransmitThreshold && !p.PMTUSuspect {
+		p.PMTUSuspect = true
+		return true
+	}
+	return false
+}
+
+// HTTPStats summarises the plaintext HTTP/1.x messages of a conversation
+type HTTPStats struct {
+	Requests   uint64 `json:"requests"`
+	Responses  uint64 `json:"responses"`
+	Errors     uint64 `json:"errors"`                // Responses with a 4xx or 5xx status
+	Method     string `json:"method,omitempty"`      // Of the latest request
+	Host       string `json:"host,omitempty"`        // Of the latest request that named one
+	Path       string `json:"path,omitempty"`        // Of the latest request
+	UserAgent  string `json:"user_agent,omitempty"`  // Of the latest request that sent one
+	StatusCode int    `json:"status_code,omitempty"` // Of the latest response
+}
+
+// Observe records one decoded request or response
+func (h *HTTPStats) Observe(info *HTTPInfo) {
+	if info.Response {
+		h.Responses++
+		h.StatusCode = info.StatusCode
+		if info.StatusCode >= 400 {
+			h.Errors++
+		}
+		return
+	}
+	h.Requests++
+	h.Method = info.Method
+	h.Path = info.Path
+	if info.Host != "" {
+		h.Host = info.Host
+	}
+	if info.UserAgent != "" {
+		h.UserAgent = info.UserAgent
+	}
+}
+
+// TLSStats is what a conversation's TLS handshake offered and settled on
+type TLSStats struct {
+	Version        string       `json:"version,omitempty"`         // Negotiated, from the ServerHello
+	ClientVersion  string       `json:"client_version,omitempty"`  // Highest the client offered
+	CipherSuite    string       `json:"cipher_suite,omitempty"`    // Selected by the server
+	OfferedCiphers []string     `json:"offered_ciphers,omitempty"` // In the client's order
+	ALPN           string       `json:"alpn,omitempty"`            // Selected by the server
+	OfferedALPN    []string     `json:"offered_alpn,omitempty"`
+	Legacy         bool         `json:"legacy,omitempty"`      // SSL 3.0, TLS 1.0 or 1.1 was negotiated
+	Certificate    *Certificate `json:"certificate,omitempty"` // The server's, up to TLS 1.2
+}
+
+// Observe records a decoded ClientHello, ServerHello or server certificate
+func (t *TLSStats) Observe(info *TLSInfo) {
+	if info.Certificate != nil {
+		t.Certificate = info.Certificate
+	}
+	switch info.Handshake {
+	case TLSClientHello:
+		t.ClientVersion = info.Version
+		t.OfferedCiphers = info.CipherSuites
+		t.OfferedALPN = info.ALPN
+		return
+	case TLSCertificate:
+		return
+	}
+	t.Version = info.Version
+	t.Legacy = IsLegacyTLS(info.Version)
+	t.CipherSuite = info.CipherSuite
+	if len(info.ALPN) > 0 {
+		t.ALPN = info.ALPN[0]
+	}
+}
+
+// ECN codepoints (RFC 3168)
+const (
+	ECNNotECT = 0
+	ECNECT1   = 1
+	ECNECT0   = 2
+	ECNCE     = 3
+)
+
+// QoSStats tracks the DSCP and ECN markings seen on a conversation's packets
+type QoSStats struct {
+	DSCPPackets  map[int]uint64 // Packets per DSCP value
+	ECNCapable   uint64         // Packets marked ECT(0) or ECT(1)
+	ECNCongested uint64         // Packets marked CE by a congested router
+}
+
+// Observe records the markings of one packet
+func (q *QoSStats) Observe(dscp, ecn int) {
+	if q.DSCPPackets == nil {
+		q.DSCPPackets = make(map[int]uint64)
+	}
+	q.DSCPPackets[dscp]++
+	switch ecn {
+	case ECNECT0, ECNECT1:
+		q.ECNCapable++
+	case ECNCE:
+		q.ECNCongested++
+	}
+}
+
+// Snapshot returns a copy of the stats that doesn't share the DSCP counts,
+// so it can be read while packets keep being observed
+func (q QoSStats) Snapshot() QoSStats {
+	if q.DSCPPackets != nil {
+		counts := make(map[int]uint64, len(q.DSCPPackets))
+		for dscp, packets := range q.DSCPPackets {
+			counts[dscp] = packets
+		}
+		q.DSCPPackets = counts
+	}
+	return q
+}
+
+// Rate history shape: bytes are counted in RateBucket slots over the last RateHistoryLen of them
+const (
+	RateBucket     = 2 * time.Second
+	RateHistoryLen = 16
+)
+
+// RateHistory counts a conversation's bytes in fixed time slots, the newest
+// RateHistoryLen are kept in a ring
+type RateHistory struct {
+	Buckets [RateHistoryLen]uint64
+	Newest  int64 // Slot number (time / RateBucket) of the latest packet
+}
+
+// Add counts a packet's bytes in the slot for its time. Packets older than the
+// kept history are ignored.
+func (r *RateHistory) Add(at time.Time, bytes uint64) {
+	slot := at.UnixNano() / int64(RateBucket)
+	if slot > r.Newest {
+		// Clear the slots skipped since the last packet
+		for s := r.Newest + 1; s <= slot && s <= r.Newest+RateHistoryLen; s++ {
+			r.Buckets[s%RateHistoryLen] = 0
+		}
+		r.Newest = slot
+	}
+	if slot <= r.Newest-RateHistoryLen {
+		return
+	}
+	r.Buckets[slot%RateHistoryLen] += bytes
+}
+
+// Series returns bytes per slot for the RateHistoryLen slots ending at now, oldest first
+func (r *RateHistory) Series(now time.Time) []uint64 {
+	series := make([]uint64, RateHistoryLen)
+	current := now.UnixNano() / int64(RateBucket)
+	for i := range series {
+		slot := current - RateHistoryLen + 1 + int64(i)
+		if slot <= r.Newest && slot > r.Newest-RateHistoryLen {
+			series[i] = r.Buckets[slot%RateHistoryLen]
+		}
+	}
+	return series
+}
+
+// Classes returns the names of the DSCP classes seen, most used first
+func (q *QoSStats) Classes() []string {
+	values := make([]int, 0, len(q.DSCPPackets))
+	for dscp := range q.DSCPPackets {
+		values = append(values, dscp)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if q.DSCPPackets[values[i]] != q.DSCPPackets[values[j]] {
+			return q.DSCPPackets[values[i]] > q.DSCPPackets[values[j]]
+		}
+		return values[i] < values[j]
+	})
+	
+	classes := make([]string, len(values))
+	for i, dscp := range values {
+		classes[i] = DSCPName(dscp)
+	}
+	return classes
+}
+
+// dscpNames are the standard per-hop behaviour names (RFC 2474, 2597, 3246, 5865, 8622)
+var dscpNames = map[int]string{
+	0: "CS0", 1: "LE", 8: "CS1", 16: "CS2", 24: "CS3", 32: "CS4", 40: "CS5", 48: "CS6", 56: "CS7",
+	10: "AF11", 12: "AF12", 14: "AF13",
+	18: "AF21", 20: "AF22", 22: "AF23",
+	26: "AF31", 28: "AF32", 30: "AF33",
+	34: "AF41", 36: "AF42", 38: "AF43",
+	44: "VA", 46: "EF",
+}
+
+// DSCPName returns the per-hop behaviour name of a DSCP value, or its number if it has none
+func DSCPName(dscp int) string {
+	if name := DSCPClass(dscp); name != "" {
+		return name
+	}
+	return fmt.Sprintf("DSCP%d", dscp)
+}
+
+// DSCPClass returns the per-hop behaviour name of a DSCP value, or "" if it has none
+func DSCPClass(dscp int) string {
+	return dscpNames[dscp]
+}
+
+// Clone returns a deep copy of the conversation, sharing nothing that
+// packets update, so it can be read without the conversation's lock
+func (c *Conversation) Clone() *Conversation {
+	clone := *c
+	if c.EndTime != nil {
+		end := *c.EndTime
+		clone.EndTime = &end
+	}
+	if c.TCPState != nil {
+		state := *c.TCPState
+		clone.TCPState = &state
+	}
+	clone.QoS = c.QoS.Snapshot()
+	if c.Geo != nil {
+		geo := *c.Geo
+		clone.Geo = &geo
+	}
+	if c.HTTP != nil {
+		http := *c.HTTP
+		clone.HTTP = &http
+	}
+	if c.TLS != nil {
+		tls := *c.TLS
+		tls.OfferedCiphers = append([]string(nil), c.TLS.OfferedCiphers...)
+		tls.OfferedALPN = append([]string(nil), c.TLS.OfferedALPN...)
+		if c.TLS.Certificate != nil {
+			cert := *c.TLS.Certificate
+			cert.SANs = append([]string(nil), c.TLS.Certificate.SANs...)
+			tls.Certificate = &cert
+		}
+		clone.TLS = &tls
+	}
+	if c.Process != nil {
+		process := *c.Process
+		clone.Process = &process
+	}
+	return &clone
+}
+
 // Duration returns the duration of the conversation
 func (c *Conversation) Duration() time.Duration {
+	return c.DurationAt(time.Now())
+}
+
+// DurationAt returns the duration of the conversation as of now
+func (c *Conversation) DurationAt(now time.Time) time.Duration {
 	if c.EndTime != nil {
 		return c.EndTime.Sub(c.StartTime)
 	}
-	return time.Since(c.StartTime)
+	return now.Sub(c.StartTime)
 }
 
 // IsActive returns true if the conversation is still active
@@ -134,46 +474,198 @@ func (c *Conversation) TotalBytes() uint64 {
 }
 
 // ConversationSummary provides a simplified view of a conversation for UI display
+
 type ConversationSummary struct {
-	ID           string            `json:"id"`
-	Protocol     string            `json:"protocol"`
-	LocalAddr    string            `json:"local_addr"`
-	RemoteAddr   string            `json:"remote_addr"`
-	State        ConversationState `json:"state"`
-	Duration     string            `json:"duration"`
-	PacketsIn    uint64            `json:"packets_in"`
-	PacketsOut   uint64            `json:"packets_out"`
-	BytesIn      uint64            `json:"bytes_in"`
-	BytesOut     uint64            `json:"bytes_out"`
-	Service      string            `json:"service,omitempty"`
-	LastActivity time.Time         `json:"last_activity"`
-}
-
-// ToSummary converts a Conversation to a ConversationSummary
-func (c *Conversation) ToSummary(localIP string) ConversationSummary {
+	ID            string            `json:"id"`
+	Protocol      string            `json:"protocol"`
+	LocalAddr     string            `json:"local_addr"`
+	RemoteAddr    string            `json:"remote_addr"`
+	State         ConversationState `json:"state"`
+	Duration      string            `json:"duration"`
+	PacketsIn     uint64            `json:"packets_in"`
+	PacketsOut    uint64            `json:"packets_out"`
+	BytesIn       uint64            `json:"bytes_in"`
+	BytesOut      uint64            `json:"bytes_out"`
+	Service       string            `json:"service,omitempty"`
+	LastActivity  time.Time         `json:"last_activity"`
+	LocalMSS      int               `json:"local_mss,omitempty"`
+	RemoteMSS     int               `json:"remote_mss,omitempty"`
+	MaxSegmentOut int               `json:"max_segment_out,omitempty"`
+	MaxSegmentIn  int               `json:"max_segment_in,omitempty"`
+	Fragments     uint64            `json:"fragments,omitempty"`
+	PMTUSuspect   bool              `json:"pmtu_blackhole_suspect,omitempty"`
+	DSCP          []string          `json:"dscp,omitempty"`
+	ECNCapable    uint64            `json:"ecn_capable_packets,omitempty"`
+	ECNCongested  uint64            `json:"ecn_ce_packets,omitempty"`
+	Hostname      string            `json:"remote_hostname,omitempty"`
+	ServerName    string            `json:"server_name,omitempty"`
+	Geo           *GeoInfo          `json:"geo,omitempty"` // Of the remote end
+	StartTime     time.Time         `json:"start_time"`
+	HandshakeRTT  float64           `json:"handshake_rtt_ms,omitempty"`
+	SYNACKRTT     float64           `json:"syn_ack_rtt_ms,omitempty"` // SYN to SYN-ACK
+	ACKRTT        float64           `json:"ack_rtt_ms,omitempty"`     // SYN-ACK to the final ACK
+	DataRTT       float64           `json:"data_rtt_ms,omitempty"`    // Smoothed, for data the local end sent
+	DataRTTSamples int              `json:"data_rtt_samples,omitempty"`
+	TCPFlags      []string          `json:"tcp_flags,omitempty"` // Handshake and teardown flags seen, see TCPConversationState.FlagsSeen
+	RateHistory   []uint64          `json:"rate_history"` // Bytes per RateBucket, oldest first, ending now
+	BytesInPerSec  float64          `json:"bytes_in_per_sec"`  // Over the last ThroughputAverage seconds
+	BytesOutPerSec float64          `json:"bytes_out_per_sec"`
+	Process       string            `json:"process,omitempty"`
+	PID           int               `json:"pid,omitempty"`
+	HTTP          *HTTPStats        `json:"http,omitempty"`
+	TLS           *TLSStats         `json:"tls,omitempty"`
+	Encryption    string            `json:"encryption,omitempty"`
+	SampleRate    int               `json:"sample_rate,omitempty"` // Multiply packets and bytes by this to estimate the totals
+}
+
+// ToSummary converts a Conversation to a ConversationSummary as of now, the
+// side whose address is in local being this host's
+func (c *Conversation) ToSummary(local LocalAddresses, now time.Time) ConversationSummary {
 	var localAddr, remoteAddr string
+	var localIsClient bool
 	
 	// Determine which side is local
-	if c.Key.SrcIP == localIP {
+	if local.Contains(c.Key.SrcIP) {
 		localAddr = fmt.Sprintf("%s:%d", c.Key.SrcIP, c.Key.SrcPort)
 		remoteAddr = fmt.Sprintf("%s:%d", c.Key.DstIP, c.Key.DstPort)
+		localIsClient = true
 	} else {
 		localAddr = fmt.Sprintf("%s:%d", c.Key.DstIP, c.Key.DstPort)
 		remoteAddr = fmt.Sprintf("%s:%d", c.Key.SrcIP, c.Key.SrcPort)
 	}
 	
-	return ConversationSummary{
-		ID:           c.ID,
-		Protocol:     c.Key.Protocol,
-		LocalAddr:    localAddr,
-		RemoteAddr:   remoteAddr,
-		State:        c.State,
-		Duration:     c.Duration().Round(time.Second).String(),
-		PacketsIn:    c.Stats.PacketsIn,
-		PacketsOut:   c.Stats.PacketsOut,
-		BytesIn:      c.Stats.BytesIn,
-		BytesOut:     c.Stats.BytesOut,
-		Service:      c.Service,
-		LastActivity: c.Stats.LastActivity,
-	}
-}
\ No newline at end of file
+	// Path stats are kept per key side, the summary reports them per local/remote side
+	localMSS, remoteMSS := c.Path.ServerMSS, c.Path.ClientMSS
+	maxOut, maxIn := c.Path.MaxSegmentServer, c.Path.MaxSegmentClient
+	if localIsClient {
+		localMSS, remoteMSS = remoteMSS, localMSS
+		maxOut, maxIn = maxIn, maxOut
+	}
+	
+	summary := ConversationSummary{
+		ID:            c.ID,
+		Protocol:      c.Key.Protocol,
+		LocalAddr:     localAddr,
+		RemoteAddr:    remoteAddr,
+		State:         c.State,
+		Duration:      c.DurationAt(now).Round(time.Second).String(),
+		PacketsIn:     c.Stats.PacketsIn,
+		PacketsOut:    c.Stats.PacketsOut,
+		BytesIn:       c.Stats.BytesIn,
+		BytesOut:      c.Stats.BytesOut,
+		Service:       c.Service,
+		LastActivity:  c.Stats.LastActivity,
+		LocalMSS:      localMSS,
+		RemoteMSS:     remoteMSS,
+		MaxSegmentOut: maxOut,
+		MaxSegmentIn:  maxIn,
+		Fragments:     c.Path.Fragments,
+		PMTUSuspect:   c.Path.PMTUSuspect,
+		DSCP:          c.QoS.Classes(),
+		ECNCapable:    c.QoS.ECNCapable,
+		ECNCongested:  c.QoS.ECNCongested,
+		Hostname:      c.Hostname,
+		ServerName:    c.ServerName,
+		Geo:           c.Geo,
+		StartTime:     c.StartTime,
+		HandshakeRTT:  c.HandshakeRTTMs(),
+		RateHistory:   c.Rate.Series(now),
+		Encryption:    c.Encryption,
+		SampleRate:    c.SampleRate,
+	}
+	if c.TCPState != nil {
+		summary.TCPFlags = c.TCPState.FlagsSeen(localIsClient)
+		summary.SYNACKRTT = durationMs(c.TCPState.SYNACKRTT)
+		summary.ACKRTT = durationMs(c.TCPState.ACKRTT)
+		data := c.TCPState.DataRTTServer
+		if localIsClient {
+			data = c.TCPState.DataRTTClient
+		}
+		summary.DataRTT, summary.DataRTTSamples = durationMs(data.SRTT), data.Samples
+	}
+	summary.BytesInPerSec, summary.BytesOutPerSec = c.Throughput.Rates(now)
+	if c.Process != nil {
+		summary.Process = c.Process.Name
+		summary.PID = c.Process.PID
+	}
+	if c.HTTP != nil {
+		http := *c.HTTP
+		summary.HTTP = &http
+	}
+	if c.TLS != nil {
+		tls := *c.TLS
+		tls.OfferedCiphers = append([]string(nil), c.TLS.OfferedCiphers...)
+		tls.OfferedALPN = append([]string(nil), c.TLS.OfferedALPN...)
+		summary.TLS = &tls
+	}
+	return summary
+}
+
+// HandshakeRTTMs returns the TCP handshake round trip in milliseconds, 0 if it wasn't observed
+func (c *Conversation) HandshakeRTTMs() float64 {
+	if c.TCPState == nil || c.TCPState.HandshakeRTT <= 0 {
+		return 0
+	}
+	return durationMs(c.TCPState.HandshakeRTT)
+}
+// RemoteName returns the best name for the conversation's remote end: the TLS
+// server name, then the resolved hostname, then the remote IP
+func (c *Conversation) RemoteName(local LocalAddresses) string {
+	if c.ServerName != "" {
+		return c.ServerName
+	}
+	if c.Hostname != "" {
+		return c.Hostname
+	}
+	if local.Contains(c.Key.SrcIP) {
+		return c.Key.DstIP
+	}
+	return c.Key.SrcIP
+}
+
+// ServiceGroup aggregates the conversations with one remote service, e.g. every
+// connection to api.github.com over HTTPS
+type ServiceGroup struct {
+	Name            string    `json:"name"`
+	Service         string    `json:"service"`
+	Conversations   int       `json:"conversations"`
+	Active          int       `json:"active"`
+	PacketsIn       uint64    `json:"packets_in"`
+	PacketsOut      uint64    `json:"packets_out"`
+	BytesIn         uint64    `json:"bytes_in"`
+	BytesOut        uint64    `json:"bytes_out"`
+	FirstSeen       time.Time `json:"first_seen"`
+	LastActivity    time.Time `json:"last_activity"`
+	RemoteAddrs     []string  `json:"remote_addrs"`
+	ConversationIDs []string  `json:"conversation_ids"`
+}
+
+// ProcessInfo identifies the local program a conversation's socket belongs to
+type ProcessInfo struct {
+	PID  int    `json:"pid"`
+	Name string `json:"name"`
+}
+
+// ProcessStats aggregates the conversations of one local program, all its
+// PIDs together. Conversations not attributed to a program are under "unknown".
+type ProcessStats struct {
+	Name           string    `json:"name"`
+	PIDs           []int     `json:"pids"`
+	Conversations  int       `json:"conversations"`
+	Active         int       `json:"active"`
+	PacketsIn      uint64    `json:"packets_in"`
+	PacketsOut     uint64    `json:"packets_out"`
+	BytesIn        uint64    `json:"bytes_in"`
+	BytesOut       uint64    `json:"bytes_out"`
+	BytesPerSecond float64   `json:"bytes_per_second"` // Over the last few completed rate buckets
+	LastActivity   time.Time `json:"last_activity"`
+}
+
+// TrafficMatrix is the bytes exchanged between the busiest local hosts and
+// remote destinations: who talks to whom on the monitored segment
+type TrafficMatrix struct {
+	Locals  []string   `json:"locals"`  // Local hosts, busiest first
+	Remotes []string   `json:"remotes"` // Remote destinations by name, busiest first
+	Bytes   [][]uint64 `json:"bytes"`   // Bytes[i][j] between Locals[i] and Remotes[j], both directions
+	Other   []uint64   `json:"other"`   // Bytes between Locals[i] and remotes not listed
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// DomainTraffic is the traffic of the conversations with one domain name,
+// from the TLS server name, HTTP Host or the remote end's resolved hostname
+type DomainTraffic struct {
+	Domain      string    `json:"domain"`      // e.g. "storage.googleapis.com", or "*.googleapis.com" grouped
+	Connections uint64    `json:"connections"` // Conversations
+	BytesIn     uint64    `json:"bytes_in"`    // Received from the domain
+	BytesOut    uint64    `json:"bytes_out"`   // Sent to the domain
+	Bytes       uint64    `json:"bytes"`
+	Names       int       `json:"names,omitempty"` // Names grouped under a wildcard
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// DomainReport is the traffic per domain over a day, or since accounting started
+type DomainReport struct {
+	Since       time.Time       `json:"since"`
+	Day         string          `json:"day,omitempty"`   // YYYY-MM-DD in the reporting zone, "" for everything
+	Group       bool            `json:"group"`           // Subdomains grouped under their registered domain
+	Match       string          `json:"match,omitempty"` // Only names matching, e.g. "*.googleapis.com"
+	Connections uint64          `json:"connections"`
+	BytesIn     uint64          `json:"bytes_in"`
+	BytesOut    uint64          `json:"bytes_out"`
+	Bytes       uint64          `json:"bytes"`
+	Domains     []DomainTraffic `json:"domains"` // Most bytes first
+	Days        []string        `json:"days"`    // Days with totals kept, oldest first
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Encryption classes of a payload or conversation. A conversation is
+// unclassified ("") until a payload says either way.
+const (
+	EncryptionTLS       = "tls"       // TLS records, including HTTPS and STARTTLS upgrades
+	EncryptionSSH       = "ssh"       // An SSH session, past its cleartext version banner
+	EncryptionQUIC      = "quic"      // QUIC, which always encrypts
+	EncryptionPlaintext = "plaintext" // Readable application data, e.g. HTTP/1.x or DNS
+)
+
+// IsEncrypted reports whether class is one of the encrypted classes
+func IsEncrypted(class string) bool {
+	return class == EncryptionTLS || class == EncryptionSSH || class == EncryptionQUIC
+}
+
+// ServiceEncryption is how much of one service's traffic was encrypted since
+// the daemon started, counted per packet by its conversation's class
+type ServiceEncryption struct {
+	Service        string    `json:"service"`        // Transport and service port, e.g. "TCP/443"
+	Name           string    `json:"name,omitempty"` // Service detected on the port, the latest seen
+	EncryptedBytes uint64    `json:"encrypted_bytes"`
+	PlaintextBytes uint64    `json:"plaintext_bytes"`
+	UnknownBytes   uint64    `json:"unknown_bytes"`   // Before the conversation was classified, e.g. handshakes
+	EncryptedRatio float64   `json:"encrypted_ratio"` // Encrypted share of the classified bytes, 0 to 1
+	LastSeen       time.Time `json:"last_seen"`
+}
+
+// EncryptionSlot is the traffic of every service in one hour
+type EncryptionSlot struct {
+	Start          time.Time `json:"start"`
+	EncryptedBytes uint64    `json:"encrypted_bytes"`
+	PlaintextBytes uint64    `json:"plaintext_bytes"`
+	UnknownBytes   uint64    `json:"unknown_bytes"`
+	EncryptedRatio float64   `json:"encrypted_ratio"`
+}
+
+// EncryptionReport is the encrypted and cleartext share of the traffic seen,
+// in total, per service and per hour
+type EncryptionReport struct {
+	Since          time.Time           `json:"since"`
+	EncryptedBytes uint64              `json:"encrypted_bytes"`
+	PlaintextBytes uint64              `json:"plaintext_bytes"`
+	UnknownBytes   uint64              `json:"unknown_bytes"`
+	EncryptedRatio float64             `json:"encrypted_ratio"`
+	Services       []ServiceEncryption `json:"services"` // Most cleartext bytes first
+	Hourly         []EncryptionSlot    `json:"hourly"`   // Hours with traffic, oldest first
+}
+
+// EncryptedRatio returns the encrypted share of the classified bytes, 0 when
+// none were classified
+func EncryptedRatio(encrypted, plaintext uint64) float64 {
+	if encrypted+plaintext == 0 {
+		return 0
+	}
+	return float64(encrypted) / float64(encrypted+plaintext)
+}
@@ -7,20 +7,28 @@ import (
 type NetworkEvent struct {
 	Timestamp         time.Time `json:"timestamp"`
 	Interface         string    `json:"interface"`
-	Direction         string    `json:"direction"` // incoming, outgoing, unknown
-	Protocol          string    `json:"protocol"`   // IPv4, IPv6
+	Direction         string    `json:"direction"` // incoming, outgoing, multicast, broadcast, unknown
+	Protocol          string    `json:"protocol"`   // IPv4, IPv6, ARP
 	TransportProtocol string    `json:"transport_protocol"` // TCP, UDP
 	AppProtocol       string    `json:"app_protocol,omitempty"` // HTTP, HTTPS, SSH, etc.
 	SourceIP          string    `json:"source_ip"`
 	DestIP            string    `json:"dest_ip"`
 	SourcePort        int       `json:"source_port"`
 	DestPort          int       `json:"dest_port"`
-	Size              int       `json:"size"`
+	Size              int       `json:"size"`          // Bytes on the wire
+	CapturedSize      int       `json:"captured_size"` // Bytes captured, fewer than Size when the snap length cut the packet short
+	SampleRate        int       `json:"sample_rate,omitempty"` // One in this many packets was processed, unset when all were
+	VLAN              int       `json:"vlan,omitempty"`        // 802.1Q VLAN ID, the inner (customer) one of a QinQ frame
+	OuterVLAN         int       `json:"outer_vlan,omitempty"`  // Outer (service) VLAN ID of a QinQ frame
 	
 	// Hostname resolution
 	SourceHostname    string    `json:"source_hostname,omitempty"`
 	DestHostname      string    `json:"dest_hostname,omitempty"`
 	
+	// Location and network of public addresses, when a GeoIP database is loaded
+	SourceGeo         *GeoInfo  `json:"source_geo,omitempty"`
+	DestGeo           *GeoInfo  `json:"dest_geo,omitempty"`
+	
 	// TLS information
 	TLSServerName     string    `json:"tls_server_name,omitempty"` // SNI hostname
 	
@@ -31,6 +39,227 @@ type NetworkEvent struct {
 	TCPFlags          *TCPPacketFlags `json:"tcp_flags,omitempty"`
 	SequenceNumber    uint32    `json:"sequence_number,omitempty"`
 	AckNumber         uint32    `json:"ack_number,omitempty"`
+	
+	// Segment size and fragmentation
+	PayloadSize       int       `json:"payload_size,omitempty"`  // Transport payload bytes
+	Payload           []byte    `json:"payload,omitempty"`       // The start of the transport payload, with -payload-bytes
+	MSS               int       `json:"mss,omitempty"`           // MSS option advertised in a SYN
+	Fragmented        bool      `json:"fragmented,omitempty"`    // Packet is (the first) IP fragment
+	DontFragment      bool      `json:"dont_fragment,omitempty"` // IPv4 DF bit set
+	
+	// QoS markings from the IPv4 ToS / IPv6 traffic class byte
+	DSCP              int       `json:"dscp,omitempty"`       // Differentiated services code point (0-63)
+	DSCPClass         string    `json:"dscp_class,omitempty"` // Its per-hop behaviour name, e.g. EF, unset for nonstandard values
+	ECN               int       `json:"ecn,omitempty"`        // ECN codepoint: 1/2 ECN-capable, 3 congestion experienced
+	
+	// Set on ARP events, which have no transport layer or conversation
+	ARP               *ARPInfo  `json:"arp,omitempty"`
+	
+	// Decoded DNS query or response
+	DNS               *DNSInfo  `json:"dns,omitempty"`
+	
+	// Decoded DHCP message
+	DHCP              *DHCPInfo `json:"dhcp,omitempty"`
+	
+	// Services advertised by an mDNS response or SSDP announcement
+	Discovery         *DiscoveryInfo `json:"discovery,omitempty"`
+	
+	// Decoded plaintext HTTP/1.x request or response headers
+	HTTP              *HTTPInfo `json:"http,omitempty"`
+	
+	// Decoded TLS ClientHello or ServerHello
+	TLS               *TLSInfo  `json:"tls,omitempty"`
+	
+	// Whether the payload was encrypted (EncryptionTLS, ...), "" if it can't be told
+	Encryption        string    `json:"encryption,omitempty"`
+	
+	// Set on IPv6 neighbor discovery events, which like ARP have no conversation
+	NDP               *NDPInfo  `json:"ndp,omitempty"`
+	
+	// Set when the packet was decapsulated from a tunnel, the rest of the event describing the inner packet
+	Encapsulation     *Encapsulation `json:"encapsulation,omitempty"`
+}
+
+// Event directions relative to this host's addresses
+const (
+	DirectionOutgoing  = "outgoing"  // From one of this host's addresses
+	DirectionIncoming  = "incoming"  // To one of them
+	DirectionMulticast = "multicast" // From another host to a multicast group
+	DirectionBroadcast = "broadcast" // From another host to the broadcast address
+	DirectionUnknown   = "unknown"   // Between two other hosts, e.g. on a SPAN port
+)
+
+// Tunnel types an event's packet can be decapsulated from
+const (
+	EncapsulationVXLAN = "vxlan"
+	EncapsulationGRE   = "gre"
+	EncapsulationIPIP  = "ipip" // IPv4 or IPv6 directly in IPv4 or IPv6
+)
+
+// Encapsulation describes the tunnel a packet was carried in
+type Encapsulation struct {
+	Type        string `json:"type"`         // EncapsulationVXLAN, EncapsulationGRE or EncapsulationIPIP
+	ID          uint32 `json:"id,omitempty"` // VXLAN network identifier (VNI) or GRE key
+	OuterSource string `json:"outer_source"` // Tunnel endpoint the packet came from
+	OuterDest   string `json:"outer_dest"`
+}
+
+// IsNeighborTraffic reports whether the event is ARP or IPv6 neighbor
+// discovery, which describe the LAN rather than a flow and belong to no conversation
+func (e *NetworkEvent) IsNeighborTraffic() bool {
+	return e.ARP != nil || e.NDP != nil
+}
+
+// Neighbor discovery message types
+const (
+	NDPRouterSolicitation    = "router_solicitation"
+	NDPRouterAdvertisement   = "router_advertisement"
+	NDPNeighborSolicitation  = "neighbor_solicitation"
+	NDPNeighborAdvertisement = "neighbor_advertisement"
+)
+
+// NDPInfo describes an ICMPv6 neighbor discovery message
+type NDPInfo struct {
+	Type           string   `json:"type"`
+	SenderIP       string   `json:"sender_ip"`
+	SenderMAC      string   `json:"sender_mac,omitempty"`      // Link-layer address option, or the Ethernet source
+	TargetIP       string   `json:"target_ip,omitempty"`       // Neighbor messages: the address resolved or announced
+	TargetMAC      string   `json:"target_mac,omitempty"`      // Neighbor advertisements: the target's link-layer address
+	Router         bool     `json:"router,omitempty"`          // Neighbor advertisements: the target is a router
+	RouterLifetime int      `json:"router_lifetime,omitempty"` // Router advertisements: seconds as default router, 0 for none
+	Prefixes       []string `json:"prefixes,omitempty"`        // Router advertisements: on-link prefixes offered
+}
+
+// DNSInfo describes a DNS query or response
+type DNSInfo struct {
+	ID        uint16   `json:"id"`                   // Transaction ID
+	Response  bool     `json:"response"`
+	Query     string   `json:"query"`                // Name asked for
+	QueryType string   `json:"query_type"`           // A, AAAA, CNAME, ...
+	RCode     string   `json:"rcode,omitempty"`      // Responses: NOERROR, NXDOMAIN, SERVFAIL, ...
+	Answers   []string `json:"answers,omitempty"`    // Responses: addresses from A and AAAA records
+	LatencyMs float64  `json:"latency_ms,omitempty"` // Responses: time since the matching query
+}
+
+// DHCP message types
+const (
+	DHCPDiscover = "discover"
+	DHCPOffer    = "offer"
+	DHCPRequest  = "request"
+	DHCPDecline  = "decline"
+	DHCPAck      = "ack"
+	DHCPNak      = "nak"
+	DHCPRelease  = "release"
+	DHCPInform   = "inform"
+)
+
+// DHCPInfo describes a DHCP message
+type DHCPInfo struct {
+	Type          string `json:"type"` // discover, offer, request, ack, ...
+	TransactionID uint32 `json:"transaction_id"`
+	ClientMAC     string `json:"client_mac"`
+	ClientIP      string `json:"client_ip,omitempty"`     // The address a client already has, when renewing or releasing
+	YourIP        string `json:"your_ip,omitempty"`       // Offers and acks: the address handed to the client
+	RequestedIP   string `json:"requested_ip,omitempty"`  // Discovers and requests: the address the client asks for
+	ServerID      string `json:"server_id,omitempty"`     // The DHCP server's address
+	Hostname      string `json:"hostname,omitempty"`      // The client's name, option 12
+	VendorClass   string `json:"vendor_class,omitempty"`  // The client's vendor class, option 60, e.g. "android-dhcp-14"
+	LeaseSeconds  uint32 `json:"lease_seconds,omitempty"` // Offers and acks: how long the lease lasts
+}
+
+// Service discovery protocols
+const (
+	DiscoveryMDNS = "mdns" // Multicast DNS, Bonjour/Zeroconf
+	DiscoverySSDP = "ssdp" // Simple Service Discovery Protocol, UPnP
+)
+
+// DiscoveryInfo describes the services a device advertises in an mDNS
+// response or an SSDP announcement
+type DiscoveryInfo struct {
+	Protocol string              `json:"protocol"`           // DiscoveryMDNS or DiscoverySSDP
+	Name     string              `json:"name,omitempty"`     // mDNS: the friendly name from a TXT record, e.g. "Living Room TV"
+	Host     string              `json:"host,omitempty"`     // mDNS: the host name, without .local
+	Server   string              `json:"server,omitempty"`   // SSDP: the SERVER header, e.g. "Roku/9.4.0 UPnP/1.0 Roku/9.4.0"
+	Services []DiscoveredService `json:"services,omitempty"`
+}
+
+// DiscoveredService is a service advertised over mDNS or SSDP
+type DiscoveredService struct {
+	Type     string `json:"type"`               // e.g. "_googlecast._tcp" or "urn:schemas-upnp-org:device:MediaRenderer:1"
+	Instance string `json:"instance,omitempty"` // mDNS: the instance name, e.g. "Living Room TV"
+	Port     int    `json:"port,omitempty"`     // mDNS: from the SRV record; SSDP: from the LOCATION URL
+	Location string `json:"location,omitempty"` // SSDP: the URL of the device description
+	Goodbye  bool   `json:"goodbye,omitempty"`  // Withdrawn: an mDNS record with a TTL of 0, or ssdp:byebye
+}
+
+// HTTPInfo describes the start line and key headers of an HTTP/1.x message
+type HTTPInfo struct {
+	Response      bool   `json:"response"`
+	Version       string `json:"version"`                  // HTTP/1.0 or HTTP/1.1
+	Method        string `json:"method,omitempty"`         // Requests: GET, POST, ...
+	Host          string `json:"host,omitempty"`           // Requests: Host header, or the authority of an absolute target
+	Path          string `json:"path,omitempty"`           // Requests: the target without its authority
+	StatusCode    int    `json:"status_code,omitempty"`    // Responses: 200, 404, ...
+	Reason        string `json:"reason,omitempty"`         // Responses: OK, Not Found, ...
+	ContentLength int64  `json:"content_length,omitempty"` // Content-Length header, 0 when absent
+	UserAgent     string `json:"user_agent,omitempty"`     // Requests: User-Agent header
+}
+
+// TLS handshake messages
+const (
+	TLSClientHello = "client_hello"
+	TLSServerHello = "server_hello"
+	TLSCertificate = "certificate" // A Certificate message without the ServerHello before it
+)
+
+// TLSInfo describes what a ClientHello offers or a ServerHello selects
+type TLSInfo struct {
+	Handshake    string       `json:"handshake"`               // TLSClientHello, TLSServerHello or TLSCertificate
+	Version      string       `json:"version"`                 // Highest offered or the one negotiated, e.g. "TLS 1.3"
+	CipherSuites []string     `json:"cipher_suites,omitempty"` // ClientHello: offered, in the client's order
+	CipherSuite  string       `json:"cipher_suite,omitempty"`  // ServerHello: selected
+	ALPN         []string     `json:"alpn,omitempty"`          // Offered, or the one selected
+	ServerName   string       `json:"server_name,omitempty"`   // ClientHello: SNI
+	Certificate  *Certificate `json:"certificate,omitempty"`   // The server's, sent in the clear up to TLS 1.2
+}
+
+// Certificate describes the leaf certificate a server presents
+type Certificate struct {
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	SANs        []string  `json:"sans,omitempty"` // Subject alternative names: DNS names and IP addresses
+	NotBefore   time.Time `json:"not_before"`
+	NotAfter    time.Time `json:"not_after"`
+	Fingerprint string    `json:"fingerprint"` // SHA-256 of the DER encoding, in hex
+}
+
+// Name returns the name the certificate is best known by: its first SAN, or its subject
+func (c *Certificate) Name() string {
+	if len(c.SANs) > 0 {
+		return c.SANs[0]
+	}
+	return c.Subject
+}
+
+// IsLegacyTLS reports whether version is SSL 3.0, TLS 1.0 or TLS 1.1, which
+// are deprecated (RFC 8996)
+func IsLegacyTLS(version string) bool {
+	return version == "SSL 3.0" || version == "TLS 1.0" || version == "TLS 1.1"
+}
+
+// ARP operations
+const (
+	ARPRequest = "request"
+	ARPReply   = "reply"
+)
+
+// ARPInfo describes an ARP request or reply
+type ARPInfo struct {
+	Operation string `json:"operation"` // request or reply
+	SenderMAC string `json:"sender_mac"`
+	SenderIP  string `json:"sender_ip"`
+	TargetMAC string `json:"target_mac"`
+	TargetIP  string `json:"target_ip"`
 }
 
 // TCPPacketFlags represents TCP flags for a single packet
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// GeoInfo is where an address is and the network announcing it, from a
+// GeoIP database. Fields the databases loaded don't cover are left empty.
+type GeoInfo struct {
+	CountryCode string `json:"country_code,omitempty"` // ISO 3166-1 alpha-2, e.g. "DE"
+	Country     string `json:"country,omitempty"`
+	City        string `json:"city,omitempty"`
+	ASN         uint   `json:"asn,omitempty"`
+	ASOrg       string `json:"as_org,omitempty"` // Name of the AS, e.g. "Cloudflare, Inc."
+}
+
+// CountryStats aggregates the conversations with remote ends in one country
+type CountryStats struct {
+	CountryCode    string    `json:"country_code"`
+	Country        string    `json:"country"`
+	Conversations  int       `json:"conversations"`
+	Active         int       `json:"active"`
+	Hosts          int       `json:"hosts"` // Distinct remote addresses
+	PacketsIn      uint64    `json:"packets_in"`
+	PacketsOut     uint64    `json:"packets_out"`
+	BytesIn        uint64    `json:"bytes_in"`
+	BytesOut       uint64    `json:"bytes_out"`
+	BytesPerSecond float64   `json:"bytes_per_second"` // Over the last few completed rate buckets
+	ASOrgs         []string  `json:"as_orgs"`          // Networks the traffic went to, busiest first
+	LastActivity   time.Time `json:"last_activity"`
+}
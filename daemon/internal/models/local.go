@@ -0,0 +1,57 @@
+package models
+
+import (
+	"net"
+	"sort"
+	"strings"
+)
+
+// LocalAddresses is the set of addresses treated as this host, IPv4 and IPv6,
+// e.g. every address of the capture interface including temporary IPv6 ones.
+// A set is never changed once made, so it can be shared without a lock.
+type LocalAddresses map[string]bool
+
+// NewLocalAddresses returns the set of ips, skipping empty ones. IPv6
+// addresses are stored in their canonical form, as events carry them.
+func NewLocalAddresses(ips ...string) LocalAddresses {
+	local := make(LocalAddresses, len(ips))
+	for _, ip := range ips {
+		if ip == "" {
+			continue
+		}
+		if parsed := net.ParseIP(ip); parsed != nil {
+			ip = parsed.String()
+		}
+		local[ip] = true
+	}
+	return local
+}
+
+// Contains reports whether ip is one of this host's addresses. Loopback
+// addresses always are, whatever interface is captured.
+func (l LocalAddresses) Contains(ip string) bool {
+	return l[ip] || strings.HasPrefix(ip, "127.") || ip == "::1"
+}
+
+// List returns the addresses sorted
+func (l LocalAddresses) List() []string {
+	list := make([]string, 0, len(l))
+	for ip := range l {
+		list = append(list, ip)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Equal reports whether both sets hold the same addresses
+func (l LocalAddresses) Equal(other LocalAddresses) bool {
+	if len(l) != len(other) {
+		return false
+	}
+	for ip := range l {
+		if !other[ip] {
+			return false
+		}
+	}
+	return true
+}
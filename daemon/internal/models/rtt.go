@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// rttGain is the weight of a new sample in the smoothed round trip, 1/8 as in
+// RFC 6298
+const rttGain = 8
+
+// RTTEstimate times a sender's data segments against the ACKs answering them,
+// one segment at a time, and smooths the samples into an EWMA
+type RTTEstimate struct {
+	SRTT    time.Duration // Smoothed round trip, zero until the first sample
+	Samples int
+
+	started bool
+	next    uint32 // Sequence number after the highest data sent
+	pending bool   // A segment is being timed
+	expect  uint32 // ACK number covering the timed segment
+	sent    time.Time
+}
+
+// Sent notes a segment from the sender, timing it when it carries new data
+// and nothing else is being timed
+func (e *RTTEstimate) Sent(seq uint32, payload int, at time.Time) {
+	if payload <= 0 {
+		return
+	}
+	if e.started && int32(seq-e.next) < 0 {
+		// A retransmission: the ACK can't tell which copy it answers, so
+		// timed data sent again isn't sampled (Karn's algorithm)
+		if e.pending && int32(seq-e.expect) < 0 {
+			e.pending = false
+		}
+		return
+	}
+	e.started, e.next = true, seq+uint32(payload)
+	if !e.pending {
+		e.pending, e.expect, e.sent = true, e.next, at
+	}
+}
+
+// Acked notes an ACK from the other side, taking a sample once it covers the
+// timed segment
+func (e *RTTEstimate) Acked(ack uint32, at time.Time) {
+	if !e.pending || int32(ack-e.expect) < 0 {
+		return
+	}
+	e.pending = false
+	sample := at.Sub(e.sent)
+	if sample <= 0 {
+		return
+	}
+	if e.Samples == 0 {
+		e.SRTT = sample
+	} else {
+		e.SRTT += (sample - e.SRTT) / rttGain
+	}
+	e.Samples++
+}
+
+// durationMs converts a duration to milliseconds, to the microsecond
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Why a conversation changed state
+const (
+	StateReasonSYN       = "syn"       // A new SYN reopened it
+	StateReasonHandshake = "handshake" // The TCP handshake completed
+	StateReasonFIN       = "fin"       // A side sent FIN
+	StateReasonRST       = "rst"       // A side reset it
+	StateReasonTimeout   = "timeout"   // Idle for longer than its protocol's timeout
+)
+
+// ConversationStateChange is a conversation moving from one state to another
+type ConversationStateChange struct {
+	Conversation ConversationSummary `json:"conversation"` // As of the change
+	From         ConversationState   `json:"from"`
+	To           ConversationState   `json:"to"`
+	Reason       string              `json:"reason"`
+	Time         time.Time           `json:"time"`
+}
+
+// Closed reports whether the change ended the conversation
+func (c ConversationStateChange) Closed() bool {
+	return c.To == ConversationStateClosed
+}
@@ -0,0 +1,137 @@
+package models
+
+import "time"
+
+// Throughput window shape: bytes each way are counted per second over the last
+// ThroughputWindow seconds, and current rates average the last
+// ThroughputAverage completed seconds
+const (
+	ThroughputWindow  = 60
+	ThroughputAverage = 3
+)
+
+// Throughput counts bytes received and sent per second, the newest
+// ThroughputWindow seconds kept in a ring
+type Throughput struct {
+	In     [ThroughputWindow]uint64
+	Out    [ThroughputWindow]uint64
+	Newest int64 // Second (Unix time) of the latest packet
+}
+
+// Add counts a packet's bytes in the second it was seen. Packets older than
+// the window are ignored.
+func (t *Throughput) Add(at time.Time, bytes uint64, outgoing bool) {
+	second := at.Unix()
+	if second > t.Newest {
+		// Clear the seconds skipped since the last packet
+		for s := t.Newest + 1; s <= second && s <= t.Newest+ThroughputWindow; s++ {
+			t.In[s%ThroughputWindow] = 0
+			t.Out[s%ThroughputWindow] = 0
+		}
+		t.Newest = second
+	}
+	if second <= t.Newest-ThroughputWindow {
+		return
+	}
+	if outgoing {
+		t.Out[second%ThroughputWindow] += bytes
+	} else {
+		t.In[second%ThroughputWindow] += bytes
+	}
+}
+
+// Series returns bytes received and sent per second for the ThroughputWindow
+// seconds ending at now, oldest first
+func (t *Throughput) Series(now time.Time) (in, out []uint64) {
+	in, out = make([]uint64, ThroughputWindow), make([]uint64, ThroughputWindow)
+	current := now.Unix()
+	for i := range in {
+		second := current - ThroughputWindow + 1 + int64(i)
+		if second <= t.Newest && second > t.Newest-ThroughputWindow {
+			in[i] = t.In[second%ThroughputWindow]
+			out[i] = t.Out[second%ThroughputWindow]
+		}
+	}
+	return in, out
+}
+
+// Rates returns the bytes per second received and sent over the last
+// ThroughputAverage completed seconds; the current one is still filling so
+// it's left out
+func (t *Throughput) Rates(now time.Time) (in, out float64) {
+	seriesIn, seriesOut := t.Series(now)
+	var bytesIn, bytesOut uint64
+	for i := ThroughputWindow - 1 - ThroughputAverage; i < ThroughputWindow-1; i++ {
+		bytesIn += seriesIn[i]
+		bytesOut += seriesOut[i]
+	}
+	return float64(bytesIn) / ThroughputAverage, float64(bytesOut) / ThroughputAverage
+}
+
+// ThroughputSummary is the traffic each way right now and over the last minute
+type ThroughputSummary struct {
+	BytesInPerSec  float64   `json:"bytes_in_per_sec"`
+	BytesOutPerSec float64   `json:"bytes_out_per_sec"`
+	In             []uint64  `json:"in"`  // Bytes received per second, oldest first, ending now
+	Out            []uint64  `json:"out"` // Bytes sent per second, oldest first, ending now
+	Time           time.Time `json:"time"`
+}
+
+// Summary describes the throughput as of now
+func (t *Throughput) Summary(now time.Time) ThroughputSummary {
+	summary := ThroughputSummary{Time: now}
+	summary.In, summary.Out = t.Series(now)
+	summary.BytesInPerSec, summary.BytesOutPerSec = t.Rates(now)
+	return summary
+}
+
+// MinuteWindow is how many minutes MinuteTraffic keeps, the longest window
+// traffic can be totalled over
+const MinuteWindow = 60
+
+// MinuteTraffic counts bytes received and sent per minute, the newest
+// MinuteWindow minutes kept in a ring
+type MinuteTraffic struct {
+	In     [MinuteWindow]uint64
+	Out    [MinuteWindow]uint64
+	Newest int64 // Minute (Unix time / 60) of the latest packet
+}
+
+// Add counts a packet's bytes in the minute it was seen. Packets older than
+// the window are ignored.
+func (t *MinuteTraffic) Add(at time.Time, bytes uint64, outgoing bool) {
+	minute := at.Unix() / 60
+	if minute > t.Newest {
+		// Clear the minutes skipped since the last packet
+		for m := t.Newest + 1; m <= minute && m <= t.Newest+MinuteWindow; m++ {
+			t.In[m%MinuteWindow] = 0
+			t.Out[m%MinuteWindow] = 0
+		}
+		t.Newest = minute
+	}
+	if minute <= t.Newest-MinuteWindow {
+		return
+	}
+	if outgoing {
+		t.Out[minute%MinuteWindow] += bytes
+	} else {
+		t.In[minute%MinuteWindow] += bytes
+	}
+}
+
+// Totals returns the bytes received and sent in the minutes overlapping the
+// window ending at now, so up to a minute more than the window
+func (t *MinuteTraffic) Totals(now time.Time, window time.Duration) (in, out uint64) {
+	minutes := int64((window + time.Minute - 1) / time.Minute)
+	if minutes > MinuteWindow {
+		minutes = MinuteWindow
+	}
+	current := now.Unix() / 60
+	for minute := current - minutes + 1; minute <= current; minute++ {
+		if minute <= t.Newest && minute > t.Newest-MinuteWindow {
+			in += t.In[minute%MinuteWindow]
+			out += t.Out[minute%MinuteWindow]
+		}
+	}
+	return in, out
+}
@@ -0,0 +1,321 @@
+package ndp
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// AlertRogueRouter is raised for a router advertisement from an unexpected router
+const AlertRogueRouter = "rogue_router_advertisement"
+
+// SeverityHigh is the severity of rogue router alerts: a rogue RA redirects a LAN's IPv6 traffic
+const SeverityHigh = "high"
+
+// Alert describes a router advertisement from a router that isn't trusted
+type Alert struct {
+	Time     time.Time `json:"time"`
+	Type     string    `json:"type"`
+	Severity string    `json:"severity"`
+	IP       string    `json:"ip"`
+	MAC      string    `json:"mac"`
+	Prefixes []string  `json:"prefixes"`
+	Message  string    `json:"message"`
+}
+
+// Neighbor is the link-layer address an IPv6 address was last resolved to
+type Neighbor struct {
+	IP        string    `json:"ip"`
+	MAC       string    `json:"mac"`
+	Router    bool      `json:"router"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Router is a router seen advertising on the LAN
+type Router struct {
+	IP        string    `json:"ip"`
+	MAC       string    `json:"mac"`
+	Lifetime  int       `json:"lifetime"` // Seconds it offers to be a default router, 0 for none
+	Prefixes  []string  `json:"prefixes"`
+	Trusted   bool      `json:"trusted"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// MessageTypes are the layer types of the neighbor discovery messages Decode
+// understands
+var MessageTypes = []gopacket.LayerType{
+	layers.LayerTypeICMPv6RouterAdvertisement,
+	layers.LayerTypeICMPv6RouterSolicitation,
+	layers.LayerTypeICMPv6NeighborSolicitation,
+	layers.LayerTypeICMPv6NeighborAdvertisement,
+}
+
+// Decode returns the neighbor discovery message in a packet: router and
+// neighbor solicitations and advertisements. Other packets return false.
+func Decode(packet gopacket.Packet) (models.NDPInfo, bool) {
+	ip6, _ := packet.Layer(layers.LayerTypeIPv6).(*layers.IPv6)
+	eth, _ := packet.Layer(layers.LayerTypeEthernet).(*layers.Ethernet)
+	for _, t := range MessageTypes {
+		if msg := packet.Layer(t); msg != nil {
+			return DecodeLayers(ip6, eth, msg)
+		}
+	}
+	return models.NDPInfo{}, false
+}
+
+// DecodeLayers is Decode for layers already decoded, such as by a
+// gopacket.DecodingLayerParser. eth may be nil.
+func DecodeLayers(ip6 *layers.IPv6, eth *layers.Ethernet, msg gopacket.Layer) (models.NDPInfo, bool) {
+	if ip6 == nil || ip6.NextHeader != layers.IPProtocolICMPv6 {
+		return models.NDPInfo{}, false
+	}
+	info := models.NDPInfo{SenderIP: ip6.SrcIP.String()}
+	if eth != nil {
+		info.SenderMAC = eth.SrcMAC.String()
+	}
+
+	var options layers.ICMPv6Options
+	switch msg := msg.(type) {
+	case *layers.ICMPv6RouterAdvertisement:
+		info.Type = models.NDPRouterAdvertisement
+		info.RouterLifetime = int(msg.RouterLifetime)
+		options = msg.Options
+	case *layers.ICMPv6RouterSolicitation:
+		info.Type = models.NDPRouterSolicitation
+		options = msg.Options
+	case *layers.ICMPv6NeighborSolicitation:
+		info.Type = models.NDPNeighborSolicitation
+		info.TargetIP = msg.TargetAddress.String()
+		options = msg.Options
+	case *layers.ICMPv6NeighborAdvertisement:
+		info.Type = models.NDPNeighborAdvertisement
+		info.TargetIP = msg.TargetAddress.String()
+		info.Router = msg.Router()
+		options = msg.Options
+	default:
+		return models.NDPInfo{}, false
+	}
+
+	for _, option := range options {
+		switch option.Type {
+		case layers.ICMPv6OptSourceAddress:
+			if len(option.Data) == 6 {
+				info.SenderMAC = net.HardwareAddr(option.Data).String()
+			}
+		case layers.ICMPv6OptTargetAddress:
+			if len(option.Data) == 6 {
+				info.TargetMAC = net.HardwareAddr(option.Data).String()
+			}
+		case layers.ICMPv6OptPrefixInfo:
+			// Prefix length, flags, lifetimes and reserved bytes precede the 16 byte prefix
+			if len(option.Data) == 30 {
+				info.Prefixes = append(info.Prefixes, fmt.Sprintf("%s/%d", net.IP(option.Data[14:]), option.Data[0]))
+			}
+		}
+	}
+	// An advertisement for ourselves comes from the target, its MAC from the Ethernet header will do
+	if info.Type == models.NDPNeighborAdvertisement && info.TargetMAC == "" {
+		info.TargetMAC = info.SenderMAC
+	}
+	return info, true
+}
+
+// Bindings returns the IPv6 to MAC bindings a message proves: its sender's
+// and, for neighbor advertisements, the target's
+func Bindings(info models.NDPInfo) map[string]string {
+	bindings := make(map[string]string)
+	// Duplicate address detection probes come from the unspecified address
+	if info.SenderMAC != "" && info.SenderIP != "::" && info.Type != models.NDPNeighborAdvertisement {
+		bindings[info.SenderIP] = info.SenderMAC
+	}
+	if info.Type == models.NDPNeighborAdvertisement && info.TargetMAC != "" && info.TargetIP != "" {
+		bindings[info.TargetIP] = info.TargetMAC
+	}
+	return bindings
+}
+
+// Monitor learns the IPv6 neighbor table from neighbor discovery and raises
+// alerts for router advertisements from routers that aren't trusted. Routers
+// are trusted when listed, or without a list, when they are the first seen.
+type Monitor struct {
+	allowed   map[string]bool // Trusted router MACs and addresses
+	neighbors map[string]*Neighbor
+	routers   map[string]*Router // By MAC, or address when the MAC is unknown
+	alerts    uint64
+	mu        sync.Mutex
+
+	// OnAlert is called (outside the lock) the first time an untrusted router advertises
+	OnAlert func(alert Alert)
+}
+
+// NewMonitor creates a monitor trusting the given router MACs and IPv6 addresses
+func NewMonitor(trustedRouters []string) *Monitor {
+	m := &Monitor{
+		allowed:   make(map[string]bool),
+		neighbors: make(map[string]*Neighbor),
+		routers:   make(map[string]*Router),
+	}
+	for _, router := range trustedRouters {
+		if router = normalize(router); router != "" {
+			m.allowed[router] = true
+		}
+	}
+	return m
+}
+
+// Observe records the bindings and routers a neighbor discovery message reveals
+func (m *Monitor) Observe(info models.NDPInfo, at time.Time) {
+	m.mu.Lock()
+	for ip, mac := range Bindings(info) {
+		neighbor, exists := m.neighbors[ip]
+		if !exists || neighbor.MAC != mac {
+			neighbor = &Neighbor{IP: ip, MAC: mac, FirstSeen: at}
+			m.neighbors[ip] = neighbor
+		}
+		neighbor.LastSeen = at
+		if info.Type == models.NDPRouterAdvertisement || (info.Type == models.NDPNeighborAdvertisement && info.Router) {
+			neighbor.Router = true
+		}
+	}
+	if info.Type != models.NDPRouterAdvertisement {
+		m.mu.Unlock()
+		return
+	}
+
+	key := info.SenderMAC
+	if key == "" {
+		key = info.SenderIP
+	}
+	router, known := m.routers[key]
+	if !known {
+		router = &Router{IP: info.SenderIP, MAC: info.SenderMAC, FirstSeen: at}
+		router.Trusted = m.trusted(router)
+		m.routers[key] = router
+	}
+	router.LastSeen = at
+	router.Lifetime = info.RouterLifetime
+	router.Prefixes = info.Prefixes
+
+	var alert *Alert
+	if !known && !router.Trusted {
+		m.alerts++
+		alert = &Alert{
+			Time:     at,
+			Type:     AlertRogueRouter,
+			Severity: SeverityHigh,
+			IP:       router.IP,
+			MAC:      router.MAC,
+			Prefixes: router.Prefixes,
+			Message:  fmt.Sprintf("Router advertisement from unexpected router %s (%s)%s", router.IP, orUnknown(router.MAC), prefixList(router.Prefixes)),
+		}
+	}
+	m.mu.Unlock()
+
+	if alert == nil {
+		return
+	}
+	log.Printf("[WARNING] %s", alert.Message)
+	if m.OnAlert != nil {
+		m.OnAlert(*alert)
+	}
+}
+
+// trusted reports whether a newly seen router is expected, must be called with the lock held
+func (m *Monitor) trusted(router *Router) bool {
+	if len(m.allowed) > 0 {
+		return m.allowed[normalize(router.MAC)] || m.allowed[normalize(router.IP)]
+	}
+	// Without a list the first router is taken to be the real one
+	return len(m.routers) == 0
+}
+
+// MACFor returns the MAC an IPv6 address was last resolved to, or ""
+func (m *Monitor) MACFor(ip string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if neighbor, ok := m.neighbors[ip]; ok {
+		return neighbor.MAC
+	}
+	return ""
+}
+
+// GetNeighbors returns the neighbor table ordered by address
+func (m *Monitor) GetNeighbors() []Neighbor {
+	m.mu.Lock()
+	neighbors := make([]Neighbor, 0, len(m.neighbors))
+	for _, neighbor := range m.neighbors {
+		neighbors = append(neighbors, *neighbor)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(neighbors, func(i, j int) bool { return neighbors[i].IP < neighbors[j].IP })
+	return neighbors
+}
+
+// GetRouters returns the routers seen advertising, trusted ones first
+func (m *Monitor) GetRouters() []Router {
+	m.mu.Lock()
+	routers := make([]Router, 0, len(m.routers))
+	for _, router := range m.routers {
+		copied := *router
+		copied.Prefixes = append([]string(nil), router.Prefixes...)
+		routers = append(routers, copied)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(routers, func(i, j int) bool {
+		if routers[i].Trusted != routers[j].Trusted {
+			return routers[i].Trusted
+		}
+		return routers[i].FirstSeen.Before(routers[j].FirstSeen)
+	})
+	return routers
+}
+
+// GetStats returns monitor metrics
+func (m *Monitor) GetStats() map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return map[string]interface{}{
+		"neighbors":       len(m.neighbors),
+		"routers":         len(m.routers),
+		"trusted_routers": len(m.allowed),
+		"alerts_total":    m.alerts,
+	}
+}
+
+// normalize puts a MAC or IPv6 address in the form the monitor compares
+func normalize(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if mac, err := net.ParseMAC(addr); err == nil {
+		return mac.String()
+	}
+	if ip := net.ParseIP(addr); ip != nil {
+		return ip.String()
+	}
+	return strings.ToLower(addr)
+}
+
+func prefixList(prefixes []string) string {
+	if len(prefixes) == 0 {
+		return ""
+	}
+	return " advertising " + strings.Join(prefixes, ", ")
+}
+
+func orUnknown(mac string) string {
+	if mac == "" {
+		return "MAC unknown"
+	}
+	return mac
+}
@@ -0,0 +1,132 @@
+package ndp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+var (
+	routerMAC = net.HardwareAddr{0xaa, 0xbb, 0xcc, 0x00, 0x00, 0x01}
+	hostMAC   = net.HardwareAddr{0xaa, 0xbb, 0xcc, 0x00, 0x00, 0x02}
+)
+
+// ndPacket builds an Ethernet frame carrying a neighbor discovery message
+func ndPacket(t *testing.T, srcMAC net.HardwareAddr, src, dst string, icmpType uint8, msg gopacket.SerializableLayer) gopacket.Packet {
+	t.Helper()
+	ip6 := &layers.IPv6{Version: 6, NextHeader: layers.IPProtocolICMPv6, HopLimit: 255, SrcIP: net.ParseIP(src), DstIP: net.ParseIP(dst)}
+	icmp := &layers.ICMPv6{TypeCode: layers.CreateICMPv6TypeCode(icmpType, 0)}
+	icmp.SetNetworkLayerForChecksum(ip6)
+	eth := &layers.Ethernet{SrcMAC: srcMAC, DstMAC: net.HardwareAddr{0x33, 0x33, 0, 0, 0, 1}, EthernetType: layers.EthernetTypeIPv6}
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, eth, ip6, icmp, msg); err != nil {
+		t.Fatalf("Failed to build packet: %v", err)
+	}
+	return gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+}
+
+func prefixOption(prefix string, length uint8) layers.ICMPv6Option {
+	data := make([]byte, 30)
+	data[0] = length
+	copy(data[14:], net.ParseIP(prefix).To16())
+	return layers.ICMPv6Option{Type: layers.ICMPv6OptPrefixInfo, Data: data}
+}
+
+func routerAdvertisement(t *testing.T, mac net.HardwareAddr, src, prefix string) gopacket.Packet {
+	return ndPacket(t, mac, src, "ff02::1", layers.ICMPv6TypeRouterAdvertisement, &layers.ICMPv6RouterAdvertisement{
+		HopLimit:       64,
+		RouterLifetime: 1800,
+		Options: layers.ICMPv6Options{
+			{Type: layers.ICMPv6OptSourceAddress, Data: mac},
+			prefixOption(prefix, 64),
+		},
+	})
+}
+
+func TestDecode_RouterAdvertisement(t *testing.T) {
+	info, ok := Decode(routerAdvertisement(t, routerMAC, "fe80::1", "2001:db8:1::"))
+	if !ok {
+		t.Fatal("Expected a router advertisement to decode")
+	}
+	if info.Type != models.NDPRouterAdvertisement || info.SenderIP != "fe80::1" || info.SenderMAC != "aa:bb:cc:00:00:01" || info.RouterLifetime != 1800 {
+		t.Errorf("Unexpected advertisement: %+v", info)
+	}
+	if len(info.Prefixes) != 1 || info.Prefixes[0] != "2001:db8:1::/64" {
+		t.Errorf("Expected the advertised prefix, got %v", info.Prefixes)
+	}
+}
+
+func TestDecode_Neighbors(t *testing.T) {
+	solicitation := ndPacket(t, hostMAC, "fe80::2", "ff02::1:ff00:1", layers.ICMPv6TypeNeighborSolicitation, &layers.ICMPv6NeighborSolicitation{
+		TargetAddress: net.ParseIP("fe80::1"),
+		Options:       layers.ICMPv6Options{{Type: layers.ICMPv6OptSourceAddress, Data: hostMAC}},
+	})
+	info, ok := Decode(solicitation)
+	if !ok || info.Type != models.NDPNeighborSolicitation || info.TargetIP != "fe80::1" {
+		t.Fatalf("Expected a neighbor solicitation for fe80::1, got %+v", info)
+	}
+	if bindings := Bindings(info); len(bindings) != 1 || bindings["fe80::2"] != "aa:bb:cc:00:00:02" {
+		t.Errorf("Expected only the sender's binding, got %v", bindings)
+	}
+
+	// Without a target link-layer option the answer's Ethernet source is used
+	advertisement := ndPacket(t, routerMAC, "fe80::1", "fe80::2", layers.ICMPv6TypeNeighborAdvertisement, &layers.ICMPv6NeighborAdvertisement{
+		Flags:         0xc0, // Router, solicited
+		TargetAddress: net.ParseIP("fe80::1"),
+	})
+	info, ok = Decode(advertisement)
+	if !ok || info.Type != models.NDPNeighborAdvertisement || !info.Router || info.TargetMAC != "aa:bb:cc:00:00:01" {
+		t.Fatalf("Expected a router's neighbor advertisement, got %+v", info)
+	}
+	if bindings := Bindings(info); len(bindings) != 1 || bindings["fe80::1"] != "aa:bb:cc:00:00:01" {
+		t.Errorf("Expected the target's binding, got %v", bindings)
+	}
+
+	// Duplicate address detection probes come from the unspecified address
+	probe := ndPacket(t, hostMAC, "::", "ff02::1:ff00:2", layers.ICMPv6TypeNeighborSolicitation, &layers.ICMPv6NeighborSolicitation{
+		TargetAddress: net.ParseIP("fe80::2"),
+	})
+	if info, ok := Decode(probe); !ok || len(Bindings(info)) != 0 {
+		t.Errorf("Expected a DAD probe to bind nothing, got %+v", info)
+	}
+}
+
+func TestMonitor_RogueRouter(t *testing.T) {
+	start := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	real, _ := Decode(routerAdvertisement(t, routerMAC, "fe80::1", "2001:db8:1::"))
+	rogue, _ := Decode(routerAdvertisement(t, hostMAC, "fe80::2", "2001:db8:bad::"))
+
+	// Without a list the first router seen is trusted
+	m := NewMonitor(nil)
+	var raised []Alert
+	m.OnAlert = func(alert Alert) { raised = append(raised, alert) }
+	m.Observe(real, start)
+	m.Observe(rogue, start.Add(time.Second))
+	m.Observe(rogue, start.Add(2*time.Second))
+	if len(raised) != 1 || raised[0].Type != AlertRogueRouter || raised[0].MAC != "aa:bb:cc:00:00:02" || raised[0].Severity != SeverityHigh {
+		t.Fatalf("Expected one alert for the second router, got %+v", raised)
+	}
+	routers := m.GetRouters()
+	if len(routers) != 2 || !routers[0].Trusted || routers[0].IP != "fe80::1" || routers[1].Trusted {
+		t.Errorf("Expected the trusted router first, got %+v", routers)
+	}
+	if mac := m.MACFor("fe80::2"); mac != "aa:bb:cc:00:00:02" {
+		t.Errorf("Expected the router's address in the neighbor table, got %q", mac)
+	}
+
+	// A listed router is trusted whenever it appears, and only it
+	m = NewMonitor([]string{"AA:BB:CC:00:00:02"})
+	raised = nil
+	m.OnAlert = func(alert Alert) { raised = append(raised, alert) }
+	m.Observe(real, start)
+	m.Observe(rogue, start.Add(time.Second))
+	if len(raised) != 1 || raised[0].IP != "fe80::1" {
+		t.Errorf("Expected only the unlisted router to alert, got %+v", raised)
+	}
+}
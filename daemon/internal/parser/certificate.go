@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+const (
+	tlsCertificate = 0x0b
+	// maxSANs bounds the subject alternative names recorded from one certificate
+	maxSANs = 100
+)
+
+// ParseTLSCertificate returns the leaf certificate of the first Certificate
+// message in the handshake records payload starts with, as a server sends
+// after its ServerHello. TLS 1.3 encrypts the message, so only certificates
+// from TLS 1.2 and older handshakes can be read. A chain cut short still
+// yields the leaf as long as all of it made it in.
+func ParseTLSCertificate(payload []byte) (*models.Certificate, bool) {
+	handshake := handshakeMessages(payload)
+	for len(handshake) >= 4 {
+		msgType := handshake[0]
+		msgLen := int(handshake[1])<<16 | int(handshake[2])<<8 | int(handshake[3])
+		handshake = handshake[4:]
+		if msgLen > len(handshake) {
+			msgLen = len(handshake)
+		}
+		if msgType != tlsCertificate {
+			handshake = handshake[msgLen:]
+			continue
+		}
+		// The list's length, then the leaf's length and its DER encoding
+		body := handshake[:msgLen]
+		if len(body) < 6 {
+			return nil, false
+		}
+		certLen := int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+		if 6+certLen > len(body) {
+			return nil, false
+		}
+		cert, err := x509.ParseCertificate(body[6 : 6+certLen])
+		if err != nil {
+			return nil, false
+		}
+		return describeCertificate(cert), true
+	}
+	return nil, false
+}
+
+// handshakeMessages joins the bodies of the handshake records payload starts
+// with, as a message may span several records
+func handshakeMessages(payload []byte) []byte {
+	var messages []byte
+	for len(payload) >= 5 && payload[0] == tlsHandshake {
+		end := 5 + int(binary.BigEndian.Uint16(payload[3:]))
+		if end > len(payload) {
+			end = len(payload)
+		}
+		messages = append(messages, payload[5:end]...)
+		payload = payload[end:]
+	}
+	return messages
+}
+
+// describeCertificate returns what is recorded of a certificate
+func describeCertificate(cert *x509.Certificate) *models.Certificate {
+	fingerprint := sha256.Sum256(cert.Raw)
+	c := &models.Certificate{
+		Subject:     cert.Subject.String(),
+		Issuer:      cert.Issuer.String(),
+		NotBefore:   cert.NotBefore.UTC(),
+		NotAfter:    cert.NotAfter.UTC(),
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+	}
+	for _, name := range cert.DNSNames {
+		if len(c.SANs) < maxSANs {
+			c.SANs = append(c.SANs, name)
+		}
+	}
+	for _, ip := range cert.IPAddresses {
+		if len(c.SANs) < maxSANs {
+			c.SANs = append(c.SANs, ip.String())
+		}
+	}
+	return c
+}
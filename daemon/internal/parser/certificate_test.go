@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// testCertificate creates a self-signed certificate for example.com
+func testCertificate(t *testing.T, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate a key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com", Organization: []string{"Example"}},
+		DNSNames:     []string{"example.com", "www.example.com"},
+		IPAddresses:  []net.IP{net.ParseIP("192.0.2.1")},
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create a certificate: %v", err)
+	}
+	return der
+}
+
+// certificateRecords builds a Certificate message holding der, split into
+// handshake records of at most size bytes
+func certificateRecords(der []byte, size int) []byte {
+	entry := append([]byte{byte(len(der) >> 16), byte(len(der) >> 8), byte(len(der))}, der...)
+	body := append([]byte{byte(len(entry) >> 16), byte(len(entry) >> 8), byte(len(entry))}, entry...)
+	message := append([]byte{tlsCertificate, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}, body...)
+	var records []byte
+	for len(message) > 0 {
+		n := min(size, len(message))
+		records = append(records, tlsHandshake, 0x03, 0x03, byte(n>>8), byte(n))
+		records = append(records, message[:n]...)
+		message = message[n:]
+	}
+	return records
+}
+
+func TestParseTLSCertificate(t *testing.T) {
+	notAfter := time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC)
+	der := testCertificate(t, notAfter)
+
+	// After a ServerHello, with the message split over records
+	flight := append(serverHello(0x0303, 0xc02f, nil), certificateRecords(der, 100)...)
+	cert, ok := ParseTLSCertificate(flight)
+	if !ok {
+		t.Fatal("Expected the certificate to parse")
+	}
+	if cert.Subject != "CN=example.com,O=Example" || cert.Issuer != cert.Subject || !cert.NotAfter.Equal(notAfter) {
+		t.Errorf("Unexpected certificate: %+v", cert)
+	}
+	if len(cert.SANs) != 3 || cert.SANs[0] != "example.com" || cert.SANs[2] != "192.0.2.1" || cert.Name() != "example.com" {
+		t.Errorf("Expected the DNS and IP SANs, got %v", cert.SANs)
+	}
+	if len(cert.Fingerprint) != 64 {
+		t.Errorf("Expected a SHA-256 fingerprint, got %q", cert.Fingerprint)
+	}
+
+	// A certificate cut short, or a ServerHello alone, yields nothing
+	if _, ok := ParseTLSCertificate(flight[:len(flight)-10]); ok {
+		t.Error("Expected a truncated certificate to be skipped")
+	}
+	if _, ok := ParseTLSCertificate(serverHello(0x0303, 0xc02f, nil)); ok {
+		t.Error("Expected no certificate in a ServerHello alone")
+	}
+}
+
+func TestTLSDecoder_Certificate(t *testing.T) {
+	r := DefaultRegistry()
+	der := testCertificate(t, time.Now().Add(24*time.Hour))
+	flight := append(serverHello(0x0303, 0xc02f, nil), certificateRecords(der, 16384)...)
+	event := &models.NetworkEvent{TransportProtocol: "TCP", SourcePort: 8443, DestPort: 51000}
+
+	// The flight is held until the certificate's record is whole
+	if r.Complete(flight[:len(flight)-10], event) || !r.Complete(flight, event) {
+		t.Error("Expected the flight to be held until the certificate arrives")
+	}
+	r.Decode(flight, event)
+	if event.TLS == nil || event.TLS.Handshake != models.TLSServerHello || event.TLS.Certificate == nil || event.TLS.Certificate.Name() != "example.com" {
+		t.Fatalf("Expected the ServerHello with its certificate, got %+v", event.TLS)
+	}
+
+	// A Certificate starting a segment of its own
+	event = &models.NetworkEvent{TransportProtocol: "TCP", SourcePort: 8443, DestPort: 51000}
+	r.Decode(certificateRecords(der, 16384), event)
+	if event.TLS == nil || event.TLS.Handshake != models.TLSCertificate || event.TLS.Certificate == nil {
+		t.Errorf("Expected the certificate alone, got %+v", event.TLS)
+	}
+}
@@ -0,0 +1,212 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Decoder parses one application protocol out of transport payloads
+type Decoder interface {
+	// Name identifies the decoder in configuration, e.g. "tls"
+	Name() string
+	// Ports lists the ports the decoder claims; traffic to or from them is always handed to it
+	Ports() []int
+	// Detect reports whether a payload on an unclaimed port belongs to the protocol
+	Detect(payload []byte) bool
+	// Decode labels the event with its application protocol and extracts what it can from the payload
+	Decode(payload []byte, event *models.NetworkEvent)
+}
+
+// Framer is implemented by decoders whose messages can span several TCP
+// segments. Complete reports whether payload, the start of a message, holds
+// all of it; until it does the capture holds the segments and hands the
+// decoder the whole message once the rest arrives.
+type Framer interface {
+	Complete(payload []byte) bool
+}
+
+// Upgrader is implemented by decoders whose protocol can take over a
+// connection on a port another decoder claims, as TLS does after an SMTP or
+// IMAP STARTTLS or on a database port. Upgraded reports whether payload
+// starts the protocol; if so the upgrader decodes it and the claiming decoder
+// then labels it, so the connection keeps the name it started with.
+type Upgrader interface {
+	Upgraded(payload []byte) bool
+}
+
+// Registry picks the decoder for each payload, by port first and then by
+// content. Register and disable decoders before capture starts: the registry
+// isn't safe for concurrent changes.
+type Registry struct {
+	decoders []Decoder
+	byPort   map[int]Decoder
+	disabled map[string]bool
+	panics   atomic.Uint64 // Payloads a decoder panicked on, see Decode
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		byPort:   make(map[int]Decoder),
+		disabled: make(map[string]bool),
+	}
+}
+
+// DefaultRegistry creates a registry with every built-in decoder
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	for _, d := range builtinDecoders() {
+		r.Register(d)
+	}
+	return r
+}
+
+// Register adds a decoder. Content detection tries decoders in registration
+// order; a port claimed by an earlier decoder moves to the new one.
+func (r *Registry) Register(d Decoder) {
+	for i, existing := range r.decoders {
+		if existing.Name() == d.Name() {
+			r.decoders = append(r.decoders[:i], r.decoders[i+1:]...)
+			break
+		}
+	}
+	for port, existing := range r.byPort {
+		if existing.Name() == d.Name() {
+			delete(r.byPort, port)
+		}
+	}
+	r.decoders = append(r.decoders, d)
+	for _, port := range d.Ports() {
+		r.byPort[port] = d
+	}
+}
+
+// Disable turns off the named decoders, traffic on their ports goes unlabelled
+func (r *Registry) Disable(names ...string) error {
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if r.lookup(name) == nil {
+			return fmt.Errorf("unknown decoder %q (available: %s)", name, strings.Join(r.Names(), ", "))
+		}
+		r.disabled[name] = true
+	}
+	return nil
+}
+
+// Names returns the names of all registered decoders
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.decoders))
+	for _, d := range r.decoders {
+		names = append(names, d.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Enabled returns the names of the decoders that are switched on
+func (r *Registry) Enabled() []string {
+	var names []string
+	for _, name := range r.Names() {
+		if !r.disabled[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Decode hands the payload to the decoder claiming the destination or source
+// port, or failing that to the first decoder that recognizes its content.
+// A payload an Upgrader recognizes goes to it even on a claimed port.
+// It reports whether a decoder handled the payload. A decoder panicking on
+// a malformed payload doesn't take the capture down: the panic is counted
+// and the payload reported as unhandled, with whatever was decoded so far.
+func (r *Registry) Decode(payload []byte, event *models.NetworkEvent) (handled bool) {
+	d := r.pick(payload, event)
+	if d == nil {
+		return false
+	}
+	defer func() {
+		if recover() != nil {
+			r.panics.Add(1)
+			handled = false
+		}
+	}()
+	d.Decode(payload, event)
+	if claimed := r.claimed(event); claimed != nil && claimed.Name() != d.Name() {
+		claimed.Decode(payload, event)
+	}
+	return true
+}
+
+// Panics returns how many payloads a decoder has panicked on
+func (r *Registry) Panics() uint64 {
+	return r.panics.Load()
+}
+
+// Complete reports whether payload holds a whole message for the decoder
+// Decode would pick. Payloads no Framer handles are always complete.
+func (r *Registry) Complete(payload []byte, event *models.NetworkEvent) bool {
+	if f, ok := r.pick(payload, event).(Framer); ok {
+		return f.Complete(payload)
+	}
+	return true
+}
+
+// pick returns the decoder for a payload, or nil if none handles it
+func (r *Registry) pick(payload []byte, event *models.NetworkEvent) Decoder {
+	claimed := r.claimed(event)
+	if len(payload) == 0 {
+		return claimed
+	}
+	if claimed != nil {
+		for _, d := range r.decoders {
+			if u, ok := d.(Upgrader); ok && d.Name() != claimed.Name() && !r.disabled[d.Name()] && u.Upgraded(payload) {
+				return d
+			}
+		}
+		return claimed
+	}
+	for _, d := range r.decoders {
+		if !r.disabled[d.Name()] && d.Detect(payload) {
+			return d
+		}
+	}
+	return nil
+}
+
+// claimed returns the enabled decoder claiming the destination or source port, or nil
+func (r *Registry) claimed(event *models.NetworkEvent) Decoder {
+	for _, port := range []int{event.DestPort, event.SourcePort} {
+		if d, ok := r.byPort[port]; ok && !r.disabled[d.Name()] {
+			return d
+		}
+	}
+	return nil
+}
+
+// Disabled returns the names of the decoders that are switched off
+func (r *Registry) Disabled() []string {
+	var names []string
+	for _, name := range r.Names() {
+		if r.disabled[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (r *Registry) lookup(name string) Decoder {
+	for _, d := range r.decoders {
+		if d.Name() == name {
+			return d
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func TestRegistry_DecodeByPortAndContent(t *testing.T) {
+	r := DefaultRegistry()
+
+	tests := []struct {
+		name     string
+		srcPort  int
+		dstPort  int
+		payload  string
+		expected string
+	}{
+		{"claimed destination port", 51000, 5432, "\x00\x00", "PostgreSQL"},
+		{"claimed source port", 443, 51000, "\x17\x03\x03", "HTTPS"},
+		{"HTTP on an unclaimed port", 51000, 8081, "GET / HTTP/1.1\r\n", "HTTP"},
+		{"SSH banner on an unclaimed port", 51000, 2222, "SSH-2.0-OpenSSH_9.6\r\n", "SSH"},
+		{"TLS on an unclaimed port", 51000, 8443, "\x16\x03\x01\x00\x05\x01", "TLS"},
+		{"unknown protocol", 51000, 9999, "hello", ""},
+	}
+	for _, tt := range tests {
+		event := &models.NetworkEvent{TransportProtocol: "TCP", SourcePort: tt.srcPort, DestPort: tt.dstPort}
+		r.Decode([]byte(tt.payload), event)
+		if event.AppProtocol != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, event.AppProtocol)
+		}
+	}
+}
+
+func TestRegistry_Disable(t *testing.T) {
+	r := DefaultRegistry()
+	if err := r.Disable("http", " SSH "); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := r.Disable("gopher"); err == nil {
+		t.Error("Expected an error for an unknown decoder")
+	}
+
+	event := &models.NetworkEvent{TransportProtocol: "TCP", SourcePort: 51000, DestPort: 80}
+	if r.Decode([]byte("GET / HTTP/1.1\r\n"), event) || event.AppProtocol != "" {
+		t.Errorf("Expected disabled decoders to be skipped, got %q", event.AppProtocol)
+	}
+	for _, name := range r.Enabled() {
+		if name == "http" || name == "ssh" {
+			t.Errorf("Expected %s to be reported as disabled", name)
+		}
+	}
+}
+
+type fakeDecoder struct{}
+
+func (fakeDecoder) Name() string               { return "fake" }
+func (fakeDecoder) Ports() []int               { return []int{80} }
+func (fakeDecoder) Detect(payload []byte) bool { return false }
+func (fakeDecoder) Decode(payload []byte, event *models.NetworkEvent) {
+	event.AppProtocol = "Fake"
+}
+
+func TestRegistry_RegisterTakesOverPorts(t *testing.T) {
+	r := DefaultRegistry()
+	r.Register(fakeDecoder{})
+
+	event := &models.NetworkEvent{TransportProtocol: "TCP", SourcePort: 51000, DestPort: 80}
+	r.Decode([]byte("GET / HTTP/1.1\r\n"), event)
+	if event.AppProtocol != "Fake" {
+		t.Errorf("Expected the new decoder to claim port 80, got %q", event.AppProtocol)
+	}
+}
+
+func TestRegistry_Upgrade(t *testing.T) {
+	r := DefaultRegistry()
+	hello := clientHello(t)
+
+	// TLS after STARTTLS, or wrapping a database connection, keeps the port's label and gains the SNI
+	for _, tt := range []struct {
+		port     int
+		expected string
+	}{{25, "SMTP"}, {587, "SMTP"}, {143, "IMAP"}, {5432, "PostgreSQL"}} {
+		event := &models.NetworkEvent{TransportProtocol: "TCP", SourcePort: 51000, DestPort: tt.port}
+		if r.Complete(hello[:100], event) {
+			t.Errorf("Port %d: expected the first part of a ClientHello to be held", tt.port)
+		}
+		r.Decode(hello, event)
+		if event.AppProtocol != tt.expected || event.TLSServerName != "github.com" || event.TLS == nil {
+			t.Errorf("Port %d: expected %s with the SNI, got %q and %q", tt.port, tt.expected, event.AppProtocol, event.TLSServerName)
+		}
+	}
+
+	// Without the tls decoder the port's decoder alone handles it
+	if err := r.Disable("tls"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	event := &models.NetworkEvent{TransportProtocol: "TCP", SourcePort: 51000, DestPort: 25}
+	r.Decode(hello, event)
+	if event.AppProtocol != "SMTP" || event.TLSServerName != "" {
+		t.Errorf("Expected SMTP without the SNI, got %q and %q", event.AppProtocol, event.TLSServerName)
+	}
+}
+
+func TestRegistry_Complete(t *testing.T) {
+	r := DefaultRegistry()
+	hello := clientHello(t)
+	event := &models.NetworkEvent{TransportProtocol: "TCP", SourcePort: 51000, DestPort: 443}
+
+	// A ClientHello split over two segments is held until the second arrives
+	if r.Complete(hello[:100], event) {
+		t.Error("Expected the first part of a ClientHello to be incomplete")
+	}
+	if !r.Complete(hello, event) {
+		t.Error("Expected the whole ClientHello to be complete")
+	}
+	r.Decode(hello[:100], event)
+	if event.TLSServerName != "" {
+		t.Errorf("Expected no SNI from the first segment, got %q", event.TLSServerName)
+	}
+	r.Decode(hello, event)
+	if event.TLSServerName != "github.com" {
+		t.Errorf("Expected the SNI from the whole ClientHello, got %q", event.TLSServerName)
+	}
+
+	web := &models.NetworkEvent{TransportProtocol: "TCP", SourcePort: 51000, DestPort: 80}
+	if r.Complete([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n"), web) {
+		t.Error("Expected HTTP headers without their blank line to be incomplete")
+	}
+	if !r.Complete([]byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"), web) {
+		t.Error("Expected HTTP headers with their blank line to be complete")
+	}
+	// Body segments and protocols without framing are never held
+	if !r.Complete([]byte("{\"partial\": "), web) {
+		t.Error("Expected a body segment to be complete")
+	}
+	unknown := &models.NetworkEvent{TransportProtocol: "TCP", SourcePort: 51000, DestPort: 9999}
+	if !r.Complete([]byte("\x16\x03\x01\x40\x00"), &models.NetworkEvent{TransportProtocol: "TCP", SourcePort: 51000, DestPort: 5432}) ||
+		!r.Complete([]byte("hello"), unknown) {
+		t.Error("Expected payloads no framer handles to be complete")
+	}
+}
+
+type panickingDecoder struct{ fakeDecoder }
+
+func (panickingDecoder) Name() string { return "panicking" }
+func (panickingDecoder) Decode(payload []byte, event *models.NetworkEvent) {
+	event.AppProtocol = "Panicking"
+	_ = payload[len(payload)]
+}
+
+func TestRegistry_DecodeRecovers(t *testing.T) {
+	r := DefaultRegistry()
+	r.Register(panickingDecoder{})
+
+	event := &models.NetworkEvent{TransportProtocol: "TCP", SourcePort: 51000, DestPort: 80}
+	if r.Decode([]byte("GET / HTTP/1.1\r\n"), event) {
+		t.Error("Expected a panicking decoder's payload to be reported as unhandled")
+	}
+	if event.AppProtocol != "Panicking" || r.Panics() != 1 {
+		t.Errorf("Expected the panic counted and the label kept, got %q and %d", event.AppProtocol, r.Panics())
+	}
+}
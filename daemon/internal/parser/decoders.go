@@ -0,0 +1,150 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// builtinDecoders returns the decoders registered by DefaultRegistry
+func builtinDecoders() []Decoder {
+	return []Decoder{
+		tlsDecoder{},
+		httpDecoder{},
+		sshDecoder{},
+		newDNSDecoder(),
+		dhcpDecoder{},
+		mdnsDecoder{},
+		ssdpDecoder{},
+		portDecoder{name: "ftp", protocol: "FTP", ports: []int{21}},
+		portDecoder{name: "smtp", protocol: "SMTP", ports: []int{25, 587}},
+		portDecoder{name: "imap", protocol: "IMAP", ports: []int{143}},
+		portDecoder{name: "mysql", protocol: "MySQL", ports: []int{3306}},
+		portDecoder{name: "postgresql", protocol: "PostgreSQL", ports: []int{5432}},
+		portDecoder{name: "redis", protocol: "Redis", ports: []int{6379}},
+		portDecoder{name: "mongodb", protocol: "MongoDB", ports: []int{27017}},
+	}
+}
+
+// tlsDecoder labels TLS traffic and extracts the versions, cipher suites,
+// ALPN protocols and SNI from ClientHellos and ServerHellos
+type tlsDecoder struct{}
+
+func (tlsDecoder) Name() string { return "tls" }
+func (tlsDecoder) Ports() []int { return []int{443} }
+
+func (tlsDecoder) Detect(payload []byte) bool {
+	// A handshake record with a TLS 1.x (or SSL 3) record version
+	return len(payload) >= 5 && payload[0] == tlsHandshake && payload[1] == 0x03
+}
+
+// Upgraded recognizes a ClientHello or ServerHello on a port another decoder
+// claims, e.g. after STARTTLS or on a TLS-wrapped database connection. It
+// needs the handshake type too, a record header alone is too easily mistaken.
+func (d tlsDecoder) Upgraded(payload []byte) bool {
+	return d.Detect(payload) && len(payload) >= 6 && (payload[5] == tlsClientHello || payload[5] == tlsServerHello)
+}
+
+// Complete holds handshake records until all of them have arrived: a
+// ClientHello with many extensions often doesn't fit in one segment, and
+// a ServerHello is often followed by a Certificate that spans several
+func (tlsDecoder) Complete(payload []byte) bool {
+	for len(payload) >= 5 && payload[0] == tlsHandshake {
+		end := 5 + int(binary.BigEndian.Uint16(payload[3:]))
+		if end > len(payload) {
+			return false
+		}
+		payload = payload[end:]
+	}
+	// Anything else is a record header cut short, or a record of another kind
+	return len(payload) == 0 || payload[0] != tlsHandshake
+}
+
+func (tlsDecoder) Decode(payload []byte, event *models.NetworkEvent) {
+	event.AppProtocol = "TLS"
+	if event.SourcePort == 443 || event.DestPort == 443 {
+		event.AppProtocol = "HTTPS"
+	}
+	if event.TransportProtocol == "TCP" {
+		info, ok := ParseTLSHello(payload)
+		if cert, found := ParseTLSCertificate(payload); found {
+			if !ok {
+				info, ok = &models.TLSInfo{Handshake: models.TLSCertificate}, true
+			}
+			info.Certificate = cert
+		}
+		if ok {
+			event.TLS = info
+			if info.ServerName != "" {
+				event.TLSServerName = info.ServerName
+			}
+		}
+	}
+}
+
+// httpMethods are the request prefixes that identify HTTP/1.x on any port
+var httpMethods = [][]byte{
+	[]byte("GET "), []byte("POST "), []byte("PUT "), []byte("DELETE "), []byte("HEAD "),
+	[]byte("OPTIONS "), []byte("PATCH "), []byte("CONNECT "), []byte("HTTP/1."),
+}
+
+// httpDecoder labels HTTP/1.x requests and responses and extracts their
+// start line and key headers
+type httpDecoder struct{}
+
+func (httpDecoder) Name() string { return "http" }
+func (httpDecoder) Ports() []int { return []int{80} }
+
+func (httpDecoder) Detect(payload []byte) bool {
+	for _, method := range httpMethods {
+		if bytes.HasPrefix(payload, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// Complete holds the start of a request or response until its headers end
+func (d httpDecoder) Complete(payload []byte) bool {
+	if !d.Detect(payload) {
+		return true
+	}
+	return bytes.Contains(payload, []byte("\r\n\r\n"))
+}
+
+func (httpDecoder) Decode(payload []byte, event *models.NetworkEvent) {
+	event.AppProtocol = "HTTP"
+	if info, ok := ParseHTTP(payload); ok {
+		event.HTTP = info
+	}
+}
+
+// sshDecoder labels SSH, recognizing the version banner on other ports
+type sshDecoder struct{}
+
+func (sshDecoder) Name() string { return "ssh" }
+func (sshDecoder) Ports() []int { return []int{22} }
+
+func (sshDecoder) Detect(payload []byte) bool {
+	return bytes.HasPrefix(payload, []byte("SSH-"))
+}
+
+func (sshDecoder) Decode(payload []byte, event *models.NetworkEvent) {
+	event.AppProtocol = "SSH"
+}
+
+// portDecoder labels a protocol by port alone, without looking at the payload
+type portDecoder struct {
+	name     string
+	protocol string
+	ports    []int
+}
+
+func (d portDecoder) Name() string               { return d.name }
+func (d portDecoder) Ports() []int               { return d.ports }
+func (d portDecoder) Detect(payload []byte) bool { return false }
+
+func (d portDecoder) Decode(payload []byte, event *models.NetworkEvent) {
+	event.AppProtocol = d.protocol
+}
@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// maxDHCPName bounds the hostname and vendor class recorded from a DHCP message
+const maxDHCPName = 64
+
+// dhcpMaxHardwareLen is the size of the chaddr field. gopacket slices the
+// client hardware address by the hlen byte unchecked and panics on one over 227.
+const dhcpMaxHardwareLen = 16
+
+// dhcpTypes names the DHCP message types
+var dhcpTypes = map[layers.DHCPMsgType]string{
+	layers.DHCPMsgTypeDiscover: models.DHCPDiscover,
+	layers.DHCPMsgTypeOffer:    models.DHCPOffer,
+	layers.DHCPMsgTypeRequest:  models.DHCPRequest,
+	layers.DHCPMsgTypeDecline:  models.DHCPDecline,
+	layers.DHCPMsgTypeAck:      models.DHCPAck,
+	layers.DHCPMsgTypeNak:      models.DHCPNak,
+	layers.DHCPMsgTypeRelease:  models.DHCPRelease,
+	layers.DHCPMsgTypeInform:   models.DHCPInform,
+}
+
+// dhcpDecoder decodes DHCP, whose clients name themselves and their vendor
+type dhcpDecoder struct{}
+
+func (dhcpDecoder) Name() string               { return "dhcp" }
+func (dhcpDecoder) Ports() []int               { return []int{67, 68} }
+func (dhcpDecoder) Detect(payload []byte) bool { return false }
+
+func (dhcpDecoder) Decode(payload []byte, event *models.NetworkEvent) {
+	event.AppProtocol = "DHCP"
+	if info, ok := ParseDHCP(payload); ok {
+		event.DHCP = info
+	}
+}
+
+// ParseDHCP decodes a DHCP message over Ethernet, or returns false for
+// BOOTP without a DHCP message type and anything malformed
+func ParseDHCP(payload []byte) (*models.DHCPInfo, bool) {
+	if len(payload) < 3 || payload[2] > dhcpMaxHardwareLen {
+		return nil, false
+	}
+	var dhcp layers.DHCPv4
+	if err := dhcp.DecodeFromBytes(payload[:len(payload):len(payload)], gopacket.NilDecodeFeedback); err != nil {
+		return nil, false
+	}
+	if dhcp.HardwareType != layers.LinkTypeEthernet || len(dhcp.ClientHWAddr) != 6 {
+		return nil, false
+	}
+	info := &models.DHCPInfo{
+		TransactionID: dhcp.Xid,
+		ClientMAC:     dhcp.ClientHWAddr.String(),
+		ClientIP:      dhcpAddress(dhcp.ClientIP),
+		YourIP:        dhcpAddress(dhcp.YourClientIP),
+	}
+	for _, option := range dhcp.Options {
+		switch option.Type {
+		case layers.DHCPOptMessageType:
+			if len(option.Data) == 1 {
+				info.Type = dhcpTypes[layers.DHCPMsgType(option.Data[0])]
+			}
+		case layers.DHCPOptRequestIP:
+			if len(option.Data) == 4 {
+				info.RequestedIP = dhcpAddress(option.Data)
+			}
+		case layers.DHCPOptServerID:
+			if len(option.Data) == 4 {
+				info.ServerID = dhcpAddress(option.Data)
+			}
+		case layers.DHCPOptLeaseTime:
+			if len(option.Data) == 4 {
+				info.LeaseSeconds = binary.BigEndian.Uint32(option.Data)
+			}
+		case layers.DHCPOptHostname:
+			info.Hostname = dhcpName(option.Data)
+		case layers.DHCPOptClassID:
+			info.VendorClass = dhcpName(option.Data)
+		}
+	}
+	if info.Type == "" {
+		return nil, false
+	}
+	return info, true
+}
+
+// dhcpAddress returns an IPv4 address, or "" for 0.0.0.0
+func dhcpAddress(ip net.IP) string {
+	if len(ip) != 4 && len(ip) != 16 || ip.IsUnspecified() {
+		return ""
+	}
+	return ip.String()
+}
+
+// dhcpName returns a name from an option, cut short and trimmed of the
+// trailing NULs some clients send, or "" if it isn't printable
+func dhcpName(data []byte) string {
+	name := strings.TrimRight(string(data), "\x00")
+	if len(name) > maxDHCPName {
+		name = name[:maxDHCPName]
+	}
+	for _, r := range name {
+		if r < 0x20 || r > 0x7e {
+			return ""
+		}
+	}
+	return name
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// dhcpMessage serializes a DHCP message of a type with the given options
+func dhcpMessage(t testing.TB, msgType layers.DHCPMsgType, yourIP string, options ...layers.DHCPOption) []byte {
+	t.Helper()
+	mac, _ := net.ParseMAC("aa:bb:cc:00:11:22")
+	op := layers.DHCPOpRequest
+	if msgType == layers.DHCPMsgTypeOffer || msgType == layers.DHCPMsgTypeAck {
+		op = layers.DHCPOpReply
+	}
+	dhcp := &layers.DHCPv4{
+		Operation:    op,
+		HardwareType: layers.LinkTypeEthernet,
+		HardwareLen:  6,
+		Xid:          0x1234,
+		ClientIP:     net.IPv4zero,
+		YourClientIP: net.ParseIP(yourIP),
+		NextServerIP: net.IPv4zero,
+		RelayAgentIP: net.IPv4zero,
+		ClientHWAddr: mac,
+		Options:      append([]layers.DHCPOption{layers.NewDHCPOption(layers.DHCPOptMessageType, []byte{byte(msgType)})}, options...),
+	}
+	buf := gopacket.NewSerializeBuffer()
+	if err := dhcp.SerializeTo(buf, gopacket.SerializeOptions{FixLengths: true}); err != nil {
+		t.Fatalf("Failed to serialize DHCP: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseDHCP(t *testing.T) {
+	request := dhcpMessage(t, layers.DHCPMsgTypeRequest, "0.0.0.0",
+		layers.NewDHCPOption(layers.DHCPOptRequestIP, []byte{192, 168, 1, 50}),
+		layers.NewDHCPOption(layers.DHCPOptHostname, []byte("kitchen-ipad\x00")),
+		layers.NewDHCPOption(layers.DHCPOptClassID, []byte("android-dhcp-14")))
+	info, ok := ParseDHCP(request)
+	if !ok {
+		t.Fatal("Expected the request to parse")
+	}
+	if info.Type != models.DHCPRequest || info.ClientMAC != "aa:bb:cc:00:11:22" || info.RequestedIP != "192.168.1.50" || info.ClientIP != "" {
+		t.Errorf("Unexpected request: %+v", info)
+	}
+	if info.Hostname != "kitchen-ipad" || info.VendorClass != "android-dhcp-14" {
+		t.Errorf("Expected the hostname and vendor class, got %q and %q", info.Hostname, info.VendorClass)
+	}
+
+	ack := dhcpMessage(t, layers.DHCPMsgTypeAck, "192.168.1.50",
+		layers.NewDHCPOption(layers.DHCPOptServerID, []byte{192, 168, 1, 1}),
+		layers.NewDHCPOption(layers.DHCPOptLeaseTime, []byte{0, 0, 0x0e, 0x10}))
+	info, ok = ParseDHCP(ack)
+	if !ok || info.Type != models.DHCPAck || info.YourIP != "192.168.1.50" || info.ServerID != "192.168.1.1" || info.LeaseSeconds != 3600 {
+		t.Errorf("Unexpected ack: %+v", info)
+	}
+
+	// Names with control characters are dropped, and BOOTP without a message type isn't DHCP
+	info, _ = ParseDHCP(dhcpMessage(t, layers.DHCPMsgTypeDiscover, "0.0.0.0", layers.NewDHCPOption(layers.DHCPOptHostname, []byte("bad\x1bname"))))
+	if info == nil || info.Hostname != "" {
+		t.Errorf("Expected a discover without its hostname, got %+v", info)
+	}
+	if _, ok := ParseDHCP(request[:100]); ok {
+		t.Error("Expected a truncated message to be refused")
+	}
+
+	// Decoded on the DHCP ports
+	event := &models.NetworkEvent{TransportProtocol: "UDP", SourcePort: 68, DestPort: 67}
+	DefaultRegistry().Decode(request, event)
+	if event.AppProtocol != "DHCP" || event.DHCP == nil || event.DHCP.Hostname != "kitchen-ipad" {
+		t.Errorf("Expected a DHCP event, got %q %+v", event.AppProtocol, event.DHCP)
+	}
+}
+
+func FuzzParseDHCP(f *testing.F) {
+	f.Add(dhcpMessage(f, layers.DHCPMsgTypeAck, "192.168.1.50",
+		layers.NewDHCPOption(layers.DHCPOptServerID, []byte{192, 168, 1, 1}),
+		layers.NewDHCPOption(layers.DHCPOptLeaseTime, []byte{0, 1, 81, 128}),
+		layers.NewDHCPOption(layers.DHCPOptHostname, []byte("kitchen-ipad"))))
+	// An hlen over 227 overflows gopacket's slicing of the hardware address
+	oversized := dhcpMessage(f, layers.DHCPMsgTypeDiscover, "0.0.0.0")
+	oversized[2] = 0xf8
+	f.Add(oversized)
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		ParseDHCP(payload)
+	})
+}
@@ -0,0 +1,180 @@
+package parser
+
+import (
+	"bytes"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Limits on what is recorded from a discovery message
+const (
+	maxDiscoveryName     = 64 // Runes kept of a device or instance name
+	maxDiscoveryServices = 32 // Services kept per message
+)
+
+// mdnsServiceTypes is the name under which mDNS enumerates service types
+const mdnsServiceTypes = "_services._dns-sd._udp.local"
+
+// mdnsDecoder decodes mDNS (Bonjour) responses, in which devices announce
+// their names and the services they offer
+type mdnsDecoder struct{}
+
+func (mdnsDecoder) Name() string               { return "mdns" }
+func (mdnsDecoder) Ports() []int               { return []int{5353} }
+func (mdnsDecoder) Detect(payload []byte) bool { return false }
+
+func (mdnsDecoder) Decode(payload []byte, event *models.NetworkEvent) {
+	event.AppProtocol = "mDNS"
+	if info, ok := ParseMDNS(payload); ok {
+		event.Discovery = info
+	}
+}
+
+// ssdpDecoder decodes SSDP (UPnP) announcements and search responses
+type ssdpDecoder struct{}
+
+func (ssdpDecoder) Name() string               { return "ssdp" }
+func (ssdpDecoder) Ports() []int               { return []int{1900} }
+func (ssdpDecoder) Detect(payload []byte) bool { return false }
+
+func (ssdpDecoder) Decode(payload []byte, event *models.NetworkEvent) {
+	event.AppProtocol = "SSDP"
+	if info, ok := ParseSSDP(payload); ok {
+		event.Discovery = info
+	}
+}
+
+// ParseMDNS decodes the services and device names an mDNS response
+// advertises, or returns false for queries, responses advertising nothing,
+// and anything malformed
+func ParseMDNS(payload []byte) (*models.DiscoveryInfo, bool) {
+	msg, ok := decodeDNS(payload)
+	if !ok || !msg.QR {
+		return nil, false
+	}
+
+	// Services are pointed to by PTR records, and located by the SRV and
+	// described by the TXT records named after their instances
+	info := &models.DiscoveryInfo{Protocol: models.DiscoveryMDNS}
+	var services []string // Instance names, in the order advertised
+	byInstance := make(map[string]*models.DiscoveredService)
+	ports := make(map[string]int)
+	records := append(msg.Answers, msg.Additionals...)
+	for _, record := range records {
+		name := string(record.Name)
+		switch record.Type {
+		case layers.DNSTypePTR:
+			if name == mdnsServiceTypes || !strings.HasSuffix(name, ".local") || strings.HasSuffix(name, ".arpa") {
+				continue
+			}
+			instance := string(record.PTR)
+			if _, seen := byInstance[instance]; seen || len(byInstance) >= maxDiscoveryServices {
+				continue
+			}
+			byInstance[instance] = &models.DiscoveredService{
+				Type:     strings.TrimSuffix(name, ".local"),
+				Instance: discoveryName(strings.TrimSuffix(instance, "."+name)),
+				Goodbye:  record.TTL == 0,
+			}
+			services = append(services, instance)
+		case layers.DNSTypeSRV:
+			ports[name] = int(record.SRV.Port)
+			if info.Host == "" {
+				info.Host = mdnsHost(string(record.SRV.Name))
+			}
+		case layers.DNSTypeTXT:
+			for _, txt := range record.TXTs {
+				// Chromecasts and other Google devices give their friendly name as fn=
+				if value, ok := bytes.CutPrefix(txt, []byte("fn=")); ok && info.Name == "" {
+					info.Name = discoveryName(string(value))
+				}
+			}
+		case layers.DNSTypeA, layers.DNSTypeAAAA:
+			if strings.HasSuffix(name, ".local") {
+				info.Host = mdnsHost(name)
+			}
+		}
+	}
+
+	for _, instance := range services {
+		service := byInstance[instance]
+		service.Port = ports[instance]
+		info.Services = append(info.Services, *service)
+	}
+	if info.Name == "" && info.Host == "" && len(info.Services) == 0 {
+		return nil, false
+	}
+	return info, true
+}
+
+// mdnsHost returns a .local host name without its domain, e.g. "Office-Printer"
+func mdnsHost(name string) string {
+	return discoveryName(strings.TrimSuffix(strings.TrimSuffix(name, "."), ".local"))
+}
+
+// ParseSSDP decodes an SSDP NOTIFY announcement or a response to an
+// M-SEARCH, or returns false for searches and anything else
+func ParseSSDP(payload []byte) (*models.DiscoveryInfo, bool) {
+	lines := strings.Split(string(payload), "\r\n")
+	if len(lines) < 2 {
+		return nil, false
+	}
+	// Announcements carry the type in NT, search responses in ST
+	typeHeader := "ST"
+	switch {
+	case strings.HasPrefix(lines[0], "NOTIFY * HTTP/1."):
+		typeHeader = "NT"
+	case strings.HasPrefix(lines[0], "HTTP/1.1 200"):
+	default:
+		return nil, false
+	}
+
+	headers := make(map[string]string)
+	for _, line := range lines[1:] {
+		if line == "" {
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok {
+			headers[strings.ToUpper(strings.TrimSpace(name))] = strings.TrimSpace(value)
+		}
+	}
+
+	info := &models.DiscoveryInfo{Protocol: models.DiscoverySSDP, Server: discoveryName(headers["SERVER"])}
+	// A "uuid:" type only repeats the device's unique service name
+	if serviceType := headers[typeHeader]; serviceType != "" && !strings.HasPrefix(serviceType, "uuid:") {
+		service := models.DiscoveredService{
+			Type:    discoveryName(serviceType),
+			Goodbye: headers["NTS"] == "ssdp:byebye",
+		}
+		if location, err := url.Parse(headers["LOCATION"]); err == nil && location.Host != "" {
+			service.Location = location.String()
+			service.Port, _ = strconv.Atoi(location.Port())
+		}
+		info.Services = append(info.Services, service)
+	}
+	if info.Server == "" && len(info.Services) == 0 {
+		return nil, false
+	}
+	return info, true
+}
+
+// discoveryName returns a name cut short and stripped of control
+// characters; unlike DHCP hostnames these are often UTF-8, e.g. "Kim’s iPhone"
+func discoveryName(name string) string {
+	name = strings.ToValidUTF8(name, "")
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	if runes := []rune(name); len(runes) > maxDiscoveryName {
+		name = string(runes[:maxDiscoveryName])
+	}
+	return strings.TrimSpace(name)
+}
@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// mdnsResponse serializes an mDNS response with the given records
+func mdnsResponse(t testing.TB, answers []layers.DNSResourceRecord, additionals ...layers.DNSResourceRecord) []byte {
+	t.Helper()
+	dns := &layers.DNS{QR: true, AA: true, Answers: answers, Additionals: additionals}
+	buf := gopacket.NewSerializeBuffer()
+	if err := dns.SerializeTo(buf, gopacket.SerializeOptions{FixLengths: true}); err != nil {
+		t.Fatalf("Failed to serialize mDNS: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseMDNS(t *testing.T) {
+	cast := "Living Room TV-1a2b._googlecast._tcp.local"
+	response := mdnsResponse(t,
+		[]layers.DNSResourceRecord{
+			{Name: []byte("_googlecast._tcp.local"), Type: layers.DNSTypePTR, Class: layers.DNSClassIN, TTL: 120, PTR: []byte(cast)},
+		},
+		layers.DNSResourceRecord{Name: []byte(cast), Type: layers.DNSTypeSRV, Class: layers.DNSClassIN, TTL: 120,
+			SRV: layers.DNSSRV{Port: 8009, Name: []byte("1a2b.local")}},
+		layers.DNSResourceRecord{Name: []byte(cast), Type: layers.DNSTypeTXT, Class: layers.DNSClassIN, TTL: 4500,
+			TXTs: [][]byte{[]byte("md=Chromecast"), []byte("fn=Living Room TV")}},
+		layers.DNSResourceRecord{Name: []byte("1a2b.local"), Type: layers.DNSTypeA, Class: layers.DNSClassIN, TTL: 120,
+			IP: net.ParseIP("192.168.1.40").To4()},
+	)
+	info, ok := ParseMDNS(response)
+	if !ok {
+		t.Fatal("Expected the response to parse")
+	}
+	if info.Protocol != models.DiscoveryMDNS || info.Name != "Living Room TV" || info.Host != "1a2b" {
+		t.Errorf("Expected the friendly name from the TXT record, got %+v", info)
+	}
+	want := models.DiscoveredService{Type: "_googlecast._tcp", Instance: "Living Room TV-1a2b", Port: 8009}
+	if len(info.Services) != 1 || info.Services[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, info.Services)
+	}
+
+	// A TTL of 0 withdraws the service
+	printer := "Office._ipp._tcp.local"
+	goodbye := mdnsResponse(t, []layers.DNSResourceRecord{
+		{Name: []byte("_ipp._tcp.local"), Type: layers.DNSTypePTR, Class: layers.DNSClassIN, PTR: []byte(printer)},
+		{Name: []byte("office-printer.local"), Type: layers.DNSTypeA, Class: layers.DNSClassIN, TTL: 120, IP: net.ParseIP("192.168.1.41").To4()},
+	})
+	info, ok = ParseMDNS(goodbye)
+	if !ok || info.Name != "" || info.Host != "office-printer" || len(info.Services) != 1 || !info.Services[0].Goodbye || info.Services[0].Instance != "Office" {
+		t.Errorf("Unexpected goodbye: %+v", info)
+	}
+
+	// Queries and ordinary DNS answers advertise nothing
+	query := &layers.DNS{Questions: []layers.DNSQuestion{{Name: []byte("_googlecast._tcp.local"), Type: layers.DNSTypePTR, Class: layers.DNSClassIN}}}
+	buf := gopacket.NewSerializeBuffer()
+	if err := query.SerializeTo(buf, gopacket.SerializeOptions{FixLengths: true}); err != nil {
+		t.Fatalf("Failed to serialize query: %v", err)
+	}
+	if _, ok := ParseMDNS(buf.Bytes()); ok {
+		t.Error("Expected a query not to parse")
+	}
+	answer := mdnsResponse(t, []layers.DNSResourceRecord{
+		{Name: []byte("example.com"), Type: layers.DNSTypeA, Class: layers.DNSClassIN, TTL: 60, IP: net.ParseIP("93.184.216.34").To4()},
+	})
+	if _, ok := ParseMDNS(answer); ok {
+		t.Error("Expected an answer outside .local not to parse")
+	}
+	if _, ok := ParseMDNS([]byte{0x00, 0x01}); ok {
+		t.Error("Expected a truncated message not to parse")
+	}
+}
+
+func TestParseSSDP(t *testing.T) {
+	notify := "NOTIFY * HTTP/1.1\r\n" +
+		"HOST: 239.255.255.250:1900\r\n" +
+		"CACHE-CONTROL: max-age=1800\r\n" +
+		"Location: http://192.168.1.42:8060/\r\n" +
+		"NT: urn:roku-com:service:ecp:1\r\n" +
+		"NTS: ssdp:alive\r\n" +
+		"SERVER: Roku/9.4.0 UPnP/1.0 Roku/9.4.0\r\n" +
+		"USN: uuid:roku:ecp:X00000000001::urn:roku-com:service:ecp:1\r\n\r\n"
+	info, ok := ParseSSDP([]byte(notify))
+	if !ok {
+		t.Fatal("Expected the announcement to parse")
+	}
+	want := models.DiscoveredService{Type: "urn:roku-com:service:ecp:1", Port: 8060, Location: "http://192.168.1.42:8060/"}
+	if info.Protocol != models.DiscoverySSDP || info.Server != "Roku/9.4.0 UPnP/1.0 Roku/9.4.0" || len(info.Services) != 1 || info.Services[0] != want {
+		t.Errorf("Unexpected announcement: %+v", info)
+	}
+
+	byebye := "NOTIFY * HTTP/1.1\r\nNT: upnp:rootdevice\r\nNTS: ssdp:byebye\r\nUSN: uuid:1234::upnp:rootdevice\r\n\r\n"
+	if info, ok := ParseSSDP([]byte(byebye)); !ok || len(info.Services) != 1 || !info.Services[0].Goodbye {
+		t.Errorf("Expected a withdrawn service, got %+v", info)
+	}
+
+	response := "HTTP/1.1 200 OK\r\nST: urn:schemas-upnp-org:device:MediaRenderer:1\r\nLOCATION: http://192.168.1.43:49152/description.xml\r\n\r\n"
+	if info, ok := ParseSSDP([]byte(response)); !ok || info.Services[0].Type != "urn:schemas-upnp-org:device:MediaRenderer:1" || info.Services[0].Port != 49152 {
+		t.Errorf("Unexpected search response: %+v", info)
+	}
+
+	search := "M-SEARCH * HTTP/1.1\r\nHOST: 239.255.255.250:1900\r\nMAN: \"ssdp:discover\"\r\nST: ssdp:all\r\n\r\n"
+	if _, ok := ParseSSDP([]byte(search)); ok {
+		t.Error("Expected a search not to parse")
+	}
+}
+
+func TestDiscoveryName(t *testing.T) {
+	if got := discoveryName("Kim’s iPhone\x00\x07"); got != "Kim’s iPhone" {
+		t.Errorf("Expected control characters stripped, got %q", got)
+	}
+	long := discoveryName(strings.Repeat("é", 100))
+	if len([]rune(long)) != maxDiscoveryName {
+		t.Errorf("Expected the name cut to %d runes, got %d", maxDiscoveryName, len([]rune(long)))
+	}
+}
+
+func FuzzParseMDNS(f *testing.F) {
+	cast := "Living Room TV-1a2b._googlecast._tcp.local"
+	f.Add(mdnsResponse(f,
+		[]layers.DNSResourceRecord{
+			{Name: []byte("_googlecast._tcp.local"), Type: layers.DNSTypePTR, Class: layers.DNSClassIN, TTL: 120, PTR: []byte(cast)},
+		},
+		layers.DNSResourceRecord{Name: []byte(cast), Type: layers.DNSTypeSRV, Class: layers.DNSClassIN, TTL: 120,
+			SRV: layers.DNSSRV{Port: 8009, Name: []byte("1a2b.local")}},
+		layers.DNSResourceRecord{Name: []byte(cast), Type: layers.DNSTypeTXT, Class: layers.DNSClassIN, TTL: 4500,
+			TXTs: [][]byte{[]byte("fn=Living Room TV")}},
+	))
+	f.Add([]byte("\x00\x01\x81\x80\x00\x01\x00\x01\x00\x00\x00\x00\x03bc\x00\x00\x00\xdf\x01\xc0\f\x00\x01\x00\x01\x00\x00\x00<\x00\x04\x01\x00\x00\x01"))
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		ParseMDNS(payload)
+	})
+}
+
+func FuzzParseSSDP(f *testing.F) {
+	f.Add([]byte("NOTIFY * HTTP/1.1\r\nNT: upnp:rootdevice\r\nNTS: ssdp:alive\r\nLOCATION: http://192.168.1.42:8060/\r\nSERVER: Roku/9.4.0\r\n\r\n"))
+	f.Add([]byte("HTTP/1.1 200 OK\r\nST: urn:schemas-upnp-org:device:MediaRenderer:1\r\nLOCATION: http://[::1\r\n\r\n"))
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		ParseSSDP(payload)
+	})
+}
@@ -0,0 +1,157 @@
+package parser
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Limits on the queries waiting for a response
+const (
+	dnsQueryTimeout = 10 * time.Second
+	dnsMaxPending   = 10000
+)
+
+// dnsRCodes are the mnemonics of the common response codes
+var dnsRCodes = map[layers.DNSResponseCode]string{
+	layers.DNSResponseCodeNoErr:    "NOERROR",
+	layers.DNSResponseCodeFormErr:  "FORMERR",
+	layers.DNSResponseCodeServFail: "SERVFAIL",
+	layers.DNSResponseCodeNXDomain: "NXDOMAIN",
+	layers.DNSResponseCodeNotImp:   "NOTIMP",
+	layers.DNSResponseCodeRefused:  "REFUSED",
+}
+
+// dnsDecoder decodes DNS over UDP and TCP, and times each response against
+// the query with the same transaction ID from the same client
+type dnsDecoder struct {
+	pending map[string]time.Time // Client, server and transaction ID -> query time
+	mu      sync.Mutex
+}
+
+func newDNSDecoder() *dnsDecoder {
+	return &dnsDecoder{pending: make(map[string]time.Time)}
+}
+
+func (*dnsDecoder) Name() string               { return "dns" }
+func (*dnsDecoder) Ports() []int               { return []int{53} }
+func (*dnsDecoder) Detect(payload []byte) bool { return false }
+
+// Complete holds a DNS over TCP message until its length prefix is satisfied
+func (*dnsDecoder) Complete(payload []byte) bool {
+	return len(payload) >= 2 && len(payload)-2 >= int(binary.BigEndian.Uint16(payload))
+}
+
+func (d *dnsDecoder) Decode(payload []byte, event *models.NetworkEvent) {
+	event.AppProtocol = "DNS"
+	info, ok := ParseDNS(payload, event.TransportProtocol == "TCP")
+	if !ok {
+		return
+	}
+	event.DNS = info
+	d.correlate(info, event)
+}
+
+// correlate remembers queries and sets the latency of the responses answering them
+func (d *dnsDecoder) correlate(info *models.DNSInfo, event *models.NetworkEvent) {
+	if event.Timestamp.IsZero() {
+		return
+	}
+	// Queries go from the client to port 53, responses come back the other way
+	client, server := endpoint(event.SourceIP, event.SourcePort), event.DestIP
+	if info.Response {
+		client, server = endpoint(event.DestIP, event.DestPort), event.SourceIP
+	}
+	key := fmt.Sprintf("%s|%s|%d", client, server, info.ID)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !info.Response {
+		if len(d.pending) >= dnsMaxPending {
+			d.expire(event.Timestamp)
+		}
+		if len(d.pending) < dnsMaxPending {
+			d.pending[key] = event.Timestamp
+		}
+		return
+	}
+	sent, ok := d.pending[key]
+	if !ok {
+		return
+	}
+	delete(d.pending, key)
+	if latency := event.Timestamp.Sub(sent); latency >= 0 && latency <= dnsQueryTimeout {
+		info.LatencyMs = float64(latency) / float64(time.Millisecond)
+	}
+}
+
+// expire forgets queries that went unanswered, must be called with the lock held
+func (d *dnsDecoder) expire(now time.Time) {
+	for key, sent := range d.pending {
+		if now.Sub(sent) > dnsQueryTimeout {
+			delete(d.pending, key)
+		}
+	}
+}
+
+// ParseDNS decodes a DNS message, which over TCP is preceded by its length.
+// Only the first question is reported, as resolvers only ever send one.
+func ParseDNS(payload []byte, tcp bool) (*models.DNSInfo, bool) {
+	if tcp {
+		if len(payload) < 2 || int(binary.BigEndian.Uint16(payload)) > len(payload)-2 {
+			return nil, false
+		}
+		payload = payload[2 : 2+binary.BigEndian.Uint16(payload)]
+	}
+
+	msg, ok := decodeDNS(payload)
+	if !ok || len(msg.Questions) == 0 {
+		return nil, false
+	}
+	info := &models.DNSInfo{
+		ID:        msg.ID,
+		Response:  msg.QR,
+		Query:     string(msg.Questions[0].Name),
+		QueryType: msg.Questions[0].Type.String(),
+	}
+	if !msg.QR {
+		return info, true
+	}
+
+	info.RCode = dnsRCodes[msg.ResponseCode]
+	if info.RCode == "" {
+		info.RCode = fmt.Sprintf("RCODE%d", msg.ResponseCode)
+	}
+	for _, answer := range msg.Answers {
+		if (answer.Type == layers.DNSTypeA || answer.Type == layers.DNSTypeAAAA) && answer.IP != nil {
+			info.Answers = append(info.Answers, answer.IP.String())
+		}
+	}
+	return info, true
+}
+
+// decodeDNS decodes a DNS message, or returns false if it's malformed.
+// gopacket's decoder slices past the end of some truncated records, reading
+// whatever follows the payload in its buffer or panicking; the payload is
+// capped at its length so it can't read on, and the panic is recovered.
+func decodeDNS(payload []byte) (msg *layers.DNS, ok bool) {
+	defer func() {
+		if recover() != nil {
+			msg, ok = nil, false
+		}
+	}()
+	msg = &layers.DNS{}
+	if err := msg.DecodeFromBytes(payload[:len(payload):len(payload)], gopacket.NilDecodeFeedback); err != nil {
+		return nil, false
+	}
+	return msg, true
+}
+
+func endpoint(ip string, port int) string {
+	return fmt.Sprintf("%s:%d", ip, port)
+}
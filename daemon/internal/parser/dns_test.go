@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func dnsMessage(t testing.TB, msg *layers.DNS) []byte {
+	t.Helper()
+	buf := gopacket.NewSerializeBuffer()
+	if err := msg.SerializeTo(buf, gopacket.SerializeOptions{FixLengths: true}); err != nil {
+		t.Fatalf("Failed to build DNS message: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func dnsQuery(t *testing.T, id uint16, name string) []byte {
+	return dnsMessage(t, &layers.DNS{
+		ID: id, RD: true,
+		Questions: []layers.DNSQuestion{{Name: []byte(name), Type: layers.DNSTypeA, Class: layers.DNSClassIN}},
+	})
+}
+
+func dnsResponse(t testing.TB, id uint16, name string, rcode layers.DNSResponseCode, ips ...net.IP) []byte {
+	msg := &layers.DNS{
+		ID: id, QR: true, RD: true, RA: true, ResponseCode: rcode,
+		Questions: []layers.DNSQuestion{{Name: []byte(name), Type: layers.DNSTypeA, Class: layers.DNSClassIN}},
+	}
+	for _, ip := range ips {
+		msg.Answers = append(msg.Answers, layers.DNSResourceRecord{Name: []byte(name), Type: layers.DNSTypeA, Class: layers.DNSClassIN, TTL: 60, IP: ip})
+	}
+	return dnsMessage(t, msg)
+}
+
+func TestDNSDecoder_CorrelatesQueries(t *testing.T) {
+	r := DefaultRegistry()
+	start := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	query := &models.NetworkEvent{Timestamp: start, TransportProtocol: "UDP", SourceIP: "192.168.1.10", SourcePort: 53000, DestIP: "1.1.1.1", DestPort: 53}
+	r.Decode(dnsQuery(t, 0x1234, "github.com"), query)
+	if query.AppProtocol != "DNS" || query.DNS == nil || query.DNS.Response || query.DNS.Query != "github.com" || query.DNS.QueryType != "A" {
+		t.Fatalf("Expected a decoded A query, got %+v", query.DNS)
+	}
+
+	// A response with another ID, or from another server, doesn't answer it
+	stray := &models.NetworkEvent{Timestamp: start.Add(time.Millisecond), TransportProtocol: "UDP", SourceIP: "8.8.8.8", SourcePort: 53, DestIP: "192.168.1.10", DestPort: 53000}
+	r.Decode(dnsResponse(t, 0x1234, "github.com", layers.DNSResponseCodeNoErr), stray)
+	if stray.DNS == nil || stray.DNS.LatencyMs != 0 {
+		t.Errorf("Expected no latency for an unmatched response, got %+v", stray.DNS)
+	}
+
+	response := &models.NetworkEvent{Timestamp: start.Add(25 * time.Millisecond), TransportProtocol: "UDP", SourceIP: "1.1.1.1", SourcePort: 53, DestIP: "192.168.1.10", DestPort: 53000}
+	r.Decode(dnsResponse(t, 0x1234, "github.com", layers.DNSResponseCodeNoErr, net.IPv4(140, 82, 112, 3), net.IPv4(140, 82, 112, 4)), response)
+	info := response.DNS
+	if info == nil || !info.Response || info.RCode != "NOERROR" || info.LatencyMs != 25 {
+		t.Fatalf("Expected a NOERROR response answered in 25ms, got %+v", info)
+	}
+	if len(info.Answers) != 2 || info.Answers[0] != "140.82.112.3" {
+		t.Errorf("Expected the answer IPs, got %v", info.Answers)
+	}
+
+	// The query was answered, a duplicate response isn't timed again
+	duplicate := *response
+	r.Decode(dnsResponse(t, 0x1234, "github.com", layers.DNSResponseCodeNoErr), &duplicate)
+	if duplicate.DNS.LatencyMs != 0 {
+		t.Errorf("Expected a duplicate response not to be timed, got %+v", duplicate.DNS)
+	}
+}
+
+func TestParseDNS(t *testing.T) {
+	nx := dnsResponse(t, 7, "nope.example", layers.DNSResponseCodeNXDomain)
+	if info, ok := ParseDNS(nx, false); !ok || info.RCode != "NXDOMAIN" || len(info.Answers) != 0 {
+		t.Errorf("Expected an NXDOMAIN response, got %+v", info)
+	}
+
+	// Over TCP the message is preceded by its length
+	framed := make([]byte, 2, 2+len(nx))
+	binary.BigEndian.PutUint16(framed, uint16(len(nx)))
+	framed = append(framed, nx...)
+	if info, ok := ParseDNS(framed, true); !ok || info.Query != "nope.example" {
+		t.Errorf("Expected the TCP message to parse, got %+v", info)
+	}
+	if _, ok := ParseDNS(framed[:10], true); ok {
+		t.Error("Expected a truncated TCP message to be rejected")
+	}
+	if _, ok := ParseDNS([]byte("hello"), false); ok {
+		t.Error("Expected garbage to be rejected")
+	}
+}
+
+func TestParseDNS_Truncated(t *testing.T) {
+	// gopacket reads truncated records past the end of the payload, so cut a
+	// response short at every length inside a buffer with more after it
+	response := dnsResponse(t, 7, "github.com", layers.DNSResponseCodeNoErr, net.IPv4(140, 82, 112, 3))
+	for n := range response {
+		buf := append(append([]byte{}, response...), make([]byte, 64)...)
+		if info, ok := ParseDNS(buf[:n], false); ok && len(info.Answers) != 0 {
+			t.Errorf("Expected no answers from %d of %d bytes, got %+v", n, len(response), info)
+		}
+	}
+}
+
+func FuzzParseDNS(f *testing.F) {
+	f.Add(dnsResponse(f, 7, "github.com", layers.DNSResponseCodeNoErr, net.IPv4(140, 82, 112, 3)), false)
+	f.Add(dnsResponse(f, 7, "nope.example", layers.DNSResponseCodeNXDomain), false)
+	f.Add([]byte("\x00\x01\x81\x80\x00\x01\x00\x01\x00\x00\x00\x00\x03bc\x00\x00\x00\xdf\x01\xc0\f\x00\x01\x00\x01\x00\x00\x00<\x00\x04\x01\x00\x00\x01"), false)
+	f.Fuzz(func(t *testing.T, payload []byte, tcp bool) {
+		ParseDNS(payload, tcp)
+	})
+}
@@ -0,0 +1,54 @@
+package parser
+
+import "github.com/iolloyd/netty/daemon/internal/models"
+
+// TLS record content types: change_cipher_spec, alert, handshake, application_data
+const (
+	tlsFirstContentType = 20
+	tlsLastContentType  = 23
+)
+
+// minTextLine is the shortest payload taken as a cleartext command or reply
+const minTextLine = 8
+
+// ClassifyEncryption tells from a payload whether its conversation is
+// encrypted, returning one of the models.Encryption classes, or "" when the
+// payload can't tell, such as the middle of a TLS record split over segments.
+// Call it after decoding: the decoders' labels are part of the evidence.
+func ClassifyEncryption(payload []byte, event *models.NetworkEvent) string {
+	switch {
+	case len(payload) == 0:
+		return ""
+	case isTLSRecord(payload):
+		return models.EncryptionTLS
+	case event.AppProtocol == "SSH":
+		return models.EncryptionSSH
+	case event.TransportProtocol == "UDP" && (event.SourcePort == 443 || event.DestPort == 443) && payload[0]&0x40 != 0:
+		// QUIC sets the fixed bit in both long and short headers
+		return models.EncryptionQUIC
+	case event.HTTP != nil || event.DNS != nil || isTextLine(payload):
+		return models.EncryptionPlaintext
+	}
+	return ""
+}
+
+// isTLSRecord reports whether payload starts with a TLS (or SSL 3) record header
+func isTLSRecord(payload []byte) bool {
+	return len(payload) >= 5 &&
+		payload[0] >= tlsFirstContentType && payload[0] <= tlsLastContentType &&
+		payload[1] == 0x03 && payload[2] <= 0x04
+}
+
+// isTextLine reports whether payload is printable lines of text, such as an
+// SMTP, FTP, POP3 or IMAP command or reply
+func isTextLine(payload []byte) bool {
+	if len(payload) < minTextLine || payload[len(payload)-1] != '\n' {
+		return false
+	}
+	for _, b := range payload {
+		if (b < 0x20 || b > 0x7e) && b != '\r' && b != '\n' && b != '\t' {
+			return false
+		}
+	}
+	return true
+}
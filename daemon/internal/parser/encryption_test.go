@@ -0,0 +1,33 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func TestClassifyEncryption(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+		event   models.NetworkEvent
+		want    string
+	}{
+		{"TLS application data", []byte{23, 3, 3, 0, 32, 0xde, 0xad}, models.NetworkEvent{TransportProtocol: "TCP", DestPort: 8443}, models.EncryptionTLS},
+		{"STARTTLS handshake", []byte{22, 3, 1, 0, 200, 1}, models.NetworkEvent{TransportProtocol: "TCP", DestPort: 25, AppProtocol: "SMTP"}, models.EncryptionTLS},
+		{"SSH", []byte("SSH-2.0-OpenSSH_9.6\r\n"), models.NetworkEvent{TransportProtocol: "TCP", DestPort: 22, AppProtocol: "SSH"}, models.EncryptionSSH},
+		{"QUIC", []byte{0xc3, 0, 0, 0, 1}, models.NetworkEvent{TransportProtocol: "UDP", DestPort: 443}, models.EncryptionQUIC},
+		{"HTTP", []byte("GET / HTTP/1.1\r\n"), models.NetworkEvent{TransportProtocol: "TCP", DestPort: 80, HTTP: &models.HTTPInfo{Method: "GET"}}, models.EncryptionPlaintext},
+		{"DNS", []byte{0x12, 0x34, 1, 0}, models.NetworkEvent{TransportProtocol: "UDP", DestPort: 53, DNS: &models.DNSInfo{Query: "example.com"}}, models.EncryptionPlaintext},
+		{"SMTP command", []byte("EHLO mail.example.com\r\n"), models.NetworkEvent{TransportProtocol: "TCP", DestPort: 25, AppProtocol: "SMTP"}, models.EncryptionPlaintext},
+		{"binary", []byte{0x00, 0x91, 0x7f, 0x10, 0x0a}, models.NetworkEvent{TransportProtocol: "TCP", DestPort: 5432}, ""},
+		{"empty", nil, models.NetworkEvent{TransportProtocol: "TCP", DestPort: 443}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyEncryption(tt.payload, &tt.event); got != tt.want {
+				t.Errorf("ClassifyEncryption() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
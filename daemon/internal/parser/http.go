@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// maxHTTPField bounds the bytes kept of a path or header value
+const maxHTTPField = 256
+
+// ParseHTTP parses the start line and headers at the beginning of an HTTP/1.x
+// request or response. Headers cut short by the end of the payload are parsed
+// as far as they go; a payload not starting with a start line is rejected.
+func ParseHTTP(payload []byte) (*models.HTTPInfo, bool) {
+	if end := bytes.Index(payload, []byte("\r\n\r\n")); end >= 0 {
+		payload = payload[:end]
+	}
+	lines := strings.Split(string(payload), "\n")
+	info, ok := parseStartLine(strings.TrimSuffix(lines[0], "\r"))
+	if !ok {
+		return nil, false
+	}
+
+	for _, line := range lines[1:] {
+		name, value, found := strings.Cut(strings.TrimSuffix(line, "\r"), ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "host":
+			if info.Host == "" && !info.Response {
+				info.Host = clip(value)
+			}
+		case "user-agent":
+			if !info.Response {
+				info.UserAgent = clip(value)
+			}
+		case "content-length":
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil && n >= 0 {
+				info.ContentLength = n
+			}
+		}
+	}
+	return info, true
+}
+
+// parseStartLine parses a request line ("GET /path HTTP/1.1") or a status line ("HTTP/1.1 200 OK")
+func parseStartLine(line string) (*models.HTTPInfo, bool) {
+	if strings.HasPrefix(line, "HTTP/1.") {
+		version, rest, _ := strings.Cut(line, " ")
+		code, reason, _ := strings.Cut(rest, " ")
+		status, err := strconv.Atoi(code)
+		if err != nil || status < 100 || status > 999 {
+			return nil, false
+		}
+		return &models.HTTPInfo{Response: true, Version: version, StatusCode: status, Reason: clip(reason)}, true
+	}
+
+	parts := strings.Split(line, " ")
+	if len(parts) != 3 || !strings.HasPrefix(parts[2], "HTTP/1.") || !isToken(parts[0]) {
+		return nil, false
+	}
+	info := &models.HTTPInfo{Method: parts[0], Version: parts[2]}
+	target := parts[1]
+	switch {
+	case info.Method == "CONNECT":
+		// The target of a tunnel through a proxy is an authority, host:port
+		info.Host = clip(target)
+	case strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://"):
+		// Absolute form, sent to proxies
+		authority := target[strings.Index(target, "//")+2:]
+		if slash := strings.IndexByte(authority, '/'); slash >= 0 {
+			authority, target = authority[:slash], authority[slash:]
+		} else {
+			target = "/"
+		}
+		info.Host = clip(authority)
+		info.Path = clip(target)
+	default:
+		info.Path = clip(target)
+	}
+	return info, true
+}
+
+// isToken reports whether s is a plausible method: upper case letters only
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, ch := range s {
+		if ch < 'A' || ch > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// clip cuts s to maxHTTPField bytes without splitting a character
+func clip(s string) string {
+	if len(s) <= maxHTTPField {
+		return s
+	}
+	s = s[:maxHTTPField]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func TestParseHTTP(t *testing.T) {
+	request := "GET /search?q=netty HTTP/1.1\r\nHost: example.com\r\nUser-Agent: curl/8.4.0\r\nAccept: */*\r\n\r\n"
+	info, ok := ParseHTTP([]byte(request))
+	if !ok || info.Response || info.Method != "GET" || info.Path != "/search?q=netty" || info.Version != "HTTP/1.1" {
+		t.Fatalf("Expected a GET request, got %+v", info)
+	}
+	if info.Host != "example.com" || info.UserAgent != "curl/8.4.0" {
+		t.Errorf("Expected the Host and User-Agent headers, got %+v", info)
+	}
+
+	response := "HTTP/1.1 404 Not Found\r\ncontent-length: 153\r\nContent-Type: text/html\r\n\r\n<html>"
+	info, ok = ParseHTTP([]byte(response))
+	if !ok || !info.Response || info.StatusCode != 404 || info.Reason != "Not Found" || info.ContentLength != 153 {
+		t.Errorf("Expected a 404 response with its length, got %+v", info)
+	}
+
+	// Requests to a proxy carry the host in the target
+	info, ok = ParseHTTP([]byte("POST http://api.example.com:8080/v1/items HTTP/1.0\r\nContent-Length: 12\r\n\r\n"))
+	if !ok || info.Host != "api.example.com:8080" || info.Path != "/v1/items" || info.ContentLength != 12 {
+		t.Errorf("Expected the host from an absolute target, got %+v", info)
+	}
+	info, ok = ParseHTTP([]byte("CONNECT github.com:443 HTTP/1.1\r\nHost: github.com:443\r\n\r\n"))
+	if !ok || info.Host != "github.com:443" || info.Path != "" {
+		t.Errorf("Expected the tunnel's authority as host, got %+v", info)
+	}
+
+	// Headers cut short are parsed as far as they go, long values are clipped
+	info, ok = ParseHTTP([]byte("GET /" + strings.Repeat("a", 1000) + " HTTP/1.1\r\nHost: example.com\r\nUser-Ag"))
+	if !ok || info.Host != "example.com" || len(info.Path) != maxHTTPField {
+		t.Errorf("Expected a clipped path and the complete headers, got %+v", info)
+	}
+
+	for _, payload := range []string{"", "hello world", "get / HTTP/1.1\r\n", "HTTP/1.1 OK\r\n", "{\"json\": true}"} {
+		if _, ok := ParseHTTP([]byte(payload)); ok {
+			t.Errorf("Expected %q to be rejected", payload)
+		}
+	}
+}
+
+func TestHTTPDecoder_SniffsAnyPort(t *testing.T) {
+	r := DefaultRegistry()
+	event := &models.NetworkEvent{TransportProtocol: "TCP", SourcePort: 51000, DestPort: 8081}
+	r.Decode([]byte("PUT /upload HTTP/1.1\r\nHost: 10.0.0.5:8081\r\n\r\n"), event)
+	if event.AppProtocol != "HTTP" || event.HTTP == nil || event.HTTP.Method != "PUT" || event.HTTP.Host != "10.0.0.5:8081" {
+		t.Errorf("Expected a decoded PUT request, got %q %+v", event.AppProtocol, event.HTTP)
+	}
+
+	// A body segment on port 80 is labelled but has no headers to extract
+	body := &models.NetworkEvent{TransportProtocol: "TCP", SourcePort: 80, DestPort: 51000}
+	r.Decode([]byte("<html><body>"), body)
+	if body.AppProtocol != "HTTP" || body.HTTP != nil {
+		t.Errorf("Expected a labelled body segment without HTTP info, got %q %+v", body.AppProtocol, body.HTTP)
+	}
+}
@@ -8,127 +8,20 @@ import (
 const (
 	tlsHandshake      = 0x16
 	tlsClientHello    = 0x01
+	tlsServerHello    = 0x02
 	extensionSNI      = 0x0000
+	extensionALPN     = 0x0010
+	extensionVersions = 0x002b
 	sniTypeHostname   = 0x00
 )
 
 // ExtractSNI attempts to extract the Server Name Indication from TLS ClientHello
 func ExtractSNI(payload []byte) string {
-	if len(payload) < 5 {
+	info, ok := ParseTLSHello(payload)
+	if !ok {
 		return ""
 	}
-
-	// Check if this is a TLS handshake record
-	if payload[0] != tlsHandshake {
-		return ""
-	}
-
-	// Skip TLS record header (5 bytes)
-	pos := 5
-
-	if pos >= len(payload) {
-		return ""
-	}
-
-	// Check if this is a ClientHello message
-	if payload[pos] != tlsClientHello {
-		return ""
-	}
-	pos++
-
-	// Skip ClientHello length (3 bytes)
-	if pos+3 > len(payload) {
-		return ""
-	}
-	pos += 3
-
-	// Skip protocol version (2 bytes)
-	if pos+2 > len(payload) {
-		return ""
-	}
-	pos += 2
-
-	// Skip random (32 bytes)
-	if pos+32 > len(payload) {
-		return ""
-	}
-	pos += 32
-
-	// Session ID length
-	if pos >= len(payload) {
-		return ""
-	}
-	sessionIDLen := int(payload[pos])
-	pos++
-
-	// Skip session ID
-	if pos+sessionIDLen > len(payload) {
-		return ""
-	}
-	pos += sessionIDLen
-
-	// Cipher suites length
-	if pos+2 > len(payload) {
-		return ""
-	}
-	cipherSuitesLen := int(binary.BigEndian.Uint16(payload[pos:]))
-	pos += 2
-
-	// Skip cipher suites
-	if pos+cipherSuitesLen > len(payload) {
-		return ""
-	}
-	pos += cipherSuitesLen
-
-	// Compression methods length
-	if pos >= len(payload) {
-		return ""
-	}
-	compressionLen := int(payload[pos])
-	pos++
-
-	// Skip compression methods
-	if pos+compressionLen > len(payload) {
-		return ""
-	}
-	pos += compressionLen
-
-	// Extensions length
-	if pos+2 > len(payload) {
-		return ""
-	}
-	extensionsLen := int(binary.BigEndian.Uint16(payload[pos:]))
-	pos += 2
-
-	// Parse extensions
-	extensionsEnd := pos + extensionsLen
-	if extensionsEnd > len(payload) {
-		return ""
-	}
-
-	for pos < extensionsEnd {
-		if pos+4 > len(payload) {
-			break
-		}
-
-		// Extension type
-		extType := binary.BigEndian.Uint16(payload[pos:])
-		pos += 2
-
-		// Extension length
-		extLen := int(binary.BigEndian.Uint16(payload[pos:]))
-		pos += 2
-
-		if extType == extensionSNI {
-			// Found SNI extension
-			return parseSNIExtension(payload[pos:pos+extLen])
-		}
-
-		// Skip this extension
-		pos += extLen
-	}
-
-	return ""
+	return info.ServerName
 }
 
 func parseSNIExtension(data []byte) string {
@@ -141,7 +34,7 @@ func parseSNIExtension(data []byte) string {
 	pos := 2
 
 	if pos+listLen > len(data) {
-		return ""
+		listLen = len(data) - pos
 	}
 
 	// Parse SNI entries
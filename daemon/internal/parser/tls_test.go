@@ -5,18 +5,21 @@ import (
 	"testing"
 )
 
-func TestExtractSNI(t *testing.T) {
-	// Sample TLS ClientHello with SNI for github.com
-	// This is a real packet capture snippet
-	clientHelloHex := "1603010200010001fc03037e184b2f1e8f7c7a0a7f6d4e8c9a2b5f3d7e9c0a1b2c3d4e5f6a7b8c9d0e1f20e0e1e2e3e4e5e6e7e8e9eaebecedeeeff0f1f2f3f4f5f6f7f8f9fafbfcfdfe003e130213031301c02cc030009fcca9cca8ccaac02bc02f009ec024c028006bc023c0270067c00ac0140039c009c0130033009d009c003d003c0035002f00ff01000193000b000403000102000a000a0008001d00170019001800230000001600000017000000000d002a0028040305030603080708080809080a080b080408050806040105010601030303010302040205020602002b00050403040303002d00020101003300260024001d00206d0e5f7a1b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0000001000110000000a6769746875622e636f6d"
-	
-	clientHello, err := hex.DecodeString(clientHelloHex)
+// clientHelloHex is a TLS ClientHello with SNI for github.com
+const clientHelloHex = "16030101270100012303037e184b2f1e8f7c7a0a7f6d4e8c9a2b5f3d7e9c0a1b2c3d4e5f6a7b8c9d0e1f0020e0e1e2e3e4e5e6e7e8e9eaebecedeeeff0f1f2f3f4f5f6f7f8f9fafbfcfdfeff003e130213031301c02cc030009fcca9cca8ccaac02bc02f009ec024c028006bc023c0270067c00ac0140039c009c0130033009d009c003d003c0035002f00ff0100009c000b000403000102000a000a0008001d001700190018002300000016000000170000000d002a0028040305030603080708080809080a080b080408050806040105010601030303010302040205020602002b00050403040303002d00020101003300260024001d00206d0e5f7a1b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0000000f000d00000a6769746875622e636f6d"
+
+func clientHello(t *testing.T) []byte {
+	t.Helper()
+	hello, err := hex.DecodeString(clientHelloHex)
 	if err != nil {
 		t.Fatalf("Failed to decode hex: %v", err)
 	}
+	return hello
+}
 
+func TestExtractSNI(t *testing.T) {
 	// Test SNI extraction
-	sni := ExtractSNI(clientHello)
+	sni := ExtractSNI(clientHello(t))
 	expected := "github.com"
 	
 	if sni != expected {
@@ -24,6 +27,19 @@ func TestExtractSNI(t *testing.T) {
 	}
 }
 
+func TestExtractSNI_Truncated(t *testing.T) {
+	// Lengths promising more than was captured, as when the snaplen cuts a
+	// ClientHello short, still give up the SNI if it made it in
+	hello := clientHello(t)
+	hello[len(hello)-157]++ // Extension list length
+	hello[len(hello)-16]++ // SNI extension length
+	hello[len(hello)-14]++ // Server name list length
+
+	if sni := ExtractSNI(hello); sni != "github.com" {
+		t.Errorf("Expected SNI 'github.com' from a truncated ClientHello, got '%s'", sni)
+	}
+}
+
 func TestExtractSNI_NoSNI(t *testing.T) {
 	// TLS packet without SNI extension
 	noSNI := []byte{0x16, 0x03, 0x01, 0x00, 0x05, 0x01, 0x00, 0x00, 0x01, 0x03}
@@ -42,4 +58,71 @@ func TestExtractSNI_InvalidPacket(t *testing.T) {
 	if sni != "" {
 		t.Errorf("Expected empty SNI for non-handshake packet, got '%s'", sni)
 	}
-}
\ No newline at end of file
+}
+
+// serverHello builds a ServerHello record selecting suite, with the given extensions
+func serverHello(legacyVersion, suite uint16, extensions []byte) []byte {
+	body := []byte{byte(legacyVersion >> 8), byte(legacyVersion)}
+	body = append(body, make([]byte, 32)...) // Random
+	body = append(body, 0)                   // Session ID
+	body = append(body, byte(suite>>8), byte(suite), 0)
+	body = append(body, byte(len(extensions)>>8), byte(len(extensions)))
+	body = append(body, extensions...)
+	message := append([]byte{tlsServerHello, 0, byte(len(body) >> 8), byte(len(body))}, body...)
+	return append([]byte{tlsHandshake, 0x03, 0x03, byte(len(message) >> 8), byte(len(message))}, message...)
+}
+
+func TestParseTLSHello_ClientHello(t *testing.T) {
+	info, ok := ParseTLSHello(clientHello(t))
+	if !ok {
+		t.Fatal("Expected the ClientHello to parse")
+	}
+	// The legacy version says 1.2, supported_versions offers 1.3
+	if info.Handshake != "client_hello" || info.Version != "TLS 1.3" || info.ServerName != "github.com" {
+		t.Errorf("Unexpected hello: %+v", info)
+	}
+	if len(info.CipherSuites) != 31 || info.CipherSuites[0] != "TLS_AES_256_GCM_SHA384" || info.CipherSuites[30] != "TLS_EMPTY_RENEGOTIATION_INFO_SCSV" {
+		t.Errorf("Unexpected offered cipher suites: %v", info.CipherSuites)
+	}
+}
+
+func TestParseTLSHello_ServerHello(t *testing.T) {
+	// ALPN selecting h2
+	alpn := []byte{0x00, 0x10, 0x00, 0x05, 0x00, 0x03, 0x02, 'h', '2'}
+
+	// TLS 1.2 with ALPN
+	info, ok := ParseTLSHello(serverHello(0x0303, 0xc02f, alpn))
+	if !ok {
+		t.Fatal("Expected the ServerHello to parse")
+	}
+	if info.Handshake != "server_hello" || info.Version != "TLS 1.2" || info.CipherSuite != "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256" {
+		t.Errorf("Unexpected hello: %+v", info)
+	}
+	if len(info.ALPN) != 1 || info.ALPN[0] != "h2" {
+		t.Errorf("Expected ALPN h2, got %v", info.ALPN)
+	}
+
+	// TLS 1.3 negotiated in supported_versions
+	versions := []byte{0x00, 0x2b, 0x00, 0x02, 0x03, 0x04}
+	if info, _ := ParseTLSHello(serverHello(0x0303, 0x1301, versions)); info.Version != "TLS 1.3" || info.CipherSuite != "TLS_AES_128_GCM_SHA256" {
+		t.Errorf("Expected TLS 1.3 from supported_versions, got %+v", info)
+	}
+
+	// A TLS 1.0 server without extensions and a suite without a name
+	if info, _ := ParseTLSHello(serverHello(0x0301, 0x0a0b, nil)); info.Version != "TLS 1.0" || info.CipherSuite != "0x0a0b" {
+		t.Errorf("Expected a legacy version and a numbered suite, got %+v", info)
+	}
+}
+
+func TestIsGREASE(t *testing.T) {
+	for _, value := range []uint16{0x0a0a, 0x1a1a, 0xfafa} {
+		if !isGREASE(value) {
+			t.Errorf("Expected 0x%04x to be GREASE", value)
+		}
+	}
+	for _, value := range []uint16{0x0a1a, 0x1301, 0x0303} {
+		if isGREASE(value) {
+			t.Errorf("Expected 0x%04x not to be GREASE", value)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package parser
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// tlsVersions names the protocol versions in hellos
+var tlsVersions = map[uint16]string{
+	0x0300: "SSL 3.0",
+	0x0301: "TLS 1.0",
+	0x0302: "TLS 1.1",
+	0x0303: "TLS 1.2",
+	0x0304: "TLS 1.3",
+}
+
+// cipherSuites names the common cipher suites by their IANA names, others
+// are reported by number
+var cipherSuites = map[uint16]string{
+	0x0004: "TLS_RSA_WITH_RC4_128_MD5",
+	0x0005: "TLS_RSA_WITH_RC4_128_SHA",
+	0x000a: "TLS_RSA_WITH_3DES_EDE_CBC_SHA",
+	0x002f: "TLS_RSA_WITH_AES_128_CBC_SHA",
+	0x0033: "TLS_DHE_RSA_WITH_AES_128_CBC_SHA",
+	0x0035: "TLS_RSA_WITH_AES_256_CBC_SHA",
+	0x0039: "TLS_DHE_RSA_WITH_AES_256_CBC_SHA",
+	0x003c: "TLS_RSA_WITH_AES_128_CBC_SHA256",
+	0x003d: "TLS_RSA_WITH_AES_256_CBC_SHA256",
+	0x0067: "TLS_DHE_RSA_WITH_AES_128_CBC_SHA256",
+	0x006b: "TLS_DHE_RSA_WITH_AES_256_CBC_SHA256",
+	0x009c: "TLS_RSA_WITH_AES_128_GCM_SHA256",
+	0x009d: "TLS_RSA_WITH_AES_256_GCM_SHA384",
+	0x009e: "TLS_DHE_RSA_WITH_AES_128_GCM_SHA256",
+	0x009f: "TLS_DHE_RSA_WITH_AES_256_GCM_SHA384",
+	0x00ff: "TLS_EMPTY_RENEGOTIATION_INFO_SCSV",
+	0x1301: "TLS_AES_128_GCM_SHA256",
+	0x1302: "TLS_AES_256_GCM_SHA384",
+	0x1303: "TLS_CHACHA20_POLY1305_SHA256",
+	0x5600: "TLS_FALLBACK_SCSV",
+	0xc009: "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA",
+	0xc00a: "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA",
+	0xc013: "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA",
+	0xc014: "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA",
+	0xc023: "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256",
+	0xc024: "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA384",
+	0xc027: "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256",
+	0xc028: "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA384",
+	0xc02b: "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+	0xc02c: "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+	0xc02f: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+	0xc030: "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+	0xcca8: "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256",
+	0xcca9: "TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256",
+	0xccaa: "TLS_DHE_RSA_WITH_CHACHA20_POLY1305_SHA256",
+}
+
+// maxALPN bounds the protocols recorded from one hello
+const maxALPN = 16
+
+// ParseTLSHello extracts what a ClientHello offers or a ServerHello selects:
+// the protocol version, cipher suites and ALPN protocols, and the SNI.
+// Lengths running past the payload, as when the snaplen or the reassembly
+// limit cuts a hello short, are clamped so the extensions that made it in
+// are still read.
+func ParseTLSHello(payload []byte) (*models.TLSInfo, bool) {
+	if len(payload) < 6 || payload[0] != tlsHandshake {
+		return nil, false
+	}
+	info := &models.TLSInfo{}
+	switch payload[5] {
+	case tlsClientHello:
+		info.Handshake = models.TLSClientHello
+	case tlsServerHello:
+		info.Handshake = models.TLSServerHello
+	default:
+		return nil, false
+	}
+
+	// Skip the record header (5 bytes) and the handshake type and length (4
+	// bytes), then read the legacy version and skip the random
+	r := helloReader{data: payload, pos: 9}
+	legacyVersion, ok := r.uint16()
+	if !ok || !r.skip(32) {
+		return nil, false
+	}
+	sessionIDLen, ok := r.uint8()
+	if !ok || !r.skip(sessionIDLen) {
+		return nil, false
+	}
+	if info.Handshake == models.TLSClientHello {
+		suitesLen, ok := r.uint16()
+		suites, ok2 := r.bytes(suitesLen)
+		if !ok || !ok2 {
+			return nil, false
+		}
+		for i := 0; i+1 < len(suites); i += 2 {
+			if suite := binary.BigEndian.Uint16(suites[i:]); !isGREASE(suite) {
+				info.CipherSuites = append(info.CipherSuites, cipherSuiteName(suite))
+			}
+		}
+		compressionLen, ok := r.uint8()
+		if !ok || !r.skip(compressionLen) {
+			return nil, false
+		}
+	} else {
+		// The selected cipher suite and compression method
+		suite, ok := r.uint16()
+		if !ok || !r.skip(1) {
+			return nil, false
+		}
+		info.CipherSuite = cipherSuiteName(uint16(suite))
+	}
+	info.Version = tlsVersionName(uint16(legacyVersion))
+
+	// Extensions, absent from the oldest hellos
+	extensionsLen, ok := r.uint16()
+	if !ok {
+		return info, true
+	}
+	extensions := r.clamped(extensionsLen)
+	for len(extensions) >= 4 {
+		extType := binary.BigEndian.Uint16(extensions)
+		extLen := int(binary.BigEndian.Uint16(extensions[2:]))
+		extensions = extensions[4:]
+		if extLen > len(extensions) {
+			extLen = len(extensions)
+		}
+		data := extensions[:extLen]
+		extensions = extensions[extLen:]
+
+		switch extType {
+		case extensionSNI:
+			info.ServerName = parseSNIExtension(data)
+		case extensionALPN:
+			info.ALPN = parseALPNExtension(data)
+		case extensionVersions:
+			// TLS 1.3 negotiates its version here, the legacy field stays at 1.2
+			if version, ok := supportedVersion(data, info.Handshake == models.TLSClientHello); ok {
+				info.Version = tlsVersionName(version)
+			}
+		}
+	}
+	return info, true
+}
+
+// parseALPNExtension returns the protocols of an ALPN extension
+func parseALPNExtension(data []byte) []string {
+	if len(data) < 2 {
+		return nil
+	}
+	listLen := int(binary.BigEndian.Uint16(data))
+	list := data[2:]
+	if listLen < len(list) {
+		list = list[:listLen]
+	}
+	var protocols []string
+	for len(list) > 0 && len(protocols) < maxALPN {
+		n := int(list[0])
+		if n == 0 || 1+n > len(list) {
+			break
+		}
+		protocols = append(protocols, string(list[1:1+n]))
+		list = list[1+n:]
+	}
+	return protocols
+}
+
+// supportedVersion returns the highest version a ClientHello's
+// supported_versions extension lists, or the one a ServerHello's selects
+func supportedVersion(data []byte, client bool) (uint16, bool) {
+	if !client {
+		if len(data) < 2 {
+			return 0, false
+		}
+		return binary.BigEndian.Uint16(data), true
+	}
+	if len(data) < 1 {
+		return 0, false
+	}
+	list := data[1:]
+	if n := int(data[0]); n < len(list) {
+		list = list[:n]
+	}
+	var highest uint16
+	for i := 0; i+1 < len(list); i += 2 {
+		if version := binary.BigEndian.Uint16(list[i:]); !isGREASE(version) && version > highest {
+			highest = version
+		}
+	}
+	return highest, highest != 0
+}
+
+// isGREASE reports whether a cipher suite or version is a GREASE value
+// (RFC 8701), sent by clients to keep servers tolerant of unknown values
+func isGREASE(value uint16) bool {
+	return value&0x0f0f == 0x0a0a && value>>8 == value&0xff
+}
+
+func tlsVersionName(version uint16) string {
+	if name, ok := tlsVersions[version]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
+
+func cipherSuiteName(suite uint16) string {
+	if name, ok := cipherSuites[suite]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04x", suite)
+}
+
+// helloReader reads the fixed fields of a hello, failing once they run out
+type helloReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *helloReader) uint8() (int, bool) {
+	if r.pos+1 > len(r.data) {
+		return 0, false
+	}
+	r.pos++
+	return int(r.data[r.pos-1]), true
+}
+
+func (r *helloReader) uint16() (int, bool) {
+	if r.pos+2 > len(r.data) {
+		return 0, false
+	}
+	r.pos += 2
+	return int(binary.BigEndian.Uint16(r.data[r.pos-2:])), true
+}
+
+func (r *helloReader) skip(n int) bool {
+	_, ok := r.bytes(n)
+	return ok
+}
+
+func (r *helloReader) bytes(n int) ([]byte, bool) {
+	if r.pos+n > len(r.data) {
+		return nil, false
+	}
+	r.pos += n
+	return r.data[r.pos-n : r.pos], true
+}
+
+// clamped returns the next n bytes, or as many as there are
+func (r *helloReader) clamped(n int) []byte {
+	end := r.pos + n
+	if end > len(r.data) {
+		end = len(r.data)
+	}
+	data := r.data[r.pos:end]
+	r.pos = end
+	return data
+}
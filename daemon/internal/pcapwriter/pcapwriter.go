@@ -0,0 +1,242 @@
+package pcapwriter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/iolloyd/netty/daemon/internal/clock"
+)
+
+// flushInterval bounds how long written packets sit in the buffer, so the
+// current file can be opened in Wireshark while the capture runs
+const flushInterval = time.Second
+
+// Per-file and per-packet record header sizes of the pcap format
+const (
+	fileHeaderSize   = 24
+	packetHeaderSize = 16
+)
+
+// Config describes where captured packets are written and when files rotate
+type Config struct {
+	Path           string        // File to write, rotated files are named after it
+	RotateSize     int64         // Start a new file before this many bytes are exceeded (0 disables)
+	RotateInterval time.Duration // Start a new file after this long (0 disables)
+}
+
+// Rotating returns true if a size or interval limit is configured
+func (c Config) Rotating() bool {
+	return c.RotateSize > 0 || c.RotateInterval > 0
+}
+
+// Pattern matches the files written for the config, relative to its directory
+func (c Config) Pattern() string {
+	if !c.Rotating() {
+		return filepath.Base(c.Path)
+	}
+	base, ext := splitExt(c.Path)
+	return filepath.Base(base) + "-*" + ext
+}
+
+// Writer writes packets to a pcap file, starting a new file when the current
+// one reaches the size or age limit. Without limits it writes to Path itself,
+// otherwise each file is named <name>-<first packet time><ext>.
+type Writer struct {
+	config   Config
+	linkType layers.LinkType
+	snaplen  uint32
+
+	file    *os.File
+	buf     *bufio.Writer
+	pcap    *pcapgo.Writer
+	path    string
+	opened  time.Time // Capture time of the file's first packet
+	size    int64
+	flushed time.Time
+	files   uint64
+	packets uint64
+	bytes   uint64
+	errors  uint64
+	lastErr string
+	closed  bool
+	mu      sync.Mutex
+}
+
+// NewWriter creates a writer for packets of the given link type. No file is
+// created until the first packet arrives.
+func NewWriter(config Config, linkType layers.LinkType, snaplen int) (*Writer, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("no pcap output path given")
+	}
+	if config.RotateSize < 0 || config.RotateInterval < 0 {
+		return nil, fmt.Errorf("rotation limits must not be negative")
+	}
+	if config.RotateSize > 0 && config.RotateSize <= fileHeaderSize+packetHeaderSize {
+		return nil, fmt.Errorf("rotate size %d is too small for a pcap file", config.RotateSize)
+	}
+	if err := os.MkdirAll(filepath.Dir(config.Path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create pcap output directory: %w", err)
+	}
+	return &Writer{
+		config:   config,
+		linkType: linkType,
+		snaplen:  uint32(snaplen),
+	}, nil
+}
+
+// WritePacket appends a packet to the current file, rotating first if the
+// packet would take the file past a limit. Packets written after Close are dropped.
+func (w *Writer) WritePacket(info gopacket.CaptureInfo, data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+
+	record := int64(packetHeaderSize + len(data))
+	if w.file != nil && w.due(info.Timestamp, record) {
+		if err := w.closeFile(); err != nil {
+			return w.fail(err)
+		}
+	}
+	if w.file == nil {
+		if err := w.openFile(info.Timestamp); err != nil {
+			return w.fail(err)
+		}
+	}
+
+	if err := w.pcap.WritePacket(info, data); err != nil {
+		return w.fail(fmt.Errorf("failed to write packet to %s: %w", w.path, err))
+	}
+	w.size += record
+	w.packets++
+	w.bytes += uint64(len(data))
+
+	if time.Since(w.flushed) >= flushInterval {
+		if err := w.buf.Flush(); err != nil {
+			return w.fail(fmt.Errorf("failed to write packet to %s: %w", w.path, err))
+		}
+		w.flushed = time.Now()
+	}
+	return nil
+}
+
+// due reports whether the current file must be rotated before a record is written
+func (w *Writer) due(at time.Time, record int64) bool {
+	if w.config.RotateInterval > 0 && at.Sub(w.opened) >= w.config.RotateInterval {
+		return true
+	}
+	// A file always takes at least one packet, however large
+	return w.config.RotateSize > 0 && w.size > fileHeaderSize && w.size+record > w.config.RotateSize
+}
+
+// openFile starts a new file, must be called with the lock held
+func (w *Writer) openFile(at time.Time) error {
+	path := w.config.Path
+	if w.config.Rotating() {
+		path = w.rotatedPath(at)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create pcap file: %w", err)
+	}
+	buf := bufio.NewWriterSize(file, 64<<10)
+	writer := pcapgo.NewWriter(buf)
+	if err := writer.WriteFileHeader(w.snaplen, w.linkType); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write pcap header to %s: %w", path, err)
+	}
+
+	w.file, w.buf, w.pcap, w.path = file, buf, writer, path
+	w.opened = at
+	w.size = fileHeaderSize
+	w.flushed = time.Now()
+	w.files++
+	return nil
+}
+
+// rotatedPath names a file after the capture time of its first packet, adding
+// a counter when several files start within the same second. Names use the
+// -tz zone and sort in the order the files were written.
+func (w *Writer) rotatedPath(at time.Time) string {
+	base, ext := splitExt(w.config.Path)
+	name := base + "-" + clock.In(at).Format("20060102-150405")
+	path := name + ext
+	for n := 1; ; n++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = fmt.Sprintf("%s_%03d%s", name, n, ext)
+	}
+}
+
+// closeFile flushes and closes the current file, must be called with the lock held
+func (w *Writer) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	file, path := w.file, w.path
+	flushErr := w.buf.Flush()
+	w.file, w.buf, w.pcap = nil, nil, nil
+	if err := file.Close(); err != nil && flushErr == nil {
+		flushErr = err
+	}
+	if flushErr != nil {
+		return fmt.Errorf("failed to finish pcap file %s: %w", path, flushErr)
+	}
+	return nil
+}
+
+// fail records a write error, must be called with the lock held
+func (w *Writer) fail(err error) error {
+	w.errors++
+	w.lastErr = err.Error()
+	return err
+}
+
+// Close flushes and closes the current file
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return w.closeFile()
+}
+
+// GetStats returns what has been written and where
+func (w *Writer) GetStats() map[string]interface{} {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	stats := map[string]interface{}{
+		"path":            w.config.Path,
+		"current_file":    w.path,
+		"files":           w.files,
+		"packets_written": w.packets,
+		"bytes_written":   w.bytes,
+		"write_errors":    w.errors,
+		"rotate_size":     w.config.RotateSize,
+		"rotate_interval": w.config.RotateInterval.String(),
+	}
+	if w.lastErr != "" {
+		stats["last_error"] = w.lastErr
+	}
+	return stats
+}
+
+// splitExt splits a path into everything before its extension and the
+// extension, defaulting to .pcap when there is none
+func splitExt(path string) (string, string) {
+	ext := filepath.Ext(path)
+	if ext == "" {
+		return path, ".pcap"
+	}
+	return strings.TrimSuffix(path, ext), ext
+}
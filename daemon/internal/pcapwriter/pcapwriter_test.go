@@ -0,0 +1,135 @@
+package pcapwriter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+)
+
+func packetAt(at time.Time, size int) (gopacket.CaptureInfo, []byte) {
+	return gopacket.CaptureInfo{Timestamp: at, CaptureLength: size, Length: size}, make([]byte, size)
+}
+
+// readPackets returns how many packets a pcap file holds
+func readPackets(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer f.Close()
+	r, err := pcapgo.NewReader(f)
+	if err != nil {
+		t.Fatalf("Expected a valid pcap file %s: %v", path, err)
+	}
+	count := 0
+	for {
+		if _, _, err := r.ReadPacketData(); err != nil {
+			return count
+		}
+		count++
+	}
+}
+
+func TestWriter_SingleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.pcap")
+	w, err := NewWriter(Config{Path: path}, layers.LinkTypeEthernet, 65536)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := w.WritePacket(packetAt(start.Add(time.Duration(i)*time.Hour), 100)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := w.WritePacket(packetAt(start, 100)); err != nil {
+		t.Errorf("Expected packets after Close to be dropped quietly, got %v", err)
+	}
+	if n := readPackets(t, path); n != 3 {
+		t.Errorf("Expected 3 packets in %s, got %d", path, n)
+	}
+}
+
+func TestWriter_RotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+	config := Config{Path: filepath.Join(dir, "capture.pcap"), RotateSize: fileHeaderSize + 2*(packetHeaderSize+100)}
+	w, err := NewWriter(config, layers.LinkTypeEthernet, 65536)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := w.WritePacket(packetAt(start, 100)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	// An oversized packet still gets written, in a file of its own
+	if err := w.WritePacket(packetAt(start, 1000)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	w.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, config.Pattern()))
+	if len(files) != 4 {
+		t.Fatalf("Expected 4 files, got %v", files)
+	}
+	if n := readPackets(t, files[3]); n != 1 {
+		t.Errorf("Expected the last file to hold only the oversized packet, got %d packets", n)
+	}
+	total := 0
+	for _, file := range files {
+		info, _ := os.Stat(file)
+		if n := readPackets(t, file); n < 1 || (n > 1 && info.Size() > config.RotateSize) {
+			t.Errorf("File %s with %d packets is %d bytes, over the limit", file, n, info.Size())
+		}
+		total += readPackets(t, file)
+	}
+	if total != 6 {
+		t.Errorf("Expected all 6 packets written, got %d", total)
+	}
+	if stats := w.GetStats(); stats["files"] != uint64(4) || stats["packets_written"] != uint64(6) {
+		t.Errorf("Unexpected stats: %v", stats)
+	}
+}
+
+func TestWriter_RotatesByInterval(t *testing.T) {
+	dir := t.TempDir()
+	config := Config{Path: filepath.Join(dir, "capture"), RotateInterval: time.Minute}
+	w, err := NewWriter(config, layers.LinkTypeEthernet, 65536)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	start := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	for _, offset := range []time.Duration{0, 30 * time.Second, 61 * time.Second, 90 * time.Second, 5 * time.Minute} {
+		if err := w.WritePacket(packetAt(start.Add(offset), 60)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	w.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "capture-*.pcap"))
+	if len(files) != 3 {
+		t.Fatalf("Expected 3 files, got %v", files)
+	}
+	if n := readPackets(t, files[0]); n != 2 {
+		t.Errorf("Expected the first minute's 2 packets in %s, got %d", files[0], n)
+	}
+}
+
+func TestNewWriter_Validates(t *testing.T) {
+	if _, err := NewWriter(Config{}, layers.LinkTypeEthernet, 65536); err == nil {
+		t.Error("Expected an error without a path")
+	}
+	if _, err := NewWriter(Config{Path: filepath.Join(t.TempDir(), "x.pcap"), RotateSize: 10}, layers.LinkTypeEthernet, 65536); err == nil {
+		t.Error("Expected an error for a rotate size smaller than a packet")
+	}
+}
@@ -0,0 +1,183 @@
+package process
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// MinRefresh bounds how often a lookup that misses rescans /proc
+const MinRefresh = time.Second
+
+// socketTables are the /proc/net files listing sockets, by transport protocol
+var socketTables = map[string][]string{
+	"TCP": {"tcp", "tcp6"},
+	"UDP": {"udp", "udp6"},
+}
+
+type socketKey struct {
+	protocol string
+	port     int
+}
+
+// Table attributes local ports to the programs owning their sockets by
+// matching the socket inodes in /proc/net against each process's open file
+// descriptors. Only Linux has /proc; elsewhere lookups never succeed.
+type Table struct {
+	procRoot  string
+	sockets   map[socketKey]models.ProcessInfo
+	refreshed time.Time
+	refreshes uint64
+	hits      uint64
+	misses    uint64
+	mu        sync.Mutex
+}
+
+// Supported reports whether process attribution works on this platform
+func Supported() bool {
+	return runtime.GOOS == "linux"
+}
+
+// NewTable creates a table reading the host's /proc
+func NewTable() *Table {
+	return newTable("/proc")
+}
+
+func newTable(procRoot string) *Table {
+	return &Table{
+		procRoot: procRoot,
+		sockets:  make(map[socketKey]models.ProcessInfo),
+	}
+}
+
+// Lookup returns the program owning the local socket bound to port. On a miss
+// /proc is rescanned, at most once per MinRefresh, as the socket may be new.
+func (t *Table) Lookup(protocol string, port int) (models.ProcessInfo, bool) {
+	key := socketKey{protocol: protocol, port: port}
+	if _, ok := socketTables[protocol]; !ok || port <= 0 {
+		return models.ProcessInfo{}, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if info, ok := t.sockets[key]; ok {
+		t.hits++
+		return info, true
+	}
+	if time.Since(t.refreshed) >= MinRefresh {
+		t.refresh()
+		if info, ok := t.sockets[key]; ok {
+			t.hits++
+			return info, true
+		}
+	}
+	t.misses++
+	return models.ProcessInfo{}, false
+}
+
+// GetStats returns attribution metrics
+func (t *Table) GetStats() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return map[string]interface{}{
+		"sockets":   len(t.sockets),
+		"refreshes": t.refreshes,
+		"hits":      t.hits,
+		"misses":    t.misses,
+	}
+}
+
+// refresh rebuilds the port to process map, must be called with the lock held
+func (t *Table) refresh() {
+	t.refreshed = time.Now()
+	t.refreshes++
+
+	inodes := make(map[string]socketKey)
+	for protocol, files := range socketTables {
+		for _, file := range files {
+			t.readSockets(filepath.Join(t.procRoot, "net", file), protocol, inodes)
+		}
+	}
+
+	sockets := make(map[socketKey]models.ProcessInfo)
+	entries, err := os.ReadDir(t.procRoot)
+	if err != nil {
+		t.sockets = sockets
+		return
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join(t.procRoot, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			// Gone already, or not ours to look at
+			continue
+		}
+		var name string
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			key, ok := inodes[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")]
+			if !ok {
+				continue
+			}
+			if _, taken := sockets[key]; taken {
+				continue
+			}
+			if name == "" {
+				name = t.commandName(entry.Name())
+			}
+			sockets[key] = models.ProcessInfo{PID: pid, Name: name}
+		}
+	}
+	t.sockets = sockets
+}
+
+// readSockets adds the inode and local port of each socket in a /proc/net table
+func (t *Table) readSockets(path, protocol string, inodes map[string]socketKey) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // Header
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[9] == "0" {
+			continue
+		}
+		colon := strings.LastIndexByte(fields[1], ':')
+		if colon < 0 {
+			continue
+		}
+		port, err := strconv.ParseUint(fields[1][colon+1:], 16, 16)
+		if err != nil || port == 0 {
+			continue
+		}
+		inodes[fields[9]] = socketKey{protocol: protocol, port: int(port)}
+	}
+}
+
+// commandName returns the short name of a process, or its PID if unreadable
+func (t *Table) commandName(pid string) string {
+	comm, err := os.ReadFile(filepath.Join(t.procRoot, pid, "comm"))
+	if err != nil || len(strings.TrimSpace(string(comm))) == 0 {
+		return "pid " + pid
+	}
+	return strings.TrimSpace(string(comm))
+}
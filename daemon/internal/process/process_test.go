@@ -0,0 +1,67 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const tcpHeader = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+
+// fakeProc lays out a /proc with one TCP socket on port 443 (0x01BB) held by
+// pid 4242 and one UDP socket on port 53 (0x0035) held by no process
+func fakeProc(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"net/tcp":   tcpHeader + "   0: 0A01A8C0:01BB 0300710C:C350 01 00000000:00000000 00:00000000 00000000  1000        0 9001 1 0000000000000000 20 4 30 10 -1\n",
+		"net/tcp6":  tcpHeader,
+		"net/udp":   tcpHeader + "   1: 00000000:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 9002 2 0000000000000000 0\n",
+		"net/udp6":  tcpHeader,
+		"4242/comm": "curl\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, "4242", "fd"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for fd, target := range map[string]string{"0": "/dev/null", "3": "socket:[9001]", "4": "pipe:[77]"} {
+		if err := os.Symlink(target, filepath.Join(root, "4242", "fd", fd)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestTable_Lookup(t *testing.T) {
+	table := newTable(fakeProc(t))
+
+	info, ok := table.Lookup("TCP", 443)
+	if !ok || info.PID != 4242 || info.Name != "curl" {
+		t.Fatalf("Expected port 443 to belong to curl (4242), got %+v %v", info, ok)
+	}
+
+	// The socket exists but no process holds it
+	if _, ok := table.Lookup("UDP", 53); ok {
+		t.Error("Expected an unowned socket not to be attributed")
+	}
+	// Same port, other transport
+	if _, ok := table.Lookup("UDP", 443); ok {
+		t.Error("Expected a UDP lookup not to match a TCP socket")
+	}
+	if _, ok := table.Lookup("ICMP", 443); ok {
+		t.Error("Expected protocols without ports not to be attributed")
+	}
+
+	stats := table.GetStats()
+	if stats["refreshes"] != uint64(1) || stats["hits"] != uint64(1) {
+		t.Errorf("Expected one refresh and one hit, got %v", stats)
+	}
+}
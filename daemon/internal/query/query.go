@@ -0,0 +1,79 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultLimit caps the rows returned when a request doesn't set a limit
+	DefaultLimit = 1000
+	// MaxLimit is the most rows a single query may return
+	MaxLimit = 10000
+	// Timeout bounds how long a single query may run
+	Timeout = 10 * time.Second
+)
+
+// Result holds the rows returned by a query
+type Result struct {
+	Columns   []string        `json:"columns"`
+	Rows      [][]interface{} `json:"rows"`
+	Truncated bool            `json:"truncated"` // More rows matched than the limit allowed
+}
+
+// Queryer runs read-only SQL against stored history. Implementations must
+// open their database read-only as well; Validate is a first line of defense.
+type Queryer interface {
+	Query(ctx context.Context, sql string, limit int) (Result, error)
+}
+
+// forbidden lists keywords that can write, change settings or reach outside the store
+var forbidden = []string{
+	"INSERT", "UPDATE", "DELETE", "UPSERT", "MERGE",
+	"CREATE", "DROP", "ALTER", "TRUNCATE", "VACUUM", "REINDEX", "ANALYZE",
+	"ATTACH", "DETACH", "PRAGMA", "BEGIN", "COMMIT", "ROLLBACK", "SAVEPOINT", "RELEASE",
+	"LOAD_EXTENSION", "COPY", "INTO",
+}
+
+// Validate checks that sql is a single read-only SELECT statement
+func Validate(sql string) error {
+	stmt := strings.TrimSpace(sql)
+	stmt = strings.TrimSuffix(stmt, ";")
+	if stmt == "" {
+		return fmt.Errorf("query is empty")
+	}
+	if strings.Contains(stmt, ";") {
+		return fmt.Errorf("only a single statement is allowed")
+	}
+	if strings.Contains(stmt, "--") || strings.Contains(stmt, "/*") {
+		return fmt.Errorf("comments are not allowed")
+	}
+
+	words := strings.FieldsFunc(strings.ToUpper(stmt), func(r rune) bool {
+		return !(r == '_' || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'))
+	})
+	if len(words) == 0 || (words[0] != "SELECT" && words[0] != "WITH") {
+		return fmt.Errorf("only SELECT queries are allowed")
+	}
+	for _, word := range words {
+		for _, keyword := range forbidden {
+			if word == keyword {
+				return fmt.Errorf("%s is not allowed", keyword)
+			}
+		}
+	}
+	return nil
+}
+
+// ClampLimit returns the row limit to use for a requested limit
+func ClampLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultLimit
+	}
+	if limit > MaxLimit {
+		return MaxLimit
+	}
+	return limit
+}
@@ -0,0 +1,38 @@
+package query
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	allowed := []string{
+		"SELECT * FROM events",
+		"select remote_ip, sum(bytes_in) from conversations group by remote_ip;",
+		"WITH t AS (SELECT * FROM events) SELECT count(*) FROM t",
+	}
+	for _, sql := range allowed {
+		if err := Validate(sql); err != nil {
+			t.Errorf("Expected %q to be allowed, got %v", sql, err)
+		}
+	}
+
+	rejected := []string{
+		"",
+		"DELETE FROM events",
+		"SELECT 1; DROP TABLE events",
+		"PRAGMA table_info(events)",
+		"SELECT * FROM events -- comment",
+		"WITH t AS (SELECT 1) INSERT INTO events SELECT * FROM t",
+		"ATTACH DATABASE '/tmp/x.db' AS x",
+		"SELECT load_extension('evil')",
+	}
+	for _, sql := range rejected {
+		if err := Validate(sql); err == nil {
+			t.Errorf("Expected %q to be rejected", sql)
+		}
+	}
+}
+
+func TestClampLimit(t *testing.T) {
+	if ClampLimit(0) != DefaultLimit || ClampLimit(50) != 50 || ClampLimit(MaxLimit+1) != MaxLimit {
+		t.Error("Unexpected limit clamping")
+	}
+}
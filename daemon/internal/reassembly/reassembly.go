@@ -0,0 +1,176 @@
+package reassembly
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limits on what the assembler holds
+const (
+	MaxStreams  = 10000            // Further messages are parsed a segment at a time until some finish
+	MaxBuffered = 16 * 1024        // Bytes held per stream, a longer message is parsed as far as it got
+	MaxPending  = 8                // Out of order segments held per stream
+	IdleTimeout = 30 * time.Second // Streams without a segment for this long are forgotten
+)
+
+// Assembler puts TCP segments back together for the application protocol
+// parsers. A segment starting a message that doesn't fit in it, such as a
+// ClientHello spanning two packets, is held and the following segments are
+// appended in sequence order until the message is complete. Streams are only
+// tracked while a message is held, so most traffic costs a single check.
+type Assembler struct {
+	streams     map[string]*stream // By direction, see Key
+	lastSweep   time.Time
+	reassembled uint64 // Messages put together from more than one segment
+	outOfOrder  uint64 // Segments held until the gap before them was filled
+	truncated   uint64 // Messages handed on incomplete: too long, too many gaps or idle
+	mu          sync.Mutex
+}
+
+// stream is the part of a message seen so far in one direction of a connection
+type stream struct {
+	next     uint32 // Sequence number of the next byte expected
+	buffer   []byte
+	pending  []segment // Out of order segments, oldest first
+	lastSeen time.Time
+}
+
+type segment struct {
+	seq     uint32
+	payload []byte
+}
+
+// NewAssembler creates an assembler holding no streams
+func NewAssembler() *Assembler {
+	return &Assembler{streams: make(map[string]*stream)}
+}
+
+// Key identifies one direction of a TCP connection
+func Key(srcIP string, srcPort int, dstIP string, dstPort int) string {
+	return srcIP + ":" + strconv.Itoa(srcPort) + ">" + dstIP + ":" + strconv.Itoa(dstPort)
+}
+
+// Add takes a segment's payload and returns the bytes to parse: the message
+// held for the stream with the segment appended, or just the segment when
+// nothing is held. complete reports whether the bytes hold a whole message;
+// until they do, or MaxBuffered is reached, the stream keeps holding them.
+func (a *Assembler) Add(key string, seq uint32, payload []byte, at time.Time, complete func([]byte) bool) []byte {
+	if len(payload) == 0 {
+		return payload
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sweep(at)
+
+	s, held := a.streams[key]
+	if !held {
+		if complete(payload) || len(a.streams) >= MaxStreams {
+			return payload
+		}
+		a.streams[key] = &stream{
+			next:     seq + uint32(len(payload)),
+			buffer:   append(make([]byte, 0, 2*len(payload)), payload...),
+			lastSeen: at,
+		}
+		return payload
+	}
+	s.lastSeen = at
+
+	offset := int32(seq - s.next)
+	if offset > 0 {
+		// A segment went missing or is late, hold this one until it turns up
+		if len(s.pending) < MaxPending {
+			s.pending = append(s.pending, segment{seq: seq, payload: append([]byte(nil), payload...)})
+			a.outOfOrder++
+			return payload
+		}
+		delete(a.streams, key)
+		a.truncated++
+		return payload
+	}
+	if !s.append(seq, payload) {
+		// A retransmission of bytes already held
+		return payload
+	}
+	s.drain()
+
+	data := s.buffer
+	switch {
+	case complete(data):
+		a.reassembled++
+		delete(a.streams, key)
+	case len(data) >= MaxBuffered:
+		a.truncated++
+		delete(a.streams, key)
+	}
+	return data
+}
+
+// Close forgets what is held for the given directions, for FIN and RST segments
+func (a *Assembler) Close(keys ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, key := range keys {
+		if _, held := a.streams[key]; held {
+			delete(a.streams, key)
+			a.truncated++
+		}
+	}
+}
+
+// GetStats returns assembler metrics
+func (a *Assembler) GetStats() map[string]interface{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return map[string]interface{}{
+		"streams":      len(a.streams),
+		"reassembled":  a.reassembled,
+		"out_of_order": a.outOfOrder,
+		"truncated":    a.truncated,
+	}
+}
+
+// sweep forgets idle streams at most once per IdleTimeout, must be called
+// with the lock held. Time is packet time, so replays expire streams too.
+func (a *Assembler) sweep(now time.Time) {
+	if now.Sub(a.lastSweep) < IdleTimeout {
+		return
+	}
+	a.lastSweep = now
+	for key, s := range a.streams {
+		if now.Sub(s.lastSeen) >= IdleTimeout {
+			delete(a.streams, key)
+			a.truncated++
+		}
+	}
+}
+
+// append adds the part of a segment beyond what is held, reporting whether
+// there was any
+func (s *stream) append(seq uint32, payload []byte) bool {
+	overlap := int(s.next - seq)
+	if overlap >= len(payload) {
+		return false
+	}
+	s.buffer = append(s.buffer, payload[overlap:]...)
+	s.next += uint32(len(payload) - overlap)
+	return true
+}
+
+// drain appends the held out of order segments that are now in sequence
+func (s *stream) drain() {
+	for progress := true; progress; {
+		progress = false
+		for i, p := range s.pending {
+			if int32(p.seq-s.next) > 0 {
+				continue
+			}
+			s.append(p.seq, p.payload)
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+			progress = true
+			break
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package reassembly
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+// framed reports whether data holds a whole message prefixed by its 2 byte length
+func framed(data []byte) bool {
+	return len(data) >= 2 && len(data)-2 >= int(binary.BigEndian.Uint16(data))
+}
+
+func message(body string) []byte {
+	msg := make([]byte, 2, 2+len(body))
+	binary.BigEndian.PutUint16(msg, uint16(len(body)))
+	return append(msg, body...)
+}
+
+func TestAssembler_HoldsSplitMessages(t *testing.T) {
+	a := NewAssembler()
+	start := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	key := Key("192.168.1.10", 51000, "140.82.112.3", 443)
+	msg := message("hello, reassembled world")
+
+	// A whole message passes straight through and nothing is held
+	if got := a.Add(key, 1000, msg, start, framed); string(got) != string(msg) {
+		t.Errorf("Expected a whole message back as is, got %q", got)
+	}
+	if streams := a.GetStats()["streams"]; streams != 0 {
+		t.Errorf("Expected no stream held for a whole message, got %v", streams)
+	}
+
+	// Split over three segments, the last two arriving the wrong way round
+	seq := uint32(2000)
+	if got := a.Add(key, seq, msg[:8], start, framed); string(got) != string(msg[:8]) {
+		t.Errorf("Expected the first segment back, got %q", got)
+	}
+	if got := a.Add(key, seq+16, msg[16:], start, framed); string(got) != string(msg[16:]) {
+		t.Errorf("Expected an out of order segment back on its own, got %q", got)
+	}
+	// A retransmission of the first segment adds nothing
+	a.Add(key, seq, msg[:8], start, framed)
+	if got := a.Add(key, seq+8, msg[8:16], start, framed); string(got) != string(msg) {
+		t.Errorf("Expected the whole message once the gap was filled, got %q", got)
+	}
+
+	stats := a.GetStats()
+	if stats["streams"] != 0 || stats["reassembled"] != uint64(1) || stats["out_of_order"] != uint64(1) {
+		t.Errorf("Expected one message reassembled with one segment out of order, got %v", stats)
+	}
+}
+
+func TestAssembler_Forgets(t *testing.T) {
+	a := NewAssembler()
+	start := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	msg := message("never finished")
+	client := Key("192.168.1.10", 51000, "140.82.112.3", 443)
+	server := Key("140.82.112.3", 443, "192.168.1.10", 51000)
+
+	// Closing the connection forgets what was held for it
+	a.Add(client, 1, msg[:4], start, framed)
+	a.Add(server, 1, msg[:4], start, framed)
+	a.Close(client, server)
+	if got := a.Add(client, 5, msg[4:], start, framed); string(got) != string(msg[4:]) {
+		t.Errorf("Expected a segment after close to be parsed alone, got %q", got)
+	}
+
+	// So does going quiet for longer than the idle timeout
+	a.Add(client, 100, msg[:4], start, framed)
+	a.Add(server, 100, msg[:4], start.Add(IdleTimeout), framed)
+	if got := a.Add(client, 104, msg[4:], start.Add(IdleTimeout), framed); string(got) != string(msg[4:]) {
+		t.Errorf("Expected an idle stream to be forgotten, got %q", got)
+	}
+
+	// A message longer than MaxBuffered is handed on as far as it got
+	other := Key("192.168.1.10", 51001, "140.82.112.3", 443)
+	long := make([]byte, MaxBuffered)
+	binary.BigEndian.PutUint16(long, 0xffff)
+	held := a.GetStats()["streams"]
+	a.Add(other, 200, long[:MaxBuffered/2], start.Add(IdleTimeout), framed)
+	if got := a.Add(other, 200+MaxBuffered/2, long[MaxBuffered/2:], start.Add(IdleTimeout), framed); len(got) != MaxBuffered {
+		t.Errorf("Expected %d bytes handed on at the limit, got %d", MaxBuffered, len(got))
+	}
+	if streams := a.GetStats()["streams"]; streams != held {
+		t.Errorf("Expected the stream to be forgotten at the limit, got %v held", streams)
+	}
+}
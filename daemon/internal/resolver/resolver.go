@@ -7,12 +7,23 @@ import (
 	"time"
 )
 
-// DNSResolver provides DNS resolution with caching
+// Limits on names learned from observed DNS responses
+const (
+	passiveTTL        = time.Hour // Since the name was last seen answered
+	maxPassiveEntries = 50000
+)
+
+// DNSResolver provides DNS resolution with caching. Names learned passively
+// from DNS responses seen on the wire take precedence over reverse lookups:
+// they are what applications actually asked for, where PTR records often
+// name a CDN edge.
 type DNSResolver struct {
 	cache     map[string]*cacheEntry
+	passive   map[string]*cacheEntry // IP -> name whose response answered it
 	cacheMu   sync.RWMutex
 	resolver  *net.Resolver
 	ttl       time.Duration
+	reverse   bool // Whether to look up addresses without a passive name
 }
 
 type cacheEntry struct {
@@ -24,24 +35,70 @@ type cacheEntry struct {
 func NewDNSResolver(ttl time.Duration) *DNSResolver {
 	return &DNSResolver{
 		cache: make(map[string]*cacheEntry),
+		passive: make(map[string]*cacheEntry),
 		resolver: &net.Resolver{
 			PreferGo: true,
 		},
-		ttl: ttl,
+		ttl:     ttl,
+		reverse: true,
+	}
+}
+
+// SetReverseLookups turns reverse DNS lookups on or off and sets how long their
+// answers are cached. Without them only names seen answered on the wire are
+// used, and the daemon sends no DNS queries of its own.
+func (r *DNSResolver) SetReverseLookups(enabled bool, ttl time.Duration) {
+	r.cacheMu.Lock()
+	defer r.cacheMu.Unlock()
+	r.reverse = enabled
+	if ttl > 0 {
+		r.ttl = ttl
 	}
 }
 
-// ResolveIP performs reverse DNS lookup with caching
+// Learn records that a DNS response answered a query for name with ip
+func (r *DNSResolver) Learn(ip, name string) {
+	if ip == "" || name == "" {
+		return
+	}
+	r.cacheMu.Lock()
+	defer r.cacheMu.Unlock()
+	if _, exists := r.passive[ip]; !exists && len(r.passive) >= maxPassiveEntries {
+		return
+	}
+	r.passive[ip] = &cacheEntry{
+		hostname:  name,
+		timestamp: time.Now(),
+	}
+}
+
+// PassiveNames returns how many addresses have a name learned from DNS responses
+func (r *DNSResolver) PassiveNames() int {
+	r.cacheMu.RLock()
+	defer r.cacheMu.RUnlock()
+	return len(r.passive)
+}
+
+// ResolveIP returns the name an IP was last seen answered for, falling back
+// to a reverse DNS lookup with caching
 func (r *DNSResolver) ResolveIP(ip string) string {
-	// Check cache first
+	// Check the names seen on the wire, then the cache
 	r.cacheMu.RLock()
+	if entry, exists := r.passive[ip]; exists && time.Since(entry.timestamp) < passiveTTL {
+		r.cacheMu.RUnlock()
+		return entry.hostname
+	}
 	if entry, exists := r.cache[ip]; exists {
 		if time.Since(entry.timestamp) < r.ttl {
 			r.cacheMu.RUnlock()
 			return entry.hostname
 		}
 	}
+	reverse := r.reverse
 	r.cacheMu.RUnlock()
+	if !reverse {
+		return ip
+	}
 
 	// Perform reverse DNS lookup
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
@@ -76,6 +133,16 @@ func (r *DNSResolver) ResolveIP(ip string) string {
 	return hostname
 }
 
+// Clear forgets every cached and passively learned name, returning how many there were
+func (r *DNSResolver) Clear() int {
+	r.cacheMu.Lock()
+	defer r.cacheMu.Unlock()
+	cleared := len(r.cache) + len(r.passive)
+	r.cache = make(map[string]*cacheEntry)
+	r.passive = make(map[string]*cacheEntry)
+	return cleared
+}
+
 // StartCleanup starts a goroutine to periodically clean expired cache entries
 func (r *DNSResolver) StartCleanup(interval time.Duration) {
 	go func() {
@@ -98,4 +165,9 @@ func (r *DNSResolver) cleanupCache() {
 			delete(r.cache, ip)
 		}
 	}
+	for ip, entry := range r.passive {
+		if now.Sub(entry.timestamp) > passiveTTL {
+			delete(r.passive, ip)
+		}
+	}
 }
\ No newline at end of file
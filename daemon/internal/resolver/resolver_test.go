@@ -0,0 +1,57 @@
+package resolver
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDNSResolver_PrefersPassiveNames(t *testing.T) {
+	r := NewDNSResolver(5 * time.Minute)
+	// A reverse lookup would give the CDN's PTR name, the cache stands in for it here
+	r.cache["151.101.1.140"] = &cacheEntry{hostname: "151.101.1.140.edge.example.net", timestamp: time.Now()}
+
+	r.Learn("151.101.1.140", "www.reddit.com")
+	if name := r.ResolveIP("151.101.1.140"); name != "www.reddit.com" {
+		t.Errorf("Expected the name the client asked for, got %q", name)
+	}
+	if n := r.PassiveNames(); n != 1 {
+		t.Errorf("Expected one passive name, got %d", n)
+	}
+
+	// Once the answer is too old the reverse lookup is used again
+	r.passive["151.101.1.140"].timestamp = time.Now().Add(-2 * passiveTTL)
+	if name := r.ResolveIP("151.101.1.140"); name != "151.101.1.140.edge.example.net" {
+		t.Errorf("Expected an expired passive name to be ignored, got %q", name)
+	}
+	r.cleanupCache()
+	if n := r.PassiveNames(); n != 0 {
+		t.Errorf("Expected the expired name to be cleaned up, got %d", n)
+	}
+}
+
+func TestDNSResolver_Clear(t *testing.T) {
+	r := NewDNSResolver(5 * time.Minute)
+	r.cache["192.0.2.1"] = &cacheEntry{hostname: "a.example", timestamp: time.Now()}
+	r.Learn("192.0.2.2", "b.example")
+
+	if n := r.Clear(); n != 2 {
+		t.Errorf("Expected two names cleared, got %d", n)
+	}
+	if n := r.PassiveNames(); n != 0 || len(r.cache) != 0 {
+		t.Errorf("Expected nothing left, got %d passive and %d cached", n, len(r.cache))
+	}
+}
+
+func TestDNSResolver_WithoutReverseLookups(t *testing.T) {
+	r := NewDNSResolver(5 * time.Minute)
+	r.SetReverseLookups(false, 0)
+	r.Learn("192.0.2.2", "b.example")
+
+	if name := r.ResolveIP("192.0.2.2"); name != "b.example" {
+		t.Errorf("Expected passive names still used, got %q", name)
+	}
+	// Without a lookup the address stands for itself, and isn't cached as a failed one
+	if name := r.ResolveIP("192.0.2.1"); name != "192.0.2.1" || len(r.cache) != 0 {
+		t.Errorf("Expected no lookup, got %q with %d cached", name, len(r.cache))
+	}
+}
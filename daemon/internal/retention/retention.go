@@ -0,0 +1,205 @@
+package retention
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/clock"
+)
+
+// Config describes how much stored data is kept
+type Config struct {
+	MaxAge   time.Duration // Data older than this is pruned (0 disables)
+	MaxBytes int64         // Oldest data is pruned while a store is larger than this (0 disables)
+	Interval time.Duration // How often stores are pruned
+}
+
+// Enabled returns true if an age or size limit is configured
+func (c Config) Enabled() bool {
+	return c.MaxAge > 0 || c.MaxBytes > 0
+}
+
+// Result describes what a single prune pass removed from a store
+type Result struct {
+	Items int
+	Bytes int64
+}
+
+// Store is persisted data that can be pruned
+type Store interface {
+	// Name identifies the store in metrics, e.g. "events" or "pcaps"
+	Name() string
+	// Prune removes data older than cutoff (zero means no age limit) and then
+	// the oldest data until the store fits in maxBytes (0 means no size limit)
+	Prune(cutoff time.Time, maxBytes int64) (Result, error)
+}
+
+// storeStats accumulates what has been pruned from a store
+type storeStats struct {
+	items     uint64
+	bytes     int64
+	lastPrune time.Time
+	lastError string
+}
+
+// Pruner periodically applies the retention policy to registered stores
+type Pruner struct {
+	config Config
+	stores []Store
+	stats  map[string]*storeStats
+	mu     sync.Mutex
+}
+
+// NewPruner creates a new pruner
+func NewPruner(config Config) *Pruner {
+	if config.Interval <= 0 {
+		config.Interval = 10 * time.Minute
+	}
+	return &Pruner{
+		config: config,
+		stats:  make(map[string]*storeStats),
+	}
+}
+
+// Register adds a store to be pruned
+func (p *Pruner) Register(store Store) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stores = append(p.stores, store)
+	p.stats[store.Name()] = &storeStats{}
+}
+
+// Run prunes every registered store once
+func (p *Pruner) Run() {
+	p.mu.Lock()
+	stores := append([]Store{}, p.stores...)
+	p.mu.Unlock()
+
+	var cutoff time.Time
+	if p.config.MaxAge > 0 {
+		cutoff = time.Now().Add(-p.config.MaxAge)
+	}
+
+	for _, store := range stores {
+		result, err := store.Prune(cutoff, p.config.MaxBytes)
+
+		p.mu.Lock()
+		stats := p.stats[store.Name()]
+		stats.items += uint64(result.Items)
+		stats.bytes += result.Bytes
+		stats.lastPrune = time.Now()
+		stats.lastError = ""
+		if err != nil {
+			stats.lastError = err.Error()
+		}
+		p.mu.Unlock()
+
+		if err != nil {
+			log.Printf("[WARNING] Failed to prune %s: %v", store.Name(), err)
+		} else if result.Items > 0 {
+			log.Printf("[INFO] Pruned %d %s (%d bytes)", result.Items, store.Name(), result.Bytes)
+		}
+	}
+}
+
+// StartPruneRoutine starts a goroutine that prunes stores on the configured interval
+func (p *Pruner) StartPruneRoutine() {
+	go func() {
+		p.Run()
+
+		ticker := time.NewTicker(p.config.Interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			p.Run()
+		}
+	}()
+}
+
+// GetStats returns what has been pruned from each store
+func (p *Pruner) GetStats() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stores := make(map[string]interface{}, len(p.stats))
+	for name, stats := range p.stats {
+		entry := map[string]interface{}{
+			"pruned_items": stats.items,
+			"pruned_bytes": stats.bytes,
+			"last_prune":   "never",
+		}
+		if !stats.lastPrune.IsZero() {
+			entry["last_prune"] = clock.In(stats.lastPrune).Format(time.RFC3339)
+		}
+		if stats.lastError != "" {
+			entry["last_error"] = stats.lastError
+		}
+		stores[name] = entry
+	}
+
+	return map[string]interface{}{
+		"max_age_seconds": p.config.MaxAge.Seconds(),
+		"max_bytes":       p.config.MaxBytes,
+		"stores":          stores,
+	}
+}
+
+// DirStore is a store made of files in a directory, such as pcaps or reports
+type DirStore struct {
+	Label   string // Store name used in metrics
+	Dir     string
+	Pattern string // Glob matched against file names, e.g. "*.pcap"
+}
+
+// Name returns the store label
+func (d DirStore) Name() string {
+	return d.Label
+}
+
+// Prune removes expired files, then the oldest files until the directory fits
+func (d DirStore) Prune(cutoff time.Time, maxBytes int64) (Result, error) {
+	matches, err := filepath.Glob(filepath.Join(d.Dir, d.Pattern))
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to list %s: %w", d.Dir, err)
+	}
+
+	type file struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []file
+	var total int64
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, file{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	var result Result
+	for _, f := range files {
+		expired := !cutoff.IsZero() && f.modTime.Before(cutoff)
+		oversized := maxBytes > 0 && total > maxBytes
+		if !expired && !oversized {
+			break
+		}
+		if err := os.Remove(f.path); err != nil {
+			return result, fmt.Errorf("failed to remove %s: %w", f.path, err)
+		}
+		total -= f.size
+		result.Items++
+		result.Bytes += f.size
+	}
+	return result, nil
+}
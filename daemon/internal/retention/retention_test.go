@@ -0,0 +1,65 @@
+package retention
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, dir, name string, size int, age time.Duration) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(-age)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDirStore_PrunesByAge(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "old.pcap", 100, 48*time.Hour)
+	writeFile(t, dir, "new.pcap", 100, time.Hour)
+	writeFile(t, dir, "other.txt", 100, 48*time.Hour)
+
+	result, err := DirStore{Label: "pcaps", Dir: dir, Pattern: "*.pcap"}.Prune(time.Now().Add(-24*time.Hour), 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Items != 1 || result.Bytes != 100 {
+		t.Errorf("Expected 1 file pruned, got %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.pcap")); !os.IsNotExist(err) {
+		t.Error("Expected old.pcap to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "other.txt")); err != nil {
+		t.Error("Expected files not matching the pattern to be kept")
+	}
+}
+
+func TestPruner_PrunesOldestBySizeAndRecordsStats(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.pcap", 400, 3*time.Hour)
+	writeFile(t, dir, "b.pcap", 400, 2*time.Hour)
+	writeFile(t, dir, "c.pcap", 400, time.Hour)
+
+	p := NewPruner(Config{MaxBytes: 900})
+	p.Register(DirStore{Label: "pcaps", Dir: dir, Pattern: "*.pcap"})
+	p.Run()
+
+	if _, err := os.Stat(filepath.Join(dir, "a.pcap")); !os.IsNotExist(err) {
+		t.Error("Expected the oldest file to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.pcap")); err != nil {
+		t.Error("Expected b.pcap to be kept once the store fits")
+	}
+
+	stores := p.GetStats()["stores"].(map[string]interface{})
+	pcaps := stores["pcaps"].(map[string]interface{})
+	if pcaps["pruned_items"].(uint64) != 1 || pcaps["pruned_bytes"].(int64) != 400 {
+		t.Errorf("Unexpected prune stats: %v", pcaps)
+	}
+}
@@ -0,0 +1,117 @@
+package sink
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Record types, the same names the WebSocket API uses for them
+const (
+	TypeEvent              = "event"
+	TypeConversationUpdate = "conversation_update"
+	TypeConversationClosed = "conversation_closed"
+)
+
+// Record is what sinks write for each event or conversation, shaped like a
+// WebSocket message
+type Record struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// Sink receives events and conversations as the daemon sees them, for
+// delivery somewhere outside it. Writes must not block the capture for long.
+type Sink interface {
+	Name() string // Label used in /api/config
+	WriteEvent(event *models.NetworkEvent) error
+	// WriteConversation receives summaries of tracked conversations when
+	// updates are enabled, and each conversation's last one once it leaves
+	// the conversation table with closed set
+	WriteConversation(summary models.ConversationSummary, closed bool) error
+	Close() error
+	GetStats() map[string]interface{}
+}
+
+// Set fans events and conversations out to every sink added to it
+type Set struct {
+	mu    sync.RWMutex
+	sinks []Sink
+}
+
+// NewSet creates an empty set
+func NewSet() *Set {
+	return &Set{}
+}
+
+// Add adds a sink
+func (s *Set) Add(sink Sink) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sinks = append(s.sinks, sink)
+}
+
+// Len returns how many sinks there are
+func (s *Set) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.sinks)
+}
+
+// WriteEvent writes an event to every sink. Failures are left to each sink's
+// stats, a failing sink doesn't hold up the others.
+func (s *Set) WriteEvent(event *models.NetworkEvent) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, sink := range s.sinks {
+		sink.WriteEvent(event)
+	}
+}
+
+// WriteConversation writes a conversation summary to every sink
+func (s *Set) WriteConversation(summary models.ConversationSummary, closed bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, sink := range s.sinks {
+		sink.WriteConversation(summary, closed)
+	}
+}
+
+// StartUpdateRoutine starts a goroutine that writes the summaries of tracked
+// conversations from active to every sink on an interval
+func (s *Set) StartUpdateRoutine(interval time.Duration, active func() []models.ConversationSummary) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			for _, summary := range active() {
+				s.WriteConversation(summary, false)
+			}
+		}
+	}()
+}
+
+// Close closes every sink, logging failures
+func (s *Set) Close() {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, sink := range s.sinks {
+		if err := sink.Close(); err != nil {
+			log.Printf("[WARNING] %s output failed: %v", sink.Name(), err)
+		}
+	}
+}
+
+// GetStats returns each sink's stats by name
+func (s *Set) GetStats() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stats := make(map[string]interface{}, len(s.sinks))
+	for _, sink := range s.sinks {
+		stats[sink.Name()] = sink.GetStats()
+	}
+	return stats
+}
@@ -0,0 +1,56 @@
+package sink
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// recorder is a sink that remembers what it was given
+type recorder struct {
+	name   string
+	events int
+	closed []bool
+	err    error
+}
+
+func (r *recorder) Name() string { return r.name }
+
+func (r *recorder) WriteEvent(event *models.NetworkEvent) error {
+	r.events++
+	return r.err
+}
+
+func (r *recorder) WriteConversation(summary models.ConversationSummary, closed bool) error {
+	r.closed = append(r.closed, closed)
+	return r.err
+}
+
+func (r *recorder) Close() error { return r.err }
+
+func (r *recorder) GetStats() map[string]interface{} {
+	return map[string]interface{}{"events": r.events}
+}
+
+func TestSet(t *testing.T) {
+	failing := &recorder{name: "failing", err: errors.New("unreachable")}
+	working := &recorder{name: "working"}
+	set := NewSet()
+	set.Add(failing)
+	set.Add(working)
+
+	set.WriteEvent(&models.NetworkEvent{})
+	set.WriteConversation(models.ConversationSummary{ID: "c1"}, false)
+	set.WriteConversation(models.ConversationSummary{ID: "c1"}, true)
+	set.Close()
+
+	// A failing sink doesn't hold up the others
+	if working.events != 1 || len(working.closed) != 2 || working.closed[0] || !working.closed[1] {
+		t.Errorf("Expected every record to reach the working sink, got %+v", working)
+	}
+	stats := set.GetStats()
+	if set.Len() != 2 || stats["working"].(map[string]interface{})["events"] != 1 {
+		t.Errorf("Unexpected stats: %v", stats)
+	}
+}
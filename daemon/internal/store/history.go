@@ -0,0 +1,162 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+	"github.com/iolloyd/netty/daemon/internal/query"
+)
+
+// HistoryFilter selects stored conversations and events
+type HistoryFilter struct {
+	From    time.Time
+	To      time.Time
+	Host    string // Remote IP, or hostname or TLS server name including subdomains, "" for any
+	Service string // Detected service or application protocol, e.g. HTTPS, "" for any
+	Limit   int    // Most conversations and events returned, each
+}
+
+// History is what was stored about a time range
+type History struct {
+	From          time.Time                    `json:"from"`
+	To            time.Time                    `json:"to"`
+	Host          string                       `json:"host,omitempty"`
+	Service       string                       `json:"service,omitempty"`
+	Conversations []models.ConversationSummary `json:"conversations"` // Active at some point in the range, oldest first
+	Events        []models.NetworkEvent        `json:"events"`        // Sampled events in the range, oldest first
+	Truncated     bool                         `json:"truncated"`     // More matched than the limit allowed
+}
+
+// History returns the conversations active and the sampled events seen in the
+// filter's time range, up to its limit each
+func (s *Store) History(ctx context.Context, filter HistoryFilter) (History, error) {
+	history := History{
+		From:          filter.From,
+		To:            filter.To,
+		Host:          filter.Host,
+		Service:       filter.Service,
+		Conversations: []models.ConversationSummary{},
+		Events:        []models.NetworkEvent{},
+	}
+	limit := filter.Limit
+
+	where, args := "start_time <= ? AND last_activity >= ?", []interface{}{formatTime(filter.To), formatTime(filter.From)}
+	if filter.Host != "" {
+		where += ` AND (remote_addr LIKE ? ESCAPE '\' OR remote_hostname = ? COLLATE NOCASE OR remote_hostname LIKE ? ESCAPE '\'
+			OR server_name = ? COLLATE NOCASE OR server_name LIKE ? ESCAPE '\')`
+		host, subdomains := likeEscape(filter.Host)+":%", "%."+likeEscape(filter.Host)
+		args = append(args, host, filter.Host, subdomains, filter.Host, subdomains)
+	}
+	if filter.Service != "" {
+		where += " AND service = ? COLLATE NOCASE"
+		args = append(args, filter.Service)
+	}
+	rows, err := s.ro.QueryContext(ctx, "SELECT summary FROM conversations WHERE "+where+" ORDER BY start_time LIMIT ?",
+		append(args, limit+1)...)
+	if err != nil {
+		return history, fmt.Errorf("failed to read conversations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if len(history.Conversations) == limit {
+			history.Truncated = true
+			break
+		}
+		var data string
+		var conv models.ConversationSummary
+		if err := rows.Scan(&data); err != nil {
+			return history, fmt.Errorf("failed to read conversations: %w", err)
+		}
+		if err := json.Unmarshal([]byte(data), &conv); err != nil {
+			return history, fmt.Errorf("failed to decode a stored conversation: %w", err)
+		}
+		history.Conversations = append(history.Conversations, conv)
+	}
+	if err := rows.Err(); err != nil {
+		return history, fmt.Errorf("failed to read conversations: %w", err)
+	}
+
+	where, args = "timestamp >= ? AND timestamp <= ?", []interface{}{formatTime(filter.From), formatTime(filter.To)}
+	if filter.Host != "" {
+		where += ` AND (source_ip = ? OR dest_ip = ?
+			OR json_extract(event, '$.source_hostname') = ? COLLATE NOCASE OR json_extract(event, '$.dest_hostname') = ? COLLATE NOCASE
+			OR json_extract(event, '$.tls_server_name') = ? COLLATE NOCASE)`
+		args = append(args, filter.Host, filter.Host, filter.Host, filter.Host, filter.Host)
+	}
+	if filter.Service != "" {
+		where += " AND app_protocol = ? COLLATE NOCASE"
+		args = append(args, filter.Service)
+	}
+	rows, err = s.ro.QueryContext(ctx, "SELECT event FROM events WHERE "+where+" ORDER BY timestamp LIMIT ?",
+		append(args, limit+1)...)
+	if err != nil {
+		return history, fmt.Errorf("failed to read events: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if len(history.Events) == limit {
+			history.Truncated = true
+			break
+		}
+		var data string
+		var event models.NetworkEvent
+		if err := rows.Scan(&data); err != nil {
+			return history, fmt.Errorf("failed to read events: %w", err)
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return history, fmt.Errorf("failed to decode a stored event: %w", err)
+		}
+		history.Events = append(history.Events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return history, fmt.Errorf("failed to read events: %w", err)
+	}
+	return history, nil
+}
+
+// likeEscape escapes LIKE wildcards in s, for patterns using ESCAPE '\'
+func likeEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Query runs read-only SQL against the conversations and events tables,
+// returning up to limit rows
+func (s *Store) Query(ctx context.Context, sql string, limit int) (query.Result, error) {
+	rows, err := s.ro.QueryContext(ctx, sql)
+	if err != nil {
+		return query.Result{}, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return query.Result{}, err
+	}
+	result := query.Result{Columns: columns, Rows: [][]interface{}{}}
+	for rows.Next() {
+		if len(result.Rows) == limit {
+			result.Truncated = true
+			break
+		}
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return query.Result{}, err
+		}
+		// Text comes back as bytes, which would encode as base64
+		for i, value := range values {
+			if b, ok := value.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, rows.Err()
+}
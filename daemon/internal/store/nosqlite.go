@@ -0,0 +1,12 @@
+//go:build !cgo
+
+package store
+
+import (
+	"errors"
+)
+
+// sqliteAvailable fails, the SQLite driver needs cgo
+func sqliteAvailable() error {
+	return errors.New("-history-db needs SQLite, which builds without cgo lack; build with CGO_ENABLED=1")
+}
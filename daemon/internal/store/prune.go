@@ -0,0 +1,92 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/retention"
+)
+
+// pruneBatch is how many of the oldest rows are deleted at a time while the
+// database is over its size limit
+const pruneBatch = 1000
+
+// Name returns the store label used by retention metrics
+func (s *Store) Name() string {
+	return "history"
+}
+
+// Prune deletes conversations that ended and events seen before cutoff, then
+// the oldest events and conversations until the database fits in maxBytes.
+// Freed pages are returned to the file system.
+func (s *Store) Prune(cutoff time.Time, maxBytes int64) (retention.Result, error) {
+	var result retention.Result
+	before, err := s.size()
+	if err != nil {
+		return result, err
+	}
+
+	if !cutoff.IsZero() {
+		for _, stmt := range []string{
+			"DELETE FROM events WHERE timestamp < ?",
+			"DELETE FROM conversations WHERE last_activity < ?",
+		} {
+			deleted, err := s.delete(stmt, formatTime(cutoff))
+			result.Items += deleted
+			if err != nil {
+				return result, err
+			}
+		}
+	}
+
+	for maxBytes > 0 {
+		size, err := s.size()
+		if err != nil || size <= maxBytes {
+			break
+		}
+		// Sampled events go first, conversations once there are none left
+		deleted, err := s.delete("DELETE FROM events WHERE id IN (SELECT id FROM events ORDER BY timestamp LIMIT ?)", pruneBatch)
+		if err == nil && deleted == 0 {
+			deleted, err = s.delete("DELETE FROM conversations WHERE id IN (SELECT id FROM conversations ORDER BY last_activity LIMIT ?)", pruneBatch)
+		}
+		result.Items += deleted
+		if err != nil {
+			return result, err
+		}
+		if deleted == 0 {
+			break
+		}
+	}
+
+	if result.Items > 0 {
+		if _, err := s.db.Exec("PRAGMA incremental_vacuum"); err != nil {
+			return result, fmt.Errorf("failed to reclaim space: %w", err)
+		}
+	}
+	if after, err := s.size(); err == nil && after < before {
+		result.Bytes = before - after
+	}
+	return result, nil
+}
+
+// delete runs a DELETE statement, returning how many rows it removed and
+// handing the space they took to the free list
+func (s *Store) delete(stmt string, args ...interface{}) (int, error) {
+	res, err := s.db.Exec(stmt, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune: %w", err)
+	}
+	deleted, _ := res.RowsAffected()
+	return int(deleted), nil
+}
+
+// size returns the bytes the database uses, leaving out free pages
+func (s *Store) size() (int64, error) {
+	var pages, free, pageSize int64
+	err := s.db.QueryRow("SELECT page_count, freelist_count, page_size FROM pragma_page_count(), pragma_freelist_count(), pragma_page_size()").
+		Scan(&pages, &free, &pageSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure the database: %w", err)
+	}
+	return (pages - free) * pageSize, nil
+}
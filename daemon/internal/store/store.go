@@ -0,0 +1,283 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/clock"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// timeFormat stores times as fixed-width UTC text, so they sort and compare
+// as strings and SQLite's date functions understand them
+const timeFormat = "2006-01-02T15:04:05.000Z"
+
+// schema creates the tables on first use. Each row keeps the full JSON the
+// API serves next to the columns worth querying.
+const schema = `
+CREATE TABLE IF NOT EXISTS conversations (
+	id              TEXT PRIMARY KEY,
+	protocol        TEXT NOT NULL,
+	local_addr      TEXT NOT NULL,
+	remote_addr     TEXT NOT NULL,
+	remote_hostname TEXT NOT NULL DEFAULT '',
+	server_name     TEXT NOT NULL DEFAULT '',
+	service         TEXT NOT NULL DEFAULT '',
+	state           TEXT NOT NULL,
+	process         TEXT NOT NULL DEFAULT '',
+	start_time      TEXT NOT NULL,
+	last_activity   TEXT NOT NULL,
+	packets_in      INTEGER NOT NULL,
+	packets_out     INTEGER NOT NULL,
+	bytes_in        INTEGER NOT NULL,
+	bytes_out       INTEGER NOT NULL,
+	summary         TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS conversations_last_activity ON conversations (last_activity);
+CREATE INDEX IF NOT EXISTS conversations_start_time ON conversations (start_time);
+
+CREATE TABLE IF NOT EXISTS events (
+	id                 INTEGER PRIMARY KEY,
+	timestamp          TEXT NOT NULL,
+	conversation_id    TEXT NOT NULL DEFAULT '',
+	transport_protocol TEXT NOT NULL DEFAULT '',
+	app_protocol       TEXT NOT NULL DEFAULT '',
+	source_ip          TEXT NOT NULL DEFAULT '',
+	source_port        INTEGER NOT NULL DEFAULT 0,
+	dest_ip            TEXT NOT NULL DEFAULT '',
+	dest_port          INTEGER NOT NULL DEFAULT 0,
+	size               INTEGER NOT NULL DEFAULT 0,
+	event              TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS events_timestamp ON events (timestamp);
+`
+
+// Config describes what is stored
+type Config struct {
+	Path        string // SQLite database file, created if missing
+	EventSample int    // Store one in this many events, 0 stores none
+}
+
+// Store persists conversation summaries and sampled events to SQLite, so
+// history survives daemon restarts. Writes are queued and flushed in batches.
+type Store struct {
+	config Config
+	db     *sql.DB                      // Read-write, for flushes and pruning
+	ro     *sql.DB                      // Read-only, for ad-hoc queries
+	local  func() models.LocalAddresses // The addresses treated as this host
+
+	mu            sync.Mutex
+	conversations map[string]models.ConversationSummary // Latest summary per conversation since the last flush
+	events        []models.NetworkEvent
+	observed      uint64 // Events seen, for sampling
+	convsWritten  uint64
+	eventsWritten uint64
+	lastError     string
+}
+
+// Open opens the database at config.Path, creating its tables if needed
+func Open(config Config, localIP string) (*Store, error) {
+	if err := sqliteAvailable(); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", "file:"+config.Path+"?_journal_mode=WAL&_busy_timeout=5000&_auto_vacuum=incremental")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", config.Path, err)
+	}
+	// SQLite allows a single writer, and a single connection avoids busy errors between them
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create tables in %s: %w", config.Path, err)
+	}
+
+	ro, err := sql.Open("sqlite3", "file:"+config.Path+"?mode=ro&_query_only=true&_busy_timeout=5000")
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open %s read-only: %w", config.Path, err)
+	}
+
+	return &Store{
+		config:        config,
+		db:            db,
+		ro:            ro,
+		local:         fixedAddresses(models.NewLocalAddresses(localIP)),
+		conversations: make(map[string]models.ConversationSummary),
+	}, nil
+}
+
+// SetLocalAddresses makes the store ask local for the addresses treated as
+// this host, so they follow network changes and IPv6 temporary addresses
+func (s *Store) SetLocalAddresses(local func() models.LocalAddresses) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.local = local
+}
+
+// fixedAddresses returns a function always returning local
+func fixedAddresses(local models.LocalAddresses) func() models.LocalAddresses {
+	return func() models.LocalAddresses { return local }
+}
+
+// AddConversation queues a conversation leaving memory to be stored
+func (s *Store) AddConversation(conv *models.Conversation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conversations[conv.ID] = conv.ToSummary(s.local(), clock.Now())
+}
+
+// AddSummaries queues the latest summaries of conversations still tracked,
+// so they are stored before they end
+func (s *Store) AddSummaries(summaries []models.ConversationSummary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, summary := range summaries {
+		s.conversations[summary.ID] = summary
+	}
+}
+
+// Observe queues every EventSample-th event to be stored
+func (s *Store) Observe(event *models.NetworkEvent) {
+	if s.config.EventSample <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observed++
+	if s.observed%uint64(s.config.EventSample) == 0 {
+		s.events = append(s.events, *event)
+	}
+}
+
+// Flush writes everything queued in a single transaction. What fails to be
+// written is dropped rather than retried, so a broken database can't grow the
+// queue without bound.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	conversations, events := s.conversations, s.events
+	s.conversations = make(map[string]models.ConversationSummary)
+	s.events = nil
+	s.mu.Unlock()
+
+	if len(conversations) == 0 && len(events) == 0 {
+		return nil
+	}
+	err := s.write(conversations, events)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.lastError = err.Error()
+		return err
+	}
+	s.lastError = ""
+	s.convsWritten += uint64(len(conversations))
+	s.eventsWritten += uint64(len(events))
+	return nil
+}
+
+// write stores conversations, replacing earlier summaries of the same ones, and events
+func (s *Store) write(conversations map[string]models.ConversationSummary, events []models.NetworkEvent) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	convStmt, err := tx.Prepare(`INSERT OR REPLACE INTO conversations
+		(id, protocol, local_addr, remote_addr, remote_hostname, server_name, service, state, process,
+		 start_time, last_activity, packets_in, packets_out, bytes_in, bytes_out, summary)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare conversation insert: %w", err)
+	}
+	defer convStmt.Close()
+	for _, conv := range conversations {
+		data, err := json.Marshal(conv)
+		if err != nil {
+			return fmt.Errorf("failed to encode conversation %s: %w", conv.ID, err)
+		}
+		if _, err := convStmt.Exec(conv.ID, conv.Protocol, conv.LocalAddr, conv.RemoteAddr, conv.Hostname,
+			conv.ServerName, conv.Service, string(conv.State), conv.Process,
+			formatTime(conv.StartTime), formatTime(conv.LastActivity),
+			conv.PacketsIn, conv.PacketsOut, conv.BytesIn, conv.BytesOut, string(data)); err != nil {
+			return fmt.Errorf("failed to store conversation %s: %w", conv.ID, err)
+		}
+	}
+
+	eventStmt, err := tx.Prepare(`INSERT INTO events
+		(timestamp, conversation_id, transport_protocol, app_protocol, source_ip, source_port, dest_ip, dest_port, size, event)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare event insert: %w", err)
+	}
+	defer eventStmt.Close()
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+		if _, err := eventStmt.Exec(formatTime(event.Timestamp), event.ConversationID, event.TransportProtocol,
+			event.AppProtocol, event.SourceIP, event.SourcePort, event.DestIP, event.DestPort, event.Size,
+			string(data)); err != nil {
+			return fmt.Errorf("failed to store event: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// StartFlushRoutine starts a goroutine that, on an interval, queues the
+// summaries of tracked conversations from active and writes what is queued
+func (s *Store) StartFlushRoutine(interval time.Duration, active func() []models.ConversationSummary) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if active != nil {
+				s.AddSummaries(active())
+			}
+			if err := s.Flush(); err != nil {
+				log.Printf("[WARNING] History store write failed: %v", err)
+			}
+		}
+	}()
+}
+
+// GetStats returns store counters
+func (s *Store) GetStats() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := map[string]interface{}{
+		"path":                  s.config.Path,
+		"event_sample":          s.config.EventSample,
+		"conversations_written": s.convsWritten,
+		"events_written":        s.eventsWritten,
+		"conversations_pending": len(s.conversations),
+		"events_pending":        len(s.events),
+	}
+	if s.lastError != "" {
+		stats["last_error"] = s.lastError
+	}
+	return stats
+}
+
+// Close closes the database, after a final Flush by the caller
+func (s *Store) Close() error {
+	s.ro.Close()
+	return s.db.Close()
+}
+
+// formatTime formats a time for storage
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
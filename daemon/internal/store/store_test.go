@@ -0,0 +1,178 @@
+//go:build cgo
+
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func TestStore_HistorySurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "netty.db")
+	s, err := Open(Config{Path: path, EventSample: 2}, "192.168.1.10")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	start := time.Date(2025, 7, 1, 13, 0, 0, 0, time.UTC)
+	s.AddConversation(&models.Conversation{
+		ID:        "old",
+		Key:       models.ConversationKey{Protocol: "TCP", SrcIP: "192.168.1.10", SrcPort: 50000, DstIP: "203.0.113.7", DstPort: 443},
+		State:     models.ConversationStateClosed,
+		StartTime: start,
+		Stats:     models.ConversationStats{PacketsOut: 5, BytesOut: 700, LastActivity: start.Add(time.Minute)},
+		Service:   "HTTPS",
+	})
+	// A tracked conversation is stored as of its latest summary
+	s.AddSummaries([]models.ConversationSummary{{ID: "live", Protocol: "UDP", StartTime: start.Add(time.Hour), LastActivity: start.Add(2 * time.Hour), BytesIn: 10}})
+	s.AddSummaries([]models.ConversationSummary{{ID: "live", Protocol: "UDP", StartTime: start.Add(time.Hour), LastActivity: start.Add(3 * time.Hour), BytesIn: 20}})
+	for i := 0; i < 4; i++ {
+		s.Observe(&models.NetworkEvent{Timestamp: start.Add(time.Duration(i) * time.Hour), Size: i})
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.Close()
+
+	s, err = Open(Config{Path: path}, "192.168.1.10")
+	if err != nil {
+		t.Fatalf("Unexpected error reopening: %v", err)
+	}
+	defer s.Close()
+
+	ctx := context.Background()
+	history, err := s.History(ctx, HistoryFilter{From: start, To: start.Add(4 * time.Hour), Limit: 100})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(history.Conversations) != 2 || history.Conversations[0].ID != "old" || history.Conversations[1].BytesIn != 20 {
+		t.Errorf("Expected both conversations, the live one as last summarized, got %+v", history.Conversations)
+	}
+	if history.Conversations[0].RemoteAddr != "203.0.113.7:443" || history.Conversations[0].Service != "HTTPS" {
+		t.Errorf("Expected the stored summary, got %+v", history.Conversations[0])
+	}
+	// Every second event was sampled
+	if len(history.Events) != 2 || history.Events[0].Size != 1 || history.Events[1].Size != 3 {
+		t.Errorf("Expected the 2nd and 4th events, got %+v", history.Events)
+	}
+
+	// Ranges select conversations active in them, and limits truncate
+	history, _ = s.History(ctx, HistoryFilter{From: start.Add(150 * time.Minute), To: start.Add(4 * time.Hour), Limit: 10})
+	if len(history.Conversations) != 1 || history.Conversations[0].ID != "live" || len(history.Events) != 1 || history.Truncated {
+		t.Errorf("Expected only the live conversation and the later event, got %+v", history)
+	}
+	history, _ = s.History(ctx, HistoryFilter{From: start, To: start.Add(4 * time.Hour), Limit: 1})
+	if len(history.Conversations) != 1 || history.Conversations[0].ID != "old" || !history.Truncated {
+		t.Errorf("Expected the first conversation, truncated, got %+v", history)
+	}
+
+	result, err := s.Query(ctx, "SELECT id, bytes_in FROM conversations ORDER BY start_time", 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Rows) != 2 || result.Rows[1][0] != "live" || result.Rows[1][1] != int64(20) {
+		t.Errorf("Unexpected query result: %+v", result)
+	}
+	if _, err := s.Query(ctx, "DELETE FROM conversations", 10); err == nil {
+		t.Error("Expected the read-only connection to refuse writes")
+	}
+}
+
+func TestStore_HistoryFilter(t *testing.T) {
+	s, err := Open(Config{Path: filepath.Join(t.TempDir(), "netty.db"), EventSample: 1}, "192.168.1.10")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	start := time.Date(2025, 7, 1, 13, 0, 0, 0, time.UTC)
+	s.AddSummaries([]models.ConversationSummary{
+		{ID: "api", RemoteAddr: "203.0.113.7:443", ServerName: "api.example.com", Service: "HTTPS", StartTime: start, LastActivity: start},
+		{ID: "dns", RemoteAddr: "192.0.2.53:53", Hostname: "resolver.example.net", Service: "DNS", StartTime: start, LastActivity: start},
+		{ID: "lookalike", RemoteAddr: "203.0.113.70:443", ServerName: "notexample.com", Service: "HTTPS", StartTime: start, LastActivity: start},
+	})
+	s.Observe(&models.NetworkEvent{Timestamp: start, DestIP: "203.0.113.7", AppProtocol: "HTTPS", TLSServerName: "api.example.com"})
+	s.Observe(&models.NetworkEvent{Timestamp: start, DestIP: "192.0.2.53", AppProtocol: "DNS", DestHostname: "resolver.example.net"})
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ids := func(filter HistoryFilter) ([]string, int) {
+		filter.From, filter.To, filter.Limit = start, start.Add(time.Hour), 10
+		history, err := s.History(context.Background(), filter)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var ids []string
+		for _, conv := range history.Conversations {
+			ids = append(ids, conv.ID)
+		}
+		return ids, len(history.Events)
+	}
+
+	tests := []struct {
+		filter HistoryFilter
+		want   string
+		events int
+	}{
+		{HistoryFilter{Host: "203.0.113.7"}, "api", 1},
+		{HistoryFilter{Host: "example.com"}, "api", 0}, // Subdomains match conversations, events match exactly
+		{HistoryFilter{Host: "API.example.com"}, "api", 1},
+		{HistoryFilter{Host: "resolver.example.net"}, "dns", 1},
+		{HistoryFilter{Service: "dns"}, "dns", 1},
+		{HistoryFilter{Host: "example.com", Service: "DNS"}, "", 0},
+	}
+	for _, tt := range tests {
+		got, events := ids(tt.filter)
+		if strings.Join(got, ",") != tt.want || events != tt.events {
+			t.Errorf("%+v: expected %q and %d events, got %v and %d", tt.filter, tt.want, tt.events, got, events)
+		}
+	}
+	if got, _ := ids(HistoryFilter{Host: "203.0.113.%"}); len(got) != 0 {
+		t.Errorf("Expected wildcards to match literally, got %v", got)
+	}
+}
+
+func TestStore_Prune(t *testing.T) {
+	s, err := Open(Config{Path: filepath.Join(t.TempDir(), "netty.db"), EventSample: 1}, "192.168.1.10")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	start := time.Date(2025, 7, 1, 13, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		at := start.Add(time.Duration(i) * 24 * time.Hour)
+		s.AddSummaries([]models.ConversationSummary{{ID: at.Format("day-02"), StartTime: at, LastActivity: at}})
+		s.Observe(&models.NetworkEvent{Timestamp: at})
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result, err := s.Prune(start.Add(36*time.Hour), 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Items != 4 {
+		t.Errorf("Expected the first two days' conversations and events pruned, got %d items", result.Items)
+	}
+	history, _ := s.History(context.Background(), HistoryFilter{From: start, To: start.Add(72 * time.Hour), Limit: 10})
+	if len(history.Conversations) != 1 || len(history.Events) != 1 {
+		t.Errorf("Expected the last day to be kept, got %+v", history)
+	}
+
+	// A size limit the database can't meet empties it
+	if _, err := s.Prune(time.Time{}, 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	history, _ = s.History(context.Background(), HistoryFilter{From: start, To: start.Add(72 * time.Hour), Limit: 10})
+	if len(history.Conversations) != 0 || len(history.Events) != 0 {
+		t.Errorf("Expected everything pruned, got %+v", history)
+	}
+}
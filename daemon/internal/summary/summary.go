@@ -0,0 +1,348 @@
+package summary
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/clock"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Period is how often a summary is produced
+type Period string
+
+const (
+	PeriodDaily  Period = "daily"
+	PeriodWeekly Period = "weekly"
+)
+
+// ParsePeriod validates a period name
+func ParsePeriod(name string) (Period, error) {
+	switch Period(strings.ToLower(name)) {
+	case PeriodDaily:
+		return PeriodDaily, nil
+	case PeriodWeekly:
+		return PeriodWeekly, nil
+	}
+	return "", fmt.Errorf("unknown summary period %q (use daily or weekly)", name)
+}
+
+// Next returns the start of the period following t. Days start at midnight
+// and weeks on Monday, both in t's location.
+func (p Period) Next(t time.Time) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if p == PeriodWeekly {
+		daysUntilMonday := (8 - int(t.Weekday())) % 7
+		if daysUntilMonday == 0 {
+			daysUntilMonday = 7
+		}
+		return midnight.AddDate(0, 0, daysUntilMonday)
+	}
+	return midnight.AddDate(0, 0, 1)
+}
+
+// Config describes when summaries are produced and where they are delivered
+type Config struct {
+	Period  Period
+	Dir     string // Directory summaries are written to
+	Webhook string // URL summaries are POSTed to as JSON
+}
+
+// Enabled returns true if a period and at least one destination are configured
+func (c Config) Enabled() bool {
+	return c.Period != "" && (c.Dir != "" || c.Webhook != "")
+}
+
+// Usage is the traffic attributed to a device or service
+type Usage struct {
+	Name    string `json:"name"`
+	Bytes   uint64 `json:"bytes"`
+	Packets uint64 `json:"packets"`
+}
+
+// Alert is a notable event raised during the period
+type Alert struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Message string    `json:"message"`
+}
+
+// Report is the summary of one period
+type Report struct {
+	Period     Period    `json:"period"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Bytes      uint64    `json:"total_bytes"`
+	Packets    uint64    `json:"total_packets"`
+	Devices    []Usage   `json:"devices"`
+	Services   []Usage   `json:"services"`
+	NewDevices []string  `json:"new_devices"`
+	Alerts     []Alert   `json:"alerts"`
+}
+
+// knownDevicesFile keeps first-seen devices across restarts so they are only reported as new once
+const knownDevicesFile = "netty-known-devices.json"
+
+// Summarizer aggregates traffic into periodic summary reports
+type Summarizer struct {
+	config     Config
+	localIP    string
+	start      time.Time
+	bytes      uint64
+	packets    uint64
+	devices    map[string]*Usage
+	services   map[string]*Usage
+	newDevices []string
+	alerts     []Alert
+	known      map[string]bool
+	client     *http.Client
+	mu         sync.Mutex
+}
+
+// NewSummarizer creates a new summarizer starting a period now
+func NewSummarizer(config Config, localIP string) *Summarizer {
+	s := &Summarizer{
+		config:   config,
+		localIP:  localIP,
+		start:    clock.Now(),
+		devices:  make(map[string]*Usage),
+		services: make(map[string]*Usage),
+		known:    make(map[string]bool),
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+	s.loadKnownDevices()
+	return s
+}
+
+// SetLocalIP changes the address treated as this host, when the interface's address changes
+func (s *Summarizer) SetLocalIP(ip string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.localIP = ip
+}
+
+// Observe adds an event to the current period
+func (s *Summarizer) Observe(event *models.NetworkEvent) {
+	size := uint64(event.Size)
+	service := serviceName(event)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bytes += size
+	s.packets++
+	addUsage(s.services, service, size)
+
+	for _, ip := range []string{event.SourceIP, event.DestIP} {
+		if !s.isDevice(ip) {
+			continue
+		}
+		addUsage(s.devices, ip, size)
+		if !s.known[ip] {
+			s.known[ip] = true
+			s.newDevices = append(s.newDevices, ip)
+		}
+	}
+}
+
+// RecordAlert adds an alert to the current period
+func (s *Summarizer) RecordAlert(alertType, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerts = append(s.alerts, Alert{Time: clock.Now(), Type: alertType, Message: message})
+}
+
+// Current returns the summary of the period in progress
+func (s *Summarizer) Current() Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshot(clock.Now())
+}
+
+// Rotate closes the current period at end and starts a new one
+func (s *Summarizer) Rotate(end time.Time) Report {
+	s.mu.Lock()
+	report := s.snapshot(end)
+	s.start = end
+	s.bytes = 0
+	s.packets = 0
+	s.devices = make(map[string]*Usage)
+	s.services = make(map[string]*Usage)
+	s.newDevices = nil
+	s.alerts = nil
+	s.mu.Unlock()
+
+	if len(report.NewDevices) > 0 {
+		s.saveKnownDevices()
+	}
+	return report
+}
+
+// snapshot builds a report from the counters, must be called with the lock held
+func (s *Summarizer) snapshot(end time.Time) Report {
+	return Report{
+		Period:     s.config.Period,
+		Start:      s.start,
+		End:        end,
+		Bytes:      s.bytes,
+		Packets:    s.packets,
+		Devices:    sortedUsage(s.devices),
+		Services:   sortedUsage(s.services),
+		NewDevices: append([]string{}, s.newDevices...),
+		Alerts:     append([]Alert{}, s.alerts...),
+	}
+}
+
+// Deliver writes the report to the configured directory and webhook
+func (s *Summarizer) Deliver(report Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode summary: %w", err)
+	}
+
+	var errs []string
+	if s.config.Dir != "" {
+		name := fmt.Sprintf("netty-summary-%s-%s.json", report.Period, report.Start.Format("2006-01-02"))
+		if err := os.WriteFile(filepath.Join(s.config.Dir, name), data, 0644); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to write summary: %v", err))
+		}
+	}
+	if s.config.Webhook != "" {
+		if err := s.post(data); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (s *Summarizer) post(data []byte) error {
+	resp, err := s.client.Post(s.config.Webhook, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to post summary: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("summary webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// StartScheduleRoutine starts a goroutine that closes and delivers a summary at every period boundary
+func (s *Summarizer) StartScheduleRoutine() {
+	go func() {
+		for {
+			next := s.config.Period.Next(clock.Now())
+			time.Sleep(time.Until(next))
+
+			report := s.Rotate(next)
+			if err := s.Deliver(report); err != nil {
+				log.Printf("[WARNING] Failed to deliver %s summary: %v", report.Period, err)
+				continue
+			}
+			log.Printf("[INFO] Delivered %s summary for %s", report.Period, report.Start.Format("2006-01-02"))
+		}
+	}()
+}
+
+// isDevice reports whether ip belongs to a device on the monitored network,
+// must be called with the lock held
+func (s *Summarizer) isDevice(ip string) bool {
+	if ip == "" {
+		return false
+	}
+	if ip == s.localIP {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	return parsed != nil && (parsed.IsPrivate() || parsed.IsLinkLocalUnicast())
+}
+
+func (s *Summarizer) loadKnownDevices() {
+	if s.config.Dir == "" {
+		return
+	}
+	data, err := os.ReadFile(filepath.Join(s.config.Dir, knownDevicesFile))
+	if err != nil {
+		return
+	}
+	var devices []string
+	if err := json.Unmarshal(data, &devices); err != nil {
+		log.Printf("[WARNING] Ignoring unreadable %s: %v", knownDevicesFile, err)
+		return
+	}
+	for _, device := range devices {
+		s.known[device] = true
+	}
+}
+
+func (s *Summarizer) saveKnownDevices() {
+	if s.config.Dir == "" {
+		return
+	}
+	s.mu.Lock()
+	devices := make([]string, 0, len(s.known))
+	for device := range s.known {
+		devices = append(devices, device)
+	}
+	s.mu.Unlock()
+	sort.Strings(devices)
+
+	data, _ := json.Marshal(devices)
+	if err := os.WriteFile(filepath.Join(s.config.Dir, knownDevicesFile), data, 0644); err != nil {
+		log.Printf("[WARNING] Failed to save known devices: %v", err)
+	}
+}
+
+// serviceName names the service an event belongs to, preferring the detected
+// application protocol and falling back to the lower (usually well-known) port
+func serviceName(event *models.NetworkEvent) string {
+	if event.AppProtocol != "" {
+		return event.AppProtocol
+	}
+	port := event.DestPort
+	if event.SourcePort > 0 && (port == 0 || event.SourcePort < port) {
+		port = event.SourcePort
+	}
+	if port == 0 {
+		return event.TransportProtocol
+	}
+	return fmt.Sprintf("%s/%d", event.TransportProtocol, port)
+}
+
+func addUsage(usage map[string]*Usage, name string, bytes uint64) {
+	u, exists := usage[name]
+	if !exists {
+		u = &Usage{Name: name}
+		usage[name] = u
+	}
+	u.Bytes += bytes
+	u.Packets++
+}
+
+// sortedUsage returns usage entries ordered by bytes, largest first
+func sortedUsage(usage map[string]*Usage) []Usage {
+	sorted := make([]Usage, 0, len(usage))
+	for _, u := range usage {
+		sorted = append(sorted, *u)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Bytes != sorted[j].Bytes {
+			return sorted[i].Bytes > sorted[j].Bytes
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
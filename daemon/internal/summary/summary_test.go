@@ -0,0 +1,96 @@
+package summary
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func TestPeriodNext(t *testing.T) {
+	// Wednesday afternoon
+	now := time.Date(2025, 7, 2, 15, 30, 0, 0, time.UTC)
+
+	if next := PeriodDaily.Next(now); !next.Equal(time.Date(2025, 7, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected next day to start at midnight, got %s", next)
+	}
+	if next := PeriodWeekly.Next(now); !next.Equal(time.Date(2025, 7, 7, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected next week to start on Monday, got %s", next)
+	}
+
+	monday := time.Date(2025, 7, 7, 0, 0, 0, 0, time.UTC)
+	if next := PeriodWeekly.Next(monday); !next.Equal(time.Date(2025, 7, 14, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected a week boundary to advance a full week, got %s", next)
+	}
+}
+
+func TestSummarizer_AggregatesAndRotates(t *testing.T) {
+	s := NewSummarizer(Config{Period: PeriodDaily}, "192.168.1.10")
+
+	s.Observe(&models.NetworkEvent{SourceIP: "192.168.1.10", SourcePort: 50000, DestIP: "203.0.113.7", DestPort: 443, TransportProtocol: "TCP", AppProtocol: "HTTPS", Size: 1000})
+	s.Observe(&models.NetworkEvent{SourceIP: "192.168.1.20", SourcePort: 5353, DestIP: "192.168.1.10", DestPort: 40000, TransportProtocol: "UDP", Size: 200})
+	s.RecordAlert("host_blocked", "203.0.113.7 exceeded limit")
+
+	report := s.Rotate(time.Now())
+	if report.Bytes != 1200 || report.Packets != 2 {
+		t.Errorf("Unexpected totals: %d bytes, %d packets", report.Bytes, report.Packets)
+	}
+	if len(report.Devices) != 2 || report.Devices[0].Name != "192.168.1.10" || report.Devices[0].Bytes != 1200 {
+		t.Errorf("Unexpected devices: %+v", report.Devices)
+	}
+	if len(report.Services) != 2 || report.Services[0].Name != "HTTPS" || report.Services[1].Name != "UDP/5353" {
+		t.Errorf("Unexpected services: %+v", report.Services)
+	}
+	if len(report.NewDevices) != 2 {
+		t.Errorf("Expected 2 new devices, got %v", report.NewDevices)
+	}
+	if len(report.Alerts) != 1 {
+		t.Errorf("Expected 1 alert, got %d", len(report.Alerts))
+	}
+
+	// Devices already seen are not new in the next period
+	s.Observe(&models.NetworkEvent{SourceIP: "192.168.1.10", DestIP: "203.0.113.7", TransportProtocol: "TCP", Size: 10})
+	report = s.Rotate(time.Now())
+	if len(report.NewDevices) != 0 || len(report.Alerts) != 0 || report.Packets != 1 {
+		t.Errorf("Expected a fresh period, got %+v", report)
+	}
+}
+
+func TestSummarizer_Deliver(t *testing.T) {
+	var received Report
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &received)
+	}))
+	defer webhook.Close()
+
+	dir := t.TempDir()
+	s := NewSummarizer(Config{Period: PeriodWeekly, Dir: dir, Webhook: webhook.URL}, "192.168.1.10")
+	s.Observe(&models.NetworkEvent{SourceIP: "192.168.1.10", DestIP: "203.0.113.7", TransportProtocol: "TCP", Size: 10})
+
+	report := s.Rotate(time.Date(2025, 7, 7, 0, 0, 0, 0, time.UTC))
+	report.Start = time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	if err := s.Deliver(report); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "netty-summary-weekly-2025-06-30.json")); err != nil {
+		t.Errorf("Expected summary file: %v", err)
+	}
+	if received.Packets != 1 {
+		t.Errorf("Expected webhook to receive the summary, got %+v", received)
+	}
+
+	// Known devices persist across restarts
+	s = NewSummarizer(Config{Period: PeriodWeekly, Dir: dir}, "192.168.1.10")
+	s.Observe(&models.NetworkEvent{SourceIP: "192.168.1.10", DestIP: "203.0.113.7", TransportProtocol: "TCP", Size: 10})
+	if report := s.Current(); len(report.NewDevices) != 0 {
+		t.Errorf("Expected no new devices after restart, got %v", report.NewDevices)
+	}
+}
@@ -0,0 +1,129 @@
+// Package tap hands a copy of each captured packet to whoever is listening,
+// such as a live pcap download, without slowing the capture when nobody is
+package tap
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/iolloyd/netty/daemon/internal/bpffilter"
+)
+
+// Buffer is how many packets a subscription holds before it drops them
+const Buffer = 4096
+
+// Packet is a captured packet as read off the wire
+type Packet struct {
+	Info gopacket.CaptureInfo
+	Data []byte
+}
+
+// Tap fans captured packets out to subscriptions
+type Tap struct {
+	linkType layers.LinkType
+	snaplen  int
+
+	mu          sync.RWMutex
+	subscribers map[*Subscription]bool
+	active      int32 // Subscriber count, read atomically on every packet
+}
+
+// Subscription receives the captured packets matching its filter
+type Subscription struct {
+	tap     *Tap
+	filter  *bpffilter.Filter // nil passes every packet
+	packets chan Packet
+	dropped uint64
+	once    sync.Once
+}
+
+// New creates a tap for packets of the given link type and snap length
+func New(linkType layers.LinkType, snaplen int) *Tap {
+	return &Tap{
+		linkType:    linkType,
+		snaplen:     snaplen,
+		subscribers: make(map[*Subscription]bool),
+	}
+}
+
+// LinkType returns the link layer type of the tapped packets
+func (t *Tap) LinkType() layers.LinkType {
+	return t.linkType
+}
+
+// SnapLen returns the maximum bytes captured per packet
+func (t *Tap) SnapLen() int {
+	return t.snaplen
+}
+
+// Subscribe starts receiving packets matching filter, a BPF expression such
+// as "host 1.2.3.4" or its tcpdump -ddd program ("" for every packet). Close
+// the subscription when done.
+func (t *Tap) Subscribe(filter string) (*Subscription, error) {
+	s := &Subscription{tap: t, packets: make(chan Packet, Buffer)}
+	if filter != "" {
+		bpf, err := bpffilter.New(t.linkType, t.snaplen, filter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter %q: %w", filter, err)
+		}
+		s.filter = bpf
+	}
+
+	t.mu.Lock()
+	t.subscribers[s] = true
+	atomic.AddInt32(&t.active, 1)
+	t.mu.Unlock()
+	return s, nil
+}
+
+// Add offers a captured packet to every subscription. A subscription whose
+// buffer is full drops it rather than holding up the capture. The capture
+// reuses data for the next packet, so subscriptions share a copy of it.
+func (t *Tap) Add(info gopacket.CaptureInfo, data []byte) {
+	if atomic.LoadInt32(&t.active) == 0 {
+		return
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var shared []byte
+	for s := range t.subscribers {
+		if s.filter != nil && !s.filter.Matches(data) {
+			continue
+		}
+		if shared == nil {
+			shared = append([]byte(nil), data...)
+		}
+		select {
+		case s.packets <- Packet{Info: info, Data: shared}:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+		}
+	}
+}
+
+// Packets returns the channel the subscription's packets arrive on. It's
+// closed by Close.
+func (s *Subscription) Packets() <-chan Packet {
+	return s.packets
+}
+
+// Dropped returns how many matching packets didn't fit in the buffer
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Close stops the subscription's packets
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		t := s.tap
+		t.mu.Lock()
+		delete(t.subscribers, s)
+		atomic.AddInt32(&t.active, -1)
+		t.mu.Unlock()
+		close(s.packets)
+	})
+}
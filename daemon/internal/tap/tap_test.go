@@ -0,0 +1,43 @@
+package tap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func TestTap_FansOutAndDrops(t *testing.T) {
+	tp := New(layers.LinkTypeEthernet, 65535)
+	info := gopacket.CaptureInfo{Timestamp: time.Now(), CaptureLength: 3, Length: 3}
+	tp.Add(info, []byte{1, 2, 3}) // Nobody listening yet
+
+	a, err := tp.Subscribe("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	b, _ := tp.Subscribe("")
+	for i := 0; i < Buffer+2; i++ {
+		tp.Add(info, []byte{byte(i)})
+	}
+	if len(a.Packets()) != Buffer || a.Dropped() != 2 || b.Dropped() != 2 {
+		t.Errorf("Expected each subscription to fill its buffer and drop the rest, got %d queued, %d and %d dropped", len(a.Packets()), a.Dropped(), b.Dropped())
+	}
+	if p := <-b.Packets(); p.Data[0] != 0 {
+		t.Errorf("Expected packets in capture order, got %v", p.Data)
+	}
+
+	a.Close()
+	a.Close()
+	for range a.Packets() {
+	}
+	tp.Add(info, []byte{9})
+	if a.Dropped() != 2 {
+		t.Errorf("Expected nothing offered after closing")
+	}
+
+	if _, err := tp.Subscribe("not a (filter"); err == nil {
+		t.Error("Expected an invalid filter to be rejected")
+	}
+}
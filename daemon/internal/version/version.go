@@ -0,0 +1,9 @@
+package version
+
+// Version is the daemon release, set when building with
+// -ldflags "-X github.com/iolloyd/netty/daemon/internal/version.Version=1.2.0"
+var Version = "dev"
+
+// Protocol is the version of the WebSocket and HTTP API. It only changes when
+// existing clients would break, new messages and fields don't bump it.
+const Protocol = 1
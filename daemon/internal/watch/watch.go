@@ -0,0 +1,297 @@
+package watch
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/clock"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Activity kinds
+const (
+	KindConnection     = "connection"      // A new conversation involving the host
+	KindNewDestination = "new_destination" // First contact with a peer
+	KindNewPort        = "new_port"        // First use of a service port
+	KindNewService     = "new_service"     // First use of an application protocol
+)
+
+// maxActivity is how many activity entries are kept for the API
+const maxActivity = 1000
+
+// maxTrackedConversations bounds the conversation IDs remembered to spot new connections
+const maxTrackedConversations = 10000
+
+// Activity is one entry in a watched host's activity stream
+type Activity struct {
+	Time           time.Time `json:"time"`
+	Host           string    `json:"host"`
+	Kind           string    `json:"kind"`
+	Peer           string    `json:"peer"`
+	PeerName       string    `json:"peer_name,omitempty"`
+	Port           int       `json:"port,omitempty"`
+	Protocol       string    `json:"protocol"`
+	Service        string    `json:"service,omitempty"`
+	Outgoing       bool      `json:"outgoing"` // The watched host sent the first packet seen
+	ConversationID string    `json:"conversation_id,omitempty"`
+}
+
+// FirstSeen reports whether the activity is a first-seen behavior rather than a plain connection
+func (a Activity) FirstSeen() bool {
+	return a.Kind != KindConnection
+}
+
+// Message describes the activity for alerts and logs
+func (a Activity) Message() string {
+	peer := a.Peer
+	if a.PeerName != "" {
+		peer = fmt.Sprintf("%s (%s)", a.PeerName, a.Peer)
+	}
+	switch a.Kind {
+	case KindNewDestination:
+		return fmt.Sprintf("%s contacted new destination %s", a.Host, peer)
+	case KindNewPort:
+		return fmt.Sprintf("%s used new port %s/%d with %s", a.Host, a.Protocol, a.Port, peer)
+	case KindNewService:
+		return fmt.Sprintf("%s used new service %s with %s", a.Host, a.Service, peer)
+	}
+	return fmt.Sprintf("%s connected with %s on %s/%d", a.Host, peer, a.Protocol, a.Port)
+}
+
+// Profile is everything a watched host has been seen doing
+type Profile struct {
+	Host         string    `json:"host"`
+	Since        time.Time `json:"since"`
+	Connections  uint64    `json:"connections"`
+	Destinations []string  `json:"destinations"`
+	Ports        []string  `json:"ports"`
+	Services     []string  `json:"services"`
+}
+
+type profile struct {
+	since        time.Time
+	connections  uint64
+	destinations map[string]bool
+	ports        map[string]bool
+	services     map[string]bool
+}
+
+// Watcher tracks what watched hosts talk to and reports new behavior
+type Watcher struct {
+	profiles      map[string]*profile
+	conversations map[string]time.Time // Conversation IDs already reported, with when they were last seen
+	activity      []Activity
+	mu            sync.Mutex
+
+	// OnActivity is called (outside the lock) for each new activity entry
+	OnActivity func(activity Activity)
+}
+
+// NewWatcher creates a watcher with no watched hosts
+func NewWatcher() *Watcher {
+	return &Watcher{
+		profiles:      make(map[string]*profile),
+		conversations: make(map[string]time.Time),
+	}
+}
+
+// Watch starts watching a host by IP address
+func (w *Watcher) Watch(host string) error {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("invalid host %q: expected an IP address", host)
+	}
+	host = ip.String()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, exists := w.profiles[host]; !exists {
+		w.profiles[host] = &profile{
+			since:        clock.Now(),
+			destinations: make(map[string]bool),
+			ports:        make(map[string]bool),
+			services:     make(map[string]bool),
+		}
+	}
+	return nil
+}
+
+// Unwatch stops watching a host and forgets its profile
+func (w *Watcher) Unwatch(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		host = ip.String()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, exists := w.profiles[host]; !exists {
+		return false
+	}
+	delete(w.profiles, host)
+	return true
+}
+
+// Observe checks an event against the watched hosts. Only the first packet of
+// each conversation is examined, so the cost per packet is a map lookup.
+func (w *Watcher) Observe(event *models.NetworkEvent) {
+	if event.ConversationID == "" {
+		return
+	}
+
+	w.mu.Lock()
+	if len(w.profiles) == 0 {
+		w.mu.Unlock()
+		return
+	}
+	if _, seen := w.conversations[event.ConversationID]; seen {
+		w.conversations[event.ConversationID] = event.Timestamp
+		w.mu.Unlock()
+		return
+	}
+
+	var activities []Activity
+	for _, side := range []struct {
+		host, peer, peerName string
+		outgoing             bool
+	}{
+		{event.SourceIP, event.DestIP, peerName(event.DestHostname, event.TLSServerName, event.DestIP), true},
+		{event.DestIP, event.SourceIP, peerName(event.SourceHostname, "", event.SourceIP), false},
+	} {
+		p, watched := w.profiles[side.host]
+		if !watched {
+			continue
+		}
+		activities = append(activities, p.observe(event, side.host, side.peer, side.peerName, side.outgoing)...)
+	}
+	if len(activities) > 0 {
+		w.trackConversation(event.ConversationID, event.Timestamp)
+		w.activity = append(w.activity, activities...)
+		if len(w.activity) > maxActivity {
+			w.activity = w.activity[len(w.activity)-maxActivity:]
+		}
+	}
+	w.mu.Unlock()
+
+	if w.OnActivity != nil {
+		for _, activity := range activities {
+			w.OnActivity(activity)
+		}
+	}
+}
+
+// observe records a new conversation for the profile and returns its activity entries
+func (p *profile) observe(event *models.NetworkEvent, host, peer, name string, outgoing bool) []Activity {
+	port := servicePort(event)
+	base := Activity{
+		Time:           event.Timestamp,
+		Host:           host,
+		Peer:           peer,
+		PeerName:       name,
+		Port:           port,
+		Protocol:       event.TransportProtocol,
+		Service:        event.AppProtocol,
+		Outgoing:       outgoing,
+		ConversationID: event.ConversationID,
+	}
+
+	p.connections++
+	activity := base
+	activity.Kind = KindConnection
+	activities := []Activity{activity}
+
+	if !p.destinations[peer] {
+		p.destinations[peer] = true
+		activity.Kind = KindNewDestination
+		activities = append(activities, activity)
+	}
+	portKey := fmt.Sprintf("%s/%d", event.TransportProtocol, port)
+	if port > 0 && !p.ports[portKey] {
+		p.ports[portKey] = true
+		activity.Kind = KindNewPort
+		activities = append(activities, activity)
+	}
+	if event.AppProtocol != "" && !p.services[event.AppProtocol] {
+		p.services[event.AppProtocol] = true
+		activity.Kind = KindNewService
+		activities = append(activities, activity)
+	}
+	return activities
+}
+
+// trackConversation remembers a reported conversation, forgetting idle ones when the table is full
+func (w *Watcher) trackConversation(id string, at time.Time) {
+	if len(w.conversations) >= maxTrackedConversations {
+		for other, last := range w.conversations {
+			if at.Sub(last) > 10*time.Minute {
+				delete(w.conversations, other)
+			}
+		}
+	}
+	w.conversations[id] = at
+}
+
+// Profiles returns the watched hosts and what they have been seen doing
+func (w *Watcher) Profiles() []Profile {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	profiles := make([]Profile, 0, len(w.profiles))
+	for host, p := range w.profiles {
+		profiles = append(profiles, Profile{
+			Host:         host,
+			Since:        p.since,
+			Connections:  p.connections,
+			Destinations: sortedKeys(p.destinations),
+			Ports:        sortedKeys(p.ports),
+			Services:     sortedKeys(p.services),
+		})
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Host < profiles[j].Host })
+	return profiles
+}
+
+// Activity returns recent activity for a host ("" for all watched hosts), newest first
+func (w *Watcher) Activity(host string, limit int) []Activity {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var list []Activity
+	for i := len(w.activity) - 1; i >= 0 && (limit <= 0 || len(list) < limit); i-- {
+		if host == "" || w.activity[i].Host == host {
+			list = append(list, w.activity[i])
+		}
+	}
+	return list
+}
+
+// peerName returns the most useful name for a peer, if it has one besides its IP
+func peerName(hostname, sni, ip string) string {
+	if sni != "" {
+		return sni
+	}
+	if hostname != ip {
+		return hostname
+	}
+	return ""
+}
+
+// servicePort returns the port identifying the service, the lower (usually well-known) of the two
+func servicePort(event *models.NetworkEvent) int {
+	port := event.DestPort
+	if event.SourcePort > 0 && (port == 0 || event.SourcePort < port) {
+		port = event.SourcePort
+	}
+	return port
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
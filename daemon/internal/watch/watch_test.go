@@ -0,0 +1,82 @@
+package watch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func event(conversationID, srcIP string, srcPort int, dstIP string, dstPort int, app string) *models.NetworkEvent {
+	return &models.NetworkEvent{
+		Timestamp:         time.Now(),
+		SourceIP:          srcIP,
+		SourcePort:        srcPort,
+		DestIP:            dstIP,
+		DestPort:          dstPort,
+		TransportProtocol: "TCP",
+		AppProtocol:       app,
+		ConversationID:    conversationID,
+	}
+}
+
+func TestWatcher_FirstSeenBehavior(t *testing.T) {
+	w := NewWatcher()
+	if err := w.Watch("192.168.1.50"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var kinds []string
+	w.OnActivity = func(activity Activity) { kinds = append(kinds, activity.Kind) }
+
+	// First conversation: everything is new
+	w.Observe(event("c1", "192.168.1.50", 40000, "203.0.113.7", 443, "HTTPS"))
+	if len(kinds) != 4 {
+		t.Fatalf("Expected connection plus 3 first-seen entries, got %v", kinds)
+	}
+
+	// Later packets of the same conversation are ignored
+	w.Observe(event("c1", "203.0.113.7", 443, "192.168.1.50", 40000, "HTTPS"))
+	if len(kinds) != 4 {
+		t.Errorf("Expected no activity for a known conversation, got %v", kinds)
+	}
+
+	// Same destination and service on a new connection is just a connection
+	kinds = nil
+	w.Observe(event("c2", "192.168.1.50", 40001, "203.0.113.7", 443, "HTTPS"))
+	if len(kinds) != 1 || kinds[0] != KindConnection {
+		t.Errorf("Expected a plain connection, got %v", kinds)
+	}
+
+	// A new port on the same destination
+	kinds = nil
+	w.Observe(event("c3", "192.168.1.50", 40002, "203.0.113.7", 8883, ""))
+	if len(kinds) != 2 || kinds[1] != KindNewPort {
+		t.Errorf("Expected a new port, got %v", kinds)
+	}
+
+	// Unwatched hosts produce nothing
+	kinds = nil
+	w.Observe(event("c4", "192.168.1.60", 40000, "203.0.113.7", 443, "HTTPS"))
+	if len(kinds) != 0 {
+		t.Errorf("Expected no activity for an unwatched host, got %v", kinds)
+	}
+
+	profiles := w.Profiles()
+	if len(profiles) != 1 || profiles[0].Connections != 3 || len(profiles[0].Ports) != 2 {
+		t.Errorf("Unexpected profile: %+v", profiles)
+	}
+	if activity := w.Activity("192.168.1.50", 2); len(activity) != 2 || activity[0].ConversationID != "c3" {
+		t.Errorf("Expected the latest activity first, got %+v", activity)
+	}
+}
+
+func TestWatcher_Watch(t *testing.T) {
+	w := NewWatcher()
+	if err := w.Watch("not-an-ip"); err == nil {
+		t.Error("Expected an error for an invalid host")
+	}
+	w.Watch("192.168.1.50")
+	if !w.Unwatch("192.168.1.50") || w.Unwatch("192.168.1.50") {
+		t.Error("Expected unwatching to succeed once")
+	}
+}
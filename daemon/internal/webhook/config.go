@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Events a rule can fire on
+const (
+	EventNewConversation = "new_conversation" // A conversation with an address in the rule's CIDR starts
+	EventBandwidth       = "bandwidth"        // Throughput rises above the rule's threshold
+	EventDaemonStarted   = "daemon_started"
+	EventDaemonStopped   = "daemon_stopped"
+)
+
+// Bandwidth directions
+const (
+	DirectionIn    = "in"
+	DirectionOut   = "out"
+	DirectionTotal = "total"
+)
+
+// Rule is a condition a webhook is notified of
+type Rule struct {
+	Event       string  `yaml:"event" json:"event"`
+	CIDR        string  `yaml:"cidr,omitempty" json:"cidr,omitempty"`                   // new_conversation: addresses to match
+	BytesPerSec float64 `yaml:"bytes_per_sec,omitempty" json:"bytes_per_sec,omitempty"` // bandwidth: threshold
+	Direction   string  `yaml:"direction,omitempty" json:"direction,omitempty"`         // bandwidth: in, out or total (default)
+
+	network *net.IPNet
+}
+
+// Hook is a URL notified when any of its rules fire
+type Hook struct {
+	URL    string `yaml:"url"`
+	Secret string `yaml:"secret"` // Key payloads are signed with, unsigned when empty
+	Rules  []Rule `yaml:"rules"`
+}
+
+// Load reads the webhooks file, a YAML document with a list of hooks under
+// "webhooks"
+func Load(path string) ([]Hook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhooks: %w", err)
+	}
+	var doc struct {
+		Webhooks []Hook `yaml:"webhooks"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse webhooks file %s: %w", path, err)
+	}
+	if len(doc.Webhooks) == 0 {
+		return nil, fmt.Errorf("no webhooks in %s", path)
+	}
+	for i := range doc.Webhooks {
+		if err := doc.Webhooks[i].validate(); err != nil {
+			return nil, fmt.Errorf("webhook %d in %s: %w", i+1, path, err)
+		}
+	}
+	return doc.Webhooks, nil
+}
+
+// validate checks a hook's URL and rules, parsing their CIDRs
+func (h *Hook) validate() error {
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("expected an http or https URL, got %q", h.URL)
+	}
+	if len(h.Rules) == 0 {
+		return fmt.Errorf("no rules for %s", redact(h.URL))
+	}
+	for i := range h.Rules {
+		rule := &h.Rules[i]
+		switch rule.Event {
+		case EventNewConversation:
+			_, network, err := net.ParseCIDR(rule.CIDR)
+			if err != nil {
+				// A single address matches just itself
+				ip := net.ParseIP(rule.CIDR)
+				if ip == nil {
+					return fmt.Errorf("rule %d: expected a CIDR or address, got %q", i+1, rule.CIDR)
+				}
+				bits := 8 * len(ip.To16())
+				if ip.To4() != nil {
+					ip, bits = ip.To4(), 32
+				}
+				network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+			}
+			rule.network = network
+		case EventBandwidth:
+			if rule.BytesPerSec <= 0 {
+				return fmt.Errorf("rule %d: expected a bytes_per_sec threshold", i+1)
+			}
+			switch rule.Direction {
+			case "":
+				rule.Direction = DirectionTotal
+			case DirectionIn, DirectionOut, DirectionTotal:
+			default:
+				return fmt.Errorf("rule %d: unknown direction %q (use in, out or total)", i+1, rule.Direction)
+			}
+		case EventDaemonStarted, EventDaemonStopped:
+		default:
+			return fmt.Errorf("rule %d: unknown event %q (use new_conversation, bandwidth, daemon_started or daemon_stopped)", i+1, rule.Event)
+		}
+	}
+	return nil
+}
+
+// redact leaves out a URL's path and query, which often carry a token
+func redact(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return u.Scheme + "://" + u.Host
+}
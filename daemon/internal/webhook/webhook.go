@@ -0,0 +1,414 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iolloyd/netty/daemon/internal/clock"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// Delivery limits
+const (
+	queueSize      = 100 // Notifications waiting per hook, newer ones are dropped beyond it
+	maxAttempts    = 5
+	minBackoff     = time.Second
+	maxBackoff     = 30 * time.Second
+	requestTimeout = 10 * time.Second
+)
+
+// maxTrackedConversations bounds the conversation IDs remembered to spot new conversations
+const maxTrackedConversations = 10000
+
+// SignatureHeader carries the hex HMAC-SHA256 of the body keyed with the
+// hook's secret, as "sha256=<hex>"
+const SignatureHeader = "X-Netty-Signature"
+
+// Payload is the JSON body POSTed for each notification
+type Payload struct {
+	ID    string      `json:"id"` // Unique per notification, the same across retries
+	Event string      `json:"event"`
+	Time  time.Time   `json:"time"`
+	Rule  *Rule       `json:"rule,omitempty"` // The rule that fired, for conversation and bandwidth rules
+	Data  interface{} `json:"data"`
+}
+
+// Conversation is the data of a new_conversation notification
+type Conversation struct {
+	ConversationID string `json:"conversation_id"`
+	SourceIP       string `json:"source_ip"`
+	SourcePort     int    `json:"source_port,omitempty"`
+	DestIP         string `json:"dest_ip"`
+	DestPort       int    `json:"dest_port,omitempty"`
+	Protocol       string `json:"protocol"`
+	Service        string `json:"service,omitempty"`
+	Hostname       string `json:"hostname,omitempty"` // Name of the matched address, if known
+}
+
+// Bandwidth is the data of a bandwidth notification
+type Bandwidth struct {
+	BytesPerSec    float64 `json:"bytes_per_sec"` // Rate in the rule's direction
+	BytesInPerSec  float64 `json:"bytes_in_per_sec"`
+	BytesOutPerSec float64 `json:"bytes_out_per_sec"`
+}
+
+// hook is a configured hook with its delivery queue and counters
+type hook struct {
+	Hook
+	queue chan Payload
+
+	delivered uint64
+	failed    uint64
+	dropped   uint64
+	retries   uint64
+	lastErr   string
+}
+
+// Notifier checks traffic against the webhook rules and POSTs a signed JSON
+// payload to each hook whose rule fires. Each hook has its own queue and
+// sender, so a slow endpoint only delays its own notifications; failed
+// deliveries are retried with exponential backoff.
+type Notifier struct {
+	hooks  []*hook
+	client *http.Client
+
+	mu            sync.Mutex
+	conversations map[string]time.Time // Conversation IDs already checked, with when they were last seen
+	above         map[*Rule]bool       // Bandwidth rules currently over their threshold
+	closed        bool
+
+	wg      sync.WaitGroup
+	abandon chan struct{} // Closed when Close runs out of time, ending retries
+}
+
+// NewNotifier creates a notifier and starts a sender per hook
+func NewNotifier(hooks []Hook) *Notifier {
+	n := &Notifier{
+		client:        &http.Client{Timeout: requestTimeout},
+		conversations: make(map[string]time.Time),
+		above:         make(map[*Rule]bool),
+		abandon:       make(chan struct{}),
+	}
+	for _, h := range hooks {
+		hk := &hook{Hook: h, queue: make(chan Payload, queueSize)}
+		n.hooks = append(n.hooks, hk)
+		n.wg.Add(1)
+		go n.send(hk)
+	}
+	return n
+}
+
+// Observe checks the first packet of each conversation against the
+// new_conversation rules
+func (n *Notifier) Observe(event *models.NetworkEvent) {
+	if event.ConversationID == "" {
+		return
+	}
+	n.mu.Lock()
+	if _, seen := n.conversations[event.ConversationID]; seen {
+		n.conversations[event.ConversationID] = event.Timestamp
+		n.mu.Unlock()
+		return
+	}
+	n.trackConversation(event.ConversationID, event.Timestamp)
+	n.mu.Unlock()
+
+	source, dest := net.ParseIP(event.SourceIP), net.ParseIP(event.DestIP)
+	for _, h := range n.hooks {
+		for i := range h.Rules {
+			rule := &h.Rules[i]
+			if rule.Event != EventNewConversation {
+				continue
+			}
+			var hostname string
+			switch {
+			case dest != nil && rule.network.Contains(dest):
+				hostname = event.DestHostname
+				if event.TLSServerName != "" {
+					hostname = event.TLSServerName
+				}
+			case source != nil && rule.network.Contains(source):
+				hostname = event.SourceHostname
+			default:
+				continue
+			}
+			n.enqueue(h, EventNewConversation, rule, Conversation{
+				ConversationID: event.ConversationID,
+				SourceIP:       event.SourceIP,
+				SourcePort:     event.SourcePort,
+				DestIP:         event.DestIP,
+				DestPort:       event.DestPort,
+				Protocol:       event.TransportProtocol,
+				Service:        event.AppProtocol,
+				Hostname:       hostname,
+			})
+		}
+	}
+}
+
+// trackConversation remembers a checked conversation, forgetting idle ones
+// when the table is full, must be called with the lock held
+func (n *Notifier) trackConversation(id string, at time.Time) {
+	if len(n.conversations) >= maxTrackedConversations {
+		for other, last := range n.conversations {
+			if at.Sub(last) > 10*time.Minute {
+				delete(n.conversations, other)
+			}
+		}
+	}
+	n.conversations[id] = at
+}
+
+// CheckBandwidth fires bandwidth rules whose rate has risen above their
+// threshold. A rule fires once per crossing, again only after the rate has
+// dropped back below.
+func (n *Notifier) CheckBandwidth(throughput models.ThroughputSummary) {
+	rates := map[string]float64{
+		DirectionIn:    throughput.BytesInPerSec,
+		DirectionOut:   throughput.BytesOutPerSec,
+		DirectionTotal: throughput.BytesInPerSec + throughput.BytesOutPerSec,
+	}
+	for _, h := range n.hooks {
+		for i := range h.Rules {
+			rule := &h.Rules[i]
+			if rule.Event != EventBandwidth {
+				continue
+			}
+			rate := rates[rule.Direction]
+			n.mu.Lock()
+			crossed := rate > rule.BytesPerSec && !n.above[rule]
+			n.above[rule] = rate > rule.BytesPerSec
+			n.mu.Unlock()
+			if crossed {
+				n.enqueue(h, EventBandwidth, rule, Bandwidth{
+					BytesPerSec:    rate,
+					BytesInPerSec:  throughput.BytesInPerSec,
+					BytesOutPerSec: throughput.BytesOutPerSec,
+				})
+			}
+		}
+	}
+}
+
+// StartBandwidthRoutine starts a goroutine checking the bandwidth rules
+// against throughput every second, if there are any
+func (n *Notifier) StartBandwidthRoutine(throughput func() models.ThroughputSummary) {
+	if !n.has(EventBandwidth) {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			n.mu.Lock()
+			closed := n.closed
+			n.mu.Unlock()
+			if closed {
+				return
+			}
+			n.CheckBandwidth(throughput())
+		}
+	}()
+}
+
+// Notify sends a daemon event, such as daemon_started, to the hooks with a
+// rule for it
+func (n *Notifier) Notify(event string, data interface{}) {
+	for _, h := range n.hooks {
+		for i := range h.Rules {
+			if h.Rules[i].Event == event {
+				n.enqueue(h, event, nil, data)
+				break
+			}
+		}
+	}
+}
+
+// has reports whether any hook has a rule for an event
+func (n *Notifier) has(event string) bool {
+	for _, h := range n.hooks {
+		for _, rule := range h.Rules {
+			if rule.Event == event {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// enqueue queues a notification for a hook, dropping it if the hook is too
+// far behind or the notifier is closed
+func (n *Notifier) enqueue(h *hook, event string, rule *Rule, data interface{}) {
+	payload := Payload{ID: uuid.New().String(), Event: event, Time: clock.Now(), Rule: rule, Data: data}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return
+	}
+	select {
+	case h.queue <- payload:
+	default:
+		h.dropped++
+	}
+}
+
+// send delivers a hook's notifications in order until its queue is closed
+func (n *Notifier) send(h *hook) {
+	defer n.wg.Done()
+	for payload := range h.queue {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			n.record(h, false, fmt.Errorf("failed to encode %s notification: %w", payload.Event, err))
+			continue
+		}
+
+		backoff := minBackoff
+		for attempt := 1; ; attempt++ {
+			retry, err := n.post(h, payload, body)
+			if err == nil {
+				n.record(h, true, nil)
+				break
+			}
+			if !retry || attempt == maxAttempts || !n.wait(h, backoff, err) {
+				n.record(h, false, err)
+				break
+			}
+			if backoff *= 2; backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+	}
+}
+
+// wait backs off before a retry, returning false if Close has given up on
+// retries instead
+func (n *Notifier) wait(h *hook, backoff time.Duration, err error) bool {
+	n.mu.Lock()
+	h.retries++
+	h.lastErr = err.Error()
+	n.mu.Unlock()
+	select {
+	case <-time.After(backoff):
+		return true
+	case <-n.abandon:
+		return false
+	}
+}
+
+// post sends a notification once, reporting whether a failure is worth
+// retrying: network errors, 408, 429 and 5xx are
+func (n *Notifier) post(h *hook, payload Payload, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "netty-daemon")
+	req.Header.Set("X-Netty-Event", payload.Event)
+	req.Header.Set("X-Netty-Delivery", payload.ID)
+	if h.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(h.Secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("%s notification to %s failed: %w", payload.Event, redact(h.URL), err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("%s notification to %s returned %s", payload.Event, redact(h.URL), resp.Status)
+}
+
+// Sign returns the signature header value for a body: "sha256=" and the hex
+// HMAC-SHA256 of the body keyed with secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// record counts a delivered or failed notification
+func (n *Notifier) record(h *hook, delivered bool, err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if delivered {
+		h.delivered++
+		return
+	}
+	h.failed++
+	h.lastErr = err.Error()
+}
+
+// Close stops taking notifications and waits for those queued to be
+// delivered, giving up on retries once ctx is done
+func (n *Notifier) Close(ctx context.Context) error {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return nil
+	}
+	n.closed = true
+	for _, h := range n.hooks {
+		close(h.queue)
+	}
+	n.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		n.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		close(n.abandon)
+		return fmt.Errorf("gave up on undelivered webhook notifications: %w", ctx.Err())
+	}
+}
+
+// GetStats returns each hook's delivery counts
+func (n *Notifier) GetStats() []map[string]interface{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	stats := make([]map[string]interface{}, 0, len(n.hooks))
+	for _, h := range n.hooks {
+		events := make([]string, 0, len(h.Rules))
+		for _, rule := range h.Rules {
+			events = append(events, rule.Event)
+		}
+		hookStats := map[string]interface{}{
+			"url":       redact(h.URL),
+			"events":    events,
+			"signed":    h.Secret != "",
+			"delivered": h.delivered,
+			"failed":    h.failed,
+			"dropped":   h.dropped,
+			"retries":   h.retries,
+			"queued":    len(h.queue),
+		}
+		if h.lastErr != "" {
+			hookStats["last_error"] = h.lastErr
+		}
+		stats = append(stats, hookStats)
+	}
+	return stats
+}
@@ -0,0 +1,195 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "webhooks.yaml")
+		os.WriteFile(path, []byte(content), 0o600)
+		return path
+	}
+
+	hooks, err := Load(write(`
+webhooks:
+  - url: https://hooks.example.com/netty/T0K3N
+    secret: s3cret
+    rules:
+      - event: new_conversation
+        cidr: 203.0.113.0/24
+      - event: new_conversation
+        cidr: 198.51.100.7
+      - event: bandwidth
+        bytes_per_sec: 1000000
+      - event: daemon_started
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	rules := hooks[0].Rules
+	if len(hooks) != 1 || len(rules) != 4 || hooks[0].Secret != "s3cret" {
+		t.Fatalf("Expected one hook with four rules, got %+v", hooks)
+	}
+	if rules[1].network.String() != "198.51.100.7/32" || rules[2].Direction != DirectionTotal {
+		t.Errorf("Expected an address as a /32 and total bandwidth by default, got %v and %q", rules[1].network, rules[2].Direction)
+	}
+
+	for _, bad := range []string{
+		"webhooks: []",
+		"webhooks:\n  - url: ftp://example.com\n    rules: [{event: daemon_started}]",
+		"webhooks:\n  - url: https://example.com\n    rules: [{event: port_scan}]",
+		"webhooks:\n  - url: https://example.com\n    rules: [{event: new_conversation, cidr: 10.0.0.0/33}]",
+		"webhooks:\n  - url: https://example.com\n    rules: [{event: bandwidth}]",
+		"webhooks:\n  - url: https://example.com\n    rules: [{event: bandwidth, bytes_per_sec: 10, direction: up}]",
+	} {
+		if _, err := Load(write(bad)); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+	if _, err := Load(write("webhooks:\n  - url: https://example.com/T0K3N\n    rules: []")); err == nil || strings.Contains(err.Error(), "T0K3N") {
+		t.Errorf("Expected an error leaving out the URL's path, got %v", err)
+	}
+}
+
+// receiver records the notifications POSTed to it, failing the first failures
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	payloads []Payload
+	headers  []http.Header
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts++
+	if r.failures > 0 {
+		r.failures--
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := io.ReadAll(req.Body)
+	var payload Payload
+	json.Unmarshal(body, &payload)
+	r.payloads = append(r.payloads, payload)
+	r.headers = append(r.headers, req.Header)
+	r.bodies = append(r.bodies, body)
+}
+
+func TestNotifier(t *testing.T) {
+	recv := &receiver{failures: 1}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	hook := Hook{URL: server.URL, Secret: "s3cret", Rules: []Rule{
+		{Event: EventNewConversation, CIDR: "203.0.113.0/24"},
+		{Event: EventBandwidth, BytesPerSec: 1000, Direction: DirectionIn},
+		{Event: EventDaemonStopped},
+	}}
+	if err := hook.validate(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	n := NewNotifier([]Hook{hook})
+
+	// Only the first packet of a conversation to a matching address fires
+	n.Observe(&models.NetworkEvent{ConversationID: "c1", SourceIP: "192.168.1.10", DestIP: "203.0.113.9", DestPort: 443, TransportProtocol: "TCP", TLSServerName: "example.com"})
+	n.Observe(&models.NetworkEvent{ConversationID: "c1", SourceIP: "203.0.113.9", DestIP: "192.168.1.10"})
+	n.Observe(&models.NetworkEvent{ConversationID: "c2", SourceIP: "192.168.1.10", DestIP: "198.51.100.1"})
+
+	// Bandwidth fires on the way up, again only after dropping back below
+	for _, rate := range []float64{500, 2000, 3000, 800, 1500} {
+		n.CheckBandwidth(models.ThroughputSummary{BytesInPerSec: rate, BytesOutPerSec: 5000})
+	}
+	n.Notify(EventDaemonStopped, map[string]string{"version": "test"})
+	n.Notify(EventDaemonStarted, nil)
+
+	// The first delivery fails and is retried after a second
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := n.Close(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	recv.mu.Lock()
+	defer recv.mu.Unlock()
+	var events []string
+	for _, payload := range recv.payloads {
+		events = append(events, payload.Event)
+	}
+	if strings.Join(events, ",") != "new_conversation,bandwidth,bandwidth,daemon_stopped" {
+		t.Fatalf("Expected a conversation, two bandwidth crossings and the stop, got %v", events)
+	}
+	if recv.attempts != 5 {
+		t.Errorf("Expected one retry, got %d attempts", recv.attempts)
+	}
+
+	data := recv.payloads[0].Data.(map[string]interface{})
+	if data["conversation_id"] != "c1" || data["hostname"] != "example.com" || recv.payloads[0].Rule.CIDR != "203.0.113.0/24" {
+		t.Errorf("Expected the conversation and the rule that matched it, got %+v", recv.payloads[0])
+	}
+	if rate := recv.payloads[1].Data.(map[string]interface{})["bytes_per_sec"]; rate != float64(2000) {
+		t.Errorf("Expected the incoming rate, got %v", rate)
+	}
+	header := recv.headers[0]
+	if header.Get(SignatureHeader) != Sign("s3cret", recv.bodies[0]) || header.Get("X-Netty-Event") != EventNewConversation || header.Get("X-Netty-Delivery") != recv.payloads[0].ID {
+		t.Errorf("Expected signed headers naming the event and delivery, got %v", header)
+	}
+	if !strings.HasPrefix(Sign("s3cret", []byte("{}")), "sha256=") {
+		t.Error("Expected the signature to name its algorithm")
+	}
+
+	stats := n.GetStats()[0]
+	if stats["delivered"] != uint64(4) || stats["retries"] != uint64(1) || stats["url"] != server.URL {
+		t.Errorf("Unexpected stats: %v", stats)
+	}
+}
+
+func TestNotifier_GivesUp(t *testing.T) {
+	recv := &receiver{failures: 100}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	rejected := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+	}))
+	defer rejected.Close()
+
+	n := NewNotifier([]Hook{
+		{URL: server.URL, Rules: []Rule{{Event: EventDaemonStopped}}},
+		{URL: rejected.URL, Rules: []Rule{{Event: EventDaemonStopped}}},
+	})
+	n.Notify(EventDaemonStopped, nil)
+
+	// Retries stop once the shutdown runs out of time
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := n.Close(ctx); err == nil {
+		t.Error("Expected undelivered notifications to be reported")
+	}
+	n.Notify(EventDaemonStopped, nil)
+
+	// Client errors aren't retried
+	deadline := time.Now().Add(time.Second)
+	for n.GetStats()[1]["failed"] == uint64(0) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if stats := n.GetStats()[1]; stats["failed"] != uint64(1) || stats["retries"] != uint64(0) {
+		t.Errorf("Expected a single failed attempt, got %v", stats)
+	}
+}
@@ -0,0 +1,86 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/iolloyd/netty/daemon/internal/alerts"
+)
+
+// SetAlertStore sets the store alerts are listed, acknowledged and resolved through
+func (s *Server) SetAlertStore(store *alerts.Store) {
+	s.alerts = store
+}
+
+// alertAction acknowledges or resolves an alert by ID
+func (s *Server) alertAction(action, id string) (alerts.Alert, error) {
+	if action == "resolve" {
+		return s.alerts.Resolve(id)
+	}
+	return s.alerts.Acknowledge(id)
+}
+
+// handleAlerts handles HTTP API requests to list alerts, optionally by ?status=
+func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	if s.alerts == nil {
+		http.Error(w, "Alerts not available", http.StatusNotFound)
+		return
+	}
+	status, err := alerts.ParseStatus(r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.alerts.List(status))
+}
+
+// handleAlertAction handles POST /api/alerts/acknowledge and /api/alerts/resolve with ?id=
+func (s *Server) handleAlertAction(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.alerts == nil {
+			http.Error(w, "Alerts not available", http.StatusNotFound)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		alert, err := s.alertAction(action, r.URL.Query().Get("id"))
+		if errors.Is(err, alerts.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(alert)
+	}
+}
+
+// handleAlertCommand handles the get_alerts, acknowledge_alert and resolve_alert commands.
+// Changes reach every client through the "alert" broadcast.
+func (c *Client) handleAlertCommand(cmdType string, data json.RawMessage) {
+	if c.server.alerts == nil {
+		return
+	}
+
+	if cmdType == "get_alerts" {
+		c.sendMessage("alerts", c.server.alerts.List(""))
+		return
+	}
+
+	var params struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return
+	}
+	action := "acknowledge"
+	if cmdType == "resolve_alert" {
+		action = "resolve"
+	}
+	c.server.alertAction(action, params.ID)
+}
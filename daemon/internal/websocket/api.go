@@ -0,0 +1,268 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// APIPrefix is where the versioned HTTP API is served. The unversioned /api
+// paths still answer for older clients but are deprecated.
+const APIPrefix = "/api/v1"
+
+// apiParam is a query parameter an API route accepts
+type apiParam struct {
+	name        string
+	kind        string // OpenAPI schema type: string, integer or boolean
+	description string
+}
+
+// apiRoute is one endpoint of the HTTP API, registered on the mux and
+// described in the OpenAPI document
+type apiRoute struct {
+	path    string // Under APIPrefix, {name} marking a path parameter
+	summary string
+	methods []string
+	params  []apiParam
+	handler func(s *Server) http.HandlerFunc
+}
+
+// apiRoutes lists every endpoint of the HTTP API
+var apiRoutes = []apiRoute{
+	{"/conversations", "Tracked conversations, filtered, sorted and paged; X-Total-Count holds the number matched", getOnly, []apiParam{
+		{"state", "string", "Only conversations in this state, e.g. ACTIVE or CLOSED"},
+		{"service", "string", "Only conversations of this service"},
+		{"remote", "string", "Only conversations whose remote end is this IP or in this CIDR"},
+		{"sort", "string", "Order: start, activity, bytes, packets, duration, remote or service"},
+		{"order", "string", "asc or desc"},
+		{"limit", "integer", "Most conversations returned"},
+		{"offset", "integer", "Matches skipped before the page"},
+	}, func(s *Server) http.HandlerFunc { return s.handleConversations }},
+	{"/conversations/summary", "Counts and totals across tracked conversations", getOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleConversationSummary }},
+	{"/conversations/groups", "Conversations grouped by service", getOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleServiceGroups }},
+	{"/conversations/matrix", "Bytes exchanged between local hosts and remote destinations", getOnly, []apiParam{
+		{"locals", "integer", "Most local hosts, 0 for all"},
+		{"remotes", "integer", "Most remote destinations, 0 for all"},
+	}, func(s *Server) http.HandlerFunc { return s.handleTrafficMatrix }},
+	{"/conversations/pcap", "A conversation's buffered packets as a pcap file", getOnly, []apiParam{
+		{"id", "string", "Conversation ID"},
+	}, func(s *Server) http.HandlerFunc { return s.handleConversationPcap }},
+	{"/conversations/{id}/packets", "A conversation's most recent packets", getOnly, []apiParam{
+		{"limit", "integer", "Most packets returned"},
+	}, func(s *Server) http.HandlerFunc { return s.handleConversationPackets }},
+	{"/pcap", "Packets captured from now on, streamed as a pcap file", getOnly, []apiParam{
+		{"duration", "string", "How long to capture, e.g. 30s (the default), up to 10m"},
+		{"filter", "string", "BPF filter, e.g. host 1.2.3.4"},
+	}, func(s *Server) http.HandlerFunc { return s.handleLivePcap }},
+	{"/encryption", "Encrypted and plaintext traffic totals", getOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleEncryption }},
+	{"/throughput", "Recent throughput samples", getOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleThroughput }},
+	{"/capture", "Whether capture is paused", getOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleCapture }},
+	{"/capture/pause", "Pause capture", postOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleCapture }},
+	{"/capture/resume", "Resume capture", postOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleCapture }},
+	{"/firewall/rules", "Preview firewall rules blocking a host", getOnly, []apiParam{
+		{"host", "string", "Host to block"},
+		{"protocol", "string", "Only this protocol"},
+		{"port", "integer", "Only this port"},
+		{"backend", "string", "Firewall to generate rules for"},
+	}, func(s *Server) http.HandlerFunc { return s.handleFirewallRules }},
+	{"/firewall/apply", "Apply firewall rules blocking a host", postOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleFirewallApply }},
+	{"/blocks", "Blocks placed by the rate-based blocker", getOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleBlocks }},
+	{"/summary", "The summary period in progress", getOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleSummary }},
+	{"/query", "Run a read-only SQL query over stored history", postOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleQuery }},
+	{"/history", "Stored conversations and sampled events", getOnly, []apiParam{
+		{"from", "string", "Start, an RFC 3339 time or a duration before now"},
+		{"to", "string", "End, an RFC 3339 time or a duration before now"},
+		{"host", "string", "Only those with this remote IP, hostname or server name"},
+		{"service", "string", "Only those of this service"},
+		{"limit", "integer", "Most conversations returned"},
+	}, func(s *Server) http.HandlerFunc { return s.handleHistory }},
+	{"/arp", "The ARP table, and the devices seen on the local network when tracked", getOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleARP }},
+	{"/leases", "DHCP clients with their addresses, hostnames and vendor classes", getOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleLeases }},
+	{"/services", "Devices advertising services over mDNS and SSDP, with their names and ports", getOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleServices }},
+	{"/ndp", "IPv6 neighbours", getOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleNDP }},
+	{"/alerts", "Alerts", getOnly, []apiParam{
+		{"status", "string", "Only alerts with this status"},
+	}, func(s *Server) http.HandlerFunc { return s.handleAlerts }},
+	{"/alerts/acknowledge", "Acknowledge an alert", postOnly, []apiParam{
+		{"id", "string", "Alert ID"},
+	}, func(s *Server) http.HandlerFunc { return s.handleAlertAction("acknowledge") }},
+	{"/alerts/resolve", "Resolve an alert", postOnly, []apiParam{
+		{"id", "string", "Alert ID"},
+	}, func(s *Server) http.HandlerFunc { return s.handleAlertAction("resolve") }},
+	{"/watch", "Watched hosts; POST watches a host and DELETE stops watching it", []string{http.MethodGet, http.MethodPost, http.MethodDelete}, []apiParam{
+		{"host", "string", "Host to watch or stop watching"},
+	}, func(s *Server) http.HandlerFunc { return s.handleWatch }},
+	{"/watch/activity", "Watched hosts' recent activity", getOnly, []apiParam{
+		{"host", "string", "Only this host"},
+		{"limit", "integer", "Most entries returned"},
+	}, func(s *Server) http.HandlerFunc { return s.handleWatchActivity }},
+	{"/beacons", "Connections recurring at regular intervals", getOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleBeacons }},
+	{"/certificates", "Server certificates seen in TLS handshakes, the soonest to expire first", getOnly, []apiParam{
+		{"expiring", "boolean", "Only certificates expiring within the alert window, or expired"},
+	}, func(s *Server) http.HandlerFunc { return s.handleCertificates }},
+	{"/endpoints", "Remote endpoints", getOnly, []apiParam{
+		{"ip", "string", "Only this endpoint"},
+		{"limit", "integer", "Most endpoints returned"},
+	}, func(s *Server) http.HandlerFunc { return s.handleEndpoints }},
+	{"/processes", "Traffic by local process", getOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleProcesses }},
+	{"/countries", "Traffic by country", getOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleCountries }},
+	{"/top", "Top talkers over a recent window", getOnly, []apiParam{
+		{"by", "string", "Group by host, port, service or process"},
+		{"window", "string", "Window as a duration, e.g. 5m"},
+		{"limit", "integer", "Most entries returned"},
+	}, func(s *Server) http.HandlerFunc { return s.handleTop }},
+	{"/hosts", "Traffic by remote host", getOnly, []apiParam{
+		{"ip", "string", "Only this host"},
+		{"sort", "string", "Order: bytes, last_seen or conversations"},
+		{"limit", "integer", "Most hosts returned"},
+	}, func(s *Server) http.HandlerFunc { return s.handleHosts }},
+	{"/domains", "Traffic by domain", getOnly, []apiParam{
+		{"day", "string", "Day as YYYY-MM-DD, today when empty"},
+		{"group", "boolean", "Group subdomains under their registered domain"},
+		{"match", "string", "Only domains containing this"},
+	}, func(s *Server) http.HandlerFunc { return s.handleDomains }},
+	{"/clients", "Connected WebSocket clients: address, connect time, subscriptions, messages sent and dropped", getOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleClients }},
+	{"/config", "The daemon's effective configuration", getOnly, nil,
+		func(s *Server) http.HandlerFunc { return s.handleConfig }},
+}
+
+var (
+	getOnly  = []string{http.MethodGet}
+	postOnly = []string{http.MethodPost}
+)
+
+// optionalRoutes are only served, and described, when what backs them is set
+var optionalRoutes = map[string]func(s *Server) bool{
+	"/query":  func(s *Server) bool { return s.queryer != nil },
+	"/blocks": func(s *Server) bool { return s.blocker != nil },
+}
+
+// serves reports whether the server has what a route needs
+func (s *Server) serves(route apiRoute) bool {
+	available, optional := optionalRoutes[route.path]
+	return !optional || available(s)
+}
+
+// pathParam matches a path parameter in a route
+var pathParam = regexp.MustCompile(`\{(\w+)\}`)
+
+// newMux returns the server's routes: /ws, /health, /metrics and the API under
+// APIPrefix, with the deprecated unversioned paths alongside
+func (s *Server) newMux() *http.ServeMux {
+	api := http.NewServeMux()
+	for _, route := range apiRoutes {
+		if s.serves(route) {
+			api.HandleFunc(route.path, s.guard(route.path, route.handler(s)))
+		}
+	}
+	api.HandleFunc("/openapi.json", s.handleOpenAPI)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", s.handleWebSocket)
+	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	mux.Handle(APIPrefix+"/", http.StripPrefix(APIPrefix, api))
+	legacy := http.StripPrefix("/api", api)
+	mux.Handle("/api/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+APIPrefix+strings.TrimPrefix(r.URL.Path, "/api")+`>; rel="successor-version"`)
+		legacy.ServeHTTP(w, r)
+	}))
+	return mux
+}
+
+// handleOpenAPI serves an OpenAPI 3 description of the API
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.openAPI())
+}
+
+// openAPI builds the OpenAPI document from apiRoutes
+func (s *Server) openAPI() map[string]interface{} {
+	paths := make(map[string]interface{}, len(apiRoutes))
+	for _, route := range apiRoutes {
+		if !s.serves(route) {
+			continue
+		}
+		var params []map[string]interface{}
+		for _, match := range pathParam.FindAllStringSubmatch(route.path, -1) {
+			params = append(params, map[string]interface{}{
+				"name": match[1], "in": "path", "required": true, "schema": map[string]string{"type": "string"},
+			})
+		}
+		for _, param := range route.params {
+			params = append(params, map[string]interface{}{
+				"name": param.name, "in": "query", "description": param.description, "schema": map[string]string{"type": param.kind},
+			})
+		}
+
+		operations := make(map[string]interface{}, len(route.methods))
+		for _, method := range route.methods {
+			operation := map[string]interface{}{
+				"summary":     route.summary,
+				"operationId": operationID(method, route.path),
+				"responses": map[string]interface{}{
+					"200":     map[string]string{"description": "OK"},
+					"default": map[string]string{"description": "Error, as plain text"},
+				},
+			}
+			if params != nil {
+				operation["parameters"] = params
+			}
+			operations[strings.ToLower(method)] = operation
+		}
+		paths[route.path] = operations
+	}
+
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]string{
+			"title":   "netty daemon API",
+			"version": "1",
+		},
+		"servers": []map[string]string{{"url": APIPrefix}},
+		"paths":   paths,
+	}
+	if s.apiToken != "" {
+		doc["components"] = map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"bearer": map[string]string{"type": "http", "scheme": "bearer"},
+			},
+		}
+		doc["security"] = []map[string][]string{{"bearer": {}}}
+	}
+	return doc
+}
+
+// operationID names an operation after its method and path, e.g.
+// getConversationsIdPackets for GET /conversations/{id}/packets
+func operationID(method, path string) string {
+	words := strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}'
+	})
+	id := strings.ToLower(method)
+	for _, word := range words {
+		id += strings.ToUpper(word[:1]) + word[1:]
+	}
+	return id
+}
@@ -0,0 +1,87 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iolloyd/netty/daemon/internal/blocker"
+)
+
+func TestNewMux_VersionedAndLegacyPaths(t *testing.T) {
+	s := NewServer("0")
+	s.SetBlocker(blocker.NewBlocker(blocker.Config{}, ""))
+	mux := s.newMux()
+
+	request := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	if rec := request("/api/v1/blocks"); rec.Code != http.StatusOK || rec.Header().Get("Deprecation") != "" {
+		t.Errorf("Expected the versioned path served, got %d %v", rec.Code, rec.Header())
+	}
+	rec := request("/api/blocks")
+	if rec.Code != http.StatusOK || rec.Header().Get("Deprecation") != "true" {
+		t.Errorf("Expected the legacy path served as deprecated, got %d %v", rec.Code, rec.Header())
+	}
+	if link := rec.Header().Get("Link"); link != `</api/v1/blocks>; rel="successor-version"` {
+		t.Errorf("Expected a link to the versioned path, got %q", link)
+	}
+	if rec := request("/api/v1/unknown"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected unknown paths not found, got %d", rec.Code)
+	}
+	if rec := request("/health"); rec.Code != http.StatusOK {
+		t.Errorf("Expected /health outside the API, got %d", rec.Code)
+	}
+}
+
+func TestHandleOpenAPI(t *testing.T) {
+	s := NewServer("0")
+	s.SetAPIToken("s3cret")
+	s.SetQueryer(fakeQueryer{})
+	s.SetBlocker(blocker.NewBlocker(blocker.Config{}, ""))
+	rec := httptest.NewRecorder()
+	s.newMux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+		Paths map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+			Parameters  []struct {
+				Name     string `json:"name"`
+				In       string `json:"in"`
+				Required bool   `json:"required"`
+			} `json:"parameters"`
+		} `json:"paths"`
+		Security []map[string][]string `json:"security"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&doc); err != nil {
+		t.Fatalf("Failed to decode the document: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" || len(doc.Servers) != 1 || doc.Servers[0].URL != APIPrefix {
+		t.Errorf("Expected an OpenAPI 3 document served from %s, got %+v", APIPrefix, doc)
+	}
+	if len(doc.Paths) != len(apiRoutes) {
+		t.Errorf("Expected every route described, got %d of %d", len(doc.Paths), len(apiRoutes))
+	}
+	if len(doc.Security) != 1 {
+		t.Errorf("Expected the bearer token required, got %+v", doc.Security)
+	}
+
+	packets, ok := doc.Paths["/conversations/{id}/packets"]["get"]
+	if !ok || packets.OperationID != "getConversationsIdPackets" || len(packets.Parameters) != 2 {
+		t.Fatalf("Expected the packets operation with its parameters, got %+v", packets)
+	}
+	if p := packets.Parameters[0]; p.Name != "id" || p.In != "path" || !p.Required {
+		t.Errorf("Expected the id path parameter first, got %+v", p)
+	}
+	if _, ok := doc.Paths["/watch"]["delete"]; !ok {
+		t.Errorf("Expected every method of a route described, got %+v", doc.Paths["/watch"])
+	}
+}
@@ -0,0 +1,38 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/iolloyd/netty/daemon/internal/arpwatch"
+)
+
+// SetARPWatcher sets the watcher whose IP to MAC bindings, and devices when
+// it tracks them, are served at /api/arp
+func (s *Server) SetARPWatcher(w *arpwatch.Watcher) {
+	s.arpWatcher = w
+}
+
+// tracksDevices reports whether the devices seen on the LAN are kept
+func (s *Server) tracksDevices() bool {
+	return s.arpWatcher != nil && s.arpWatcher.TracksDevices()
+}
+
+// handleARP handles HTTP API requests for the learned IP to MAC bindings and
+// the devices seen on the LAN
+func (s *Server) handleARP(w http.ResponseWriter, r *http.Request) {
+	if s.arpWatcher == nil {
+		http.Error(w, "ARP watching not enabled", http.StatusNotFound)
+		return
+	}
+
+	response := map[string]interface{}{
+		"gateway":  s.arpWatcher.Gateway(),
+		"bindings": s.arpWatcher.GetBindings(),
+	}
+	if s.tracksDevices() {
+		response["devices"] = s.arpWatcher.Devices()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,131 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/clock"
+)
+
+// mutatingCommands lists the WebSocket commands that change the daemon's
+// state, refused in read-only mode
+var mutatingCommands = map[string]bool{
+	"apply_firewall_rules": true,
+	"acknowledge_alert":    true,
+	"resolve_alert":        true,
+	"watch_host":           true,
+	"unwatch_host":         true,
+	"pause_capture":        true,
+	"resume_capture":       true,
+	"reset":                true,
+	"disconnect_client":    true,
+}
+
+// mutatingRequest reports whether an HTTP API request changes the daemon's
+// state. /query is a POST but only reads.
+func mutatingRequest(path, method string) bool {
+	return method != http.MethodGet && method != http.MethodHead && path != "/query"
+}
+
+// AuditEntry records one client command or API request
+type AuditEntry struct {
+	Time    string `json:"time"`
+	Remote  string `json:"remote"`
+	Via     string `json:"via"`     // websocket or http
+	Command string `json:"command"` // Command type, or method and API path
+	Allowed bool   `json:"allowed"` // False when refused in read-only mode
+}
+
+// auditLog appends AuditEntry lines to a file
+type auditLog struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// SetReadOnly refuses every command and API request that would change the
+// daemon's state, such as pausing the capture or applying firewall rules,
+// leaving clients only able to watch
+func (s *Server) SetReadOnly(on bool) {
+	s.readOnly = on
+}
+
+// SetAuditLog appends a JSON line to path for every client command and API
+// request: when, from where and whether it was allowed. Command arguments,
+// which may hold tokens, aren't recorded.
+func (s *Server) SetAuditLog(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	s.audit = &auditLog{file: file, enc: json.NewEncoder(file)}
+	return nil
+}
+
+// allow audits a command and reports whether the daemon carries it out
+func (s *Server) allow(remote, via, command string, mutates bool) bool {
+	allowed := !mutates || !s.readOnly
+	if !allowed {
+		log.Printf("[WARNING] Refused %s from %s: read-only mode", command, remote)
+	}
+	if s.audit != nil {
+		s.audit.write(AuditEntry{
+			Time:    clock.Now().Format(time.RFC3339),
+			Remote:  remote,
+			Via:     via,
+			Command: command,
+			Allowed: allowed,
+		})
+	}
+	return allowed
+}
+
+// allowCommand audits a WebSocket command, telling the client when it's refused
+func (c *Client) allowCommand(command string) bool {
+	remote := ""
+	if c.conn != nil {
+		remote = c.conn.RemoteAddr().String()
+	}
+	if c.server.allow(remote, "websocket", command, mutatingCommands[command]) {
+		return true
+	}
+	c.sendMessage("command_rejected", map[string]string{
+		"command": command,
+		"error":   "daemon is read-only",
+	})
+	return false
+}
+
+// guard audits the requests for an API path, refusing those that would
+// change the daemon's state in read-only mode
+func (s *Server) guard(path string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		command := r.Method + " " + APIPrefix + r.URL.Path
+		if !s.allow(r.RemoteAddr, "http", command, mutatingRequest(path, r.Method)) {
+			http.Error(w, "Daemon is read-only", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// write appends an entry, logging rather than failing the command when it can't
+func (a *auditLog) write(entry AuditEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.enc.Encode(entry); err != nil {
+		log.Printf("[WARNING] Failed to write audit log: %v", err)
+	}
+}
+
+// close closes the audit log file
+func (a *auditLog) close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
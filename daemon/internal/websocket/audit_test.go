@@ -0,0 +1,76 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadOnlyAndAuditLog(t *testing.T) {
+	capture := &fakeCapture{}
+	s := NewServer("0")
+	s.SetCaptureControl(capture)
+	s.SetReadOnly(true)
+	s.SetQueryer(fakeQueryer{})
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	if err := s.SetAuditLog(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	c := &Client{send: make(chan []byte, 4), server: s}
+
+	c.handleCommand([]byte(`{"type":"pause_capture"}`))
+	if _, paused := capture.Paused(); paused || len(c.send) != 1 || !strings.Contains(string(<-c.send), `"type":"command_rejected"`) {
+		t.Errorf("Expected pausing refused and the client told")
+	}
+	c.handleCommand([]byte(`{"type":"get_capture_state"}`))
+	if len(c.send) != 1 || !strings.Contains(string(<-c.send), `"type":"capture_state"`) {
+		t.Errorf("Expected reading the state allowed")
+	}
+
+	mux := s.newMux()
+	for target, want := range map[string]int{
+		"/api/v1/capture/pause": http.StatusForbidden,
+		"/api/v1/query":         http.StatusBadRequest, // Reads, though a POST
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, nil))
+		if rec.Code != want {
+			t.Errorf("POST %s: expected %d, got %d", target, want, rec.Code)
+		}
+	}
+	if !strings.Contains(strings.Join(s.capabilities(), " "), "read_only") {
+		t.Errorf("Expected read-only mode advertised, got %v", s.capabilities())
+	}
+
+	s.audit.close()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var entries []AuditEntry
+	for scanner := bufio.NewScanner(file); scanner.Scan(); {
+		var entry AuditEntry
+		json.Unmarshal(scanner.Bytes(), &entry)
+		entries = append(entries, entry)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("Expected every command and request audited, got %+v", entries)
+	}
+	if e := entries[0]; e.Command != "pause_capture" || e.Via != "websocket" || e.Allowed || e.Time == "" {
+		t.Errorf("Expected the refused pause first, got %+v", e)
+	}
+	if e := entries[1]; e.Command != "get_capture_state" || !e.Allowed {
+		t.Errorf("Expected the state request allowed, got %+v", e)
+	}
+	for _, e := range entries[2:] {
+		if e.Via != "http" || e.Allowed != (e.Command == "POST /api/v1/query") {
+			t.Errorf("Expected the API requests audited, got %+v", e)
+		}
+	}
+}
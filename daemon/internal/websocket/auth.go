@@ -0,0 +1,48 @@
+package websocket
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// SetAPIToken requires clients of /ws and /api/* to present token, either as
+// an "Authorization: Bearer <token>" header or, for browsers that can't set
+// headers on a WebSocket, a "token" query parameter. An empty token leaves the
+// API open. /health stays open for monitoring; it reports counts, not traffic.
+func (s *Server) SetAPIToken(token string) {
+	s.apiToken = token
+}
+
+// authenticate wraps the server's handlers, refusing requests to protected
+// paths that don't carry the API token
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.apiToken == "" || !protectedPath(r.URL.Path) || s.authorized(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		log.Printf("[WARNING] Refused unauthenticated request for %s from %s", r.URL.Path, r.RemoteAddr)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="netty"`)
+		http.Error(w, "Missing or invalid API token", http.StatusUnauthorized)
+	})
+}
+
+// authorized reports whether a request carries the API token
+func (s *Server) authorized(r *http.Request) bool {
+	token := r.URL.Query().Get("token")
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, value, _ := strings.Cut(header, " ")
+		if !strings.EqualFold(scheme, "Bearer") {
+			return false
+		}
+		token = strings.TrimSpace(value)
+	}
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.apiToken)) == 1
+}
+
+// protectedPath reports whether a path requires the API token
+func protectedPath(path string) bool {
+	return path == "/ws" || strings.HasPrefix(path, "/api/")
+}
@@ -0,0 +1,51 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthenticate(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+	s := NewServer("0")
+
+	tests := []struct {
+		name   string
+		target string
+		header string
+		want   int
+	}{
+		{"no token", "/api/conversations", "", http.StatusUnauthorized},
+		{"bearer token", "/api/conversations", "Bearer s3cret", http.StatusOK},
+		{"case-insensitive scheme", "/api/conversations", "bearer s3cret", http.StatusOK},
+		{"wrong token", "/api/conversations", "Bearer guess", http.StatusUnauthorized},
+		{"basic auth", "/api/conversations", "Basic czNjcmV0", http.StatusUnauthorized},
+		{"wrong header beats query", "/api/conversations?token=s3cret", "Bearer guess", http.StatusUnauthorized},
+		{"websocket without token", "/ws", "", http.StatusUnauthorized},
+		{"websocket query token", "/ws?token=s3cret", "", http.StatusOK},
+		{"health stays open", "/health", "", http.StatusOK},
+	}
+	for _, open := range []bool{true, false} {
+		if !open {
+			s.SetAPIToken("s3cret")
+		}
+		for _, tt := range tests {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			s.authenticate(mux).ServeHTTP(rec, req)
+
+			want := tt.want
+			if open {
+				want = http.StatusOK
+			}
+			if rec.Code != want {
+				t.Errorf("%s (token required %v): expected %d, got %d", tt.name, !open, want, rec.Code)
+			}
+		}
+	}
+}
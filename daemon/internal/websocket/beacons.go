@@ -0,0 +1,25 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/iolloyd/netty/daemon/internal/beacon"
+)
+
+// SetBeaconDetector sets the detector whose beacons are served at /api/beacons
+func (s *Server) SetBeaconDetector(d *beacon.Detector) {
+	s.beacons = d
+}
+
+// handleBeacons handles HTTP API requests for hosts connecting to a
+// destination at a regular interval, the most regular first
+func (s *Server) handleBeacons(w http.ResponseWriter, r *http.Request) {
+	if s.beacons == nil {
+		http.Error(w, "Beacon detection not enabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.beacons.Beacons())
+}
@@ -0,0 +1,42 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/certs"
+)
+
+// SetCertificateInventory sets the inventory whose certificates are served at /api/certificates
+func (s *Server) SetCertificateInventory(inv *certs.Inventory) {
+	s.certificates = inv
+}
+
+// handleCertificates handles HTTP API requests for the server certificates
+// seen, the soonest to expire first, e.g. /api/certificates?expiring=true
+func (s *Server) handleCertificates(w http.ResponseWriter, r *http.Request) {
+	if s.certificates == nil {
+		http.Error(w, "Certificate inventory not enabled", http.StatusNotFound)
+		return
+	}
+
+	list := s.certificates.Certificates()
+	if value := r.URL.Query().Get("expiring"); value != "" {
+		expiring, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid expiring", http.StatusBadRequest)
+			return
+		}
+		if expiring {
+			list = s.certificates.Expiring(time.Now())
+		}
+	}
+	if list == nil {
+		list = []certs.Certificate{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
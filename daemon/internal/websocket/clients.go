@@ -0,0 +1,100 @@
+package websocket
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync/atomic"
+)
+
+// ClientInfo describes a connected WebSocket client for /api/v1/clients
+type ClientInfo struct {
+	ID uint64 `json:"id"`
+	ClientQueue
+	Subscriptions []string `json:"subscriptions"` // Streams it is sent: events, and conversations once subscribed
+	MessagesSent  uint64   `json:"messages_sent"`
+	BytesSent     uint64   `json:"bytes_sent"`
+}
+
+// disconnectRequest is the data of a disconnect_client command
+type disconnectRequest struct {
+	Token string `json:"token"`
+	ID    uint64 `json:"id"`
+}
+
+// disconnectResult answers a disconnect_client command
+type disconnectResult struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// clientInfos returns every connected client, oldest connection first
+func (s *Server) clientInfos() []ClientInfo {
+	clients := s.connectedClients()
+	s.stream.mu.Lock()
+	subscribed := make(map[*Client]bool, len(s.stream.subscribers))
+	for client := range s.stream.subscribers {
+		subscribed[client] = true
+	}
+	s.stream.mu.Unlock()
+
+	infos := make([]ClientInfo, 0, len(clients))
+	for _, client := range clients {
+		info := ClientInfo{
+			ID:            client.id,
+			ClientQueue:   client.queue(),
+			Subscriptions: []string{"events"},
+			MessagesSent:  atomic.LoadUint64(&client.sentMessages),
+			BytesSent:     atomic.LoadUint64(&client.sentBytes),
+		}
+		if subscribed[client] {
+			info.Subscriptions = append(info.Subscriptions, "conversations")
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// handleClients handles HTTP API requests for the connected WebSocket clients
+func (s *Server) handleClients(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.clientInfos())
+}
+
+// handleDisconnectCommand handles the disconnect_client command, which needs
+// the admin token, answering with a "disconnect_result"
+func (c *Client) handleDisconnectCommand(data json.RawMessage) {
+	var req disconnectRequest
+	json.Unmarshal(data, &req)
+	result := c.server.disconnect(req)
+	c.sendMessage("disconnect_result", result)
+	if result.OK {
+		log.Printf("[INFO] Disconnected client %d on request of %s", req.ID, c.conn.RemoteAddr())
+	}
+}
+
+// disconnect closes the connection of the client a request names; its read
+// pump then unregisters it
+func (s *Server) disconnect(req disconnectRequest) disconnectResult {
+	if s.adminToken == "" {
+		return disconnectResult{Error: "disconnecting clients is disabled, start the daemon with -admin-token to enable it"}
+	}
+	if subtle.ConstantTimeCompare([]byte(req.Token), []byte(s.adminToken)) != 1 {
+		return disconnectResult{Error: "invalid token"}
+	}
+
+	s.mu.RLock()
+	var target *Client
+	for client := range s.clients {
+		if client.id == req.ID {
+			target = client
+		}
+	}
+	s.mu.RUnlock()
+	if target == nil || target.conn == nil {
+		return disconnectResult{Error: "no such client"}
+	}
+	target.conn.Close()
+	return disconnectResult{OK: true}
+}
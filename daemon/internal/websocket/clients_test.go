@@ -0,0 +1,81 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/iolloyd/netty/daemon/internal/conversation"
+)
+
+func TestClients_ListAndDisconnect(t *testing.T) {
+	s := NewServer("0")
+	s.SetConversationManager(conversation.NewManager("192.168.1.10"))
+	s.SetReset("s3cret", nil)
+	go s.run()
+	server := httptest.NewServer(s.newMux())
+	defer server.Close()
+
+	dial := func() *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		conn.ReadMessage() // hello
+		return conn
+	}
+	admin := dial()
+	defer admin.Close()
+	watcher := dial()
+	defer watcher.Close()
+	watcher.WriteJSON(map[string]string{"type": "subscribe_conversations"})
+	watcher.ReadMessage() // snapshot
+
+	var clients []ClientInfo
+	resp, err := http.Get(server.URL + "/api/v1/clients")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	json.NewDecoder(resp.Body).Decode(&clients)
+	resp.Body.Close()
+	if len(clients) != 2 || clients[0].ID != 1 || clients[1].ID != 2 {
+		t.Fatalf("Expected both clients, oldest first, got %+v", clients)
+	}
+	if c := clients[1]; strings.Join(c.Subscriptions, ",") != "events,conversations" || c.MessagesSent == 0 || c.BytesSent == 0 || c.Remote == "" {
+		t.Errorf("Expected the subscriber's stream and what it was sent, got %+v", c)
+	}
+
+	disconnect := func(data string) disconnectResult {
+		admin.WriteMessage(websocket.TextMessage, []byte(`{"type":"disconnect_client","data":`+data+`}`))
+		var reply struct {
+			Data disconnectResult `json:"data"`
+		}
+		admin.ReadJSON(&reply)
+		return reply.Data
+	}
+	if result := disconnect(`{"token":"guess","id":2}`); result.OK || result.Error != "invalid token" {
+		t.Errorf("Expected a wrong token refused, got %+v", result)
+	}
+	if result := disconnect(`{"token":"s3cret","id":9}`); result.OK {
+		t.Errorf("Expected an unknown client refused, got %+v", result)
+	}
+	if result := disconnect(`{"token":"s3cret","id":2}`); !result.OK {
+		t.Errorf("Expected the client disconnected, got %+v", result)
+	}
+	watcher.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		if _, _, err := watcher.ReadMessage(); err != nil {
+			break
+		}
+	}
+	for deadline := time.Now().Add(2 * time.Second); s.getClientCount() != 1 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if count := s.getClientCount(); count != 1 {
+		t.Errorf("Expected the disconnected client unregistered, %d left", count)
+	}
+}
@@ -0,0 +1,100 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgpackSubprotocol is the WebSocket subprotocol a client asks for to be sent
+// MessagePack binary frames instead of JSON text frames. The messages are the
+// same: a map of "type" and "data", with the JSON field names.
+const MsgpackSubprotocol = "netty.msgpack.v1"
+
+// maxPooledBuffer is the largest encoding buffer kept for reuse, so one huge
+// snapshot doesn't pin its memory
+const maxPooledBuffer = 1 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// envelope is how every message is sent, whatever the format
+type envelope struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// encodeMessage encodes a typed message as msgpack when binary, JSON otherwise
+func encodeMessage(binary bool, msgType string, payload interface{}) ([]byte, error) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBuffer {
+			bufferPool.Put(buf)
+		}
+	}()
+
+	message := envelope{Type: msgType, Data: payload}
+	if binary {
+		enc := msgpack.GetEncoder()
+		defer msgpack.PutEncoder(enc)
+		enc.Reset(buf)
+		enc.SetCustomStructTag("json")
+		if err := enc.Encode(message); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := json.NewEncoder(buf).Encode(message); err != nil {
+			return nil, err
+		}
+		// Encode ends with a newline Marshal doesn't add
+		buf.Truncate(buf.Len() - 1)
+	}
+	// The buffer goes back to the pool, the message to a client's queue
+	return append([]byte(nil), buf.Bytes()...), nil
+}
+
+// encoded returns the broadcast in a client's format, encoding it the first
+// time that format is asked for. Not safe for concurrent use.
+func (m *outbound) encoded(binary bool) ([]byte, error) {
+	target := &m.data
+	if binary {
+		target = &m.binary
+	}
+	if *target == nil {
+		data, err := encodeMessage(binary, m.msgType, m.payload)
+		if err != nil {
+			return nil, err
+		}
+		*target = data
+	}
+	return *target, nil
+}
+
+// encodeForClients encodes the broadcast in every format a client connected
+// with, so each is encoded once however many clients share it
+func (s *Server) encodeForClients(m *outbound) error {
+	if atomic.LoadInt32(&s.textClients) > 0 {
+		if _, err := m.encoded(false); err != nil {
+			return err
+		}
+	}
+	if atomic.LoadInt32(&s.binaryClients) > 0 {
+		if _, err := m.encoded(true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatClients returns the count of connected clients in a client's format
+func (s *Server) formatClients(c *Client) *int32 {
+	if c.binary {
+		return &s.binaryClients
+	}
+	return &s.textClients
+}
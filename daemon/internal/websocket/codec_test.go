@@ -0,0 +1,96 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/iolloyd/netty/daemon/internal/models"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestMsgpackClients(t *testing.T) {
+	s := NewServer("0")
+	go s.run()
+	server := httptest.NewServer(http.HandlerFunc(s.handleWebSocket))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	text, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer text.Close()
+	dialer := websocket.Dialer{Subprotocols: []string{MsgpackSubprotocol}}
+	binary, response, err := dialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer binary.Close()
+	if protocol := response.Header.Get("Sec-WebSocket-Protocol"); protocol != MsgpackSubprotocol {
+		t.Fatalf("Expected the msgpack subprotocol accepted, got %q", protocol)
+	}
+
+	// Past the hello each is registered, in its own format
+	if frame, _, err := text.ReadMessage(); err != nil || frame != websocket.TextMessage {
+		t.Fatalf("Expected a text hello, got frame %d (%v)", frame, err)
+	}
+	if frame, _, err := binary.ReadMessage(); err != nil || frame != websocket.BinaryMessage {
+		t.Fatalf("Expected a binary hello, got frame %d (%v)", frame, err)
+	}
+
+	s.Broadcast(&models.NetworkEvent{SourceIP: "192.168.1.10", DestIP: "203.0.113.7", DestPort: 443, Size: 1200, TransportProtocol: "TCP"})
+	type message struct {
+		Type string              `json:"type"`
+		Data models.NetworkEvent `json:"data"`
+	}
+
+	var fromText message
+	if _, data, err := text.ReadMessage(); err != nil || json.Unmarshal(data, &fromText) != nil {
+		t.Fatalf("Expected a JSON event, got %s (%v)", data, err)
+	}
+	var fromBinary message
+	frame, data, err := binary.ReadMessage()
+	if err != nil || frame != websocket.BinaryMessage {
+		t.Fatalf("Expected a binary event, got frame %d (%v)", frame, err)
+	}
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	if err := dec.Decode(&fromBinary); err != nil {
+		t.Fatalf("Expected a msgpack event, got %v", err)
+	}
+	if fromBinary.Type != "network_event" || fromBinary.Data.DestIP != "203.0.113.7" || fromBinary.Data.Size != 1200 {
+		t.Errorf("Expected the event in msgpack, got %+v", fromBinary)
+	}
+	if fromText.Data.DestIP != fromBinary.Data.DestIP || fromText.Data.TransportProtocol != fromBinary.Data.TransportProtocol {
+		t.Errorf("Expected the same event in both formats, got %+v and %+v", fromText.Data, fromBinary.Data)
+	}
+	if len(data) >= len(mustJSON(t, fromText)) {
+		t.Errorf("Expected msgpack smaller than JSON, got %d bytes", len(data))
+	}
+}
+
+func TestEncodeMessage_MatchesMarshal(t *testing.T) {
+	payload := map[string]interface{}{"html": "<b>", "n": 1}
+	data, err := encodeMessage(false, "test", payload)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := mustJSON(t, envelope{Type: "test", Data: payload})
+	if string(data) != string(want) {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return data
+}
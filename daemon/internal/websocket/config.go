@@ -0,0 +1,26 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// SetConfigFunction sets the function to retrieve the daemon's effective configuration
+func (s *Server) SetConfigFunction(fn func() map[string]interface{}) {
+	s.configFunc = fn
+}
+
+// handleConfig handles HTTP API requests for the effective capture configuration
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if s.configFunc == nil {
+		http.Error(w, "Configuration not available", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.configFunc())
+}
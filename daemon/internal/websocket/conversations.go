@@ -0,0 +1,63 @@
+package websocket
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/iolloyd/netty/daemon/internal/conversation"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// parseConversationQuery reads the filter, order and page of a request for
+// conversations
+func parseConversationQuery(values url.Values) (conversation.Query, error) {
+	query := conversation.Query{
+		State:   models.ConversationState(strings.ToUpper(values.Get("state"))),
+		Service: values.Get("service"),
+		Sort:    values.Get("sort"),
+	}
+	switch query.State {
+	case "", models.ConversationStateNew, models.ConversationStateEstablished, models.ConversationStateClosing, models.ConversationStateClosed:
+	default:
+		return query, fmt.Errorf("unknown state %q (available: NEW, ESTABLISHED, CLOSING, CLOSED)", values.Get("state"))
+	}
+
+	if remote := values.Get("remote"); remote != "" {
+		_, network, err := net.ParseCIDR(remote)
+		if err != nil {
+			// A single address is a network of one
+			ip := net.ParseIP(remote)
+			if ip == nil {
+				return query, fmt.Errorf("invalid remote %q, want an address or CIDR", remote)
+			}
+			bits := 8 * len(ip)
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+		}
+		query.Remote = network
+	}
+
+	switch values.Get("order") {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		return query, fmt.Errorf("invalid order %q, want asc or desc", values.Get("order"))
+	}
+
+	for name, target := range map[string]*int{"limit": &query.Limit, "offset": &query.Offset} {
+		if value := values.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return query, fmt.Errorf("invalid %s %q", name, value)
+			}
+			*target = n
+		}
+	}
+	return query, nil
+}
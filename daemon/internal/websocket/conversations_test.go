@@ -0,0 +1,41 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iolloyd/netty/daemon/internal/conversation"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func TestHandleConversations_Query(t *testing.T) {
+	mgr := conversation.NewManager("192.168.1.10")
+	for i, remote := range []string{"10.1.2.3", "10.1.2.4", "203.0.113.7"} {
+		mgr.ProcessEvent(&models.NetworkEvent{TransportProtocol: "UDP", SourceIP: "192.168.1.10", SourcePort: 50000 + i, DestIP: remote, DestPort: 53, Size: 100 * (i + 1)})
+	}
+	s := NewServer("0")
+	s.SetConversationManager(mgr)
+
+	request := func(query string) (*httptest.ResponseRecorder, []models.Conversation) {
+		rec := httptest.NewRecorder()
+		s.handleConversations(rec, httptest.NewRequest(http.MethodGet, "/api/conversations?"+query, nil))
+		var conversations []models.Conversation
+		json.NewDecoder(rec.Body).Decode(&conversations)
+		return rec, conversations
+	}
+
+	rec, page := request("remote=10.0.0.0/8&sort=bytes&order=desc&limit=1")
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Total-Count") != "2" || len(page) != 1 || page[0].Stats.BytesOut != 200 {
+		t.Errorf("Expected the busier of two 10/8 conversations, got %d with %s in all", rec.Code, rec.Header().Get("X-Total-Count"))
+	}
+	if rec, page := request("remote=203.0.113.7"); rec.Header().Get("X-Total-Count") != "1" || len(page) != 1 {
+		t.Errorf("Expected a single address to match its conversation, got %d", len(page))
+	}
+	for _, query := range []string{"state=OPEN", "remote=10.0.0", "order=up", "limit=-1", "offset=x", "sort=size"} {
+		if rec, _ := request(query); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s refused, got %d", query, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/iolloyd/netty/daemon/internal/geoip"
+)
+
+// SetGeoIP sets the GeoIP database locating remote addresses, enabling the
+// per-country totals at /api/countries
+func (s *Server) SetGeoIP(db *geoip.DB) {
+	s.geoip = db
+}
+
+// countriesEnabled reports whether conversations are located by country
+func (s *Server) countriesEnabled() bool {
+	return s.geoip != nil && s.convMgr != nil
+}
+
+// handleCountries handles HTTP API requests for traffic totals per country of
+// the remote end, busiest first
+func (s *Server) handleCountries(w http.ResponseWriter, r *http.Request) {
+	if !s.countriesEnabled() {
+		http.Error(w, "GeoIP not enabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.convMgr.GetCountryStats())
+}
@@ -0,0 +1,56 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// handleDomains handles HTTP API requests for the traffic per domain name,
+// e.g. /api/domains?day=today&group=true&match=*.googleapis.com
+func (s *Server) handleDomains(w http.ResponseWriter, r *http.Request) {
+	if s.convMgr == nil {
+		http.Error(w, "Conversation manager not initialized", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	group := false
+	if value := query.Get("group"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid group", http.StatusBadRequest)
+			return
+		}
+		group = parsed
+	}
+
+	report, err := s.convMgr.GetDomainStats(query.Get("day"), group, query.Get("match"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// handleDomainsCommand replies to a get_domains command, falling back to the
+// totals since accounting started for an invalid day
+func (c *Client) handleDomainsCommand(data json.RawMessage) {
+	if c.server.convMgr == nil {
+		return
+	}
+	var params struct {
+		Day   string `json:"day"`
+		Group bool   `json:"group"`
+		Match string `json:"match"`
+	}
+	json.Unmarshal(data, &params)
+
+	report, err := c.server.convMgr.GetDomainStats(params.Day, params.Group, params.Match)
+	if err != nil {
+		report, _ = c.server.convMgr.GetDomainStats("", params.Group, params.Match)
+	}
+	c.sendMessage("domains", report)
+}
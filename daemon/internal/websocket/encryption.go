@@ -0,0 +1,18 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// handleEncryption handles HTTP API requests for the encrypted and cleartext
+// share of the traffic, per service and per hour
+func (s *Server) handleEncryption(w http.ResponseWriter, r *http.Request) {
+	if s.convMgr == nil {
+		http.Error(w, "Conversation manager not initialized", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.convMgr.GetEncryptionStats())
+}
@@ -0,0 +1,47 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/iolloyd/netty/daemon/internal/inventory"
+)
+
+// SetInventory sets the endpoint inventory served at /api/endpoints
+func (s *Server) SetInventory(inv *inventory.Inventory) {
+	s.inventory = inv
+}
+
+// handleEndpoints handles HTTP API requests for the endpoint inventory: every
+// endpoint, most recently seen first (?limit=), or a single one (?ip=)
+func (s *Server) handleEndpoints(w http.ResponseWriter, r *http.Request) {
+	if s.inventory == nil {
+		http.Error(w, "Endpoint inventory not enabled", http.StatusNotFound)
+		return
+	}
+
+	if ip := r.URL.Query().Get("ip"); ip != "" {
+		endpoint, ok := s.inventory.Lookup(ip)
+		if !ok {
+			http.Error(w, "Endpoint not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(endpoint)
+		return
+	}
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.inventory.Endpoints(limit))
+}
@@ -0,0 +1,153 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/iolloyd/netty/daemon/internal/blocker"
+	"github.com/iolloyd/netty/daemon/internal/firewall"
+)
+
+// firewallRequest is the payload accepted by the firewall API and commands
+type firewallRequest struct {
+	firewall.Target
+	Backend string `json:"backend,omitempty"`
+	Confirm bool   `json:"confirm,omitempty"`
+}
+
+// firewallResponse describes generated rules and, when applied, the outcome
+type firewallResponse struct {
+	Backend firewall.Backend `json:"backend"`
+	Target  firewall.Target  `json:"target"`
+	Rules   []string         `json:"rules"`
+	Applied bool             `json:"applied"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// EnableFirewallApply allows clients to apply generated firewall rules on this
+// host. Rules are only applied while an API token is set, see SetAPIToken, so
+// nobody without the token can change the firewall.
+func (s *Server) EnableFirewallApply(enabled bool) {
+	s.firewallApply = enabled
+}
+
+// canApplyFirewall reports whether clients may apply firewall rules
+func (s *Server) canApplyFirewall() bool {
+	return s.firewallApply && s.apiToken != ""
+}
+
+// SetBlocker sets the rate-based blocker whose active blocks are reported at
+// /api/blocks, which isn't served without one
+func (s *Server) SetBlocker(b *blocker.Blocker) {
+	s.blocker = b
+}
+
+// buildFirewallResponse generates rules for the request and applies them if asked to
+func (s *Server) buildFirewallResponse(req firewallRequest, apply bool) (firewallResponse, int) {
+	backend, err := firewall.ParseBackend(req.Backend)
+	if err != nil {
+		return firewallResponse{Target: req.Target, Error: err.Error()}, http.StatusBadRequest
+	}
+
+	rules, err := firewall.GenerateRules(backend, req.Target)
+	resp := firewallResponse{Backend: backend, Target: req.Target}
+	if err != nil {
+		resp.Error = err.Error()
+		return resp, http.StatusBadRequest
+	}
+	for _, rule := range rules {
+		resp.Rules = append(resp.Rules, rule.String())
+	}
+
+	if !apply {
+		return resp, http.StatusOK
+	}
+	if !s.canApplyFirewall() {
+		resp.Error = "applying firewall rules is disabled (start the daemon with -allow-firewall and -api-token)"
+		return resp, http.StatusForbidden
+	}
+	if !req.Confirm {
+		resp.Error = "confirmation required: resend with \"confirm\": true"
+		return resp, http.StatusPreconditionRequired
+	}
+
+	if err := firewall.Apply(rules); err != nil {
+		log.Printf("[WARNING] Failed to apply firewall rules for %s: %v", req.Host, err)
+		resp.Error = err.Error()
+		return resp, http.StatusInternalServerError
+	}
+	log.Printf("[INFO] Applied %s firewall rules blocking %s", backend, req.Host)
+	resp.Applied = true
+	return resp, http.StatusOK
+}
+
+// handleFirewallRules handles HTTP requests to preview rules for a host
+func (s *Server) handleFirewallRules(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := firewallRequest{
+		Target: firewall.Target{
+			Host:     query.Get("host"),
+			Protocol: query.Get("protocol"),
+		},
+		Backend: query.Get("backend"),
+	}
+	if portStr := query.Get("port"); portStr != "" {
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			http.Error(w, "Invalid port", http.StatusBadRequest)
+			return
+		}
+		req.Port = port
+	}
+
+	resp, status := s.buildFirewallResponse(req, false)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleFirewallApply handles HTTP requests to apply rules for a host
+func (s *Server) handleFirewallApply(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req firewallRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	resp, status := s.buildFirewallResponse(req, true)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleFirewallCommand processes generate_firewall_rules and apply_firewall_rules commands
+func (c *Client) handleFirewallCommand(cmdType string, data json.RawMessage) {
+	var req firewallRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return
+	}
+
+	apply := cmdType == "apply_firewall_rules"
+	resp, _ := c.server.buildFirewallResponse(req, apply)
+
+	msgType := "firewall_rules"
+	if apply {
+		msgType = "firewall_result"
+	}
+	c.sendMessage(msgType, resp)
+}
+
+// handleBlocks handles HTTP API requests for blocks placed by the rate-based blocker
+func (s *Server) handleBlocks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.blocker.ActiveBlocks())
+}
@@ -0,0 +1,66 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/iolloyd/netty/daemon/internal/blocker"
+)
+
+func TestFirewallApply_NeedsFlagAndToken(t *testing.T) {
+	apply := func(s *Server, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/firewall/apply", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer s3cret")
+		rec := httptest.NewRecorder()
+		s.authenticate(http.HandlerFunc(s.handleFirewallApply)).ServeHTTP(rec, req)
+		return rec
+	}
+	target := `{"host": "203.0.113.7", "backend": "iptables"}`
+
+	s := NewServer("0")
+	if rec := apply(s, target); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected applying to be off by default, got %d", rec.Code)
+	}
+
+	// -allow-firewall alone isn't enough, anyone could reach an open API
+	s.EnableFirewallApply(true)
+	if rec := apply(s, target); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected applying to need an API token, got %d", rec.Code)
+	}
+	if s.canApplyFirewall() {
+		t.Error("Expected no firewall_apply capability without an API token")
+	}
+
+	// With both, requests still need the token and a confirmation
+	s.SetAPIToken("s3cret")
+	if rec := apply(s, target); rec.Code != http.StatusPreconditionRequired {
+		t.Errorf("Expected an unconfirmed request to be refused, got %d", rec.Code)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/firewall/apply", strings.NewReader(`{"host": "203.0.113.7", "confirm": true}`))
+	rec := httptest.NewRecorder()
+	s.authenticate(http.HandlerFunc(s.handleFirewallApply)).ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a request without the token to be refused, got %d", rec.Code)
+	}
+}
+
+func TestHandleBlocks(t *testing.T) {
+	request := func(s *Server) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.newMux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/blocks", nil))
+		return rec
+	}
+
+	// Without automatic blocking there's nothing to report
+	s := NewServer("0")
+	if rec := request(s); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected no blocks endpoint without a blocker, got %d", rec.Code)
+	}
+
+	s.SetBlocker(blocker.NewBlocker(blocker.Config{}, ""))
+	if rec := request(s); rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("Expected an empty list, got %d %q", rec.Code, rec.Body.String())
+	}
+}
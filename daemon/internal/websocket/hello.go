@@ -0,0 +1,105 @@
+package websocket
+
+import (
+	"github.com/iolloyd/netty/daemon/internal/version"
+)
+
+// hello describes the daemon to clients: sent when they connect, in reply to
+// a "hello" command and as part of /health
+func (s *Server) hello() map[string]interface{} {
+	return map[string]interface{}{
+		"version":      version.Version,
+		"protocol":     version.Protocol,
+		"capabilities": s.capabilities(),
+	}
+}
+
+// capabilities lists the optional features this daemon has enabled, so clients
+// can hide what it can't do
+func (s *Server) capabilities() []string {
+	// Any client may ask for binary frames, see MsgpackSubprotocol
+	capabilities := []string{"msgpack"}
+	if s.convMgr != nil {
+		capabilities = append(capabilities, "conversations", "service_groups", "traffic_matrix", "encryption_stats", "throughput", "conversation_stream")
+	}
+	if s.alerts != nil {
+		capabilities = append(capabilities, "alerts")
+	}
+	if s.hostWatcher != nil {
+		capabilities = append(capabilities, "watch")
+	}
+	if s.beacons != nil {
+		capabilities = append(capabilities, "beacons")
+	}
+	if s.certificates != nil {
+		capabilities = append(capabilities, "certificates")
+	}
+	if s.configFunc != nil {
+		capabilities = append(capabilities, "config")
+	}
+	if s.convMgr != nil && s.convMgr.PacketHistory() > 0 {
+		capabilities = append(capabilities, "conversation_packets")
+	}
+	if s.replay != nil {
+		capabilities = append(capabilities, "recent_events")
+	}
+	if s.packetHistory != nil {
+		capabilities = append(capabilities, "pcap_export")
+	}
+	if s.tap != nil {
+		capabilities = append(capabilities, "live_pcap")
+	}
+	if s.pcapOutputStatsFunc != nil {
+		capabilities = append(capabilities, "pcap_output")
+	}
+	if s.canApplyFirewall() {
+		capabilities = append(capabilities, "firewall_apply")
+	}
+	if s.blocker != nil {
+		capabilities = append(capabilities, "blocking")
+	}
+	if s.summarizer != nil {
+		capabilities = append(capabilities, "summaries")
+	}
+	if s.queryer != nil {
+		capabilities = append(capabilities, "query")
+	}
+	if s.historyStore != nil {
+		capabilities = append(capabilities, "history")
+	}
+	if s.arpWatcher != nil {
+		capabilities = append(capabilities, "arp_watch")
+	}
+	if s.tracksDevices() {
+		capabilities = append(capabilities, "devices")
+	}
+	if s.leases != nil {
+		capabilities = append(capabilities, "leases")
+	}
+	if s.services != nil {
+		capabilities = append(capabilities, "services")
+	}
+	if s.ndpMonitor != nil {
+		capabilities = append(capabilities, "ndp_watch")
+	}
+	if s.inventory != nil {
+		capabilities = append(capabilities, "endpoints")
+	}
+	if s.processesEnabled() {
+		capabilities = append(capabilities, "processes")
+	}
+	if s.countriesEnabled() {
+		capabilities = append(capabilities, "geoip")
+	}
+	if s.captureControl != nil {
+		capabilities = append(capabilities, "pause")
+	}
+	if s.resetEnabled() {
+		capabilities = append(capabilities, "reset")
+	}
+	if s.readOnly {
+		// Commands changing state are refused, whatever else is listed
+		capabilities = append(capabilities, "read_only")
+	}
+	return capabilities
+}
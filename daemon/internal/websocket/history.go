@@ -0,0 +1,51 @@
+package websocket
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/iolloyd/netty/daemon/internal/history"
+)
+
+// SetPacketHistory sets the per-conversation packet history served as pcap files
+func (s *Server) SetPacketHistory(h *history.History) {
+	s.packetHistory = h
+}
+
+// handleConversationPcap serves a conversation's buffered packets as a pcap file (?id=)
+func (s *Server) handleConversationPcap(w http.ResponseWriter, r *http.Request) {
+	if s.packetHistory == nil {
+		http.Error(w, "Packet history not enabled (start the daemon with -pcap-history)", http.StatusNotFound)
+		return
+	}
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "Missing conversation id", http.StatusBadRequest)
+		return
+	}
+
+	// Buffer the file so a failure can still be reported as an HTTP error
+	var pcap bytes.Buffer
+	count, err := s.packetHistory.WritePcap(&pcap, id)
+	if errors.Is(err, history.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("[WARNING] Failed to export conversation %s: %v", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	name := id
+	if len(name) > 8 {
+		name = name[:8]
+	}
+	w.Header().Set("Content-Type", "application/vnd.tcpdump.pcap")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"netty-%s.pcap\"", name))
+	w.Header().Set("X-Packet-Count", fmt.Sprintf("%d", count))
+	w.Write(pcap.Bytes())
+}
@@ -0,0 +1,61 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/iolloyd/netty/daemon/internal/hosts"
+)
+
+// handleHosts handles HTTP API requests for the tracked conversations grouped
+// by remote host: every host (?sort=bytes|last_seen|conversations&limit=), or
+// a single one (?ip=)
+func (s *Server) handleHosts(w http.ResponseWriter, r *http.Request) {
+	if s.convMgr == nil {
+		http.Error(w, "Conversation manager not initialized", http.StatusInternalServerError)
+		return
+	}
+	list := hosts.Group(s.convMgr.GetConversationSummaries())
+
+	query := r.URL.Query()
+	if ip := query.Get("ip"); ip != "" {
+		for _, host := range list {
+			if host.IP == ip {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(host)
+				return
+			}
+		}
+		http.Error(w, "Host not found", http.StatusNotFound)
+		return
+	}
+
+	if order := query.Get("sort"); order != "" {
+		if err := hosts.Sort(list, order); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		if limit > 0 && len(list) > limit {
+			list = list[:limit]
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// handleHostsCommand replies to a get_hosts command with every host, most traffic first
+func (c *Client) handleHostsCommand() {
+	if c.server.convMgr == nil {
+		return
+	}
+	c.sendMessage("hosts", hosts.Group(c.server.convMgr.GetConversationSummaries()))
+}
@@ -0,0 +1,56 @@
+package websocket
+
+import (
+	"errors"
+	"log"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// Keepalive defaults: each client is pinged every KeepaliveInterval, and one
+// that sends nothing back, not even a pong, for KeepaliveTimeout is dropped
+const (
+	KeepaliveInterval = 30 * time.Second
+	KeepaliveTimeout  = 75 * time.Second
+)
+
+// writeTimeout is how long a single write to a client may block before the
+// client is considered gone
+const writeTimeout = 10 * time.Second
+
+// SetKeepalive sets how often clients are pinged and how long one may stay
+// silent before it's dropped. The timeout should allow for a missed ping or
+// two. Call it before Start.
+func (s *Server) SetKeepalive(interval, timeout time.Duration) {
+	s.keepaliveInterval = interval
+	s.keepaliveTimeout = timeout
+}
+
+// extendDeadline gives the client another keepalive timeout to send something
+func (c *Client) extendDeadline() error {
+	return c.conn.SetReadDeadline(time.Now().Add(c.server.keepaliveTimeout))
+}
+
+// write sends one frame, failing if the client doesn't take it in time
+func (c *Client) write(frame int, data []byte) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	err := c.conn.WriteMessage(frame, data)
+	if isTimeout(err) {
+		c.reap("stopped reading")
+	}
+	return err
+}
+
+// reap counts and logs a client dropped for not responding; closing its
+// connection then unregisters it
+func (c *Client) reap(reason string) {
+	atomic.AddUint64(&c.server.reapedClients, 1)
+	log.Printf("Dropping unresponsive client %s: %s", c.conn.RemoteAddr(), reason)
+}
+
+// isTimeout reports whether a read or write failed by passing its deadline
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,52 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestKeepalive_ReapsSilentClients(t *testing.T) {
+	s := NewServer("0")
+	s.SetKeepalive(20*time.Millisecond, 150*time.Millisecond)
+	go s.run()
+	server := httptest.NewServer(http.HandlerFunc(s.handleWebSocket))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	// A client that keeps reading answers the pings; a half-open one never does
+	alive, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer alive.Close()
+	go func() {
+		for {
+			if _, _, err := alive.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	silent, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer silent.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadUint64(&s.reapedClients) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if reaped := atomic.LoadUint64(&s.reapedClients); reaped != 1 {
+		t.Fatalf("Expected the silent client reaped, got %d reaped", reaped)
+	}
+	if count := s.getClientCount(); count != 1 {
+		t.Errorf("Expected the responsive client kept, got %d clients", count)
+	}
+}
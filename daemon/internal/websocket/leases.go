@@ -0,0 +1,25 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/iolloyd/netty/daemon/internal/dhcp"
+)
+
+// SetLeaseTable sets the table of DHCP leases served at /api/leases
+func (s *Server) SetLeaseTable(t *dhcp.Table) {
+	s.leases = t
+}
+
+// handleLeases handles HTTP API requests for the DHCP clients seen, most
+// recently seen first
+func (s *Server) handleLeases(w http.ResponseWriter, r *http.Request) {
+	if s.leases == nil {
+		http.Error(w, "DHCP lease tracking not enabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.leases.Leases())
+}
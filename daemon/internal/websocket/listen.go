@@ -0,0 +1,88 @@
+package websocket
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+)
+
+// unixSocketMode leaves a Unix socket to root and the socket's group
+const unixSocketMode = 0660
+
+// SetListen sets where the server accepts connections: addr, a TCP host:port
+// ("" for none), and unixPath, a Unix domain socket ("" for none). NewServer
+// listens on the loopback interface only. Call it before Start.
+func (s *Server) SetListen(addr, unixPath string) {
+	s.addr = addr
+	s.unixSocket = unixPath
+}
+
+// listen opens the server's listeners, TCP first
+func (s *Server) listen() ([]net.Listener, error) {
+	var listeners []net.Listener
+	if s.addr != "" {
+		l, err := net.Listen("tcp", s.addr)
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	if s.unixSocket != "" {
+		l, err := listenUnix(s.unixSocket)
+		if err != nil {
+			for _, open := range listeners {
+				open.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	if len(listeners) == 0 {
+		return nil, fmt.Errorf("no address to listen on")
+	}
+	return listeners, nil
+}
+
+// listenUnix listens on a Unix domain socket, replacing one left behind by a
+// daemon that didn't shut down cleanly but not one still in use
+func listenUnix(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use by another process", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, unixSocketMode); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return l, nil
+}
+
+// serve serves HTTP on one listener. TLS covers TCP only; a Unix socket
+// never leaves the host.
+func (s *Server) serve(server *http.Server, l net.Listener) error {
+	if _, unix := l.(*net.UnixListener); unix {
+		log.Printf("WebSocket server listening on unix:%s", l.Addr())
+		return server.Serve(l)
+	}
+	if s.tlsConfig != nil {
+		log.Printf("WebSocket server listening on %s (TLS)", l.Addr())
+		return server.ServeTLS(l, "", "")
+	}
+	log.Printf("WebSocket server listening on %s", l.Addr())
+	return server.Serve(l)
+}
@@ -0,0 +1,85 @@
+package websocket
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// socketPath returns a Unix socket path short enough for the platform's limit
+func socketPath(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "netty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "netty.sock")
+}
+
+func TestStart_UnixSocket(t *testing.T) {
+	path := socketPath(t)
+	s := NewServer("0")
+	s.SetListen("", path)
+	go s.Start()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	var resp *http.Response
+	var err error
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if resp, err = client.Get("http://netty/health"); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("Expected /health served on the socket: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200, got %d", resp.StatusCode)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != unixSocketMode {
+		t.Errorf("Expected the socket restricted to %o, got %v %v", unixSocketMode, info.Mode(), err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the socket removed on shutdown, got %v", err)
+	}
+}
+
+func TestListenUnix_StaleAndInUse(t *testing.T) {
+	path := socketPath(t)
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+
+	l, err := listenUnix(path)
+	if err != nil {
+		t.Fatalf("Expected a stale socket replaced, got %v", err)
+	}
+	defer l.Close()
+	if _, err := listenUnix(path); err == nil {
+		t.Errorf("Expected a socket in use refused")
+	}
+
+	file := filepath.Join(filepath.Dir(path), "file")
+	os.WriteFile(file, nil, 0600)
+	if _, err := listenUnix(file); err == nil {
+		t.Errorf("Expected a regular file left alone")
+	}
+}
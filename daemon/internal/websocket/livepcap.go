@@ -0,0 +1,96 @@
+package websocket
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/gopacket/pcapgo"
+	"github.com/iolloyd/netty/daemon/internal/clock"
+	"github.com/iolloyd/netty/daemon/internal/tap"
+)
+
+// Live pcap windows: the default and the longest a download may run
+const (
+	DefaultLivePcapDuration = 30 * time.Second
+	MaxLivePcapDuration     = 10 * time.Minute
+)
+
+// livePcapFlushInterval bounds how long streamed packets wait in buffers, so
+// a download can be watched in Wireshark as it arrives
+const livePcapFlushInterval = time.Second
+
+// SetTap sets the tap live pcap downloads subscribe to
+func (s *Server) SetTap(t *tap.Tap) {
+	s.tap = t
+}
+
+// handleLivePcap streams the packets captured from now on as a pcap file, for
+// ?duration= (30s by default) and matching an optional BPF ?filter=. Packets
+// the download couldn't keep up with are counted in the X-Dropped-Packets
+// trailer. A shutdown ends the download early, so it doesn't wait out the
+// duration.
+func (s *Server) handleLivePcap(w http.ResponseWriter, r *http.Request) {
+	if s.tap == nil {
+		http.Error(w, "Live capture not available", http.StatusNotFound)
+		return
+	}
+	duration := DefaultLivePcapDuration
+	if value := r.URL.Query().Get("duration"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 || parsed > MaxLivePcapDuration {
+			http.Error(w, fmt.Sprintf("Invalid duration (up to %s)", MaxLivePcapDuration), http.StatusBadRequest)
+			return
+		}
+		duration = parsed
+	}
+	sub, err := s.tap.Subscribe(r.URL.Query().Get("filter"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "application/vnd.tcpdump.pcap")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="netty-%s.pcap"`, clock.Now().Format("20060102-150405")))
+	w.Header().Set("Trailer", "X-Dropped-Packets")
+	writer := pcapgo.NewWriter(w)
+	if err := writer.WriteFileHeader(uint32(s.tap.SnapLen()), s.tap.LinkType()); err != nil {
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	log.Printf("Streaming live pcap to %s for %s", r.RemoteAddr, duration)
+
+	written := 0
+	done := time.NewTimer(duration)
+	defer done.Stop()
+	flush := time.NewTicker(livePcapFlushInterval)
+	defer flush.Stop()
+stream:
+	for {
+		select {
+		case packet := <-sub.Packets():
+			if err := writer.WritePacket(packet.Info, packet.Data); err != nil {
+				return // The client went away
+			}
+			written++
+		case <-flush.C:
+			if flusher != nil {
+				flusher.Flush()
+			}
+		case <-done.C:
+			break stream
+		case <-s.stopping:
+			break stream
+		case <-r.Context().Done():
+			return
+		}
+	}
+	w.Header().Set("X-Dropped-Packets", strconv.FormatUint(sub.Dropped(), 10))
+	log.Printf("Streamed %d packets to %s (%d dropped)", written, r.RemoteAddr, sub.Dropped())
+}
@@ -0,0 +1,70 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/iolloyd/netty/daemon/internal/tap"
+)
+
+func TestHandleLivePcap(t *testing.T) {
+	s := NewServer("0")
+	request := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.newMux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+	if rec := request("/api/v1/pcap"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected no live capture without a tap, got %d", rec.Code)
+	}
+
+	packets := tap.New(layers.LinkTypeEthernet, 65535)
+	s.SetTap(packets)
+	for _, target := range []string{"/api/v1/pcap?duration=1h", "/api/v1/pcap?duration=x", "/api/v1/pcap?filter=not+a+(filter"} {
+		if rec := request(target); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", target, rec.Code)
+		}
+	}
+
+	// Offer packets until the download's window closes
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- request("/api/v1/pcap?duration=100ms") }()
+	var rec *httptest.ResponseRecorder
+	info := gopacket.CaptureInfo{Timestamp: time.Now(), CaptureLength: 4, Length: 4}
+	for rec == nil {
+		select {
+		case rec = <-done:
+		case <-time.After(5 * time.Millisecond):
+			packets.Add(info, []byte{1, 2, 3, 4})
+		}
+	}
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/vnd.tcpdump.pcap" {
+		t.Fatalf("Expected a pcap file, got %d %v", rec.Code, rec.Header())
+	}
+	if dropped := rec.Result().Trailer.Get("X-Dropped-Packets"); dropped != "0" {
+		t.Errorf("Expected nothing dropped, got %q", dropped)
+	}
+	reader, err := pcapgo.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("Failed to read the pcap: %v", err)
+	}
+	if reader.LinkType() != layers.LinkTypeEthernet {
+		t.Errorf("Expected Ethernet, got %v", reader.LinkType())
+	}
+	count := 0
+	for {
+		if _, _, err := reader.ReadPacketData(); err != nil {
+			break
+		}
+		count++
+	}
+	if count == 0 {
+		t.Error("Expected the packets captured during the window")
+	}
+}
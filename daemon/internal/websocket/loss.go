@@ -0,0 +1,157 @@
+package websocket
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/clock"
+)
+
+// LossReportInterval is how often each client is sent a loss_report
+const LossReportInterval = 5 * time.Second
+
+// outbound is a broadcast queued for every client, see publish
+type outbound struct {
+	data    []byte // As JSON, nil until a text client needs it
+	binary  []byte // As msgpack, nil until a binary client needs it
+	event   bool   // A network event, counted for loss accounting
+	msgType string // What the encodings are made from, see encoded
+	payload interface{}
+}
+
+// LossReport tells a client how much of the traffic it was sent, counting
+// everything dropped between the wire and its queue since it connected
+type LossReport struct {
+	Since          string  `json:"since"`           // When the client connected
+	Events         uint64  `json:"events"`          // Network events queued for the client
+	KernelDropped  uint64  `json:"kernel_dropped"`  // Packets the kernel dropped before capture read them
+	CaptureDropped uint64  `json:"capture_dropped"` // Events dropped from the full capture channel
+	ClientDropped  uint64  `json:"client_dropped"`  // Events dropped from this client's full queue
+	Completeness   float64 `json:"completeness"`    // Percent of events that reached the client
+}
+
+// clientLoss counts a client's deliveries, and the drops upstream of it when it connected
+type clientLoss struct {
+	since           time.Time
+	kernelBase      uint64
+	captureBase     uint64
+	events          uint64
+	droppedEvents   uint64
+	droppedMessages uint64
+}
+
+// SetLossFunction sets the function reporting the packets and events lost
+// before they reach the server
+func (s *Server) SetLossFunction(fn func() (kernelDropped, channelDropped uint64)) {
+	s.lossFunc = fn
+}
+
+// upstreamLoss returns the drops before events reach the server
+func (s *Server) upstreamLoss() (kernel, capture uint64) {
+	if s.lossFunc == nil {
+		return 0, 0
+	}
+	return s.lossFunc()
+}
+
+// newClientLoss starts loss accounting for a client connecting now
+func (s *Server) newClientLoss() clientLoss {
+	kernel, capture := s.upstreamLoss()
+	return clientLoss{
+		since:       time.Now(),
+		kernelBase:  kernel,
+		captureBase: capture,
+	}
+}
+
+// deliver queues a broadcast for the client. A full queue drops the message
+// and counts it, a slow client misses messages rather than being cut off.
+// It returns false once the client is closed.
+func (c *Client) deliver(msg outbound) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return false
+	}
+	data := msg.data
+	if c.binary {
+		data = msg.binary
+	}
+	select {
+	case c.send <- data:
+		if msg.event {
+			atomic.AddUint64(&c.loss.events, 1)
+		}
+	default:
+		if msg.event {
+			atomic.AddUint64(&c.loss.droppedEvents, 1)
+		} else {
+			atomic.AddUint64(&c.loss.droppedMessages, 1)
+		}
+	}
+	return true
+}
+
+// lossReport returns the client's loss counters since it connected
+func (c *Client) lossReport() LossReport {
+	kernel, capture := c.server.upstreamLoss()
+	report := LossReport{
+		Since:          clock.In(c.loss.since).Format(time.RFC3339),
+		Events:         atomic.LoadUint64(&c.loss.events),
+		KernelDropped:  delta(kernel, c.loss.kernelBase),
+		CaptureDropped: delta(capture, c.loss.captureBase),
+		ClientDropped:  atomic.LoadUint64(&c.loss.droppedEvents),
+		Completeness:   100,
+	}
+	// A kernel drop is a packet rather than an event, but most packets become one
+	lost := report.KernelDropped + report.CaptureDropped + report.ClientDropped
+	if total := report.Events + lost; total > 0 {
+		report.Completeness = 100 * float64(report.Events) / float64(total)
+	}
+	return report
+}
+
+// reportLoss sends every client its loss report each interval
+func (s *Server) reportLoss(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.RLock()
+		clients := make([]*Client, 0, len(s.clients))
+		for client := range s.clients {
+			clients = append(clients, client)
+		}
+		s.mu.RUnlock()
+
+		for _, client := range clients {
+			client.sendMessage("loss_report", client.lossReport())
+		}
+	}
+}
+
+// lossStats returns the drops across all clients for /health
+func (s *Server) lossStats() map[string]interface{} {
+	kernel, capture := s.upstreamLoss()
+	var clientEvents, clientMessages uint64
+	s.mu.RLock()
+	for client := range s.clients {
+		clientEvents += atomic.LoadUint64(&client.loss.droppedEvents)
+		clientMessages += atomic.LoadUint64(&client.loss.droppedMessages)
+	}
+	s.mu.RUnlock()
+	return map[string]interface{}{
+		"kernel_dropped":          kernel,
+		"capture_dropped":         capture,
+		"client_dropped_events":   clientEvents,
+		"client_dropped_messages": clientMessages,
+	}
+}
+
+// delta returns current minus base, 0 if the counter was reset in between
+func delta(current, base uint64) uint64 {
+	if current < base {
+		return 0
+	}
+	return current - base
+}
@@ -0,0 +1,33 @@
+package websocket
+
+import "testing"
+
+func TestLossReport(t *testing.T) {
+	s := NewServer("0")
+	kernel, capture := uint64(5), uint64(1)
+	s.SetLossFunction(func() (uint64, uint64) { return kernel, capture })
+	c := &Client{send: make(chan []byte, 2), server: s, loss: s.newClientLoss()}
+
+	// Drops before the client connected aren't its losses
+	kernel, capture = 7, 2
+	for i := 0; i < 3; i++ {
+		if !c.deliver(outbound{data: []byte("{}"), event: true}) {
+			t.Fatal("Expected an open client to accept deliveries")
+		}
+	}
+	c.deliver(outbound{data: []byte("{}")})
+
+	report := c.lossReport()
+	if report.Events != 2 || report.ClientDropped != 1 || report.KernelDropped != 2 || report.CaptureDropped != 1 {
+		t.Fatalf("Unexpected loss counters: %+v", report)
+	}
+	// 2 delivered out of 2 + 4 lost
+	if report.Completeness < 33.3 || report.Completeness > 33.4 {
+		t.Errorf("Expected about 33.3%% complete, got %v", report.Completeness)
+	}
+
+	c.closed = true
+	if c.deliver(outbound{data: []byte("{}"), event: true}) {
+		t.Error("Expected a closed client to refuse deliveries")
+	}
+}
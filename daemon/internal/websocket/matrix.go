@@ -0,0 +1,58 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/iolloyd/netty/daemon/internal/conversation"
+)
+
+// handleTrafficMatrix handles HTTP API requests for the bytes exchanged between
+// local hosts and remote destinations (?locals=&remotes= bound its size)
+func (s *Server) handleTrafficMatrix(w http.ResponseWriter, r *http.Request) {
+	if s.convMgr == nil {
+		http.Error(w, "Conversation manager not initialized", http.StatusInternalServerError)
+		return
+	}
+
+	locals, ok := matrixSize(r, "locals", conversation.DefaultMatrixLocals)
+	if !ok {
+		http.Error(w, "Invalid locals", http.StatusBadRequest)
+		return
+	}
+	remotes, ok := matrixSize(r, "remotes", conversation.DefaultMatrixRemotes)
+	if !ok {
+		http.Error(w, "Invalid remotes", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.convMgr.GetTrafficMatrix(locals, remotes))
+}
+
+// handleTrafficMatrixCommand replies to a get_traffic_matrix command
+func (c *Client) handleTrafficMatrixCommand(data json.RawMessage) {
+	if c.server.convMgr == nil {
+		return
+	}
+	params := struct {
+		Locals  int `json:"locals"`
+		Remotes int `json:"remotes"`
+	}{conversation.DefaultMatrixLocals, conversation.DefaultMatrixRemotes}
+	json.Unmarshal(data, &params)
+	c.sendMessage("traffic_matrix", c.server.convMgr.GetTrafficMatrix(params.Locals, params.Remotes))
+}
+
+// matrixSize parses a matrix dimension from the query, 0 meaning no limit
+func matrixSize(r *http.Request, name string, fallback int) (int, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, true
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		return 0, false
+	}
+	return parsed, true
+}
@@ -0,0 +1,78 @@
+package websocket
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// metricHelp describes the capture statistics scrapers most often alert on
+var metricHelp = map[string]string{
+	"kernel_dropped":        "Packets the kernel dropped before the capture read them",
+	"kernel_buffer_dropped": "Packets dropped for lack of room in the kernel's capture buffer",
+	"interface_dropped":     "Packets dropped by the interface or its driver",
+	"dropped_packets":       "Events dropped because the capture's event channel was full",
+	"total_packets":         "Packets captured",
+	"total_bytes":           "Bytes captured, as on the wire",
+	"decoder_panics":        "Payloads a protocol decoder panicked on and left undecoded",
+}
+
+// handleMetrics serves the capture statistics and client counts in the
+// Prometheus text format. Like /health it needs no token.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+	s.mu.RLock()
+	clients := len(s.clients)
+	s.mu.RUnlock()
+	writeMetric(&b, "netty_clients", "gauge", "Connected WebSocket clients", float64(clients))
+	writeMetric(&b, "netty_clients_reaped", "counter", "Clients disconnected after missing the pong/read deadline", float64(atomic.LoadUint64(&s.reapedClients)))
+
+	if s.statsFunc != nil {
+		stats := s.statsFunc()
+		keys := make([]string, 0, len(stats))
+		for key := range stats {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, ok := metricValue(stats[key])
+			if !ok {
+				continue
+			}
+			writeMetric(&b, "netty_capture_"+key, "untyped", metricHelp[key], value)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(b.String()))
+}
+
+// writeMetric writes one sample with its type, and help when there is any
+func writeMetric(b *strings.Builder, name, kind, help string, value float64) {
+	if help != "" {
+		fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	}
+	fmt.Fprintf(b, "# TYPE %s %s\n%s %v\n", name, kind, name, value)
+}
+
+// metricValue returns a statistic as a number, false for anything else
+func metricValue(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case bool:
+		if n {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,42 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleMetrics(t *testing.T) {
+	s := NewServer("0")
+	s.SetAPIToken("s3cret")
+	s.SetStatsFunction(func() map[string]interface{} {
+		return map[string]interface{}{
+			"kernel_buffer_dropped": uint64(5),
+			"interface_dropped":     uint64(2),
+			"packets_per_second":    12.5,
+			"capture_state":         "capturing",
+		}
+	})
+	rec := httptest.NewRecorder()
+	s.newMux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected /metrics open without a token, got %d", rec.Code)
+	}
+
+	body := rec.Body.String()
+	for _, line := range []string{
+		"netty_clients 0\n",
+		"# HELP netty_capture_kernel_buffer_dropped Packets dropped for lack of room in the kernel's capture buffer\n",
+		"netty_capture_kernel_buffer_dropped 5\n",
+		"netty_capture_interface_dropped 2\n",
+		"netty_capture_packets_per_second 12.5\n",
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected %q in the metrics, got:\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, "capture_state") {
+		t.Errorf("Expected only numeric statistics, got:\n%s", body)
+	}
+}
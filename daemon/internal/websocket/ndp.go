@@ -0,0 +1,27 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/iolloyd/netty/daemon/internal/ndp"
+)
+
+// SetNDPMonitor sets the monitor whose routers and neighbors are served at /api/ndp
+func (s *Server) SetNDPMonitor(m *ndp.Monitor) {
+	s.ndpMonitor = m
+}
+
+// handleNDP handles HTTP API requests for the IPv6 routers and neighbor table
+func (s *Server) handleNDP(w http.ResponseWriter, r *http.Request) {
+	if s.ndpMonitor == nil {
+		http.Error(w, "Neighbor discovery watching not enabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"routers":   s.ndpMonitor.GetRouters(),
+		"neighbors": s.ndpMonitor.GetNeighbors(),
+	})
+}
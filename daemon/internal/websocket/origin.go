@@ -0,0 +1,121 @@
+package websocket
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// SetAllowedOrigins lets web pages from these origins, such as
+// "https://dashboard.lan:3000", use the WebSocket and HTTP API from a browser.
+// "*" allows any origin and "null" pages opened from a file. Pages from the
+// daemon's own host:port are always allowed, and requests without an Origin
+// header, from anything but a browser, aren't affected.
+func (s *Server) SetAllowedOrigins(origins []string) error {
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		origin = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+		if origin != "*" && origin != "null" {
+			u, err := url.Parse(origin)
+			if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+				return fmt.Errorf("invalid origin %q (want scheme://host[:port])", origin)
+			}
+		}
+		allowed[origin] = true
+	}
+	s.allowedOrigins = allowed
+	return nil
+}
+
+// SetAllowedHosts adds host names the daemon may be reached by, such as
+// "netty.lan", to IP addresses, localhost, this machine's hostname and the
+// -listen host, which are always allowed. See hostAllowed.
+func (s *Server) SetAllowedHosts(hosts []string) {
+	allowed := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		if host = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), ".")); host != "" {
+			allowed[host] = true
+		}
+	}
+	s.allowedHosts = allowed
+}
+
+// hostAllowed reports whether a request names the daemon by a host it can
+// be trusted to be. A page on a domain its owner rebinds to 127.0.0.1 sends
+// that domain as both Origin and Host, passing for same-origin, so the Host
+// must be one the daemon knows: an IP address, which can't be rebound,
+// localhost, which browsers resolve themselves, this machine's name, the
+// -listen host or one given to SetAllowedHosts. Unix socket requests never
+// come from a browser.
+func (s *Server) hostAllowed(r *http.Request) bool {
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok && addr.Network() == "unix" {
+		return true
+	}
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(strings.Trim(host, "[]"), "."))
+	if net.ParseIP(host) != nil || host == "localhost" || strings.HasSuffix(host, ".localhost") || s.allowedHosts[host] {
+		return true
+	}
+	if listen, _, err := net.SplitHostPort(s.addr); err == nil && strings.EqualFold(listen, host) {
+		return true
+	}
+	if name, err := os.Hostname(); err == nil && name != "" {
+		name = strings.ToLower(name)
+		return host == name || host == name+".local"
+	}
+	return false
+}
+
+// originAllowed reports whether a request may come from the page that made it
+func (s *Server) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || s.allowedOrigins["*"] || s.allowedOrigins[strings.ToLower(origin)] {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// cors wraps the server's handlers, refusing requests for hosts the daemon
+// doesn't answer to and from pages whose origin isn't allowed, which a
+// browser would otherwise still send, and telling browsers which
+// cross-origin responses a page may read
+func (s *Server) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Checked with or without an Origin: browsers leave it off same-origin GETs
+		if !s.hostAllowed(r) {
+			log.Printf("[WARNING] Refused request for %s to host %s", r.URL.Path, r.Host)
+			http.Error(w, "Host not allowed", http.StatusForbidden)
+			return
+		}
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !s.originAllowed(r) {
+			log.Printf("[WARNING] Refused request for %s from origin %s", r.URL.Path, origin)
+			http.Error(w, "Origin not allowed", http.StatusForbidden)
+			return
+		}
+
+		header := w.Header()
+		header.Set("Access-Control-Allow-Origin", origin)
+		header.Add("Vary", "Origin")
+		header.Set("Access-Control-Expose-Headers", "X-Total-Count, Deprecation, Link")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", "GET, POST, DELETE")
+			header.Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,112 @@
+package websocket
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/iolloyd/netty/daemon/internal/blocker"
+)
+
+func TestCORS(t *testing.T) {
+	s := NewServer("0")
+	s.SetBlocker(blocker.NewBlocker(blocker.Config{}, ""))
+	s.SetAllowedHosts([]string{"netty.lan"})
+	if err := s.SetAllowedOrigins([]string{"https://Dashboard.lan:3000/", "null"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	handler := s.cors(s.newMux())
+
+	tests := []struct {
+		name   string
+		method string
+		origin string
+		want   int
+		allow  string
+	}{
+		{"no origin", http.MethodGet, "", http.StatusOK, ""},
+		{"same host", http.MethodGet, "http://netty.lan:8080", http.StatusOK, "http://netty.lan:8080"},
+		{"allowed", http.MethodGet, "https://dashboard.lan:3000", http.StatusOK, "https://dashboard.lan:3000"},
+		{"file", http.MethodGet, "null", http.StatusOK, "null"},
+		{"other page", http.MethodGet, "https://evil.example", http.StatusForbidden, ""},
+		{"other page posting", http.MethodPost, "https://evil.example", http.StatusForbidden, ""},
+		{"preflight", http.MethodOptions, "https://dashboard.lan:3000", http.StatusNoContent, "https://dashboard.lan:3000"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "http://netty.lan:8080/api/v1/blocks", nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		if tt.method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want || rec.Header().Get("Access-Control-Allow-Origin") != tt.allow {
+			t.Errorf("%s: expected %d allowing %q, got %d allowing %q", tt.name, tt.want, tt.allow, rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+		}
+	}
+
+	if err := s.SetAllowedOrigins([]string{"dashboard.lan"}); err == nil {
+		t.Error("Expected an origin without a scheme rejected")
+	}
+	s.SetAllowedOrigins([]string{"*"})
+	req := httptest.NewRequest(http.MethodGet, "http://netty.lan:8080/ws", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	if !s.originAllowed(req) {
+		t.Error("Expected * to allow any origin")
+	}
+}
+
+func TestHostAllowed(t *testing.T) {
+	s := NewServer("8080")
+	s.SetBlocker(blocker.NewBlocker(blocker.Config{}, ""))
+	s.SetListen("nas.example:8080", "")
+	s.SetAllowedHosts([]string{" Netty.LAN. "})
+	handler := s.cors(s.newMux())
+	hostname, _ := os.Hostname()
+
+	tests := []struct {
+		host   string
+		origin string
+		want   int
+	}{
+		{"127.0.0.1:8080", "", http.StatusOK},
+		{"[::1]:8080", "", http.StatusOK},
+		{"192.168.1.10:8080", "", http.StatusOK},
+		{"localhost:8080", "", http.StatusOK},
+		{"app.localhost:8080", "", http.StatusOK},
+		{"netty.lan:8080", "", http.StatusOK},
+		{"nas.example:8080", "", http.StatusOK},
+		{hostname + ":8080", "", http.StatusOK},
+		// A rebound domain fails with or without a matching Origin: browsers leave it off same-origin GETs
+		{"evil.example:8080", "", http.StatusForbidden},
+		{"evil.example:8080", "http://evil.example:8080", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if tt.host == ":8080" {
+			continue // No hostname to test
+		}
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/blocks", nil)
+		req.Host = tt.host
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("Host %s, origin %q: expected %d, got %d", tt.host, tt.origin, tt.want, rec.Code)
+		}
+	}
+
+	// Unix socket requests never come from a browser
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/blocks", nil)
+	req.Host = "evil.example"
+	req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, &net.UnixAddr{Name: "/run/netty.sock", Net: "unix"}))
+	if !s.hostAllowed(req) {
+		t.Error("Expected a request over the Unix socket allowed")
+	}
+}
@@ -0,0 +1,65 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// conversationPackets is a conversation's recent packet history
+type conversationPackets struct {
+	ID      string                `json:"id"`
+	Found   bool                  `json:"found"` // False once the conversation is no longer tracked
+	Packets []models.NetworkEvent `json:"packets"`
+}
+
+// handleConversationPacketsCommand answers get_conversation_packets with the
+// conversation's latest packets, up to an optional limit
+func (c *Client) handleConversationPacketsCommand(data json.RawMessage) {
+	if c.server.convMgr == nil {
+		return
+	}
+	var params struct {
+		ID    string `json:"id"`
+		Limit int    `json:"limit"`
+	}
+	json.Unmarshal(data, &params)
+	packets, found := c.server.convMgr.GetConversationPackets(params.ID, params.Limit)
+	if packets == nil {
+		packets = []models.NetworkEvent{}
+	}
+	c.sendMessage("conversation_packets", conversationPackets{ID: params.ID, Found: found, Packets: packets})
+}
+
+// handleConversationPackets handles HTTP API requests for
+// /api/v1/conversations/{id}/packets, with an optional ?limit=
+func (s *Server) handleConversationPackets(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.convMgr == nil || s.convMgr.PacketHistory() <= 0 {
+		http.Error(w, "Packet history not enabled (start the daemon with -packet-history)", http.StatusNotFound)
+		return
+	}
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	packets, found := s.convMgr.GetConversationPackets(id, limit)
+	if !found {
+		http.Error(w, "Conversation not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(packets)
+}
@@ -0,0 +1,45 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iolloyd/netty/daemon/internal/conversation"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func TestHandleConversationPackets(t *testing.T) {
+	mgr := conversation.NewManager("192.168.1.10")
+	for port := 1; port <= 3; port++ {
+		mgr.ProcessEvent(&models.NetworkEvent{
+			TransportProtocol: "UDP", SourceIP: "192.168.1.10", SourcePort: 53000,
+			DestIP: "192.168.1.1", DestPort: 53, Size: 60 + port,
+		})
+	}
+	id := mgr.GetConversationSummaries()[0].ID
+	s := NewServer("0")
+	s.SetConversationManager(mgr)
+
+	request := func(target string) (int, []models.NetworkEvent) {
+		rec := httptest.NewRecorder()
+		s.newMux().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var packets []models.NetworkEvent
+		json.NewDecoder(rec.Body).Decode(&packets)
+		return rec.Code, packets
+	}
+
+	if code, packets := request("/api/v1/conversations/" + id + "/packets?limit=2"); code != http.StatusOK || len(packets) != 2 || packets[1].Size != 63 {
+		t.Errorf("Expected the latest two packets, got %d %+v", code, packets)
+	}
+	if code, _ := request("/api/v1/conversations/unknown/packets"); code != http.StatusNotFound {
+		t.Errorf("Expected an unknown conversation to be not found, got %d", code)
+	}
+	if code, _ := request("/api/v1/conversations/" + id + "/other"); code != http.StatusNotFound {
+		t.Errorf("Expected other paths to be not found, got %d", code)
+	}
+	if code, _ := request("/api/v1/conversations/" + id + "/packets?limit=x"); code != http.StatusBadRequest {
+		t.Errorf("Expected a bad limit to be rejected, got %d", code)
+	}
+}
@@ -0,0 +1,98 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/clock"
+)
+
+// CaptureControl pauses and resumes the packet capture
+type CaptureControl interface {
+	Pause() bool
+	Resume() bool
+	Paused() (time.Time, bool)
+}
+
+// captureState is what clients are told about a pause
+type captureState struct {
+	Paused bool       `json:"paused"`
+	Since  *time.Time `json:"since,omitempty"` // When the capture paused
+}
+
+// SetCaptureControl lets clients pause and resume the capture
+func (s *Server) SetCaptureControl(control CaptureControl) {
+	s.captureControl = control
+}
+
+// captureState returns whether the capture is paused
+func (s *Server) captureState() captureState {
+	since, paused := s.captureControl.Paused()
+	if !paused {
+		return captureState{}
+	}
+	since = clock.In(since)
+	return captureState{Paused: true, Since: &since}
+}
+
+// setPaused pauses or resumes the capture, telling every client when that
+// changed anything, and returns the resulting state
+func (s *Server) setPaused(paused bool) captureState {
+	var changed bool
+	if paused {
+		changed = s.captureControl.Pause()
+	} else {
+		changed = s.captureControl.Resume()
+	}
+	state := s.captureState()
+	if changed {
+		s.BroadcastMessage("capture_state", state)
+	}
+	return state
+}
+
+// handleCaptureCommand handles the pause_capture, resume_capture and
+// get_capture_state commands. Changes reach every client as "capture_state";
+// a command that changed nothing is answered to its sender alone.
+func (c *Client) handleCaptureCommand(command string) {
+	if c.server.captureControl == nil {
+		return
+	}
+	switch command {
+	case "pause_capture", "resume_capture":
+		before := c.server.captureState().Paused
+		state := c.server.setPaused(command == "pause_capture")
+		if state.Paused == before {
+			c.sendMessage("capture_state", state)
+		}
+	default:
+		c.sendMessage("capture_state", c.server.captureState())
+	}
+}
+
+// handleCapture handles HTTP API requests for the capture's pause state: GET
+// /api/v1/capture reports it, POST /api/v1/capture/pause and
+// /api/v1/capture/resume change it
+func (s *Server) handleCapture(w http.ResponseWriter, r *http.Request) {
+	if s.captureControl == nil {
+		http.Error(w, "Capture control not available", http.StatusNotFound)
+		return
+	}
+
+	var state captureState
+	switch {
+	case r.URL.Path == "/capture" && r.Method == http.MethodGet:
+		state = s.captureState()
+	case r.URL.Path == "/capture/pause" && r.Method == http.MethodPost:
+		state = s.setPaused(true)
+	case r.URL.Path == "/capture/resume" && r.Method == http.MethodPost:
+		state = s.setPaused(false)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
@@ -0,0 +1,69 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeCapture stands in for the packet capture
+type fakeCapture struct {
+	since time.Time
+}
+
+func (f *fakeCapture) Pause() bool {
+	if !f.since.IsZero() {
+		return false
+	}
+	f.since = time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	return true
+}
+
+func (f *fakeCapture) Resume() bool {
+	if f.since.IsZero() {
+		return false
+	}
+	f.since = time.Time{}
+	return true
+}
+
+func (f *fakeCapture) Paused() (time.Time, bool) {
+	return f.since, !f.since.IsZero()
+}
+
+func TestHandleCapture(t *testing.T) {
+	s := NewServer("0")
+	s.SetCaptureControl(&fakeCapture{})
+	c := &Client{send: make(chan []byte, 4), server: s}
+	s.clients[c] = true
+
+	request := func(method, path string) (int, captureState) {
+		rec := httptest.NewRecorder()
+		s.newMux().ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		var state captureState
+		json.NewDecoder(rec.Body).Decode(&state)
+		return rec.Code, state
+	}
+
+	if code, state := request(http.MethodPost, "/api/v1/capture/pause"); code != http.StatusOK || !state.Paused || state.Since == nil {
+		t.Errorf("Expected the capture paused, got %d %+v", code, state)
+	}
+	if len(c.send) != 1 {
+		t.Errorf("Expected clients told about the pause, got %d messages", len(c.send))
+	}
+	// Pausing again changes nothing and tells no one
+	if _, state := request(http.MethodPost, "/api/v1/capture/pause"); !state.Paused || len(c.send) != 1 {
+		t.Errorf("Expected a second pause to change nothing, got %+v", state)
+	}
+	if _, state := request(http.MethodGet, "/api/v1/capture"); !state.Paused {
+		t.Errorf("Expected the state reported paused, got %+v", state)
+	}
+	if _, state := request(http.MethodPost, "/api/v1/capture/resume"); state.Paused || state.Since != nil {
+		t.Errorf("Expected the capture resumed, got %+v", state)
+	}
+	if code, _ := request(http.MethodGet, "/api/v1/capture/pause"); code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected pausing to need POST, got %d", code)
+	}
+}
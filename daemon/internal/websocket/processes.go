@@ -0,0 +1,31 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/iolloyd/netty/daemon/internal/process"
+)
+
+// SetProcessTable sets the table attributing conversations to local programs,
+// enabling the per-process totals at /api/processes
+func (s *Server) SetProcessTable(table *process.Table) {
+	s.processes = table
+}
+
+// processesEnabled reports whether conversations are attributed to programs
+func (s *Server) processesEnabled() bool {
+	return s.processes != nil && s.convMgr != nil
+}
+
+// handleProcesses handles HTTP API requests for traffic totals per local
+// program, busiest first
+func (s *Server) handleProcesses(w http.ResponseWriter, r *http.Request) {
+	if !s.processesEnabled() {
+		http.Error(w, "Process attribution not enabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.convMgr.GetProcessStats())
+}
@@ -0,0 +1,50 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/iolloyd/netty/daemon/internal/query"
+)
+
+type queryRequest struct {
+	SQL   string `json:"sql"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+// SetQueryer sets the history store that /api/query runs against. Without
+// one the endpoint isn't served; call it before Start.
+func (s *Server) SetQueryer(q query.Queryer) {
+	s.queryer = q
+}
+
+// handleQuery handles ad-hoc read-only SQL queries over stored history
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req queryRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := query.Validate(req.SQL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), query.Timeout)
+	defer cancel()
+
+	result, err := s.queryer.Query(ctx, req.SQL, query.ClampLimit(req.Limit))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,52 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/iolloyd/netty/daemon/internal/query"
+)
+
+// fakeQueryer answers every query with one row echoing the SQL and limit
+type fakeQueryer struct{}
+
+func (fakeQueryer) Query(ctx context.Context, sql string, limit int) (query.Result, error) {
+	return query.Result{Columns: []string{"sql", "limit"}, Rows: [][]interface{}{{sql, limit}}}, nil
+}
+
+func TestHandleQuery(t *testing.T) {
+	s := NewServer("0")
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.newMux().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/query", strings.NewReader(body)))
+		return rec
+	}
+
+	// Without a history store there is no endpoint to query
+	if rec := post(`{"sql": "SELECT 1"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected no endpoint without a history store, got %d", rec.Code)
+	}
+	if _, described := s.openAPI()["paths"].(map[string]interface{})["/query"]; described {
+		t.Error("Expected the endpoint left out of the OpenAPI document")
+	}
+
+	s.SetQueryer(fakeQueryer{})
+	rec := post(`{"sql": "SELECT id FROM conversations", "limit": 5}`)
+	var result query.Result
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Expected a result, got %d (%v)", rec.Code, err)
+	}
+	if len(result.Rows) != 1 || result.Rows[0][0] != "SELECT id FROM conversations" || result.Rows[0][1] != float64(5) {
+		t.Errorf("Expected the query run with its limit, got %+v", result)
+	}
+	if rec := post(`{"sql": "DELETE FROM conversations"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a write refused, got %d", rec.Code)
+	}
+	if _, described := s.openAPI()["paths"].(map[string]interface{})["/query"]; !described {
+		t.Error("Expected the endpoint in the OpenAPI document")
+	}
+}
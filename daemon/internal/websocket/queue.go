@@ -0,0 +1,95 @@
+package websocket
+
+import (
+	"log"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/clock"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// ClientQueueSize is how many messages each client's queue holds. A client
+// that falls further behind drops its own messages; the others are unaffected.
+const ClientQueueSize = 256
+
+// ClientQueue is one client's send queue and what it dropped since connecting
+type ClientQueue struct {
+	Remote          string `json:"remote"`
+	Since           string `json:"since"`
+	Format          string `json:"format"` // json or msgpack
+	Queued          int    `json:"queued"`
+	Capacity        int    `json:"capacity"`
+	DroppedEvents   uint64 `json:"dropped_events"`
+	DroppedMessages uint64 `json:"dropped_messages"`
+}
+
+// publish queues a broadcast straight onto every client's queue, without a
+// shared channel in between that would drop it for everyone when full
+func (s *Server) publish(message outbound) {
+	// Encoded before taking the lock for the formats in use, so clients
+	// connecting don't wait on it
+	if err := s.encodeForClients(&message); err != nil {
+		log.Printf("Failed to marshal %s message: %v", message.msgType, err)
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	// A client may have connected in a format not in use a moment ago
+	if err := s.encodeForClients(&message); err != nil {
+		log.Printf("Failed to marshal %s message: %v", message.msgType, err)
+		return
+	}
+	if message.event && s.replay != nil {
+		s.replay.add(message.payload.(*models.NetworkEvent))
+	}
+	for client := range s.clients {
+		// A closed client is unregistered by its read pump
+		client.deliver(message)
+	}
+}
+
+// clientQueues returns every client's queue for /health, oldest connection first
+func (s *Server) clientQueues() []ClientQueue {
+	clients := s.connectedClients()
+	queues := make([]ClientQueue, 0, len(clients))
+	for _, client := range clients {
+		queues = append(queues, client.queue())
+	}
+	return queues
+}
+
+// connectedClients returns the registered clients, oldest connection first
+func (s *Server) connectedClients() []*Client {
+	s.mu.RLock()
+	clients := make([]*Client, 0, len(s.clients))
+	for client := range s.clients {
+		clients = append(clients, client)
+	}
+	s.mu.RUnlock()
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].loss.since.Before(clients[j].loss.since)
+	})
+	return clients
+}
+
+// queue reports the client's send queue and what it dropped
+func (c *Client) queue() ClientQueue {
+	queue := ClientQueue{
+		Since:           clock.In(c.loss.since).Format(time.RFC3339),
+		Format:          "json",
+		Queued:          len(c.send),
+		Capacity:        cap(c.send),
+		DroppedEvents:   atomic.LoadUint64(&c.loss.droppedEvents),
+		DroppedMessages: atomic.LoadUint64(&c.loss.droppedMessages),
+	}
+	if c.conn != nil {
+		queue.Remote = c.conn.RemoteAddr().String()
+	}
+	if c.binary {
+		queue.Format = "msgpack"
+	}
+	return queue
+}
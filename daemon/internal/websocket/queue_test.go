@@ -0,0 +1,43 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func TestPublish_SlowClientDropsOnlyItsOwn(t *testing.T) {
+	s := NewServer("0")
+	s.SetReplayEvents(10)
+	slow := &Client{send: make(chan []byte, 1), server: s, loss: s.newClientLoss()}
+	fast := &Client{send: make(chan []byte, 8), server: s, loss: s.newClientLoss()}
+	fast.loss.since = slow.loss.since.Add(time.Second)
+	for _, c := range []*Client{slow, fast} {
+		s.clients[c] = true
+		s.textClients++
+	}
+
+	for i := 0; i < 3; i++ {
+		s.Broadcast(&models.NetworkEvent{Size: i})
+	}
+	s.BroadcastMessage("capture_state", map[string]bool{"paused": true})
+
+	if len(fast.send) != 4 || len(slow.send) != 1 {
+		t.Fatalf("Expected the fast client sent everything and the slow one its first, got %d and %d", len(fast.send), len(slow.send))
+	}
+	if len(s.replay.latest(0)) != 3 {
+		t.Errorf("Expected the events kept for replay whoever dropped them")
+	}
+
+	queues := s.clientQueues()
+	if len(queues) != 2 {
+		t.Fatalf("Expected both clients' queues, got %+v", queues)
+	}
+	if q := queues[0]; q.Queued != 1 || q.Capacity != 1 || q.DroppedEvents != 2 || q.DroppedMessages != 1 || q.Format != "json" {
+		t.Errorf("Expected the slow client's drops first, got %+v", q)
+	}
+	if q := queues[1]; q.Queued != 4 || q.DroppedEvents != 0 || q.DroppedMessages != 0 {
+		t.Errorf("Expected nothing dropped for the fast client, got %+v", q)
+	}
+}
@@ -0,0 +1,87 @@
+package websocket
+
+import (
+	"sync"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// DefaultReplayEvents is how many recent network events are kept for clients
+// that connect later
+const DefaultReplayEvents = 5000
+
+// replayBatch is the most events sent in one recent_events message, so a
+// full buffer takes a few slots of a client's queue rather than one huge message
+const replayBatch = 500
+
+// RecentEvents is a batch of the network events broadcast before a client
+// connected, oldest first. Last is set on the final batch.
+type RecentEvents struct {
+	Events []*models.NetworkEvent `json:"events"`
+	Last   bool                   `json:"last"`
+}
+
+// eventReplay keeps the latest broadcast events, overwriting the oldest once
+// full. They are encoded as they're sent, in each client's format.
+type eventReplay struct {
+	mu     sync.Mutex
+	size   int
+	events []*models.NetworkEvent
+	next   int // Where the next event goes once full
+}
+
+func (r *eventReplay) add(event *models.NetworkEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.events) < r.size {
+		r.events = append(r.events, event)
+		return
+	}
+	r.events[r.next] = event
+	r.next = (r.next + 1) % len(r.events)
+}
+
+// latest returns up to limit of the newest events (0 for all), oldest first
+func (r *eventReplay) latest(limit int) []*models.NetworkEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := make([]*models.NetworkEvent, 0, len(r.events))
+	events = append(events, r.events[r.next:]...)
+	events = append(events, r.events[:r.next]...)
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	return events
+}
+
+// SetReplayEvents sets how many recent network events are kept and sent to
+// clients as they connect, 0 keeping none. Call it before Start.
+func (s *Server) SetReplayEvents(size int) {
+	s.replay = nil
+	if size > 0 {
+		s.replay = &eventReplay{size: size}
+	}
+}
+
+// sendRecentEvents queues up to limit of the latest events (0 for all kept)
+// for the client in batches, always ending with a batch marked last
+func (c *Client) sendRecentEvents(limit int) {
+	var events []*models.NetworkEvent
+	if c.server.replay != nil {
+		events = c.server.replay.latest(limit)
+	}
+	for {
+		batch := RecentEvents{Events: events, Last: len(events) <= replayBatch}
+		if !batch.Last {
+			batch.Events = events[:replayBatch]
+		}
+		if batch.Events == nil {
+			batch.Events = []*models.NetworkEvent{}
+		}
+		c.sendMessage("recent_events", batch)
+		if batch.Last {
+			return
+		}
+		events = events[replayBatch:]
+	}
+}
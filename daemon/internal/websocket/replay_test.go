@@ -0,0 +1,43 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+func TestRecentEvents(t *testing.T) {
+	s := NewServer("0")
+	s.SetReplayEvents(600)
+	for i := 1; i <= 700; i++ {
+		s.replay.add(&models.NetworkEvent{Size: i})
+	}
+
+	c := &Client{send: make(chan []byte, 4), server: s}
+	c.sendRecentEvents(0)
+	var first, second RecentEvents
+	receive(t, c, "recent_events", &first)
+	receive(t, c, "recent_events", &second)
+	if len(first.Events) != replayBatch || first.Last || len(second.Events) != 100 || !second.Last {
+		t.Fatalf("Expected 600 events in a full batch and a last one, got %d and %d", len(first.Events), len(second.Events))
+	}
+	if first.Events[0].Size != 101 || second.Events[99].Size != 700 {
+		t.Errorf("Expected the latest 600 oldest first, got %d to %d", first.Events[0].Size, second.Events[99].Size)
+	}
+
+	c.sendRecentEvents(2)
+	var latest RecentEvents
+	receive(t, c, "recent_events", &latest)
+	if len(latest.Events) != 2 || !latest.Last || latest.Events[1].Size != 700 {
+		t.Errorf("Expected the latest two, got %+v", latest)
+	}
+
+	// Without a buffer the answer is an empty last batch
+	s.SetReplayEvents(0)
+	c.sendRecentEvents(0)
+	var empty RecentEvents
+	receive(t, c, "recent_events", &empty)
+	if len(empty.Events) != 0 || !empty.Last {
+		t.Errorf("Expected an empty last batch, got %+v", empty)
+	}
+}
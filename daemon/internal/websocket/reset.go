@@ -0,0 +1,102 @@
+package websocket
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/clock"
+)
+
+// Reset targets
+const (
+	ResetStats         = "stats"         // Capture counters
+	ResetConversations = "conversations" // Tracked conversations and their packet history
+	ResetResolver      = "resolver"      // Cached and passively learned hostnames
+)
+
+// ResetFunc clears one kind of daemon state, returning how many items it cleared
+type ResetFunc func() int
+
+// resetRequest is the data of a reset command
+type resetRequest struct {
+	Token   string   `json:"token"`
+	Targets []string `json:"targets"` // Empty for every target
+	Confirm bool     `json:"confirm"`
+}
+
+// resetResult answers a reset command. An unconfirmed command clears nothing
+// and lists the targets it would reset, to be sent again with "confirm": true.
+type resetResult struct {
+	OK              bool           `json:"ok"`
+	Error           string         `json:"error,omitempty"`
+	ConfirmRequired bool           `json:"confirm_required,omitempty"`
+	Targets         []string       `json:"targets,omitempty"`
+	Cleared         map[string]int `json:"cleared,omitempty"`
+}
+
+// SetReset enables the reset command. Commands must carry token, and an empty
+// token leaves resets disabled.
+func (s *Server) SetReset(token string, resets map[string]ResetFunc) {
+	s.adminToken = token
+	s.resets = resets
+}
+
+// resetEnabled reports whether clients can reset daemon state
+func (s *Server) resetEnabled() bool {
+	return s.adminToken != "" && len(s.resets) > 0
+}
+
+// handleResetCommand handles the reset command, answering with a "reset_result"
+// and telling every client what was cleared with a "daemon_reset" message
+func (c *Client) handleResetCommand(data json.RawMessage) {
+	var req resetRequest
+	json.Unmarshal(data, &req)
+	result := c.server.reset(req)
+	c.sendMessage("reset_result", result)
+	if !result.OK {
+		return
+	}
+
+	log.Printf("[INFO] Reset %v on request of %s", result.Targets, c.conn.RemoteAddr())
+	c.server.BroadcastMessage("daemon_reset", map[string]interface{}{
+		"time":    clock.Now().Format(time.RFC3339),
+		"targets": result.Targets,
+		"cleared": result.Cleared,
+	})
+}
+
+// reset checks a reset request and, once confirmed, carries it out
+func (s *Server) reset(req resetRequest) resetResult {
+	if !s.resetEnabled() {
+		return resetResult{Error: "reset is disabled, start the daemon with -admin-token to enable it"}
+	}
+	if subtle.ConstantTimeCompare([]byte(req.Token), []byte(s.adminToken)) != 1 {
+		return resetResult{Error: "invalid token"}
+	}
+
+	targets := req.Targets
+	if len(targets) == 0 {
+		for target := range s.resets {
+			targets = append(targets, target)
+		}
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		if _, ok := s.resets[target]; !ok {
+			return resetResult{Error: fmt.Sprintf("unknown reset target %q", target)}
+		}
+	}
+	if !req.Confirm {
+		return resetResult{ConfirmRequired: true, Targets: targets}
+	}
+
+	cleared := make(map[string]int, len(targets))
+	for _, target := range targets {
+		cleared[target] = s.resets[target]()
+	}
+	return resetResult{OK: true, Targets: targets, Cleared: cleared}
+}
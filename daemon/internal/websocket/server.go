@@ -1,26 +1,91 @@
 package websocket
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"log"
+	"net"
 	"net/http"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/iolloyd/netty/daemon/internal/alerts"
+	"github.com/iolloyd/netty/daemon/internal/arpwatch"
+	"github.com/iolloyd/netty/daemon/internal/beacon"
+	"github.com/iolloyd/netty/daemon/internal/blocker"
+	"github.com/iolloyd/netty/daemon/internal/certs"
 	"github.com/iolloyd/netty/daemon/internal/conversation"
+	"github.com/iolloyd/netty/daemon/internal/dhcp"
+	"github.com/iolloyd/netty/daemon/internal/discovery"
+	"github.com/iolloyd/netty/daemon/internal/geoip"
+	"github.com/iolloyd/netty/daemon/internal/history"
+	"github.com/iolloyd/netty/daemon/internal/inventory"
+	"github.com/iolloyd/netty/daemon/internal/process"
 	"github.com/iolloyd/netty/daemon/internal/models"
+	"github.com/iolloyd/netty/daemon/internal/ndp"
+	"github.com/iolloyd/netty/daemon/internal/query"
+	"github.com/iolloyd/netty/daemon/internal/store"
+	"github.com/iolloyd/netty/daemon/internal/summary"
+	"github.com/iolloyd/netty/daemon/internal/tap"
+	"github.com/iolloyd/netty/daemon/internal/watch"
 )
 
 type Server struct {
-	port      string
+	addr       string // TCP address served, see SetListen
+	unixSocket string // Unix domain socket served, see SetListen
 	clients   map[*Client]bool
-	broadcast chan []byte
 	register  chan *Client
 	unregister chan *Client
 	upgrader  websocket.Upgrader
 	mu        sync.RWMutex
 	convMgr   *conversation.Manager
 	statsFunc func() map[string]interface{} // Function to get capture statistics
+	firewallApply bool // Whether clients may apply generated firewall rules
+	blocker   *blocker.Blocker
+	summarizer *summary.Summarizer
+	retentionStatsFunc func() map[string]interface{} // Function to get retention pruning metrics
+	pcapOutputStatsFunc func() map[string]interface{} // Function to get pcap file output metrics
+	configFunc func() map[string]interface{} // Function to get the effective daemon configuration
+	packetHistory *history.History
+	tap       *tap.Tap // Live packets for /api/v1/pcap, see SetTap
+	queryer   query.Queryer // History store for ad-hoc SQL queries
+	historyStore *store.Store // Stored conversations and events served at /api/history
+	arpWatcher *arpwatch.Watcher
+	leases    *dhcp.Table
+	services  *discovery.Inventory
+	ndpMonitor *ndp.Monitor
+	alerts    *alerts.Store
+	hostWatcher *watch.Watcher
+	beacons   *beacon.Detector
+	certificates *certs.Inventory
+	inventory *inventory.Inventory
+	processes *process.Table
+	geoip     *geoip.DB
+	adminToken string // Required by reset commands, which are disabled without it
+	resets    map[string]ResetFunc
+	lossFunc  func() (kernelDropped, channelDropped uint64) // Losses before events reach the server
+	tlsConfig *tls.Config // Serve wss and https when set, see SetTLS
+	apiToken  string // Required by /ws and /api/* when set, see SetAPIToken
+	allowedOrigins map[string]bool // Browser origins allowed besides the daemon's own, see SetAllowedOrigins
+	allowedHosts   map[string]bool // Host names the daemon answers to besides its own, see SetAllowedHosts
+	readOnly  bool      // Refuse commands that change state, see SetReadOnly
+	clientIDs uint64    // Last client ID handed out, updated atomically
+	audit     *auditLog // Records client commands when set, see SetAuditLog
+	captureControl CaptureControl // Pauses and resumes the capture
+	httpServer *http.Server
+	writers   sync.WaitGroup // Client write pumps still running, see Shutdown
+	shuttingDown int32       // Set by Shutdown, read atomically
+	stopping  chan struct{}  // Closed by Shutdown, ending live pcap downloads
+	stream    conversationStream // Conversation changes for subscribed clients
+	replay    *eventReplay       // Recent events for clients connecting later, nil when off
+	textClients   int32 // Clients sent JSON, read atomically
+	binaryClients int32 // Clients sent msgpack, read atomically
+	keepaliveInterval time.Duration // How often clients are pinged, see SetKeepalive
+	keepaliveTimeout  time.Duration // How long a client may stay silent
+	reapedClients     uint64        // Clients dropped for not responding, read atomically
 }
 
 type Client struct {
@@ -29,23 +94,28 @@ type Client struct {
 	server *Server
 	mu     sync.Mutex
 	closed bool
+	loss   clientLoss
+	binary bool // Sent msgpack binary frames, see MsgpackSubprotocol
+	id     uint64 // Numbers clients for /api/v1/clients, from 1
+	sentMessages uint64 // Messages written to the connection, read atomically
+	sentBytes    uint64 // Their bytes, read atomically
 }
 
 func NewServer(port string) *Server {
-	return &Server{
-		port:       port,
+	s := &Server{
+		addr:       net.JoinHostPort("127.0.0.1", port),
 		clients:    make(map[*Client]bool),
-		broadcast:  make(chan []byte, 256),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		stopping:   make(chan struct{}),
+		keepaliveInterval: KeepaliveInterval,
+		keepaliveTimeout:  KeepaliveTimeout,
 		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				// Allow connections from any origin for development
-				// TODO: Restrict this in production
-				return true
-			},
+			Subprotocols: []string{MsgpackSubprotocol},
 		},
 	}
+	s.upgrader.CheckOrigin = s.originAllowed
+	return s
 }
 
 // getClientCount returns the current number of connected clients
@@ -65,16 +135,38 @@ func (s *Server) SetStatsFunction(fn func() map[string]interface{}) {
 	s.statsFunc = fn
 }
 
+// SetRetentionStatsFunction sets the function to retrieve retention pruning metrics
+func (s *Server) SetRetentionStatsFunction(fn func() map[string]interface{}) {
+	s.retentionStatsFunc = fn
+}
+
+// SetPcapOutputStatsFunction sets the function to retrieve pcap file output metrics
+func (s *Server) SetPcapOutputStatsFunction(fn func() map[string]interface{}) {
+	s.pcapOutputStatsFunc = fn
+}
+
 func (s *Server) Start() error {
 	go s.run()
+	go s.reportLoss(LossReportInterval)
+	go s.streamConversations(ConversationStreamInterval)
 
-	http.HandleFunc("/ws", s.handleWebSocket)
-	http.HandleFunc("/health", s.handleHealth)
-	http.HandleFunc("/api/conversations", s.handleConversations)
-	http.HandleFunc("/api/conversations/summary", s.handleConversationSummary)
-
-	log.Printf("WebSocket server starting on port %s", s.port)
-	return http.ListenAndServe(":"+s.port, nil)
+	listeners, err := s.listen()
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: s.cors(s.authenticate(s.newMux())), TLSConfig: s.tlsConfig}
+	s.mu.Lock()
+	s.httpServer = server
+	s.mu.Unlock()
+
+	// Shutdown ends every listener's Serve; a listener failing alone ends Start
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			errs <- s.serve(server, l)
+		}(l)
+	}
+	return <-errs
 }
 
 func (s *Server) run() {
@@ -83,6 +175,11 @@ func (s *Server) run() {
 		case client := <-s.register:
 			s.mu.Lock()
 			s.clients[client] = true
+			atomic.AddInt32(s.formatClients(client), 1)
+			// Sent under the lock broadcasts take, so the replay ends exactly where the live events begin
+			if s.replay != nil {
+				client.sendRecentEvents(0)
+			}
 			s.mu.Unlock()
 			log.Printf("Client connected. Total clients: %d", len(s.clients))
 
@@ -90,6 +187,7 @@ func (s *Server) run() {
 			s.mu.Lock()
 			if _, ok := s.clients[client]; ok {
 				delete(s.clients, client)
+				atomic.AddInt32(s.formatClients(client), -1)
 				s.mu.Unlock()
 				
 				// Close the client's send channel safely
@@ -99,27 +197,12 @@ func (s *Server) run() {
 					close(client.send)
 				}
 				client.mu.Unlock()
+				s.unsubscribeConversations(client)
 				
 				log.Printf("Client disconnected. Total clients: %d", s.getClientCount())
 			} else {
 				s.mu.Unlock()
 			}
-
-		case message := <-s.broadcast:
-			s.mu.RLock()
-			clientsCopy := make([]*Client, 0, len(s.clients))
-			for client := range s.clients {
-				clientsCopy = append(clientsCopy, client)
-			}
-			s.mu.RUnlock()
-
-			for _, client := range clientsCopy {
-				// Use safeSend to avoid panic
-				if !client.safeSend(message) {
-					// Client's send channel is full or closed, unregister it
-					s.unregister <- client
-				}
-			}
 		}
 	}
 }
@@ -132,13 +215,19 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 
 	client := &Client{
+		id:     atomic.AddUint64(&s.clientIDs, 1),
 		conn:   conn,
-		send:   make(chan []byte, 256),
+		send:   make(chan []byte, ClientQueueSize),
 		server: s,
+		loss:   s.newClientLoss(),
+		binary: conn.Subprotocol() == MsgpackSubprotocol,
 	}
 
+	// Tell the client what it is talking to before anything else is queued
+	client.sendMessage("hello", s.hello())
 	s.register <- client
 
+	s.writers.Add(1)
 	go client.writePump()
 	go client.readPump()
 }
@@ -151,15 +240,76 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
 		"status":  "healthy",
 		"clients": clientCount,
+		"clients_reaped": atomic.LoadUint64(&s.reapedClients),
+	}
+	for key, value := range s.hello() {
+		response[key] = value
 	}
 
-	// Add capture statistics if available
+	// Add capture statistics if available, a capture that isn't reading packets degrades the status
 	if s.statsFunc != nil {
-		response["capture_stats"] = s.statsFunc()
+		stats := s.statsFunc()
+		response["capture_stats"] = stats
+		if state, ok := stats["capture_state"].(string); ok && (state == "recovering" || state == "failed") {
+			response["status"] = "degraded"
+		}
+	}
+	
+	// Add retention metrics if pruning is enabled
+	if s.retentionStatsFunc != nil {
+		response["retention_stats"] = s.retentionStatsFunc()
+	}
+	
+	// Add pcap file output metrics if packets are written to disk
+	if s.pcapOutputStatsFunc != nil {
+		response["pcap_output_stats"] = s.pcapOutputStatsFunc()
+	}
+	
+	// Add alert counts
+	if s.alerts != nil {
+		response["alert_stats"] = s.alerts.GetStats()
+	}
+	
+	// Add packet history usage if enabled
+	if s.packetHistory != nil {
+		response["history_stats"] = s.packetHistory.GetStats()
+	}
+	
+	// Add ARP watch metrics and LAN device counts if enabled
+	if s.arpWatcher != nil {
+		response["arp_stats"] = s.arpWatcher.GetStats()
+	}
+	
+	// Add DHCP client counts if enabled
+	if s.leases != nil {
+		response["lease_stats"] = s.leases.GetStats()
+	}
+	
+	// Add mDNS and SSDP service counts if enabled
+	if s.services != nil {
+		response["service_stats"] = s.services.GetStats()
+	}
+	
+	// Add IPv6 neighbor discovery metrics if enabled
+	if s.ndpMonitor != nil {
+		response["ndp_stats"] = s.ndpMonitor.GetStats()
+	}
+	
+	// Add endpoint inventory size if enabled
+	if s.inventory != nil {
+		response["endpoint_stats"] = s.inventory.GetStats()
 	}
+	
+	// Add process attribution metrics if enabled
+	if s.processes != nil {
+		response["process_stats"] = s.processes.GetStats()
+	}
+	
+	// Add what was dropped between capture and clients, and each client's share
+	response["loss_stats"] = s.lossStats()
+	response["client_queues"] = s.clientQueues()
 
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*") // CORS for development
 	json.NewEncoder(w).Encode(response)
 }
 
@@ -168,26 +318,12 @@ func (s *Server) Broadcast(event *models.NetworkEvent) {
 	// Event broadcast is handled silently
 	
 	// Wrap event in a message type
-	message := struct {
-		Type string               `json:"type"`
-		Data *models.NetworkEvent `json:"data"`
-	}{
-		Type: "network_event",
-		Data: event,
-	}
-
-	data, err := json.Marshal(message)
-	if err != nil {
-		log.Printf("Failed to marshal event: %v", err)
-		return
-	}
+	s.publish(outbound{event: true, msgType: "network_event", payload: event})
+}
 
-	select {
-	case s.broadcast <- data:
-		// Event queued successfully
-	default:
-		log.Println("Broadcast channel full, dropping event")
-	}
+// BroadcastMessage sends an arbitrary typed message to all clients
+func (s *Server) BroadcastMessage(msgType string, payload interface{}) {
+	s.publish(outbound{msgType: msgType, payload: payload})
 }
 
 // BroadcastConversationUpdate sends conversation updates to all clients
@@ -201,25 +337,7 @@ func (s *Server) BroadcastConversationUpdate(conversationID string) {
 		return
 	}
 
-	message := struct {
-		Type string                  `json:"type"`
-		Data *models.Conversation    `json:"data"`
-	}{
-		Type: "conversation_update",
-		Data: conv,
-	}
-
-	data, err := json.Marshal(message)
-	if err != nil {
-		log.Printf("Failed to marshal conversation update: %v", err)
-		return
-	}
-
-	select {
-	case s.broadcast <- data:
-	default:
-		log.Println("Broadcast channel full, dropping conversation update")
-	}
+	s.publish(outbound{msgType: "conversation_update", payload: conv})
 }
 
 func (c *Client) readPump() {
@@ -228,15 +346,22 @@ func (c *Client) readPump() {
 		c.conn.Close()
 	}()
 
+	// A client that goes quiet for too long, pongs included, is gone
+	c.extendDeadline()
+	c.conn.SetPongHandler(func(string) error { return c.extendDeadline() })
+
 	for {
 		// Read message from client (for ping/pong and potential future commands)
 		_, message, err := c.conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+			if isTimeout(err) {
+				c.reap("no pong or message within " + c.server.keepaliveTimeout.String())
+			} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("WebSocket error: %v", err)
 			}
 			break
 		}
+		c.extendDeadline()
 		
 		// Handle client commands
 		c.handleCommand(message)
@@ -261,6 +386,13 @@ func (c *Client) safeSend(data []byte) bool {
 	}
 }
 
+// sendMessage encodes a typed message in the client's format and queues it
+func (c *Client) sendMessage(msgType string, payload interface{}) {
+	if data, err := encodeMessage(c.binary, msgType, payload); err == nil {
+		c.safeSend(data)
+	}
+}
+
 // handleCommand processes commands from clients
 func (c *Client) handleCommand(message []byte) {
 	defer func() {
@@ -277,42 +409,60 @@ func (c *Client) handleCommand(message []byte) {
 	if err := json.Unmarshal(message, &cmd); err != nil {
 		return // Ignore malformed messages
 	}
+	if !c.allowCommand(cmd.Type) {
+		return
+	}
 	
 	switch cmd.Type {
 	case "get_conversations":
 		// Send active conversations to this client
 		if c.server.convMgr != nil {
-			conversations := c.server.convMgr.GetActiveConversations()
-			response := struct {
-				Type string `json:"type"`
-				Data interface{} `json:"data"`
-			}{
-				Type: "conversations",
-				Data: conversations,
-			}
-			
-			if data, err := json.Marshal(response); err == nil {
-				c.safeSend(data)
-			}
+			c.sendMessage("conversations", c.server.convMgr.GetActiveConversations())
 		}
 	
 	case "get_conversation_summaries":
 		// Send conversation summaries to this client
 		if c.server.convMgr != nil {
-			summaries := c.server.convMgr.GetConversationSummaries()
-			response := struct {
-				Type string `json:"type"`
-				Data interface{} `json:"data"`
-			}{
-				Type: "conversation_summaries",
-				Data: summaries,
-			}
-			
-			if data, err := json.Marshal(response); err == nil {
-				c.safeSend(data)
-			}
+			c.sendMessage("conversation_summaries", c.server.convMgr.GetConversationSummaries())
+		}
+	
+	case "get_service_groups":
+		// Send conversations grouped by remote service to this client
+		if c.server.convMgr != nil {
+			c.sendMessage("service_groups", c.server.convMgr.GetServiceGroups())
 		}
 	
+	case "get_traffic_matrix":
+		// Send bytes between local hosts and remote destinations to this client
+		c.handleTrafficMatrixCommand(cmd.Data)
+	
+	case "get_hosts":
+		// Send the conversations grouped by remote host to this client
+		c.handleHostsCommand()
+	
+	case "get_top":
+		// Send the busiest hosts, ports, services or programs to this client
+		c.handleTopCommand(cmd.Data)
+	
+	case "get_recent_events":
+		// Send the events broadcast before this client connected
+		var params struct {
+			Limit int `json:"limit"`
+		}
+		json.Unmarshal(cmd.Data, &params)
+		c.sendRecentEvents(params.Limit)
+	
+	case "subscribe_conversations":
+		// Send a snapshot of the conversation summaries, then what changes
+		c.server.subscribeConversations(c)
+	
+	case "unsubscribe_conversations":
+		c.server.unsubscribeConversations(c)
+	
+	case "get_domains":
+		// Send the traffic per domain name to this client
+		c.handleDomainsCommand(cmd.Data)
+	
 	case "get_conversation":
 		// Get specific conversation by ID
 		var params struct {
@@ -320,18 +470,99 @@ func (c *Client) handleCommand(message []byte) {
 		}
 		if err := json.Unmarshal(cmd.Data, &params); err == nil && c.server.convMgr != nil {
 			if conv, exists := c.server.convMgr.GetConversation(params.ID); exists {
-				response := struct {
-					Type string `json:"type"`
-					Data interface{} `json:"data"`
-				}{
-					Type: "conversation",
-					Data: conv,
-				}
-				
-				if data, err := json.Marshal(response); err == nil {
-					c.safeSend(data)
-				}
+				c.sendMessage("conversation", conv)
+			}
+		}
+	
+	case "get_history":
+		// Query the history store for a past time range
+		c.handleHistoryCommand(cmd.Data)
+	
+	case "get_conversation_packets":
+		// Send a conversation's most recent packets to this client
+		c.handleConversationPacketsCommand(cmd.Data)
+	
+	case "generate_firewall_rules", "apply_firewall_rules":
+		c.handleFirewallCommand(cmd.Type, cmd.Data)
+	
+	case "get_alerts", "acknowledge_alert", "resolve_alert":
+		c.handleAlertCommand(cmd.Type, cmd.Data)
+	
+	case "watch_host", "unwatch_host", "get_watched_hosts":
+		c.handleWatchCommand(cmd.Type, cmd.Data)
+	
+	case "hello":
+		c.sendMessage("hello", c.server.hello())
+	
+	case "get_beacons":
+		if c.server.beacons != nil {
+			c.sendMessage("beacons", c.server.beacons.Beacons())
+		}
+	
+	case "get_certificates":
+		if c.server.certificates != nil {
+			c.sendMessage("certificates", c.server.certificates.Certificates())
+		}
+	
+	case "get_devices":
+		if c.server.tracksDevices() {
+			c.sendMessage("devices", c.server.arpWatcher.Devices())
+		}
+	
+	case "get_leases":
+		if c.server.leases != nil {
+			c.sendMessage("leases", c.server.leases.Leases())
+		}
+	
+	case "get_services":
+		if c.server.services != nil {
+			c.sendMessage("services", c.server.services.Devices())
+		}
+	
+	case "get_endpoints":
+		if c.server.inventory != nil {
+			var params struct {
+				Limit int `json:"limit"`
 			}
+			json.Unmarshal(cmd.Data, &params)
+			c.sendMessage("endpoints", c.server.inventory.Endpoints(params.Limit))
+		}
+	
+	case "pause_capture", "resume_capture", "get_capture_state":
+		// Pause or resume reading packets, or report whether it is paused
+		c.handleCaptureCommand(cmd.Type)
+	
+	case "get_encryption_stats":
+		// Send the encrypted and cleartext share of each service's traffic to this client
+		if c.server.convMgr != nil {
+			c.sendMessage("encryption_stats", c.server.convMgr.GetEncryptionStats())
+		}
+	
+	case "get_throughput":
+		// Send the bytes per second each way, now and over the last minute, to this client
+		if c.server.convMgr != nil {
+			c.sendMessage("throughput", c.server.convMgr.GetThroughput())
+		}
+	
+	case "get_processes":
+		if c.server.processesEnabled() {
+			c.sendMessage("processes", c.server.convMgr.GetProcessStats())
+		}
+	
+	case "get_countries":
+		if c.server.countriesEnabled() {
+			c.sendMessage("countries", c.server.convMgr.GetCountryStats())
+		}
+	
+	case "reset":
+		c.handleResetCommand(cmd.Data)
+	
+	case "disconnect_client":
+		c.handleDisconnectCommand(cmd.Data)
+	
+	case "get_config":
+		if c.server.configFunc != nil {
+			c.sendMessage("config", c.server.configFunc())
 		}
 	}
 }
@@ -342,35 +573,61 @@ func (c *Client) writePump() {
 			// Silently handle panic
 		}
 		c.conn.Close()
+		c.server.writers.Done()
 	}()
 
+	ping := time.NewTicker(c.server.keepaliveInterval)
+	defer ping.Stop()
+
 	for {
 		select {
 		case message, ok := <-c.send:
 			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.write(websocket.CloseMessage, c.server.closeFrame())
 				return
 			}
 
-			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			frame := websocket.TextMessage
+			if c.binary {
+				frame = websocket.BinaryMessage
+			}
+			if err := c.write(frame, message); err != nil {
 				// Write error handled silently
 				return
 			}
+			atomic.AddUint64(&c.sentMessages, 1)
+			atomic.AddUint64(&c.sentBytes, uint64(len(message)))
+
+		case <-ping.C:
+			if err := c.write(websocket.PingMessage, nil); err != nil {
+				return
+			}
 		}
 	}
 }
 
-// handleConversations handles HTTP API requests for conversations
+// handleConversations handles HTTP API requests for conversations: the active
+// ones, or those matching ?state=&service=&remote=, ordered by ?sort=&order=
+// and paged by ?limit=&offset=, with how many matched in X-Total-Count
 func (s *Server) handleConversations(w http.ResponseWriter, r *http.Request) {
 	if s.convMgr == nil {
 		http.Error(w, "Conversation manager not initialized", http.StatusInternalServerError)
 		return
 	}
 	
-	conversations := s.convMgr.GetActiveConversations()
+	query, err := parseConversationQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	conversations, total, err := s.convMgr.QueryConversations(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*") // CORS for development
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
 	json.NewEncoder(w).Encode(conversations)
 }
 
@@ -384,6 +641,18 @@ func (s *Server) handleConversationSummary(w http.ResponseWriter, r *http.Reques
 	summaries := s.convMgr.GetConversationSummaries()
 	
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*") // CORS for development
 	json.NewEncoder(w).Encode(summaries)
-}
\ No newline at end of file
+}
+
+// handleServiceGroups handles HTTP API requests for conversations grouped by remote service
+func (s *Server) handleServiceGroups(w http.ResponseWriter, r *http.Request) {
+	if s.convMgr == nil {
+		http.Error(w, "Conversation manager not initialized", http.StatusInternalServerError)
+		return
+	}
+	
+	groups := s.convMgr.GetServiceGroups()
+	
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
@@ -0,0 +1,25 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/iolloyd/netty/daemon/internal/discovery"
+)
+
+// SetServiceInventory sets the inventory of mDNS and SSDP services served at /api/services
+func (s *Server) SetServiceInventory(inv *discovery.Inventory) {
+	s.services = inv
+}
+
+// handleServices handles HTTP API requests for the devices advertising
+// services over mDNS and SSDP, named ones first
+func (s *Server) handleServices(w http.ResponseWriter, r *http.Request) {
+	if s.services == nil {
+		http.Error(w, "Service discovery not enabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.services.Devices())
+}
@@ -0,0 +1,67 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+)
+
+// Shutdown stops the server gracefully: it stops accepting connections and
+// HTTP requests, lets the messages already queued reach the clients, then
+// closes each client with a "going away" close frame so they know to
+// reconnect rather than waiting for a timeout. Live pcap downloads end with
+// the packets streamed so far. It gives up when ctx is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if atomic.CompareAndSwapInt32(&s.shuttingDown, 0, 1) {
+		close(s.stopping)
+	}
+	s.mu.RLock()
+	server := s.httpServer
+	s.mu.RUnlock()
+	var err error
+	if server != nil {
+		err = server.Shutdown(ctx)
+	}
+
+	// Broadcasts go straight to the clients' send buffers, so nothing is in between
+	s.mu.RLock()
+	clients := make([]*Client, 0, len(s.clients))
+	for client := range s.clients {
+		clients = append(clients, client)
+	}
+	s.mu.RUnlock()
+	// Closing a client's send buffer makes its writer send what is left, then the close frame
+	for _, client := range clients {
+		select {
+		case s.unregister <- client:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.writers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		log.Printf("Closed %d WebSocket clients", len(clients))
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if s.audit != nil {
+		s.audit.close()
+	}
+	return err
+}
+
+// closeFrame returns the close message a client is sent when its connection ends
+func (s *Server) closeFrame() []byte {
+	if atomic.LoadInt32(&s.shuttingDown) == 1 {
+		return websocket.FormatCloseMessage(websocket.CloseGoingAway, "daemon shutting down")
+	}
+	return []byte{}
+}
@@ -0,0 +1,78 @@
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/gorilla/websocket"
+	"github.com/iolloyd/netty/daemon/internal/tap"
+)
+
+func TestShutdown_ClosesClientsAfterQueuedMessages(t *testing.T) {
+	s := NewServer("0")
+	go s.run()
+	server := httptest.NewServer(http.HandlerFunc(s.handleWebSocket))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer conn.Close()
+	if _, hello, err := conn.ReadMessage(); err != nil || !strings.Contains(string(hello), `"hello"`) {
+		t.Fatalf("Expected the hello message first, got %s (%v)", hello, err)
+	}
+
+	s.BroadcastMessage("capture_restarted", map[string]string{"kind": "wake"})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The message queued before shutdown still arrives, then the close frame
+	if _, message, err := conn.ReadMessage(); err != nil || !strings.Contains(string(message), "capture_restarted") {
+		t.Errorf("Expected the queued message before closing, got %s (%v)", message, err)
+	}
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("Expected a going away close frame, got %v", err)
+	}
+}
+
+func TestShutdown_EndsLivePcap(t *testing.T) {
+	s := NewServer("0")
+	s.SetTap(tap.New(layers.LinkTypeEthernet, 65535))
+	server := httptest.NewServer(s.newMux())
+	defer server.Close()
+	s.httpServer = server.Config
+
+	resp, err := http.Get(server.URL + "/api/v1/pcap?duration=1m")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// The download ends with the shutdown rather than after its minute
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("Expected the shutdown not to wait for the download, got %v", err)
+	}
+	reader, err := pcapgo.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read the pcap: %v", err)
+	}
+	if _, _, err := reader.ReadPacketData(); err == nil {
+		t.Error("Expected no packets")
+	}
+	if dropped := resp.Trailer.Get("X-Dropped-Packets"); dropped != "0" {
+		t.Errorf("Expected the download finished with its trailer, got %q", dropped)
+	}
+}
@@ -0,0 +1,139 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/clock"
+	"github.com/iolloyd/netty/daemon/internal/query"
+	"github.com/iolloyd/netty/daemon/internal/store"
+)
+
+// defaultHistoryRange is how far back /api/history looks without a from
+const defaultHistoryRange = time.Hour
+
+// historyResponse answers get_history, with the error if the query failed
+type historyResponse struct {
+	store.History
+	Error string `json:"error,omitempty"`
+}
+
+// SetHistoryStore sets the SQLite store /api/history reads from
+func (s *Server) SetHistoryStore(st *store.Store) {
+	s.historyStore = st
+}
+
+// handleHistoryCommand answers get_history with what was stored between from
+// and to, optionally only for a host or service, as /api/history does
+func (c *Client) handleHistoryCommand(data json.RawMessage) {
+	var params struct {
+		From    string `json:"from"`
+		To      string `json:"to"`
+		Host    string `json:"host"`
+		Service string `json:"service"`
+		Limit   int    `json:"limit"`
+	}
+	json.Unmarshal(data, &params)
+
+	resp := historyResponse{History: store.History{Host: params.Host, Service: params.Service}}
+	filter, err := historyFilter(params.From, params.To, params.Host, params.Service, params.Limit)
+	switch {
+	case c.server.historyStore == nil:
+		resp.Error = "no history store enabled (start the daemon with -history-db)"
+	case err != nil:
+		resp.Error = err.Error()
+	default:
+		ctx, cancel := context.WithTimeout(context.Background(), query.Timeout)
+		defer cancel()
+		resp.History, err = c.server.historyStore.History(ctx, filter)
+		if err != nil {
+			resp.Error = err.Error()
+		}
+	}
+	c.sendMessage("history", resp)
+}
+
+// handleHistory handles HTTP API requests for the conversations and sampled
+// events stored between ?from= and ?to=, each an RFC 3339 time or a duration
+// before now such as 24h. to defaults to now and from to an hour before it.
+// ?host= keeps those with a remote IP, hostname or server name (subdomains
+// included), and ?service= those of a service such as HTTPS.
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.historyStore == nil {
+		http.Error(w, "No history store enabled (start the daemon with -history-db)", http.StatusServiceUnavailable)
+		return
+	}
+
+	params := r.URL.Query()
+	limit := 0
+	if value := params.Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+	filter, err := historyFilter(params.Get("from"), params.Get("to"), params.Get("host"), params.Get("service"), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	history, err := s.historyStore.History(r.Context(), filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// historyFilter builds the store filter for a history request
+func historyFilter(from, to, host, service string, limit int) (store.HistoryFilter, error) {
+	if limit < 0 {
+		return store.HistoryFilter{}, fmt.Errorf("invalid limit %d", limit)
+	}
+	now := clock.Now()
+	filter := store.HistoryFilter{
+		Host:    strings.TrimSpace(host),
+		Service: strings.TrimSpace(service),
+		Limit:   query.ClampLimit(limit),
+	}
+	var err error
+	if filter.To, err = parseHistoryTime(to, now, now); err != nil {
+		return filter, fmt.Errorf("invalid to: %w", err)
+	}
+	if filter.From, err = parseHistoryTime(from, now, filter.To.Add(-defaultHistoryRange)); err != nil {
+		return filter, fmt.Errorf("invalid from: %w", err)
+	}
+	if filter.To.Before(filter.From) {
+		return filter, fmt.Errorf("to is before from")
+	}
+	return filter, nil
+}
+
+// parseHistoryTime parses an RFC 3339 time or a duration before now, returning
+// fallback for an empty value
+func parseHistoryTime(value string, now, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	ago, err := time.ParseDuration(value)
+	if err != nil || ago < 0 {
+		return time.Time{}, fmt.Errorf("expected an RFC 3339 time or a duration such as 24h, got %q", value)
+	}
+	return now.Add(-ago), nil
+}
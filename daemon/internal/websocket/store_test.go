@@ -0,0 +1,103 @@
+//go:build cgo
+
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+	"github.com/iolloyd/netty/daemon/internal/store"
+)
+
+func TestHandleHistory(t *testing.T) {
+	s := NewServer("0")
+	request := func(target string) (int, store.History) {
+		rec := httptest.NewRecorder()
+		s.handleHistory(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var history store.History
+		json.NewDecoder(rec.Body).Decode(&history)
+		return rec.Code, history
+	}
+	if code, _ := request("/api/history"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a store, got %d", code)
+	}
+
+	st, err := store.Open(store.Config{Path: filepath.Join(t.TempDir(), "netty.db")}, "192.168.1.10")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer st.Close()
+	recent := time.Now().Add(-30 * time.Minute)
+	st.AddSummaries([]models.ConversationSummary{
+		{ID: "recent", RemoteAddr: "203.0.113.7:443", Service: "HTTPS", StartTime: recent, LastActivity: recent},
+		{ID: "lookup", RemoteAddr: "192.0.2.53:53", Service: "DNS", StartTime: recent, LastActivity: recent},
+		{ID: "yesterday", StartTime: recent.Add(-24 * time.Hour), LastActivity: recent.Add(-24 * time.Hour)},
+	})
+	if err := st.Flush(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.SetHistoryStore(st)
+
+	if code, history := request("/api/history"); code != http.StatusOK || len(history.Conversations) != 2 {
+		t.Errorf("Expected the last hour by default, got %d %+v", code, history.Conversations)
+	}
+	if code, history := request("/api/history?host=203.0.113.7"); code != http.StatusOK || len(history.Conversations) != 1 || history.Conversations[0].ID != "recent" {
+		t.Errorf("Expected the host's conversation, got %d %+v", code, history.Conversations)
+	}
+	if code, history := request("/api/history?service=dns"); code != http.StatusOK || len(history.Conversations) != 1 || history.Conversations[0].ID != "lookup" {
+		t.Errorf("Expected the service's conversation, got %d %+v", code, history.Conversations)
+	}
+	from := recent.Add(-25 * time.Hour).UTC().Format(time.RFC3339)
+	if code, history := request("/api/history?from=" + from + "&to=2h"); code != http.StatusOK || len(history.Conversations) != 1 || history.Conversations[0].ID != "yesterday" {
+		t.Errorf("Expected yesterday's conversation, got %d %+v", code, history.Conversations)
+	}
+	for _, target := range []string{"/api/history?from=yesterday", "/api/history?from=1h&to=2h", "/api/history?limit=-1"} {
+		if code, _ := request(target); code != http.StatusBadRequest {
+			t.Errorf("Expected %s to be rejected, got %d", target, code)
+		}
+	}
+}
+
+func TestHistoryCommand(t *testing.T) {
+	s := NewServer("0")
+	c := &Client{send: make(chan []byte, 4), server: s}
+	command := func(data string) historyResponse {
+		c.handleHistoryCommand(json.RawMessage(data))
+		var msg struct {
+			Type string          `json:"type"`
+			Data historyResponse `json:"data"`
+		}
+		json.Unmarshal(<-c.send, &msg)
+		if msg.Type != "history" {
+			t.Fatalf("Expected a history message, got %q", msg.Type)
+		}
+		return msg.Data
+	}
+	if resp := command(`{}`); resp.Error == "" {
+		t.Error("Expected an error without a store")
+	}
+
+	st, err := store.Open(store.Config{Path: filepath.Join(t.TempDir(), "netty.db")}, "192.168.1.10")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer st.Close()
+	earlier := time.Now().Add(-90 * time.Minute)
+	st.AddSummaries([]models.ConversationSummary{{ID: "earlier", RemoteAddr: "203.0.113.7:443", StartTime: earlier, LastActivity: earlier}})
+	if err := st.Flush(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	s.SetHistoryStore(st)
+
+	if resp := command(`{"from": "2h", "to": "1h", "host": "203.0.113.7"}`); resp.Error != "" || len(resp.Conversations) != 1 || resp.Host != "203.0.113.7" {
+		t.Errorf("Expected the earlier conversation, got %+v", resp)
+	}
+	if resp := command(`{"from": "1h", "to": "2h"}`); resp.Error == "" {
+		t.Error("Expected a backwards range to be rejected")
+	}
+}
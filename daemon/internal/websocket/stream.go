@@ -0,0 +1,173 @@
+package websocket
+
+import (
+	"log"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// ConversationStreamInterval is how often subscribed clients are sent the
+// conversations that changed
+const ConversationStreamInterval = time.Second
+
+// ConversationSnapshot is every tracked conversation's summary, sent to a
+// client when it subscribes or falls behind. The next delta has Seq+1.
+type ConversationSnapshot struct {
+	Seq           uint64                       `json:"seq"`
+	Conversations []models.ConversationSummary `json:"conversations"`
+}
+
+// ConversationDelta is what changed since the delta (or snapshot) numbered Seq-1
+type ConversationDelta struct {
+	Seq     uint64                       `json:"seq"`
+	Added   []models.ConversationSummary `json:"added"`   // Summaries of new conversations
+	Updated []models.ConversationSummary `json:"updated"` // Summaries that changed
+	Removed []string                     `json:"removed"` // IDs no longer tracked
+}
+
+// conversationStream tracks the summaries subscribers were last sent. They all
+// share one sequence, so a delta is encoded once per format for every client in step.
+type conversationStream struct {
+	mu          sync.Mutex
+	seq         uint64
+	last        map[string]models.ConversationSummary // Summaries as of seq, by ID
+	subscribers map[*Client]bool                      // Whether each is in step, or needs a snapshot
+}
+
+// subscribeConversations brings the stream up to date and sends the client a
+// snapshot of it, followed by a delta each interval something changed
+func (s *Server) subscribeConversations(c *Client) {
+	if s.convMgr == nil {
+		return
+	}
+	s.stream.mu.Lock()
+	defer s.stream.mu.Unlock()
+
+	if s.stream.subscribers == nil {
+		s.stream.subscribers = make(map[*Client]bool)
+	}
+	s.stepConversationStream()
+	s.stream.subscribers[c] = c.sendSnapshot(s.stream.seq, s.stream.last)
+}
+
+// unsubscribeConversations stops a client's deltas
+func (s *Server) unsubscribeConversations(c *Client) {
+	s.stream.mu.Lock()
+	defer s.stream.mu.Unlock()
+	delete(s.stream.subscribers, c)
+}
+
+// streamConversations updates subscribers each interval
+func (s *Server) streamConversations(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.tickConversationStream()
+	}
+}
+
+// tickConversationStream sends subscribers the delta since the last tick, and
+// a fresh snapshot to those whose queue was too full for an earlier message
+func (s *Server) tickConversationStream() {
+	s.stream.mu.Lock()
+	defer s.stream.mu.Unlock()
+
+	if len(s.stream.subscribers) == 0 {
+		// Nobody to compare against; the next subscriber starts afresh
+		s.stream.last = nil
+		return
+	}
+	s.stepConversationStream()
+	for c, inStep := range s.stream.subscribers {
+		if !inStep {
+			s.stream.subscribers[c] = c.sendSnapshot(s.stream.seq, s.stream.last)
+		}
+	}
+}
+
+// stepConversationStream compares the manager's summaries with those last
+// sent and, when any changed, sends the delta to the subscribers in step.
+// Called with the stream locked.
+func (s *Server) stepConversationStream() {
+	current := make(map[string]models.ConversationSummary)
+	delta := ConversationDelta{Added: []models.ConversationSummary{}, Updated: []models.ConversationSummary{}, Removed: []string{}}
+	for _, summary := range s.convMgr.GetConversationSummaries() {
+		current[summary.ID] = summary
+		previous, known := s.stream.last[summary.ID]
+		switch {
+		case !known:
+			delta.Added = append(delta.Added, summary)
+		case changed(previous, summary):
+			delta.Updated = append(delta.Updated, summary)
+		}
+	}
+	for id := range s.stream.last {
+		if _, exists := current[id]; !exists {
+			delta.Removed = append(delta.Removed, id)
+		}
+	}
+	s.stream.last = current
+	if len(delta.Added)+len(delta.Updated)+len(delta.Removed) == 0 {
+		return
+	}
+
+	s.stream.seq++
+	delta.Seq = s.stream.seq
+	sort.Strings(delta.Removed)
+	message := outbound{msgType: "conversations_delta", payload: delta}
+	for c, inStep := range s.stream.subscribers {
+		if !inStep {
+			continue
+		}
+		data, err := message.encoded(c.binary)
+		if err != nil {
+			log.Printf("Failed to encode conversations delta: %v", err)
+			return
+		}
+		if !c.queueMessage(data) {
+			// It missed this delta, so it gets a snapshot instead of the next
+			s.stream.subscribers[c] = false
+		}
+	}
+}
+
+// changed reports whether a conversation's summary differs from the one last
+// sent. Its duration and rates are recomputed as of each tick, so they'd make
+// every open conversation look changed; they're left out of the comparison and
+// brought up to date whenever anything else changes.
+func changed(previous, current models.ConversationSummary) bool {
+	previous.Duration, current.Duration = "", ""
+	previous.RateHistory, current.RateHistory = nil, nil
+	previous.BytesInPerSec, current.BytesInPerSec = 0, 0
+	previous.BytesOutPerSec, current.BytesOutPerSec = 0, 0
+	return !reflect.DeepEqual(previous, current)
+}
+
+// sendSnapshot queues every conversation in last, reporting whether it fit
+func (c *Client) sendSnapshot(seq uint64, last map[string]models.ConversationSummary) bool {
+	snapshot := ConversationSnapshot{Seq: seq, Conversations: make([]models.ConversationSummary, 0, len(last))}
+	for _, summary := range last {
+		snapshot.Conversations = append(snapshot.Conversations, summary)
+	}
+	data, err := encodeMessage(c.binary, "conversations_snapshot", snapshot)
+	if err != nil {
+		return false
+	}
+	return c.queueMessage(data)
+}
+
+// queueMessage queues an encoded message, counting it as dropped when the
+// client's queue is full
+func (c *Client) queueMessage(data []byte) bool {
+	if c.safeSend(data) {
+		return true
+	}
+	atomic.AddUint64(&c.loss.droppedMessages, 1)
+	return false
+}
@@ -0,0 +1,94 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/conversation"
+	"github.com/iolloyd/netty/daemon/internal/models"
+)
+
+// receive decodes the next message queued for a client
+func receive(t *testing.T, c *Client, msgType string, payload interface{}) {
+	t.Helper()
+	select {
+	case data := <-c.send:
+		var msg struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		json.Unmarshal(data, &msg)
+		if msg.Type != msgType {
+			t.Fatalf("Expected %s, got %s", msgType, data)
+		}
+		json.Unmarshal(msg.Data, payload)
+	default:
+		t.Fatalf("Expected %s, got nothing", msgType)
+	}
+}
+
+func TestConversationStream(t *testing.T) {
+	mgr := conversation.NewManager("192.168.1.10")
+	now := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	mgr.SetClock(func() time.Time { return now })
+	dns := &models.NetworkEvent{Timestamp: now, TransportProtocol: "UDP", SourceIP: "192.168.1.10", SourcePort: 53000, DestIP: "192.168.1.1", DestPort: 53, Size: 60}
+	mgr.ProcessEvent(dns)
+	s := NewServer("0")
+	s.SetConversationManager(mgr)
+
+	c := &Client{send: make(chan []byte, 1), server: s}
+	s.subscribeConversations(c)
+	var snapshot ConversationSnapshot
+	receive(t, c, "conversations_snapshot", &snapshot)
+	if snapshot.Seq != 1 || len(snapshot.Conversations) != 1 {
+		t.Fatalf("Expected the one conversation at seq 1, got %+v", snapshot)
+	}
+
+	// Nothing changed, nothing sent
+	s.tickConversationStream()
+	if len(c.send) != 0 {
+		t.Fatalf("Expected no delta without changes, got %s", <-c.send)
+	}
+
+	// Nor when only the clock moved, though the duration and rates did
+	now = now.Add(10 * time.Second)
+	s.tickConversationStream()
+	if len(c.send) != 0 {
+		t.Fatalf("Expected no delta for an idle conversation, got %s", <-c.send)
+	}
+
+	mgr.ProcessEvent(dns)
+	web := &models.NetworkEvent{Timestamp: now, TransportProtocol: "UDP", SourceIP: "192.168.1.10", SourcePort: 50000, DestIP: "203.0.113.7", DestPort: 443, Size: 1200}
+	mgr.ProcessEvent(web)
+	s.tickConversationStream()
+	var delta ConversationDelta
+	receive(t, c, "conversations_delta", &delta)
+	if delta.Seq != 2 || len(delta.Added) != 1 || len(delta.Updated) != 1 || len(delta.Removed) != 0 {
+		t.Fatalf("Expected the new flow added and the DNS flow updated at seq 2, got %+v", delta)
+	}
+	if updated := delta.Updated[0]; updated.ID != dns.ConversationID || updated.PacketsOut != 2 {
+		t.Errorf("Expected the DNS flow's second packet, got %+v", updated)
+	}
+
+	// A delta that doesn't fit the queue is replaced by a snapshot
+	mgr.Flush()
+	c.send <- []byte("{}")
+	s.tickConversationStream()
+	if s.stream.subscribers[c] {
+		t.Fatalf("Expected the client out of step after missing a delta")
+	}
+	<-c.send
+	s.tickConversationStream()
+	receive(t, c, "conversations_snapshot", &snapshot)
+	if snapshot.Seq != 3 || len(snapshot.Conversations) != 0 || !s.stream.subscribers[c] {
+		t.Errorf("Expected an empty snapshot at seq 3, got %+v", snapshot)
+	}
+
+	s.unsubscribeConversations(c)
+	mgr.ProcessEvent(dns)
+	s.tickConversationStream()
+	if len(c.send) != 0 || s.stream.last != nil {
+		t.Errorf("Expected no deltas and nothing kept after unsubscribing")
+	}
+}
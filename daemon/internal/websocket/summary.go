@@ -0,0 +1,24 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/iolloyd/netty/daemon/internal/summary"
+)
+
+// SetSummarizer sets the summarizer whose in-progress period is served at /api/summary
+func (s *Server) SetSummarizer(sum *summary.Summarizer) {
+	s.summarizer = sum
+}
+
+// handleSummary handles HTTP API requests for the current summary period
+func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	if s.summarizer == nil {
+		http.Error(w, "Summary reports not enabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.summarizer.Current())
+}
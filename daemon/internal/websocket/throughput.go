@@ -0,0 +1,18 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// handleThroughput handles HTTP API requests for the bytes received and sent
+// per second across every conversation
+func (s *Server) handleThroughput(w http.ResponseWriter, r *http.Request) {
+	if s.convMgr == nil {
+		http.Error(w, "Conversation manager not initialized", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.convMgr.GetThroughput())
+}
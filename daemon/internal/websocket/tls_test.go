@@ -0,0 +1,69 @@
+package websocket
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSelfSigned writes a self-signed certificate and its key to dir
+func writeSelfSigned(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "router.lan"},
+		DNSNames:     []string{"router.lan"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
+
+func TestSetTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSigned(t, dir)
+
+	s := NewServer("0")
+	if s.UsesTLS() {
+		t.Error("Expected a new server to serve cleartext")
+	}
+	if err := s.SetTLS(certFile, keyFile); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !s.UsesTLS() || len(s.tlsConfig.Certificates) != 1 {
+		t.Errorf("Expected the certificate to be loaded, got %+v", s.tlsConfig)
+	}
+
+	// A key that doesn't match the certificate fails at startup
+	_, otherKey := writeSelfSigned(t, t.TempDir())
+	if err := NewServer("0").SetTLS(certFile, otherKey); err == nil {
+		t.Error("Expected an error for a mismatched key")
+	}
+	if err := NewServer("0").SetTLS(filepath.Join(dir, "missing.pem"), keyFile); err == nil {
+		t.Error("Expected an error for a missing certificate")
+	}
+}
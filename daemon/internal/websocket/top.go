@@ -0,0 +1,72 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/iolloyd/netty/daemon/internal/conversation"
+)
+
+// handleTop handles HTTP API requests for the busiest hosts, ports, services
+// or programs over a recent window, e.g. /api/top?by=process&window=15m&limit=5
+func (s *Server) handleTop(w http.ResponseWriter, r *http.Request) {
+	if s.convMgr == nil {
+		http.Error(w, "Conversation manager not initialized", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	by := query.Get("by")
+	if by == "" {
+		by = conversation.TopByHost
+	}
+	window := conversation.DefaultTopWindow
+	if value := query.Get("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			http.Error(w, "Invalid window", http.StatusBadRequest)
+			return
+		}
+		window = parsed
+	}
+	limit := conversation.DefaultTopLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	top, err := s.convMgr.GetTopTalkers(by, window, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(top)
+}
+
+// handleTopCommand replies to a get_top command, ignoring invalid parameters
+// for their defaults
+func (c *Client) handleTopCommand(data json.RawMessage) {
+	if c.server.convMgr == nil {
+		return
+	}
+	params := struct {
+		By            string `json:"by"`
+		WindowSeconds int    `json:"window_seconds"`
+		Limit         int    `json:"limit"`
+	}{conversation.TopByHost, int(conversation.DefaultTopWindow.Seconds()), conversation.DefaultTopLimit}
+	json.Unmarshal(data, &params)
+
+	top, err := c.server.convMgr.GetTopTalkers(params.By, time.Duration(params.WindowSeconds)*time.Second, params.Limit)
+	if err != nil {
+		top, _ = c.server.convMgr.GetTopTalkers(conversation.TopByHost, conversation.DefaultTopWindow, params.Limit)
+	}
+	c.sendMessage("top_talkers", top)
+}
@@ -0,0 +1,86 @@
+package websocket
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/iolloyd/netty/daemon/internal/watch"
+)
+
+// SetHostWatcher sets the watcher hosts are added to and whose activity is served
+func (s *Server) SetHostWatcher(w *watch.Watcher) {
+	s.hostWatcher = w
+}
+
+// handleWatch lists watched hosts (GET), watches a host (POST ?host=) or stops watching one (DELETE ?host=)
+func (s *Server) handleWatch(w http.ResponseWriter, r *http.Request) {
+	if s.hostWatcher == nil {
+		http.Error(w, "Host watch not available", http.StatusNotFound)
+		return
+	}
+
+	host := r.URL.Query().Get("host")
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if err := s.hostWatcher.Watch(host); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case http.MethodDelete:
+		if !s.hostWatcher.Unwatch(host) {
+			http.Error(w, "Host is not watched", http.StatusNotFound)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.hostWatcher.Profiles())
+}
+
+// handleWatchActivity handles HTTP API requests for watched hosts' recent activity (?host=&limit=)
+func (s *Server) handleWatchActivity(w http.ResponseWriter, r *http.Request) {
+	if s.hostWatcher == nil {
+		http.Error(w, "Host watch not available", http.StatusNotFound)
+		return
+	}
+	limit := 100
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.hostWatcher.Activity(r.URL.Query().Get("host"), limit))
+}
+
+// handleWatchCommand handles the watch_host, unwatch_host and get_watched_hosts commands,
+// answering with the updated "watched_hosts" list
+func (c *Client) handleWatchCommand(cmdType string, data json.RawMessage) {
+	if c.server.hostWatcher == nil {
+		return
+	}
+
+	var params struct {
+		Host string `json:"host"`
+	}
+	json.Unmarshal(data, &params)
+	switch cmdType {
+	case "watch_host":
+		if err := c.server.hostWatcher.Watch(params.Host); err != nil {
+			log.Printf("[WARNING] Ignoring watch_host command: %v", err)
+		}
+	case "unwatch_host":
+		c.server.hostWatcher.Unwatch(params.Host)
+	}
+	c.sendMessage("watched_hosts", c.server.hostWatcher.Profiles())
+}
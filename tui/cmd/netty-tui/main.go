@@ -6,22 +6,153 @@ import (
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/config"
+	"github.com/netty/tui/internal/filter"
+	"github.com/netty/tui/internal/report"
 	"github.com/netty/tui/internal/ui"
 	"github.com/netty/tui/internal/websocket"
 )
 
 func main() {
 	var (
-		host = flag.String("host", "localhost", "Daemon host address")
-		port = flag.Int("port", 8080, "Daemon WebSocket port")
+		host       = flag.String("host", "localhost", "Daemon host address, or a ws:// or wss:// URL")
+		port       = flag.Int("port", 8080, "Daemon WebSocket port")
+		socket     = flag.String("socket", "", "Connect through the daemon's Unix socket (-listen-unix) instead of -host and -port")
+		useTLS     = flag.Bool("tls", false, "Connect over TLS (wss and https), for a daemon started with -tls-cert")
+		tlsCA      = flag.String("tls-ca", "", "PEM file of CA certificates to trust for -tls, e.g. the daemon's self-signed certificate")
+		token      = flag.String("token", "", "API token for a daemon started with -api-token (better set in the config file)")
+		useMsgpack = flag.Bool("msgpack", false, "Ask the daemon for MessagePack binary frames instead of JSON")
+		themeName  = flag.String("theme", "default", "Color theme: default, high-contrast or mono")
+		accessible = flag.Bool("accessible", false, "Screen-reader-friendly rendering (no box drawing, textual markers)")
+		internet   = flag.Bool("internet-only", false, "Start with local traffic hidden, showing only flows to or from the internet")
+		display    = flag.String("filter", "", "Display filter for the packet list, e.g. 'tcp && dst.port == 443'")
+		reportDir  = flag.String("report-dir", ".", "Directory session reports are written to")
+		reportFmt  = flag.String("report-format", "md", "Session report format: md or html")
+		configPath = flag.String("config", config.DefaultPath(), "Path to the JSON config file")
+		recordPath = flag.String("record", "", "Save the daemon's message stream to this file")
+		replayPath = flag.String("replay", "", "Play back a recording made with -record instead of connecting")
+		speed      = flag.Float64("replay-speed", 1, "Replay pace: 1 is the original, 10 ten times faster, 0 as fast as possible")
 	)
 	flag.Parse()
 
-	// Create WebSocket client
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// Config file values apply unless the flag was given explicitly
+	setFlags := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	if !setFlags["host"] && cfg.Host != "" {
+		*host = cfg.Host
+	}
+	if !setFlags["port"] && cfg.Port != 0 {
+		*port = cfg.Port
+	}
+	if !setFlags["socket"] && cfg.Socket != "" {
+		*socket = cfg.Socket
+	}
+	if !setFlags["tls"] && cfg.TLS {
+		*useTLS = true
+	}
+	if !setFlags["tls-ca"] && cfg.TLSCA != "" {
+		*tlsCA = cfg.TLSCA
+	}
+	if !setFlags["token"] && cfg.Token != "" {
+		*token = cfg.Token
+	}
+	if !setFlags["msgpack"] && cfg.Msgpack {
+		*useMsgpack = true
+	}
+	if !setFlags["theme"] && cfg.Theme != "" {
+		*themeName = cfg.Theme
+	}
+	if !setFlags["accessible"] && cfg.Accessible {
+		*accessible = true
+	}
+	if !setFlags["internet-only"] && cfg.InternetOnly {
+		*internet = true
+	}
+	if !setFlags["filter"] && cfg.Filter != "" {
+		*display = cfg.Filter
+	}
+	if !setFlags["report-dir"] && cfg.ReportDir != "" {
+		*reportDir = cfg.ReportDir
+	}
+	if !setFlags["report-format"] && cfg.ReportFormat != "" {
+		*reportFmt = cfg.ReportFormat
+	}
+
+	keys, err := ui.NewKeymap(cfg.Keys)
+	if err != nil {
+		fmt.Printf("Invalid key bindings in %s: %v\n", *configPath, err)
+		os.Exit(1)
+	}
+
+	// Honor the NO_COLOR convention unless a theme was chosen explicitly
+	if os.Getenv("NO_COLOR") != "" && !setFlags["theme"] && cfg.Theme == "" {
+		*themeName = "mono"
+	}
+	if *reportFmt != string(report.FormatMarkdown) && *reportFmt != string(report.FormatHTML) {
+		fmt.Printf("Unsupported report format %q (use md or html)\n", *reportFmt)
+		os.Exit(1)
+	}
+
+	displayFilter, err := filter.Parse(*display)
+	if err != nil {
+		fmt.Printf("Invalid display filter: %v\n", err)
+		os.Exit(1)
+	}
+
+	theme, err := ui.ThemeByName(*themeName)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *recordPath != "" && *replayPath != "" {
+		fmt.Println("-record and -replay can't be used together")
+		os.Exit(1)
+	}
+
+	// Create WebSocket client, or one playing a recording back
 	wsClient := websocket.NewClient(*host, *port)
+	if *useTLS || *tlsCA != "" {
+		if err := wsClient.EnableTLS(*tlsCA); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	if *socket != "" {
+		wsClient.UseSocket(*socket)
+	}
+	wsClient.SetToken(*token)
+	wsClient.UseMsgpack(*useMsgpack)
+	if *replayPath != "" {
+		wsClient, err = websocket.NewReplayClient(*replayPath, *speed)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	if *recordPath != "" {
+		if err := wsClient.Record(*recordPath); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
 
 	// Create the UI model
-	model := ui.NewModel(wsClient)
+	model := ui.NewModel(wsClient, ui.Options{
+		Theme:        theme,
+		Accessible:   *accessible,
+		InternetOnly: *internet,
+		Filter:       displayFilter,
+		ReportDir:    *reportDir,
+		ReportFormat: report.Format(*reportFmt),
+		Keys:         keys,
+	})
 
 	// Create and run the Bubble Tea program
 	p := tea.NewProgram(model, tea.WithAltScreen())
@@ -33,4 +164,8 @@ func main() {
 
 	// Clean up
 	_ = wsClient.Close()
+	if err := wsClient.StopRecording(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 }
\ No newline at end of file
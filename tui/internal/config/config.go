@@ -0,0 +1,56 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Config holds TUI settings read from the config file. Command-line flags
+// take precedence over values set here.
+type Config struct {
+	Host         string              `json:"host,omitempty"`
+	Port         int                 `json:"port,omitempty"`
+	Socket       string              `json:"socket,omitempty"` // Unix socket, used instead of host and port
+	TLS          bool                `json:"tls,omitempty"`
+	TLSCA        string              `json:"tls_ca,omitempty"`
+	Token        string              `json:"token,omitempty"` // API token, kept here rather than on the command line
+	Msgpack      bool                `json:"msgpack,omitempty"`
+	Theme        string              `json:"theme,omitempty"`
+	Accessible   bool                `json:"accessible,omitempty"`
+	InternetOnly bool                `json:"internet_only,omitempty"`
+	Filter       string              `json:"filter,omitempty"` // Display filter, e.g. "tcp && dst.port == 443"
+	ReportDir    string              `json:"report_dir,omitempty"`
+	ReportFormat string              `json:"report_format,omitempty"`
+	Keys         map[string][]string `json:"keys,omitempty"` // Action name -> keys, replacing the defaults
+}
+
+// DefaultPath returns the per-user config file location, e.g. ~/.config/netty/tui.json
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "netty", "tui.json")
+}
+
+// Load reads the config file at path. A missing file yields an empty config.
+func Load(path string) (Config, error) {
+	var cfg Config
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return cfg, nil
+}
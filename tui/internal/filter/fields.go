@@ -0,0 +1,129 @@
+package filter
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/netty/tui/internal/models"
+)
+
+// field is something an expression can test. Exactly one of flag, number
+// and text is set, deciding which operators apply.
+type field struct {
+	flag    func(event *models.NetworkEvent) bool
+	number  func(event *models.NetworkEvent) []int64
+	text    func(event *models.NetworkEvent) []string
+	address bool // Compares against a subnet given as CIDR, e.g. 10.0.0.0/8
+}
+
+// fields are the names an expression can use
+var fields = map[string]*field{
+	"ip":   {text: func(e *models.NetworkEvent) []string { return []string{e.SourceIP, e.DestIP} }, address: true},
+	"ipv4": {flag: func(e *models.NetworkEvent) bool { return e.Protocol == "IPv4" }},
+	"ipv6": {flag: func(e *models.NetworkEvent) bool { return e.Protocol == "IPv6" }},
+	"tcp":  {flag: transport("TCP")},
+	"udp":  {flag: transport("UDP")},
+	"icmp": {flag: func(e *models.NetworkEvent) bool { return strings.HasPrefix(e.TransportProtocol, "ICMP") }},
+	"arp":  {flag: func(e *models.NetworkEvent) bool { return e.ARP != nil }},
+	"ndp":  {flag: func(e *models.NetworkEvent) bool { return e.NDP != nil }},
+	"dns":  {flag: func(e *models.NetworkEvent) bool { return e.DNS != nil }},
+	"http": {flag: func(e *models.NetworkEvent) bool { return e.HTTP != nil }},
+	"tls":  {flag: func(e *models.NetworkEvent) bool { return e.TLS != nil }},
+
+	"src.ip":   {text: func(e *models.NetworkEvent) []string { return []string{e.SourceIP} }, address: true},
+	"dst.ip":   {text: func(e *models.NetworkEvent) []string { return []string{e.DestIP} }, address: true},
+	"port":     {number: func(e *models.NetworkEvent) []int64 { return []int64{int64(e.SourcePort), int64(e.DestPort)} }},
+	"src.port": {number: func(e *models.NetworkEvent) []int64 { return []int64{int64(e.SourcePort)} }},
+	"dst.port": {number: func(e *models.NetworkEvent) []int64 { return []int64{int64(e.DestPort)} }},
+	"host": {text: func(e *models.NetworkEvent) []string {
+		return []string{e.SourceHostname, e.DestHostname, e.TLSServerName}
+	}},
+	"src.host":  {text: func(e *models.NetworkEvent) []string { return []string{e.SourceHostname} }},
+	"dst.host":  {text: func(e *models.NetworkEvent) []string { return []string{e.DestHostname} }},
+	"proto":     {text: func(e *models.NetworkEvent) []string { return []string{e.TransportProtocol} }},
+	"app":       {text: func(e *models.NetworkEvent) []string { return []string{e.AppProtocol} }},
+	"direction": {text: func(e *models.NetworkEvent) []string { return []string{e.Direction} }},
+	"iface":     {text: func(e *models.NetworkEvent) []string { return []string{e.Interface} }},
+	"size":      {number: func(e *models.NetworkEvent) []int64 { return []int64{int64(e.Size)} }},
+	"dscp":      {number: func(e *models.NetworkEvent) []int64 { return []int64{int64(e.DSCP)} }},
+	"vlan": {number: func(e *models.NetworkEvent) []int64 {
+		if e.OuterVLAN != 0 {
+			return []int64{int64(e.VLAN), int64(e.OuterVLAN)}
+		}
+		return []int64{int64(e.VLAN)}
+	}},
+
+	"tcp.flags.syn": {flag: tcpFlag(func(f *models.TCPPacketFlags) bool { return f.SYN })},
+	"tcp.flags.ack": {flag: tcpFlag(func(f *models.TCPPacketFlags) bool { return f.ACK })},
+	"tcp.flags.fin": {flag: tcpFlag(func(f *models.TCPPacketFlags) bool { return f.FIN })},
+	"tcp.flags.rst": {flag: tcpFlag(func(f *models.TCPPacketFlags) bool { return f.RST })},
+
+	"dns.query":  {text: dnsText(func(d *models.DNSInfo) []string { return []string{d.Query} })},
+	"dns.type":   {text: dnsText(func(d *models.DNSInfo) []string { return []string{d.QueryType} })},
+	"dns.rcode":  {text: dnsText(func(d *models.DNSInfo) []string { return []string{d.RCode} })},
+	"dns.answer": {text: dnsText(func(d *models.DNSInfo) []string { return d.Answers })},
+
+	"http.method": {text: httpText(func(h *models.HTTPInfo) string { return h.Method })},
+	"http.host":   {text: httpText(func(h *models.HTTPInfo) string { return h.Host })},
+	"http.path":   {text: httpText(func(h *models.HTTPInfo) string { return h.Path })},
+	"http.status": {number: func(e *models.NetworkEvent) []int64 {
+		if e.HTTP == nil {
+			return nil
+		}
+		return []int64{int64(e.HTTP.StatusCode)}
+	}},
+
+	"tls.sni": {text: func(e *models.NetworkEvent) []string {
+		if e.TLS != nil && e.TLS.ServerName != "" {
+			return []string{e.TLS.ServerName}
+		}
+		return []string{e.TLSServerName}
+	}},
+	"tls.version": {text: tlsText(func(t *models.TLSInfo) []string { return []string{t.Version} })},
+	"tls.alpn":    {text: tlsText(func(t *models.TLSInfo) []string { return t.ALPN })},
+}
+
+// Fields returns the names an expression can use, sorted
+func Fields() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func transport(name string) func(*models.NetworkEvent) bool {
+	return func(e *models.NetworkEvent) bool { return e.TransportProtocol == name }
+}
+
+func tcpFlag(set func(*models.TCPPacketFlags) bool) func(*models.NetworkEvent) bool {
+	return func(e *models.NetworkEvent) bool { return e.TCPFlags != nil && set(e.TCPFlags) }
+}
+
+func dnsText(get func(*models.DNSInfo) []string) func(*models.NetworkEvent) []string {
+	return func(e *models.NetworkEvent) []string {
+		if e.DNS == nil {
+			return nil
+		}
+		return get(e.DNS)
+	}
+}
+
+func httpText(get func(*models.HTTPInfo) string) func(*models.NetworkEvent) []string {
+	return func(e *models.NetworkEvent) []string {
+		if e.HTTP == nil {
+			return nil
+		}
+		return []string{get(e.HTTP)}
+	}
+}
+
+func tlsText(get func(*models.TLSInfo) []string) func(*models.NetworkEvent) []string {
+	return func(e *models.NetworkEvent) []string {
+		if e.TLS == nil {
+			return nil
+		}
+		return get(e.TLS)
+	}
+}
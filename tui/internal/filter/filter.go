@@ -0,0 +1,300 @@
+package filter
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/netty/tui/internal/models"
+)
+
+// Filter is a parsed display filter, e.g. `tcp && dst.port == 443 && ip
+// contains "10.0"`. A nil Filter matches every event.
+type Filter struct {
+	text string
+	root node
+}
+
+// Parse compiles a display filter expression. Blank text yields a nil Filter.
+func Parse(text string) (*Filter, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	tokens, err := lex(text)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at column %d", tok.text, tok.pos+1)
+	}
+	return &Filter{text: strings.TrimSpace(text), root: root}, nil
+}
+
+// Match reports whether an event passes the filter
+func (f *Filter) Match(event *models.NetworkEvent) bool {
+	if f == nil {
+		return true
+	}
+	return f.root.match(event)
+}
+
+// String returns the expression as given to Parse
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.text
+}
+
+// node is one operation of a parsed expression
+type node interface {
+	match(event *models.NetworkEvent) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) match(event *models.NetworkEvent) bool {
+	return n.left.match(event) && n.right.match(event)
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) match(event *models.NetworkEvent) bool {
+	return n.left.match(event) || n.right.match(event)
+}
+
+type notNode struct{ operand node }
+
+func (n notNode) match(event *models.NetworkEvent) bool {
+	return !n.operand.match(event)
+}
+
+// presentNode is a field on its own: true when the event has it, e.g. `dns`
+type presentNode struct{ field *field }
+
+func (n presentNode) match(event *models.NetworkEvent) bool {
+	switch {
+	case n.field.flag != nil:
+		return n.field.flag(event)
+	case n.field.number != nil:
+		for _, v := range n.field.number(event) {
+			if v != 0 {
+				return true
+			}
+		}
+	default:
+		for _, v := range n.field.text(event) {
+			if v != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compareNode compares a field with a value. A field with several values,
+// such as ip for both ends, matches when any of them does; != is the negation
+// of ==, so it matches when none does.
+type compareNode struct {
+	field  *field
+	op     string
+	text   string
+	number int64
+	re     *regexp.Regexp
+	subnet *net.IPNet
+}
+
+func (n compareNode) match(event *models.NetworkEvent) bool {
+	if n.op == "!=" {
+		eq := n
+		eq.op = "=="
+		return !eq.match(event)
+	}
+	if n.field.number != nil {
+		for _, v := range n.field.number(event) {
+			if compareNumbers(v, n.op, n.number) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, v := range n.field.text(event) {
+		if n.matchText(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n compareNode) matchText(v string) bool {
+	switch n.op {
+	case "contains":
+		return strings.Contains(strings.ToLower(v), n.text)
+	case "matches":
+		return n.re.MatchString(v)
+	}
+	if n.subnet != nil {
+		ip := net.ParseIP(v)
+		return ip != nil && n.subnet.Contains(ip)
+	}
+	return strings.EqualFold(v, n.text)
+}
+
+func compareNumbers(v int64, op string, operand int64) bool {
+	switch op {
+	case "==":
+		return v == operand
+	case "<":
+		return v < operand
+	case "<=":
+		return v <= operand
+	case ">":
+		return v > operand
+	case ">=":
+		return v >= operand
+	}
+	return false
+}
+
+// parser builds nodes from tokens by recursive descent, binding ! tighter
+// than && and && tighter than ||
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("||", "or") {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (node, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("&&", "and") {
+		p.next()
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) not() (node, error) {
+	if p.peek().is("!", "not") {
+		p.next()
+		operand, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokEOF:
+		return nil, fmt.Errorf("expression ends early, expected a field")
+	case tok.is("("):
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); !closing.is(")") {
+			return nil, fmt.Errorf("missing ) for the ( at column %d", tok.pos+1)
+		}
+		return inner, nil
+	case tok.kind != tokWord:
+		return nil, fmt.Errorf("unexpected %q at column %d, expected a field", tok.text, tok.pos+1)
+	}
+
+	f, ok := fields[strings.ToLower(tok.text)]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q at column %d", tok.text, tok.pos+1)
+	}
+	op := p.peek()
+	if !op.isOperator() {
+		return presentNode{f}, nil
+	}
+	p.next()
+	return p.comparison(tok.text, f, op)
+}
+
+// comparison parses the value a field is compared with
+func (p *parser) comparison(name string, f *field, op token) (node, error) {
+	value := p.next()
+	if value.kind != tokWord && value.kind != tokString {
+		return nil, fmt.Errorf("expected a value after %q at column %d", op.text, op.pos+1)
+	}
+	n := compareNode{field: f, op: strings.ToLower(op.text)}
+
+	switch {
+	case f.flag != nil:
+		return nil, fmt.Errorf("%s can't be compared, use it on its own", name)
+	case f.number != nil:
+		if n.op == "contains" || n.op == "matches" {
+			return nil, fmt.Errorf("%s is a number, %s needs text", name, n.op)
+		}
+		number, err := strconv.ParseInt(value.text, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is a number, %q isn't", name, value.text)
+		}
+		n.number = number
+	case n.op == "<" || n.op == "<=" || n.op == ">" || n.op == ">=":
+		return nil, fmt.Errorf("%s is text, %s needs a number", name, n.op)
+	case n.op == "matches":
+		re, err := regexp.Compile("(?i)" + value.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %v", value.text, err)
+		}
+		n.re = re
+	case n.op == "contains":
+		n.text = strings.ToLower(value.text)
+	default:
+		n.text = value.text
+		if f.address && strings.Contains(value.text, "/") {
+			_, subnet, err := net.ParseCIDR(value.text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid subnet %q", value.text)
+			}
+			n.subnet = subnet
+		}
+	}
+	return n, nil
+}
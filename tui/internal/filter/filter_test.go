@@ -0,0 +1,91 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/netty/tui/internal/models"
+)
+
+func TestFilter_Match(t *testing.T) {
+	https := &models.NetworkEvent{
+		Protocol: "IPv4", TransportProtocol: "TCP", AppProtocol: "HTTPS",
+		SourceIP: "10.0.0.5", DestIP: "140.82.112.3", SourcePort: 51000, DestPort: 443, Size: 1500,
+		Direction: "outgoing", DestHostname: "github.com", TLSServerName: "github.com",
+		TCPFlags: &models.TCPPacketFlags{SYN: true},
+	}
+	lookup := &models.NetworkEvent{
+		Protocol: "IPv6", TransportProtocol: "UDP", AppProtocol: "DNS",
+		SourceIP: "fe80::1", DestIP: "fe80::53", SourcePort: 53000, DestPort: 53, Size: 80, VLAN: 20, OuterVLAN: 100,
+		DNS: &models.DNSInfo{Query: "example.com", QueryType: "AAAA", Response: true, RCode: "NXDOMAIN"},
+	}
+	arp := &models.NetworkEvent{ARP: &models.ARPInfo{Operation: "request"}}
+
+	tests := []struct {
+		expr string
+		want [3]bool // https, lookup, arp
+	}{
+		{`tcp && dst.port == 443 && ip contains "10.0"`, [3]bool{true, false, false}},
+		{`tcp and dst.port == 443 and not ip contains "10.0"`, [3]bool{false, false, false}},
+		{`udp || arp`, [3]bool{false, true, true}},
+		{`!(tcp || udp)`, [3]bool{false, false, true}},
+		{`port == 53 || port == 443`, [3]bool{true, true, false}},
+		{`port != 443`, [3]bool{false, true, true}},
+		{`size >= 100 && size < 2000`, [3]bool{true, false, false}},
+		{`ip == 10.0.0.0/8`, [3]bool{true, false, false}},
+		{`src.ip == 10.0.0.5 && dst.ip == 140.82.112.3`, [3]bool{true, false, false}},
+		{`host matches "^git(hub|lab)\\.com$"`, [3]bool{true, false, false}},
+		{`app == https`, [3]bool{true, false, false}},
+		{`dns.rcode == NXDOMAIN && dns.query contains EXAMPLE`, [3]bool{false, true, false}},
+		{`tls.sni == "github.com" && tcp.flags.syn`, [3]bool{true, false, false}},
+		{`ipv6 || ipv4 && direction == outgoing`, [3]bool{true, true, false}},
+		{`ip`, [3]bool{true, true, false}},
+		{`vlan == 20 && vlan == 100`, [3]bool{false, true, false}},
+		{`vlan == 0`, [3]bool{true, false, true}},
+	}
+	for _, tt := range tests {
+		f, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		for i, event := range []*models.NetworkEvent{https, lookup, arp} {
+			if got := f.Match(event); got != tt.want[i] {
+				t.Errorf("%q on event %d: got %v, want %v", tt.expr, i, got, tt.want[i])
+			}
+		}
+	}
+
+	// No filter lets everything through
+	f, err := Parse("  ")
+	if err != nil || f != nil || !f.Match(arp) {
+		t.Errorf("Expected a blank expression to match everything, got %v, %v", f, err)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{`tcp &&`, "ends early"},
+		{`(tcp || udp`, "missing )"},
+		{`tcp udp`, `unexpected "udp" at column 5`},
+		{`colour == red`, `unknown field "colour"`},
+		{`port == https`, "is a number"},
+		{`host > 3`, "needs a number"},
+		{`port contains 4`, "needs text"},
+		{`tcp == 1`, "can't be compared"},
+		{`ip == 10.0.0.0/33`, "invalid subnet"},
+		{`host matches "("`, "invalid regular expression"},
+		{`host == "github.com`, "unterminated string"},
+		{`port = 443`, "use =="},
+		{`dst.port ==`, `expected a value after "=="`},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.expr)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q): expected an error containing %q, got %v", tt.expr, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF    tokenKind = iota
+	tokWord             // A field name, keyword or bare value such as 10.0.0.1
+	tokString           // A quoted value
+	tokSymbol           // An operator or parenthesis
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // Byte offset in the expression
+}
+
+// is reports whether the token is one of the given symbols or keywords
+func (t token) is(texts ...string) bool {
+	if t.kind != tokWord && t.kind != tokSymbol {
+		return false
+	}
+	for _, text := range texts {
+		if strings.EqualFold(t.text, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// isOperator reports whether the token compares a field with a value
+func (t token) isOperator() bool {
+	return t.is("==", "!=", "<", "<=", ">", ">=", "contains", "matches")
+}
+
+// symbols are the operators and parentheses, longest first so "<=" wins over "<"
+var symbols = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")"}
+
+// lex splits an expression into tokens, ending with a tokEOF
+func lex(text string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+			continue
+		case c == '"':
+			value, n, err := lexString(text[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%v at column %d", err, i+1)
+			}
+			tokens = append(tokens, token{kind: tokString, text: value, pos: i})
+			i += n
+			continue
+		}
+		if symbol := symbolAt(text[i:]); symbol != "" {
+			tokens = append(tokens, token{kind: tokSymbol, text: symbol, pos: i})
+			i += len(symbol)
+			continue
+		}
+		if strings.ContainsRune("=&|", rune(c)) {
+			return nil, fmt.Errorf("unexpected %q at column %d (use ==, && or ||)", c, i+1)
+		}
+		start := i
+		for i < len(text) && !strings.ContainsRune(" \t\"=&|!<>()", rune(text[i])) {
+			i++
+		}
+		tokens = append(tokens, token{kind: tokWord, text: text[start:i], pos: start})
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(text)}), nil
+}
+
+func symbolAt(text string) string {
+	for _, symbol := range symbols {
+		if strings.HasPrefix(text, symbol) {
+			return symbol
+		}
+	}
+	return ""
+}
+
+// lexString reads a double-quoted value, where \" and \\ escape, returning
+// it and the bytes consumed
+func lexString(text string) (string, int, error) {
+	var value strings.Builder
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '"':
+			return value.String(), i + 1, nil
+		case '\\':
+			if i+1 < len(text) {
+				i++
+			}
+		}
+		value.WriteByte(text[i])
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Alert severities
+const (
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+// Alert statuses
+const (
+	AlertStatusOpen         = "open"
+	AlertStatusAcknowledged = "acknowledged"
+	AlertStatusResolved     = "resolved"
+)
+
+// Alert is a rule match or detection raised by the daemon
+type Alert struct {
+	ID             string     `json:"id"`
+	Time           time.Time  `json:"time"`
+	Type           string     `json:"type"`
+	Severity       string     `json:"severity"`
+	Message        string     `json:"message"`
+	Host           string     `json:"host,omitempty"`
+	ConversationID string     `json:"conversation_id,omitempty"`
+	Status         string     `json:"status"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+}
+
+// IsOpen returns true if the alert hasn't been acknowledged or resolved
+func (a *Alert) IsOpen() bool {
+	return a.Status == AlertStatusOpen
+}
+
+// IsResolved returns true if the alert has been resolved
+func (a *Alert) IsResolved() bool {
+	return a.Status == AlertStatusResolved
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -38,6 +40,87 @@ type Conversation struct {
 	BytesOut       int64             `json:"bytes_out"`
 	Service        string            `json:"service,omitempty"`
 	LastActivity   time.Time         `json:"last_activity"`
+	LocalMSS       int               `json:"local_mss,omitempty"`
+	RemoteMSS      int               `json:"remote_mss,omitempty"`
+	MaxSegmentOut  int               `json:"max_segment_out,omitempty"`
+	MaxSegmentIn   int               `json:"max_segment_in,omitempty"`
+	Fragments      int64             `json:"fragments,omitempty"`
+	PMTUSuspect    bool              `json:"pmtu_blackhole_suspect,omitempty"`
+	DSCP           []string          `json:"dscp,omitempty"`
+	ECNCapable     int64             `json:"ecn_capable_packets,omitempty"`
+	ECNCongested   int64             `json:"ecn_ce_packets,omitempty"`
+	RemoteHostname string            `json:"remote_hostname,omitempty"`
+	ServerName     string            `json:"server_name,omitempty"`
+	StartTime      time.Time         `json:"start_time"`
+	HandshakeRTTMs float64           `json:"handshake_rtt_ms,omitempty"`
+	SYNACKRTTMs    float64           `json:"syn_ack_rtt_ms,omitempty"` // SYN to SYN-ACK
+	ACKRTTMs       float64           `json:"ack_rtt_ms,omitempty"`     // SYN-ACK to the final ACK
+	DataRTTMs      float64           `json:"data_rtt_ms,omitempty"`    // Smoothed, for data the local end sent
+	DataRTTSamples int               `json:"data_rtt_samples,omitempty"`
+	TCPFlags       []string          `json:"tcp_flags,omitempty"` // e.g. SYN, SYN-ACK, ACK, FIN local
+	RateHistory    []int64           `json:"rate_history,omitempty"` // Bytes per 2s slot, oldest first
+	BytesInPerSec  float64           `json:"bytes_in_per_sec"`       // Averaged over the last few seconds
+	BytesOutPerSec float64           `json:"bytes_out_per_sec"`
+	HTTP           *HTTPStats        `json:"http,omitempty"`
+	TLS            *TLSStats         `json:"tls,omitempty"`
+	Geo            *GeoInfo          `json:"geo,omitempty"` // Of the remote end
+}
+
+// TLSStats is what a conversation's TLS handshake offered and settled on
+type TLSStats struct {
+	Version        string   `json:"version,omitempty"` // Negotiated
+	ClientVersion  string   `json:"client_version,omitempty"`
+	CipherSuite    string   `json:"cipher_suite,omitempty"`
+	OfferedCiphers []string `json:"offered_ciphers,omitempty"`
+	ALPN           string   `json:"alpn,omitempty"`
+	OfferedALPN    []string `json:"offered_alpn,omitempty"`
+	Legacy         bool     `json:"legacy,omitempty"` // SSL 3.0, TLS 1.0 or 1.1 negotiated
+}
+
+// Summary describes the session, e.g. "TLS 1.1 (legacy)
+// TLS_RSA_WITH_AES_128_CBC_SHA http/1.1", or what the client offered when
+// the ServerHello wasn't seen
+func (t *TLSStats) Summary() string {
+	if t.Version == "" {
+		return "offered " + t.ClientVersion
+	}
+	version := t.Version
+	if t.Legacy {
+		version += " (legacy)"
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s %s", version, t.CipherSuite, t.ALPN))
+}
+
+// HTTPStats summarises the plaintext HTTP/1.x messages of a conversation
+type HTTPStats struct {
+	Requests   int64  `json:"requests"`
+	Responses  int64  `json:"responses"`
+	Errors     int64  `json:"errors"` // Responses with a 4xx or 5xx status
+	Method     string `json:"method,omitempty"`
+	Host       string `json:"host,omitempty"`
+	Path       string `json:"path,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+}
+
+// Summary describes the latest exchange, e.g. "GET example.com/ -> 200 (3 requests, 1 error)"
+func (h *HTTPStats) Summary() string {
+	summary := h.Method + " " + h.Host + h.Path
+	if h.StatusCode > 0 {
+		summary += fmt.Sprintf(" -> %d", h.StatusCode)
+	}
+	summary += fmt.Sprintf(" (%d %s", h.Requests, plural(h.Requests, "request"))
+	if h.Errors > 0 {
+		summary += fmt.Sprintf(", %d %s", h.Errors, plural(h.Errors, "error"))
+	}
+	return summary + ")"
+}
+
+func plural(n int64, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
 }
 
 // TCPFlags tracks which TCP flags have been seen in the conversation
@@ -71,6 +154,11 @@ func (c *Conversation) TotalBytes() int64 {
 	return c.BytesIn + c.BytesOut
 }
 
+// BytesPerSec returns the current throughput in both directions
+func (c *Conversation) BytesPerSec() float64 {
+	return c.BytesInPerSec + c.BytesOutPerSec
+}
+
 // GetEndpointPair returns a formatted string of the conversation endpoints
 func (c *Conversation) GetEndpointPair() string {
 	return fmt.Sprintf("%s → %s", c.LocalAddr, c.RemoteAddr)
@@ -82,4 +170,82 @@ func (c *Conversation) GetServiceInfo() string {
 		return c.Service
 	}
 	return c.Protocol
-}
\ No newline at end of file
+}
+
+// RemoteName returns the best name for the remote end: the TLS server name,
+// then the resolved hostname, then the remote IP
+func (c *Conversation) RemoteName() string {
+	if c.ServerName != "" {
+		return c.ServerName
+	}
+	if c.RemoteHostname != "" {
+		return c.RemoteHostname
+	}
+	host, _ := c.RemoteEndpoint()
+	return host
+}
+
+// CountryCode returns the remote end's country code, or "--" when the daemon
+// couldn't place it
+func (c *Conversation) CountryCode() string {
+	if c.Geo == nil || c.Geo.CountryCode == "" {
+		return "--"
+	}
+	return c.Geo.CountryCode
+}
+
+// RemoteEndpoint splits RemoteAddr into host and port, tolerating unbracketed IPv6 addresses
+func (c *Conversation) RemoteEndpoint() (string, int) {
+	return splitEndpoint(c.RemoteAddr)
+}
+
+// LocalEndpoint splits LocalAddr into host and port, tolerating unbracketed IPv6 addresses
+func (c *Conversation) LocalEndpoint() (string, int) {
+	return splitEndpoint(c.LocalAddr)
+}
+
+func splitEndpoint(addr string) (string, int) {
+	idx := strings.LastIndex(addr, ":")
+	if idx < 0 {
+		return addr, 0
+	}
+	port, err := strconv.Atoi(addr[idx+1:])
+	if err != nil {
+		return addr, 0
+	}
+	return strings.Trim(addr[:idx], "[]"), port
+}
+
+// PathInfo summarizes MSS, segment sizes and fragmentation, or "" if nothing was observed
+func (c *Conversation) PathInfo() string {
+	var parts []string
+	if c.LocalMSS > 0 || c.RemoteMSS > 0 {
+		parts = append(parts, fmt.Sprintf("MSS %s/%s", sizeOrDash(c.LocalMSS), sizeOrDash(c.RemoteMSS)))
+	}
+	if c.MaxSegmentOut > 0 || c.MaxSegmentIn > 0 {
+		parts = append(parts, fmt.Sprintf("max seg %s/%s", sizeOrDash(c.MaxSegmentOut), sizeOrDash(c.MaxSegmentIn)))
+	}
+	if c.Fragments > 0 {
+		parts = append(parts, fmt.Sprintf("%d frags", c.Fragments))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// QoSInfo summarizes DSCP classes and ECN marks, or "" if the conversation is unmarked
+func (c *Conversation) QoSInfo() string {
+	var parts []string
+	if len(c.DSCP) > 1 || (len(c.DSCP) == 1 && c.DSCP[0] != "CS0") {
+		parts = append(parts, "DSCP "+strings.Join(c.DSCP, ","))
+	}
+	if c.ECNCapable > 0 || c.ECNCongested > 0 {
+		parts = append(parts, fmt.Sprintf("ECN %d capable, %d CE", c.ECNCapable, c.ECNCongested))
+	}
+	return strings.Join(parts, " · ")
+}
+
+func sizeOrDash(size int) string {
+	if size == 0 {
+		return "-"
+	}
+	return strconv.Itoa(size)
+}
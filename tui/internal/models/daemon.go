@@ -0,0 +1,45 @@
+package models
+
+// DaemonInfo is what the daemon says about itself when a client connects
+type DaemonInfo struct {
+	Version      string   `json:"version"`
+	Protocol     int      `json:"protocol"` // API version, 0 for daemons that predate version reporting
+	Capabilities []string `json:"capabilities"`
+}
+
+// Has reports whether the daemon has an optional feature enabled
+func (d *DaemonInfo) Has(capability string) bool {
+	for _, c := range d.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// DaemonHealth is the daemon's /health report
+type DaemonHealth struct {
+	DaemonInfo
+	Status       string       `json:"status"` // healthy or degraded
+	Clients      int          `json:"clients"`
+	CaptureStats CaptureStats `json:"capture_stats"`
+}
+
+// CaptureStats are the daemon's packet capture counters
+type CaptureStats struct {
+	UptimeSeconds   float64 `json:"uptime_seconds"`
+	TotalPackets    int64   `json:"total_packets"`
+	DroppedPackets  int64   `json:"dropped_packets"`
+	ProcessedEvents int64   `json:"processed_events"`
+	CaptureState    string  `json:"capture_state"`
+	LastReadError   string  `json:"last_read_error,omitempty"`
+}
+
+// DropRate returns the share of events the daemon dropped instead of sending them to clients
+func (s CaptureStats) DropRate() float64 {
+	total := s.DroppedPackets + s.ProcessedEvents
+	if total == 0 {
+		return 0
+	}
+	return float64(s.DroppedPackets) / float64(total)
+}
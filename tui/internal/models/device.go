@@ -0,0 +1,23 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Device is a MAC address the daemon has seen on the LAN
+type Device struct {
+	MAC       string    `json:"mac"`
+	IPs       []string  `json:"ips"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Packets   uint64    `json:"packets"`
+}
+
+// Addresses lists the device's IPs, or says it has none yet
+func (d *Device) Addresses() string {
+	if len(d.IPs) == 0 {
+		return "no address yet"
+	}
+	return strings.Join(d.IPs, ", ")
+}
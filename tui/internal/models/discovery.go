@@ -0,0 +1,60 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DiscoveredDevice is a device the daemon has seen advertising services
+// over mDNS or SSDP
+type DiscoveredDevice struct {
+	IP        string              `json:"ip"`
+	Name      string              `json:"name,omitempty"`   // Friendly name, e.g. "Living Room TV"
+	Host      string              `json:"host,omitempty"`   // mDNS host name
+	Server    string              `json:"server,omitempty"` // SSDP SERVER header
+	Services  []DiscoveredService `json:"services"`
+	FirstSeen time.Time           `json:"first_seen"`
+	LastSeen  time.Time           `json:"last_seen"`
+}
+
+// DiscoveredService is a service a device advertises
+type DiscoveredService struct {
+	Protocol  string    `json:"protocol"` // mdns or ssdp
+	Type      string    `json:"type"`
+	Instance  string    `json:"instance,omitempty"`
+	Port      int       `json:"port,omitempty"`
+	Location  string    `json:"location,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Label returns the device's friendly name, or failing that its host name or
+// SSDP server, or "" if it gave none
+func (d *DiscoveredDevice) Label() string {
+	switch {
+	case d.Name != "":
+		return d.Name
+	case d.Host != "":
+		return d.Host
+	}
+	return d.Server
+}
+
+// Short names the service type and port, e.g. "googlecast:8009" for
+// "_googlecast._tcp" or "MediaRenderer" for
+// "urn:schemas-upnp-org:device:MediaRenderer:1"
+func (s *DiscoveredService) Short() string {
+	name := s.Type
+	if s.Protocol == "mdns" {
+		name = strings.TrimPrefix(strings.SplitN(name, ".", 2)[0], "_")
+	} else if parts := strings.Split(name, ":"); len(parts) >= 5 && parts[0] == "urn" {
+		name = parts[len(parts)-2]
+	} else if len(parts) == 2 {
+		name = parts[1]
+	}
+	if s.Port != 0 {
+		name += ":" + strconv.Itoa(s.Port)
+	}
+	return name
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// DomainTraffic is the daemon's traffic with one domain name, or with a
+// registered domain and its subdomains as "*.example.com"
+type DomainTraffic struct {
+	Domain      string    `json:"domain"`
+	Connections int64     `json:"connections"`
+	BytesIn     int64     `json:"bytes_in"`
+	BytesOut    int64     `json:"bytes_out"`
+	Bytes       int64     `json:"bytes"`
+	Names       int       `json:"names,omitempty"` // Names grouped under a wildcard
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// DomainReport is the daemon's traffic per domain over a day, or since it started
+type DomainReport struct {
+	Since       time.Time       `json:"since"`
+	Day         string          `json:"day,omitempty"` // YYYY-MM-DD, "" for everything
+	Group       bool            `json:"group"`
+	Match       string          `json:"match,omitempty"`
+	Connections int64           `json:"connections"`
+	BytesIn     int64           `json:"bytes_in"`
+	BytesOut    int64           `json:"bytes_out"`
+	Bytes       int64           `json:"bytes"`
+	Domains     []DomainTraffic `json:"domains"` // Most bytes first
+	Days        []string        `json:"days"`    // Days the daemon keeps, oldest first
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// ServiceEncryption is how much of one service's traffic was encrypted
+type ServiceEncryption struct {
+	Service        string    `json:"service"` // Transport and port, e.g. "TCP/443"
+	Name           string    `json:"name,omitempty"`
+	EncryptedBytes int64     `json:"encrypted_bytes"`
+	PlaintextBytes int64     `json:"plaintext_bytes"`
+	UnknownBytes   int64     `json:"unknown_bytes"`
+	EncryptedRatio float64   `json:"encrypted_ratio"` // Of the classified bytes, 0 to 1
+	LastSeen       time.Time `json:"last_seen"`
+}
+
+// EncryptionSlot is the traffic of every service in one hour
+type EncryptionSlot struct {
+	Start          time.Time `json:"start"`
+	EncryptedBytes int64     `json:"encrypted_bytes"`
+	PlaintextBytes int64     `json:"plaintext_bytes"`
+	UnknownBytes   int64     `json:"unknown_bytes"`
+	EncryptedRatio float64   `json:"encrypted_ratio"`
+}
+
+// EncryptionReport is the encrypted and cleartext share of the traffic since
+// the daemon started
+type EncryptionReport struct {
+	Since          time.Time           `json:"since"`
+	EncryptedBytes int64               `json:"encrypted_bytes"`
+	PlaintextBytes int64               `json:"plaintext_bytes"`
+	UnknownBytes   int64               `json:"unknown_bytes"`
+	EncryptedRatio float64             `json:"encrypted_ratio"`
+	Services       []ServiceEncryption `json:"services"` // Most cleartext first
+	Hourly         []EncryptionSlot    `json:"hourly"`   // Oldest first
+}
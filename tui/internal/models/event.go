@@ -1,11 +1,16 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
 
 type NetworkEvent struct {
 	Timestamp         time.Time `json:"timestamp"`
 	Interface         string    `json:"interface"`
-	Direction         string    `json:"direction"` // incoming, outgoing, unknown
+	Direction         string    `json:"direction"` // incoming, outgoing, multicast, broadcast, unknown
 	Protocol          string    `json:"protocol"`   // IPv4, IPv6
 	TransportProtocol string    `json:"transport_protocol"` // TCP, UDP
 	AppProtocol       string    `json:"app_protocol,omitempty"` // HTTP, HTTPS, SSH, etc.
@@ -14,11 +19,17 @@ type NetworkEvent struct {
 	SourcePort        int       `json:"source_port"`
 	DestPort          int       `json:"dest_port"`
 	Size              int       `json:"size"`
+	VLAN              int       `json:"vlan,omitempty"`       // 802.1Q VLAN ID, the inner one of a QinQ frame
+	OuterVLAN         int       `json:"outer_vlan,omitempty"` // Outer VLAN ID of a QinQ frame
 	
 	// Hostname resolution
 	SourceHostname    string    `json:"source_hostname,omitempty"`
 	DestHostname      string    `json:"dest_hostname,omitempty"`
 	
+	// GeoIP location, for public addresses the daemon's databases list
+	SourceGeo         *GeoInfo  `json:"source_geo,omitempty"`
+	DestGeo           *GeoInfo  `json:"dest_geo,omitempty"`
+	
 	// TLS information
 	TLSServerName     string    `json:"tls_server_name,omitempty"` // SNI hostname
 	
@@ -29,6 +40,37 @@ type NetworkEvent struct {
 	TCPFlags          *TCPPacketFlags `json:"tcp_flags,omitempty"`
 	SequenceNumber    uint32    `json:"sequence_number,omitempty"`
 	AckNumber         uint32    `json:"ack_number,omitempty"`
+	PayloadSize       int       `json:"payload_size,omitempty"` // Transport payload bytes
+	Payload           []byte    `json:"payload,omitempty"`      // Its start, when the daemon runs with -payload-bytes
+	
+	// QoS markings
+	DSCP              int       `json:"dscp,omitempty"`
+	DSCPClass         string    `json:"dscp_class,omitempty"`
+	ECN               int       `json:"ecn,omitempty"`
+	
+	// Set on ARP events, which have no ports or conversation
+	ARP               *ARPInfo  `json:"arp,omitempty"`
+	
+	// Set on IPv6 neighbor discovery events, which have no ports or conversation either
+	NDP               *NDPInfo  `json:"ndp,omitempty"`
+	
+	// Decoded DNS query or response
+	DNS               *DNSInfo  `json:"dns,omitempty"`
+	
+	// Decoded plaintext HTTP/1.x request or response headers
+	HTTP              *HTTPInfo `json:"http,omitempty"`
+	
+	// Decoded TLS ClientHello or ServerHello
+	TLS               *TLSInfo  `json:"tls,omitempty"`
+	
+	// Tunnel the packet was decapsulated from, the rest describing the inner packet
+	Encapsulation     *Encapsulation `json:"encapsulation,omitempty"`
+	
+	// Client-assigned sequence number, a stable identity for list rows
+	Seq               uint64    `json:"-"`
+	
+	// Exact JSON received from the daemon, for the raw view
+	Raw               json.RawMessage `json:"-"`
 }
 
 // TCPPacketFlags represents TCP flags for a single packet
@@ -39,4 +81,205 @@ type TCPPacketFlags struct {
 	RST bool `json:"rst"`
 	PSH bool `json:"psh"`
 	URG bool `json:"urg"`
-}
\ No newline at end of file
+}
+
+// ARPInfo describes an ARP request or reply
+type ARPInfo struct {
+	Operation string `json:"operation"` // request or reply
+	SenderMAC string `json:"sender_mac"`
+	SenderIP  string `json:"sender_ip"`
+	TargetMAC string `json:"target_mac"`
+	TargetIP  string `json:"target_ip"`
+}
+
+// Encapsulation describes the tunnel a packet was carried in
+type Encapsulation struct {
+	Type        string `json:"type"`         // vxlan, gre or ipip
+	ID          uint32 `json:"id,omitempty"` // VXLAN network identifier (VNI) or GRE key
+	OuterSource string `json:"outer_source"`
+	OuterDest   string `json:"outer_dest"`
+}
+
+// Summary describes the tunnel, e.g. "VXLAN VNI 42 10.0.0.1 -> 10.0.0.2"
+func (e *Encapsulation) Summary() string {
+	kind := strings.ToUpper(e.Type)
+	if e.Type == "ipip" {
+		kind = "IP-in-IP"
+	}
+	switch {
+	case e.ID != 0 && e.Type == "vxlan":
+		kind += fmt.Sprintf(" VNI %d", e.ID)
+	case e.ID != 0:
+		kind += fmt.Sprintf(" key %d", e.ID)
+	}
+	return fmt.Sprintf("%s %s -> %s", kind, e.OuterSource, e.OuterDest)
+}
+
+// Summary describes the packet the way tcpdump does, e.g. "who-has 192.168.1.1 tell 192.168.1.20"
+func (a *ARPInfo) Summary() string {
+	switch {
+	case a.Operation == "reply":
+		return fmt.Sprintf("%s is-at %s", a.SenderIP, a.SenderMAC)
+	case a.SenderIP == "0.0.0.0":
+		return fmt.Sprintf("probe %s from %s", a.TargetIP, a.SenderMAC)
+	case a.SenderIP == a.TargetIP:
+		return fmt.Sprintf("announce %s at %s", a.SenderIP, a.SenderMAC)
+	}
+	return fmt.Sprintf("who-has %s tell %s", a.TargetIP, a.SenderIP)
+}
+
+// NDPInfo describes an ICMPv6 neighbor discovery message
+type NDPInfo struct {
+	Type           string   `json:"type"` // router_solicitation, router_advertisement, neighbor_solicitation or neighbor_advertisement
+	SenderIP       string   `json:"sender_ip"`
+	SenderMAC      string   `json:"sender_mac,omitempty"`
+	TargetIP       string   `json:"target_ip,omitempty"`
+	TargetMAC      string   `json:"target_mac,omitempty"`
+	Router         bool     `json:"router,omitempty"`
+	RouterLifetime int      `json:"router_lifetime,omitempty"`
+	Prefixes       []string `json:"prefixes,omitempty"`
+}
+
+// Summary describes the message in the style of ARPInfo.Summary, e.g. "who-has fe80::1 tell fe80::2"
+func (n *NDPInfo) Summary() string {
+	switch n.Type {
+	case "router_solicitation":
+		return "router solicitation from " + n.SenderIP
+	case "router_advertisement":
+		summary := fmt.Sprintf("router advertisement from %s", n.SenderIP)
+		if len(n.Prefixes) > 0 {
+			summary += " prefix " + strings.Join(n.Prefixes, ", ")
+		}
+		return summary
+	case "neighbor_solicitation":
+		if n.SenderIP == "::" {
+			return "probe " + n.TargetIP
+		}
+		return fmt.Sprintf("who-has %s tell %s", n.TargetIP, n.SenderIP)
+	case "neighbor_advertisement":
+		summary := fmt.Sprintf("%s is-at %s", n.TargetIP, n.TargetMAC)
+		if n.Router {
+			summary += " (router)"
+		}
+		return summary
+	}
+	return n.Type
+}
+
+// DNSInfo describes a DNS query or response
+type DNSInfo struct {
+	ID        uint16   `json:"id"`
+	Response  bool     `json:"response"`
+	Query     string   `json:"query"`
+	QueryType string   `json:"query_type"`
+	RCode     string   `json:"rcode,omitempty"`
+	Answers   []string `json:"answers,omitempty"`
+	LatencyMs float64  `json:"latency_ms,omitempty"`
+}
+
+// Summary describes the message, e.g. "A github.com" for a query and
+// "A github.com -> 140.82.112.3 (25ms)" for its response
+func (d *DNSInfo) Summary() string {
+	summary := d.QueryType + " " + d.Query
+	if !d.Response {
+		return summary
+	}
+	switch {
+	case d.RCode != "" && d.RCode != "NOERROR":
+		summary += " " + d.RCode
+	case len(d.Answers) > 0:
+		summary += " -> " + strings.Join(d.Answers, ", ")
+	}
+	if d.LatencyMs > 0 {
+		summary += fmt.Sprintf(" (%.0fms)", d.LatencyMs)
+	}
+	return summary
+}
+
+// HTTPInfo describes the start line and key headers of an HTTP/1.x message
+type HTTPInfo struct {
+	Response      bool   `json:"response"`
+	Version       string `json:"version"`
+	Method        string `json:"method,omitempty"`
+	Host          string `json:"host,omitempty"`
+	Path          string `json:"path,omitempty"`
+	StatusCode    int    `json:"status_code,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+	ContentLength int64  `json:"content_length,omitempty"`
+	UserAgent     string `json:"user_agent,omitempty"`
+}
+
+// StartLine returns the message's first line as sent, e.g. "GET /index.html
+// HTTP/1.1" or "HTTP/1.1 404 Not Found"
+func (h *HTTPInfo) StartLine() string {
+	if h.Response {
+		return strings.TrimSpace(fmt.Sprintf("%s %d %s", h.Version, h.StatusCode, h.Reason))
+	}
+	return fmt.Sprintf("%s %s %s", h.Method, orSlash(h.Path), h.Version)
+}
+
+// Summary describes the message, e.g. "GET example.com/index.html" for a
+// request and "404 Not Found" for a response
+func (h *HTTPInfo) Summary() string {
+	if h.Response {
+		return strings.TrimSpace(fmt.Sprintf("%d %s", h.StatusCode, h.Reason))
+	}
+	return h.Method + " " + h.Host + h.Path
+}
+
+// TLSInfo describes what a ClientHello offers or a ServerHello selects
+type TLSInfo struct {
+	Handshake    string   `json:"handshake"` // client_hello or server_hello
+	Version      string   `json:"version"`   // Highest offered or negotiated, e.g. "TLS 1.3"
+	CipherSuites []string `json:"cipher_suites,omitempty"`
+	CipherSuite  string   `json:"cipher_suite,omitempty"`
+	ALPN         []string `json:"alpn,omitempty"`
+	ServerName   string   `json:"server_name,omitempty"`
+}
+
+// IsServerHello reports whether the handshake message is the server's
+func (t *TLSInfo) IsServerHello() bool {
+	return t.Handshake == "server_hello"
+}
+
+// Summary describes the hello, e.g. "ServerHello TLS 1.2
+// TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 h2" or "ClientHello TLS 1.3, 17
+// suites, ALPN h2,http/1.1"
+func (t *TLSInfo) Summary() string {
+	if t.IsServerHello() {
+		return strings.TrimSpace(fmt.Sprintf("ServerHello %s %s %s", t.Version, t.CipherSuite, strings.Join(t.ALPN, ",")))
+	}
+	summary := fmt.Sprintf("ClientHello %s, %d suites", t.Version, len(t.CipherSuites))
+	if len(t.ALPN) > 0 {
+		summary += ", ALPN " + strings.Join(t.ALPN, ",")
+	}
+	return summary
+}
+
+func orSlash(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// DSCPName returns the DSCP class of the event, e.g. "EF (46)", named by the daemon
+func (e *NetworkEvent) DSCPName() string {
+	if e.DSCPClass != "" {
+		return fmt.Sprintf("%s (%d)", e.DSCPClass, e.DSCP)
+	}
+	return fmt.Sprintf("%d", e.DSCP)
+}
+
+// ECNName returns the ECN codepoint of the event
+func (e *NetworkEvent) ECNName() string {
+	switch e.ECN {
+	case 1:
+		return "ECT(1)"
+	case 2:
+		return "ECT(0)"
+	case 3:
+		return "CE (congestion experienced)"
+	}
+	return "Not-ECT"
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// FirewallTarget identifies the remote host (and optional port) to block
+type FirewallTarget struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// FirewallRules holds rules generated by the daemon and, once applied, the outcome
+type FirewallRules struct {
+	Backend string         `json:"backend"`
+	Target  FirewallTarget `json:"target"`
+	Rules   []string       `json:"rules"`
+	Applied bool           `json:"applied"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// Block is an automatic block placed by the daemon's rate-based blocker
+type Block struct {
+	Target         FirewallTarget `json:"target"`
+	Reason         string         `json:"reason"`
+	Since          time.Time      `json:"since"`
+	Expires        time.Time      `json:"expires"`
+	ConversationID string         `json:"conversation_id,omitempty"`
+}
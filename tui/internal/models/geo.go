@@ -0,0 +1,58 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GeoInfo is where the daemon's GeoIP databases place an address
+type GeoInfo struct {
+	CountryCode string `json:"country_code,omitempty"` // ISO 3166, e.g. "DE"
+	Country     string `json:"country,omitempty"`
+	City        string `json:"city,omitempty"`
+	ASN         uint   `json:"asn,omitempty"`
+	ASOrg       string `json:"as_org,omitempty"` // Network owner, e.g. "Hetzner Online GmbH"
+}
+
+// String describes the location and network, e.g. "Falkenstein, Germany
+// (DE), AS24940 Hetzner Online GmbH"
+func (g *GeoInfo) String() string {
+	if g == nil {
+		return ""
+	}
+	var parts []string
+	place := g.Country
+	if g.City != "" {
+		place = g.City + ", " + place
+	}
+	if g.CountryCode != "" {
+		parts = append(parts, strings.TrimPrefix(fmt.Sprintf("%s (%s)", place, g.CountryCode), " "))
+	}
+	if g.ASN != 0 {
+		parts = append(parts, strings.TrimSpace(fmt.Sprintf("AS%d %s", g.ASN, g.ASOrg)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// CountryStats aggregates the conversations whose remote end is in one
+// country. Those the daemon couldn't place are grouped under "--".
+type CountryStats struct {
+	CountryCode    string    `json:"country_code"`
+	Country        string    `json:"country"`
+	Conversations  int       `json:"conversations"`
+	Active         int       `json:"active"`
+	Hosts          int       `json:"hosts"` // Distinct remote addresses
+	PacketsIn      int64     `json:"packets_in"`
+	PacketsOut     int64     `json:"packets_out"`
+	BytesIn        int64     `json:"bytes_in"`
+	BytesOut       int64     `json:"bytes_out"`
+	BytesPerSecond float64   `json:"bytes_per_second"` // Current throughput
+	ASOrgs         []string  `json:"as_orgs"`          // Busiest networks first
+	LastActivity   time.Time `json:"last_activity"`
+}
+
+// TotalBytes returns bytes in both directions
+func (c *CountryStats) TotalBytes() int64 {
+	return c.BytesIn + c.BytesOut
+}
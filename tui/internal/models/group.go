@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// ServiceGroup aggregates the conversations with one remote service, e.g. every
+// connection to api.github.com over HTTPS
+type ServiceGroup struct {
+	Name            string    `json:"name"`
+	Service         string    `json:"service"`
+	Conversations   int       `json:"conversations"`
+	Active          int       `json:"active"`
+	PacketsIn       int64     `json:"packets_in"`
+	PacketsOut      int64     `json:"packets_out"`
+	BytesIn         int64     `json:"bytes_in"`
+	BytesOut        int64     `json:"bytes_out"`
+	FirstSeen       time.Time `json:"first_seen"`
+	LastActivity    time.Time `json:"last_activity"`
+	RemoteAddrs     []string  `json:"remote_addrs"`
+	ConversationIDs []string  `json:"conversation_ids"`
+}
+
+// TotalBytes returns bytes in both directions
+func (g *ServiceGroup) TotalBytes() int64 {
+	return g.BytesIn + g.BytesOut
+}
+
+// TotalPackets returns packets in both directions
+func (g *ServiceGroup) TotalPackets() int64 {
+	return g.PacketsIn + g.PacketsOut
+}
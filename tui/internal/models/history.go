@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// History is what the daemon's history store kept about a past time range
+type History struct {
+	From          time.Time      `json:"from"`
+	To            time.Time      `json:"to"`
+	Host          string         `json:"host,omitempty"`
+	Service       string         `json:"service,omitempty"`
+	Conversations []Conversation `json:"conversations"` // Active at some point in the range, oldest first
+	Events        []NetworkEvent `json:"events"`        // Sampled events in the range, oldest first
+	Truncated     bool           `json:"truncated"`     // More matched than the limit allowed
+	Error         string         `json:"error,omitempty"`
+}
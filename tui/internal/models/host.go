@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Host aggregates the daemon's conversations with one remote address
+type Host struct {
+	IP              string    `json:"ip"`
+	Names           []string  `json:"names"` // TLS server names and resolved hostnames
+	Conversations   int       `json:"conversations"`
+	Active          int       `json:"active"`
+	PacketsIn       int64     `json:"packets_in"`
+	PacketsOut      int64     `json:"packets_out"`
+	BytesIn         int64     `json:"bytes_in"`
+	BytesOut        int64     `json:"bytes_out"`
+	Bytes           int64     `json:"bytes"`
+	BytesPerSecond  float64   `json:"bytes_per_second"` // Current throughput
+	Services        []string  `json:"services"`
+	FirstSeen       time.Time `json:"first_seen"`
+	LastSeen        time.Time `json:"last_seen"`
+	Geo             *GeoInfo  `json:"geo,omitempty"`
+	ConversationIDs []string  `json:"conversation_ids"`
+}
+
+// Name returns the host's first name, or its IP without one
+func (h *Host) Name() string {
+	if len(h.Names) > 0 {
+		return h.Names[0]
+	}
+	return h.IP
+}
+
+// CountryCode returns the host's country code, or "--" when unknown
+func (h *Host) CountryCode() string {
+	if h.Geo == nil || h.Geo.CountryCode == "" {
+		return "--"
+	}
+	return h.Geo.CountryCode
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Lease is what the daemon has seen of a DHCP client
+type Lease struct {
+	MAC          string     `json:"mac"`
+	IP           string     `json:"ip,omitempty"`
+	Hostname     string     `json:"hostname,omitempty"`
+	VendorClass  string     `json:"vendor_class,omitempty"`
+	Server       string     `json:"server,omitempty"`
+	State        string     `json:"state"` // discovering, offered, requesting, bound, rejected, declined or released
+	LeaseSeconds uint32     `json:"lease_seconds,omitempty"`
+	Expires      *time.Time `json:"expires,omitempty"`
+	FirstSeen    time.Time  `json:"first_seen"`
+	LastSeen     time.Time  `json:"last_seen"`
+}
+
+// Bound reports whether the client holds its address
+func (l *Lease) Bound() bool {
+	return l.State == "bound"
+}
@@ -0,0 +1,18 @@
+package models
+
+// LossReport is the daemon's count of events dropped on the way to this
+// client since it connected, sent periodically
+type LossReport struct {
+	Since            string  `json:"since"`
+	Events           int64   `json:"events"`            // Network events the daemon queued for this client
+	KernelDropped    int64   `json:"kernel_dropped"`    // Packets the kernel dropped before capture read them
+	CaptureDropped   int64   `json:"capture_dropped"`   // Events dropped from the daemon's full capture channel
+	BroadcastDropped int64   `json:"broadcast_dropped"` // Sent by daemons from before per-client queues
+	ClientDropped    int64   `json:"client_dropped"`    // Events dropped from this client's full queue at the daemon
+	Completeness     float64 `json:"completeness"`      // Percent of events the daemon sent
+}
+
+// Lost returns the events dropped before reaching the client
+func (r *LossReport) Lost() int64 {
+	return r.KernelDropped + r.CaptureDropped + r.BroadcastDropped + r.ClientDropped
+}
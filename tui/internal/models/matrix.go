@@ -0,0 +1,10 @@
+package models
+
+// TrafficMatrix is the bytes exchanged between the busiest local hosts and
+// remote destinations
+type TrafficMatrix struct {
+	Locals  []string  `json:"locals"`  // Local hosts, busiest first
+	Remotes []string  `json:"remotes"` // Remote destinations, busiest first
+	Bytes   [][]int64 `json:"bytes"`   // Bytes[i][j] between Locals[i] and Remotes[j]
+	Other   []int64   `json:"other"`   // Bytes between Locals[i] and remotes not listed
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// ProcessStats aggregates the conversations of one local program, all its
+// PIDs together. Conversations the daemon couldn't attribute are "unknown".
+type ProcessStats struct {
+	Name           string    `json:"name"`
+	PIDs           []int     `json:"pids"`
+	Conversations  int       `json:"conversations"`
+	Active         int       `json:"active"`
+	PacketsIn      int64     `json:"packets_in"`
+	PacketsOut     int64     `json:"packets_out"`
+	BytesIn        int64     `json:"bytes_in"`
+	BytesOut       int64     `json:"bytes_out"`
+	BytesPerSecond float64   `json:"bytes_per_second"` // Current throughput
+	LastActivity   time.Time `json:"last_activity"`
+}
+
+// TotalPackets returns packets in both directions
+func (p *ProcessStats) TotalPackets() int64 {
+	return p.PacketsIn + p.PacketsOut
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// CaptureRestart is the daemon reopening its capture after the system woke
+// from sleep or the network changed
+type CaptureRestart struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"` // wake, address or interfaces
+	Detail  string    `json:"detail"`
+	LocalIP string    `json:"local_ip,omitempty"`
+}
+
+// Message describes the restart, e.g. "capture restarted after wake (asleep for 2h5m0s)"
+func (r *CaptureRestart) Message() string {
+	reason := "network change"
+	if r.Kind == "wake" {
+		reason = "wake"
+	}
+	message := "capture restarted after " + reason
+	if r.Detail != "" {
+		message += " (" + r.Detail + ")"
+	}
+	return message
+}
+
+// CaptureState is whether the daemon's capture is paused
+type CaptureState struct {
+	Paused bool       `json:"paused"`
+	Since  *time.Time `json:"since,omitempty"` // When it paused
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// ConversationStateChange is the daemon reporting a conversation moving to
+// another state, with its summary as of the change
+type ConversationStateChange struct {
+	Conversation Conversation      `json:"conversation"`
+	From         ConversationState `json:"from"`
+	To           ConversationState `json:"to"`
+	Reason       string            `json:"reason"` // syn, handshake, fin, rst or timeout
+	Time         time.Time         `json:"time"`
+}
+
+// ConversationSnapshot is every conversation the daemon tracks, sent when
+// subscribing to its conversation stream
+type ConversationSnapshot struct {
+	Seq           uint64         `json:"seq"`
+	Conversations []Conversation `json:"conversations"`
+}
+
+// ConversationDelta is what changed in the daemon's conversations since the
+// stream message numbered Seq-1
+type ConversationDelta struct {
+	Seq     uint64         `json:"seq"`
+	Added   []Conversation `json:"added"`
+	Updated []Conversation `json:"updated"`
+	Removed []string       `json:"removed"` // IDs
+}
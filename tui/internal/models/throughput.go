@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Throughput is the daemon's bytes per second each way across every
+// conversation, now and over the last minute
+type Throughput struct {
+	BytesInPerSec  float64   `json:"bytes_in_per_sec"` // Averaged over the last few seconds
+	BytesOutPerSec float64   `json:"bytes_out_per_sec"`
+	In             []int64   `json:"in"`  // Bytes received per second, oldest first, ending now
+	Out            []int64   `json:"out"` // Bytes sent per second, oldest first, ending now
+	Time           time.Time `json:"time"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// TopTalker is the traffic of one host, port, service or program over a window
+type TopTalker struct {
+	Key            string  `json:"key"` // e.g. "github.com", "TCP/443", "HTTPS" or "firefox"
+	Conversations  int     `json:"conversations"`
+	BytesIn        int64   `json:"bytes_in"`
+	BytesOut       int64   `json:"bytes_out"`
+	Bytes          int64   `json:"bytes"`
+	Share          float64 `json:"share"`            // Of all bytes in the window, 0 to 1
+	BytesPerSecond float64 `json:"bytes_per_second"` // Current throughput
+}
+
+// TopTalkers ranks the daemon's traffic over a window by one grouping, busiest first
+type TopTalkers struct {
+	By            string      `json:"by"` // host, port, service or process
+	WindowSeconds int         `json:"window_seconds"`
+	TotalBytes    int64       `json:"total_bytes"`
+	Talkers       []TopTalker `json:"talkers"`
+	Time          time.Time   `json:"time"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// WatchProfile is what a watched host has been seen doing
+type WatchProfile struct {
+	Host         string    `json:"host"`
+	Since        time.Time `json:"since"`
+	Connections  int64     `json:"connections"`
+	Destinations []string  `json:"destinations"`
+	Ports        []string  `json:"ports"`
+	Services     []string  `json:"services"`
+}
+
+// WatchActivity is one entry in a watched host's activity stream
+type WatchActivity struct {
+	Time           time.Time `json:"time"`
+	Host           string    `json:"host"`
+	Kind           string    `json:"kind"` // connection, new_destination, new_port or new_service
+	Peer           string    `json:"peer"`
+	PeerName       string    `json:"peer_name,omitempty"`
+	Port           int       `json:"port,omitempty"`
+	Protocol       string    `json:"protocol"`
+	Service        string    `json:"service,omitempty"`
+	Outgoing       bool      `json:"outgoing"`
+	ConversationID string    `json:"conversation_id,omitempty"`
+}
+
+// PeerLabel returns the peer's name if known, otherwise its address
+func (a *WatchActivity) PeerLabel() string {
+	if a.PeerName != "" {
+		return a.PeerName
+	}
+	return a.Peer
+}
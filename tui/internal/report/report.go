@@ -0,0 +1,262 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/netty/tui/internal/models"
+)
+
+// Format is the output format of a session report
+type Format string
+
+const (
+	FormatMarkdown Format = "md"
+	FormatHTML     Format = "html"
+)
+
+// topN is how many rows the ranked sections of a report show
+const topN = 10
+
+// Session is the data a report is generated from
+type Session struct {
+	Start         time.Time
+	End           time.Time
+	Daemon        string
+	TotalPackets  int
+	TotalBytes    int
+	Events        []models.NetworkEvent
+	Conversations []models.Conversation
+	Blocks        []models.Block
+}
+
+// Talker aggregates traffic for a single remote host
+type Talker struct {
+	Host          string
+	Bytes         int64
+	Packets       int64
+	Conversations int
+}
+
+// TopTalkers ranks remote hosts by bytes, using conversations when available
+// and falling back to the buffered events otherwise
+func TopTalkers(s Session, n int) []Talker {
+	talkers := make(map[string]*Talker)
+	get := func(host string) *Talker {
+		t, ok := talkers[host]
+		if !ok {
+			t = &Talker{Host: host}
+			talkers[host] = t
+		}
+		return t
+	}
+
+	if len(s.Conversations) > 0 {
+		for _, conv := range s.Conversations {
+			host, _ := conv.RemoteEndpoint()
+			t := get(host)
+			t.Bytes += conv.TotalBytes()
+			t.Packets += conv.TotalPackets()
+			t.Conversations++
+		}
+	} else {
+		for _, event := range s.Events {
+			host := event.DestIP
+			if event.Direction == "incoming" {
+				host = event.SourceIP
+			}
+			t := get(host)
+			t.Bytes += int64(event.Size)
+			t.Packets++
+		}
+	}
+
+	ranked := make([]Talker, 0, len(talkers))
+	for _, t := range talkers {
+		ranked = append(ranked, *t)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Bytes != ranked[j].Bytes {
+			return ranked[i].Bytes > ranked[j].Bytes
+		}
+		return ranked[i].Host < ranked[j].Host
+	})
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
+// NotableConversations returns the largest conversations by total bytes
+func NotableConversations(s Session, n int) []models.Conversation {
+	convs := make([]models.Conversation, len(s.Conversations))
+	copy(convs, s.Conversations)
+	sort.Slice(convs, func(i, j int) bool {
+		return convs[i].TotalBytes() > convs[j].TotalBytes()
+	})
+	if len(convs) > n {
+		convs = convs[:n]
+	}
+	return convs
+}
+
+// Markdown renders the session report as Markdown
+func Markdown(s Session) string {
+	var b strings.Builder
+
+	b.WriteString("# Netty Session Report\n\n")
+	fmt.Fprintf(&b, "- **Time range:** %s – %s (%s)\n", s.Start.Format(time.RFC3339), s.End.Format(time.RFC3339), s.End.Sub(s.Start).Round(time.Second))
+	if s.Daemon != "" {
+		fmt.Fprintf(&b, "- **Daemon:** %s\n", s.Daemon)
+	}
+	fmt.Fprintf(&b, "- **Packets seen:** %d (%s)\n", s.TotalPackets, formatBytes(int64(s.TotalBytes)))
+	fmt.Fprintf(&b, "- **Conversations:** %d\n\n", len(s.Conversations))
+
+	b.WriteString("## Top Talkers\n\n")
+	talkers := TopTalkers(s, topN)
+	if len(talkers) == 0 {
+		b.WriteString("No traffic recorded.\n\n")
+	} else {
+		b.WriteString("| Host | Bytes | Packets | Conversations |\n|---|---:|---:|---:|\n")
+		for _, t := range talkers {
+			fmt.Fprintf(&b, "| %s | %s | %d | %d |\n", t.Host, formatBytes(t.Bytes), t.Packets, t.Conversations)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Alerts\n\n")
+	if len(s.Blocks) == 0 {
+		b.WriteString("No alerts raised.\n\n")
+	} else {
+		for _, block := range s.Blocks {
+			fmt.Fprintf(&b, "- %s **blocked %s**: %s\n", block.Since.Format(time.RFC3339), blockTarget(block), block.Reason)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Notable Conversations\n\n")
+	convs := NotableConversations(s, topN)
+	if len(convs) == 0 {
+		b.WriteString("No conversations recorded.\n")
+	} else {
+		b.WriteString("| Conversation | Service | State | Packets | Data | Duration |\n|---|---|---|---:|---:|---:|\n")
+		for _, conv := range convs {
+			fmt.Fprintf(&b, "| %s | %s | %s | %d | %s | %s |\n",
+				conv.GetEndpointPair(), conv.GetServiceInfo(), conv.State, conv.TotalPackets(), formatBytes(conv.TotalBytes()), conv.Duration)
+		}
+	}
+
+	return b.String()
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"bytes":  formatBytes,
+	"time":   func(t time.Time) string { return t.Format(time.RFC3339) },
+	"target": blockTarget,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Netty Session Report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+</style>
+</head>
+<body>
+<h1>Netty Session Report</h1>
+<ul>
+<li><strong>Time range:</strong> {{time .Session.Start}} – {{time .Session.End}} ({{.Duration}})</li>
+{{if .Session.Daemon}}<li><strong>Daemon:</strong> {{.Session.Daemon}}</li>{{end}}
+<li><strong>Packets seen:</strong> {{.Session.TotalPackets}} ({{bytes .TotalBytes}})</li>
+<li><strong>Conversations:</strong> {{len .Session.Conversations}}</li>
+</ul>
+<h2>Top Talkers</h2>
+{{if .Talkers}}<table>
+<tr><th>Host</th><th>Bytes</th><th>Packets</th><th>Conversations</th></tr>
+{{range .Talkers}}<tr><td>{{.Host}}</td><td>{{bytes .Bytes}}</td><td>{{.Packets}}</td><td>{{.Conversations}}</td></tr>
+{{end}}</table>{{else}}<p>No traffic recorded.</p>{{end}}
+<h2>Alerts</h2>
+{{if .Session.Blocks}}<ul>
+{{range .Session.Blocks}}<li>{{time .Since}} <strong>blocked {{target .}}</strong>: {{.Reason}}</li>
+{{end}}</ul>{{else}}<p>No alerts raised.</p>{{end}}
+<h2>Notable Conversations</h2>
+{{if .Conversations}}<table>
+<tr><th>Conversation</th><th>Service</th><th>State</th><th>Packets</th><th>Data</th><th>Duration</th></tr>
+{{range .Conversations}}<tr><td>{{.GetEndpointPair}}</td><td>{{.GetServiceInfo}}</td><td>{{.State}}</td><td>{{.TotalPackets}}</td><td>{{bytes .TotalBytes}}</td><td>{{.Duration}}</td></tr>
+{{end}}</table>{{else}}<p>No conversations recorded.</p>{{end}}
+</body>
+</html>
+`))
+
+// HTML renders the session report as a standalone HTML page
+func HTML(s Session) (string, error) {
+	var buf bytes.Buffer
+	err := htmlTemplate.Execute(&buf, struct {
+		Session       Session
+		Duration      time.Duration
+		TotalBytes    int64
+		Talkers       []Talker
+		Conversations []models.Conversation
+	}{
+		Session:       s,
+		Duration:      s.End.Sub(s.Start).Round(time.Second),
+		TotalBytes:    int64(s.TotalBytes),
+		Talkers:       TopTalkers(s, topN),
+		Conversations: NotableConversations(s, topN),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// Write renders the report in the given format into dir and returns the file path
+func Write(s Session, dir string, format Format) (string, error) {
+	var content string
+	switch format {
+	case FormatMarkdown:
+		content = Markdown(s)
+	case FormatHTML:
+		var err error
+		if content, err = HTML(s); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("unsupported report format %q", format)
+	}
+
+	name := fmt.Sprintf("netty-report-%s.%s", s.End.Format("20060102-150405"), format)
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+	return path, nil
+}
+
+func blockTarget(block models.Block) string {
+	if block.Target.Port > 0 {
+		return fmt.Sprintf("%s port %d", block.Target.Host, block.Target.Port)
+	}
+	return block.Target.Host
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,84 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netty/tui/internal/models"
+)
+
+func testSession() Session {
+	end := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	return Session{
+		Start:        end.Add(-time.Hour),
+		End:          end,
+		TotalPackets: 30,
+		TotalBytes:   6000,
+		Conversations: []models.Conversation{
+			{ID: "a", Protocol: "TCP", LocalAddr: "10.0.0.2:5000", RemoteAddr: "203.0.113.7:443", State: models.ConversationStateEstablished, BytesIn: 4000, BytesOut: 1000, PacketsIn: 20, PacketsOut: 5, Service: "HTTPS"},
+			{ID: "b", Protocol: "UDP", LocalAddr: "10.0.0.2:5001", RemoteAddr: "198.51.100.1:53", State: models.ConversationStateClosed, BytesIn: 600, BytesOut: 400, PacketsIn: 3, PacketsOut: 2, Service: "DNS"},
+			{ID: "c", Protocol: "TCP", LocalAddr: "10.0.0.2:5002", RemoteAddr: "203.0.113.7:80", State: models.ConversationStateClosed, BytesIn: 100},
+		},
+		Blocks: []models.Block{
+			{Target: models.FirewallTarget{Host: "192.0.2.66"}, Reason: "too many packets", Since: end.Add(-time.Minute)},
+		},
+	}
+}
+
+func TestTopTalkers_GroupsByRemoteHost(t *testing.T) {
+	talkers := TopTalkers(testSession(), 10)
+	if len(talkers) != 2 {
+		t.Fatalf("Expected 2 talkers, got %d", len(talkers))
+	}
+	if talkers[0].Host != "203.0.113.7" || talkers[0].Bytes != 5100 || talkers[0].Conversations != 2 {
+		t.Errorf("Unexpected top talker: %+v", talkers[0])
+	}
+}
+
+func TestTopTalkers_FallsBackToEvents(t *testing.T) {
+	s := Session{Events: []models.NetworkEvent{
+		{SourceIP: "10.0.0.2", DestIP: "203.0.113.7", Direction: "outgoing", Size: 100},
+		{SourceIP: "203.0.113.7", DestIP: "10.0.0.2", Direction: "incoming", Size: 300},
+	}}
+	talkers := TopTalkers(s, 10)
+	if len(talkers) != 1 || talkers[0].Host != "203.0.113.7" || talkers[0].Bytes != 400 {
+		t.Errorf("Expected events to aggregate under the remote host, got %+v", talkers)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	md := Markdown(testSession())
+	for _, expected := range []string{
+		"# Netty Session Report",
+		"2025-07-01T11:00:00Z – 2025-07-01T12:00:00Z (1h0m0s)",
+		"| 203.0.113.7 | 5.0 KB | 25 | 2 |",
+		"**blocked 192.0.2.66**: too many packets",
+		"| 10.0.0.2:5000 → 203.0.113.7:443 | HTTPS | ESTABLISHED |",
+	} {
+		if !strings.Contains(md, expected) {
+			t.Errorf("Expected report to contain '%s', got:\n%s", expected, md)
+		}
+	}
+}
+
+func TestWrite_HTML(t *testing.T) {
+	dir := t.TempDir()
+	path, err := Write(testSession(), dir, FormatHTML)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if filepath.Base(path) != "netty-report-20250701-120000.html" {
+		t.Errorf("Unexpected report file name: %s", path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read report: %v", err)
+	}
+	if !strings.Contains(string(content), "<td>10.0.0.2:5000 → 203.0.113.7:443</td>") {
+		t.Errorf("Expected HTML report to list notable conversations, got:\n%s", content)
+	}
+}
@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/netty/tui/internal/models"
+)
+
+// upsertAlert adds a new alert or replaces an updated one, keeping the newest first
+func (m *Model) upsertAlert(alert models.Alert) {
+	for i := range m.alerts {
+		if m.alerts[i].ID == alert.ID {
+			m.alerts[i] = alert
+			return
+		}
+	}
+	m.alerts = append(m.alerts, alert)
+	sort.SliceStable(m.alerts, func(i, j int) bool {
+		return m.alerts[i].Time.After(m.alerts[j].Time)
+	})
+}
+
+// openAlertCount returns how many alerts haven't been acknowledged or resolved yet
+func (m *Model) openAlertCount() int {
+	count := 0
+	for _, alert := range m.alerts {
+		if alert.IsOpen() {
+			count++
+		}
+	}
+	return count
+}
+
+// alertAction acknowledges or resolves the selected alert. The daemon answers
+// with an updated alert that replaces the row.
+func (m *Model) alertAction(resolve bool) tea.Cmd {
+	if m.selectedIndex < 0 || m.selectedIndex >= len(m.alerts) {
+		return nil
+	}
+
+	id := m.alerts[m.selectedIndex].ID
+	return func() tea.Msg {
+		if m.wsClient != nil {
+			if resolve {
+				m.wsClient.ResolveAlert(id)
+			} else {
+				m.wsClient.AcknowledgeAlert(id)
+			}
+		}
+		return nil
+	}
+}
+
+// showAlertConversation switches to the conversation that triggered the selected alert
+func (m *Model) showAlertConversation() {
+	if m.selectedIndex < 0 || m.selectedIndex >= len(m.alerts) {
+		return
+	}
+	id := m.alerts[m.selectedIndex].ConversationID
+	if id == "" {
+		m.notice = "This alert has no triggering conversation"
+		return
+	}
+	for i, conv := range m.conversations {
+		if conv.ID == id {
+			m.viewMode = ViewModeConversations
+			m.selectedIndex = i
+			m.scrollOffset = 0
+			m.ensureSelectedVisible()
+			return
+		}
+	}
+	m.notice = "The triggering conversation is no longer tracked"
+}
+
+// requestAlerts asks the daemon for the full alert list
+func (m *Model) requestAlerts() tea.Cmd {
+	return func() tea.Msg {
+		if m.wsClient != nil {
+			m.wsClient.RequestAlerts()
+		}
+		return nil
+	}
+}
+
+// renderAlertList renders alerts, newest first
+func (m *Model) renderAlertList() string {
+	viewHeight := m.viewportHeight()
+
+	if len(m.alerts) == 0 {
+		message := "No alerts"
+		if !m.connected {
+			message = "Not connected to daemon"
+		}
+		return lipgloss.NewStyle().
+			Foreground(m.theme.Muted).
+			Align(lipgloss.Center).
+			Width(m.width).
+			Height(viewHeight).
+			Render(message)
+	}
+
+	var lines []string
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	header := m.headerPrefix("ST") + fmt.Sprintf("%-14s %-8s %-12s %-20s %-10s %s",
+		"Time", "Severity", "Status", "Type", "Conv", "Message")
+	lines = append(lines, headerStyle.Render(header))
+
+	endIdx := m.scrollOffset + viewHeight - 1
+	if endIdx > len(m.alerts) {
+		endIdx = len(m.alerts)
+	}
+	for i := m.scrollOffset; i < endIdx; i++ {
+		lines = append(lines, m.renderAlertLine(m.alerts[i], i == m.selectedIndex))
+	}
+
+	for len(lines) < viewHeight {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderAlertLine renders a single alert row, colored by severity until resolved
+func (m *Model) renderAlertLine(alert models.Alert, selected bool) string {
+	conversation := alert.ConversationID
+	if len(conversation) > 8 {
+		conversation = conversation[:8]
+	}
+	if conversation == "" {
+		conversation = "-"
+	}
+
+	line := m.rowPrefix(selected, alert.ID, alertStatusMarker(alert.Status)) + fmt.Sprintf("%-14s %-8s %-12s %-20s %-10s %s",
+		alert.Time.Format("01-02 15:04:05"),
+		strings.ToUpper(alert.Severity),
+		alert.Status,
+		truncateString(alert.Type, 20),
+		conversation,
+		alert.Message,
+	)
+	if m.width > 0 {
+		line = truncateString(line, m.width)
+	}
+
+	style := lipgloss.NewStyle()
+	switch {
+	case selected:
+		style = m.selectedStyle()
+	case alert.IsResolved():
+		style = style.Foreground(m.theme.Muted)
+	case alert.Severity == models.SeverityHigh:
+		style = style.Foreground(m.theme.Error).Bold(alert.IsOpen())
+	case alert.Severity == models.SeverityMedium:
+		style = style.Foreground(m.theme.Warning)
+	default:
+		style = style.Foreground(m.theme.Text)
+	}
+	return style.Width(m.width).Render(line)
+}
+
+// alertStatusMarker returns a short textual marker for an alert status
+func alertStatusMarker(status string) string {
+	switch status {
+	case models.AlertStatusOpen:
+		return "!"
+	case models.AlertStatusAcknowledged:
+		return "ACK"
+	case models.AlertStatusResolved:
+		return "OK"
+	}
+	return "?"
+}
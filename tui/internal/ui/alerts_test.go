@@ -0,0 +1,30 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/netty/tui/internal/models"
+)
+
+func TestUpsertAlert(t *testing.T) {
+	m := Model{}
+	now := time.Now()
+	m.upsertAlert(models.Alert{ID: "old", Time: now.Add(-time.Minute), Status: models.AlertStatusOpen})
+	m.upsertAlert(models.Alert{ID: "new", Time: now, Status: models.AlertStatusOpen})
+	if len(m.alerts) != 2 || m.alerts[0].ID != "new" {
+		t.Fatalf("Expected alerts newest first, got %+v", m.alerts)
+	}
+	if m.openAlertCount() != 2 {
+		t.Errorf("Expected 2 open alerts, got %d", m.openAlertCount())
+	}
+
+	// Updates from the daemon replace the existing row
+	m.upsertAlert(models.Alert{ID: "old", Time: now.Add(-time.Minute), Status: models.AlertStatusResolved})
+	if len(m.alerts) != 2 || m.alerts[1].Status != models.AlertStatusResolved {
+		t.Errorf("Expected the alert to be updated in place, got %+v", m.alerts)
+	}
+	if m.openAlertCount() != 1 {
+		t.Errorf("Expected 1 open alert, got %d", m.openAlertCount())
+	}
+}
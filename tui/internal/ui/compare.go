@@ -0,0 +1,231 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/netty/tui/internal/models"
+)
+
+// compareRow is one line of a side-by-side conversation comparison
+type compareRow struct {
+	label string
+	a, b  string
+}
+
+// differs reports whether the two sides have different values
+func (r compareRow) differs() bool {
+	return r.a != r.b
+}
+
+// markForCompare marks the selected conversation, or compares it with the one
+// already marked. Marking the same conversation again clears the mark.
+func (m *Model) markForCompare() {
+	if m.selectedIndex < 0 || m.selectedIndex >= len(m.conversations) {
+		return
+	}
+	conv := m.conversations[m.selectedIndex]
+
+	switch m.compareMark {
+	case "":
+		m.compareMark = conv.ID
+		m.notice = fmt.Sprintf("Marked %s, select another conversation and press %s to compare",
+			conv.GetEndpointPair(), m.keys.Key(ActionCompare))
+	case conv.ID:
+		m.compareMark = ""
+		m.notice = "Comparison mark cleared"
+	default:
+		first, ok := m.findConversation(m.compareMark)
+		if !ok {
+			m.compareMark = conv.ID
+			m.notice = "The marked conversation is no longer tracked, marked this one instead"
+			return
+		}
+		m.comparePair = [2]models.Conversation{first, conv}
+		m.compareMark = ""
+		m.viewMode = ViewModeCompare
+	}
+}
+
+// findConversation returns the latest copy of a tracked conversation
+func (m *Model) findConversation(id string) (models.Conversation, bool) {
+	for _, conv := range m.conversations {
+		if conv.ID == id {
+			return conv, true
+		}
+	}
+	return models.Conversation{}, false
+}
+
+// handleCompareKey handles key presses in the comparison view
+func (m *Model) handleCompareKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.keys.Action(msg.String()) {
+	case ActionBack, ActionQuit:
+		m.viewMode = ViewModeConversations
+	case ActionCompare:
+		m.comparePair[0], m.comparePair[1] = m.comparePair[1], m.comparePair[0]
+	case ActionHelp:
+		m.showHelp = !m.showHelp
+	}
+	return m, nil
+}
+
+// comparedConversations returns the compared pair, refreshed from the latest
+// conversation list while they are still tracked
+func (m *Model) comparedConversations() (models.Conversation, models.Conversation) {
+	pair := m.comparePair
+	for i := range pair {
+		if conv, ok := m.findConversation(pair[i].ID); ok {
+			pair[i] = conv
+		}
+	}
+	return pair[0], pair[1]
+}
+
+// comparisonRows lines up two conversations' endpoints, stats, timing and TLS details
+func comparisonRows(a, b models.Conversation, packetsA, packetsB []models.NetworkEvent) []compareRow {
+	field := func(label string, value func(c models.Conversation, packets []models.NetworkEvent) string) compareRow {
+		return compareRow{label: label, a: value(a, packetsA), b: value(b, packetsB)}
+	}
+	return []compareRow{
+		field("Local", func(c models.Conversation, _ []models.NetworkEvent) string { return c.LocalAddr }),
+		field("Remote", func(c models.Conversation, _ []models.NetworkEvent) string { return c.RemoteAddr }),
+		field("Remote name", func(c models.Conversation, _ []models.NetworkEvent) string { return orDash(c.RemoteHostname) }),
+		field("Protocol", func(c models.Conversation, _ []models.NetworkEvent) string { return c.Protocol }),
+		field("Service", func(c models.Conversation, _ []models.NetworkEvent) string { return orDash(c.Service) }),
+		field("State", func(c models.Conversation, _ []models.NetworkEvent) string { return string(c.State) }),
+		field("Duration", func(c models.Conversation, _ []models.NetworkEvent) string { return c.Duration }),
+		field("Last activity", func(c models.Conversation, _ []models.NetworkEvent) string {
+			return c.LastActivity.Format("15:04:05")
+		}),
+		field("Packets out/in", func(c models.Conversation, _ []models.NetworkEvent) string {
+			return fmt.Sprintf("%d / %d", c.PacketsOut, c.PacketsIn)
+		}),
+		field("Bytes out/in", func(c models.Conversation, _ []models.NetworkEvent) string {
+			return fmt.Sprintf("%s / %s", formatBytes(int(c.BytesOut)), formatBytes(int(c.BytesIn)))
+		}),
+		field("Avg packet", func(c models.Conversation, _ []models.NetworkEvent) string {
+			if c.TotalPackets() == 0 {
+				return "-"
+			}
+			return formatBytes(int(c.TotalBytes() / c.TotalPackets()))
+		}),
+		field("Packet gap", func(_ models.Conversation, packets []models.NetworkEvent) string {
+			return averageGap(packets)
+		}),
+		field("TLS server", func(c models.Conversation, packets []models.NetworkEvent) string {
+			return orDash(tlsServerName(c, packets))
+		}),
+		field("HTTP", func(c models.Conversation, _ []models.NetworkEvent) string {
+			if c.HTTP == nil {
+				return "-"
+			}
+			return c.HTTP.Summary()
+		}),
+		field("TLS", func(c models.Conversation, _ []models.NetworkEvent) string {
+			if c.TLS == nil {
+				return "-"
+			}
+			return c.TLS.Summary()
+		}),
+		field("MSS local/remote", func(c models.Conversation, _ []models.NetworkEvent) string {
+			return fmt.Sprintf("%s / %s", intOrDash(c.LocalMSS), intOrDash(c.RemoteMSS))
+		}),
+		field("Max seg out/in", func(c models.Conversation, _ []models.NetworkEvent) string {
+			return fmt.Sprintf("%s / %s", intOrDash(c.MaxSegmentOut), intOrDash(c.MaxSegmentIn))
+		}),
+		field("Fragments", func(c models.Conversation, _ []models.NetworkEvent) string { return fmt.Sprintf("%d", c.Fragments) }),
+		field("QoS", func(c models.Conversation, _ []models.NetworkEvent) string { return orDash(c.QoSInfo()) }),
+		field("PMTU suspect", func(c models.Conversation, _ []models.NetworkEvent) string { return yesNo(c.PMTUSuspect) }),
+	}
+}
+
+// averageGap returns the mean time between buffered packets
+func averageGap(packets []models.NetworkEvent) string {
+	if len(packets) < 2 {
+		return "-"
+	}
+	span := packets[len(packets)-1].Timestamp.Sub(packets[0].Timestamp)
+	return formatDuration(span / time.Duration(len(packets)-1))
+}
+
+// tlsServerName returns the conversation's SNI, from the daemon or its buffered packets
+func tlsServerName(conv models.Conversation, packets []models.NetworkEvent) string {
+	if conv.ServerName != "" {
+		return conv.ServerName
+	}
+	for _, event := range packets {
+		if event.TLSServerName != "" {
+			return event.TLSServerName
+		}
+	}
+	return ""
+}
+
+// renderComparison renders two conversations side by side, highlighting differences
+func (m *Model) renderComparison() string {
+	viewHeight := m.viewportHeight()
+	a, b := m.comparedConversations()
+	rows := comparisonRows(a, b, m.conversationPackets(a.ID), m.conversationPackets(b.ID))
+
+	labelWidth := 18
+	columnWidth := (m.width - labelWidth - 4) / 2
+	if columnWidth < 10 {
+		columnWidth = 10
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	header := fmt.Sprintf("%-*s %-*s  %s", labelWidth, "Compare", columnWidth,
+		truncateString("A: "+a.GetEndpointPair(), columnWidth), truncateString("B: "+b.GetEndpointPair(), columnWidth))
+	lines := []string{titleStyle.Render(header), ""}
+
+	differences := 0
+	for _, row := range rows {
+		marker := "  "
+		style := m.fg(m.theme.Text)
+		if row.differs() {
+			differences++
+			marker = "≠ "
+			if m.accessible {
+				marker = "* "
+			}
+			style = m.fg(m.theme.Warning)
+		}
+		line := fmt.Sprintf("%s%-*s %-*s  %s", marker, labelWidth-2, row.label, columnWidth,
+			truncateString(row.a, columnWidth), truncateString(row.b, columnWidth))
+		lines = append(lines, style.Render(truncateString(line, m.width)))
+	}
+	lines = append(lines, "", m.fg(m.theme.Muted).Render(fmt.Sprintf("%d of %d fields differ", differences, len(rows))))
+
+	for len(lines) < viewHeight {
+		lines = append(lines, "")
+	}
+	if len(lines) > viewHeight && viewHeight > 0 {
+		lines = lines[:viewHeight]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+func intOrDash(value int) string {
+	if value == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d", value)
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/netty/tui/internal/models"
+)
+
+func TestComparisonRows(t *testing.T) {
+	start := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	good := models.Conversation{ID: "good", Protocol: "TCP", RemoteAddr: "140.82.112.3:443", ServerName: "github.com", PacketsOut: 10, BytesOut: 1000}
+	suspect := models.Conversation{ID: "bad", Protocol: "TCP", RemoteAddr: "203.0.113.9:443", PacketsOut: 10, BytesOut: 1000}
+	packets := []models.NetworkEvent{
+		{Timestamp: start, TLSServerName: "github.example.net"},
+		{Timestamp: start.Add(2 * time.Second)},
+	}
+
+	rows := comparisonRows(good, suspect, nil, packets)
+	values := make(map[string]compareRow)
+	for _, row := range rows {
+		values[row.label] = row
+	}
+
+	if row := values["Protocol"]; row.differs() {
+		t.Errorf("Expected matching protocols not to differ: %+v", row)
+	}
+	if row := values["Remote"]; !row.differs() {
+		t.Errorf("Expected different remotes to differ: %+v", row)
+	}
+	if row := values["TLS server"]; row.a != "github.com" || row.b != "github.example.net" {
+		t.Errorf("Expected SNI from the summary and the buffered packets, got %+v", row)
+	}
+	if row := values["Packet gap"]; row.a != "-" || row.b != "2.0s" {
+		t.Errorf("Unexpected packet gaps: %+v", row)
+	}
+}
+
+func TestMarkForCompare(t *testing.T) {
+	m := Model{
+		keys:          DefaultKeymap(),
+		viewMode:      ViewModeConversations,
+		conversations: []models.Conversation{{ID: "a"}, {ID: "b"}},
+	}
+	m.markForCompare()
+	if m.compareMark != "a" || m.viewMode != ViewModeConversations {
+		t.Fatalf("Expected the first conversation to be marked, got %q", m.compareMark)
+	}
+
+	m.selectedIndex = 1
+	m.markForCompare()
+	if m.viewMode != ViewModeCompare || m.comparePair[0].ID != "a" || m.comparePair[1].ID != "b" {
+		t.Errorf("Expected to compare a with b, got %+v in mode %d", m.comparePair, m.viewMode)
+	}
+	if m.compareMark != "" {
+		t.Errorf("Expected the mark to be cleared, got %q", m.compareMark)
+	}
+}
@@ -0,0 +1,199 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+// detailPackets is how many of a conversation's latest packets its detail view lists
+const detailPackets = 50
+
+// openConversationDetail shows the selected conversation's details, asking
+// the daemon for its recent packets
+func (m *Model) openConversationDetail() tea.Cmd {
+	if m.selectedIndex < 0 || m.selectedIndex >= len(m.conversations) {
+		return nil
+	}
+	m.convDetail = m.conversations[m.selectedIndex]
+	m.convDetailScroll = 0
+	m.convDetailPackets = nil
+	m.viewMode = ViewModeConversationDetail
+	return m.requestConversationPackets()
+}
+
+// requestConversationPackets asks the daemon for the shown conversation's
+// recent packets, when it keeps them
+func (m *Model) requestConversationPackets() tea.Cmd {
+	if m.wsClient == nil || !m.connected {
+		return nil
+	}
+	if info := m.daemonDetails(); info == nil || !info.Has("conversation_packets") {
+		return nil
+	}
+	client, id := m.wsClient, m.convDetail.ID
+	return func() tea.Msg {
+		client.RequestConversationPackets(id, detailPackets)
+		return nil
+	}
+}
+
+// handleConversationPackets keeps the daemon's packet history for the shown
+// conversation. Once the daemon stops tracking it the last history stays.
+func (m *Model) handleConversationPackets(msg websocket.ConversationPacketsMsg) {
+	if msg.ID != m.convDetail.ID || !msg.Found {
+		return
+	}
+	m.convDetailPackets = msg.Packets
+	if m.convDetailPackets == nil {
+		m.convDetailPackets = []models.NetworkEvent{}
+	}
+}
+
+// detailPacketHistory returns the shown conversation's packets, from the
+// daemon's history when it sent one and from the buffered events otherwise
+func (m *Model) detailPacketHistory(conv models.Conversation) []models.NetworkEvent {
+	if m.convDetailPackets != nil {
+		return m.convDetailPackets
+	}
+	return m.conversationPackets(conv.ID)
+}
+
+// handleConversationDetailKey handles key presses in the conversation detail view
+func (m *Model) handleConversationDetailKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.keys.Action(msg.String()) {
+	case ActionBack, ActionQuit, ActionSelect:
+		m.viewMode = ViewModeConversations
+	case ActionDown:
+		if m.convDetailScroll < len(m.conversationDetailLines())-m.viewportHeight() {
+			m.convDetailScroll++
+		}
+	case ActionUp:
+		if m.convDetailScroll > 0 {
+			m.convDetailScroll--
+		}
+	case ActionHelp:
+		m.showHelp = !m.showHelp
+	}
+	return m, nil
+}
+
+// detailedConversation returns the conversation shown, refreshed from the
+// latest conversation list while it is still tracked
+func (m *Model) detailedConversation() models.Conversation {
+	if conv, ok := m.findConversation(m.convDetail.ID); ok {
+		return conv
+	}
+	return m.convDetail
+}
+
+// detailRow is one line of the conversation detail view
+type detailRow struct {
+	label string
+	value string
+}
+
+// conversationDetailRows describes a conversation field by field
+func conversationDetailRows(conv models.Conversation, packets []models.NetworkEvent) []detailRow {
+	rtt, dataRTT, average := "-", "-", "-"
+	if conv.HandshakeRTTMs > 0 {
+		rtt = fmt.Sprintf("%.1fms", conv.HandshakeRTTMs)
+		if conv.SYNACKRTTMs > 0 && conv.ACKRTTMs > 0 {
+			rtt += fmt.Sprintf(" (SYN-ACK after %.1fms, ACK %.1fms later)", conv.SYNACKRTTMs, conv.ACKRTTMs)
+		}
+	}
+	if conv.DataRTTSamples > 0 {
+		dataRTT = fmt.Sprintf("%.1fms (smoothed over %d samples)", conv.DataRTTMs, conv.DataRTTSamples)
+	}
+	if conv.TotalPackets() > 0 {
+		average = formatBytes(int(conv.TotalBytes() / conv.TotalPackets()))
+	}
+
+	rows := []detailRow{
+		{"Protocol", conv.Protocol},
+		{"Local", conv.LocalAddr},
+		{"Remote", conv.RemoteAddr},
+		{"Remote name", orDash(conv.RemoteHostname)},
+		{"Location", orDash(conv.Geo.String())},
+		{"TLS server", orDash(tlsServerName(conv, packets))},
+		{"Service", orDash(conv.Service)},
+		{"State", string(conv.State)},
+		{"TCP flags seen", orDash(strings.Join(conv.TCPFlags, ", "))},
+		{"Started", conv.StartTime.Format("2006-01-02 15:04:05")},
+		{"Duration", conv.Duration},
+		{"Last activity", conv.LastActivity.Format("15:04:05")},
+		{"Handshake RTT", rtt},
+		{"Data RTT", dataRTT},
+		{"Packets out/in", fmt.Sprintf("%d / %d (%d total)", conv.PacketsOut, conv.PacketsIn, conv.TotalPackets())},
+		{"Bytes out/in", fmt.Sprintf("%s / %s (%s total)",
+			formatBytes(int(conv.BytesOut)), formatBytes(int(conv.BytesIn)), formatBytes(int(conv.TotalBytes())))},
+		{"Avg packet", average},
+		{"Rate in/out", fmt.Sprintf("%s / %s", formatRate(conv.BytesInPerSec), formatRate(conv.BytesOutPerSec))},
+	}
+	if conv.HTTP != nil {
+		rows = append(rows, detailRow{"HTTP", conv.HTTP.Summary()})
+	}
+	if conv.TLS != nil {
+		rows = append(rows, detailRow{"TLS", conv.TLS.Summary()})
+	}
+	if info := conv.PathInfo(); info != "" {
+		rows = append(rows, detailRow{"Path (local/remote)", info})
+	}
+	if info := conv.QoSInfo(); info != "" {
+		rows = append(rows, detailRow{"QoS", info})
+	}
+	return rows
+}
+
+// conversationDetailLines renders the whole detail view, for scrolling
+func (m *Model) conversationDetailLines() []string {
+	conv := m.detailedConversation()
+	packets := m.detailPacketHistory(conv)
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	labelStyle := m.fg(m.theme.Muted)
+	valueStyle := m.fg(m.theme.Text)
+
+	lines := []string{titleStyle.Render(truncateString("Conversation "+conv.GetEndpointPair(), m.width)), ""}
+	for _, row := range conversationDetailRows(conv, packets) {
+		line := labelStyle.Render(fmt.Sprintf("  %-20s ", row.label)) + valueStyle.Render(truncateString(row.value, m.width-23))
+		lines = append(lines, line)
+	}
+	if len(conv.RateHistory) > 0 {
+		lines = append(lines, labelStyle.Render(fmt.Sprintf("  %-20s ", "Throughput"))+m.sparkline(conv.RateHistory, sparklineWidth))
+	}
+
+	lines = append(lines, "")
+	if len(packets) == 0 {
+		return append(lines, m.fg(m.theme.Muted).Render("  No buffered packets for this conversation"))
+	}
+	if len(packets) > detailPackets {
+		packets = packets[len(packets)-detailPackets:]
+	}
+	lines = append(lines, titleStyle.Render(fmt.Sprintf("Last %d packets", len(packets))))
+	lines = append(lines, titleStyle.Render("  "+conversationPacketHeader()))
+	for _, event := range packets {
+		lines = append(lines, m.renderConversationPacket(event, m.width, "  "))
+	}
+	return lines
+}
+
+// renderConversationDetail renders the part of the detail view scrolled to
+func (m *Model) renderConversationDetail() string {
+	viewHeight := m.viewportHeight()
+	lines := m.conversationDetailLines()
+	if m.convDetailScroll > 0 && m.convDetailScroll < len(lines) {
+		lines = lines[m.convDetailScroll:]
+	}
+	for len(lines) < viewHeight {
+		lines = append(lines, "")
+	}
+	if len(lines) > viewHeight && viewHeight > 0 {
+		lines = lines[:viewHeight]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+func TestConversationDetail(t *testing.T) {
+	m := NewModel(nil, Options{})
+	m.width, m.height = 120, 40
+	m.viewMode = ViewModeConversations
+	m.conversations = []models.Conversation{
+		{ID: "dns", Protocol: "UDP", LocalAddr: "192.168.1.10:53000", RemoteAddr: "192.168.1.1:53"},
+		{
+			ID: "web", Protocol: "TCP", LocalAddr: "192.168.1.10:50000", RemoteAddr: "140.82.112.3:443",
+			State: models.ConversationStateEstablished, ServerName: "github.com", Service: "HTTPS",
+			PacketsOut: 3, PacketsIn: 1, BytesOut: 300, BytesIn: 100, HandshakeRTTMs: 42.5,
+			SYNACKRTTMs: 40, ACKRTTMs: 2.5, DataRTTMs: 38.25, DataRTTSamples: 12,
+			TCPFlags: []string{"SYN", "SYN-ACK", "ACK"},
+		},
+	}
+	start := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < detailPackets+5; i++ {
+		m.events = append(m.events, models.NetworkEvent{ConversationID: "web", Timestamp: start.Add(time.Duration(i) * time.Second)})
+	}
+	m.selectedIndex = 1
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.viewMode != ViewModeConversationDetail || m.convDetail.ID != "web" {
+		t.Fatalf("Expected enter to open the selected conversation, got view %v for %q", m.viewMode, m.convDetail.ID)
+	}
+
+	values := make(map[string]string)
+	for _, row := range conversationDetailRows(m.detailedConversation(), nil) {
+		values[row.label] = row.value
+	}
+	if values["TCP flags seen"] != "SYN, SYN-ACK, ACK" || values["Handshake RTT"] != "42.5ms (SYN-ACK after 40.0ms, ACK 2.5ms later)" ||
+		values["Data RTT"] != "38.2ms (smoothed over 12 samples)" || values["Avg packet"] != "100 B" {
+		t.Errorf("Unexpected details: %v", values)
+	}
+
+	view := strings.Join(m.conversationDetailLines(), "\n")
+	if !strings.Contains(view, "github.com") || !strings.Contains(view, "Last 50 packets") {
+		t.Errorf("Expected the SNI and the last %d packets in the view, got:\n%s", detailPackets, view)
+	}
+
+	// Updates to the conversation show while the view is open
+	m.conversations[1].State = models.ConversationStateClosed
+	if state := m.detailedConversation().State; state != models.ConversationStateClosed {
+		t.Errorf("Expected the refreshed state, got %s", state)
+	}
+
+	// The daemon's history replaces the buffered packets, for this conversation only
+	m.handleConversationPackets(websocket.ConversationPacketsMsg{ID: "dns", Found: true})
+	if m.convDetailPackets != nil {
+		t.Errorf("Expected another conversation's packets to be ignored, got %d", len(m.convDetailPackets))
+	}
+	history := []models.NetworkEvent{{ConversationID: "web"}, {ConversationID: "web"}}
+	m.handleConversationPackets(websocket.ConversationPacketsMsg{ID: "web", Found: true, Packets: history})
+	if view := strings.Join(m.conversationDetailLines(), "\n"); !strings.Contains(view, "Last 2 packets") {
+		t.Errorf("Expected the daemon's 2 packets in the view, got:\n%s", view)
+	}
+	m.handleConversationPackets(websocket.ConversationPacketsMsg{ID: "web", Found: false})
+	if len(m.convDetailPackets) != 2 {
+		t.Errorf("Expected the history to stay once the daemon forgets the conversation, got %d packets", len(m.convDetailPackets))
+	}
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.viewMode != ViewModeConversations {
+		t.Errorf("Expected esc to return to the conversations, got view %v", m.viewMode)
+	}
+}
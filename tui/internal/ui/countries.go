@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/netty/tui/internal/models"
+)
+
+// countryBarWidth is the width of each country's share of the traffic bar
+const countryBarWidth = 15
+
+// openCountries switches to the per country traffic summary
+func (m *Model) openCountries() tea.Cmd {
+	m.viewMode = ViewModeCountries
+	m.countriesScroll = 0
+	m.lastConvUpdate = time.Now()
+	return m.requestCountries()
+}
+
+// requestCountries asks the daemon for its traffic totals per country
+func (m *Model) requestCountries() tea.Cmd {
+	return func() tea.Msg {
+		if m.wsClient != nil {
+			m.wsClient.RequestCountries()
+		}
+		return nil
+	}
+}
+
+// handleCountriesKey handles key presses in the countries view
+func (m *Model) handleCountriesKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.keys.Action(msg.String()) {
+	case ActionBack, ActionQuit, ActionCountries:
+		m.viewMode = ViewModeConversations
+		return m, m.requestConversations()
+	case ActionDown:
+		if m.countriesScroll < len(m.countries)-1 {
+			m.countriesScroll++
+		}
+	case ActionUp:
+		if m.countriesScroll > 0 {
+			m.countriesScroll--
+		}
+	case ActionHelp:
+		m.showHelp = !m.showHelp
+	}
+	return m, nil
+}
+
+// renderCountries renders traffic totals per country of the remote end, busiest first
+func (m *Model) renderCountries() string {
+	viewHeight := m.viewportHeight()
+
+	if len(m.countries) == 0 {
+		message := "No conversations yet"
+		switch {
+		case !m.connected:
+			message = "Not connected to daemon"
+		case m.daemonInfo != nil && !m.daemonInfo.Has("geoip"):
+			message = "The daemon has no GeoIP database\n\nStart it with -geoip-db (and -geoip-asn-db) pointing at a GeoLite2 .mmdb file"
+		}
+		return lipgloss.NewStyle().
+			Foreground(m.theme.Muted).
+			Align(lipgloss.Center).
+			Width(m.width).
+			Height(viewHeight).
+			Render(message)
+	}
+
+	var total int64
+	for _, country := range m.countries {
+		total += country.TotalBytes()
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	header := fmt.Sprintf("%-3s %-20s %-8s %-6s %-10s %-10s %-12s %-*s %s",
+		"CC", "Country", "Conns", "Hosts", "In", "Out", "Rate", countryBarWidth+5, "Share", "Networks")
+	lines := []string{titleStyle.Render(truncateString(header, m.width))}
+
+	// Header above, legend below
+	visible := viewHeight - 2
+	if visible < 1 {
+		visible = 1
+	}
+	start := m.countriesScroll
+	if start > len(m.countries)-1 {
+		start = len(m.countries) - 1
+	}
+	for i := start; i < len(m.countries) && i-start < visible; i++ {
+		lines = append(lines, m.renderCountryLine(m.countries[i], total))
+	}
+
+	for len(lines) < viewHeight-1 {
+		lines = append(lines, "")
+	}
+	legend := " Grouped by the remote end of each conversation; -- is local or unlisted addresses"
+	lines = append(lines, m.fg(m.theme.Muted).Render(truncateString(legend, m.width)))
+	return strings.Join(lines, "\n")
+}
+
+// renderCountryLine renders one country's row
+func (m *Model) renderCountryLine(country models.CountryStats, total int64) string {
+	var ratio float64
+	if total > 0 {
+		ratio = float64(country.TotalBytes()) / float64(total)
+	}
+	line := fmt.Sprintf("%-3s %-20s %-8s %-6d %-10s %-10s %-12s %s %3.0f%% %s",
+		country.CountryCode,
+		truncateString(country.Country, 20),
+		fmt.Sprintf("%d/%d", country.Active, country.Conversations),
+		country.Hosts,
+		formatBytes(int(country.BytesIn)),
+		formatBytes(int(country.BytesOut)),
+		formatBytes(int(country.BytesPerSecond))+"/s",
+		m.shareBar(ratio, countryBarWidth),
+		ratio*100,
+		strings.Join(country.ASOrgs, ", "),
+	)
+
+	style := m.fg(m.theme.Muted)
+	if country.Active > 0 {
+		style = m.fg(m.theme.Good)
+	}
+	return style.Render(truncateString(line, m.width))
+}
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+func TestCountriesView(t *testing.T) {
+	m := NewModel(nil, Options{Accessible: true})
+	m.width, m.height, m.connected = 160, 20, true
+	m.viewMode = ViewModeConversations
+	m.daemonInfo = &models.DaemonInfo{}
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("C")})
+	if m.viewMode != ViewModeCountries {
+		t.Fatalf("Expected C to open the countries view, got view %d", m.viewMode)
+	}
+	if view := m.renderCountries(); !strings.Contains(view, "no GeoIP database") {
+		t.Errorf("Expected a hint when the daemon has no GeoIP database, got:\n%s", view)
+	}
+
+	updated, _ := m.Update(websocket.CountriesMsg{
+		{CountryCode: "DE", Country: "Germany", Conversations: 3, Active: 2, Hosts: 2, BytesIn: 2500, BytesOut: 500,
+			ASOrgs: []string{"Hetzner Online GmbH", "Deutsche Telekom AG"}},
+		{CountryCode: "--", Country: "Unknown", Conversations: 1, Hosts: 1, BytesIn: 1000},
+	})
+	m = updated.(Model)
+
+	rows := strings.Split(m.renderCountries(), "\n")
+	if !strings.HasPrefix(rows[1], "DE  Germany") || !strings.Contains(rows[1], "2/3") ||
+		!strings.Contains(rows[1], "###########....  75% Hetzner Online GmbH, Deutsche Telekom AG") {
+		t.Errorf("Expected Germany first with its share and networks, got %q", rows[1])
+	}
+	if !strings.HasPrefix(rows[2], "--  Unknown") || !strings.Contains(rows[2], "  25%") {
+		t.Errorf("Expected the unknown group second, got %q", rows[2])
+	}
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.viewMode != ViewModeConversations {
+		t.Errorf("Expected esc to return to the conversations view, got %d", m.viewMode)
+	}
+}
+
+func TestConversationCountryColumn(t *testing.T) {
+	m := NewModel(nil, Options{})
+	m.width, m.height, m.connected = 160, 20, true
+	m.viewMode = ViewModeConversations
+	m.conversations = []models.Conversation{
+		{ID: "a", LocalAddr: "192.168.1.10:50000", RemoteAddr: "88.99.12.34:443", Geo: &models.GeoInfo{CountryCode: "DE"}},
+		{ID: "b", LocalAddr: "192.168.1.10:50001", RemoteAddr: "192.168.1.1:53"},
+	}
+
+	rows := strings.Split(m.renderConversationList(), "\n")
+	if !strings.Contains(rows[0], "Conversation") || !strings.Contains(rows[0], " CC ") {
+		t.Errorf("Expected a country column in the header, got %q", rows[0])
+	}
+	if !strings.Contains(rows[1], "88.99.12.34:443") || !strings.Contains(rows[1], " DE ") {
+		t.Errorf("Expected the remote end's country, got %q", rows[1])
+	}
+	if !strings.Contains(rows[2], " -- ") {
+		t.Errorf("Expected -- for an address without a country, got %q", rows[2])
+	}
+}
@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+// Daemon health checks: how often /health is polled and what counts as trouble
+const (
+	healthInterval   = 15 * time.Second
+	noPacketsGrace   = 10 * time.Second // A daemon that just started may not have seen traffic yet
+	highDropRate     = 0.01
+	minDropsReported = 100
+)
+
+// healthMsg carries the result of a /health request
+type healthMsg struct {
+	health models.DaemonHealth
+	err    error
+}
+
+// checkHealth fetches the daemon's health report in the background
+func (m *Model) checkHealth() tea.Cmd {
+	m.healthChecked = time.Now()
+	client := m.wsClient
+	return func() tea.Msg {
+		if client == nil {
+			return nil
+		}
+		health, err := client.FetchHealth()
+		return healthMsg{health: health, err: err}
+	}
+}
+
+// handleHealth keeps the latest health report. A failed request forgets the
+// previous report rather than warning from stale numbers.
+func (m *Model) handleHealth(msg healthMsg) {
+	if msg.err != nil {
+		m.daemonHealth = nil
+		return
+	}
+	m.daemonHealth = &msg.health
+}
+
+// daemonDetails returns what the daemon reported about itself, from its hello
+// message or failing that its health report
+func (m *Model) daemonDetails() *models.DaemonInfo {
+	if m.daemonInfo != nil {
+		return m.daemonInfo
+	}
+	if m.daemonHealth != nil {
+		return &m.daemonHealth.DaemonInfo
+	}
+	return nil
+}
+
+// daemonWarnings lists the problems worth more than a plain "Connected":
+// incompatible versions, a capture that isn't working and dropped events
+func (m *Model) daemonWarnings() []string {
+	var warnings []string
+	if info := m.daemonDetails(); info != nil {
+		switch {
+		case info.Protocol == 0:
+			warnings = append(warnings, "daemon predates version checks, upgrade it")
+		case info.Protocol > websocket.Protocol:
+			warnings = append(warnings, fmt.Sprintf("daemon %s speaks protocol %d, upgrade the TUI (protocol %d)",
+				info.Version, info.Protocol, websocket.Protocol))
+		case info.Protocol < websocket.Protocol:
+			warnings = append(warnings, fmt.Sprintf("daemon %s speaks protocol %d, upgrade the daemon (TUI protocol %d)",
+				info.Version, info.Protocol, websocket.Protocol))
+		}
+	}
+
+	if m.daemonHealth == nil {
+		return warnings
+	}
+	stats := m.daemonHealth.CaptureStats
+	switch {
+	case stats.CaptureState == "failed":
+		warnings = append(warnings, "capture failed: "+orDash(stats.LastReadError))
+	case stats.CaptureState == "recovering":
+		warnings = append(warnings, "capture recovering from read errors")
+	case stats.TotalPackets == 0 && stats.UptimeSeconds >= noPacketsGrace.Seconds():
+		warnings = append(warnings, "no packets captured, check the interface, filter and permissions")
+	}
+	if stats.DroppedPackets >= minDropsReported && stats.DropRate() >= highDropRate {
+		warnings = append(warnings, fmt.Sprintf("daemon dropped %.1f%% of events", stats.DropRate()*100))
+	}
+	return warnings
+}
+
+// connectedStatus returns the header status while connected, and whether it is a warning
+func (m *Model) connectedStatus() (string, bool) {
+	if warnings := m.daemonWarnings(); len(warnings) > 0 {
+		status := "! " + warnings[0]
+		if len(warnings) > 1 {
+			status += fmt.Sprintf(" (+%d more, see help)", len(warnings)-1)
+		}
+		return status, true
+	}
+	if m.wsClient != nil && m.wsClient.ReplayPath() != "" {
+		return "Replaying " + filepath.Base(m.wsClient.ReplayPath()), false
+	}
+	if info := m.daemonDetails(); info != nil && info.Version != "" {
+		return "Connected to daemon " + info.Version, false
+	}
+	return m.connectionStatus, false
+}
+
+// renderDaemonHelp describes the connected daemon for the help screen
+func (m *Model) renderDaemonHelp() string {
+	var help strings.Builder
+	help.WriteString(" \n Daemon:\n")
+	if info := m.daemonDetails(); info != nil {
+		help.WriteString(fmt.Sprintf("   Version %s, protocol %d (this TUI speaks %d)\n", orDash(info.Version), info.Protocol, websocket.Protocol))
+		if len(info.Capabilities) > 0 {
+			help.WriteString("   Features: " + strings.Join(info.Capabilities, ", ") + "\n")
+		}
+	} else {
+		help.WriteString("   Not reported yet\n")
+	}
+	for _, warning := range m.daemonWarnings() {
+		help.WriteString("   ! " + warning + "\n")
+	}
+	return help.String()
+}
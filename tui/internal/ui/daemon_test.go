@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+func TestDaemonWarnings(t *testing.T) {
+	m := Model{connectionStatus: "Connected"}
+	if status, warning := m.connectedStatus(); warning || status != "Connected" {
+		t.Errorf("Expected a plain status before the daemon reports, got %q", status)
+	}
+
+	m.daemonInfo = &models.DaemonInfo{Version: "1.4.0", Protocol: websocket.Protocol}
+	m.daemonHealth = &models.DaemonHealth{
+		Status:       "healthy",
+		CaptureStats: models.CaptureStats{UptimeSeconds: 60, TotalPackets: 5000, ProcessedEvents: 5000, CaptureState: "running"},
+	}
+	if status, warning := m.connectedStatus(); warning || status != "Connected to daemon 1.4.0" {
+		t.Errorf("Expected the daemon version, got %q (warning %v)", status, warning)
+	}
+
+	m.daemonInfo.Protocol = websocket.Protocol + 1
+	m.daemonHealth.CaptureStats.TotalPackets = 0
+	m.daemonHealth.CaptureStats.DroppedPackets = 500
+	warnings := m.daemonWarnings()
+	if len(warnings) != 3 || !strings.Contains(warnings[0], "upgrade the TUI") ||
+		!strings.Contains(warnings[1], "no packets captured") || !strings.Contains(warnings[2], "dropped 9.1%") {
+		t.Errorf("Unexpected warnings: %v", warnings)
+	}
+	if status, warning := m.connectedStatus(); !warning || !strings.Contains(status, "+2 more") {
+		t.Errorf("Expected the first warning with a count of the rest, got %q", status)
+	}
+
+	// A daemon without a hello message or protocol in /health predates version reporting
+	m.daemonInfo = nil
+	m.daemonHealth = &models.DaemonHealth{CaptureStats: models.CaptureStats{UptimeSeconds: 1, CaptureState: "failed", LastReadError: "device gone"}}
+	warnings = m.daemonWarnings()
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "predates") || warnings[1] != "capture failed: device gone" {
+		t.Errorf("Unexpected warnings: %v", warnings)
+	}
+}
+
+func TestReplayStatus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	os.WriteFile(path, []byte(`{"time":"2025-07-01T12:00:00Z","message":{"type":"network_event","data":{}}}`+"\n"), 0o644)
+	client, err := websocket.NewReplayClient(path, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	m := NewModel(client, Options{})
+	m.Update(websocket.ConnectionStatusMsg{Connected: true})
+	if status, _ := m.connectedStatus(); status != "Replaying session.jsonl" {
+		t.Errorf("Expected the recording's name, got %q", status)
+	}
+
+	// The end of a recording isn't a lost connection to reconnect
+	updated, cmd := m.Update(websocket.ConnectionStatusMsg{Connected: false, Error: websocket.ErrReplayFinished})
+	m = updated.(Model)
+	if cmd != nil || m.connectionStatus != "Replay finished" || m.connectionError != "" {
+		t.Errorf("Expected the replay to end quietly, got status %q, error %q", m.connectionStatus, m.connectionError)
+	}
+}
@@ -0,0 +1,12 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/netty/tui/internal/models"
+)
+
+// handleNewDevice announces a MAC address the daemon hasn't seen on the LAN before
+func (m *Model) handleNewDevice(device models.Device) {
+	m.notice = fmt.Sprintf("NEW DEVICE %s (%s)", device.MAC, device.Addresses())
+}
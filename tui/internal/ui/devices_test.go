@@ -0,0 +1,17 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+func TestNewDeviceNotice(t *testing.T) {
+	updated, _ := Model{}.Update(websocket.NewDeviceMsg(models.Device{MAC: "aa:bb:cc:00:00:14"}))
+	m := updated.(Model)
+	if !strings.Contains(m.notice, "aa:bb:cc:00:00:14") || !strings.Contains(m.notice, "no address yet") {
+		t.Errorf("Expected a notice naming the new device, got %q", m.notice)
+	}
+}
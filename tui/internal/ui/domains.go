@@ -0,0 +1,198 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+// domainBarWidth is the width of each domain's share of the traffic bar
+const domainBarWidth = 15
+
+// openDomains switches to the traffic per domain name, today's first
+func (m *Model) openDomains() tea.Cmd {
+	m.viewMode = ViewModeDomains
+	m.lastConvUpdate = time.Now()
+	return m.showDomains("today", m.domainsGroup)
+}
+
+// showDomains changes the day ("today", YYYY-MM-DD, or "" since the daemon
+// started) and grouping shown, and asks for them
+func (m *Model) showDomains(day string, group bool) tea.Cmd {
+	m.domainsDay, m.domainsGroup = day, group
+	m.domainsScroll = 0
+	m.domains = nil
+	return m.requestDomains()
+}
+
+// requestDomains asks the daemon for the traffic per domain on the day shown
+func (m *Model) requestDomains() tea.Cmd {
+	client, day, group := m.wsClient, m.domainsDay, m.domainsGroup
+	return func() tea.Msg {
+		if client != nil {
+			client.RequestDomains(day, group)
+		}
+		return nil
+	}
+}
+
+// handleDomains stores the daemon's answer, dropping answers to earlier requests
+func (m *Model) handleDomains(msg websocket.DomainsMsg) {
+	report := models.DomainReport(msg)
+	if report.Group != m.domainsGroup {
+		return
+	}
+	if m.domainsDay == "today" && report.Day == "" || m.domainsDay != "today" && report.Day != m.domainsDay {
+		return
+	}
+	m.domains = &report
+	if m.domainsScroll > len(report.Domains)-1 {
+		m.domainsScroll = len(report.Domains) - 1
+	}
+	if m.domainsScroll < 0 {
+		m.domainsScroll = 0
+	}
+}
+
+// stepDomainsDay shows the day before (-1) or after (1) the one shown, the
+// totals since the daemon started coming after its latest day
+func (m *Model) stepDomainsDay(step int) tea.Cmd {
+	if m.domains == nil {
+		return nil
+	}
+	days := append(append([]string{}, m.domains.Days...), "")
+	current := len(days) - 1
+	for i, day := range days {
+		if day == m.domains.Day {
+			current = i
+		}
+	}
+	next := current + step
+	if next < 0 || next >= len(days) {
+		return nil
+	}
+	return m.showDomains(days[next], m.domainsGroup)
+}
+
+// handleDomainsKey handles key presses in the domains view
+func (m *Model) handleDomainsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.keys.Action(msg.String()) {
+	case ActionBack, ActionQuit, ActionDomains:
+		m.viewMode = ViewModeConversations
+		return m, m.requestConversations()
+	case ActionSwitchView:
+		// Group subdomains under their registered domain, or list every name
+		day := m.domainsDay
+		if m.domains != nil && day != "" {
+			day = m.domains.Day
+		}
+		return m, m.showDomains(day, !m.domainsGroup)
+	case ActionEarlier:
+		return m, m.stepDomainsDay(-1)
+	case ActionLater:
+		return m, m.stepDomainsDay(1)
+	case ActionDown:
+		if m.domains != nil && m.domainsScroll < len(m.domains.Domains)-1 {
+			m.domainsScroll++
+		}
+	case ActionUp:
+		if m.domainsScroll > 0 {
+			m.domainsScroll--
+		}
+	case ActionHelp:
+		m.showHelp = !m.showHelp
+	}
+	return m, nil
+}
+
+// domainsPeriod describes the day shown, or since when the totals run
+func (m *Model) domainsPeriod() string {
+	switch {
+	case m.domains != nil && m.domains.Day != "":
+		return m.domains.Day
+	case m.domains != nil:
+		return "since " + m.domains.Since.Local().Format("Jan 2 15:04")
+	case m.domainsDay == "":
+		return "since start"
+	}
+	return m.domainsDay
+}
+
+// renderDomains renders the bytes and connections per domain name, busiest first
+func (m *Model) renderDomains() string {
+	viewHeight := m.viewportHeight()
+
+	if m.domains == nil || len(m.domains.Domains) == 0 {
+		message := fmt.Sprintf("No named traffic %s", m.domainsPeriod())
+		switch {
+		case !m.connected:
+			message = "Not connected to daemon"
+		case m.domains == nil:
+			message = "Waiting for the daemon..."
+		}
+		return lipgloss.NewStyle().
+			Foreground(m.theme.Muted).
+			Align(lipgloss.Center).
+			Width(m.width).
+			Height(viewHeight).
+			Render(message)
+	}
+
+	report := m.domains
+	summary := fmt.Sprintf(" %s: %s in, %s out over %d connections to %d domains",
+		m.domainsPeriod(), formatBytes(int(report.BytesIn)), formatBytes(int(report.BytesOut)),
+		report.Connections, len(report.Domains))
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	header := fmt.Sprintf("%-40s %-7s %-10s %-10s %-*s %s",
+		"Domain", "Conns", "In", "Out", domainBarWidth+5, "Share", "Last seen")
+	lines := []string{
+		m.fg(m.theme.Text).Render(truncateString(summary, m.width)),
+		titleStyle.Render(truncateString(header, m.width)),
+	}
+
+	// Summary and header above
+	visible := viewHeight - 2
+	if visible < 1 {
+		visible = 1
+	}
+	start := m.domainsScroll
+	if start > len(report.Domains)-1 {
+		start = len(report.Domains) - 1
+	}
+	now := time.Now()
+	for i := start; i < len(report.Domains) && i-start < visible; i++ {
+		lines = append(lines, m.renderDomainLine(report.Domains[i], report.Bytes, now))
+	}
+
+	for len(lines) < viewHeight {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderDomainLine renders one domain's row with its share of the bytes shown
+func (m *Model) renderDomainLine(domain models.DomainTraffic, total int64, now time.Time) string {
+	name := domain.Domain
+	if domain.Names > 1 {
+		name = fmt.Sprintf("%s (%d names)", name, domain.Names)
+	}
+	var share float64
+	if total > 0 {
+		share = float64(domain.Bytes) / float64(total)
+	}
+	line := fmt.Sprintf("%-40s %-7d %-10s %-10s %s %3.0f%% %s",
+		truncateString(name, 40),
+		domain.Connections,
+		formatBytes(int(domain.BytesIn)),
+		formatBytes(int(domain.BytesOut)),
+		m.shareBar(share, domainBarWidth),
+		share*100,
+		formatDuration(now.Sub(domain.LastSeen).Truncate(time.Second))+" ago",
+	)
+	return m.fg(m.theme.Text).Render(truncateString(line, m.width))
+}
@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+func TestDomainsView(t *testing.T) {
+	m := NewModel(nil, Options{Accessible: true})
+	m.width, m.height, m.connected = 140, 20, true
+	m.viewMode = ViewModeConversations
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("D")})
+	if m.viewMode != ViewModeDomains || m.domainsDay != "today" || m.domainsGroup {
+		t.Fatalf("Expected D to open today's names, got view %d day %q group %v", m.viewMode, m.domainsDay, m.domainsGroup)
+	}
+
+	now := time.Now()
+	today := websocket.DomainsMsg{
+		Day:         "2025-07-02",
+		Connections: 3,
+		BytesIn:     3000,
+		BytesOut:    1000,
+		Bytes:       4000,
+		Domains: []models.DomainTraffic{
+			{Domain: "storage.googleapis.com", Connections: 2, BytesIn: 2500, BytesOut: 500, Bytes: 3000, LastSeen: now},
+			{Domain: "github.com", Connections: 1, BytesIn: 500, BytesOut: 500, Bytes: 1000, LastSeen: now},
+		},
+		Days: []string{"2025-07-01", "2025-07-02"},
+	}
+	updated, _ := m.Update(today)
+	m = updated.(Model)
+
+	rows := strings.Split(m.renderDomains(), "\n")
+	if !strings.Contains(rows[0], "2025-07-02: 2.9 KB in, 1000 B out over 3 connections to 2 domains") {
+		t.Errorf("Expected the day's totals in the summary, got %q", rows[0])
+	}
+	if !strings.HasPrefix(rows[2], "storage.googleapis.com") || !strings.Contains(rows[2], "###########....  75%") {
+		t.Errorf("Expected the busiest name first with its share, got %q", rows[2])
+	}
+
+	// Tab groups subdomains; the answer listing names no longer applies
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyTab})
+	if !m.domainsGroup || m.domainsDay != "2025-07-02" {
+		t.Fatalf("Expected the same day grouped, got day %q group %v", m.domainsDay, m.domainsGroup)
+	}
+	updated, _ = m.Update(today)
+	m = updated.(Model)
+	if view := m.renderDomains(); !strings.Contains(view, "Waiting for the daemon") {
+		t.Errorf("Expected a stale answer to be dropped, got:\n%s", view)
+	}
+
+	grouped := today
+	grouped.Group = true
+	grouped.Domains = []models.DomainTraffic{
+		{Domain: "*.googleapis.com", Connections: 2, Bytes: 3000, Names: 2, LastSeen: now},
+		{Domain: "*.github.com", Connections: 1, Bytes: 1000, Names: 1, LastSeen: now},
+	}
+	updated, _ = m.Update(grouped)
+	m = updated.(Model)
+	if rows := strings.Split(m.renderDomains(), "\n"); !strings.HasPrefix(rows[2], "*.googleapis.com (2 names)") {
+		t.Errorf("Expected the wildcard with its name count, got %q", rows[2])
+	}
+
+	// [ goes back a day, ] past the latest shows the totals since start
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("[")})
+	if m.domainsDay != "2025-07-01" {
+		t.Errorf("Expected [ to ask for the day before, got %q", m.domainsDay)
+	}
+	m.domains = &models.DomainReport{Day: "2025-07-02", Group: true, Days: grouped.Days}
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("]")})
+	if m.domainsDay != "" {
+		t.Errorf("Expected ] after the latest day to ask for everything, got %q", m.domainsDay)
+	}
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.viewMode != ViewModeConversations {
+		t.Errorf("Expected esc to return to the conversations view, got %d", m.viewMode)
+	}
+}
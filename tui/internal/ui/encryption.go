@@ -0,0 +1,163 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/netty/tui/internal/models"
+)
+
+// encryptionBarWidth is the width of each service's encrypted share bar
+const encryptionBarWidth = 20
+
+// openEncryption switches to the per service encryption audit
+func (m *Model) openEncryption() tea.Cmd {
+	m.viewMode = ViewModeEncryption
+	m.encryptionScroll = 0
+	m.lastConvUpdate = time.Now()
+	return m.requestEncryptionStats()
+}
+
+// requestEncryptionStats asks the daemon for the encrypted share of each service's traffic
+func (m *Model) requestEncryptionStats() tea.Cmd {
+	return func() tea.Msg {
+		if m.wsClient != nil {
+			m.wsClient.RequestEncryptionStats()
+		}
+		return nil
+	}
+}
+
+// handleEncryptionKey handles key presses in the encryption view
+func (m *Model) handleEncryptionKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.keys.Action(msg.String()) {
+	case ActionBack, ActionQuit, ActionEncryption:
+		m.viewMode = ViewModeConversations
+		return m, m.requestConversations()
+	case ActionDown:
+		if m.encryption != nil && m.encryptionScroll < len(m.encryption.Services)-1 {
+			m.encryptionScroll++
+		}
+	case ActionUp:
+		if m.encryptionScroll > 0 {
+			m.encryptionScroll--
+		}
+	case ActionHelp:
+		m.showHelp = !m.showHelp
+	}
+	return m, nil
+}
+
+// renderEncryption renders the encrypted and cleartext share of each service's traffic
+func (m *Model) renderEncryption() string {
+	viewHeight := m.viewportHeight()
+
+	if m.encryption == nil || len(m.encryption.Services) == 0 {
+		message := "No traffic to audit yet"
+		if !m.connected {
+			message = "Not connected to daemon"
+		}
+		return lipgloss.NewStyle().
+			Foreground(m.theme.Muted).
+			Align(lipgloss.Center).
+			Width(m.width).
+			Height(viewHeight).
+			Render(message)
+	}
+
+	report := m.encryption
+	summary := fmt.Sprintf(" Encrypted %.0f%% of classified traffic since %s (%s encrypted, %s cleartext, %s unknown)",
+		report.EncryptedRatio*100, report.Since.Local().Format("Jan 2 15:04"),
+		formatBytes(int(report.EncryptedBytes)), formatBytes(int(report.PlaintextBytes)), formatBytes(int(report.UnknownBytes)))
+	trend := fmt.Sprintf(" Hourly, oldest first: %s", m.ratioTrend(report.Hourly))
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	header := fmt.Sprintf("%-11s %-12s %10s %10s %10s  %s",
+		"Service", "Name", "Encrypted", "Cleartext", "Unknown", "Encrypted share")
+	lines := []string{
+		m.fg(m.theme.Text).Render(truncateString(summary, m.width)),
+		m.fg(m.theme.Muted).Render(truncateString(trend, m.width)),
+		titleStyle.Render(truncateString(header, m.width)),
+	}
+
+	// Summary, trend and header above, legend below
+	visible := viewHeight - 4
+	if visible < 1 {
+		visible = 1
+	}
+	start := m.encryptionScroll
+	if start > len(report.Services)-1 {
+		start = len(report.Services) - 1
+	}
+	for i := start; i < len(report.Services) && i-start < visible; i++ {
+		lines = append(lines, m.renderEncryptionLine(report.Services[i]))
+	}
+
+	for len(lines) < viewHeight-1 {
+		lines = append(lines, "")
+	}
+	legend := " Services with the most cleartext first; unknown bytes came before a conversation could be classified"
+	lines = append(lines, m.fg(m.theme.Muted).Render(truncateString(legend, m.width)))
+	return strings.Join(lines, "\n")
+}
+
+// renderEncryptionLine renders one service's row, red when mostly cleartext
+func (m *Model) renderEncryptionLine(service models.ServiceEncryption) string {
+	share := strings.Repeat(" ", encryptionBarWidth) + "    -"
+	if service.EncryptedBytes+service.PlaintextBytes > 0 {
+		share = fmt.Sprintf("%s %3.0f%%", m.shareBar(service.EncryptedRatio, encryptionBarWidth), service.EncryptedRatio*100)
+	}
+	line := fmt.Sprintf("%-11s %-12s %10s %10s %10s  %s",
+		truncateString(service.Service, 11),
+		truncateString(service.Name, 12),
+		formatBytes(int(service.EncryptedBytes)),
+		formatBytes(int(service.PlaintextBytes)),
+		formatBytes(int(service.UnknownBytes)),
+		share,
+	)
+
+	style := m.fg(m.theme.Muted)
+	switch {
+	case service.PlaintextBytes > 0 && service.EncryptedRatio < 0.5:
+		style = m.fg(m.theme.Error)
+	case service.PlaintextBytes > 0:
+		style = m.fg(m.theme.Warning)
+	case service.EncryptedBytes > 0:
+		style = m.fg(m.theme.Good)
+	}
+	return style.Render(truncateString(line, m.width))
+}
+
+// shareBar renders a ratio from 0 to 1 as a bar of the given width
+func (m *Model) shareBar(ratio float64, width int) string {
+	full, empty := "█", "░"
+	if m.accessible {
+		full, empty = "#", "."
+	}
+	filled := int(ratio*float64(width) + 0.5)
+	return strings.Repeat(full, filled) + strings.Repeat(empty, width-filled)
+}
+
+// ratioTrend renders the encrypted share of each hour as one sparkline level,
+// empty for hours with nothing classified
+func (m *Model) ratioTrend(hourly []models.EncryptionSlot) string {
+	glyphs := sparkGlyphs
+	if m.accessible {
+		glyphs = sparkASCII
+	}
+	var trend strings.Builder
+	for _, slot := range hourly {
+		if slot.EncryptedBytes+slot.PlaintextBytes == 0 {
+			trend.WriteRune(' ')
+			continue
+		}
+		trend.WriteRune(glyphs[int(slot.EncryptedRatio*float64(len(glyphs)-1)+0.5)])
+	}
+	if len(hourly) > 0 {
+		latest := hourly[len(hourly)-1]
+		fmt.Fprintf(&trend, " (%.0f%% this hour)", latest.EncryptedRatio*100)
+	}
+	return trend.String()
+}
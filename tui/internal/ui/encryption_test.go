@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+func TestEncryptionView(t *testing.T) {
+	m := NewModel(nil, Options{Accessible: true})
+	m.width, m.height, m.connected = 120, 20, true
+	m.viewMode = ViewModeConversations
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	if m.viewMode != ViewModeEncryption {
+		t.Fatalf("Expected t to open the encryption view, got view %d", m.viewMode)
+	}
+	if view := m.renderEncryption(); !strings.Contains(view, "No traffic to audit yet") {
+		t.Errorf("Expected an empty message before the report, got:\n%s", view)
+	}
+
+	hour := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	updated, _ := m.Update(websocket.EncryptionStatsMsg{
+		Since:          hour,
+		EncryptedBytes: 2500,
+		PlaintextBytes: 500,
+		EncryptedRatio: 2500.0 / 3000,
+		Services: []models.ServiceEncryption{
+			{Service: "TCP/80", Name: "HTTP", PlaintextBytes: 400},
+			{Service: "TCP/25", Name: "SMTP", EncryptedBytes: 300, PlaintextBytes: 100, EncryptedRatio: 0.75},
+			{Service: "TCP/5432", Name: "PostgreSQL", UnknownBytes: 900},
+		},
+		Hourly: []models.EncryptionSlot{
+			{Start: hour, EncryptedBytes: 0, PlaintextBytes: 100},
+			{Start: hour.Add(time.Hour), EncryptedBytes: 2500, PlaintextBytes: 400, EncryptedRatio: 2500.0 / 2900},
+		},
+	})
+	m = updated.(Model)
+
+	view := m.renderEncryption()
+	rows := strings.Split(view, "\n")
+	if !strings.Contains(rows[0], "Encrypted 83% of classified traffic") {
+		t.Errorf("Expected the overall share in the summary, got %q", rows[0])
+	}
+	// Each hour is a level, none encrypted the lowest
+	if !strings.Contains(rows[1], "_* (86% this hour)") {
+		t.Errorf("Expected the hourly trend, got %q", rows[1])
+	}
+	if !strings.Contains(rows[3], "TCP/80") || !strings.Contains(rows[3], "...................   0%") {
+		t.Errorf("Expected HTTP first with an empty bar, got %q", rows[3])
+	}
+	if !strings.Contains(rows[4], "###############.....  75%") {
+		t.Errorf("Expected SMTP three quarters encrypted, got %q", rows[4])
+	}
+	// Nothing classified has no share at all
+	if !strings.HasSuffix(strings.TrimRight(rows[5], " "), "-") {
+		t.Errorf("Expected no share for an unclassified service, got %q", rows[5])
+	}
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.viewMode != ViewModeConversations {
+		t.Errorf("Expected esc to return to the conversations view, got %d", m.viewMode)
+	}
+}
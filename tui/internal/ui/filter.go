@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/filter"
+)
+
+// filterPrompt is a display filter being typed in the footer
+type filterPrompt struct {
+	text string
+	err  string // Why the text didn't parse, shown until it's edited
+}
+
+// openFilterPrompt starts editing the display filter, from the one applied
+func (m *Model) openFilterPrompt() {
+	m.filterPrompt = &filterPrompt{text: m.filter.String()}
+}
+
+// handleFilterKey handles key presses while the filter prompt is open. Enter
+// applies the expression, or an empty one to clear it, and esc leaves the
+// applied filter as it was.
+func (m *Model) handleFilterKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	prompt := m.filterPrompt
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.filterPrompt = nil
+	case tea.KeyEnter:
+		f, err := filter.Parse(prompt.text)
+		if err != nil {
+			prompt.err = err.Error()
+			return m, nil
+		}
+		m.filterPrompt = nil
+		m.filter = f
+		m.scrollOffset = 0
+		m.applyFilter()
+	case tea.KeyBackspace:
+		if runes := []rune(prompt.text); len(runes) > 0 {
+			prompt.text = string(runes[:len(runes)-1])
+		}
+		prompt.err = ""
+	case tea.KeyCtrlU:
+		prompt.text, prompt.err = "", ""
+	case tea.KeySpace:
+		prompt.text += " "
+		prompt.err = ""
+	case tea.KeyRunes:
+		prompt.text += string(msg.Runes)
+		prompt.err = ""
+	}
+	return m, nil
+}
+
+// renderFilterPrompt renders the filter being typed in place of the footer
+func (m *Model) renderFilterPrompt() string {
+	prompt := m.filterPrompt
+	line := m.fg(m.theme.Accent).Render(" Filter: ") + prompt.text + m.cursor()
+	if prompt.err != "" {
+		line += "  " + m.fg(m.theme.Error).Render(prompt.err)
+	} else if prompt.text == "" {
+		line += "  " + m.fg(m.theme.Muted).Render(`e.g. tcp && dst.port == 443 && ip contains "10.0"`)
+	}
+	return m.fg(m.theme.Text).Width(m.width).Render(line)
+}
+
+// cursor marks the end of the text being typed
+func (m *Model) cursor() string {
+	if m.accessible {
+		return "_"
+	}
+	return "█"
+}
+
+// wrapList joins items with ", ", breaking lines before width and starting
+// each new line with indent
+func wrapList(items []string, width int, indent string) string {
+	var list strings.Builder
+	lineLen := 0
+	for i, item := range items {
+		if i > 0 {
+			list.WriteString(",")
+			lineLen++
+			if lineLen+len(item)+1 > width {
+				list.WriteString(indent)
+				lineLen = 0
+			} else {
+				list.WriteString(" ")
+				lineLen++
+			}
+		}
+		list.WriteString(item)
+		lineLen += len(item)
+	}
+	return list.String()
+}
+
+// filterStatus describes the applied display filter for the stats line
+func (m *Model) filterStatus() string {
+	if m.filter == nil {
+		return ""
+	}
+	return " | Filter: " + m.filter.String()
+}
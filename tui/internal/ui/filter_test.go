@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/models"
+)
+
+func TestFilterPrompt(t *testing.T) {
+	m := NewModel(nil, Options{})
+	m.events = []models.NetworkEvent{
+		{TransportProtocol: "TCP", SourceIP: "10.0.0.5", DestIP: "140.82.112.3", DestPort: 443},
+		{TransportProtocol: "UDP", SourceIP: "10.0.0.5", DestIP: "10.0.0.1", DestPort: 53},
+	}
+	m.applyFilter()
+
+	typeKeys := func(keys ...tea.KeyMsg) {
+		for _, key := range keys {
+			m.handleKeyPress(key)
+		}
+	}
+	text := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	// Space types a space rather than pausing the capture
+	typeKeys(text("f"), text("udp"), tea.KeyMsg{Type: tea.KeySpace}, text("&&"), enter)
+	if m.filterPrompt == nil || !strings.Contains(m.filterPrompt.err, "ends early") {
+		t.Fatalf("Expected the prompt to stay open with the parse error, got %+v", m.filterPrompt)
+	}
+
+	typeKeys(text(" dst.port == 53"), enter)
+	if m.filterPrompt != nil || len(m.filteredEvents) != 1 || m.filteredEvents[0].DestPort != 53 {
+		t.Fatalf("Expected only the DNS packet, got %+v", m.filteredEvents)
+	}
+	if status := m.filterStatus(); status != " | Filter: udp && dst.port == 53" {
+		t.Errorf("Expected the filter in the stats line, got %q", status)
+	}
+
+	// Esc keeps the applied filter, an empty expression clears it
+	typeKeys(text("f"), tea.KeyMsg{Type: tea.KeyBackspace}, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.filter.String() != "udp && dst.port == 53" {
+		t.Errorf("Expected esc to leave the filter, got %q", m.filter)
+	}
+	typeKeys(text("f"), tea.KeyMsg{Type: tea.KeyCtrlU}, enter)
+	if m.filter != nil || len(m.filteredEvents) != 2 {
+		t.Errorf("Expected every packet back, got %d", len(m.filteredEvents))
+	}
+}
@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/netty/tui/internal/models"
+)
+
+// blockSelectedConversation asks the daemon for rules blocking the selected conversation's remote end
+func (m *Model) blockSelectedConversation() tea.Cmd {
+	if m.selectedIndex < 0 || m.selectedIndex >= len(m.conversations) {
+		return nil
+	}
+
+	conv := m.conversations[m.selectedIndex]
+	host, port := conv.RemoteEndpoint()
+	if host == "" {
+		return nil
+	}
+
+	target := models.FirewallTarget{
+		Host:     host,
+		Port:     port,
+		Protocol: strings.ToLower(conv.Protocol),
+	}
+	return func() tea.Msg {
+		if m.wsClient != nil {
+			m.wsClient.RequestFirewallRules(target)
+		}
+		return nil
+	}
+}
+
+// handleFirewallKey handles key presses while the firewall prompt is shown
+func (m *Model) handleFirewallKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	prompt := m.firewallPrompt
+	m.firewallPrompt = nil
+
+	// Results are dismissed with any key; previews can be confirmed with the confirm key
+	if m.firewallResult || prompt.Error != "" || m.keys.Action(msg.String()) != ActionConfirm {
+		m.firewallResult = false
+		return m, nil
+	}
+
+	target := prompt.Target
+	return m, func() tea.Msg {
+		if m.wsClient != nil {
+			m.wsClient.ApplyFirewallRules(target)
+		}
+		return nil
+	}
+}
+
+// renderFirewallPrompt renders generated rules with a confirmation prompt or the apply result
+func (m *Model) renderFirewallPrompt() string {
+	prompt := m.firewallPrompt
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	ruleStyle := m.fg(m.theme.Text)
+	hintStyle := m.fg(m.theme.Muted)
+	errorStyle := m.fg(m.theme.Error)
+
+	target := prompt.Target.Host
+	if prompt.Target.Port > 0 {
+		target = fmt.Sprintf("%s port %d", target, prompt.Target.Port)
+	}
+
+	var content strings.Builder
+	content.WriteString(titleStyle.Render(fmt.Sprintf("Block %s (%s)", target, prompt.Backend)))
+	content.WriteString("\n\n")
+	for _, rule := range prompt.Rules {
+		content.WriteString(ruleStyle.Render(rule) + "\n")
+	}
+	content.WriteString("\n")
+
+	switch {
+	case prompt.Error != "":
+		content.WriteString(errorStyle.Render(prompt.Error) + "\n\n")
+		content.WriteString(hintStyle.Render("Press any key to close"))
+	case m.firewallResult && prompt.Applied:
+		content.WriteString(titleStyle.Render("Rules applied") + "\n\n")
+		content.WriteString(hintStyle.Render("Press any key to close"))
+	default:
+		content.WriteString(hintStyle.Render(fmt.Sprintf("Apply these rules on the daemon host? %s:apply | any other key:cancel", m.keys.Key(ActionConfirm))))
+	}
+
+	boxStyle := m.boxStyle(m.theme.Error)
+
+	return lipgloss.NewStyle().
+		Width(m.width).
+		Height(m.viewportHeight()).
+		Align(lipgloss.Center, lipgloss.Center).
+		Render(boxStyle.Render(content.String()))
+}
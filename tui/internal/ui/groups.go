@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/netty/tui/internal/models"
+)
+
+// conversationRows returns how many rows the conversations view lists
+func (m *Model) conversationRows() int {
+	if m.groupServices {
+		return len(m.serviceGroups)
+	}
+	return len(m.conversations)
+}
+
+// toggleServiceGroups switches the conversations view between single
+// conversations and conversations grouped by remote service
+func (m *Model) toggleServiceGroups() tea.Cmd {
+	m.groupServices = !m.groupServices
+	m.selectedIndex = 0
+	m.scrollOffset = 0
+	return m.requestConversations()
+}
+
+// showGroupConversations leaves the grouped view with the selected group's
+// most recently active conversation selected
+func (m *Model) showGroupConversations() {
+	if m.selectedIndex < 0 || m.selectedIndex >= len(m.serviceGroups) {
+		return
+	}
+	ids := make(map[string]bool)
+	for _, id := range m.serviceGroups[m.selectedIndex].ConversationIDs {
+		ids[id] = true
+	}
+
+	m.groupServices = false
+	m.selectedIndex = 0
+	m.scrollOffset = 0
+	for i, conv := range m.conversations {
+		if ids[conv.ID] {
+			m.selectedIndex = i
+			m.ensureSelectedVisible()
+			return
+		}
+	}
+	m.notice = "The group's conversations are no longer tracked"
+}
+
+// renderServiceGroupList renders conversations grouped by remote service, largest first
+func (m *Model) renderServiceGroupList() string {
+	viewHeight := m.viewportHeight()
+
+	if len(m.serviceGroups) == 0 {
+		message := "No active conversations"
+		if !m.connected {
+			message = "Not connected to daemon"
+		}
+		return lipgloss.NewStyle().
+			Foreground(m.theme.Muted).
+			Align(lipgloss.Center).
+			Width(m.width).
+			Height(viewHeight).
+			Render(message)
+	}
+
+	var lines []string
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	header := m.headerPrefix("ACT") + fmt.Sprintf("%-40s %-15s %-8s %-10s %-10s %s",
+		"Service", "Protocol", "Conns", "Packets", "Data", "Addresses")
+	lines = append(lines, headerStyle.Render(header))
+
+	endIdx := m.scrollOffset + viewHeight - 1
+	if endIdx > len(m.serviceGroups) {
+		endIdx = len(m.serviceGroups)
+	}
+	for i := m.scrollOffset; i < endIdx; i++ {
+		lines = append(lines, m.renderServiceGroupLine(m.serviceGroups[i], i == m.selectedIndex))
+	}
+
+	for len(lines) < viewHeight {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderServiceGroupLine renders a single service group row
+func (m *Model) renderServiceGroupLine(group models.ServiceGroup, selected bool) string {
+	marker := "-"
+	if group.Active > 0 {
+		marker = "+"
+	}
+	line := m.rowPrefix(selected, group.Name+"/"+group.Service, marker) + fmt.Sprintf("%-40s %-15s %-8s %-10d %-10s %s",
+		truncateString(group.Name, 40),
+		truncateString(group.Service, 15),
+		fmt.Sprintf("%d/%d", group.Active, group.Conversations),
+		group.TotalPackets(),
+		formatBytes(int(group.TotalBytes())),
+		strings.Join(group.RemoteAddrs, ", "),
+	)
+	if m.width > 0 {
+		line = truncateString(line, m.width)
+	}
+
+	style := lipgloss.NewStyle()
+	switch {
+	case selected:
+		style = m.selectedStyle()
+	case group.Active > 0:
+		style = style.Foreground(m.theme.Good)
+	default:
+		style = style.Foreground(m.theme.Muted)
+	}
+	return style.Width(m.width).Render(line)
+}
@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/netty/tui/internal/models"
+)
+
+func TestShowGroupConversations(t *testing.T) {
+	m := Model{
+		groupServices: true,
+		conversations: []models.Conversation{{ID: "ssh"}, {ID: "gh-2"}, {ID: "gh-1"}},
+		serviceGroups: []models.ServiceGroup{
+			{Name: "api.github.com", Service: "HTTPS", ConversationIDs: []string{"gh-1", "gh-2"}},
+		},
+		height: 20,
+	}
+	if rows := m.conversationRows(); rows != 1 {
+		t.Fatalf("Expected 1 row while grouped, got %d", rows)
+	}
+
+	m.showGroupConversations()
+	if m.groupServices {
+		t.Error("Expected the grouped view to be left")
+	}
+	if m.selectedIndex != 1 {
+		t.Errorf("Expected the group's first listed conversation to be selected, got %d", m.selectedIndex)
+	}
+}
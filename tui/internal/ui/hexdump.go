@@ -0,0 +1,151 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/netty/tui/internal/models"
+)
+
+// payloadRegion is a span of an event's payload the daemon decoded,
+// highlighted in the hex dump
+type payloadRegion struct {
+	start, end int
+	name       string // Shown in the legend, e.g. "SNI"
+}
+
+// payloadRegions finds the parts of an event's payload its decoded fields
+// came from: the TLS record header, the server name and the HTTP start line
+func payloadRegions(event models.NetworkEvent) []payloadRegion {
+	data := event.Payload
+	var regions []payloadRegion
+	// A record header is a content type from 20 to 24 and a 3.x version
+	if event.TLS != nil && len(data) >= 5 && data[0] >= 20 && data[0] <= 24 && data[1] == 3 {
+		regions = append(regions, payloadRegion{0, 5, "TLS record header"})
+	}
+	if sni := event.TLSServerName; sni != "" {
+		if i := bytes.Index(data, []byte(sni)); i >= 0 {
+			regions = append(regions, payloadRegion{i, i + len(sni), "SNI"})
+		}
+	}
+	if event.HTTP != nil {
+		// A start line cut short by -payload-bytes runs to the end
+		end := bytes.Index(data, []byte("\r\n"))
+		if end < 0 {
+			end = len(data)
+		}
+		name := "HTTP request line"
+		if event.HTTP.Response {
+			name = "HTTP status line"
+		}
+		regions = append(regions, payloadRegion{0, end, name})
+	}
+	return regions
+}
+
+// hexDumpLines lays data out like hexdump -C, a line per 16 bytes: the
+// offset, the bytes in hex and the same bytes as ASCII, dots standing in for
+// anything unprintable. paint, when set, styles the hex and ASCII text of the
+// byte at an offset.
+func hexDumpLines(data []byte, paint func(offset int, text string) string) []string {
+	if paint == nil {
+		paint = func(_ int, text string) string { return text }
+	}
+	var lines []string
+	for offset := 0; offset < len(data); offset += 16 {
+		row := data[offset:min(offset+16, len(data))]
+		var b strings.Builder
+		fmt.Fprintf(&b, "%08x ", offset)
+		for i := 0; i < 16; i++ {
+			if i == 8 {
+				b.WriteByte(' ')
+			}
+			if i < len(row) {
+				b.WriteString(" " + paint(offset+i, fmt.Sprintf("%02x", row[i])))
+			} else {
+				b.WriteString("   ")
+			}
+		}
+		b.WriteString("  |")
+		for i, c := range row {
+			if c < 0x20 || c > 0x7e {
+				c = '.'
+			}
+			b.WriteString(paint(offset+i, string(c)))
+		}
+		b.WriteString("|")
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+// regionStyles returns the highlight for each of an event's payload regions
+func (m *Model) regionStyles(regions []payloadRegion) []lipgloss.Style {
+	colors := []lipgloss.TerminalColor{m.theme.Accent, m.theme.Warning, m.theme.Good}
+	styles := make([]lipgloss.Style, len(regions))
+	for i := range regions {
+		if m.theme.Monochrome {
+			// Without color, regions alternate between reverse and underline
+			styles[i] = lipgloss.NewStyle().Reverse(i%2 == 0).Underline(i%2 == 1)
+		} else {
+			styles[i] = m.fg(colors[i%len(colors)]).Bold(true)
+		}
+	}
+	return styles
+}
+
+// renderHexPane renders the part of the event's payload dump that fits in
+// height lines, from the scroll position, with its decoded regions highlighted
+func (m *Model) renderHexPane(event models.NetworkEvent, height int) string {
+	regions := payloadRegions(event)
+	styles := m.regionStyles(regions)
+	paint := func(offset int, text string) string {
+		// Later regions lie inside earlier ones, e.g. the SNI in a TLS record
+		for i := len(regions) - 1; i >= 0; i-- {
+			if offset >= regions[i].start && offset < regions[i].end {
+				return styles[i].Render(text)
+			}
+		}
+		return text
+	}
+
+	title := fmt.Sprintf("Payload (first %d of %d bytes)", len(event.Payload), event.PayloadSize)
+	lines := []string{m.fg(m.theme.Accent).Bold(true).Render(title), ""}
+	dump := hexDumpLines(event.Payload, paint)
+	start := min(m.hexScroll, m.hexScrollLimit(event, height))
+	end := min(start+m.hexVisibleLines(height, len(regions)), len(dump))
+	lines = append(lines, dump[start:end]...)
+	if end < len(dump) {
+		lines = append(lines, m.fg(m.theme.Muted).Render(fmt.Sprintf("... %d more lines", len(dump)-end)))
+	}
+	if len(regions) > 0 {
+		lines = append(lines, "")
+		for i, region := range regions {
+			lines = append(lines, styles[i].Render(fmt.Sprintf("%04x-%04x", region.start, region.end-1))+" "+region.name)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hexPaneHeight returns the lines the payload pane has inside the detail box
+func (m *Model) hexPaneHeight() int {
+	return m.viewportHeight() - 4
+}
+
+// hexVisibleLines returns how many dump lines fit in height beside the
+// title, the scroll hint and the legend
+func (m *Model) hexVisibleLines(height, regions int) int {
+	chrome := 3
+	if regions > 0 {
+		chrome += 1 + regions
+	}
+	return max(height-chrome, 1)
+}
+
+// hexScrollLimit returns the furthest the event's payload dump can scroll in height lines
+func (m *Model) hexScrollLimit(event models.NetworkEvent, height int) int {
+	rows := (len(event.Payload) + 15) / 16
+	return max(rows-m.hexVisibleLines(height, len(payloadRegions(event))), 0)
+}
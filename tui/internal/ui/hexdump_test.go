@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/models"
+)
+
+func TestHexDumpLines(t *testing.T) {
+	got := strings.Join(hexDumpLines([]byte("GET / HTTP/1.1\r\nHost: x\r\n"), nil), "\n")
+	want := "00000000  47 45 54 20 2f 20 48 54  54 50 2f 31 2e 31 0d 0a  |GET / HTTP/1.1..|\n" +
+		"00000010  48 6f 73 74 3a 20 78 0d  0a                       |Host: x..|"
+	if got != want {
+		t.Errorf("Unexpected dump:\n%s\nwant:\n%s", got, want)
+	}
+	if lines := hexDumpLines(nil, nil); len(lines) != 0 {
+		t.Errorf("Expected nothing for no data, got %q", lines)
+	}
+}
+
+func TestPayloadDetail(t *testing.T) {
+	// The daemon sends the payload base64-encoded, as JSON does []byte
+	var event models.NetworkEvent
+	if err := json.Unmarshal([]byte(`{"source_ip": "10.0.0.5", "payload_size": 1200, "payload": "U1NILTIuMA=="}`), &event); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	m := NewModel(nil, Options{})
+	m.width, m.height = 200, 60
+	m.filteredEvents = []models.NetworkEvent{event}
+	m.selectedIndex = 0
+	detail := m.renderEventDetail()
+	for _, want := range []string{"Payload (first 7 of 1200 bytes)", "53 53 48 2d 32 2e 30", "|SSH-2.0|"} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("Expected %q in the detail view, got %q", want, detail)
+		}
+	}
+}
+
+func TestPayloadRegions(t *testing.T) {
+	// A ClientHello record, the server name inside it
+	hello := append([]byte{0x16, 0x03, 0x01, 0x00, 0x40}, make([]byte, 40)...)
+	hello = append(hello, "example.com"...)
+	tls := models.NetworkEvent{Payload: hello, TLSServerName: "example.com", TLS: &models.TLSInfo{Handshake: "client_hello"}}
+	regions := payloadRegions(tls)
+	if len(regions) != 2 || regions[0] != (payloadRegion{0, 5, "TLS record header"}) || regions[1] != (payloadRegion{45, 56, "SNI"}) {
+		t.Errorf("Expected the record header and SNI, got %+v", regions)
+	}
+
+	http := models.NetworkEvent{Payload: []byte("GET /index.html HTTP/1.1\r\nHost: example.com\r\n"), HTTP: &models.HTTPInfo{Method: "GET"}}
+	if regions := payloadRegions(http); len(regions) != 1 || regions[0] != (payloadRegion{0, 24, "HTTP request line"}) {
+		t.Errorf("Expected the request line, got %+v", regions)
+	}
+	// Cut short before the line ends, the whole payload is the line
+	http.Payload = http.Payload[:10]
+	if regions := payloadRegions(http); regions[0].end != 10 {
+		t.Errorf("Expected the line to run to the end, got %+v", regions)
+	}
+
+	if regions := payloadRegions(models.NetworkEvent{Payload: []byte("SSH-2.0")}); len(regions) != 0 {
+		t.Errorf("Expected no regions in an undecoded payload, got %+v", regions)
+	}
+}
+
+func TestHexPaneScroll(t *testing.T) {
+	m := NewModel(nil, Options{})
+	m.width, m.height = 200, 30
+	event := models.NetworkEvent{SourceIP: "10.0.0.5", PayloadSize: 4096, Payload: make([]byte, 1024)}
+	m.filteredEvents = []models.NetworkEvent{event}
+	m.selectedIndex = 0
+	m.viewMode = ViewModePacketDetail
+
+	if detail := m.renderEventDetail(); !strings.Contains(detail, "00000000 ") || strings.Contains(detail, "000003f0 ") {
+		t.Fatalf("Expected the dump to start at the top, got %q", detail)
+	}
+	limit := m.hexScrollLimit(event, m.hexPaneHeight())
+	for i := 0; i < limit+5; i++ {
+		m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	}
+	if m.hexScroll != limit {
+		t.Errorf("Expected scrolling to stop at %d, got %d", limit, m.hexScroll)
+	}
+	if detail := m.renderEventDetail(); !strings.Contains(detail, "000003f0 ") {
+		t.Errorf("Expected the last line after scrolling to the end, got %q", detail)
+	}
+}
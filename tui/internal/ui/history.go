@@ -0,0 +1,277 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+// historyLimit is the most conversations and events asked for per window
+const historyLimit = 1000
+
+// historySpans are the window lengths zooming steps through
+var historySpans = []time.Duration{15 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour}
+
+// historyWindow is the past time range the history view shows
+type historyWindow struct {
+	To     time.Time // End of the window, whole seconds
+	Span   int       // Index into historySpans
+	Host   string    // Only this remote host, "" for every host
+	Events bool      // List the sampled events instead of conversations
+}
+
+// From returns the start of the window
+func (w historyWindow) From() time.Time {
+	return w.To.Add(-historySpans[w.Span])
+}
+
+// openHistory switches to the history view, showing the last hour from the
+// daemon's history store
+func (m *Model) openHistory() tea.Cmd {
+	if info := m.daemonDetails(); info == nil || !info.Has("history") {
+		m.notice = "The daemon keeps no history, start it with -history-db"
+		return nil
+	}
+	m.viewMode = ViewModeHistory
+	m.historyWindow = historyWindow{To: time.Now().Truncate(time.Second), Span: 1}
+	m.historyScroll = 0
+	m.history = nil
+	return m.requestHistory()
+}
+
+// requestHistory asks the daemon for what it stored in the current window
+func (m *Model) requestHistory() tea.Cmd {
+	client, window := m.wsClient, m.historyWindow
+	return func() tea.Msg {
+		if client != nil {
+			client.RequestHistory(window.From(), window.To, window.Host, "", historyLimit)
+		}
+		return nil
+	}
+}
+
+// handleHistory stores the daemon's answer for the current window, the
+// busiest conversations first, dropping answers to earlier requests
+func (m *Model) handleHistory(msg websocket.HistoryMsg) {
+	history := models.History(msg)
+	if m.viewMode != ViewModeHistory || history.Host != m.historyWindow.Host {
+		return
+	}
+	if history.Error == "" && (!history.From.Equal(m.historyWindow.From()) || !history.To.Equal(m.historyWindow.To)) {
+		return
+	}
+	sort.SliceStable(history.Conversations, func(i, j int) bool {
+		return history.Conversations[i].TotalBytes() > history.Conversations[j].TotalBytes()
+	})
+	for i := range history.Events {
+		history.Events[i].Seq = uint64(i + 1)
+	}
+	m.history = &history
+	m.clampHistoryScroll()
+}
+
+// historyRows returns how many rows the history view lists
+func (m *Model) historyRows() int {
+	if m.history == nil {
+		return 0
+	}
+	if m.historyWindow.Events {
+		return len(m.history.Events)
+	}
+	return len(m.history.Conversations)
+}
+
+// clampHistoryScroll keeps the selected row within the list
+func (m *Model) clampHistoryScroll() {
+	if m.historyScroll > m.historyRows()-1 {
+		m.historyScroll = m.historyRows() - 1
+	}
+	if m.historyScroll < 0 {
+		m.historyScroll = 0
+	}
+}
+
+// moveHistoryWindow changes the window and asks for what's in it
+func (m *Model) moveHistoryWindow(window historyWindow) tea.Cmd {
+	if now := time.Now().Truncate(time.Second); window.To.After(now) {
+		window.To = now
+	}
+	m.historyWindow = window
+	m.historyScroll = 0
+	return m.requestHistory()
+}
+
+// handleHistoryKey handles key presses in the history view
+func (m *Model) handleHistoryKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	window := m.historyWindow
+	switch m.keys.Action(msg.String()) {
+	case ActionBack:
+		// Drop the host first, then leave
+		if window.Host != "" {
+			window.Host = ""
+			return m, m.moveHistoryWindow(window)
+		}
+		m.viewMode = ViewModeConversations
+		return m, m.requestConversations()
+	case ActionQuit, ActionHistory:
+		m.viewMode = ViewModeConversations
+		return m, m.requestConversations()
+	case ActionEarlier:
+		window.To = window.To.Add(-historySpans[window.Span])
+		return m, m.moveHistoryWindow(window)
+	case ActionLater:
+		window.To = window.To.Add(historySpans[window.Span])
+		return m, m.moveHistoryWindow(window)
+	case ActionZoomIn:
+		if window.Span > 0 {
+			window.Span--
+			return m, m.moveHistoryWindow(window)
+		}
+	case ActionZoomOut:
+		if window.Span < len(historySpans)-1 {
+			window.Span++
+			return m, m.moveHistoryWindow(window)
+		}
+	case ActionSelect:
+		// Narrow the window to the selected conversation's remote host
+		if !window.Events && m.history != nil && m.historyScroll < len(m.history.Conversations) {
+			window.Host = m.history.Conversations[m.historyScroll].RemoteName()
+			return m, m.moveHistoryWindow(window)
+		}
+	case ActionSwitchView:
+		m.historyWindow.Events = !m.historyWindow.Events
+		m.historyScroll = 0
+	case ActionDown:
+		if m.historyScroll < m.historyRows()-1 {
+			m.historyScroll++
+		}
+	case ActionUp:
+		if m.historyScroll > 0 {
+			m.historyScroll--
+		}
+	case ActionHelp:
+		m.showHelp = !m.showHelp
+	}
+	return m, nil
+}
+
+// historyTitle describes the window, e.g. "Jul 1 14:00:00 - 15:00:00 (1h)"
+func (m *Model) historyTitle() string {
+	window := m.historyWindow
+	from, to := window.From().Local(), window.To.Local()
+	end := to.Format("15:04:05")
+	if from.YearDay() != to.YearDay() || from.Year() != to.Year() {
+		end = to.Format("Jan 2 15:04:05")
+	}
+	title := fmt.Sprintf("%s - %s (%s)", from.Format("Jan 2 15:04:05"), end, formatDuration(historySpans[window.Span]))
+	if window.Host != "" {
+		title += " for " + window.Host
+	}
+	return title
+}
+
+// renderHistory renders the conversations or sampled events stored for the window
+func (m *Model) renderHistory() string {
+	viewHeight := m.viewportHeight()
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+
+	var message string
+	switch {
+	case !m.connected:
+		message = "Not connected to daemon"
+	case m.history == nil:
+		message = "Loading " + m.historyTitle()
+	case m.history.Error != "":
+		message = "The daemon couldn't read its history: " + m.history.Error
+	case m.historyRows() == 0 && m.historyWindow.Events:
+		message = "No events stored for " + m.historyTitle() + "\n\nThe daemon stores them with -history-sample"
+	case m.historyRows() == 0:
+		message = "No conversations stored for " + m.historyTitle()
+	}
+	if message != "" {
+		return lipgloss.NewStyle().
+			Foreground(m.theme.Muted).
+			Align(lipgloss.Center).
+			Width(m.width).
+			Height(viewHeight).
+			Render(message)
+	}
+
+	history := m.history
+	var bytesIn, bytesOut int64
+	for _, conv := range history.Conversations {
+		bytesIn += conv.BytesIn
+		bytesOut += conv.BytesOut
+	}
+	summary := fmt.Sprintf(" %s: %d conversations, %s in, %s out, %d sampled events",
+		m.historyTitle(), len(history.Conversations), formatBytes(int(bytesIn)), formatBytes(int(bytesOut)), len(history.Events))
+	if history.Truncated {
+		summary += fmt.Sprintf(" (first %d of each)", historyLimit)
+	}
+	lines := []string{m.fg(m.theme.Text).Render(truncateString(summary, m.width))}
+
+	header := m.headerPrefix("ACT") + fmt.Sprintf("%-8s %-40s %-15s %-10s %-10s %-8s",
+		"Started", "Conversation", "Service", "In", "Out", "Duration")
+	if m.historyWindow.Events {
+		header = m.headerPrefix("DIR") + eventHeader()
+	}
+	lines = append(lines, titleStyle.Render(truncateString(header, m.width)))
+
+	// Summary and header above, legend below
+	visible := viewHeight - 3
+	if visible < 1 {
+		visible = 1
+	}
+	start := 0
+	if m.historyScroll >= visible {
+		start = m.historyScroll - visible + 1
+	}
+	for i := start; i < m.historyRows() && i-start < visible; i++ {
+		if m.historyWindow.Events {
+			lines = append(lines, m.renderEventLine(history.Events[i], i == m.historyScroll))
+		} else {
+			lines = append(lines, m.renderHistoryLine(history.Conversations[i], i == m.historyScroll))
+		}
+	}
+
+	for len(lines) < viewHeight-1 {
+		lines = append(lines, "")
+	}
+	legend := " Busiest conversations first; their bytes are totals, including any outside the window"
+	if m.historyWindow.Events {
+		legend = " Oldest first; only the sampled events the daemon stored are shown"
+	}
+	lines = append(lines, m.fg(m.theme.Muted).Render(truncateString(legend, m.width)))
+	return strings.Join(lines, "\n")
+}
+
+// renderHistoryLine renders one stored conversation
+func (m *Model) renderHistoryLine(conv models.Conversation, selected bool) string {
+	marker := "-"
+	if conv.IsActive() {
+		marker = "*"
+	}
+	line := m.rowPrefix(selected, conv.ID, marker) + fmt.Sprintf("%-8s %-40s %-15s %-10s %-10s %-8s",
+		conv.StartTime.Local().Format("15:04:05"),
+		truncateString(conv.GetEndpointPair(), 40),
+		truncateString(conv.GetServiceInfo(), 15),
+		formatBytes(int(conv.BytesIn)),
+		formatBytes(int(conv.BytesOut)),
+		formatDuration(conv.DurationValue()),
+	)
+	if m.width > 3 {
+		line = truncateString(line, m.width)
+	}
+
+	style := m.fg(m.theme.Text)
+	if selected {
+		style = m.selectedStyle()
+	}
+	return style.Width(m.width).Render(line)
+}
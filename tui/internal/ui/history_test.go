@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+func TestHistoryView(t *testing.T) {
+	m := NewModel(nil, Options{Accessible: true})
+	m.width, m.height, m.connected = 140, 20, true
+	m.viewMode = ViewModeConversations
+	press := func(key string) {
+		m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	}
+
+	press("H")
+	if m.viewMode != ViewModeConversations || !strings.Contains(m.notice, "-history-db") {
+		t.Fatalf("Expected a notice without a history store, got view %d and %q", m.viewMode, m.notice)
+	}
+	m.daemonInfo = &models.DaemonInfo{Capabilities: []string{"history"}}
+	press("H")
+	if m.viewMode != ViewModeHistory {
+		t.Fatalf("Expected H to open the history view, got view %d", m.viewMode)
+	}
+	if span := m.historyWindow.To.Sub(m.historyWindow.From()); span != time.Hour || time.Since(m.historyWindow.To) > time.Minute {
+		t.Errorf("Expected the last hour, got %s ending %s", span, m.historyWindow.To)
+	}
+
+	// Stepping back a window, then zooming out around its end
+	to := m.historyWindow.To
+	press("[")
+	if !m.historyWindow.To.Equal(to.Add(-time.Hour)) {
+		t.Errorf("Expected the previous hour, got one ending %s", m.historyWindow.To)
+	}
+	press("-")
+	if m.historyWindow.From() != to.Add(-7*time.Hour) {
+		t.Errorf("Expected six hours before the previous hour's end, got %s", m.historyWindow.From())
+	}
+	// Later never goes past now
+	press("]")
+	press("]")
+	if m.historyWindow.To.After(time.Now()) {
+		t.Errorf("Expected the window to end by now, got %s", m.historyWindow.To)
+	}
+
+	// Answers for another window are dropped
+	window := m.historyWindow
+	m.handleHistory(websocket.HistoryMsg{From: window.From().Add(-time.Hour), To: window.To})
+	if m.history != nil {
+		t.Fatalf("Expected a stale answer to be dropped, got %+v", m.history)
+	}
+	m.handleHistory(websocket.HistoryMsg{
+		From: window.From(),
+		To:   window.To,
+		Conversations: []models.Conversation{
+			{ID: "quiet", LocalAddr: "192.168.1.10:50000", RemoteAddr: "192.0.2.53:53", Service: "DNS", BytesIn: 100},
+			{ID: "busy", LocalAddr: "192.168.1.10:50001", RemoteAddr: "203.0.113.7:443", ServerName: "api.example.com", Service: "HTTPS", BytesIn: 3 << 20},
+		},
+		Events: []models.NetworkEvent{{SourceIP: "192.168.1.10", DestIP: "203.0.113.7", Size: 1500}},
+	})
+
+	rows := strings.Split(m.renderHistory(), "\n")
+	if !strings.Contains(rows[0], "2 conversations, 3.0 MB in") || !strings.Contains(rows[0], "1 sampled events") {
+		t.Errorf("Expected the window's totals, got %q", rows[0])
+	}
+	if !strings.Contains(rows[2], "busy") || !strings.Contains(rows[3], "quiet") {
+		t.Errorf("Expected the busiest conversation first, got:\n%s", strings.Join(rows, "\n"))
+	}
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyTab})
+	if !m.historyWindow.Events || !strings.Contains(m.renderHistory(), "203.0.113.7") {
+		t.Errorf("Expected tab to list the sampled events")
+	}
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyTab})
+
+	// Enter narrows to the selected conversation's host, esc widens again
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.historyWindow.Host != "api.example.com" {
+		t.Errorf("Expected enter to narrow to the server name, got %q", m.historyWindow.Host)
+	}
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.viewMode != ViewModeHistory || m.historyWindow.Host != "" {
+		t.Errorf("Expected esc to drop the host first, got view %d and %q", m.viewMode, m.historyWindow.Host)
+	}
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.viewMode != ViewModeConversations {
+		t.Errorf("Expected esc to return to conversations, got view %d", m.viewMode)
+	}
+}
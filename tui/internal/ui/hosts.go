@@ -0,0 +1,188 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/netty/tui/internal/models"
+)
+
+// hostOrders are the orders Tab cycles the hosts view through
+var hostOrders = []string{"bytes", "last seen", "conversations"}
+
+// openHosts switches to the conversations grouped by remote host
+func (m *Model) openHosts() tea.Cmd {
+	m.viewMode = ViewModeHosts
+	m.hostsSelected = 0
+	m.lastConvUpdate = time.Now()
+	return m.requestHosts()
+}
+
+// requestHosts asks the daemon for its conversations grouped by remote host
+func (m *Model) requestHosts() tea.Cmd {
+	return func() tea.Msg {
+		if m.wsClient != nil {
+			m.wsClient.RequestHosts()
+		}
+		return nil
+	}
+}
+
+// setHosts stores the daemon's hosts in the chosen order, keeping the
+// selection on the same host
+func (m *Model) setHosts(hosts []models.Host) {
+	var selected string
+	if m.hostsSelected < len(m.hosts) {
+		selected = m.hosts[m.hostsSelected].IP
+	}
+	m.hosts = hosts
+	m.sortHosts()
+	m.hostsSelected = 0
+	for i, host := range m.hosts {
+		if host.IP == selected {
+			m.hostsSelected = i
+		}
+	}
+}
+
+// sortHosts orders the hosts by the chosen order, ties by IP
+func (m *Model) sortHosts() {
+	sort.SliceStable(m.hosts, func(i, j int) bool {
+		a, b := m.hosts[i], m.hosts[j]
+		switch hostOrders[m.hostsOrder] {
+		case "last seen":
+			if !a.LastSeen.Equal(b.LastSeen) {
+				return a.LastSeen.After(b.LastSeen)
+			}
+		case "conversations":
+			if a.Conversations != b.Conversations {
+				return a.Conversations > b.Conversations
+			}
+		default:
+			if a.Bytes != b.Bytes {
+				return a.Bytes > b.Bytes
+			}
+		}
+		return a.IP < b.IP
+	})
+}
+
+// handleHostsKey handles key presses in the hosts view
+func (m *Model) handleHostsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.keys.Action(msg.String()) {
+	case ActionBack, ActionQuit, ActionHosts:
+		m.viewMode = ViewModeConversations
+		return m, m.requestConversations()
+	case ActionSwitchView:
+		m.hostsOrder = (m.hostsOrder + 1) % len(hostOrders)
+		m.sortHosts()
+		m.hostsSelected = 0
+	case ActionWatch:
+		// Watch or stop watching the selected host, as in the conversations view
+		if m.hostsSelected < len(m.hosts) {
+			return m, m.toggleWatch(m.hosts[m.hostsSelected].IP)
+		}
+	case ActionDown:
+		if m.hostsSelected < len(m.hosts)-1 {
+			m.hostsSelected++
+		}
+	case ActionUp:
+		if m.hostsSelected > 0 {
+			m.hostsSelected--
+		}
+	case ActionTop:
+		m.hostsSelected = 0
+	case ActionBottom:
+		if len(m.hosts) > 0 {
+			m.hostsSelected = len(m.hosts) - 1
+		}
+	case ActionHelp:
+		m.showHelp = !m.showHelp
+	}
+	return m, nil
+}
+
+// renderHosts renders a row per remote host with its conversations' totals
+func (m *Model) renderHosts() string {
+	viewHeight := m.viewportHeight()
+
+	if len(m.hosts) == 0 {
+		message := "No conversations yet"
+		if !m.connected {
+			message = "Not connected to daemon"
+		}
+		return lipgloss.NewStyle().
+			Foreground(m.theme.Muted).
+			Align(lipgloss.Center).
+			Width(m.width).
+			Height(viewHeight).
+			Render(message)
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	header := m.headerPrefix("ACT") + fmt.Sprintf("%-30s %-3s %-8s %-10s %-10s %-12s %-9s %s",
+		"Host", "CC", "Conns", "In", "Out", "Rate", "Last seen", "Services")
+	lines := []string{titleStyle.Render(truncateString(header, m.width))}
+
+	// Keep the selected host in view below the header
+	visible := viewHeight - 1
+	if visible < 1 {
+		visible = 1
+	}
+	start := 0
+	if m.hostsSelected >= visible {
+		start = m.hostsSelected - visible + 1
+	}
+	now := time.Now()
+	for i := start; i < len(m.hosts) && i-start < visible; i++ {
+		lines = append(lines, m.renderHostLine(m.hosts[i], i == m.hostsSelected, now))
+	}
+
+	for len(lines) < viewHeight {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderHostLine renders a single host's row
+func (m *Model) renderHostLine(host models.Host, selected bool, now time.Time) string {
+	marker := "-"
+	if host.Active > 0 {
+		marker = "+"
+	}
+	name := host.Name()
+	if name != host.IP {
+		name = fmt.Sprintf("%s (%s)", name, host.IP)
+	}
+	line := m.rowPrefix(selected, host.IP, marker) + fmt.Sprintf("%-30s %-3s %-8s %-10s %-10s %-12s %-9s %s",
+		truncateString(name, 30),
+		host.CountryCode(),
+		fmt.Sprintf("%d/%d", host.Active, host.Conversations),
+		formatBytes(int(host.BytesIn)),
+		formatBytes(int(host.BytesOut)),
+		formatBytes(int(host.BytesPerSecond))+"/s",
+		formatDuration(now.Sub(host.LastSeen).Truncate(time.Second))+" ago",
+		strings.Join(host.Services, ", "),
+	)
+	if m.isWatched(host.IP) {
+		line += " WATCH"
+	}
+	if m.width > 0 {
+		line = truncateString(line, m.width)
+	}
+
+	style := lipgloss.NewStyle()
+	switch {
+	case selected:
+		style = m.selectedStyle()
+	case host.Active > 0:
+		style = style.Foreground(m.theme.Good)
+	default:
+		style = style.Foreground(m.theme.Muted)
+	}
+	return style.Width(m.width).Render(line)
+}
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+func TestHostsView(t *testing.T) {
+	m := NewModel(nil, Options{Accessible: true})
+	m.width, m.height, m.connected = 160, 20, true
+	m.viewMode = ViewModeConversations
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("R")})
+	if m.viewMode != ViewModeHosts {
+		t.Fatalf("Expected R to open the hosts view, got view %d", m.viewMode)
+	}
+
+	now := time.Now()
+	updated, _ := m.Update(websocket.HostsMsg{
+		{IP: "140.82.112.3", Names: []string{"github.com"}, Conversations: 3, Active: 1, BytesIn: 4500, BytesOut: 500, Bytes: 5000,
+			Services: []string{"HTTPS", "TCP/22"}, LastSeen: now.Add(-time.Minute), Geo: &models.GeoInfo{CountryCode: "US"}},
+		{IP: "203.0.113.7", Conversations: 5, Bytes: 800, Services: []string{"DNS"}, LastSeen: now},
+	})
+	m = updated.(Model)
+
+	rows := strings.Split(m.renderHosts(), "\n")
+	if !strings.Contains(rows[1], "github.com (140.82.112.3)") || !strings.Contains(rows[1], " US ") ||
+		!strings.Contains(rows[1], "1/3") || !strings.Contains(rows[1], "HTTPS, TCP/22") {
+		t.Errorf("Expected the busiest host first with its name, country and services, got %q", rows[1])
+	}
+	if !strings.HasPrefix(rows[1], ">") || !strings.Contains(rows[2], "203.0.113.7") {
+		t.Errorf("Expected the first host selected and the other below it, got %q", rows[1:3])
+	}
+
+	// Tab sorts by last seen, then by conversations
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyTab})
+	if m.hosts[0].IP != "203.0.113.7" {
+		t.Errorf("Expected the most recently seen host first, got %s", m.hosts[0].IP)
+	}
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyTab})
+	if hostOrders[m.hostsOrder] != "conversations" || m.hosts[0].IP != "203.0.113.7" {
+		t.Errorf("Expected the host with the most conversations first, got %s", m.hosts[0].IP)
+	}
+
+	// A refresh keeps the selection on the same host
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	updated, _ = m.Update(websocket.HostsMsg{
+		{IP: "198.51.100.1", Conversations: 9},
+		{IP: "203.0.113.7", Conversations: 5},
+		{IP: "140.82.112.3", Conversations: 3},
+	})
+	m = updated.(Model)
+	if m.hosts[m.hostsSelected].IP != "140.82.112.3" {
+		t.Errorf("Expected the selection to follow the host, got %s", m.hosts[m.hostsSelected].IP)
+	}
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	if !strings.Contains(m.notice, "Watching 140.82.112.3") {
+		t.Errorf("Expected w to watch the selected host, got notice %q", m.notice)
+	}
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.viewMode != ViewModeConversations {
+		t.Errorf("Expected esc to return to the conversations view, got %d", m.viewMode)
+	}
+}
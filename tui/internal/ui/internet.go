@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"net"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/models"
+)
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), also used by
+// overlay VPNs such as Tailscale; it isn't routed on the internet
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// toggleInternetOnly hides or shows traffic between local addresses, then
+// refreshes the conversations so the list reflects the change
+func (m *Model) toggleInternetOnly() tea.Cmd {
+	m.internetOnly = !m.internetOnly
+	m.applyFilter()
+	m.selectedIndex = 0
+	m.scrollOffset = 0
+	if m.internetOnly {
+		m.notice = "Showing only traffic to or from the internet"
+	} else {
+		m.notice = "Showing all traffic, including loopback and LAN"
+	}
+	if m.streaming {
+		m.setConversations(m.streamedConversations())
+	}
+	return m.requestConversations()
+}
+
+// visibleConversations drops conversations that stay on local addresses while
+// they are hidden
+func (m *Model) visibleConversations(conversations []models.Conversation) []models.Conversation {
+	if !m.internetOnly {
+		return conversations
+	}
+	visible := conversations[:0]
+	for _, conv := range conversations {
+		local, _ := conv.LocalEndpoint()
+		remote, _ := conv.RemoteEndpoint()
+		if isInternetAddress(local) || isInternetAddress(remote) {
+			visible = append(visible, conv)
+		}
+	}
+	return visible
+}
+
+// visibleGroups drops service groups with no remote address on the internet while
+// local traffic is hidden
+func (m *Model) visibleGroups(groups []models.ServiceGroup) []models.ServiceGroup {
+	if !m.internetOnly {
+		return groups
+	}
+	visible := groups[:0]
+	for _, group := range groups {
+		for _, addr := range group.RemoteAddrs {
+			if isInternetAddress(addr) {
+				visible = append(visible, group)
+				break
+			}
+		}
+	}
+	return visible
+}
+
+// crossesInternet reports whether either end of an event is an internet address.
+// ARP and neighbor discovery never leave the LAN.
+func crossesInternet(event models.NetworkEvent) bool {
+	if event.ARP != nil || event.NDP != nil {
+		return false
+	}
+	return isInternetAddress(event.SourceIP) || isInternetAddress(event.DestIP)
+}
+
+// isInternetAddress reports whether ip is routed on the public internet, as
+// opposed to loopback, private, link-local, multicast or broadcast addresses
+func isInternetAddress(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || !parsed.IsGlobalUnicast() || parsed.IsPrivate() {
+		return false
+	}
+	return !parsed.Equal(net.IPv4bcast) && !sharedAddressSpace.Contains(parsed)
+}
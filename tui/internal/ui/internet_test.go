@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/netty/tui/internal/models"
+)
+
+func TestInternetOnly(t *testing.T) {
+	m := Model{}
+	m.events = []models.NetworkEvent{
+		{SourceIP: "192.168.1.10", DestIP: "140.82.112.3"},
+		{SourceIP: "192.168.1.10", DestIP: "192.168.1.1"},
+		{SourceIP: "127.0.0.1", DestIP: "127.0.0.1"},
+		{SourceIP: "fe80::1", DestIP: "ff02::fb"},
+		{SourceIP: "192.168.1.10", DestIP: "192.168.1.1", ARP: &models.ARPInfo{Operation: "request"}},
+	}
+	m.toggleInternetOnly()
+	if len(m.filteredEvents) != 1 || m.filteredEvents[0].DestIP != "140.82.112.3" {
+		t.Fatalf("Expected only the internet event, got %+v", m.filteredEvents)
+	}
+
+	conversations := m.visibleConversations([]models.Conversation{
+		{ID: "web", LocalAddr: "192.168.1.10:50000", RemoteAddr: "[2606:4700::1111]:443"},
+		{ID: "nas", LocalAddr: "192.168.1.10:50001", RemoteAddr: "192.168.1.5:445"},
+		{ID: "tailnet", LocalAddr: "100.64.0.2:50002", RemoteAddr: "100.101.102.103:22"},
+	})
+	if len(conversations) != 1 || conversations[0].ID != "web" {
+		t.Errorf("Expected only the conversation with an internet peer, got %+v", conversations)
+	}
+
+	m.toggleInternetOnly()
+	if len(m.filteredEvents) != len(m.events) {
+		t.Errorf("Expected every event back after toggling off, got %d", len(m.filteredEvents))
+	}
+}
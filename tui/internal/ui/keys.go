@@ -0,0 +1,192 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Action is something a key press can trigger
+type Action string
+
+const (
+	ActionNone       Action = ""
+	ActionQuit       Action = "quit"
+	ActionHelp       Action = "help"
+	ActionSelect     Action = "select"
+	ActionBack       Action = "back"
+	ActionDown       Action = "down"
+	ActionUp         Action = "up"
+	ActionTop        Action = "top"
+	ActionBottom     Action = "bottom"
+	ActionPageDown   Action = "page_down"
+	ActionPageUp     Action = "page_up"
+	ActionClear      Action = "clear"
+	ActionFilter     Action = "filter"
+	ActionExport     Action = "export_report"
+	ActionSplit      Action = "toggle_split"
+	ActionBlock      Action = "block_host"
+	ActionSwitchView Action = "switch_view"
+	ActionConfirm    Action = "confirm"
+	ActionAck        Action = "acknowledge"
+	ActionResolve    Action = "resolve"
+	ActionWatch      Action = "watch_host"
+	ActionGroup      Action = "group_services"
+	ActionCompare    Action = "compare"
+	ActionRaw        Action = "raw_json"
+	ActionPcap       Action = "export_pcap"
+	ActionLatency    Action = "latency_heatmap"
+	ActionInternet   Action = "internet_only"
+	ActionMatrix     Action = "traffic_matrix"
+	ActionEncryption Action = "encryption_audit"
+	ActionCountries  Action = "countries"
+	ActionTopTalkers Action = "top_talkers"
+	ActionHosts      Action = "remote_hosts"
+	ActionDomains    Action = "domains"
+	ActionLeases     Action = "dhcp_leases"
+	ActionServices   Action = "services"
+	ActionPause      Action = "pause_capture"
+	ActionHistory    Action = "history"
+	ActionEarlier    Action = "history_earlier"
+	ActionLater      Action = "history_later"
+	ActionZoomIn     Action = "history_zoom_in"
+	ActionZoomOut    Action = "history_zoom_out"
+)
+
+// defaultBindings are the built-in keys for every action
+var defaultBindings = map[Action][]string{
+	ActionQuit:       {"q", "ctrl+c"},
+	ActionHelp:       {"?", "h"},
+	ActionSelect:     {"enter"},
+	ActionBack:       {"esc"},
+	ActionDown:       {"j", "down"},
+	ActionUp:         {"k", "up"},
+	ActionTop:        {"g"},
+	ActionBottom:     {"G"},
+	ActionPageDown:   {"ctrl+d"},
+	ActionPageUp:     {"ctrl+u"},
+	ActionClear:      {"c"},
+	ActionFilter:     {"f"},
+	ActionExport:     {"e"},
+	ActionSplit:      {"s"},
+	ActionBlock:      {"b"},
+	ActionSwitchView: {"tab"},
+	ActionConfirm:    {"y"},
+	ActionAck:        {"a"},
+	ActionResolve:    {"r"},
+	ActionWatch:      {"w"},
+	ActionGroup:      {"v"},
+	ActionCompare:    {"d"},
+	ActionRaw:        {"J"},
+	ActionPcap:       {"p"},
+	ActionLatency:    {"l"},
+	ActionInternet:   {"i"},
+	ActionMatrix:     {"m"},
+	ActionEncryption: {"t"},
+	ActionCountries:  {"C"},
+	ActionTopTalkers: {"T"},
+	ActionHosts:      {"R"},
+	ActionDomains:    {"D"},
+	ActionLeases:     {"L"},
+	ActionServices:   {"S"},
+	ActionPause:      {" "},
+	ActionHistory:    {"H"},
+	ActionEarlier:    {"["},
+	ActionLater:      {"]"},
+	ActionZoomIn:     {"+", "="},
+	ActionZoomOut:    {"-"},
+}
+
+// Keymap maps keys to actions
+type Keymap struct {
+	bindings map[Action][]string
+	actions  map[string]Action
+}
+
+// DefaultKeymap returns the built-in key bindings
+func DefaultKeymap() Keymap {
+	keymap, _ := NewKeymap(nil)
+	return keymap
+}
+
+// NewKeymap returns the built-in key bindings with overrides applied. Each
+// override replaces every key for its action, e.g. {"down": ["n", "down"]}.
+func NewKeymap(overrides map[string][]string) (Keymap, error) {
+	bindings := make(map[Action][]string, len(defaultBindings))
+	for action, keys := range defaultBindings {
+		bindings[action] = keys
+	}
+	for name, keys := range overrides {
+		action := Action(name)
+		if _, ok := defaultBindings[action]; !ok {
+			return Keymap{}, fmt.Errorf("unknown action %q (available: %s)", name, strings.Join(ActionNames(), ", "))
+		}
+		if len(keys) == 0 {
+			return Keymap{}, fmt.Errorf("no keys given for action %q", name)
+		}
+		bindings[action] = keys
+	}
+
+	actions := make(map[string]Action)
+	for action, keys := range bindings {
+		for _, key := range keys {
+			if key == "space" {
+				key = " "
+			}
+			if other, taken := actions[key]; taken {
+				return Keymap{}, fmt.Errorf("key %q is bound to both %s and %s", key, other, action)
+			}
+			actions[key] = action
+		}
+	}
+	return Keymap{bindings: bindings, actions: actions}, nil
+}
+
+// ActionNames returns the names of all remappable actions
+func ActionNames() []string {
+	names := make([]string, 0, len(defaultBindings))
+	for action := range defaultBindings {
+		names = append(names, string(action))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Action returns the action bound to a key
+func (k Keymap) Action(key string) Action {
+	return k.actions[key]
+}
+
+// Key returns the primary key for an action, for short hints
+func (k Keymap) Key(action Action) string {
+	keys := k.bindings[action]
+	if len(keys) == 0 {
+		return ""
+	}
+	return keyLabel(keys[0])
+}
+
+// Keys returns every key for an action, for the help screen
+func (k Keymap) Keys(action Action) string {
+	labels := make([]string, 0, len(k.bindings[action]))
+	for _, key := range k.bindings[action] {
+		labels = append(labels, keyLabel(key))
+	}
+	return strings.Join(labels, "/")
+}
+
+// keyLabel returns how a key is shown to the user
+func keyLabel(key string) string {
+	switch key {
+	case "up":
+		return "↑"
+	case "down":
+		return "↓"
+	case " ":
+		return "space"
+	}
+	if strings.HasPrefix(key, "ctrl+") {
+		return "Ctrl+" + strings.TrimPrefix(key, "ctrl+")
+	}
+	return key
+}
@@ -0,0 +1,28 @@
+package ui
+
+import "testing"
+
+func TestNewKeymap_Overrides(t *testing.T) {
+	keys, err := NewKeymap(map[string][]string{"down": {"n", "down"}, "export_report": {"x"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if keys.Action("n") != ActionDown || keys.Action("x") != ActionExport {
+		t.Error("Expected overridden keys to be bound")
+	}
+	if keys.Action("j") != ActionNone || keys.Action("e") != ActionNone {
+		t.Error("Expected overrides to replace the default keys")
+	}
+	if keys.Keys(ActionDown) != "n/↓" {
+		t.Errorf("Unexpected key label: %s", keys.Keys(ActionDown))
+	}
+}
+
+func TestNewKeymap_Errors(t *testing.T) {
+	if _, err := NewKeymap(map[string][]string{"fly": {"z"}}); err == nil {
+		t.Error("Expected an error for an unknown action")
+	}
+	if _, err := NewKeymap(map[string][]string{"clear": {"q"}}); err == nil {
+		t.Error("Expected an error for a key bound to two actions")
+	}
+}
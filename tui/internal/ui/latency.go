@@ -0,0 +1,284 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/netty/tui/internal/models"
+)
+
+// Heatmap geometry: one column per bucket, an hour of history at most
+const (
+	latencyBucket     = time.Minute
+	latencyMaxBuckets = 60
+	latencyWindow     = latencyBucket * latencyMaxBuckets
+	latencyLabelWidth = 24
+)
+
+// maxLatencySamples bounds the samples kept for the heatmap
+const maxLatencySamples = 20000
+
+// latencyLevel is one band of the heatmap scale
+type latencyLevel struct {
+	max    float64 // Upper bound in milliseconds
+	glyph  string
+	ascii  string // Accessible mode glyph
+	status int    // 0 good, 1 warning, 2 error
+}
+
+var latencyLevels = []latencyLevel{
+	{20, "▁", "1", 0},
+	{50, "▃", "2", 0},
+	{100, "▅", "3", 1},
+	{250, "▇", "4", 1},
+	{math.Inf(1), "█", "5", 2},
+}
+
+// latencySample is the handshake RTT of one conversation
+type latencySample struct {
+	host string
+	at   time.Time
+	rtt  float64 // Milliseconds
+}
+
+// latencyRow is one remote host's line of the heatmap
+type latencyRow struct {
+	host    string
+	cells   []float64 // Worst RTT per bucket, oldest first, 0 where there were no handshakes
+	peak    float64
+	samples int
+}
+
+// recordLatency keeps the handshake RTT of conversations not seen before and
+// drops samples that have aged out of the heatmap. Samples outlive the
+// conversations, so history survives the daemon expiring them.
+func (m *Model) recordLatency(conversations []models.Conversation, now time.Time) {
+	if m.latencySamples == nil {
+		m.latencySamples = make(map[string]latencySample)
+	}
+	for _, conv := range conversations {
+		if conv.HandshakeRTTMs <= 0 {
+			continue
+		}
+		if _, seen := m.latencySamples[conv.ID]; seen {
+			continue
+		}
+		at := conv.StartTime
+		if at.IsZero() {
+			at = conv.LastActivity
+		}
+		m.latencySamples[conv.ID] = latencySample{host: conv.RemoteName(), at: at, rtt: conv.HandshakeRTTMs}
+	}
+
+	for id, sample := range m.latencySamples {
+		if now.Sub(sample.at) >= latencyWindow {
+			delete(m.latencySamples, id)
+		}
+	}
+	if len(m.latencySamples) > maxLatencySamples {
+		ids := make([]string, 0, len(m.latencySamples))
+		for id := range m.latencySamples {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool {
+			return m.latencySamples[ids[i]].at.Before(m.latencySamples[ids[j]].at)
+		})
+		for _, id := range ids[:len(ids)-maxLatencySamples] {
+			delete(m.latencySamples, id)
+		}
+	}
+}
+
+// latencyHeatmap buckets samples per remote host, the newest bucket ending at
+// now. Hosts with the worst peak come first.
+func latencyHeatmap(samples map[string]latencySample, now time.Time, buckets int) []latencyRow {
+	byHost := make(map[string]*latencyRow)
+	for _, sample := range samples {
+		age := int(now.Sub(sample.at) / latencyBucket)
+		if age < 0 {
+			age = 0
+		}
+		if age >= buckets {
+			continue
+		}
+		row, ok := byHost[sample.host]
+		if !ok {
+			row = &latencyRow{host: sample.host, cells: make([]float64, buckets)}
+			byHost[sample.host] = row
+		}
+		col := buckets - 1 - age
+		row.cells[col] = math.Max(row.cells[col], sample.rtt)
+		row.peak = math.Max(row.peak, sample.rtt)
+		row.samples++
+	}
+
+	rows := make([]latencyRow, 0, len(byHost))
+	for _, row := range byHost {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].peak != rows[j].peak {
+			return rows[i].peak > rows[j].peak
+		}
+		return rows[i].host < rows[j].host
+	})
+	return rows
+}
+
+// levelFor returns the heatmap band for an RTT in milliseconds
+func levelFor(rtt float64) latencyLevel {
+	for _, level := range latencyLevels {
+		if rtt < level.max {
+			return level
+		}
+	}
+	return latencyLevels[len(latencyLevels)-1]
+}
+
+// openLatencyHeatmap switches to the heatmap and refreshes the conversations feeding it
+func (m *Model) openLatencyHeatmap() tea.Cmd {
+	m.viewMode = ViewModeLatency
+	m.latencyScroll = 0
+	return m.requestConversations()
+}
+
+// handleLatencyKey handles key presses in the heatmap view
+func (m *Model) handleLatencyKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.keys.Action(msg.String()) {
+	case ActionBack, ActionQuit, ActionLatency:
+		m.viewMode = ViewModeConversations
+	case ActionDown:
+		if m.latencyScroll < len(latencyHeatmap(m.latencySamples, time.Now(), m.latencyBuckets()))-1 {
+			m.latencyScroll++
+		}
+	case ActionUp:
+		if m.latencyScroll > 0 {
+			m.latencyScroll--
+		}
+	case ActionHelp:
+		m.showHelp = !m.showHelp
+	}
+	return m, nil
+}
+
+// latencyBuckets returns how many buckets fit beside the host labels
+func (m *Model) latencyBuckets() int {
+	buckets := m.width - latencyLabelWidth - 14
+	if buckets > latencyMaxBuckets {
+		buckets = latencyMaxBuckets
+	}
+	if buckets < 10 {
+		buckets = 10
+	}
+	return buckets
+}
+
+// renderLatencyHeatmap renders handshake RTT per remote host over time
+func (m *Model) renderLatencyHeatmap() string {
+	viewHeight := m.viewportHeight()
+	buckets := m.latencyBuckets()
+	rows := latencyHeatmap(m.latencySamples, time.Now(), buckets)
+
+	if len(rows) == 0 {
+		return lipgloss.NewStyle().
+			Foreground(m.theme.Muted).
+			Align(lipgloss.Center).
+			Width(m.width).
+			Height(viewHeight).
+			Render("No handshake latency yet, it is measured on TCP connections whose handshake was captured")
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	axis := fmt.Sprintf("-%dm", buckets)
+	axis += strings.Repeat(" ", buckets-len(axis)-3) + "now"
+	lines := []string{
+		titleStyle.Render(fmt.Sprintf("%-*s %s  %s", latencyLabelWidth, "Remote host", axis, "peak")),
+	}
+
+	empty := "·"
+	if m.accessible {
+		empty = "."
+	}
+	// Title and legend take two lines
+	visible := viewHeight - 2
+	if visible < 1 {
+		visible = 1
+	}
+	start := m.latencyScroll
+	if start > len(rows)-1 {
+		start = len(rows) - 1
+	}
+	for _, row := range rows[start:] {
+		if len(lines) > visible {
+			break
+		}
+		var cells strings.Builder
+		for _, rtt := range row.cells {
+			if rtt == 0 {
+				cells.WriteString(m.fg(m.theme.Faint).Render(empty))
+				continue
+			}
+			level := levelFor(rtt)
+			glyph := level.glyph
+			if m.accessible {
+				glyph = level.ascii
+			}
+			cells.WriteString(m.latencyStyle(level).Render(glyph))
+		}
+		label := fmt.Sprintf("%-*s", latencyLabelWidth, truncateString(row.host, latencyLabelWidth))
+		peak := m.latencyStyle(levelFor(row.peak)).Render(formatLatency(row.peak))
+		lines = append(lines, fmt.Sprintf("%s %s  %s", label, cells.String(), peak))
+	}
+
+	for len(lines) < viewHeight-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, m.fg(m.theme.Muted).Render(truncateString(m.latencyLegend(empty), m.width)))
+	return strings.Join(lines, "\n")
+}
+
+// latencyLegend explains the heatmap bands
+func (m *Model) latencyLegend(empty string) string {
+	parts := []string{empty + " none"}
+	low := 0.0
+	for _, level := range latencyLevels {
+		glyph := level.glyph
+		if m.accessible {
+			glyph = level.ascii
+		}
+		if math.IsInf(level.max, 1) {
+			atLeast := "≥"
+			if m.accessible {
+				atLeast = ">="
+			}
+			parts = append(parts, fmt.Sprintf("%s %s%.0fms", glyph, atLeast, low))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s <%.0fms", glyph, level.max))
+		}
+		low = level.max
+	}
+	return " Worst handshake RTT per minute: " + strings.Join(parts, "  ")
+}
+
+func (m *Model) latencyStyle(level latencyLevel) lipgloss.Style {
+	switch level.status {
+	case 2:
+		return m.fg(m.theme.Error)
+	case 1:
+		return m.fg(m.theme.Warning)
+	}
+	return m.fg(m.theme.Good)
+}
+
+// formatLatency formats an RTT in milliseconds
+func formatLatency(rtt float64) string {
+	if rtt >= 1000 {
+		return fmt.Sprintf("%.1fs", rtt/1000)
+	}
+	return fmt.Sprintf("%.0fms", rtt)
+}
@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/netty/tui/internal/models"
+)
+
+func TestLatencyHeatmap(t *testing.T) {
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	m := Model{}
+	m.recordLatency([]models.Conversation{
+		{ID: "a", RemoteAddr: "140.82.112.3:443", ServerName: "github.com", StartTime: now.Add(-30 * time.Second), HandshakeRTTMs: 20},
+		{ID: "b", RemoteAddr: "140.82.112.4:443", ServerName: "github.com", StartTime: now.Add(-40 * time.Second), HandshakeRTTMs: 350},
+		{ID: "c", RemoteAddr: "203.0.113.9:22", StartTime: now.Add(-5 * time.Minute), HandshakeRTTMs: 12},
+		{ID: "udp", RemoteAddr: "1.1.1.1:53", StartTime: now},
+		{ID: "old", RemoteAddr: "203.0.113.9:22", StartTime: now.Add(-2 * time.Hour), HandshakeRTTMs: 900},
+	}, now)
+
+	if len(m.latencySamples) != 3 {
+		t.Fatalf("Expected samples only for recent completed handshakes, got %+v", m.latencySamples)
+	}
+
+	// A later update with a different RTT for a known conversation doesn't add a sample
+	m.recordLatency([]models.Conversation{{ID: "a", RemoteAddr: "140.82.112.3:443", StartTime: now, HandshakeRTTMs: 999}}, now)
+	if sample := m.latencySamples["a"]; sample.rtt != 20 {
+		t.Errorf("Expected the first sample to be kept, got %+v", sample)
+	}
+
+	rows := latencyHeatmap(m.latencySamples, now, 10)
+	if len(rows) != 2 {
+		t.Fatalf("Expected two hosts, got %+v", rows)
+	}
+	github := rows[0]
+	if github.host != "github.com" || github.peak != 350 || github.samples != 2 {
+		t.Errorf("Expected github.com first with its worst handshake, got %+v", github)
+	}
+	if github.cells[9] != 350 {
+		t.Errorf("Expected the newest bucket to hold the worst RTT, got %v", github.cells)
+	}
+	if ssh := rows[1]; ssh.host != "203.0.113.9" || ssh.cells[4] != 12 {
+		t.Errorf("Expected the IP host five buckets back, got %+v", ssh)
+	}
+}
+
+func TestLevelFor(t *testing.T) {
+	if level := levelFor(5); level.status != 0 {
+		t.Errorf("Expected a fast handshake to be good, got %+v", level)
+	}
+	if level := levelFor(2000); level.status != 2 || level.ascii != "5" {
+		t.Errorf("Expected a slow handshake in the top band, got %+v", level)
+	}
+}
@@ -0,0 +1,160 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/netty/tui/internal/models"
+)
+
+// openLeases switches to the devices the daemon has seen using DHCP
+func (m *Model) openLeases() tea.Cmd {
+	m.viewMode = ViewModeLeases
+	m.leasesSelected = 0
+	m.lastConvUpdate = time.Now()
+	return m.requestLeases()
+}
+
+// requestLeases asks the daemon for its DHCP lease table
+func (m *Model) requestLeases() tea.Cmd {
+	return func() tea.Msg {
+		if m.wsClient != nil {
+			m.wsClient.RequestLeases()
+		}
+		return nil
+	}
+}
+
+// setLeases stores the daemon's leases, most recently seen first, keeping
+// the selection on the same device
+func (m *Model) setLeases(leases []models.Lease) {
+	var selected string
+	if m.leasesSelected < len(m.leases) {
+		selected = m.leases[m.leasesSelected].MAC
+	}
+	m.leases = leases
+	m.leasesSelected = 0
+	for i, lease := range m.leases {
+		if lease.MAC == selected {
+			m.leasesSelected = i
+		}
+	}
+}
+
+// handleLeasesKey handles key presses in the leases view
+func (m *Model) handleLeasesKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.keys.Action(msg.String()) {
+	case ActionBack, ActionQuit, ActionLeases:
+		m.viewMode = ViewModeConversations
+		return m, m.requestConversations()
+	case ActionWatch:
+		// Watch or stop watching the selected device's address
+		if m.leasesSelected < len(m.leases) && m.leases[m.leasesSelected].IP != "" {
+			return m, m.toggleWatch(m.leases[m.leasesSelected].IP)
+		}
+	case ActionDown:
+		if m.leasesSelected < len(m.leases)-1 {
+			m.leasesSelected++
+		}
+	case ActionUp:
+		if m.leasesSelected > 0 {
+			m.leasesSelected--
+		}
+	case ActionTop:
+		m.leasesSelected = 0
+	case ActionBottom:
+		if len(m.leases) > 0 {
+			m.leasesSelected = len(m.leases) - 1
+		}
+	case ActionHelp:
+		m.showHelp = !m.showHelp
+	}
+	return m, nil
+}
+
+// renderLeases renders a row per DHCP client with its address and names
+func (m *Model) renderLeases() string {
+	viewHeight := m.viewportHeight()
+
+	if len(m.leases) == 0 {
+		message := "No DHCP traffic seen yet"
+		if !m.connected {
+			message = "Not connected to daemon"
+		}
+		return lipgloss.NewStyle().
+			Foreground(m.theme.Muted).
+			Align(lipgloss.Center).
+			Width(m.width).
+			Height(viewHeight).
+			Render(message)
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	header := m.headerPrefix("ACT") + fmt.Sprintf("%-15s %-24s %-17s %-11s %-12s %-9s %s",
+		"IP", "Hostname", "MAC", "State", "Expires", "Last seen", "Vendor")
+	lines := []string{titleStyle.Render(truncateString(header, m.width))}
+
+	// Keep the selected device in view below the header
+	visible := viewHeight - 1
+	if visible < 1 {
+		visible = 1
+	}
+	start := 0
+	if m.leasesSelected >= visible {
+		start = m.leasesSelected - visible + 1
+	}
+	now := time.Now()
+	for i := start; i < len(m.leases) && i-start < visible; i++ {
+		lines = append(lines, m.renderLeaseLine(m.leases[i], i == m.leasesSelected, now))
+	}
+
+	for len(lines) < viewHeight {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderLeaseLine renders a single DHCP client's row
+func (m *Model) renderLeaseLine(lease models.Lease, selected bool, now time.Time) string {
+	marker := "-"
+	if lease.Bound() {
+		marker = "+"
+	}
+	expires := "-"
+	if lease.Expires != nil {
+		if lease.Expires.After(now) {
+			expires = "in " + formatDuration(lease.Expires.Sub(now).Truncate(time.Second))
+		} else {
+			expires = "expired"
+		}
+	}
+	line := m.rowPrefix(selected, lease.IP, marker) + fmt.Sprintf("%-15s %-24s %-17s %-11s %-12s %-9s %s",
+		orDash(lease.IP),
+		truncateString(orDash(lease.Hostname), 24),
+		lease.MAC,
+		lease.State,
+		expires,
+		formatDuration(now.Sub(lease.LastSeen).Truncate(time.Second))+" ago",
+		lease.VendorClass,
+	)
+	if lease.IP != "" && m.isWatched(lease.IP) {
+		line += " WATCH"
+	}
+	if m.width > 0 {
+		line = truncateString(line, m.width)
+	}
+
+	style := lipgloss.NewStyle()
+	switch {
+	case selected:
+		style = m.selectedStyle()
+	case lease.Bound():
+		style = style.Foreground(m.theme.Good)
+	default:
+		style = style.Foreground(m.theme.Muted)
+	}
+	return style.Width(m.width).Render(line)
+}
@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/websocket"
+)
+
+func TestLeasesView(t *testing.T) {
+	m := NewModel(nil, Options{Accessible: true})
+	m.width, m.height, m.connected = 160, 20, true
+	m.viewMode = ViewModeConversations
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("L")})
+	if m.viewMode != ViewModeLeases {
+		t.Fatalf("Expected L to open the leases view, got view %d", m.viewMode)
+	}
+	if !strings.Contains(m.renderLeases(), "No DHCP traffic seen yet") {
+		t.Error("Expected a message before any lease arrives")
+	}
+
+	now := time.Now()
+	expires := now.Add(2 * time.Hour)
+	updated, _ := m.Update(websocket.LeasesMsg{
+		{MAC: "aa:bb:cc:00:11:22", IP: "192.168.1.50", Hostname: "kitchen-ipad", VendorClass: "android-dhcp-14",
+			State: "bound", Expires: &expires, LastSeen: now.Add(-time.Minute)},
+		{MAC: "aa:bb:cc:00:11:33", State: "discovering", LastSeen: now.Add(-time.Hour)},
+	})
+	m = updated.(Model)
+
+	rows := strings.Split(m.renderLeases(), "\n")
+	if !strings.Contains(rows[1], "192.168.1.50") || !strings.Contains(rows[1], "kitchen-ipad") ||
+		!strings.Contains(rows[1], "bound") || !strings.Contains(rows[1], "in 2.0h") || !strings.Contains(rows[1], "android-dhcp-14") {
+		t.Errorf("Expected the bound lease with its name, expiry and vendor, got %q", rows[1])
+	}
+	if !strings.HasPrefix(rows[1], ">") || !strings.Contains(rows[2], "aa:bb:cc:00:11:33") || !strings.Contains(rows[2], "discovering") {
+		t.Errorf("Expected the first lease selected and the discovering client below it, got %q", rows[1:3])
+	}
+	if stats := m.renderStats(); !strings.Contains(stats, "Devices: 2 | Bound: 1") {
+		t.Errorf("Expected the lease counts in the stats, got %q", stats)
+	}
+
+	// A refresh keeps the selection on the same device
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	updated, _ = m.Update(websocket.LeasesMsg{
+		{MAC: "aa:bb:cc:00:11:44", IP: "192.168.1.52", State: "bound", LastSeen: now},
+		{MAC: "aa:bb:cc:00:11:33", State: "offered", IP: "192.168.1.51", LastSeen: now},
+	})
+	m = updated.(Model)
+	if m.leases[m.leasesSelected].MAC != "aa:bb:cc:00:11:33" {
+		t.Errorf("Expected the selection to follow the device, got %s", m.leases[m.leasesSelected].MAC)
+	}
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.viewMode != ViewModeConversations {
+		t.Errorf("Expected esc to return to the conversations view, got %d", m.viewMode)
+	}
+}
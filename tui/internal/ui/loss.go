@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/netty/tui/internal/models"
+)
+
+// handleLossReport keeps the daemon's latest loss report along with the events
+// this UI dropped itself by then
+func (m *Model) handleLossReport(report models.LossReport) {
+	m.loss = &report
+	m.lossLocal = 0
+	if m.wsClient != nil {
+		m.lossLocal = int64(m.wsClient.DroppedEvents())
+	}
+}
+
+// completeness returns the percentage of captured events that reached the
+// view, counting every drop from the kernel to the UI, and false before the
+// daemon has reported any
+func (m *Model) completeness() (float64, bool) {
+	if m.loss == nil {
+		return 0, false
+	}
+	shown := m.loss.Events - m.lossLocal
+	if shown < 0 {
+		shown = 0
+	}
+	total := m.loss.Events + m.loss.Lost()
+	if total == 0 {
+		return 100, true
+	}
+	return 100 * float64(shown) / float64(total), true
+}
+
+// lossStatus describes how complete the events shown are for the stats line,
+// flagging a sample when anything was dropped and calling out kernel drops,
+// which mean the host can't keep up with the traffic
+func (m *Model) lossStatus() string {
+	percent, ok := m.completeness()
+	if !ok {
+		return ""
+	}
+	if percent >= 99.95 {
+		return " | Complete: 100%"
+	}
+	status := fmt.Sprintf(" | SAMPLED: %.1f%% of events", percent)
+	if m.loss.KernelDropped > 0 {
+		status += fmt.Sprintf(" | KERNEL DROPS: %d", m.loss.KernelDropped)
+	}
+	return status
+}
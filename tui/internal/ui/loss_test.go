@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+func TestLossStatus(t *testing.T) {
+	m := NewModel(nil, Options{})
+	m.width = 200
+	if status := m.lossStatus(); status != "" {
+		t.Errorf("Expected no completeness before a loss report, got %q", status)
+	}
+
+	updated, _ := m.Update(websocket.LossReportMsg{Events: 1000})
+	m = updated.(Model)
+	if status := m.lossStatus(); status != " | Complete: 100%" {
+		t.Errorf("Expected complete data without drops, got %q", status)
+	}
+
+	// Drops anywhere on the way, including by the UI itself, make it a sample
+	updated, _ = m.Update(websocket.LossReportMsg{Events: 950, KernelDropped: 20, CaptureDropped: 30})
+	m = updated.(Model)
+	m.lossLocal = 50
+	if percent, _ := m.completeness(); percent != 90 {
+		t.Errorf("Expected 90%% of events shown, got %v", percent)
+	}
+	if stats := m.renderStats(); !strings.Contains(stats, "SAMPLED: 90.0% of events") {
+		t.Errorf("Expected the stats line to flag sampling, got %q", stats)
+	}
+	if status := m.lossStatus(); !strings.Contains(status, "KERNEL DROPS: 20") {
+		t.Errorf("Expected the kernel's drops called out, got %q", status)
+	}
+
+	m.handleLossReport(models.LossReport{})
+	if percent, ok := m.completeness(); !ok || percent != 100 {
+		t.Errorf("Expected an empty report to be complete, got %v", percent)
+	}
+}
@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Matrix geometry and how much of it is requested from the daemon
+const (
+	matrixLabelWidth = 18
+	matrixCellWidth  = 10
+	matrixLocals     = 50
+	matrixRemotes    = 12
+)
+
+// openTrafficMatrix switches to the local host by remote destination matrix
+func (m *Model) openTrafficMatrix() tea.Cmd {
+	m.viewMode = ViewModeMatrix
+	m.matrixScroll = 0
+	m.lastConvUpdate = time.Now()
+	return m.requestTrafficMatrix()
+}
+
+// requestTrafficMatrix asks the daemon for the traffic matrix
+func (m *Model) requestTrafficMatrix() tea.Cmd {
+	return func() tea.Msg {
+		if m.wsClient != nil {
+			m.wsClient.RequestTrafficMatrix(matrixLocals, matrixRemotes)
+		}
+		return nil
+	}
+}
+
+// handleMatrixKey handles key presses in the traffic matrix view
+func (m *Model) handleMatrixKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.keys.Action(msg.String()) {
+	case ActionBack, ActionQuit, ActionMatrix:
+		m.viewMode = ViewModeConversations
+		return m, m.requestConversations()
+	case ActionDown:
+		if m.matrix != nil && m.matrixScroll < len(m.matrix.Locals)-1 {
+			m.matrixScroll++
+		}
+	case ActionUp:
+		if m.matrixScroll > 0 {
+			m.matrixScroll--
+		}
+	case ActionHelp:
+		m.showHelp = !m.showHelp
+	}
+	return m, nil
+}
+
+// matrixColumns returns how many remote columns fit beside the host labels
+// and the "other" column
+func (m *Model) matrixColumns() int {
+	if m.matrix == nil {
+		return 0
+	}
+	columns := (m.width - matrixLabelWidth - 2*(matrixCellWidth+1)) / (matrixCellWidth + 1)
+	if columns < 1 {
+		columns = 1
+	}
+	if columns > len(m.matrix.Remotes) {
+		columns = len(m.matrix.Remotes)
+	}
+	return columns
+}
+
+// renderTrafficMatrix renders the bytes between local hosts and remote destinations
+func (m *Model) renderTrafficMatrix() string {
+	viewHeight := m.viewportHeight()
+
+	if m.matrix == nil || len(m.matrix.Locals) == 0 {
+		message := "No conversations to build the traffic matrix from yet"
+		if !m.connected {
+			message = "Not connected to daemon"
+		}
+		return lipgloss.NewStyle().
+			Foreground(m.theme.Muted).
+			Align(lipgloss.Center).
+			Width(m.width).
+			Height(viewHeight).
+			Render(message)
+	}
+
+	matrix := m.matrix
+	columns := m.matrixColumns()
+	var peak int64
+	for _, row := range matrix.Bytes {
+		for _, bytes := range row {
+			if bytes > peak {
+				peak = bytes
+			}
+		}
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	header := fmt.Sprintf("%-*s", matrixLabelWidth, "Local \\ Remote")
+	for _, remote := range matrix.Remotes[:columns] {
+		header += fmt.Sprintf(" %*s", matrixCellWidth, truncateString(remote, matrixCellWidth))
+	}
+	header += fmt.Sprintf(" %*s %*s", matrixCellWidth, "other", matrixCellWidth, "total")
+	lines := []string{titleStyle.Render(truncateString(header, m.width))}
+
+	empty := "·"
+	if m.accessible {
+		empty = "."
+	}
+	// Header and legend take two lines
+	visible := viewHeight - 2
+	if visible < 1 {
+		visible = 1
+	}
+	start := m.matrixScroll
+	if start > len(matrix.Locals)-1 {
+		start = len(matrix.Locals) - 1
+	}
+	for i := start; i < len(matrix.Locals) && len(lines) <= visible; i++ {
+		var cells strings.Builder
+		other, total := matrix.Other[i], matrix.Other[i]
+		for j, bytes := range matrix.Bytes[i] {
+			total += bytes
+			if j >= columns {
+				// Columns that don't fit count as other
+				other += bytes
+				continue
+			}
+			cell := fmt.Sprintf(" %*s", matrixCellWidth, empty)
+			style := m.fg(m.theme.Faint)
+			if bytes > 0 {
+				cell = fmt.Sprintf(" %*s", matrixCellWidth, formatBytes(int(bytes)))
+				style = m.fg(m.theme.Text)
+				if bytes*4 >= peak {
+					style = m.fg(m.theme.Accent)
+				}
+			}
+			cells.WriteString(style.Render(cell))
+		}
+		label := fmt.Sprintf("%-*s", matrixLabelWidth, truncateString(matrix.Locals[i], matrixLabelWidth))
+		lines = append(lines, fmt.Sprintf("%s%s %*s %*s", label, cells.String(),
+			matrixCellWidth, formatBytes(int(other)), matrixCellWidth, formatBytes(int(total))))
+	}
+
+	for len(lines) < viewHeight-1 {
+		lines = append(lines, "")
+	}
+	legend := " Bytes in both directions, busiest first"
+	if hidden := len(matrix.Remotes) - columns; hidden > 0 {
+		legend += fmt.Sprintf("; %d more remotes in other", hidden)
+	}
+	lines = append(lines, m.fg(m.theme.Muted).Render(truncateString(legend, m.width)))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/websocket"
+)
+
+func TestTrafficMatrixView(t *testing.T) {
+	m := NewModel(nil, Options{Accessible: true})
+	m.width, m.height, m.connected = 70, 20, true
+	m.viewMode = ViewModeConversations
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("m")})
+	if m.viewMode != ViewModeMatrix {
+		t.Fatalf("Expected m to open the matrix, got view %d", m.viewMode)
+	}
+
+	updated, _ := m.Update(websocket.TrafficMatrixMsg{
+		Locals:  []string{"192.168.1.10", "192.168.1.20"},
+		Remotes: []string{"github.com", "203.0.113.7", "198.51.100.1"},
+		Bytes:   [][]int64{{2048, 100, 0}, {300, 0, 50}},
+		Other:   []int64{10, 0},
+	})
+	m = updated.(Model)
+	if columns := m.matrixColumns(); columns != 2 {
+		t.Fatalf("Expected 2 remote columns to fit in 70 columns, got %d", columns)
+	}
+	view := m.renderTrafficMatrix()
+	rows := strings.Split(view, "\n")
+	if !strings.Contains(rows[0], "github.com") || strings.Contains(rows[0], "198.51.100.1") {
+		t.Errorf("Expected only the columns that fit in the header, got %q", rows[0])
+	}
+	// The remote that didn't fit counts as other, the total covers everything
+	if !strings.Contains(rows[2], "192.168.1.20") || !strings.Contains(rows[2], "50 B") || !strings.Contains(rows[2], "350 B") {
+		t.Errorf("Expected the hidden column folded into other, got %q", rows[2])
+	}
+	if !strings.Contains(view, "1 more remotes in other") {
+		t.Errorf("Expected the legend to mention the hidden remote, got:\n%s", view)
+	}
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.viewMode != ViewModeConversations {
+		t.Errorf("Expected esc to return to the conversations view, got %d", m.viewMode)
+	}
+}
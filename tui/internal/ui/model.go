@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -8,7 +9,9 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/netty/tui/internal/filter"
 	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/report"
 	"github.com/netty/tui/internal/websocket"
 )
 
@@ -27,12 +30,89 @@ type Model struct {
 	connected        bool
 	connectionError  string
 	connectionStatus string
-	filter           Filter
+	filter           *filter.Filter // Display filter for the packet list, nil shows every packet
+	filterPrompt     *filterPrompt
 	stats            Stats
 	showHelp         bool
 	selectedIndex    int
 	viewMode         ViewMode
 	lastConvUpdate   time.Time
+	firewallPrompt   *models.FirewallRules
+	firewallResult   bool
+	theme            Theme
+	accessible       bool
+	nextSeq          uint64
+	splitLayout      bool
+	blocks           []models.Block
+	alerts           []models.Alert
+	watched          map[string]models.WatchProfile
+	groupServices    bool
+	serviceGroups    []models.ServiceGroup
+	processes        []models.ProcessStats
+	matrix           *models.TrafficMatrix
+	matrixScroll     int
+	encryption       *models.EncryptionReport
+	encryptionScroll int
+	countries        []models.CountryStats
+	countriesScroll  int
+	topTalkers       *models.TopTalkers // Latest answer for the grouping and window shown
+	topBy            int                // Index into topGroupings
+	topWindow        int                // Index into topWindows
+	topScroll        int
+	hosts            []models.Host
+	hostsSelected    int
+	hostsOrder       int // Index into hostOrders
+	domains          *models.DomainReport // Latest answer for the day and grouping shown
+	domainsDay       string               // Day asked for: "today", YYYY-MM-DD or "" since start
+	domainsGroup     bool                 // Subdomains grouped under their registered domain
+	domainsScroll    int
+	leases           []models.Lease
+	leasesSelected   int
+	services         []models.DiscoveredDevice
+	servicesSelected int
+	history          *models.History // Stored window from the daemon, nil until it answers
+	historyWindow    historyWindow
+	historyScroll    int
+	loss             *models.LossReport // Latest loss report from the daemon
+	lossLocal        int64              // Events the UI dropped itself when it arrived
+	compareMark      string // ID of the conversation marked for comparison
+	comparePair      [2]models.Conversation
+	convDetail       models.Conversation // Shown in the conversation detail view
+	convDetailScroll int
+	convDetailPackets []models.NetworkEvent // The daemon's history for it, nil until it answers
+	rawJSON          bool // Show the packet detail view as the daemon's raw JSON
+	rawScroll        int
+	hexScroll        int // First payload dump line shown in the packet detail view
+	latencySamples   map[string]latencySample // Handshake RTTs by conversation ID
+	justClosed       map[string]closure       // Conversations the daemon reported closed, by ID
+	streaming        bool                            // The daemon pushes conversation changes, no need to poll
+	streamSeq        uint64                          // Sequence number of the latest snapshot or delta
+	streamed         map[string]models.Conversation // Every streamed conversation, before the internet filter
+	replayed         int                             // Events replayed from the daemon's buffer so far
+	latencyScroll    int
+	daemonInfo       *models.DaemonInfo   // From the daemon's hello message
+	daemonHealth     *models.DaemonHealth // Latest /health report
+	internetOnly     bool // Hide traffic between local addresses
+	captureState     models.CaptureState // Whether the daemon's capture is paused
+	healthChecked    time.Time
+	throughput       *models.Throughput // Daemon-wide bytes per second, nil until it reports
+	throughputChecked time.Time
+	startTime        time.Time
+	notice           string
+	reportDir        string
+	reportFormat     report.Format
+	keys             Keymap
+}
+
+// Options configures optional TUI behavior
+type Options struct {
+	Theme        Theme
+	Accessible   bool          // Screen-reader-friendly rendering: no box drawing, textual markers
+	InternetOnly bool          // Start with traffic between local addresses hidden
+	Filter       *filter.Filter // Display filter applied from the start
+	ReportDir    string        // Directory session reports are written to
+	ReportFormat report.Format // Session report format (md or html)
+	Keys         Keymap        // Key bindings, DefaultKeymap() when unset
 }
 
 type ViewMode int
@@ -41,14 +121,22 @@ const (
 	ViewModePackets ViewMode = iota
 	ViewModeConversations
 	ViewModePacketDetail
+	ViewModeAlerts
+	ViewModeCompare
+	ViewModeLatency
+	ViewModeProcesses
+	ViewModeMatrix
+	ViewModeEncryption
+	ViewModeConversationDetail
+	ViewModeHistory
+	ViewModeCountries
+	ViewModeTop
+	ViewModeHosts
+	ViewModeDomains
+	ViewModeLeases
+	ViewModeServices
 )
 
-type Filter struct {
-	Protocol string
-	IP       string
-	Port     string
-}
-
 type Stats struct {
 	TotalPackets   int
 	TotalBytes     int
@@ -56,7 +144,11 @@ type Stats struct {
 	LastUpdate     time.Time
 }
 
-func NewModel(wsClient *websocket.Client) Model {
+func NewModel(wsClient *websocket.Client, opts Options) Model {
+	if opts.Theme.Name == "" {
+		opts.Theme = themes["default"]
+	}
+	
 	m := Model{
 		wsClient:         wsClient,
 		events:           make([]models.NetworkEvent, 0, maxEvents),
@@ -66,8 +158,28 @@ func NewModel(wsClient *websocket.Client) Model {
 			ProtocolCounts: make(map[string]int),
 			LastUpdate:     time.Now(),
 		},
-		viewMode: ViewModePackets,
+		viewMode:     ViewModePackets,
+		theme:        opts.Theme,
+		accessible:   opts.Accessible,
+		internetOnly: opts.InternetOnly,
+		filter:       opts.Filter,
+		splitLayout:  true,
+		startTime:    time.Now(),
+		reportDir:    opts.ReportDir,
+		reportFormat: opts.ReportFormat,
+		keys:         opts.Keys,
+		watched:      make(map[string]models.WatchProfile),
 	}
+	if m.keys.actions == nil {
+		m.keys = DefaultKeymap()
+	}
+	if m.reportDir == "" {
+		m.reportDir = "."
+	}
+	if m.reportFormat == "" {
+		m.reportFormat = report.FormatMarkdown
+	}
+	
 	// Initialize filtered events
 	m.applyFilter()
 	return m
@@ -106,6 +218,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, tickCmd())
 		// Always wait for events (including connection status updates)
 		cmds = append(cmds, m.wsClient.WaitForEvent())
+		if m.connected && time.Since(m.healthChecked) >= healthInterval {
+			cmds = append(cmds, m.checkHealth())
+		}
+		if m.connected && time.Since(m.throughputChecked) >= throughputInterval {
+			cmds = append(cmds, m.requestThroughput())
+		}
 		return m, tea.Batch(cmds...)
 	
 	case reconnectMsg:
@@ -117,11 +235,25 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.Connected {
 			m.connectionStatus = "Connected"
 			m.connectionError = ""
+			// The daemon may have been replaced, forget what the last one reported
+			m.daemonInfo = nil
+			m.daemonHealth = nil
+			m.loss = nil
+			m.throughput = nil
+			m.captureState = models.CaptureState{}
+			m.streaming, m.streamed = false, nil
 			// Request initial conversation data
 			if m.viewMode == ViewModeConversations {
-				return m, m.requestConversations()
+				return m, tea.Batch(m.requestConversations(), m.requestAlerts(), m.requestWatchedHosts(), m.requestCaptureState(), m.checkHealth())
 			}
-			return m, nil
+			if m.viewMode == ViewModeProcesses {
+				return m, tea.Batch(m.requestProcesses(), m.requestAlerts(), m.requestWatchedHosts(), m.requestCaptureState(), m.checkHealth())
+			}
+			return m, tea.Batch(m.requestAlerts(), m.requestWatchedHosts(), m.requestCaptureState(), m.checkHealth())
+		} else if errors.Is(msg.Error, websocket.ErrReplayFinished) {
+			// Nothing to reconnect to, what was played stays on screen
+			m.connectionStatus = "Replay finished"
+			m.connectionError = ""
 		} else if msg.Error != nil {
 			m.connectionError = msg.Error.Error()
 			if strings.Contains(msg.Error.Error(), "connection lost") {
@@ -144,17 +276,202 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.updateStats(event)
 		m.applyFilter()
 		// Periodically request conversation updates
-		if time.Since(m.lastConvUpdate) > 2*time.Second && m.viewMode == ViewModeConversations {
+		if time.Since(m.lastConvUpdate) > 2*time.Second && m.viewMode == ViewModeConversationDetail {
+			m.lastConvUpdate = time.Now()
+			return m, tea.Batch(m.requestConversations(), m.requestConversationPackets())
+		}
+		if time.Since(m.lastConvUpdate) > 2*time.Second && (m.viewMode == ViewModeConversations || m.viewMode == ViewModeLatency) {
 			m.lastConvUpdate = time.Now()
 			return m, m.requestConversations()
 		}
+		if time.Since(m.lastConvUpdate) > 2*time.Second && m.viewMode == ViewModeProcesses {
+			m.lastConvUpdate = time.Now()
+			return m, m.requestProcesses()
+		}
+		if time.Since(m.lastConvUpdate) > 2*time.Second && m.viewMode == ViewModeMatrix {
+			m.lastConvUpdate = time.Now()
+			return m, m.requestTrafficMatrix()
+		}
+		if time.Since(m.lastConvUpdate) > 2*time.Second && m.viewMode == ViewModeEncryption {
+			m.lastConvUpdate = time.Now()
+			return m, m.requestEncryptionStats()
+		}
+		if time.Since(m.lastConvUpdate) > 2*time.Second && m.viewMode == ViewModeCountries {
+			m.lastConvUpdate = time.Now()
+			return m, m.requestCountries()
+		}
+		if time.Since(m.lastConvUpdate) > 2*time.Second && m.viewMode == ViewModeTop {
+			m.lastConvUpdate = time.Now()
+			return m, m.requestTopTalkers()
+		}
+		if time.Since(m.lastConvUpdate) > 2*time.Second && m.viewMode == ViewModeHosts {
+			m.lastConvUpdate = time.Now()
+			return m, m.requestHosts()
+		}
+		if time.Since(m.lastConvUpdate) > 2*time.Second && m.viewMode == ViewModeDomains {
+			m.lastConvUpdate = time.Now()
+			return m, m.requestDomains()
+		}
+		if time.Since(m.lastConvUpdate) > 2*time.Second && m.viewMode == ViewModeLeases {
+			m.lastConvUpdate = time.Now()
+			return m, m.requestLeases()
+		}
+		if time.Since(m.lastConvUpdate) > 2*time.Second && m.viewMode == ViewModeServices {
+			m.lastConvUpdate = time.Now()
+			return m, m.requestServices()
+		}
 		return m, nil
 	
 	case websocket.ConversationsMsg:
-		m.conversations = []models.Conversation(msg)
-		// Sort conversations by last activity (most recent first)
-		sort.Slice(m.conversations, func(i, j int) bool {
-			return m.conversations[i].LastActivity.After(m.conversations[j].LastActivity)
+		if m.streaming {
+			// An answer to a poll from before the stream started
+			return m, nil
+		}
+		m.setConversations([]models.Conversation(msg))
+		return m, nil
+	
+	case websocket.RecentEventsMsg:
+		m.handleRecentEvents(msg)
+		return m, nil
+	
+	case websocket.ConversationSnapshotMsg:
+		m.handleConversationSnapshot(msg)
+		return m, nil
+	
+	case websocket.ConversationDeltaMsg:
+		return m, m.handleConversationDelta(msg)
+	
+	case websocket.ConversationStateMsg:
+		m.handleStateChange(msg, time.Now())
+		return m, nil
+	
+	case websocket.DaemonInfoMsg:
+		info := models.DaemonInfo(msg)
+		m.daemonInfo = &info
+		if info.Has("conversation_stream") && !m.streaming {
+			return m, m.subscribeConversations()
+		}
+		return m, nil
+	
+	case healthMsg:
+		m.handleHealth(msg)
+		return m, nil
+	
+	case websocket.ServiceGroupsMsg:
+		m.serviceGroups = m.visibleGroups([]models.ServiceGroup(msg))
+		return m, nil
+	
+	case websocket.ProcessesMsg:
+		m.processes = []models.ProcessStats(msg)
+		return m, nil
+	
+	case websocket.LossReportMsg:
+		m.handleLossReport(models.LossReport(msg))
+		return m, nil
+	
+	case websocket.TrafficMatrixMsg:
+		matrix := models.TrafficMatrix(msg)
+		m.matrix = &matrix
+		return m, nil
+	
+	case websocket.EncryptionStatsMsg:
+		report := models.EncryptionReport(msg)
+		m.encryption = &report
+		return m, nil
+	
+	case websocket.CountriesMsg:
+		m.countries = []models.CountryStats(msg)
+		return m, nil
+	
+	case websocket.TopTalkersMsg:
+		m.handleTopTalkers(msg)
+		return m, nil
+	
+	case websocket.HostsMsg:
+		m.setHosts([]models.Host(msg))
+		return m, nil
+	
+	case websocket.DomainsMsg:
+		m.handleDomains(msg)
+		return m, nil
+	
+	case websocket.LeasesMsg:
+		m.setLeases([]models.Lease(msg))
+		return m, nil
+	
+	case websocket.ServicesMsg:
+		m.setServices([]models.DiscoveredDevice(msg))
+		return m, nil
+	
+	case pcapExportMsg:
+		m.handlePcapExport(msg)
+		return m, nil
+	
+	case websocket.FirewallRulesMsg:
+		rules := models.FirewallRules(msg)
+		m.firewallPrompt = &rules
+		m.firewallResult = false
+		return m, nil
+	
+	case websocket.FirewallResultMsg:
+		rules := models.FirewallRules(msg)
+		m.firewallPrompt = &rules
+		m.firewallResult = true
+		return m, nil
+	
+	case websocket.BlockMsg:
+		m.blocks = append(m.blocks, models.Block(msg))
+		return m, nil
+	
+	case websocket.AlertMsg:
+		alert := models.Alert(msg)
+		if alert.IsOpen() && alert.Severity == models.SeverityHigh {
+			m.notice = fmt.Sprintf("! HIGH ALERT: %s (%s to view)", alert.Message, m.keys.Key(ActionSwitchView))
+		}
+		m.upsertAlert(alert)
+		return m, nil
+	
+	case websocket.WatchedHostsMsg:
+		m.setWatchedHosts([]models.WatchProfile(msg))
+		return m, nil
+	
+	case websocket.WatchActivityMsg:
+		m.handleWatchActivity(models.WatchActivity(msg))
+		return m, nil
+	
+	case websocket.NewDeviceMsg:
+		m.handleNewDevice(models.Device(msg))
+		return m, nil
+	
+	case websocket.CaptureRestartedMsg:
+		restart := models.CaptureRestart(msg)
+		m.notice = restart.Message()
+		return m, nil
+	
+	case websocket.CommandRejectedMsg:
+		m.notice = fmt.Sprintf("Daemon refused %s: %s", msg.Command, msg.Error)
+		return m, nil
+	
+	case websocket.ThroughputMsg:
+		m.handleThroughput(models.Throughput(msg))
+		return m, nil
+
+	case websocket.HistoryMsg:
+		m.handleHistory(msg)
+		return m, nil
+
+	case websocket.ConversationPacketsMsg:
+		m.handleConversationPackets(msg)
+		return m, nil
+
+	case websocket.CaptureStateMsg:
+		m.handleCaptureState(models.CaptureState(msg))
+		return m, nil
+	
+	case websocket.AlertsMsg:
+		m.alerts = []models.Alert(msg)
+		sort.SliceStable(m.alerts, func(i, j int) bool {
+			return m.alerts[i].Time.After(m.alerts[j].Time)
 		})
 		return m, nil
 	}
@@ -163,8 +480,54 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "ctrl+c", "q":
+	// A pending firewall prompt captures the next key press
+	if m.firewallPrompt != nil {
+		return m.handleFirewallKey(msg)
+	}
+	// So does the filter prompt, until enter or esc
+	if m.filterPrompt != nil {
+		return m.handleFilterKey(msg)
+	}
+	m.notice = ""
+	if m.viewMode == ViewModeCompare {
+		return m.handleCompareKey(msg)
+	}
+	if m.viewMode == ViewModeLatency {
+		return m.handleLatencyKey(msg)
+	}
+	if m.viewMode == ViewModeMatrix {
+		return m.handleMatrixKey(msg)
+	}
+	if m.viewMode == ViewModeEncryption {
+		return m.handleEncryptionKey(msg)
+	}
+	if m.viewMode == ViewModeCountries {
+		return m.handleCountriesKey(msg)
+	}
+	if m.viewMode == ViewModeTop {
+		return m.handleTopKey(msg)
+	}
+	if m.viewMode == ViewModeHosts {
+		return m.handleHostsKey(msg)
+	}
+	if m.viewMode == ViewModeDomains {
+		return m.handleDomainsKey(msg)
+	}
+	if m.viewMode == ViewModeLeases {
+		return m.handleLeasesKey(msg)
+	}
+	if m.viewMode == ViewModeServices {
+		return m.handleServicesKey(msg)
+	}
+	if m.viewMode == ViewModeConversationDetail {
+		return m.handleConversationDetailKey(msg)
+	}
+	if m.viewMode == ViewModeHistory {
+		return m.handleHistoryKey(msg)
+	}
+	
+	switch m.keys.Action(msg.String()) {
+	case ActionQuit:
 		// Don't quit if in detail view, just exit detail view
 		if m.viewMode == ViewModePacketDetail {
 			m.viewMode = ViewModePackets
@@ -172,35 +535,57 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, tea.Quit
 	
-	case "?", "h":
+	case ActionHelp:
 		// Don't show help in detail view
 		if m.viewMode != ViewModePacketDetail {
 			m.showHelp = !m.showHelp
 		}
 		return m, nil
 	
-	case "enter":
+	case ActionSelect:
 		// Show detail view for selected packet
 		if m.viewMode == ViewModePackets && len(m.filteredEvents) > 0 {
 			m.viewMode = ViewModePacketDetail
+			m.rawScroll, m.hexScroll = 0, 0
+		}
+		// Show the selected conversation in detail
+		if m.viewMode == ViewModeConversations && !m.groupServices {
+			return m, m.openConversationDetail()
+		}
+		// Jump from an alert to the conversation that triggered it
+		if m.viewMode == ViewModeAlerts {
+			m.showAlertConversation()
+		}
+		// Expand a service group into its conversations
+		if m.viewMode == ViewModeConversations && m.groupServices {
+			m.showGroupConversations()
 		}
 		return m, nil
 	
-	case "esc":
+	case ActionBack:
 		// Exit detail view
 		if m.viewMode == ViewModePacketDetail {
 			m.viewMode = ViewModePackets
 		}
 		return m, nil
 	
-	case "j", "down":
-		// Don't navigate in detail view
+	case ActionDown:
+		// Don't navigate in detail view, but scroll raw JSON or the payload dump
 		if m.viewMode == ViewModePacketDetail {
+			if m.rawJSON && m.rawScroll < m.rawScrollLimit() {
+				m.rawScroll++
+			} else if event, ok := m.selectedEvent(); ok && !m.rawJSON && m.hexScroll < m.hexScrollLimit(event, m.hexPaneHeight()) {
+				m.hexScroll++
+			}
 			return m, nil
 		}
 		maxItems := len(m.filteredEvents) - 1
 		if m.viewMode == ViewModeConversations {
-			maxItems = len(m.conversations) - 1
+			maxItems = m.conversationRows() - 1
+		} else if m.viewMode == ViewModeAlerts {
+			maxItems = len(m.alerts) - 1
+		} else if m.viewMode == ViewModeProcesses {
+			maxItems = len(m.processes) - 1
 		}
 		if m.selectedIndex < maxItems {
 			m.selectedIndex++
@@ -208,9 +593,14 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 	
-	case "k", "up":
-		// Don't navigate in detail view
+	case ActionUp:
+		// Don't navigate in detail view, but scroll raw JSON or the payload dump
 		if m.viewMode == ViewModePacketDetail {
+			if m.rawJSON && m.rawScroll > 0 {
+				m.rawScroll--
+			} else if !m.rawJSON && m.hexScroll > 0 {
+				m.hexScroll--
+			}
 			return m, nil
 		}
 		if m.selectedIndex > 0 {
@@ -219,20 +609,24 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 	
-	case "G":
+	case ActionBottom:
 		// Don't navigate in detail view
 		if m.viewMode == ViewModePacketDetail {
 			return m, nil
 		}
 		if m.viewMode == ViewModePackets {
 			m.selectedIndex = len(m.filteredEvents) - 1
+		} else if m.viewMode == ViewModeAlerts {
+			m.selectedIndex = len(m.alerts) - 1
+		} else if m.viewMode == ViewModeProcesses {
+			m.selectedIndex = len(m.processes) - 1
 		} else {
-			m.selectedIndex = len(m.conversations) - 1
+			m.selectedIndex = m.conversationRows() - 1
 		}
 		m.ensureSelectedVisible()
 		return m, nil
 	
-	case "g":
+	case ActionTop:
 		// Don't navigate in detail view
 		if m.viewMode == ViewModePacketDetail {
 			return m, nil
@@ -241,7 +635,7 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.scrollOffset = 0
 		return m, nil
 	
-	case "ctrl+d":
+	case ActionPageDown:
 		// Don't navigate in detail view
 		if m.viewMode == ViewModePacketDetail {
 			return m, nil
@@ -249,7 +643,7 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.scrollDown(m.height / 2)
 		return m, nil
 	
-	case "ctrl+u":
+	case ActionPageUp:
 		// Don't navigate in detail view
 		if m.viewMode == ViewModePacketDetail {
 			return m, nil
@@ -257,7 +651,7 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.scrollUp(m.height / 2)
 		return m, nil
 	
-	case "c":
+	case ActionClear:
 		// Don't clear in detail view
 		if m.viewMode == ViewModePacketDetail {
 			return m, nil
@@ -265,30 +659,184 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.clearEvents()
 		return m, nil
 	
-	case "f":
+	case ActionFilter:
 		// Don't filter in detail view
 		if m.viewMode == ViewModePacketDetail {
 			return m, nil
 		}
-		// TODO: Implement filter dialog
+		m.openFilterPrompt()
 		return m, nil
 	
-	case "tab":
+	case ActionExport:
+		// Export a report of the session so far
+		m.exportReport()
+		return m, nil
+	
+	case ActionSplit:
+		// Toggle the side-by-side conversations/packets layout
+		if m.viewMode == ViewModeConversations {
+			m.splitLayout = !m.splitLayout
+		}
+		return m, nil
+	
+	case ActionBlock:
+		// Generate firewall rules for the selected conversation's remote host
+		if m.viewMode == ViewModeConversations && !m.groupServices {
+			return m, m.blockSelectedConversation()
+		}
+		return m, nil
+	
+	case ActionWatch:
+		// Watch the selected conversation's remote host
+		if m.viewMode == ViewModeConversations && !m.groupServices {
+			return m, m.toggleWatchSelected()
+		}
+		return m, nil
+	
+	case ActionRaw:
+		// Toggle the detail view between decoded fields and raw JSON
+		if m.viewMode == ViewModePacketDetail {
+			m.rawJSON = !m.rawJSON
+			m.rawScroll = 0
+		}
+		return m, nil
+	
+	case ActionPcap:
+		// Save the selected conversation's packets as a pcap file
+		if m.viewMode == ViewModeConversations && !m.groupServices {
+			return m, m.exportSelectedPcap()
+		}
+		return m, nil
+	
+	case ActionCompare:
+		// Mark the selected conversation, or compare it with the marked one
+		if m.viewMode == ViewModeConversations && !m.groupServices {
+			m.markForCompare()
+		}
+		return m, nil
+	
+	case ActionInternet:
+		// Hide or show traffic that never leaves the local network
+		if m.viewMode == ViewModePackets || m.viewMode == ViewModeConversations {
+			return m, m.toggleInternetOnly()
+		}
+		return m, nil
+	
+	case ActionLatency:
+		// Show handshake latency per remote host over time
+		if m.viewMode == ViewModeConversations {
+			return m, m.openLatencyHeatmap()
+		}
+		return m, nil
+	
+	case ActionMatrix:
+		// Show who talks to whom: local hosts by remote destinations
+		if m.viewMode == ViewModeConversations {
+			return m, m.openTrafficMatrix()
+		}
+		return m, nil
+	
+	case ActionEncryption:
+		// Show how much of each service's traffic is encrypted
+		if m.viewMode == ViewModeConversations {
+			return m, m.openEncryption()
+		}
+		return m, nil
+	
+	case ActionCountries:
+		// Show traffic per country of the remote end
+		if m.viewMode == ViewModeConversations {
+			return m, m.openCountries()
+		}
+		return m, nil
+	
+	case ActionHosts:
+		// Show the conversations grouped by remote host
+		if m.viewMode == ViewModeConversations {
+			return m, m.openHosts()
+		}
+		return m, nil
+	
+	case ActionDomains:
+		// Show the traffic per domain name, today's first
+		if m.viewMode == ViewModeConversations {
+			return m, m.openDomains()
+		}
+		return m, nil
+	
+	case ActionLeases:
+		// Show the devices seen using DHCP
+		if m.viewMode == ViewModeConversations {
+			return m, m.openLeases()
+		}
+		return m, nil
+	
+	case ActionServices:
+		// Show the devices advertising services over mDNS and SSDP
+		if m.viewMode == ViewModeConversations {
+			return m, m.openServices()
+		}
+		return m, nil
+	
+	case ActionTopTalkers:
+		// Show the busiest hosts, ports, services or programs
+		if m.viewMode == ViewModeConversations {
+			return m, m.openTopTalkers()
+		}
+		return m, nil
+	
+	case ActionHistory:
+		// Browse what the daemon stored, an hour at a time
+		if m.viewMode == ViewModeConversations {
+			return m, m.openHistory()
+		}
+		return m, nil
+	
+	case ActionPause:
+		// Freeze the daemon's capture to investigate, or carry on
+		return m, m.togglePause()
+	
+	case ActionGroup:
+		// Toggle grouping conversations by remote service
+		if m.viewMode == ViewModeConversations {
+			return m, m.toggleServiceGroups()
+		}
+		return m, nil
+	
+	case ActionAck:
+		if m.viewMode == ViewModeAlerts {
+			return m, m.alertAction(false)
+		}
+		return m, nil
+	
+	case ActionResolve:
+		if m.viewMode == ViewModeAlerts {
+			return m, m.alertAction(true)
+		}
+		return m, nil
+	
+	case ActionSwitchView:
 		// Don't switch view modes in detail view
 		if m.viewMode == ViewModePacketDetail {
 			return m, nil
 		}
-		// Toggle between packets and conversations view
-		if m.viewMode == ViewModePackets {
+		// Cycle through the packets, conversations, processes and alerts views
+		m.selectedIndex = 0
+		m.scrollOffset = 0
+		switch m.viewMode {
+		case ViewModePackets:
 			m.viewMode = ViewModeConversations
-			m.selectedIndex = 0
-			m.scrollOffset = 0
 			// Request conversation update
 			return m, m.requestConversations()
-		} else {
+		case ViewModeConversations:
+			m.viewMode = ViewModeProcesses
+			m.lastConvUpdate = time.Now()
+			return m, m.requestProcesses()
+		case ViewModeProcesses:
+			m.viewMode = ViewModeAlerts
+			return m, m.requestAlerts()
+		default:
 			m.viewMode = ViewModePackets
-			m.selectedIndex = 0
-			m.scrollOffset = 0
 		}
 		return m, nil
 	}
@@ -297,6 +845,8 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) addEvent(event models.NetworkEvent) {
+	m.nextSeq++
+	event.Seq = m.nextSeq
 	m.events = append(m.events, event)
 	
 	// Keep only the last maxEvents
@@ -331,25 +881,11 @@ func (m *Model) applyFilter() {
 }
 
 func (m *Model) matchesFilter(event models.NetworkEvent) bool {
-	if m.filter.Protocol != "" && !strings.EqualFold(event.Protocol, m.filter.Protocol) {
+	if m.internetOnly && !crossesInternet(event) {
 		return false
 	}
 	
-	if m.filter.IP != "" {
-		if !strings.Contains(event.SourceIP, m.filter.IP) && !strings.Contains(event.DestIP, m.filter.IP) {
-			return false
-		}
-	}
-	
-	if m.filter.Port != "" {
-		portStr := fmt.Sprintf("%d", event.SourcePort)
-		destPortStr := fmt.Sprintf("%d", event.DestPort)
-		if portStr != m.filter.Port && destPortStr != m.filter.Port {
-			return false
-		}
-	}
-	
-	return true
+	return m.filter.Match(&event)
 }
 
 func (m *Model) clearEvents() {
@@ -413,12 +949,48 @@ func (m Model) View() string {
 	s.WriteString(m.renderStats())
 	s.WriteString("\n")
 	
-	if m.viewMode == ViewModePackets {
+	if m.firewallPrompt != nil {
+		s.WriteString(m.renderFirewallPrompt())
+	} else if m.viewMode == ViewModePackets {
 		s.WriteString(m.renderEventList())
+	} else if m.useSplitLayout() {
+		s.WriteString(m.renderSplitView())
+	} else if m.viewMode == ViewModeConversations && m.groupServices {
+		s.WriteString(m.renderServiceGroupList())
 	} else if m.viewMode == ViewModeConversations {
 		s.WriteString(m.renderConversationList())
+	} else if m.viewMode == ViewModeProcesses {
+		s.WriteString(m.renderProcessList())
+	} else if m.viewMode == ViewModeAlerts {
+		s.WriteString(m.renderAlertList())
+	} else if m.viewMode == ViewModePacketDetail && m.rawJSON {
+		s.WriteString(m.renderEventJSON())
 	} else if m.viewMode == ViewModePacketDetail {
 		s.WriteString(m.renderEventDetail())
+	} else if m.viewMode == ViewModeCompare {
+		s.WriteString(m.renderComparison())
+	} else if m.viewMode == ViewModeLatency {
+		s.WriteString(m.renderLatencyHeatmap())
+	} else if m.viewMode == ViewModeMatrix {
+		s.WriteString(m.renderTrafficMatrix())
+	} else if m.viewMode == ViewModeEncryption {
+		s.WriteString(m.renderEncryption())
+	} else if m.viewMode == ViewModeCountries {
+		s.WriteString(m.renderCountries())
+	} else if m.viewMode == ViewModeTop {
+		s.WriteString(m.renderTopTalkers())
+	} else if m.viewMode == ViewModeHosts {
+		s.WriteString(m.renderHosts())
+	} else if m.viewMode == ViewModeDomains {
+		s.WriteString(m.renderDomains())
+	} else if m.viewMode == ViewModeLeases {
+		s.WriteString(m.renderLeases())
+	} else if m.viewMode == ViewModeServices {
+		s.WriteString(m.renderServices())
+	} else if m.viewMode == ViewModeConversationDetail {
+		s.WriteString(m.renderConversationDetail())
+	} else if m.viewMode == ViewModeHistory {
+		s.WriteString(m.renderHistory())
 	}
 	
 	s.WriteString("\n")
@@ -434,17 +1006,21 @@ func (m *Model) renderHeader() string {
 		status = "Disconnected"
 	}
 	
-	statusStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	statusStyle := m.fg(m.theme.Error)
 	
 	if m.connected {
-		statusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("46"))
+		statusStyle = m.fg(m.theme.Good)
+		var warning bool
+		if status, warning = m.connectedStatus(); warning {
+			statusStyle = m.fg(m.theme.Warning)
+		}
 	} else if strings.Contains(status, "Connecting") || strings.Contains(status, "Reconnecting") {
-		statusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("226"))
+		statusStyle = m.fg(m.theme.Warning)
 	}
 	
 	header := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("86")).
+		Foreground(m.theme.Accent).
 		Padding(0, 1).
 		Render(title)
 	
@@ -457,16 +1033,23 @@ func (m *Model) renderHeader() string {
 		statusText = statusStyle.Padding(0, 1).Render(status)
 	}
 	
+	// Current throughput between the title and the status, when it fits
+	var rate string
+	if text := m.throughputHeader(); text != "" && lipgloss.Width(header)+lipgloss.Width(text)+2+lipgloss.Width(statusText) <= m.width {
+		rate = m.fg(m.theme.Text).Padding(0, 1).Render(text)
+	}
+	
 	headerLine := lipgloss.JoinHorizontal(
 		lipgloss.Top,
 		header,
-		lipgloss.NewStyle().Width(m.width - lipgloss.Width(header) - lipgloss.Width(statusText)).Render(""),
+		rate,
+		lipgloss.NewStyle().Width(m.width - lipgloss.Width(header) - lipgloss.Width(rate) - lipgloss.Width(statusText)).Render(""),
 		statusText,
 	)
 	
 	return lipgloss.NewStyle().
 		Width(m.width).
-		Background(lipgloss.Color("235")).
+		Background(m.theme.Bar).
 		Render(headerLine)
 }
 
@@ -480,6 +1063,142 @@ func (m *Model) renderStats() string {
 			len(m.filteredEvents),
 			len(m.events),
 		)
+	} else if m.viewMode == ViewModeAlerts {
+		stats = fmt.Sprintf(
+			" [ALERTS VIEW] Open: %d / Total: %d",
+			m.openAlertCount(),
+			len(m.alerts),
+		)
+	} else if m.viewMode == ViewModeLatency {
+		stats = fmt.Sprintf(
+			" [LATENCY VIEW] Handshakes in the last hour: %d",
+			len(m.latencySamples),
+		)
+	} else if m.viewMode == ViewModeMatrix {
+		var locals, remotes int
+		if m.matrix != nil {
+			locals, remotes = len(m.matrix.Locals), len(m.matrix.Remotes)
+		}
+		stats = fmt.Sprintf(
+			" [MATRIX VIEW] Local hosts: %d | Remote destinations: %d",
+			locals,
+			remotes,
+		)
+	} else if m.viewMode == ViewModeEncryption {
+		var services int
+		var ratio float64
+		if m.encryption != nil {
+			services, ratio = len(m.encryption.Services), m.encryption.EncryptedRatio
+		}
+		stats = fmt.Sprintf(
+			" [ENCRYPTION VIEW] Services: %d | Encrypted: %.0f%%",
+			services,
+			ratio*100,
+		)
+	} else if m.viewMode == ViewModeCountries {
+		var active int
+		for _, country := range m.countries {
+			if country.Active > 0 {
+				active++
+			}
+		}
+		stats = fmt.Sprintf(
+			" [COUNTRIES VIEW] Countries: %d | Active: %d",
+			len(m.countries),
+			active,
+		)
+	} else if m.viewMode == ViewModeHosts {
+		var active int
+		for _, host := range m.hosts {
+			if host.Active > 0 {
+				active++
+			}
+		}
+		stats = fmt.Sprintf(
+			" [HOSTS VIEW] Hosts: %d | Active: %d | By: %s",
+			len(m.hosts),
+			active,
+			hostOrders[m.hostsOrder],
+		)
+	} else if m.viewMode == ViewModeDomains {
+		var listed int
+		if m.domains != nil {
+			listed = len(m.domains.Domains)
+		}
+		grouping := "names"
+		if m.domainsGroup {
+			grouping = "registered domains"
+		}
+		stats = fmt.Sprintf(
+			" [DOMAINS VIEW] Day: %s | By: %s | Listed: %d",
+			m.domainsPeriod(),
+			grouping,
+			listed,
+		)
+	} else if m.viewMode == ViewModeLeases {
+		var bound int
+		for _, lease := range m.leases {
+			if lease.Bound() {
+				bound++
+			}
+		}
+		stats = fmt.Sprintf(
+			" [DHCP LEASES VIEW] Devices: %d | Bound: %d",
+			len(m.leases),
+			bound,
+		)
+	} else if m.viewMode == ViewModeServices {
+		var services int
+		for _, device := range m.services {
+			services += len(device.Services)
+		}
+		stats = fmt.Sprintf(
+			" [SERVICES VIEW] Devices: %d | Services: %d",
+			len(m.services),
+			services,
+		)
+	} else if m.viewMode == ViewModeTop {
+		var talkers int
+		if m.topTalkers != nil {
+			talkers = len(m.topTalkers.Talkers)
+		}
+		stats = fmt.Sprintf(
+			" [TOP TALKERS VIEW] By: %s | Window: %s | Listed: %d",
+			topGroupings[m.topBy],
+			formatDuration(topWindows[m.topWindow]),
+			talkers,
+		)
+	} else if m.viewMode == ViewModeConversationDetail {
+		conv := m.detailedConversation()
+		stats = fmt.Sprintf(
+			" [CONVERSATION] %s | State: %s | Buffered packets: %d",
+			conv.GetEndpointPair(),
+			conv.State,
+			len(m.conversationPackets(conv.ID)),
+		)
+	} else if m.viewMode == ViewModeHistory {
+		list := "conversations"
+		if m.historyWindow.Events {
+			list = "sampled events"
+		}
+		stats = fmt.Sprintf(
+			" [HISTORY VIEW] %s | Showing: %s",
+			m.historyTitle(),
+			list,
+		)
+	} else if m.viewMode == ViewModeProcesses {
+		var conversations int
+		var rate float64
+		for _, proc := range m.processes {
+			conversations += proc.Conversations
+			rate += proc.BytesPerSecond
+		}
+		stats = fmt.Sprintf(
+			" [PROCESSES VIEW] Programs: %d | Conversations: %d | Rate: %s/s",
+			len(m.processes),
+			conversations,
+			formatBytes(int(rate)),
+		)
 	} else {
 		activeCount := 0
 		for _, conv := range m.conversations {
@@ -495,9 +1214,19 @@ func (m *Model) renderStats() string {
 			formatBytes(m.stats.TotalBytes),
 		)
 	}
+	if m.internetOnly && (m.viewMode == ViewModePackets || m.viewMode == ViewModeConversations) {
+		stats += " | Internet only"
+	}
+	if m.viewMode == ViewModePackets {
+		stats += m.filterStatus()
+	}
+	if m.viewMode == ViewModePackets || m.viewMode == ViewModeConversations {
+		stats += m.lossStatus()
+	}
+	stats += m.pauseStatus()
 	
 	return lipgloss.NewStyle().
-		Foreground(lipgloss.Color("245")).
+		Foreground(m.theme.Muted).
 		Width(m.width).
 		Padding(0, 1).
 		Render(stats)
@@ -515,7 +1244,7 @@ func (m *Model) renderEventList() string {
 		}
 		
 		empty := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("245")).
+			Foreground(m.theme.Muted).
 			Align(lipgloss.Center).
 			Width(m.width).
 			Height(viewHeight).
@@ -526,9 +1255,8 @@ func (m *Model) renderEventList() string {
 	var lines []string
 	
 	// Header row
-	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("86"))
-	header := fmt.Sprintf("%-8s %-25s %-6s %-25s %-6s %-8s %-8s",
-		"Time", "Source", "Port", "Destination", "Port", "Protocol", "Size")
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	header := m.headerPrefix("DIR") + eventHeader()
 	lines = append(lines, headerStyle.Render(header))
 	
 	// Event rows
@@ -551,6 +1279,25 @@ func (m *Model) renderEventList() string {
 	return strings.Join(lines, "\n")
 }
 
+// eventColumns lays out an event row and its header
+const eventColumns = "%-8s %-25s %-6s %-25s %-6s %-8s %-8s %-7s"
+
+// eventHeader returns the header of the event columns
+func eventHeader() string {
+	return fmt.Sprintf(eventColumns, "Time", "Source", "Port", "Destination", "Port", "Protocol", "Size", "VLAN")
+}
+
+// vlanLabel returns an event's VLAN, as outer.inner for QinQ, or "" when untagged
+func vlanLabel(event models.NetworkEvent) string {
+	switch {
+	case event.OuterVLAN != 0:
+		return fmt.Sprintf("%d.%d", event.OuterVLAN, event.VLAN)
+	case event.VLAN != 0:
+		return fmt.Sprint(event.VLAN)
+	}
+	return ""
+}
+
 func (m *Model) renderEventLine(event models.NetworkEvent, selected bool) string {
 	timeStr := event.Timestamp.Format("15:04:05")
 	
@@ -570,26 +1317,53 @@ func (m *Model) renderEventLine(event models.NetworkEvent, selected bool) string
 		destDisplay = event.TLSServerName
 	}
 	
-	line := fmt.Sprintf("%-8s %-25s %-6d %-25s %-6d %-8s %-8s",
+	// ARP and neighbor discovery have no ports, the message says more
+	sourcePort, destPort, protocol := fmt.Sprint(event.SourcePort), fmt.Sprint(event.DestPort), event.TransportProtocol
+	if event.ARP != nil {
+		sourcePort, destPort, protocol = "", "", "ARP"
+	}
+	if event.NDP != nil {
+		sourcePort, destPort = "", ""
+	}
+	
+	prefix := m.rowPrefix(selected, fmt.Sprintf("#%d", event.Seq), directionMarker(event.Direction))
+	line := prefix + fmt.Sprintf(eventColumns,
 		timeStr,
 		truncateString(sourceDisplay, 25),
-		event.SourcePort,
+		sourcePort,
 		truncateString(destDisplay, 25),
-		event.DestPort,
-		event.TransportProtocol,
+		destPort,
+		protocol,
 		formatBytes(event.Size),
+		vlanLabel(event),
 	)
+	switch {
+	case event.ARP != nil:
+		line += " " + event.ARP.Summary()
+	case event.NDP != nil:
+		line += " " + event.NDP.Summary()
+	case event.DNS != nil:
+		line += " " + event.DNS.Summary()
+	case event.HTTP != nil:
+		line += " " + event.HTTP.Summary()
+	case event.TLS != nil:
+		line += " " + event.TLS.Summary()
+	}
+	// Keep the row on one line however long the summary
+	if m.width > 3 {
+		line = truncateString(line, m.width)
+	}
 	
 	style := lipgloss.NewStyle()
 	
 	if selected {
-		style = style.Background(lipgloss.Color("238")).Foreground(lipgloss.Color("255"))
+		style = m.selectedStyle()
 	} else {
 		// Color code by direction
-		if event.Direction == "inbound" {
-			style = style.Foreground(lipgloss.Color("45"))
+		if event.Direction == "incoming" {
+			style = style.Foreground(m.theme.Inbound)
 		} else {
-			style = style.Foreground(lipgloss.Color("213"))
+			style = style.Foreground(m.theme.Outbound)
 		}
 	}
 	
@@ -597,53 +1371,140 @@ func (m *Model) renderEventLine(event models.NetworkEvent, selected bool) string
 }
 
 func (m *Model) renderFooter() string {
+	if m.filterPrompt != nil {
+		return m.renderFilterPrompt()
+	}
+	k := m.keys
 	var help string
 	if m.viewMode == ViewModePackets {
-		help = " q:quit | ?:help | j/k:navigate | enter:details | c:clear | f:filter | tab:conversations "
+		help = fmt.Sprintf(" %s:quit | %s:help | %s/%s:navigate | %s:details | %s:clear | %s:report | %s:filter | %s:internet only | %s:pause | %s:conversations ",
+			k.Key(ActionQuit), k.Key(ActionHelp), k.Key(ActionDown), k.Key(ActionUp), k.Key(ActionSelect),
+			k.Key(ActionClear), k.Key(ActionExport), k.Key(ActionFilter), k.Key(ActionInternet), k.Key(ActionPause), k.Key(ActionSwitchView))
+	} else if m.viewMode == ViewModeConversations && m.groupServices {
+		help = fmt.Sprintf(" %s:quit | %s:help | %s/%s:navigate | %s:conversations | %s:ungroup | %s:processes ",
+			k.Key(ActionQuit), k.Key(ActionHelp), k.Key(ActionDown), k.Key(ActionUp), k.Key(ActionSelect),
+			k.Key(ActionGroup), k.Key(ActionSwitchView))
 	} else if m.viewMode == ViewModeConversations {
-		help = " q:quit | ?:help | j/k:navigate | tab:switch to packets view "
+		help = fmt.Sprintf(" %s:quit | %s:help | %s/%s:navigate | %s:block | %s:watch | %s:compare | %s:pcap | %s:group | %s:latency | %s:split | %s:processes ",
+			k.Key(ActionQuit), k.Key(ActionHelp), k.Key(ActionDown), k.Key(ActionUp), k.Key(ActionBlock),
+			k.Key(ActionWatch), k.Key(ActionCompare), k.Key(ActionPcap), k.Key(ActionGroup), k.Key(ActionLatency), k.Key(ActionSplit), k.Key(ActionSwitchView))
+	} else if m.viewMode == ViewModeProcesses {
+		help = fmt.Sprintf(" %s:quit | %s:help | %s/%s:navigate | %s:alerts ",
+			k.Key(ActionQuit), k.Key(ActionHelp), k.Key(ActionDown), k.Key(ActionUp), k.Key(ActionSwitchView))
+	} else if m.viewMode == ViewModeAlerts {
+		help = fmt.Sprintf(" %s:quit | %s:help | %s/%s:navigate | %s:acknowledge | %s:resolve | %s:conversation | %s:packets ",
+			k.Key(ActionQuit), k.Key(ActionHelp), k.Key(ActionDown), k.Key(ActionUp), k.Key(ActionAck),
+			k.Key(ActionResolve), k.Key(ActionSelect), k.Key(ActionSwitchView))
 	} else if m.viewMode == ViewModePacketDetail {
-		help = " esc:back | q:back "
+		help = fmt.Sprintf(" %s:back | %s:back | %s:raw JSON ", k.Key(ActionBack), k.Key(ActionQuit), k.Key(ActionRaw))
+		if event, ok := m.selectedEvent(); ok && len(event.Payload) > 0 {
+			help = fmt.Sprintf(" %s:back | %s/%s:scroll payload | %s:raw JSON ", k.Key(ActionBack), k.Key(ActionDown), k.Key(ActionUp), k.Key(ActionRaw))
+		}
+		if m.rawJSON {
+			help = fmt.Sprintf(" %s:back | %s/%s:scroll | %s:decoded view ", k.Key(ActionBack), k.Key(ActionDown), k.Key(ActionUp), k.Key(ActionRaw))
+		}
+	} else if m.viewMode == ViewModeCompare {
+		help = fmt.Sprintf(" %s:back | %s:swap sides ", k.Key(ActionBack), k.Key(ActionCompare))
+	} else if m.viewMode == ViewModeLatency {
+		help = fmt.Sprintf(" %s:back | %s/%s:scroll hosts ", k.Key(ActionBack), k.Key(ActionDown), k.Key(ActionUp))
+	} else if m.viewMode == ViewModeMatrix {
+		help = fmt.Sprintf(" %s:back | %s/%s:scroll hosts ", k.Key(ActionBack), k.Key(ActionDown), k.Key(ActionUp))
+	} else if m.viewMode == ViewModeEncryption {
+		help = fmt.Sprintf(" %s:back | %s/%s:scroll services ", k.Key(ActionBack), k.Key(ActionDown), k.Key(ActionUp))
+	} else if m.viewMode == ViewModeCountries {
+		help = fmt.Sprintf(" %s:back | %s/%s:scroll countries ", k.Key(ActionBack), k.Key(ActionDown), k.Key(ActionUp))
+	} else if m.viewMode == ViewModeHosts {
+		help = fmt.Sprintf(" %s:back | %s/%s:navigate | %s:watch | %s:sort by bytes/last seen/conversations ",
+			k.Key(ActionBack), k.Key(ActionDown), k.Key(ActionUp), k.Key(ActionWatch), k.Key(ActionSwitchView))
+	} else if m.viewMode == ViewModeDomains {
+		help = fmt.Sprintf(" %s:back | %s/%s:scroll | %s:group subdomains | %s/%s:earlier/later day ",
+			k.Key(ActionBack), k.Key(ActionDown), k.Key(ActionUp), k.Key(ActionSwitchView), k.Key(ActionEarlier), k.Key(ActionLater))
+	} else if m.viewMode == ViewModeLeases || m.viewMode == ViewModeServices {
+		help = fmt.Sprintf(" %s:back | %s/%s:navigate | %s:watch ",
+			k.Key(ActionBack), k.Key(ActionDown), k.Key(ActionUp), k.Key(ActionWatch))
+	} else if m.viewMode == ViewModeTop {
+		help = fmt.Sprintf(" %s:back | %s/%s:navigate | %s:host/port/service/process | %s/%s:window ",
+			k.Key(ActionBack), k.Key(ActionDown), k.Key(ActionUp), k.Key(ActionSwitchView), k.Key(ActionZoomIn), k.Key(ActionZoomOut))
+	} else if m.viewMode == ViewModeConversationDetail {
+		help = fmt.Sprintf(" %s:back | %s/%s:scroll ", k.Key(ActionBack), k.Key(ActionDown), k.Key(ActionUp))
+	} else if m.viewMode == ViewModeHistory {
+		help = fmt.Sprintf(" %s:back | %s/%s:earlier/later | %s/%s:zoom | %s:only this host | %s:conversations/events ",
+			k.Key(ActionBack), k.Key(ActionEarlier), k.Key(ActionLater), k.Key(ActionZoomIn), k.Key(ActionZoomOut),
+			k.Key(ActionSelect), k.Key(ActionSwitchView))
+	}
+	
+	if m.notice != "" {
+		help = " " + m.notice + " "
 	}
 	
 	return lipgloss.NewStyle().
-		Foreground(lipgloss.Color("240")).
+		Foreground(m.theme.Faint).
 		Width(m.width).
 		Align(lipgloss.Center).
-		Background(lipgloss.Color("235")).
+		Background(m.theme.Bar).
 		Render(help)
 }
 
 func (m *Model) renderHelp() string {
-	helpText := `
- Netty Network Monitor - Help
- 
- Navigation:
-   j/↓     Move down
-   k/↑     Move up
-   g       Go to top
-   G       Go to bottom
-   Ctrl+d  Page down
-   Ctrl+u  Page up
- 
- Actions:
-   c       Clear all events
-   f       Open filter dialog
-   tab     Toggle between packets/conversations view
-   ?/h     Toggle this help
-   q       Quit
- 
- Filters:
-   You can filter events by protocol, IP address, or port.
-   Use the 'f' key to open the filter dialog.
- 
- Press any key to return...`
+	line := func(action Action, description string) string {
+		return fmt.Sprintf("   %-9s %s\n", m.keys.Keys(action), description)
+	}
+	
+	var help strings.Builder
+	help.WriteString("\n Netty Network Monitor - Help\n \n Navigation:\n")
+	help.WriteString(line(ActionDown, "Move down"))
+	help.WriteString(line(ActionUp, "Move up"))
+	help.WriteString(line(ActionTop, "Go to top"))
+	help.WriteString(line(ActionBottom, "Go to bottom"))
+	help.WriteString(line(ActionPageDown, "Page down"))
+	help.WriteString(line(ActionPageUp, "Page up"))
+	help.WriteString(" \n Actions:\n")
+	help.WriteString(line(ActionSelect, "Show details for the selected packet or conversation, or the conversation behind an alert"))
+	help.WriteString(line(ActionBack, "Leave the detail view"))
+	help.WriteString(line(ActionRaw, "Toggle the detail view between decoded fields and the daemon's raw JSON"))
+	help.WriteString(line(ActionClear, "Clear all events"))
+	help.WriteString(line(ActionExport, "Export a session report (Markdown/HTML)"))
+	help.WriteString(line(ActionFilter, "Filter the packet list with a display filter expression"))
+	help.WriteString(line(ActionSwitchView, "Cycle between packets/conversations/processes/alerts views"))
+	help.WriteString(line(ActionBlock, "Block selected conversation's remote host (conversations view)"))
+	help.WriteString(line(ActionSplit, "Toggle side-by-side conversations/packets layout (wide terminals)"))
+	help.WriteString(line(ActionWatch, "Watch/unwatch selected conversation's remote host (conversations view)"))
+	help.WriteString(line(ActionGroup, "Group conversations by remote service, Enter lists a group's conversations"))
+	help.WriteString(line(ActionCompare, "Mark a conversation, then press again on another to compare them side by side"))
+	help.WriteString(line(ActionPcap, "Save the selected conversation's packets as a pcap file for Wireshark"))
+	help.WriteString(line(ActionLatency, "Show a heatmap of TCP handshake latency per remote host over the last hour"))
+	help.WriteString(line(ActionMatrix, "Show a matrix of bytes between local hosts and top remote destinations (conversations view)"))
+	help.WriteString(line(ActionEncryption, "Show how much of each service's traffic is encrypted (conversations view)"))
+	help.WriteString(line(ActionHosts, "Group conversations by remote host, with names, services and first/last seen (conversations view)"))
+	help.WriteString(line(ActionDomains, "Show bytes and connections per TLS server name, HTTP Host or hostname, by day (conversations view)"))
+	help.WriteString(line(ActionLeases, "List the devices seen using DHCP, with their addresses, hostnames and vendors (conversations view)"))
+	help.WriteString(line(ActionServices, "List the devices announcing services over mDNS and SSDP, by name (conversations view)"))
+	help.WriteString(line(ActionTopTalkers, "Show the busiest hosts, ports, services or programs over the last minutes (conversations view)"))
+	help.WriteString(line(ActionCountries, "Show traffic per country of the remote end, from the daemon's GeoIP database (conversations view)"))
+	help.WriteString(line(ActionInternet, "Show only traffic to or from the internet, hiding loopback and LAN flows"))
+	help.WriteString(line(ActionHistory, "Browse conversations and events the daemon stored, an hour at a time (conversations view)"))
+	help.WriteString(line(ActionEarlier, "Show the previous history window"))
+	help.WriteString(line(ActionLater, "Show the next history window"))
+	help.WriteString(line(ActionZoomIn, "Shorten the history window (15m, 1h, 6h, 24h)"))
+	help.WriteString(line(ActionZoomOut, "Lengthen the history window"))
+	help.WriteString(line(ActionPause, "Pause or resume the daemon's capture, freezing conversations and statistics"))
+	help.WriteString(line(ActionAck, "Acknowledge the selected alert (alerts view)"))
+	help.WriteString(line(ActionResolve, "Resolve the selected alert (alerts view)"))
+	help.WriteString(line(ActionHelp, "Toggle this help"))
+	help.WriteString(line(ActionQuit, "Quit"))
+	help.WriteString(" \n Filters:\n")
+	help.WriteString(fmt.Sprintf("   Press '%s' and type an expression, e.g. tcp && dst.port == 443 && ip contains \"10.0\"\n", m.keys.Key(ActionFilter)))
+	help.WriteString("   Combine tests with && || ! and parentheses, compare with == != < <= > >= contains matches\n")
+	help.WriteString("   Fields: " + wrapList(filter.Fields(), 90, "\n           ") + "\n")
+	help.WriteString(m.renderDaemonHelp())
+	help.WriteString(" \n Keys can be remapped in the config file.\n")
+	help.WriteString(fmt.Sprintf(" \n Press %s to return...", m.keys.Key(ActionHelp)))
 	
 	return lipgloss.NewStyle().
 		Width(m.width).
 		Height(m.height).
 		Align(lipgloss.Center, lipgloss.Center).
-		Render(helpText)
+		Render(help.String())
 }
 
 func formatBytes(bytes int) string {
@@ -677,7 +1538,7 @@ func (m *Model) renderConversationList() string {
 		}
 		
 		empty := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("245")).
+			Foreground(m.theme.Muted).
 			Align(lipgloss.Center).
 			Width(m.width).
 			Height(viewHeight).
@@ -688,9 +1549,14 @@ func (m *Model) renderConversationList() string {
 	var lines []string
 	
 	// Header row
-	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("86"))
-	header := fmt.Sprintf("%-40s %-15s %-8s %-10s %-10s %-8s",
-		"Conversation", "Service", "State", "Packets", "Data", "Duration")
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	header := m.headerPrefix("ACT") + fmt.Sprintf("%-40s %-3s %-15s %-8s %-10s %-10s %-8s",
+		"Conversation", "CC", "Service", "State", "Packets", "Data", "Duration")
+	// The throughput sparkline only shows when it fits
+	showRate := m.width >= len(header)+1+sparklineWidth+1+rateWidth
+	if showRate {
+		header += fmt.Sprintf(" %-*s", sparklineWidth+1+rateWidth, "Rate")
+	}
 	lines = append(lines, headerStyle.Render(header))
 	
 	// Conversation rows
@@ -701,7 +1567,7 @@ func (m *Model) renderConversationList() string {
 	
 	for i := m.scrollOffset; i < endIdx && i < len(m.conversations); i++ {
 		conv := m.conversations[i]
-		line := m.renderConversationLine(conv, i == m.selectedIndex)
+		line := m.renderConversationLine(conv, i == m.selectedIndex, showRate)
 		lines = append(lines, line)
 	}
 	
@@ -714,7 +1580,7 @@ func (m *Model) renderConversationList() string {
 }
 
 // renderConversationLine renders a single conversation line
-func (m *Model) renderConversationLine(conv models.Conversation, selected bool) string {
+func (m *Model) renderConversationLine(conv models.Conversation, selected, showRate bool) string {
 	endpoints := conv.GetEndpointPair()
 	if len(endpoints) > 40 {
 		endpoints = endpoints[:37] + "..."
@@ -734,22 +1600,42 @@ func (m *Model) renderConversationLine(conv models.Conversation, selected bool)
 	data := formatBytes(int(conv.TotalBytes()))
 	duration := conv.Duration
 	
-	line := fmt.Sprintf("%-40s %-15s %-8s %-10s %-10s %-8s",
-		endpoints, service, state, packets, data, duration)
+	marker := "-"
+	if conv.IsActive() {
+		marker = "+"
+	}
+	line := m.rowPrefix(selected, conv.ID, marker) + fmt.Sprintf("%-40s %-3s %-15s %-8s %-10s %-10s %-8s",
+		endpoints, conv.CountryCode(), service, state, packets, data, duration)
+	if showRate {
+		line += " " + m.sparkline(conv.RateHistory, sparklineWidth) + fmt.Sprintf(" %-*s", rateWidth, formatRate(conv.BytesPerSec()))
+	}
+	if conv.PMTUSuspect {
+		line += " PMTU?"
+	}
+	if conv.ECNCongested > 0 {
+		line += " CE"
+	}
+	if host, _ := conv.RemoteEndpoint(); m.isWatched(host) {
+		line += " WATCH"
+	}
+	if conv.ID == m.compareMark {
+		line += " CMP"
+	}
+	line += m.closedNote(conv, time.Now())
 	
 	style := lipgloss.NewStyle()
 	
 	if selected {
-		style = style.Background(lipgloss.Color("238")).Foreground(lipgloss.Color("255"))
+		style = m.selectedStyle()
 	} else {
 		// Color by state
 		switch conv.State {
 		case models.ConversationStateEstablished:
-			style = style.Foreground(lipgloss.Color("46")) // Green
+			style = style.Foreground(m.theme.Good)
 		case models.ConversationStateNew:
-			style = style.Foreground(lipgloss.Color("226")) // Yellow
+			style = style.Foreground(m.theme.Warning)
 		case models.ConversationStateClosing, models.ConversationStateClosed:
-			style = style.Foreground(lipgloss.Color("245")) // Gray
+			style = style.Foreground(m.theme.Muted)
 		}
 	}
 	
@@ -770,15 +1656,29 @@ func formatDuration(d time.Duration) string {
 
 // requestConversations sends a request for conversation data
 func (m *Model) requestConversations() tea.Cmd {
+	streaming := m.streaming
 	return func() tea.Msg {
 		// Send request to websocket
 		if m.wsClient != nil {
-			m.wsClient.RequestConversations()
+			if !streaming {
+				m.wsClient.RequestConversations()
+			}
+			if m.groupServices {
+				m.wsClient.RequestServiceGroups()
+			}
 		}
 		return nil
 	}
 }
 
+// selectedEvent returns the event selected in the packet list, false when there's none
+func (m *Model) selectedEvent() (models.NetworkEvent, bool) {
+	if m.selectedIndex < 0 || m.selectedIndex >= len(m.filteredEvents) {
+		return models.NetworkEvent{}, false
+	}
+	return m.filteredEvents[m.selectedIndex], true
+}
+
 // renderEventDetail renders detailed information about a selected event
 func (m *Model) renderEventDetail() string {
 	if m.selectedIndex < 0 || m.selectedIndex >= len(m.filteredEvents) {
@@ -787,9 +1687,9 @@ func (m *Model) renderEventDetail() string {
 	
 	event := m.filteredEvents[m.selectedIndex]
 	
-	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("86"))
-	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
-	valueStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("255"))
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	labelStyle := m.fg(m.theme.Muted)
+	valueStyle := m.fg(m.theme.Text)
 	sectionStyle := lipgloss.NewStyle().Padding(1, 2)
 	
 	var details strings.Builder
@@ -805,13 +1705,21 @@ func (m *Model) renderEventDetail() string {
 		labelStyle.Render("Direction: ") + valueStyle.Render(event.Direction) + "\n" +
 		labelStyle.Render("Size: ") + valueStyle.Render(formatBytes(event.Size)) + "\n",
 	))
+	if vlan := vlanLabel(event); vlan != "" {
+		details.WriteString(sectionStyle.Render(labelStyle.Render("VLAN: ") + valueStyle.Render(vlan) + "\n"))
+	}
+	if event.Encapsulation != nil {
+		details.WriteString(sectionStyle.Render(labelStyle.Render("Tunnel: ") + valueStyle.Render(event.Encapsulation.Summary()) + "\n"))
+	}
 	
 	// Network Layer
 	details.WriteString("\n" + titleStyle.Render("Network Layer") + "\n")
 	details.WriteString(sectionStyle.Render(
 		labelStyle.Render("Protocol: ") + valueStyle.Render(event.Protocol) + "\n" +
 		labelStyle.Render("Source IP: ") + valueStyle.Render(event.SourceIP) + "\n" +
-		labelStyle.Render("Destination IP: ") + valueStyle.Render(event.DestIP) + "\n",
+		labelStyle.Render("Destination IP: ") + valueStyle.Render(event.DestIP) + "\n" +
+		labelStyle.Render("DSCP: ") + valueStyle.Render(event.DSCPName()) + "\n" +
+		labelStyle.Render("ECN: ") + valueStyle.Render(event.ECNName()) + "\n",
 	))
 	
 	// Hostname Resolution
@@ -829,13 +1737,53 @@ func (m *Model) renderEventDetail() string {
 		}
 	}
 	
-	// Transport Layer
-	details.WriteString("\n" + titleStyle.Render("Transport Layer") + "\n")
-	details.WriteString(sectionStyle.Render(
-		labelStyle.Render("Protocol: ") + valueStyle.Render(event.TransportProtocol) + "\n" +
-		labelStyle.Render("Source Port: ") + valueStyle.Render(fmt.Sprintf("%d", event.SourcePort)) + "\n" +
-		labelStyle.Render("Destination Port: ") + valueStyle.Render(fmt.Sprintf("%d", event.DestPort)) + "\n",
-	))
+	// GeoIP location
+	if event.SourceGeo != nil || event.DestGeo != nil {
+		details.WriteString("\n" + titleStyle.Render("Location") + "\n")
+		if event.SourceGeo != nil {
+			details.WriteString(sectionStyle.Render(
+				labelStyle.Render("Source: ") + valueStyle.Render(event.SourceGeo.String()) + "\n",
+			))
+		}
+		if event.DestGeo != nil {
+			details.WriteString(sectionStyle.Render(
+				labelStyle.Render("Destination: ") + valueStyle.Render(event.DestGeo.String()) + "\n",
+			))
+		}
+	}
+	
+	// ARP packets take the place of a transport layer
+	if event.ARP != nil {
+		details.WriteString("\n" + titleStyle.Render("ARP") + "\n")
+		details.WriteString(sectionStyle.Render(
+			labelStyle.Render("Operation: ") + valueStyle.Render(event.ARP.Summary()) + "\n" +
+			labelStyle.Render("Sender: ") + valueStyle.Render(event.ARP.SenderMAC+" "+event.ARP.SenderIP) + "\n" +
+			labelStyle.Render("Target: ") + valueStyle.Render(event.ARP.TargetMAC+" "+event.ARP.TargetIP) + "\n",
+		))
+	} else if event.NDP != nil {
+		ndp := event.NDP
+		details.WriteString("\n" + titleStyle.Render("Neighbor Discovery") + "\n")
+		section := labelStyle.Render("Message: ") + valueStyle.Render(ndp.Summary()) + "\n" +
+			labelStyle.Render("Sender: ") + valueStyle.Render(strings.TrimSpace(ndp.SenderMAC+" "+ndp.SenderIP)) + "\n"
+		if ndp.TargetIP != "" {
+			section += labelStyle.Render("Target: ") + valueStyle.Render(strings.TrimSpace(ndp.TargetMAC+" "+ndp.TargetIP)) + "\n"
+		}
+		if ndp.Type == "router_advertisement" {
+			section += labelStyle.Render("Router Lifetime: ") + valueStyle.Render(fmt.Sprintf("%ds", ndp.RouterLifetime)) + "\n"
+			if len(ndp.Prefixes) > 0 {
+				section += labelStyle.Render("Prefixes: ") + valueStyle.Render(strings.Join(ndp.Prefixes, ", ")) + "\n"
+			}
+		}
+		details.WriteString(sectionStyle.Render(section))
+	} else {
+		// Transport Layer
+		details.WriteString("\n" + titleStyle.Render("Transport Layer") + "\n")
+		details.WriteString(sectionStyle.Render(
+			labelStyle.Render("Protocol: ") + valueStyle.Render(event.TransportProtocol) + "\n" +
+			labelStyle.Render("Source Port: ") + valueStyle.Render(fmt.Sprintf("%d", event.SourcePort)) + "\n" +
+			labelStyle.Render("Destination Port: ") + valueStyle.Render(fmt.Sprintf("%d", event.DestPort)) + "\n",
+		))
+	}
 	
 	// TCP Flags (if applicable)
 	if event.TCPFlags != nil {
@@ -876,6 +1824,69 @@ func (m *Model) renderEventDetail() string {
 		}
 	}
 	
+	// DNS
+	if event.DNS != nil {
+		dns := event.DNS
+		details.WriteString("\n" + titleStyle.Render("DNS") + "\n")
+		var lines strings.Builder
+		lines.WriteString(labelStyle.Render("Query: ") + valueStyle.Render(fmt.Sprintf("%s %s (ID %d)", dns.QueryType, dns.Query, dns.ID)) + "\n")
+		if dns.Response {
+			lines.WriteString(labelStyle.Render("Response Code: ") + valueStyle.Render(dns.RCode) + "\n")
+			if len(dns.Answers) > 0 {
+				lines.WriteString(labelStyle.Render("Answers: ") + valueStyle.Render(strings.Join(dns.Answers, ", ")) + "\n")
+			}
+			if dns.LatencyMs > 0 {
+				lines.WriteString(labelStyle.Render("Latency: ") + valueStyle.Render(formatLatency(dns.LatencyMs)) + "\n")
+			}
+		}
+		details.WriteString(sectionStyle.Render(lines.String()))
+	}
+	
+	// HTTP
+	if event.HTTP != nil {
+		http := event.HTTP
+		details.WriteString("\n" + titleStyle.Render("HTTP") + "\n")
+		var lines strings.Builder
+		label := "Request: "
+		if http.Response {
+			label = "Status: "
+		}
+		lines.WriteString(labelStyle.Render(label) + valueStyle.Render(http.StartLine()) + "\n")
+		if http.Host != "" {
+			lines.WriteString(labelStyle.Render("Host: ") + valueStyle.Render(http.Host) + "\n")
+		}
+		if http.UserAgent != "" {
+			lines.WriteString(labelStyle.Render("User-Agent: ") + valueStyle.Render(http.UserAgent) + "\n")
+		}
+		if http.ContentLength > 0 {
+			lines.WriteString(labelStyle.Render("Content-Length: ") + valueStyle.Render(fmt.Sprintf("%d (%s)", http.ContentLength, formatBytes(int(http.ContentLength)))) + "\n")
+		}
+		details.WriteString(sectionStyle.Render(lines.String()))
+	}
+	
+	// TLS
+	if event.TLS != nil {
+		tls := event.TLS
+		details.WriteString("\n" + titleStyle.Render("TLS") + "\n")
+		var lines strings.Builder
+		handshake, version := "ClientHello", "Highest Version: "
+		if tls.IsServerHello() {
+			handshake, version = "ServerHello", "Version: "
+		}
+		lines.WriteString(labelStyle.Render("Handshake: ") + valueStyle.Render(handshake) + "\n")
+		lines.WriteString(labelStyle.Render(version) + valueStyle.Render(tls.Version) + "\n")
+		if tls.CipherSuite != "" {
+			lines.WriteString(labelStyle.Render("Cipher Suite: ") + valueStyle.Render(tls.CipherSuite) + "\n")
+		}
+		if len(tls.CipherSuites) > 0 {
+			lines.WriteString(labelStyle.Render("Cipher Suites: ") + valueStyle.Render(strings.Join(tls.CipherSuites, ", ")) + "\n")
+		}
+		if len(tls.ALPN) > 0 {
+			lines.WriteString(labelStyle.Render("ALPN: ") + valueStyle.Render(strings.Join(tls.ALPN, ", ")) + "\n")
+		}
+		details.WriteString(sectionStyle.Render(lines.String()))
+	}
+	
 	// Conversation Tracking
 	if event.ConversationID != "" {
 		details.WriteString("\n" + titleStyle.Render("Conversation") + "\n")
@@ -884,8 +1895,19 @@ func (m *Model) renderEventDetail() string {
 		))
 	}
 	
-	// Center the content
+	// The payload dump goes beside the fields, or below them when the
+	// terminal is too narrow for both
 	content := details.String()
+	if len(event.Payload) > 0 {
+		pane := m.renderHexPane(event, m.hexPaneHeight())
+		if lipgloss.Width(content)+lipgloss.Width(pane)+12 <= m.width {
+			content = lipgloss.JoinHorizontal(lipgloss.Top, content, "    ", pane)
+		} else {
+			content += "\n" + pane
+		}
+	}
+	
+	// Center the content
 	lines := strings.Split(content, "\n")
 	maxWidth := 0
 	for _, line := range lines {
@@ -895,15 +1917,34 @@ func (m *Model) renderEventDetail() string {
 	}
 	
 	// Create a box around the details
-	boxStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("86")).
-		Padding(1, 2).
-		Width(maxWidth + 6)
+	boxStyle := m.boxStyle(m.theme.Accent).Width(maxWidth + 6)
 	
 	return lipgloss.NewStyle().
 		Width(m.width).
 		Height(m.viewportHeight()).
 		Align(lipgloss.Center, lipgloss.Center).
 		Render(boxStyle.Render(content))
-}
\ No newline at end of file
+}
+
+// exportReport writes a report of the current session and shows where it went
+func (m *Model) exportReport() {
+	session := report.Session{
+		Start:         m.startTime,
+		End:           time.Now(),
+		TotalPackets:  m.stats.TotalPackets,
+		TotalBytes:    m.stats.TotalBytes,
+		Events:        m.events,
+		Conversations: m.conversations,
+		Blocks:        m.blocks,
+	}
+	if m.wsClient != nil {
+		session.Daemon = m.wsClient.URL()
+	}
+	
+	path, err := report.Write(session, m.reportDir, m.reportFormat)
+	if err != nil {
+		m.notice = fmt.Sprintf("Report failed: %v", err)
+		return
+	}
+	m.notice = fmt.Sprintf("Report written to %s", path)
+}
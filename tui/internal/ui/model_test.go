@@ -0,0 +1,213 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+func TestARPEventLine(t *testing.T) {
+	m := NewModel(nil, Options{})
+	m.width = 160
+	event := models.NetworkEvent{
+		Protocol: "ARP",
+		SourceIP: "192.168.1.20",
+		DestIP:   "192.168.1.1",
+		ARP:      &models.ARPInfo{Operation: "request", SenderMAC: "aa:bb:cc:00:00:14", SenderIP: "192.168.1.20", TargetIP: "192.168.1.1"},
+	}
+	line := m.renderEventLine(event, false)
+	if !strings.Contains(line, "ARP") || !strings.Contains(line, "who-has 192.168.1.1 tell 192.168.1.20") {
+		t.Errorf("Expected the ARP operation in the line, got %q", line)
+	}
+
+	reply := models.ARPInfo{Operation: "reply", SenderMAC: "aa:bb:cc:00:00:01", SenderIP: "192.168.1.1"}
+	if summary := reply.Summary(); summary != "192.168.1.1 is-at aa:bb:cc:00:00:01" {
+		t.Errorf("Unexpected reply summary %q", summary)
+	}
+}
+
+func TestVLANEventLine(t *testing.T) {
+	m := NewModel(nil, Options{})
+	m.width = 160
+	event := models.NetworkEvent{TransportProtocol: "TCP", SourceIP: "10.0.0.5", DestIP: "10.0.0.1", VLAN: 20}
+	if line := m.renderEventLine(event, false); !strings.Contains(line, " 20 ") {
+		t.Errorf("Expected VLAN 20 in the line, got %q", line)
+	}
+	event.OuterVLAN = 100
+	if line := m.renderEventLine(event, false); !strings.Contains(line, "100.20") {
+		t.Errorf("Expected the QinQ tags as outer.inner, got %q", line)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(eventHeader()), "VLAN") {
+		t.Errorf("Expected a VLAN column, got %q", eventHeader())
+	}
+}
+
+func TestEncapsulationSummary(t *testing.T) {
+	tests := []struct {
+		tunnel models.Encapsulation
+		want   string
+	}{
+		{models.Encapsulation{Type: "vxlan", ID: 42, OuterSource: "10.0.0.1", OuterDest: "10.0.0.2"}, "VXLAN VNI 42 10.0.0.1 -> 10.0.0.2"},
+		{models.Encapsulation{Type: "gre", ID: 7, OuterSource: "10.0.0.1", OuterDest: "10.0.0.2"}, "GRE key 7 10.0.0.1 -> 10.0.0.2"},
+		{models.Encapsulation{Type: "ipip", OuterSource: "10.0.0.1", OuterDest: "10.0.0.2"}, "IP-in-IP 10.0.0.1 -> 10.0.0.2"},
+	}
+	for _, tt := range tests {
+		if got := tt.tunnel.Summary(); got != tt.want {
+			t.Errorf("Expected %q, got %q", tt.want, got)
+		}
+	}
+}
+
+func TestNDPEventLine(t *testing.T) {
+	m := NewModel(nil, Options{})
+	m.width = 160
+	event := models.NetworkEvent{
+		TransportProtocol: "ICMPv6",
+		SourceIP:          "fe80::1",
+		DestIP:            "ff02::1",
+		Size:              86,
+		NDP: &models.NDPInfo{Type: "router_advertisement", SenderIP: "fe80::1", SenderMAC: "aa:bb:cc:00:00:01",
+			RouterLifetime: 1800, Prefixes: []string{"2001:db8:1::/64"}},
+	}
+	line := m.renderEventLine(event, false)
+	if !strings.Contains(line, "ICMPv6") || !strings.Contains(line, "router advertisement from fe80::1 prefix 2001:db8:1::/64") {
+		t.Errorf("Expected the advertisement in the line, got %q", line)
+	}
+	if strings.Contains(line, " 0 ") {
+		t.Errorf("Expected no ports in the line, got %q", line)
+	}
+
+	advert := models.NDPInfo{Type: "neighbor_advertisement", SenderIP: "fe80::1", TargetIP: "fe80::1", TargetMAC: "aa:bb:cc:00:00:01", Router: true}
+	if summary := advert.Summary(); summary != "fe80::1 is-at aa:bb:cc:00:00:01 (router)" {
+		t.Errorf("Unexpected advertisement summary %q", summary)
+	}
+}
+
+func TestDNSEventLine(t *testing.T) {
+	m := NewModel(nil, Options{})
+	m.width = 160
+	event := models.NetworkEvent{
+		SourceIP:          "1.1.1.1",
+		SourcePort:        53,
+		DestIP:            "192.168.1.10",
+		DestPort:          53000,
+		TransportProtocol: "UDP",
+		DNS: &models.DNSInfo{Response: true, Query: "github.com", QueryType: "A", RCode: "NOERROR",
+			Answers: []string{"140.82.112.3"}, LatencyMs: 24.6},
+	}
+	line := m.renderEventLine(event, false)
+	if !strings.Contains(line, "A github.com -> 140.82.112.3 (25ms)") {
+		t.Errorf("Expected the DNS answer in the line, got %q", line)
+	}
+	m.width = 100
+	if line := m.renderEventLine(event, false); strings.Contains(line, "\n") {
+		t.Errorf("Expected the line to be cut to fit the terminal, got %q", line)
+	}
+
+	nx := models.DNSInfo{Response: true, Query: "nope.example", QueryType: "AAAA", RCode: "NXDOMAIN"}
+	if summary := nx.Summary(); summary != "AAAA nope.example NXDOMAIN" {
+		t.Errorf("Unexpected summary %q", summary)
+	}
+}
+
+func TestHTTPEventLineAndDetail(t *testing.T) {
+	m := NewModel(nil, Options{})
+	m.width, m.height = 160, 60
+	request := models.NetworkEvent{
+		SourceIP:          "192.168.1.10",
+		SourcePort:        51000,
+		DestIP:            "93.184.216.34",
+		DestPort:          80,
+		TransportProtocol: "TCP",
+		AppProtocol:       "HTTP",
+		HTTP: &models.HTTPInfo{Version: "HTTP/1.1", Method: "GET", Host: "example.com", Path: "/index.html",
+			UserAgent: "curl/8.4.0"},
+	}
+	if line := m.renderEventLine(request, false); !strings.Contains(line, "GET example.com/index.html") {
+		t.Errorf("Expected the request in the line, got %q", line)
+	}
+
+	m.filteredEvents = []models.NetworkEvent{request}
+	m.selectedIndex = 0
+	detail := m.renderEventDetail()
+	for _, want := range []string{"GET /index.html HTTP/1.1", "example.com", "curl/8.4.0"} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("Expected %q in the detail view, got %q", want, detail)
+		}
+	}
+
+	response := models.HTTPInfo{Response: true, Version: "HTTP/1.1", StatusCode: 404, Reason: "Not Found", ContentLength: 153}
+	if line := response.StartLine(); line != "HTTP/1.1 404 Not Found" {
+		t.Errorf("Unexpected status line %q", line)
+	}
+	if summary := response.Summary(); summary != "404 Not Found" {
+		t.Errorf("Unexpected response summary %q", summary)
+	}
+	stats := models.HTTPStats{Requests: 3, Errors: 1, Method: "GET", Host: "example.com", Path: "/", StatusCode: 200}
+	if summary := stats.Summary(); summary != "GET example.com/ -> 200 (3 requests, 1 error)" {
+		t.Errorf("Unexpected conversation summary %q", summary)
+	}
+}
+
+func TestTLSEventLineAndDetail(t *testing.T) {
+	m := NewModel(nil, Options{})
+	m.width, m.height = 200, 60
+	hello := models.NetworkEvent{
+		SourceIP:          "198.51.100.9",
+		SourcePort:        443,
+		DestIP:            "192.168.1.10",
+		DestPort:          51000,
+		TransportProtocol: "TCP",
+		AppProtocol:       "HTTPS",
+		DSCP:              46,
+		DSCPClass:         "EF",
+		TLS: &models.TLSInfo{Handshake: "server_hello", Version: "TLS 1.2",
+			CipherSuite: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", ALPN: []string{"h2"}},
+	}
+	if line := m.renderEventLine(hello, false); !strings.Contains(line, "ServerHello TLS 1.2 TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 h2") {
+		t.Errorf("Expected the ServerHello in the line, got %q", line)
+	}
+
+	m.filteredEvents = []models.NetworkEvent{hello}
+	m.selectedIndex = 0
+	detail := m.renderEventDetail()
+	for _, want := range []string{"ServerHello", "Version: TLS 1.2", "Cipher Suite: TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "ALPN: h2", "DSCP: EF (46)"} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("Expected %q in the detail view, got %q", want, detail)
+		}
+	}
+
+	client := models.TLSInfo{Handshake: "client_hello", Version: "TLS 1.3", CipherSuites: []string{"TLS_AES_128_GCM_SHA256", "TLS_AES_256_GCM_SHA384"}, ALPN: []string{"h2", "http/1.1"}}
+	if summary := client.Summary(); summary != "ClientHello TLS 1.3, 2 suites, ALPN h2,http/1.1" {
+		t.Errorf("Unexpected ClientHello summary %q", summary)
+	}
+	stats := models.TLSStats{Version: "TLS 1.1", Legacy: true, CipherSuite: "TLS_RSA_WITH_AES_128_CBC_SHA", ALPN: "http/1.1"}
+	if summary := stats.Summary(); summary != "TLS 1.1 (legacy) TLS_RSA_WITH_AES_128_CBC_SHA http/1.1" {
+		t.Errorf("Unexpected conversation summary %q", summary)
+	}
+	if summary := (&models.TLSStats{ClientVersion: "TLS 1.3"}).Summary(); summary != "offered TLS 1.3" {
+		t.Errorf("Unexpected summary without a ServerHello %q", summary)
+	}
+}
+
+func TestCaptureRestartedNotice(t *testing.T) {
+	updated, _ := Model{}.Update(websocket.CaptureRestartedMsg(models.CaptureRestart{Kind: "wake", Detail: "asleep for 9h12m4s"}))
+	m := updated.(Model)
+	if m.notice != "capture restarted after wake (asleep for 9h12m4s)" {
+		t.Errorf("Expected a wake notice, got %q", m.notice)
+	}
+
+	roam := models.CaptureRestart{Kind: "address", Detail: "en0 address 192.168.1.10 -> 10.0.0.7"}
+	if message := roam.Message(); message != "capture restarted after network change (en0 address 192.168.1.10 -> 10.0.0.7)" {
+		t.Errorf("Unexpected message %q", message)
+	}
+}
+
+func TestCommandRejectedNotice(t *testing.T) {
+	updated, _ := Model{}.Update(websocket.CommandRejectedMsg{Command: "pause_capture", Error: "daemon is read-only"})
+	if notice := updated.(Model).notice; notice != "Daemon refused pause_capture: daemon is read-only" {
+		t.Errorf("Expected a refusal notice, got %q", notice)
+	}
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/models"
+)
+
+// togglePause asks the daemon to pause or resume its capture. The view
+// changes once the daemon confirms with a capture_state message.
+func (m *Model) togglePause() tea.Cmd {
+	if m.wsClient == nil || !m.connected {
+		m.notice = "Not connected to a daemon"
+		return nil
+	}
+	if info := m.daemonDetails(); info != nil && !info.Has("pause") {
+		m.notice = "This daemon can't pause its capture, upgrade it"
+		return nil
+	}
+	pause := !m.captureState.Paused
+	if pause {
+		m.notice = "Pausing capture..."
+	} else {
+		m.notice = "Resuming capture..."
+	}
+	client := m.wsClient
+	return func() tea.Msg {
+		client.PauseCapture(pause)
+		return nil
+	}
+}
+
+// requestCaptureState asks the daemon whether its capture is paused
+func (m *Model) requestCaptureState() tea.Cmd {
+	return func() tea.Msg {
+		if m.wsClient != nil {
+			m.wsClient.RequestCaptureState()
+		}
+		return nil
+	}
+}
+
+// handleCaptureState keeps the daemon's pause state, announcing changes
+func (m *Model) handleCaptureState(state models.CaptureState) {
+	if state.Paused != m.captureState.Paused {
+		if state.Paused {
+			m.notice = "Capture paused, press " + m.keys.Key(ActionPause) + " to resume"
+		} else {
+			m.notice = "Capture resumed"
+		}
+	}
+	m.captureState = state
+}
+
+// pauseStatus describes a paused capture for the stats line, "" while running
+func (m *Model) pauseStatus() string {
+	if !m.captureState.Paused {
+		return ""
+	}
+	if m.captureState.Since == nil {
+		return " | PAUSED"
+	}
+	return " | PAUSED for " + formatDuration(time.Since(*m.captureState.Since))
+}
@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netty/tui/internal/models"
+)
+
+func TestHandleCaptureState(t *testing.T) {
+	m := NewModel(nil, Options{})
+	if m.keys.Action(" ") != ActionPause || m.keys.Key(ActionPause) != "space" {
+		t.Fatalf("Expected space to pause, got %q shown as %q", m.keys.Action(" "), m.keys.Key(ActionPause))
+	}
+
+	since := time.Now().Add(-90 * time.Second)
+	m.handleCaptureState(models.CaptureState{Paused: true, Since: &since})
+	if !strings.Contains(m.notice, "press space to resume") {
+		t.Errorf("Expected a notice on how to resume, got %q", m.notice)
+	}
+	if status := m.pauseStatus(); !strings.HasPrefix(status, " | PAUSED for 1.5m") {
+		t.Errorf("Expected the pause in the stats line, got %q", status)
+	}
+
+	// The same state again, e.g. in reply to get_capture_state, isn't announced
+	m.notice = ""
+	m.handleCaptureState(models.CaptureState{Paused: true, Since: &since})
+	if m.notice != "" {
+		t.Errorf("Expected no notice without a change, got %q", m.notice)
+	}
+
+	m.handleCaptureState(models.CaptureState{})
+	if m.notice != "Capture resumed" || m.pauseStatus() != "" {
+		t.Errorf("Expected the capture resumed, got %q and %q", m.notice, m.pauseStatus())
+	}
+
+	// Pausing needs a daemon to ask
+	if cmd := m.togglePause(); cmd != nil || m.notice != "Not connected to a daemon" {
+		t.Errorf("Expected no request while disconnected, got %q", m.notice)
+	}
+}
+
+func TestNewKeymap_Space(t *testing.T) {
+	keys, err := NewKeymap(map[string][]string{"pause_capture": {"space"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys.Action(" ") != ActionPause {
+		t.Errorf("Expected \"space\" in the config to bind the space bar, got %q", keys.Action(" "))
+	}
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pcapExportMsg reports the outcome of saving a conversation as a pcap file
+type pcapExportMsg struct {
+	path string
+	err  error
+}
+
+// exportSelectedPcap downloads the selected conversation's buffered packets
+// from the daemon and saves them in the report directory
+func (m *Model) exportSelectedPcap() tea.Cmd {
+	if m.selectedIndex < 0 || m.selectedIndex >= len(m.conversations) || m.wsClient == nil {
+		return nil
+	}
+	id := m.conversations[m.selectedIndex].ID
+	dir := m.reportDir
+	client := m.wsClient
+	m.notice = "Exporting conversation packets..."
+
+	return func() tea.Msg {
+		data, err := client.FetchConversationPcap(id)
+		if err != nil {
+			return pcapExportMsg{err: err}
+		}
+		path, err := writePcapFile(dir, id, data)
+		return pcapExportMsg{path: path, err: err}
+	}
+}
+
+// writePcapFile saves a pcap in dir named after the conversation
+func writePcapFile(dir, id string, data []byte) (string, error) {
+	name := id
+	if len(name) > 8 {
+		name = name[:8]
+	}
+	path := filepath.Join(dir, fmt.Sprintf("netty-%s.pcap", name))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// handlePcapExport shows where an exported pcap went, or why it failed
+func (m *Model) handlePcapExport(msg pcapExportMsg) {
+	if msg.err != nil {
+		m.notice = fmt.Sprintf("Pcap export failed: %v", msg.err)
+		return
+	}
+	m.notice = fmt.Sprintf("Conversation packets written to %s", msg.path)
+}
@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/netty/tui/internal/models"
+)
+
+// requestProcesses asks the daemon for its per-program traffic totals
+func (m *Model) requestProcesses() tea.Cmd {
+	return func() tea.Msg {
+		if m.wsClient != nil {
+			m.wsClient.RequestProcesses()
+		}
+		return nil
+	}
+}
+
+// renderProcessList renders traffic totals per local program, busiest first
+func (m *Model) renderProcessList() string {
+	viewHeight := m.viewportHeight()
+
+	if len(m.processes) == 0 {
+		message := "No conversations attributed to programs yet"
+		switch {
+		case !m.connected:
+			message = "Not connected to daemon"
+		case m.daemonInfo != nil && !m.daemonInfo.Has("processes"):
+			message = "The daemon isn't attributing traffic to programs\n\nIt needs Linux and -processes (on by default)"
+		}
+		return lipgloss.NewStyle().
+			Foreground(m.theme.Muted).
+			Align(lipgloss.Center).
+			Width(m.width).
+			Height(viewHeight).
+			Render(message)
+	}
+
+	var lines []string
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	header := m.headerPrefix("ACT") + fmt.Sprintf("%-20s %-8s %-10s %-10s %-10s %-12s %s",
+		"Program", "Conns", "Packets", "In", "Out", "Rate", "PIDs")
+	lines = append(lines, headerStyle.Render(header))
+
+	endIdx := m.scrollOffset + viewHeight - 1
+	if endIdx > len(m.processes) {
+		endIdx = len(m.processes)
+	}
+	for i := m.scrollOffset; i < endIdx; i++ {
+		lines = append(lines, m.renderProcessLine(m.processes[i], i == m.selectedIndex))
+	}
+
+	for len(lines) < viewHeight {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderProcessLine renders a single program's row
+func (m *Model) renderProcessLine(proc models.ProcessStats, selected bool) string {
+	marker := "-"
+	if proc.Active > 0 {
+		marker = "+"
+	}
+	pids := make([]string, len(proc.PIDs))
+	for i, pid := range proc.PIDs {
+		pids[i] = fmt.Sprint(pid)
+	}
+	line := m.rowPrefix(selected, proc.Name, marker) + fmt.Sprintf("%-20s %-8s %-10d %-10s %-10s %-12s %s",
+		truncateString(proc.Name, 20),
+		fmt.Sprintf("%d/%d", proc.Active, proc.Conversations),
+		proc.TotalPackets(),
+		formatBytes(int(proc.BytesIn)),
+		formatBytes(int(proc.BytesOut)),
+		formatBytes(int(proc.BytesPerSecond))+"/s",
+		strings.Join(pids, ", "),
+	)
+	if m.width > 0 {
+		line = truncateString(line, m.width)
+	}
+
+	style := lipgloss.NewStyle()
+	switch {
+	case selected:
+		style = m.selectedStyle()
+	case proc.Active > 0:
+		style = style.Foreground(m.theme.Good)
+	default:
+		style = style.Foreground(m.theme.Muted)
+	}
+	return style.Width(m.width).Render(line)
+}
@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+func TestProcessesView(t *testing.T) {
+	m := NewModel(nil, Options{})
+	m.width, m.height, m.connected = 120, 20, true
+
+	// Tab goes packets -> conversations -> processes -> alerts
+	for _, want := range []ViewMode{ViewModeConversations, ViewModeProcesses, ViewModeAlerts, ViewModePackets} {
+		m.handleKeyPress(tea.KeyMsg{Type: tea.KeyTab})
+		if m.viewMode != want {
+			t.Fatalf("Expected view %d after tab, got %d", want, m.viewMode)
+		}
+	}
+
+	m.viewMode = ViewModeProcesses
+	updated, _ := m.Update(websocket.ProcessesMsg{
+		{Name: "firefox", PIDs: []int{2210, 2245}, Conversations: 3, Active: 2, PacketsIn: 80, PacketsOut: 20, BytesIn: 4096, BytesOut: 1024, BytesPerSecond: 2048},
+		{Name: "unknown", Conversations: 1, PacketsIn: 1},
+	})
+	m = updated.(Model)
+	list := m.renderProcessList()
+	if !strings.Contains(list, "firefox") || !strings.Contains(list, "2/3") || !strings.Contains(list, "2210, 2245") {
+		t.Errorf("Expected firefox's totals and PIDs listed, got:\n%s", list)
+	}
+	if !strings.Contains(m.renderStats(), "Programs: 2 | Conversations: 4") {
+		t.Errorf("Expected program totals in the stats line, got %q", m.renderStats())
+	}
+
+	// Navigation stops at the last program
+	for i := 0; i < 3; i++ {
+		m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	}
+	if m.selectedIndex != 1 {
+		t.Errorf("Expected the selection to stop at the last program, got %d", m.selectedIndex)
+	}
+}
+
+func TestProcessesViewUnsupported(t *testing.T) {
+	m := NewModel(nil, Options{})
+	m.width, m.height, m.connected = 120, 20, true
+	m.viewMode = ViewModeProcesses
+	m.daemonInfo = &models.DaemonInfo{Capabilities: []string{"conversations"}}
+	if list := m.renderProcessList(); !strings.Contains(list, "isn't attributing traffic") {
+		t.Errorf("Expected a hint that the daemon lacks process attribution, got:\n%s", list)
+	}
+}
@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/netty/tui/internal/models"
+)
+
+// eventJSON returns the event's payload as received from the daemon, pretty-printed.
+// Events without a recorded payload are re-encoded, which reports so.
+func eventJSON(event models.NetworkEvent) (string, bool) {
+	raw := []byte(event.Raw)
+	exact := len(raw) > 0
+	if !exact {
+		var err error
+		if raw, err = json.Marshal(event); err != nil {
+			return fmt.Sprintf("failed to encode event: %v", err), false
+		}
+	}
+
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, raw, "", "  "); err != nil {
+		return string(raw), exact
+	}
+	return pretty.String(), exact
+}
+
+// renderEventJSON renders the selected event's raw JSON, unstyled so it can be
+// copied straight from the terminal
+func (m *Model) renderEventJSON() string {
+	if m.selectedIndex < 0 || m.selectedIndex >= len(m.filteredEvents) {
+		return "No event selected"
+	}
+	viewHeight := m.viewportHeight()
+
+	payload, exact := eventJSON(m.filteredEvents[m.selectedIndex])
+	title := "Raw JSON from the daemon"
+	if !exact {
+		title = "Event re-encoded as JSON (the original payload wasn't kept)"
+	}
+
+	body := strings.Split(payload, "\n")
+	start := m.rawScroll
+	if limit := m.rawScrollLimit(); start > limit {
+		start = limit
+	}
+	end := start + m.rawVisibleLines()
+	if end > len(body) {
+		end = len(body)
+	}
+
+	lines := []string{m.fg(m.theme.Accent).Bold(true).Render(title), ""}
+	lines = append(lines, body[start:end]...)
+	if end < len(body) {
+		lines[len(lines)-1] = m.fg(m.theme.Muted).Render(fmt.Sprintf("... %d more lines", len(body)-end+1))
+	}
+	for len(lines) < viewHeight {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rawVisibleLines returns how many JSON lines fit below the raw view's title
+func (m *Model) rawVisibleLines() int {
+	if visible := m.viewportHeight() - 2; visible > 1 {
+		return visible
+	}
+	return 1
+}
+
+// rawScrollLimit returns the furthest the raw JSON of the selected event can scroll
+func (m *Model) rawScrollLimit() int {
+	if m.selectedIndex < 0 || m.selectedIndex >= len(m.filteredEvents) {
+		return 0
+	}
+	payload, _ := eventJSON(m.filteredEvents[m.selectedIndex])
+	if limit := strings.Count(payload, "\n") + 1 - m.rawVisibleLines(); limit > 0 {
+		return limit
+	}
+	return 0
+}
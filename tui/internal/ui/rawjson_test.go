@@ -0,0 +1,27 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/netty/tui/internal/models"
+)
+
+func TestEventJSON(t *testing.T) {
+	raw := `{"source_ip":"10.0.0.1","future_field":{"nested":true}}`
+	event := models.NetworkEvent{SourceIP: "10.0.0.1", Raw: []byte(raw)}
+
+	payload, exact := eventJSON(event)
+	if !exact {
+		t.Error("Expected the daemon's payload to be shown as is")
+	}
+	// Fields the client doesn't know about are kept
+	if !strings.Contains(payload, `"future_field": {`) || !strings.Contains(payload, "\n    \"nested\": true") {
+		t.Errorf("Expected pretty-printed original JSON, got:\n%s", payload)
+	}
+
+	payload, exact = eventJSON(models.NetworkEvent{SourceIP: "10.0.0.2"})
+	if exact || !strings.Contains(payload, `"source_ip": "10.0.0.2"`) {
+		t.Errorf("Expected a re-encoded event, got (exact=%v):\n%s", exact, payload)
+	}
+}
@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/netty/tui/internal/websocket"
+)
+
+// handleRecentEvents adds the events the daemon broadcast before the TUI
+// connected, skipping those it already has from before a reconnect
+func (m *Model) handleRecentEvents(msg websocket.RecentEventsMsg) {
+	var newest int64
+	if len(m.events) > 0 {
+		newest = m.events[len(m.events)-1].Timestamp.UnixNano()
+	}
+	for _, event := range msg.Events {
+		if event.Timestamp.UnixNano() <= newest {
+			continue
+		}
+		m.addEvent(event)
+		m.updateStats(event)
+		m.replayed++
+	}
+	m.applyFilter()
+
+	if msg.Last {
+		if m.replayed > 0 {
+			m.notice = fmt.Sprintf("Events from before connecting replayed: %d", m.replayed)
+		}
+		m.replayed = 0
+	}
+}
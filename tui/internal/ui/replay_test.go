@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+func TestRecentEvents(t *testing.T) {
+	m := NewModel(nil, Options{})
+	start := time.Now()
+	event := func(offset time.Duration, size int) models.NetworkEvent {
+		return models.NetworkEvent{Timestamp: start.Add(offset), Protocol: "IPv4", SourceIP: "192.168.1.10", DestIP: "140.82.112.3", Size: size}
+	}
+
+	updated, _ := m.Update(websocket.RecentEventsMsg{Events: []models.NetworkEvent{event(0, 100), event(time.Second, 200)}})
+	m = updated.(Model)
+	updated, _ = m.Update(websocket.RecentEventsMsg{Events: []models.NetworkEvent{event(2*time.Second, 300)}, Last: true})
+	m = updated.(Model)
+	if len(m.events) != 3 || m.stats.TotalBytes != 600 || len(m.filteredEvents) != 3 {
+		t.Fatalf("Expected the three replayed events listed and counted, got %d events and %d bytes", len(m.events), m.stats.TotalBytes)
+	}
+	if m.notice != "Events from before connecting replayed: 3" {
+		t.Errorf("Expected a notice of the replay, got %q", m.notice)
+	}
+
+	// After a reconnect only events newer than those held are added
+	updated, _ = m.Update(websocket.RecentEventsMsg{Events: []models.NetworkEvent{event(time.Second, 200), event(2*time.Second, 300), event(3*time.Second, 400)}, Last: true})
+	m = updated.(Model)
+	if len(m.events) != 4 || m.events[3].Size != 400 || m.notice != "Events from before connecting replayed: 1" {
+		t.Errorf("Expected only the newer event added, got %d events and %q", len(m.events), m.notice)
+	}
+}
@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/netty/tui/internal/models"
+)
+
+// openServices switches to the devices the daemon has seen advertising
+// services over mDNS and SSDP
+func (m *Model) openServices() tea.Cmd {
+	m.viewMode = ViewModeServices
+	m.servicesSelected = 0
+	m.lastConvUpdate = time.Now()
+	return m.requestServices()
+}
+
+// requestServices asks the daemon for its service inventory
+func (m *Model) requestServices() tea.Cmd {
+	return func() tea.Msg {
+		if m.wsClient != nil {
+			m.wsClient.RequestServices()
+		}
+		return nil
+	}
+}
+
+// setServices stores the daemon's devices, named ones first, keeping the
+// selection on the same device
+func (m *Model) setServices(devices []models.DiscoveredDevice) {
+	var selected string
+	if m.servicesSelected < len(m.services) {
+		selected = m.services[m.servicesSelected].IP
+	}
+	m.services = devices
+	m.servicesSelected = 0
+	for i, device := range m.services {
+		if device.IP == selected {
+			m.servicesSelected = i
+		}
+	}
+}
+
+// handleServicesKey handles key presses in the services view
+func (m *Model) handleServicesKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.keys.Action(msg.String()) {
+	case ActionBack, ActionQuit, ActionServices:
+		m.viewMode = ViewModeConversations
+		return m, m.requestConversations()
+	case ActionWatch:
+		// Watch or stop watching the selected device's address
+		if m.servicesSelected < len(m.services) {
+			return m, m.toggleWatch(m.services[m.servicesSelected].IP)
+		}
+	case ActionDown:
+		if m.servicesSelected < len(m.services)-1 {
+			m.servicesSelected++
+		}
+	case ActionUp:
+		if m.servicesSelected > 0 {
+			m.servicesSelected--
+		}
+	case ActionTop:
+		m.servicesSelected = 0
+	case ActionBottom:
+		if len(m.services) > 0 {
+			m.servicesSelected = len(m.services) - 1
+		}
+	case ActionHelp:
+		m.showHelp = !m.showHelp
+	}
+	return m, nil
+}
+
+// renderServices renders a row per device with its name and the services it
+// advertises
+func (m *Model) renderServices() string {
+	viewHeight := m.viewportHeight()
+
+	if len(m.services) == 0 {
+		message := "No mDNS or SSDP announcements seen yet"
+		if !m.connected {
+			message = "Not connected to daemon"
+		}
+		return lipgloss.NewStyle().
+			Foreground(m.theme.Muted).
+			Align(lipgloss.Center).
+			Width(m.width).
+			Height(viewHeight).
+			Render(message)
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	header := m.headerPrefix("ACT") + fmt.Sprintf("%-15s %-30s %-9s %s",
+		"IP", "Name", "Last seen", "Services")
+	lines := []string{titleStyle.Render(truncateString(header, m.width))}
+
+	// Keep the selected device in view below the header
+	visible := viewHeight - 1
+	if visible < 1 {
+		visible = 1
+	}
+	start := 0
+	if m.servicesSelected >= visible {
+		start = m.servicesSelected - visible + 1
+	}
+	now := time.Now()
+	for i := start; i < len(m.services) && i-start < visible; i++ {
+		lines = append(lines, m.renderServiceLine(m.services[i], i == m.servicesSelected, now))
+	}
+
+	for len(lines) < viewHeight {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderServiceLine renders a single device's row
+func (m *Model) renderServiceLine(device models.DiscoveredDevice, selected bool, now time.Time) string {
+	named := device.Name != "" || device.Host != ""
+	marker := "-"
+	if named {
+		marker = "+"
+	}
+	var services []string
+	seen := make(map[string]bool)
+	for _, service := range device.Services {
+		// The same type is often advertised over both protocols, or by several instances
+		if short := service.Short(); !seen[short] {
+			seen[short] = true
+			services = append(services, short)
+		}
+	}
+	line := m.rowPrefix(selected, device.IP, marker) + fmt.Sprintf("%-15s %-30s %-9s %s",
+		device.IP,
+		truncateString(orDash(device.Label()), 30),
+		formatDuration(now.Sub(device.LastSeen).Truncate(time.Second))+" ago",
+		orDash(strings.Join(services, ", ")),
+	)
+	if m.isWatched(device.IP) {
+		line += " WATCH"
+	}
+	if m.width > 0 {
+		line = truncateString(line, m.width)
+	}
+
+	style := lipgloss.NewStyle()
+	switch {
+	case selected:
+		style = m.selectedStyle()
+	case named:
+		style = style.Foreground(m.theme.Good)
+	default:
+		style = style.Foreground(m.theme.Muted)
+	}
+	return style.Width(m.width).Render(line)
+}
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+func TestServicesView(t *testing.T) {
+	m := NewModel(nil, Options{Accessible: true})
+	m.width, m.height, m.connected = 160, 20, true
+	m.viewMode = ViewModeConversations
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("S")})
+	if m.viewMode != ViewModeServices {
+		t.Fatalf("Expected S to open the services view, got view %d", m.viewMode)
+	}
+	if !strings.Contains(m.renderServices(), "No mDNS or SSDP announcements seen yet") {
+		t.Error("Expected a message before any device arrives")
+	}
+
+	now := time.Now()
+	updated, _ := m.Update(websocket.ServicesMsg{
+		{IP: "192.168.1.40", Name: "Living Room TV", Host: "1a2b", LastSeen: now.Add(-time.Minute), Services: []models.DiscoveredService{
+			{Protocol: "mdns", Type: "_googlecast._tcp", Instance: "Living Room TV-1a2b", Port: 8009},
+			{Protocol: "ssdp", Type: "urn:dial-multiscreen-org:service:dial:1", Port: 8008},
+			{Protocol: "ssdp", Type: "upnp:rootdevice", Port: 8008},
+		}},
+		{IP: "192.168.1.1", Server: "RouterOS UPnP/1.0", LastSeen: now.Add(-time.Hour)},
+	})
+	m = updated.(Model)
+
+	rows := strings.Split(m.renderServices(), "\n")
+	if !strings.Contains(rows[1], "192.168.1.40") || !strings.Contains(rows[1], "Living Room TV") ||
+		!strings.Contains(rows[1], "googlecast:8009, dial:8008, rootdevice:8008") {
+		t.Errorf("Expected the named device with its services, got %q", rows[1])
+	}
+	if !strings.HasPrefix(rows[1], ">") || !strings.Contains(rows[2], "RouterOS UPnP/1.0") {
+		t.Errorf("Expected the first device selected and the router named by its server below it, got %q", rows[1:3])
+	}
+	if stats := m.renderStats(); !strings.Contains(stats, "Devices: 2 | Services: 3") {
+		t.Errorf("Expected the device and service counts in the stats, got %q", stats)
+	}
+
+	// A refresh keeps the selection on the same device
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	updated, _ = m.Update(websocket.ServicesMsg{
+		{IP: "192.168.1.41", Host: "office-printer", LastSeen: now},
+		{IP: "192.168.1.1", Server: "RouterOS UPnP/1.0", LastSeen: now},
+	})
+	m = updated.(Model)
+	if m.services[m.servicesSelected].IP != "192.168.1.1" {
+		t.Errorf("Expected the selection to follow the device, got %s", m.services[m.servicesSelected].IP)
+	}
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.viewMode != ViewModeConversations {
+		t.Errorf("Expected esc to return to the conversations view, got %d", m.viewMode)
+	}
+}
@@ -0,0 +1,47 @@
+package ui
+
+import "strings"
+
+// Sparkline widths: the full history in the conversation list, the most recent part in the split view
+const (
+	sparklineWidth      = 16
+	splitSparklineWidth = 8
+)
+
+// sparkGlyphs and sparkASCII are the sparkline levels, lowest first
+var (
+	sparkGlyphs = []rune("▁▂▃▄▅▆▇█")
+	sparkASCII  = []rune("_.-:=+*#")
+)
+
+// sparkline renders the last width slots of a rate history, scaled to their
+// own peak so every flow shows its shape. Idle slots are blank and missing
+// history pads on the left.
+func (m *Model) sparkline(series []int64, width int) string {
+	if len(series) > width {
+		series = series[len(series)-width:]
+	}
+	glyphs := sparkGlyphs
+	if m.accessible {
+		glyphs = sparkASCII
+	}
+
+	var peak int64
+	for _, bytes := range series {
+		if bytes > peak {
+			peak = bytes
+		}
+	}
+
+	var line strings.Builder
+	line.WriteString(strings.Repeat(" ", width-len(series)))
+	for _, bytes := range series {
+		if bytes <= 0 {
+			line.WriteRune(' ')
+			continue
+		}
+		level := int((bytes*int64(len(glyphs)) - 1) / peak)
+		line.WriteRune(glyphs[level])
+	}
+	return line.String()
+}
@@ -0,0 +1,21 @@
+package ui
+
+import "testing"
+
+func TestSparkline(t *testing.T) {
+	m := Model{}
+	if got := m.sparkline([]int64{0, 100, 800, 400, 0}, 5); got != " ▁█▄ " {
+		t.Errorf("Expected the series scaled to its peak, got %q", got)
+	}
+	if got := m.sparkline([]int64{800, 100, 800}, 2); got != "▁█" {
+		t.Errorf("Expected only the most recent slots, got %q", got)
+	}
+	if got := m.sparkline(nil, 4); got != "    " {
+		t.Errorf("Expected a blank column without history, got %q", got)
+	}
+
+	m.accessible = true
+	if got := m.sparkline([]int64{1, 0, 8}, 4); got != " _ #" {
+		t.Errorf("Expected ASCII levels in accessible mode, got %q", got)
+	}
+}
@@ -0,0 +1,206 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/netty/tui/internal/models"
+)
+
+// minSplitWidth is the narrowest terminal that gets the side-by-side layout
+const minSplitWidth = 140
+
+// useSplitLayout reports whether the conversations view should render side by side
+func (m *Model) useSplitLayout() bool {
+	return m.splitLayout && m.viewMode == ViewModeConversations && !m.groupServices && m.width >= minSplitWidth
+}
+
+// conversationPackets returns the buffered events that belong to a conversation
+func (m *Model) conversationPackets(conversationID string) []models.NetworkEvent {
+	var packets []models.NetworkEvent
+	for _, event := range m.events {
+		if event.ConversationID == conversationID {
+			packets = append(packets, event)
+		}
+	}
+	return packets
+}
+
+// renderSplitView renders conversations on the left and the selected conversation's packets on the right
+func (m *Model) renderSplitView() string {
+	viewHeight := m.viewportHeight()
+	leftWidth := m.width * 55 / 100
+	rightWidth := m.width - leftWidth - 3
+
+	separator := "│"
+	if m.accessible {
+		separator = "|"
+	}
+	divider := make([]string, viewHeight)
+	for i := range divider {
+		divider[i] = " " + separator + " "
+	}
+
+	left := lipgloss.NewStyle().Width(leftWidth).Height(viewHeight).Render(m.renderSplitConversations(leftWidth, viewHeight))
+	right := lipgloss.NewStyle().Width(rightWidth).Height(viewHeight).Render(m.renderSplitPackets(rightWidth, viewHeight))
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, left, m.fg(m.theme.Faint).Render(strings.Join(divider, "\n")), right)
+}
+
+func (m *Model) renderSplitConversations(width, height int) string {
+	if len(m.conversations) == 0 {
+		message := "No active conversations"
+		if !m.connected {
+			message = "Not connected to daemon"
+		}
+		return m.fg(m.theme.Muted).Render(message)
+	}
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	header := m.headerPrefix("ACT") + fmt.Sprintf("%-34s %-10s %-8s %-9s",
+		"Conversation", "Service", "State", "Data")
+	// The recent part of the throughput sparkline, when it fits
+	showRate := width >= len(header)+1+splitSparklineWidth
+	if showRate {
+		header += " Rate"
+	}
+	lines := []string{headerStyle.Render(truncateString(header, width))}
+
+	endIdx := m.scrollOffset + height - 1
+	for i := m.scrollOffset; i < endIdx && i < len(m.conversations); i++ {
+		conv := m.conversations[i]
+		selected := i == m.selectedIndex
+
+		marker := "-"
+		if conv.IsActive() {
+			marker = "+"
+		}
+		state := string(conv.State)
+		if len(state) > 8 {
+			state = state[:8]
+		}
+		line := m.rowPrefix(selected, conv.ID, marker) + fmt.Sprintf("%-34s %-10s %-8s %-9s",
+			truncateString(conv.GetEndpointPair(), 34),
+			truncateString(conv.GetServiceInfo(), 10),
+			state,
+			formatBytes(int(conv.TotalBytes())),
+		)
+		line = truncateString(line, width)
+		if showRate {
+			line += " " + m.sparkline(conv.RateHistory, splitSparklineWidth)
+		}
+
+		style := lipgloss.NewStyle()
+		if selected {
+			style = m.selectedStyle()
+		} else if conv.IsActive() {
+			style = m.fg(m.theme.Good)
+		} else {
+			style = m.fg(m.theme.Muted)
+		}
+		lines = append(lines, style.Width(width).Render(line))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *Model) renderSplitPackets(width, height int) string {
+	if m.selectedIndex < 0 || m.selectedIndex >= len(m.conversations) {
+		return m.fg(m.theme.Muted).Render("Select a conversation to see its packets")
+	}
+
+	conv := m.conversations[m.selectedIndex]
+	packets := m.conversationPackets(conv.ID)
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	lines := []string{
+		titleStyle.Render(truncateString(fmt.Sprintf("Packets for %s (%d buffered)", conv.GetEndpointPair(), len(packets)), width)),
+	}
+	if info := conv.PathInfo(); info != "" {
+		lines = append(lines, m.fg(m.theme.Muted).Render(truncateString(info+" (local/remote)", width)))
+	}
+	if host, _ := conv.RemoteEndpoint(); m.isWatched(host) {
+		lines = append(lines, m.fg(m.theme.Accent).Render(truncateString(m.watchInfo(host), width)))
+	}
+	if info := conv.QoSInfo(); info != "" {
+		lines = append(lines, m.fg(m.theme.Muted).Render(truncateString(info, width)))
+	}
+	if conv.PMTUSuspect {
+		lines = append(lines, m.fg(m.theme.Warning).Bold(true).Render(truncateString("! Possible PMTU blackhole: full-size segments keep being retransmitted", width)))
+	}
+	if conv.ECNCongested > 0 {
+		lines = append(lines, m.fg(m.theme.Warning).Bold(true).Render(truncateString(fmt.Sprintf("! %d packets marked congestion experienced (ECN CE)", conv.ECNCongested), width)))
+	}
+
+	if len(packets) == 0 {
+		lines = append(lines, m.fg(m.theme.Muted).Render("No buffered packets for this conversation"))
+		return strings.Join(lines, "\n")
+	}
+
+	lines = append(lines, titleStyle.Render(conversationPacketHeader()))
+
+	// Show the most recent packets that fit, oldest at the top
+	start := len(packets) - (height - len(lines))
+	if start < 0 {
+		start = 0
+	}
+	for _, event := range packets[start:] {
+		lines = append(lines, m.renderConversationPacket(event, width, ""))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// conversationPacketHeader heads the columns of renderConversationPacket
+func conversationPacketHeader() string {
+	return fmt.Sprintf("%-8s %-3s %-12s %-12s %-8s", "Time", "Dir", "Flags", "App", "Size")
+}
+
+// renderConversationPacket renders one of a conversation's packets as a
+// compact row, colored by direction
+func (m *Model) renderConversationPacket(event models.NetworkEvent, width int, indent string) string {
+	line := indent + fmt.Sprintf("%-8s %-3s %-12s %-12s %-8s",
+		event.Timestamp.Format("15:04:05"),
+		directionMarker(event.Direction),
+		tcpFlagString(event.TCPFlags),
+		truncateString(event.AppProtocol, 12),
+		formatBytes(event.Size),
+	)
+
+	style := m.fg(m.theme.Outbound)
+	if event.Direction == "incoming" {
+		style = m.fg(m.theme.Inbound)
+	}
+	return style.Render(truncateString(line, width))
+}
+
+// tcpFlagString returns a compact representation of the TCP flags set on a packet
+func tcpFlagString(flags *models.TCPPacketFlags) string {
+	if flags == nil {
+		return "-"
+	}
+	var set []string
+	if flags.SYN {
+		set = append(set, "S")
+	}
+	if flags.ACK {
+		set = append(set, "A")
+	}
+	if flags.FIN {
+		set = append(set, "F")
+	}
+	if flags.RST {
+		set = append(set, "R")
+	}
+	if flags.PSH {
+		set = append(set, "P")
+	}
+	if flags.URG {
+		set = append(set, "U")
+	}
+	if len(set) == 0 {
+		return "-"
+	}
+	return strings.Join(set, "")
+}
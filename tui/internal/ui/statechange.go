@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+// justClosedFor is how long a conversation is marked as just closed
+const justClosedFor = 10 * time.Second
+
+// closure is why and when a conversation was seen to close
+type closure struct {
+	reason string
+	at     time.Time
+}
+
+// handleStateChange applies a conversation's new state from the daemon to the
+// list shown without waiting for the next full refresh
+func (m *Model) handleStateChange(msg websocket.ConversationStateMsg, now time.Time) {
+	change := models.ConversationStateChange(msg)
+	conv := change.Conversation
+	if conv.ID == "" {
+		return
+	}
+
+	if m.justClosed == nil {
+		m.justClosed = make(map[string]closure)
+	}
+	for id, closed := range m.justClosed {
+		if now.Sub(closed.at) > justClosedFor {
+			delete(m.justClosed, id)
+		}
+	}
+	if change.To == models.ConversationStateClosed {
+		m.justClosed[conv.ID] = closure{reason: change.Reason, at: now}
+	} else {
+		delete(m.justClosed, conv.ID)
+	}
+
+	if m.streaming {
+		m.streamed[conv.ID] = conv
+	}
+	if len(m.visibleConversations([]models.Conversation{conv})) == 0 {
+		return
+	}
+	m.recordLatency([]models.Conversation{conv}, now)
+	for i := range m.conversations {
+		if m.conversations[i].ID == conv.ID {
+			m.conversations[i] = conv
+			return
+		}
+	}
+	// A conversation the last refresh didn't have yet
+	m.conversations = append(m.conversations, conv)
+	sort.SliceStable(m.conversations, func(i, j int) bool {
+		return m.conversations[i].LastActivity.After(m.conversations[j].LastActivity)
+	})
+}
+
+// closedNote marks a conversation that closed in the last justClosedFor, e.g. " closed (rst)"
+func (m *Model) closedNote(conv models.Conversation, now time.Time) string {
+	closed, ok := m.justClosed[conv.ID]
+	if !ok || conv.State != models.ConversationStateClosed || now.Sub(closed.at) > justClosedFor {
+		return ""
+	}
+	return fmt.Sprintf(" closed (%s)", closed.reason)
+}
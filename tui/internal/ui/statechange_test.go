@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+func TestStateChange(t *testing.T) {
+	m := NewModel(nil, Options{Accessible: true})
+	m.width, m.height = 140, 20
+	now := time.Now()
+	m.conversations = []models.Conversation{
+		{ID: "web", LocalAddr: "192.168.1.10:50000", RemoteAddr: "140.82.112.3:443", State: models.ConversationStateEstablished, LastActivity: now},
+	}
+
+	closed := m.conversations[0]
+	closed.State = models.ConversationStateClosed
+	m.handleStateChange(websocket.ConversationStateMsg{Conversation: closed, From: models.ConversationStateEstablished, To: models.ConversationStateClosed, Reason: "rst"}, now)
+	if m.conversations[0].State != models.ConversationStateClosed {
+		t.Fatalf("Expected the conversation closed in place, got %s", m.conversations[0].State)
+	}
+	if line := m.renderConversationLine(m.conversations[0], false, false); !strings.Contains(line, "CLOSED") || !strings.Contains(line, "closed (rst)") {
+		t.Errorf("Expected the row marked just closed, got %q", line)
+	}
+	if note := m.closedNote(m.conversations[0], now.Add(justClosedFor+time.Second)); note != "" {
+		t.Errorf("Expected the mark gone after %s, got %q", justClosedFor, note)
+	}
+
+	// A conversation the list doesn't have yet is added in order
+	dns := models.Conversation{ID: "dns", LocalAddr: "192.168.1.10:53000", RemoteAddr: "1.1.1.1:53", State: models.ConversationStateEstablished, LastActivity: now.Add(time.Second)}
+	m.handleStateChange(websocket.ConversationStateMsg{Conversation: dns, From: models.ConversationStateNew, To: models.ConversationStateEstablished, Reason: "handshake"}, now)
+	if len(m.conversations) != 2 || m.conversations[0].ID != "dns" {
+		t.Errorf("Expected the new conversation first, got %+v", m.conversations)
+	}
+	if _, marked := m.justClosed["dns"]; marked {
+		t.Errorf("Expected only closed conversations marked")
+	}
+}
@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+// subscribeConversations asks the daemon to push conversation changes rather
+// than be polled for every summary
+func (m *Model) subscribeConversations() tea.Cmd {
+	client := m.wsClient
+	return func() tea.Msg {
+		if client != nil {
+			client.SubscribeConversations()
+		}
+		return nil
+	}
+}
+
+// handleConversationSnapshot replaces the conversations with the daemon's
+// snapshot, after which only deltas arrive
+func (m *Model) handleConversationSnapshot(msg websocket.ConversationSnapshotMsg) {
+	m.streamSeq = msg.Seq
+	m.streamed = make(map[string]models.Conversation, len(msg.Conversations))
+	for _, conv := range msg.Conversations {
+		m.streamed[conv.ID] = conv
+	}
+	m.streaming = true
+	m.setConversations(m.streamedConversations())
+}
+
+// handleConversationDelta applies the daemon's changes to the conversations,
+// subscribing afresh when one went missing
+func (m *Model) handleConversationDelta(msg websocket.ConversationDeltaMsg) tea.Cmd {
+	if !m.streaming || msg.Seq <= m.streamSeq {
+		return nil
+	}
+	if msg.Seq != m.streamSeq+1 {
+		// Polls cover the gap until the new snapshot arrives
+		m.streaming = false
+		return m.subscribeConversations()
+	}
+	m.streamSeq = msg.Seq
+	for _, conv := range append(msg.Added, msg.Updated...) {
+		m.streamed[conv.ID] = conv
+	}
+	for _, id := range msg.Removed {
+		delete(m.streamed, id)
+	}
+	m.setConversations(m.streamedConversations())
+	return nil
+}
+
+// streamedConversations returns the streamed conversations as a list
+func (m *Model) streamedConversations() []models.Conversation {
+	conversations := make([]models.Conversation, 0, len(m.streamed))
+	for _, conv := range m.streamed {
+		conversations = append(conversations, conv)
+	}
+	return conversations
+}
+
+// setConversations shows the daemon's conversations, most recently active first
+func (m *Model) setConversations(conversations []models.Conversation) {
+	m.conversations = m.visibleConversations(conversations)
+	sort.SliceStable(m.conversations, func(i, j int) bool {
+		if !m.conversations[i].LastActivity.Equal(m.conversations[j].LastActivity) {
+			return m.conversations[i].LastActivity.After(m.conversations[j].LastActivity)
+		}
+		return m.conversations[i].ID < m.conversations[j].ID
+	})
+	m.recordLatency(m.conversations, time.Now())
+}
@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+func TestConversationStream(t *testing.T) {
+	m := NewModel(nil, Options{})
+	now := time.Now()
+	web := models.Conversation{ID: "web", LocalAddr: "192.168.1.10:50000", RemoteAddr: "140.82.112.3:443", LastActivity: now}
+	nas := models.Conversation{ID: "nas", LocalAddr: "192.168.1.10:50001", RemoteAddr: "192.168.1.5:445", LastActivity: now.Add(-time.Minute)}
+
+	updated, cmd := m.Update(websocket.DaemonInfoMsg{Capabilities: []string{"conversations", "conversation_stream"}})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatalf("Expected a daemon with the stream to be subscribed to")
+	}
+
+	updated, _ = m.Update(websocket.ConversationSnapshotMsg{Seq: 7, Conversations: []models.Conversation{nas, web}})
+	m = updated.(Model)
+	if !m.streaming || len(m.conversations) != 2 || m.conversations[0].ID != "web" {
+		t.Fatalf("Expected the snapshot shown most recent first, got %+v", m.conversations)
+	}
+
+	// Polled answers no longer replace the list
+	updated, _ = m.Update(websocket.ConversationsMsg{nas})
+	m = updated.(Model)
+	if len(m.conversations) != 2 {
+		t.Errorf("Expected a stale poll ignored, got %+v", m.conversations)
+	}
+
+	nas.LastActivity = now.Add(time.Second)
+	dns := models.Conversation{ID: "dns", LocalAddr: "192.168.1.10:53000", RemoteAddr: "1.1.1.1:53", LastActivity: now.Add(-time.Hour)}
+	updated, cmd = m.Update(websocket.ConversationDeltaMsg{Seq: 8, Added: []models.Conversation{dns}, Updated: []models.Conversation{nas}, Removed: []string{"web"}})
+	m = updated.(Model)
+	if cmd != nil || len(m.conversations) != 2 || m.conversations[0].ID != "nas" || m.conversations[1].ID != "dns" {
+		t.Errorf("Expected the delta applied, got %+v", m.conversations)
+	}
+
+	// Hiding local traffic applies to the streamed list straight away
+	m.toggleInternetOnly()
+	if len(m.conversations) != 1 || m.conversations[0].ID != "dns" {
+		t.Errorf("Expected only the internet flow, got %+v", m.conversations)
+	}
+
+	// A gap means a delta was lost: subscribe again and poll until the snapshot
+	updated, cmd = m.Update(websocket.ConversationDeltaMsg{Seq: 10, Removed: []string{"dns"}})
+	m = updated.(Model)
+	if cmd == nil || m.streaming || len(m.conversations) != 1 {
+		t.Errorf("Expected a resubscription without applying the delta, got streaming %v and %+v", m.streaming, m.conversations)
+	}
+}
@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme holds the colors used to render the TUI, keyed by role
+type Theme struct {
+	Name          string
+	Accent        lipgloss.TerminalColor // Titles and table headers
+	Text          lipgloss.TerminalColor // Primary values
+	Muted         lipgloss.TerminalColor // Labels and secondary text
+	Faint         lipgloss.TerminalColor // Footer hints
+	Bar           lipgloss.TerminalColor // Header and footer background
+	Selection     lipgloss.TerminalColor // Selected row background
+	SelectionText lipgloss.TerminalColor // Selected row foreground
+	Good          lipgloss.TerminalColor
+	Warning       lipgloss.TerminalColor
+	Error         lipgloss.TerminalColor
+	Inbound       lipgloss.TerminalColor
+	Outbound      lipgloss.TerminalColor
+
+	// Monochrome themes use text attributes and markers instead of color
+	Monochrome bool
+}
+
+var themes = map[string]Theme{
+	"default": {
+		Name:          "default",
+		Accent:        lipgloss.Color("86"),
+		Text:          lipgloss.Color("255"),
+		Muted:         lipgloss.Color("245"),
+		Faint:         lipgloss.Color("240"),
+		Bar:           lipgloss.Color("235"),
+		Selection:     lipgloss.Color("238"),
+		SelectionText: lipgloss.Color("255"),
+		Good:          lipgloss.Color("46"),
+		Warning:       lipgloss.Color("226"),
+		Error:         lipgloss.Color("196"),
+		Inbound:       lipgloss.Color("45"),
+		Outbound:      lipgloss.Color("213"),
+	},
+	// high-contrast sticks to the 16 base ANSI colors so terminal palettes apply
+	"high-contrast": {
+		Name:          "high-contrast",
+		Accent:        lipgloss.Color("14"),
+		Text:          lipgloss.Color("15"),
+		Muted:         lipgloss.Color("15"),
+		Faint:         lipgloss.Color("15"),
+		Bar:           lipgloss.Color("0"),
+		Selection:     lipgloss.Color("11"),
+		SelectionText: lipgloss.Color("0"),
+		Good:          lipgloss.Color("10"),
+		Warning:       lipgloss.Color("11"),
+		Error:         lipgloss.Color("9"),
+		Inbound:       lipgloss.Color("14"),
+		Outbound:      lipgloss.Color("13"),
+	},
+	"mono": {
+		Name:          "mono",
+		Accent:        lipgloss.NoColor{},
+		Text:          lipgloss.NoColor{},
+		Muted:         lipgloss.NoColor{},
+		Faint:         lipgloss.NoColor{},
+		Bar:           lipgloss.NoColor{},
+		Selection:     lipgloss.NoColor{},
+		SelectionText: lipgloss.NoColor{},
+		Good:          lipgloss.NoColor{},
+		Warning:       lipgloss.NoColor{},
+		Error:         lipgloss.NoColor{},
+		Inbound:       lipgloss.NoColor{},
+		Outbound:      lipgloss.NoColor{},
+		Monochrome:    true,
+	},
+}
+
+// ThemeByName returns the named theme
+func ThemeByName(name string) (Theme, error) {
+	if name == "" {
+		name = "default"
+	}
+	theme, ok := themes[strings.ToLower(name)]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(ThemeNames(), ", "))
+	}
+	return theme, nil
+}
+
+// ThemeNames returns the names of all built-in themes
+func ThemeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fg returns a style with the given foreground color
+func (m *Model) fg(color lipgloss.TerminalColor) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(color)
+}
+
+// selectedStyle returns the style for the highlighted row
+func (m *Model) selectedStyle() lipgloss.Style {
+	if m.theme.Monochrome {
+		return lipgloss.NewStyle().Reverse(true)
+	}
+	return lipgloss.NewStyle().Background(m.theme.Selection).Foreground(m.theme.SelectionText)
+}
+
+// boxStyle returns a bordered box, or a plain padded block in accessible mode
+func (m *Model) boxStyle(border lipgloss.TerminalColor) lipgloss.Style {
+	if m.accessible {
+		return lipgloss.NewStyle().Padding(1, 2)
+	}
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(border).
+		Padding(1, 2)
+}
+
+// textMarkers reports whether rows need textual selection/direction markers
+// because color alone can't be relied on
+func (m *Model) textMarkers() bool {
+	return m.accessible || m.theme.Monochrome
+}
+
+// rowPrefix returns the textual marker columns for a list row: a selection
+// marker, a stable identifier and a short direction/state marker
+func (m *Model) rowPrefix(selected bool, id, marker string) string {
+	if !m.textMarkers() {
+		return ""
+	}
+	cursor := " "
+	if selected {
+		cursor = ">"
+	}
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return fmt.Sprintf("%s %-8s %-3s ", cursor, id, marker)
+}
+
+// headerPrefix returns the header cells matching rowPrefix
+func (m *Model) headerPrefix(marker string) string {
+	if !m.textMarkers() {
+		return ""
+	}
+	return fmt.Sprintf("  %-8s %-3s ", "ID", marker)
+}
+
+// directionMarker returns a short textual marker for an event direction
+func directionMarker(direction string) string {
+	switch direction {
+	case "incoming":
+		return "IN"
+	case "outgoing":
+		return "OUT"
+	case "multicast":
+		return "MC"
+	case "broadcast":
+		return "BC"
+	}
+	return "?"
+}
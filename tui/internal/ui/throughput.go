@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/models"
+)
+
+// Throughput shown in the header: how often it's polled, how many of the last
+// seconds each sparkline covers and the width of a formatted rate
+const (
+	throughputInterval   = time.Second
+	headerSparklineWidth = 20
+	rateWidth            = len("1023.9 KB/s")
+)
+
+// requestThroughput asks the daemon for its traffic per second, when it
+// tracks it
+func (m *Model) requestThroughput() tea.Cmd {
+	m.throughputChecked = time.Now()
+	if m.wsClient == nil {
+		return nil
+	}
+	if info := m.daemonDetails(); info == nil || !info.Has("throughput") {
+		return nil
+	}
+	client := m.wsClient
+	return func() tea.Msg {
+		client.RequestThroughput()
+		return nil
+	}
+}
+
+// handleThroughput keeps the daemon's latest throughput for the header
+func (m *Model) handleThroughput(throughput models.Throughput) {
+	m.throughput = &throughput
+}
+
+// formatRate formats bytes per second
+func formatRate(bytesPerSec float64) string {
+	return formatBytes(int(bytesPerSec)) + "/s"
+}
+
+// throughputHeader renders the current rate each way followed by its last
+// seconds as a sparkline, or nothing before the daemon reports it
+func (m *Model) throughputHeader() string {
+	t := m.throughput
+	if t == nil {
+		return ""
+	}
+	in, out := "↓", "↑"
+	if m.accessible {
+		in, out = "in", "out"
+	}
+	return fmt.Sprintf("%s %s %s  %s %s %s",
+		in, formatRate(t.BytesInPerSec), m.sparkline(t.In, headerSparklineWidth),
+		out, formatRate(t.BytesOutPerSec), m.sparkline(t.Out, headerSparklineWidth))
+}
@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/netty/tui/internal/models"
+)
+
+func TestThroughputHeader(t *testing.T) {
+	m := NewModel(nil, Options{})
+	m.width, m.height = 160, 40
+	if header := m.throughputHeader(); header != "" {
+		t.Errorf("Expected no throughput before the daemon reports it, got %q", header)
+	}
+
+	m.handleThroughput(models.Throughput{
+		BytesInPerSec: 1536, BytesOutPerSec: 200,
+		In: []int64{0, 100, 800}, Out: []int64{50, 0, 50},
+	})
+	header := m.throughputHeader()
+	if !strings.Contains(header, "↓ 1.5 KB/s") || !strings.Contains(header, "↑ 200 B/s") || !strings.Contains(header, "▁█") {
+		t.Errorf("Expected the rates and sparklines each way, got %q", header)
+	}
+	if !strings.Contains(m.renderHeader(), "1.5 KB/s") {
+		t.Errorf("Expected the throughput in a wide header, got %q", m.renderHeader())
+	}
+	m.width = 60
+	if strings.Contains(m.renderHeader(), "1.5 KB/s") {
+		t.Errorf("Expected no throughput in a narrow header, got %q", m.renderHeader())
+	}
+
+	m.accessible = true
+	if header := m.throughputHeader(); !strings.HasPrefix(header, "in 1.5 KB/s") || strings.ContainsAny(header, "↓▁█") {
+		t.Errorf("Expected ASCII labels and sparklines in accessible mode, got %q", header)
+	}
+}
+
+func TestConversationRate(t *testing.T) {
+	m := NewModel(nil, Options{})
+	m.width = 200
+	conv := models.Conversation{ID: "web", Protocol: "TCP", RateHistory: []int64{10, 20}, BytesInPerSec: 2048, BytesOutPerSec: 1024}
+	if line := m.renderConversationLine(conv, false, true); !strings.Contains(line, "3.0 KB/s") {
+		t.Errorf("Expected the current rate in the row, got %q", line)
+	}
+}
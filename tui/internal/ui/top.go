@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+// Top talkers view shape
+const (
+	topLimit    = 50 // Groups asked for
+	topBarWidth = 25
+)
+
+// topGroupings are the daemon's groupings, in the order the view cycles through them
+var topGroupings = []string{"host", "port", "service", "process"}
+
+// topWindows are the window lengths zooming steps through
+var topWindows = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour}
+
+// openTopTalkers switches to the top talkers view, by host over the last five minutes
+func (m *Model) openTopTalkers() tea.Cmd {
+	m.viewMode = ViewModeTop
+	m.lastConvUpdate = time.Now()
+	return m.showTopTalkers(0, 1)
+}
+
+// showTopTalkers changes the grouping and window, indexes into topGroupings
+// and topWindows, and asks for what's in them
+func (m *Model) showTopTalkers(by, window int) tea.Cmd {
+	m.topBy, m.topWindow = by, window
+	m.topScroll = 0
+	m.topTalkers = nil
+	return m.requestTopTalkers()
+}
+
+// requestTopTalkers asks the daemon for the busiest groups in the current grouping and window
+func (m *Model) requestTopTalkers() tea.Cmd {
+	client, by, window := m.wsClient, topGroupings[m.topBy], topWindows[m.topWindow]
+	return func() tea.Msg {
+		if client != nil {
+			client.RequestTopTalkers(by, window, topLimit)
+		}
+		return nil
+	}
+}
+
+// handleTopTalkers stores the daemon's answer, dropping answers to earlier requests
+func (m *Model) handleTopTalkers(msg websocket.TopTalkersMsg) {
+	top := models.TopTalkers(msg)
+	if top.By != topGroupings[m.topBy] || top.WindowSeconds != int(topWindows[m.topWindow].Seconds()) {
+		return
+	}
+	m.topTalkers = &top
+	if m.topScroll > len(top.Talkers)-1 {
+		m.topScroll = len(top.Talkers) - 1
+	}
+	if m.topScroll < 0 {
+		m.topScroll = 0
+	}
+}
+
+// handleTopKey handles key presses in the top talkers view
+func (m *Model) handleTopKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.keys.Action(msg.String()) {
+	case ActionBack, ActionQuit, ActionTopTalkers:
+		m.viewMode = ViewModeConversations
+		return m, m.requestConversations()
+	case ActionSwitchView:
+		return m, m.showTopTalkers((m.topBy+1)%len(topGroupings), m.topWindow)
+	case ActionZoomIn:
+		if m.topWindow > 0 {
+			return m, m.showTopTalkers(m.topBy, m.topWindow-1)
+		}
+	case ActionZoomOut:
+		if m.topWindow < len(topWindows)-1 {
+			return m, m.showTopTalkers(m.topBy, m.topWindow+1)
+		}
+	case ActionDown:
+		// Scroll the list
+		if m.topTalkers != nil && m.topScroll < len(m.topTalkers.Talkers)-1 {
+			m.topScroll++
+		}
+	case ActionUp:
+		if m.topScroll > 0 {
+			m.topScroll--
+		}
+	case ActionHelp:
+		m.showHelp = !m.showHelp
+	}
+	return m, nil
+}
+
+// renderTopTalkers renders the busiest groups over the window with a bar of each one's share
+func (m *Model) renderTopTalkers() string {
+	viewHeight := m.viewportHeight()
+	by, window := topGroupings[m.topBy], topWindows[m.topWindow]
+
+	if m.topTalkers == nil || len(m.topTalkers.Talkers) == 0 {
+		message := fmt.Sprintf("No traffic in the last %s", formatDuration(window))
+		switch {
+		case !m.connected:
+			message = "Not connected to daemon"
+		case m.topTalkers == nil:
+			message = "Waiting for the daemon..."
+		}
+		return lipgloss.NewStyle().
+			Foreground(m.theme.Muted).
+			Align(lipgloss.Center).
+			Width(m.width).
+			Height(viewHeight).
+			Render(message)
+	}
+
+	top := m.topTalkers
+	summary := fmt.Sprintf(" Top %ss over the last %s: %s in total", by, formatDuration(window), formatBytes(int(top.TotalBytes)))
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(m.theme.Accent)
+	header := fmt.Sprintf("%-30s %-6s %-10s %-10s %-12s %s",
+		strings.ToUpper(by[:1])+by[1:], "Conns", "In", "Out", "Rate", "Share")
+	lines := []string{
+		m.fg(m.theme.Text).Render(truncateString(summary, m.width)),
+		titleStyle.Render(truncateString(header, m.width)),
+	}
+
+	// Summary and header above
+	visible := viewHeight - 2
+	if visible < 1 {
+		visible = 1
+	}
+	start := m.topScroll
+	if start > len(top.Talkers)-1 {
+		start = len(top.Talkers) - 1
+	}
+	for i := start; i < len(top.Talkers) && i-start < visible; i++ {
+		lines = append(lines, m.renderTopTalkerLine(top.Talkers[i]))
+	}
+
+	for len(lines) < viewHeight {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderTopTalkerLine renders one group's row, highlighted while it's sending
+func (m *Model) renderTopTalkerLine(talker models.TopTalker) string {
+	line := fmt.Sprintf("%-30s %-6d %-10s %-10s %-12s %s %3.0f%%",
+		truncateString(talker.Key, 30),
+		talker.Conversations,
+		formatBytes(int(talker.BytesIn)),
+		formatBytes(int(talker.BytesOut)),
+		formatBytes(int(talker.BytesPerSecond))+"/s",
+		m.shareBar(talker.Share, topBarWidth),
+		talker.Share*100,
+	)
+
+	style := m.fg(m.theme.Muted)
+	if talker.BytesPerSecond > 0 {
+		style = m.fg(m.theme.Good)
+	}
+	return style.Render(truncateString(line, m.width))
+}
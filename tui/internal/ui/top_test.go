@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/models"
+	"github.com/netty/tui/internal/websocket"
+)
+
+func TestTopTalkersView(t *testing.T) {
+	m := NewModel(nil, Options{Accessible: true})
+	m.width, m.height, m.connected = 120, 20, true
+	m.viewMode = ViewModeConversations
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("T")})
+	if m.viewMode != ViewModeTop {
+		t.Fatalf("Expected T to open the top talkers view, got view %d", m.viewMode)
+	}
+	if topGroupings[m.topBy] != "host" || topWindows[m.topWindow].Minutes() != 5 {
+		t.Errorf("Expected hosts over five minutes first, got %s over %s", topGroupings[m.topBy], topWindows[m.topWindow])
+	}
+
+	talkers := websocket.TopTalkersMsg{
+		By:            "host",
+		WindowSeconds: 300,
+		TotalBytes:    4000,
+		Talkers: []models.TopTalker{
+			{Key: "github.com", Conversations: 2, BytesIn: 2900, BytesOut: 100, Bytes: 3000, Share: 0.75, BytesPerSecond: 512},
+			{Key: "203.0.113.7", Conversations: 1, BytesIn: 500, BytesOut: 500, Bytes: 1000, Share: 0.25},
+		},
+	}
+	updated, _ := m.Update(talkers)
+	m = updated.(Model)
+
+	rows := strings.Split(m.renderTopTalkers(), "\n")
+	if !strings.Contains(rows[0], "Top hosts over the last 5.0m") {
+		t.Errorf("Expected the grouping and window in the summary, got %q", rows[0])
+	}
+	if !strings.HasPrefix(rows[2], "github.com") || !strings.Contains(rows[2], "512 B/s") || !strings.Contains(rows[2], "###################......  75%") {
+		t.Errorf("Expected github.com first with its share, got %q", rows[2])
+	}
+	if !strings.HasPrefix(rows[3], "203.0.113.7") || !strings.Contains(rows[3], "  25%") {
+		t.Errorf("Expected the SSH host second, got %q", rows[3])
+	}
+
+	// Tab moves on to ports; the answer for hosts no longer applies
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyTab})
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("-")})
+	if topGroupings[m.topBy] != "port" || topWindows[m.topWindow].Minutes() != 15 {
+		t.Errorf("Expected ports over 15 minutes, got %s over %s", topGroupings[m.topBy], topWindows[m.topWindow])
+	}
+	updated, _ = m.Update(talkers)
+	m = updated.(Model)
+	if view := m.renderTopTalkers(); !strings.Contains(view, "Waiting for the daemon") {
+		t.Errorf("Expected a stale answer for hosts to be dropped, got:\n%s", view)
+	}
+
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.viewMode != ViewModeConversations {
+		t.Errorf("Expected esc to return to the conversations view, got %d", m.viewMode)
+	}
+}
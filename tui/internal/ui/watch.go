@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/netty/tui/internal/models"
+)
+
+// isWatched reports whether the daemon is watching a host
+func (m *Model) isWatched(host string) bool {
+	_, watched := m.watched[host]
+	return watched
+}
+
+// setWatchedHosts replaces the watched hosts with the daemon's list
+func (m *Model) setWatchedHosts(profiles []models.WatchProfile) {
+	m.watched = make(map[string]models.WatchProfile, len(profiles))
+	for _, profile := range profiles {
+		m.watched[profile.Host] = profile
+	}
+}
+
+// toggleWatchSelected watches the selected conversation's remote host, or stops watching it
+func (m *Model) toggleWatchSelected() tea.Cmd {
+	if m.selectedIndex < 0 || m.selectedIndex >= len(m.conversations) {
+		return nil
+	}
+	host, _ := m.conversations[m.selectedIndex].RemoteEndpoint()
+	return m.toggleWatch(host)
+}
+
+// toggleWatch watches a host, or stops watching it
+func (m *Model) toggleWatch(host string) tea.Cmd {
+	if host == "" {
+		return nil
+	}
+
+	watch := !m.isWatched(host)
+	if watch {
+		m.notice = fmt.Sprintf("Watching %s: new destinations, ports and services will be alerted", host)
+	} else {
+		m.notice = fmt.Sprintf("Stopped watching %s", host)
+	}
+	return func() tea.Msg {
+		if m.wsClient != nil {
+			m.wsClient.WatchHost(host, watch)
+		}
+		return nil
+	}
+}
+
+// requestWatchedHosts asks the daemon for the watched hosts
+func (m *Model) requestWatchedHosts() tea.Cmd {
+	return func() tea.Msg {
+		if m.wsClient != nil {
+			m.wsClient.RequestWatchedHosts()
+		}
+		return nil
+	}
+}
+
+// handleWatchActivity announces first-seen behavior of a watched host
+func (m *Model) handleWatchActivity(activity models.WatchActivity) {
+	profile := m.watched[activity.Host]
+	profile.Host = activity.Host
+	switch activity.Kind {
+	case "connection":
+		profile.Connections++
+		m.watched[activity.Host] = profile
+		return
+	case "new_destination":
+		profile.Destinations = append(profile.Destinations, activity.Peer)
+		m.notice = fmt.Sprintf("WATCH %s: new destination %s", activity.Host, activity.PeerLabel())
+	case "new_port":
+		profile.Ports = append(profile.Ports, fmt.Sprintf("%s/%d", activity.Protocol, activity.Port))
+		m.notice = fmt.Sprintf("WATCH %s: new port %s/%d (%s)", activity.Host, activity.Protocol, activity.Port, activity.PeerLabel())
+	case "new_service":
+		profile.Services = append(profile.Services, activity.Service)
+		m.notice = fmt.Sprintf("WATCH %s: new service %s (%s)", activity.Host, activity.Service, activity.PeerLabel())
+	}
+	m.watched[activity.Host] = profile
+}
+
+// watchInfo summarizes a watched host's profile, or "" if it isn't watched
+func (m *Model) watchInfo(host string) string {
+	profile, watched := m.watched[host]
+	if !watched {
+		return ""
+	}
+	return fmt.Sprintf("Watched: %d connections, %d destinations, %d ports, %d services",
+		profile.Connections, len(profile.Destinations), len(profile.Ports), len(profile.Services))
+}
@@ -0,0 +1,33 @@
+package websocket
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrUnauthorized is the connection error when the daemon refuses the client's API token
+var ErrUnauthorized = errors.New("daemon refused the API token, check -token")
+
+// SetToken sets the API token sent to a daemon started with -api-token, as a
+// bearer token on the WebSocket handshake and every HTTP API request
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+}
+
+// authHeader returns the headers authenticating a request, nil without a token
+func (c *Client) authHeader() http.Header {
+	if c.token == "" {
+		return nil
+	}
+	return http.Header{"Authorization": {"Bearer " + c.token}}
+}
+
+// refused translates a daemon's 401 into ErrUnauthorized
+func refused(resp *http.Response, err error) error {
+	if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
+	return err
+}
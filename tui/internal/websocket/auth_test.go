@@ -0,0 +1,51 @@
+package websocket
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestSetToken(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			http.Error(w, "Missing or invalid API token", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/health":
+			w.Write([]byte(`{"status":"healthy","version":"1.4.0"}`))
+		case "/ws":
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			conn.ReadMessage()
+		}
+	}))
+	defer server.Close()
+
+	host, portText, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	port, _ := strconv.Atoi(portText)
+	c := NewClient(host, port)
+	if status, ok := c.Connect()().(ConnectionStatusMsg); !ok || !errors.Is(status.Error, ErrUnauthorized) {
+		t.Errorf("Expected the connection to be refused without a token, got %+v", status)
+	}
+
+	c.SetToken("s3cret")
+	if status, ok := c.Connect()().(ConnectionStatusMsg); !ok || !status.Connected {
+		t.Errorf("Expected to connect with the token, got %+v", status)
+	}
+	defer c.Close()
+	if health, err := c.FetchHealth(); err != nil || health.Version != "1.4.0" {
+		t.Errorf("Expected the token on API requests, got %+v (%v)", health, err)
+	}
+}
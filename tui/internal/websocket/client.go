@@ -1,10 +1,17 @@
 package websocket
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -20,6 +27,13 @@ type Client struct {
 	isConnected  bool
 	statusUpdate chan ConnectionStatusMsg
 	stopRead     chan struct{}
+	dropped      uint64      // Events dropped since connecting because the UI fell behind
+	recorder     *recorder   // Saves received messages, nil when not recording
+	replay       *replay     // Plays a recording back instead of connecting, nil for a live client
+	tlsConfig    *tls.Config // Set when connecting with wss, see EnableTLS
+	token        string      // API token for a daemon requiring one, see SetToken
+	msgpack      bool        // Ask for binary frames, see UseMsgpack
+	socket       string      // Unix socket to connect through, see UseSocket
 }
 
 type EventMsg models.NetworkEvent
@@ -28,18 +42,93 @@ type ConnectionStatusMsg struct {
 	Error     error
 }
 type ConversationsMsg []models.Conversation
+type FirewallRulesMsg models.FirewallRules
+type FirewallResultMsg models.FirewallRules
+type BlockMsg models.Block
+type AlertMsg models.Alert
+type AlertsMsg []models.Alert
+type WatchedHostsMsg []models.WatchProfile
+type WatchActivityMsg models.WatchActivity
+type ServiceGroupsMsg []models.ServiceGroup
+type ProcessesMsg []models.ProcessStats
+type TrafficMatrixMsg models.TrafficMatrix
+type EncryptionStatsMsg models.EncryptionReport
+type CountriesMsg []models.CountryStats
+type TopTalkersMsg models.TopTalkers
+type HostsMsg []models.Host
+type DomainsMsg models.DomainReport
+type LeasesMsg []models.Lease
+type ServicesMsg []models.DiscoveredDevice
+type ConversationStateMsg models.ConversationStateChange
+type ConversationSnapshotMsg models.ConversationSnapshot
+type ConversationDeltaMsg models.ConversationDelta
 
+// RecentEventsMsg is a batch of the events the daemon broadcast before the
+// client connected, oldest first
+type RecentEventsMsg struct {
+	Events []models.NetworkEvent
+	Last   bool // The final batch
+}
+type LossReportMsg models.LossReport
+type DaemonInfoMsg models.DaemonInfo
+type NewDeviceMsg models.Device
+type CaptureRestartedMsg models.CaptureRestart
+
+// CaptureStateMsg is whether the daemon's capture is paused, sent when that changes
+type CaptureStateMsg models.CaptureState
+
+// ThroughputMsg is the daemon's traffic per second each way, as requested
+type ThroughputMsg models.Throughput
+
+// ConversationPacketsMsg is a conversation's recent packets kept by the daemon
+type ConversationPacketsMsg struct {
+	ID      string                `json:"id"`
+	Found   bool                  `json:"found"` // False once the daemon no longer tracks it
+	Packets []models.NetworkEvent `json:"packets"`
+}
+
+// CommandRejectedMsg is a command the daemon refused, such as one changing
+// its state while it's read-only
+type CommandRejectedMsg struct {
+	Command string `json:"command"`
+	Error   string `json:"error"`
+}
+
+// HistoryMsg is what the daemon stored about a past time range, as requested
+type HistoryMsg models.History
+
+// Protocol is the daemon API version this client speaks
+const Protocol = 1
+
+// NewClient creates a client for the daemon at host and port. host may also
+// be given as a URL, "wss://router.lan:8443" connecting over TLS to the port
+// in it if there is one.
 func NewClient(host string, port int) *Client {
-	u := url.URL{Scheme: "ws", Host: fmt.Sprintf("%s:%d", host, port), Path: "/ws"}
-	return &Client{
+	scheme := "ws"
+	if s, rest, ok := strings.Cut(host, "://"); ok {
+		scheme, host = s, strings.TrimSuffix(rest, "/")
+	}
+	address := net.JoinHostPort(strings.Trim(host, "[]"), strconv.Itoa(port))
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		address = host
+	}
+	u := url.URL{Scheme: scheme, Host: address, Path: "/ws"}
+	c := &Client{
 		url:          u.String(),
 		messages:     make(chan interface{}, 100),
 		statusUpdate: make(chan ConnectionStatusMsg, 10),
 		stopRead:     make(chan struct{}),
 	}
+	if scheme == "wss" {
+		c.tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return c
 }
 
 func (c *Client) Connect() tea.Cmd {
+	if c.replay != nil {
+		return c.startReplay
+	}
 	return func() tea.Msg {
 		c.mu.Lock()
 		defer c.mu.Unlock()
@@ -56,13 +145,14 @@ func (c *Client) Connect() tea.Cmd {
 		default:
 		}
 		
-		conn, _, err := websocket.DefaultDialer.Dial(c.url, nil)
+		conn, resp, err := c.dialer().Dial(c.url, c.authHeader())
 		if err != nil {
 			c.isConnected = false
-			return ConnectionStatusMsg{Connected: false, Error: err}
+			return ConnectionStatusMsg{Connected: false, Error: refused(resp, err)}
 		}
 		c.conn = conn
 		c.isConnected = true
+		atomic.StoreUint64(&c.dropped, 0)
 		
 		go c.readMessages()
 		
@@ -109,7 +199,7 @@ func (c *Client) readMessages() {
 			// Set read deadline to allow periodic checks
 			conn.SetReadDeadline(time.Now().Add(1 * time.Second))
 			
-			_, message, err := conn.ReadMessage()
+			frame, message, err := conn.ReadMessage()
 			if err != nil {
 				// Check if it's a timeout (which is expected)
 				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
@@ -123,54 +213,225 @@ func (c *Client) readMessages() {
 				// Timeout or normal close, continue
 				continue
 			}
-		
-			// Try to parse as a typed message first
-			var typedMsg struct {
-				Type string          `json:"type"`
-				Data json.RawMessage `json:"data"`
-			}
-			
-			if err := json.Unmarshal(message, &typedMsg); err == nil && typedMsg.Type != "" {
-				// Handle typed messages
-				switch typedMsg.Type {
-				case "network_event":
-					var event models.NetworkEvent
-					if err := json.Unmarshal(typedMsg.Data, &event); err == nil {
-						select {
-						case c.messages <- event:
-						default:
-						}
-					}
-				case "conversations", "conversation_summaries":
-					var conversations []models.Conversation
-					if err := json.Unmarshal(typedMsg.Data, &conversations); err == nil {
-						select {
-						case c.messages <- ConversationsMsg(conversations):
-						default:
-						}
-					}
-				case "conversation", "conversation_update":
-					var conversation models.Conversation
-					if err := json.Unmarshal(typedMsg.Data, &conversation); err == nil {
-						// For now, we'll just request a full update
-						// In the future, we could handle individual updates
-						c.RequestConversations()
-					}
-				}
-			} else {
-				// Try to parse as network event (backward compatibility)
-				var event models.NetworkEvent
-				if err := json.Unmarshal(message, &event); err != nil {
-					// Silently skip malformed messages
+			if frame == websocket.BinaryMessage {
+				if message, err = binaryToJSON(message); err != nil {
 					continue
 				}
-				
-				select {
-				case c.messages <- event:
-				default:
-					// Drop message if channel is full
+			}
+		
+			c.record(message)
+			c.deliver(c.parseMessage(message))
+		}
+	}
+}
+
+// parseMessage turns a daemon message into the message handed to the UI, nil
+// for messages it doesn't show
+func (c *Client) parseMessage(message []byte) interface{} {
+	// Try to parse as a typed message first
+	var typedMsg struct {
+		Type string          `json:"type"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(message, &typedMsg); err != nil || typedMsg.Type == "" {
+		// Try to parse as network event (backward compatibility)
+		var event models.NetworkEvent
+		if err := json.Unmarshal(message, &event); err != nil {
+			// Silently skip malformed messages
+			return nil
+		}
+		event.Raw = message
+		return event
+	}
+	
+	// Handle typed messages
+	switch typedMsg.Type {
+	case "network_event":
+		var event models.NetworkEvent
+		if err := json.Unmarshal(typedMsg.Data, &event); err == nil {
+			event.Raw = typedMsg.Data
+			return event
+		}
+	case "conversations", "conversation_summaries":
+		var conversations []models.Conversation
+		if err := json.Unmarshal(typedMsg.Data, &conversations); err == nil {
+			return ConversationsMsg(conversations)
+		}
+	case "conversation", "conversation_update":
+		// Per packet; the model polls for summaries or streams them instead
+	case "recent_events":
+		var batch struct {
+			Events []json.RawMessage `json:"events"`
+			Last   bool              `json:"last"`
+		}
+		if err := json.Unmarshal(typedMsg.Data, &batch); err == nil {
+			recent := RecentEventsMsg{Last: batch.Last}
+			for _, raw := range batch.Events {
+				var event models.NetworkEvent
+				if err := json.Unmarshal(raw, &event); err == nil {
+					event.Raw = raw
+					recent.Events = append(recent.Events, event)
 				}
 			}
+			return recent
+		}
+	case "conversations_snapshot":
+		var snapshot models.ConversationSnapshot
+		if err := json.Unmarshal(typedMsg.Data, &snapshot); err == nil {
+			return ConversationSnapshotMsg(snapshot)
+		}
+	case "conversations_delta":
+		var delta models.ConversationDelta
+		if err := json.Unmarshal(typedMsg.Data, &delta); err == nil {
+			return ConversationDeltaMsg(delta)
+		}
+	case "conversation_state_changed", "conversation_closed":
+		var change models.ConversationStateChange
+		if err := json.Unmarshal(typedMsg.Data, &change); err == nil {
+			return ConversationStateMsg(change)
+		}
+	case "firewall_rules", "firewall_result":
+		var rules models.FirewallRules
+		if err := json.Unmarshal(typedMsg.Data, &rules); err == nil {
+			if typedMsg.Type == "firewall_result" {
+				return FirewallResultMsg(rules)
+			}
+			return FirewallRulesMsg(rules)
+		}
+	case "host_blocked":
+		var block models.Block
+		if err := json.Unmarshal(typedMsg.Data, &block); err == nil {
+			return BlockMsg(block)
+		}
+	case "alert":
+		var alert models.Alert
+		if err := json.Unmarshal(typedMsg.Data, &alert); err == nil {
+			return AlertMsg(alert)
+		}
+	case "watched_hosts":
+		var profiles []models.WatchProfile
+		if err := json.Unmarshal(typedMsg.Data, &profiles); err == nil {
+			return WatchedHostsMsg(profiles)
+		}
+	case "watch_activity":
+		var activity models.WatchActivity
+		if err := json.Unmarshal(typedMsg.Data, &activity); err == nil {
+			return WatchActivityMsg(activity)
+		}
+	case "service_groups":
+		var groups []models.ServiceGroup
+		if err := json.Unmarshal(typedMsg.Data, &groups); err == nil {
+			return ServiceGroupsMsg(groups)
+		}
+	case "processes":
+		var processes []models.ProcessStats
+		if err := json.Unmarshal(typedMsg.Data, &processes); err == nil {
+			return ProcessesMsg(processes)
+		}
+	case "traffic_matrix":
+		var matrix models.TrafficMatrix
+		if err := json.Unmarshal(typedMsg.Data, &matrix); err == nil {
+			return TrafficMatrixMsg(matrix)
+		}
+	case "encryption_stats":
+		var report models.EncryptionReport
+		if err := json.Unmarshal(typedMsg.Data, &report); err == nil {
+			return EncryptionStatsMsg(report)
+		}
+	case "countries":
+		var countries []models.CountryStats
+		if err := json.Unmarshal(typedMsg.Data, &countries); err == nil {
+			return CountriesMsg(countries)
+		}
+	case "hosts":
+		var hosts []models.Host
+		if err := json.Unmarshal(typedMsg.Data, &hosts); err == nil {
+			return HostsMsg(hosts)
+		}
+	case "leases":
+		var leases []models.Lease
+		if err := json.Unmarshal(typedMsg.Data, &leases); err == nil {
+			return LeasesMsg(leases)
+		}
+	case "services":
+		var devices []models.DiscoveredDevice
+		if err := json.Unmarshal(typedMsg.Data, &devices); err == nil {
+			return ServicesMsg(devices)
+		}
+	case "domains":
+		var report models.DomainReport
+		if err := json.Unmarshal(typedMsg.Data, &report); err == nil {
+			return DomainsMsg(report)
+		}
+	case "top_talkers":
+		var top models.TopTalkers
+		if err := json.Unmarshal(typedMsg.Data, &top); err == nil {
+			return TopTalkersMsg(top)
+		}
+	case "loss_report":
+		var report models.LossReport
+		if err := json.Unmarshal(typedMsg.Data, &report); err == nil {
+			return LossReportMsg(report)
+		}
+	case "hello":
+		var info models.DaemonInfo
+		if err := json.Unmarshal(typedMsg.Data, &info); err == nil {
+			return DaemonInfoMsg(info)
+		}
+	case "capture_restarted":
+		var restart models.CaptureRestart
+		if err := json.Unmarshal(typedMsg.Data, &restart); err == nil {
+			return CaptureRestartedMsg(restart)
+		}
+	case "capture_state":
+		var state models.CaptureState
+		if err := json.Unmarshal(typedMsg.Data, &state); err == nil {
+			return CaptureStateMsg(state)
+		}
+	case "throughput":
+		var throughput models.Throughput
+		if err := json.Unmarshal(typedMsg.Data, &throughput); err == nil {
+			return ThroughputMsg(throughput)
+		}
+	case "history":
+		var history models.History
+		if err := json.Unmarshal(typedMsg.Data, &history); err == nil {
+			return HistoryMsg(history)
+		}
+	case "conversation_packets":
+		var packets ConversationPacketsMsg
+		if err := json.Unmarshal(typedMsg.Data, &packets); err == nil {
+			return packets
+		}
+	case "new_device":
+		var device models.Device
+		if err := json.Unmarshal(typedMsg.Data, &device); err == nil {
+			return NewDeviceMsg(device)
+		}
+	case "alerts":
+		var alerts []models.Alert
+		if err := json.Unmarshal(typedMsg.Data, &alerts); err == nil {
+			return AlertsMsg(alerts)
+		}
+	case "command_rejected":
+		var rejected CommandRejectedMsg
+		if err := json.Unmarshal(typedMsg.Data, &rejected); err == nil {
+			return rejected
+		}
+	}
+	return nil
+}
+
+// deliver hands a message to the UI, dropping it when the UI has fallen behind
+func (c *Client) deliver(msg interface{}) {
+	if msg == nil {
+		return
+	}
+	select {
+	case c.messages <- msg:
+	default:
+		if _, ok := msg.(models.NetworkEvent); ok {
+			atomic.AddUint64(&c.dropped, 1)
 		}
 	}
 }
@@ -184,6 +445,66 @@ func (c *Client) WaitForEvent() tea.Cmd {
 				return EventMsg(m)
 			case ConversationsMsg:
 				return m
+			case FirewallRulesMsg:
+				return m
+			case FirewallResultMsg:
+				return m
+			case BlockMsg:
+				return m
+			case AlertMsg:
+				return m
+			case AlertsMsg:
+				return m
+			case WatchedHostsMsg:
+				return m
+			case WatchActivityMsg:
+				return m
+			case ServiceGroupsMsg:
+				return m
+			case ProcessesMsg:
+				return m
+			case TrafficMatrixMsg:
+				return m
+			case EncryptionStatsMsg:
+				return m
+			case CountriesMsg:
+				return m
+			case TopTalkersMsg:
+				return m
+			case HostsMsg:
+				return m
+			case DomainsMsg:
+				return m
+			case LeasesMsg:
+				return m
+			case ServicesMsg:
+				return m
+			case ConversationStateMsg:
+				return m
+			case ConversationSnapshotMsg:
+				return m
+			case ConversationDeltaMsg:
+				return m
+			case RecentEventsMsg:
+				return m
+			case LossReportMsg:
+				return m
+			case DaemonInfoMsg:
+				return m
+			case NewDeviceMsg:
+				return m
+			case CaptureRestartedMsg:
+				return m
+			case CaptureStateMsg:
+				return m
+			case ConversationPacketsMsg:
+				return m
+			case ThroughputMsg:
+				return m
+			case HistoryMsg:
+				return m
+			case ConnectionStatusMsg:
+				return m
 			default:
 				return nil
 			}
@@ -216,6 +537,9 @@ func (c *Client) Close() error {
 }
 
 func (c *Client) SendCommand(cmd interface{}) error {
+	if err := c.replayError(); err != nil {
+		return err
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	
@@ -254,6 +578,383 @@ func (c *Client) RequestConversations() error {
 	return c.SendCommand(cmd)
 }
 
+// SubscribeConversations asks the daemon for a snapshot of the conversation
+// summaries followed by deltas as they change, instead of polling for them
+func (c *Client) SubscribeConversations() error {
+	cmd := struct {
+		Type string `json:"type"`
+	}{
+		Type: "subscribe_conversations",
+	}
+	return c.SendCommand(cmd)
+}
+
+// RequestServiceGroups sends a request for conversations grouped by remote service
+func (c *Client) RequestServiceGroups() error {
+	cmd := struct {
+		Type string `json:"type"`
+	}{
+		Type: "get_service_groups",
+	}
+	return c.SendCommand(cmd)
+}
+
+// RequestProcesses sends a request for traffic totals per local program
+func (c *Client) RequestProcesses() error {
+	cmd := struct {
+		Type string `json:"type"`
+	}{
+		Type: "get_processes",
+	}
+	return c.SendCommand(cmd)
+}
+
+// RequestCountries sends a request for traffic totals per country of the
+// remote end
+func (c *Client) RequestCountries() error {
+	cmd := struct {
+		Type string `json:"type"`
+	}{
+		Type: "get_countries",
+	}
+	return c.SendCommand(cmd)
+}
+
+// RequestEncryptionStats sends a request for the encrypted and cleartext
+// share of each service's traffic
+// PauseCapture asks the daemon to pause or resume reading packets. Every
+// client is told of the change with a capture_state message.
+func (c *Client) PauseCapture(pause bool) error {
+	cmd := struct {
+		Type string `json:"type"`
+	}{
+		Type: "resume_capture",
+	}
+	if pause {
+		cmd.Type = "pause_capture"
+	}
+	return c.SendCommand(cmd)
+}
+
+// RequestCaptureState asks the daemon whether its capture is paused
+func (c *Client) RequestCaptureState() error {
+	cmd := struct {
+		Type string `json:"type"`
+	}{
+		Type: "get_capture_state",
+	}
+	return c.SendCommand(cmd)
+}
+
+// RequestThroughput sends a request for the bytes per second each way across
+// every conversation
+func (c *Client) RequestThroughput() error {
+	cmd := struct {
+		Type string `json:"type"`
+	}{
+		Type: "get_throughput",
+	}
+	return c.SendCommand(cmd)
+}
+
+func (c *Client) RequestEncryptionStats() error {
+	cmd := struct {
+		Type string `json:"type"`
+	}{
+		Type: "get_encryption_stats",
+	}
+	return c.SendCommand(cmd)
+}
+
+// RequestTrafficMatrix sends a request for the bytes between the busiest
+// locals local hosts and remotes remote destinations
+func (c *Client) RequestTrafficMatrix(locals, remotes int) error {
+	cmd := struct {
+		Type string `json:"type"`
+		Data struct {
+			Locals  int `json:"locals"`
+			Remotes int `json:"remotes"`
+		} `json:"data"`
+	}{
+		Type: "get_traffic_matrix",
+	}
+	cmd.Data.Locals = locals
+	cmd.Data.Remotes = remotes
+	return c.SendCommand(cmd)
+}
+
+// RequestHosts sends a request for the conversations grouped by remote host
+func (c *Client) RequestHosts() error {
+	cmd := struct {
+		Type string `json:"type"`
+	}{
+		Type: "get_hosts",
+	}
+	return c.SendCommand(cmd)
+}
+
+// RequestServices sends a request for the devices the daemon has seen
+// advertising services over mDNS and SSDP
+func (c *Client) RequestServices() error {
+	cmd := struct {
+		Type string `json:"type"`
+	}{
+		Type: "get_services",
+	}
+	return c.SendCommand(cmd)
+}
+
+// RequestLeases sends a request for the DHCP clients the daemon has seen
+func (c *Client) RequestLeases() error {
+	cmd := struct {
+		Type string `json:"type"`
+	}{
+		Type: "get_leases",
+	}
+	return c.SendCommand(cmd)
+}
+
+// RequestDomains sends a request for the traffic per domain name on a day
+// ("YYYY-MM-DD" or "today", "" since the daemon started), with subdomains
+// grouped under their registered domain when group is set
+func (c *Client) RequestDomains(day string, group bool) error {
+	cmd := struct {
+		Type string `json:"type"`
+		Data struct {
+			Day   string `json:"day"`
+			Group bool   `json:"group"`
+		} `json:"data"`
+	}{
+		Type: "get_domains",
+	}
+	cmd.Data.Day = day
+	cmd.Data.Group = group
+	return c.SendCommand(cmd)
+}
+
+// RequestTopTalkers sends a request for the limit busiest hosts, ports,
+// services or programs (by) over the last window
+func (c *Client) RequestTopTalkers(by string, window time.Duration, limit int) error {
+	cmd := struct {
+		Type string `json:"type"`
+		Data struct {
+			By            string `json:"by"`
+			WindowSeconds int    `json:"window_seconds"`
+			Limit         int    `json:"limit"`
+		} `json:"data"`
+	}{
+		Type: "get_top",
+	}
+	cmd.Data.By = by
+	cmd.Data.WindowSeconds = int(window.Seconds())
+	cmd.Data.Limit = limit
+	return c.SendCommand(cmd)
+}
+
+// RequestConversationPackets sends a request for up to limit of a
+// conversation's most recent packets
+func (c *Client) RequestConversationPackets(id string, limit int) error {
+	cmd := struct {
+		Type string `json:"type"`
+		Data struct {
+			ID    string `json:"id"`
+			Limit int    `json:"limit"`
+		} `json:"data"`
+	}{
+		Type: "get_conversation_packets",
+	}
+	cmd.Data.ID = id
+	cmd.Data.Limit = limit
+	return c.SendCommand(cmd)
+}
+
+// RequestHistory sends a request for up to limit conversations and sampled
+// events stored between from and to, narrowed to a host or service unless
+// they're empty
+func (c *Client) RequestHistory(from, to time.Time, host, service string, limit int) error {
+	cmd := struct {
+		Type string `json:"type"`
+		Data struct {
+			From    string `json:"from"`
+			To      string `json:"to"`
+			Host    string `json:"host,omitempty"`
+			Service string `json:"service,omitempty"`
+			Limit   int    `json:"limit"`
+		} `json:"data"`
+	}{
+		Type: "get_history",
+	}
+	cmd.Data.From = from.UTC().Format(time.RFC3339)
+	cmd.Data.To = to.UTC().Format(time.RFC3339)
+	cmd.Data.Host = host
+	cmd.Data.Service = service
+	cmd.Data.Limit = limit
+	return c.SendCommand(cmd)
+}
+
+// RequestFirewallRules asks the daemon to generate block rules for a remote host
+func (c *Client) RequestFirewallRules(target models.FirewallTarget) error {
+	cmd := struct {
+		Type string                `json:"type"`
+		Data models.FirewallTarget `json:"data"`
+	}{
+		Type: "generate_firewall_rules",
+		Data: target,
+	}
+	return c.SendCommand(cmd)
+}
+
+// ApplyFirewallRules asks the daemon to apply block rules for a remote host.
+// The daemon only honors this if it was started with -allow-firewall and an API token.
+func (c *Client) ApplyFirewallRules(target models.FirewallTarget) error {
+	cmd := struct {
+		Type string `json:"type"`
+		Data struct {
+			models.FirewallTarget
+			Confirm bool `json:"confirm"`
+		} `json:"data"`
+	}{
+		Type: "apply_firewall_rules",
+	}
+	cmd.Data.FirewallTarget = target
+	cmd.Data.Confirm = true
+	return c.SendCommand(cmd)
+}
+
+// RequestAlerts asks the daemon for every alert it holds
+func (c *Client) RequestAlerts() error {
+	cmd := struct {
+		Type string `json:"type"`
+	}{
+		Type: "get_alerts",
+	}
+	return c.SendCommand(cmd)
+}
+
+// AcknowledgeAlert marks an alert as seen
+func (c *Client) AcknowledgeAlert(id string) error {
+	return c.sendAlertCommand("acknowledge_alert", id)
+}
+
+// ResolveAlert closes an alert
+func (c *Client) ResolveAlert(id string) error {
+	return c.sendAlertCommand("resolve_alert", id)
+}
+
+func (c *Client) sendAlertCommand(cmdType, id string) error {
+	cmd := struct {
+		Type string `json:"type"`
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}{
+		Type: cmdType,
+	}
+	cmd.Data.ID = id
+	return c.SendCommand(cmd)
+}
+
+// WatchHost asks the daemon to watch a host, or to stop watching it
+func (c *Client) WatchHost(host string, watch bool) error {
+	cmd := struct {
+		Type string `json:"type"`
+		Data struct {
+			Host string `json:"host"`
+		} `json:"data"`
+	}{
+		Type: "unwatch_host",
+	}
+	if watch {
+		cmd.Type = "watch_host"
+	}
+	cmd.Data.Host = host
+	return c.SendCommand(cmd)
+}
+
+// RequestWatchedHosts asks the daemon for the watched hosts
+func (c *Client) RequestWatchedHosts() error {
+	cmd := struct {
+		Type string `json:"type"`
+	}{
+		Type: "get_watched_hosts",
+	}
+	return c.SendCommand(cmd)
+}
+
+// FetchConversationPcap downloads a conversation's buffered packets from the
+// daemon's HTTP API as a pcap file
+func (c *Client) FetchConversationPcap(id string) ([]byte, error) {
+	if err := c.replayError(); err != nil {
+		return nil, err
+	}
+	resp, err := c.apiGet("/api/v1/conversations/pcap", url.Values{"id": {id}}, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pcap: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("daemon returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// FetchHealth gets the daemon's /health report over its HTTP API
+func (c *Client) FetchHealth() (models.DaemonHealth, error) {
+	var health models.DaemonHealth
+	if err := c.replayError(); err != nil {
+		return health, err
+	}
+	resp, err := c.apiGet("/health", nil, 5*time.Second)
+	if err != nil {
+		return health, fmt.Errorf("failed to reach daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return health, fmt.Errorf("daemon returned %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return health, fmt.Errorf("failed to parse health report: %w", err)
+	}
+	return health, nil
+}
+
+// apiGet requests a path of the daemon's HTTP API, with the API token if set
+func (c *Client) apiGet(path string, query url.Values, timeout time.Duration) (*http.Response, error) {
+	u, err := c.apiURL(path, query)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid daemon URL: %w", err)
+	}
+	for key, values := range c.authHeader() {
+		req.Header[key] = values
+	}
+	return c.httpClient(timeout).Do(req)
+}
+
+// DroppedEvents returns how many network events were dropped since connecting
+// because the UI wasn't reading them fast enough
+func (c *Client) DroppedEvents() uint64 {
+	return atomic.LoadUint64(&c.dropped)
+}
+
+// URL returns the daemon WebSocket URL the client connects to, or its Unix
+// socket
+func (c *Client) URL() string {
+	if c.socket != "" {
+		return "unix:" + c.socket
+	}
+	return c.url
+}
+
 // IsConnected returns the current connection status
 func (c *Client) IsConnected() bool {
 	c.mu.Lock()
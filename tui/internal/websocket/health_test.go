@@ -0,0 +1,35 @@
+package websocket
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFetchHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"status":"degraded","version":"1.4.0","protocol":1,"capabilities":["conversations","pcap_export"],
+			"capture_stats":{"total_packets":42,"dropped_packets":3,"processed_events":39,"capture_state":"recovering"}}`))
+	}))
+	defer server.Close()
+
+	host, portText, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	port, _ := strconv.Atoi(portText)
+	health, err := NewClient(host, port).FetchHealth()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if health.Status != "degraded" || health.Version != "1.4.0" || health.Protocol != 1 || !health.Has("pcap_export") {
+		t.Errorf("Unexpected daemon details: %+v", health)
+	}
+	if health.CaptureStats.TotalPackets != 42 || health.CaptureStats.CaptureState != "recovering" {
+		t.Errorf("Unexpected capture stats: %+v", health.CaptureStats)
+	}
+}
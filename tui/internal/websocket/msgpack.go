@@ -0,0 +1,54 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgpackSubprotocol is the WebSocket subprotocol asking the daemon for
+// MessagePack binary frames instead of JSON text frames
+const MsgpackSubprotocol = "netty.msgpack.v1"
+
+// UseMsgpack makes the client ask the daemon for MessagePack binary frames,
+// which are smaller and cheaper for it to encode at high event rates. A daemon
+// without them keeps sending JSON. Call it before Connect.
+func (c *Client) UseMsgpack(on bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.msgpack = on
+}
+
+// binaryToJSON converts a MessagePack message to the JSON the daemon would
+// have sent, so recordings and everything downstream see one format
+func binaryToJSON(data []byte) ([]byte, error) {
+	dec := msgpack.GetDecoder()
+	defer msgpack.PutDecoder(dec)
+	dec.Reset(bytes.NewReader(data))
+	// JSON object keys are strings, whatever the map was keyed by
+	dec.SetMapDecoder(func(d *msgpack.Decoder) (interface{}, error) {
+		n, err := d.DecodeMapLen()
+		if err != nil || n == -1 {
+			return nil, err
+		}
+		m := make(map[string]interface{}, n)
+		for i := 0; i < n; i++ {
+			key, err := d.DecodeInterface()
+			if err != nil {
+				return nil, err
+			}
+			if m[fmt.Sprint(key)], err = d.DecodeInterface(); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	})
+
+	message, err := dec.DecodeInterface()
+	if err != nil {
+		return nil, fmt.Errorf("invalid msgpack message: %w", err)
+	}
+	return json.Marshal(message)
+}
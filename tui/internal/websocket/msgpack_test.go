@@ -0,0 +1,62 @@
+package websocket
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestUseMsgpack(t *testing.T) {
+	at := time.Date(2025, 7, 1, 10, 30, 45, 0, time.UTC)
+	upgrader := websocket.Upgrader{Subprotocols: []string{MsgpackSubprotocol}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if conn.Subprotocol() != MsgpackSubprotocol {
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"network_event","data":{"size":0}}`))
+		} else {
+			data, _ := msgpack.Marshal(map[string]interface{}{
+				"type": "network_event",
+				"data": map[string]interface{}{"timestamp": at, "dest_ip": "140.82.112.3", "size": 1500},
+			})
+			conn.WriteMessage(websocket.BinaryMessage, data)
+		}
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	host, portText, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	port, _ := strconv.Atoi(portText)
+	c := NewClient(host, port)
+	c.UseMsgpack(true)
+	if status, ok := c.Connect()().(ConnectionStatusMsg); !ok || !status.Connected {
+		t.Fatalf("Expected to connect, got %+v", status)
+	}
+	defer c.Close()
+
+	event, ok := c.WaitForEvent()().(EventMsg)
+	if !ok || event.Size != 1500 || event.DestIP != "140.82.112.3" || !event.Timestamp.Equal(at) {
+		t.Errorf("Expected the binary event decoded, got %+v", event)
+	}
+}
+
+func TestBinaryToJSON_IntegerKeys(t *testing.T) {
+	data, _ := msgpack.Marshal(map[string]interface{}{"type": "ports", "data": map[int]string{443: "https"}})
+	message, err := binaryToJSON(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(message) != `{"data":{"443":"https"},"type":"ports"}` {
+		t.Errorf("Expected keys as JSON strings, got %s", message)
+	}
+}
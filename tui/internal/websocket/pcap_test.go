@@ -0,0 +1,37 @@
+package websocket
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFetchConversationPcap(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/conversations/pcap" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("id") != "abc" {
+			http.Error(w, "no packets buffered for conversation", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("pcap-data"))
+	}))
+	defer server.Close()
+
+	host, portText, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	port, _ := strconv.Atoi(portText)
+	client := NewClient(host, port)
+
+	data, err := client.FetchConversationPcap("abc")
+	if err != nil || string(data) != "pcap-data" {
+		t.Errorf("Expected the pcap body, got %q (%v)", data, err)
+	}
+	if _, err := client.FetchConversationPcap("missing"); err == nil || !strings.Contains(err.Error(), "no packets buffered") {
+		t.Errorf("Expected the daemon's error to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ErrReplayFinished is the connection error once a recording has played to the end
+var ErrReplayFinished = errors.New("end of recording")
+
+// recordedMessage is one line of a recording: a daemon message as received
+type recordedMessage struct {
+	Time    time.Time       `json:"time"`
+	Message json.RawMessage `json:"message"`
+}
+
+// recorder appends the messages a client receives to a file
+type recorder struct {
+	file    *os.File
+	encoder *json.Encoder
+	err     error // The first write error, after which recording stops
+	mu      sync.Mutex
+}
+
+// replay plays a recording back in place of a daemon connection
+type replay struct {
+	path     string
+	messages []recordedMessage
+	speed    float64 // 1 is the original pace, 0 as fast as the UI takes messages
+	started  bool
+}
+
+// Record saves every message the client receives from now on to path, one
+// JSON object per line, replacing the file if it exists. Recordings are
+// played back with NewReplayClient.
+func (c *Client) Record(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create recording: %w", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recorder = &recorder{file: file, encoder: json.NewEncoder(file)}
+	return nil
+}
+
+// StopRecording closes the recording, returning the first error writing it
+func (c *Client) StopRecording() error {
+	c.mu.Lock()
+	r := c.recorder
+	c.recorder = nil
+	c.mu.Unlock()
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.file.Close(); err != nil && r.err == nil {
+		r.err = fmt.Errorf("failed to save recording: %w", err)
+	}
+	return r.err
+}
+
+// record appends a received message to the recording, if there is one
+func (c *Client) record(message []byte) {
+	c.mu.Lock()
+	r := c.recorder
+	c.mu.Unlock()
+	if r == nil || !json.Valid(message) {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if err := r.encoder.Encode(recordedMessage{Time: time.Now(), Message: message}); err != nil {
+		r.err = fmt.Errorf("failed to save recording: %w", err)
+	}
+}
+
+// NewReplayClient creates a client that plays a recording back instead of
+// connecting to a daemon. speed scales the pace: 1 replays the messages with
+// their original gaps, 10 ten times faster and 0 as fast as the UI takes them.
+// Commands aren't sent anywhere; the recording holds the replies to the
+// commands of the recorded session.
+func NewReplayClient(path string, speed float64) (*Client, error) {
+	if speed < 0 {
+		return nil, fmt.Errorf("invalid replay speed %g", speed)
+	}
+	messages, err := loadRecording(path)
+	if err != nil {
+		return nil, err
+	}
+	c := NewClient("", 0)
+	c.replay = &replay{path: path, messages: messages, speed: speed}
+	return c, nil
+}
+
+// loadRecording reads the messages of a recording
+func loadRecording(path string) ([]recordedMessage, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer file.Close()
+
+	var messages []recordedMessage
+	decoder := json.NewDecoder(file)
+	for {
+		var recorded recordedMessage
+		err := decoder.Decode(&recorded)
+		if err == io.EOF {
+			return messages, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read recording %s: %w", path, err)
+		}
+		messages = append(messages, recorded)
+	}
+}
+
+// ReplayPath returns the recording being played back, "" for a live connection
+func (c *Client) ReplayPath() string {
+	if c.replay == nil {
+		return ""
+	}
+	return c.replay.path
+}
+
+// startReplay starts playing the recording, which can only be played once
+func (c *Client) startReplay() tea.Msg {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.replay.started {
+		return ConnectionStatusMsg{Connected: false, Error: ErrReplayFinished}
+	}
+	c.replay.started = true
+	c.isConnected = true
+	go c.play()
+	return ConnectionStatusMsg{Connected: true}
+}
+
+// play hands the recorded messages to the UI at the replay's pace. Unlike a
+// live daemon a recording can wait for the UI, so nothing is dropped.
+func (c *Client) play() {
+	var previous time.Time
+	for i, recorded := range c.replay.messages {
+		if gap := recorded.Time.Sub(previous); c.replay.speed > 0 && i > 0 && gap > 0 {
+			select {
+			case <-time.After(time.Duration(float64(gap) / c.replay.speed)):
+			case <-c.stopRead:
+				return
+			}
+		}
+		previous = recorded.Time
+
+		msg := c.parseMessage(recorded.Message)
+		if msg == nil {
+			continue
+		}
+		select {
+		case c.messages <- msg:
+		case <-c.stopRead:
+			return
+		}
+	}
+
+	c.mu.Lock()
+	c.isConnected = false
+	c.mu.Unlock()
+	// Queued behind the messages so the UI sees the end after the last of them
+	select {
+	case c.messages <- ConnectionStatusMsg{Connected: false, Error: ErrReplayFinished}:
+	case <-c.stopRead:
+	}
+}
+
+// replayError is the error for what a replay can't do, nil for a live connection
+func (c *Client) replayError() error {
+	if c.replay == nil {
+		return nil
+	}
+	return fmt.Errorf("replaying %s, there is no daemon to ask", c.replay.path)
+}
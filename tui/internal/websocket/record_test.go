@@ -0,0 +1,101 @@
+package websocket
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	sent := []string{
+		`{"type":"network_event","data":{"source_ip":"192.168.1.10","dest_ip":"140.82.112.3","size":60}}`,
+		`{"type":"conversations","data":[{"id":"tcp-1"}]}`,
+		`{"type":"network_event","data":{"source_ip":"140.82.112.3","dest_ip":"192.168.1.10","size":1500}}`,
+	}
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for _, message := range sent {
+			conn.WriteMessage(websocket.TextMessage, []byte(message))
+		}
+		conn.ReadMessage() // Hold the connection open until the client leaves
+	}))
+	defer server.Close()
+
+	host, portText, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	port, _ := strconv.Atoi(portText)
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+
+	live := NewClient(host, port)
+	if err := live.Record(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if status, ok := live.Connect()().(ConnectionStatusMsg); !ok || !status.Connected {
+		t.Fatalf("Expected to connect, got %+v", status)
+	}
+	for range sent {
+		live.WaitForEvent()()
+	}
+	live.Close()
+	if err := live.StopRecording(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines != len(sent) {
+		t.Fatalf("Expected %d recorded messages, got %d:\n%s", len(sent), lines, data)
+	}
+
+	replayed, err := NewReplayClient(path, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if replayed.ReplayPath() != path {
+		t.Errorf("Expected replay path %s, got %q", path, replayed.ReplayPath())
+	}
+	if status, ok := replayed.Connect()().(ConnectionStatusMsg); !ok || !status.Connected {
+		t.Fatalf("Expected the replay to start, got %+v", status)
+	}
+	if event, ok := replayed.WaitForEvent()().(EventMsg); !ok || event.Size != 60 {
+		t.Errorf("Expected the first event first, got %+v", event)
+	}
+	if conversations, ok := replayed.WaitForEvent()().(ConversationsMsg); !ok || len(conversations) != 1 {
+		t.Errorf("Expected the conversations second, got %+v", conversations)
+	}
+	if event, ok := replayed.WaitForEvent()().(EventMsg); !ok || event.Size != 1500 {
+		t.Errorf("Expected the last event last, got %+v", event)
+	}
+	if status, ok := replayed.WaitForEvent()().(ConnectionStatusMsg); !ok || !errors.Is(status.Error, ErrReplayFinished) {
+		t.Errorf("Expected the replay to finish, got %+v", status)
+	}
+
+	// A finished recording doesn't start over, and there is no daemon to command
+	if status, ok := replayed.Connect()().(ConnectionStatusMsg); !ok || status.Connected || !errors.Is(status.Error, ErrReplayFinished) {
+		t.Errorf("Expected a finished replay to stay finished, got %+v", status)
+	}
+	if err := replayed.RequestConversations(); err == nil {
+		t.Error("Expected commands to fail while replaying")
+	}
+}
+
+func TestNewReplayClient_Errors(t *testing.T) {
+	if _, err := NewReplayClient(filepath.Join(t.TempDir(), "missing.jsonl"), 1); err == nil {
+		t.Error("Expected an error for a missing recording")
+	}
+	path := filepath.Join(t.TempDir(), "corrupt.jsonl")
+	os.WriteFile(path, []byte("not a recording\n"), 0o644)
+	if _, err := NewReplayClient(path, 1); err == nil {
+		t.Error("Expected an error for a corrupt recording")
+	}
+}
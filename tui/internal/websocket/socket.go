@@ -0,0 +1,26 @@
+package websocket
+
+import (
+	"context"
+	"net"
+	"net/url"
+)
+
+// UseSocket makes the client reach the daemon through the Unix domain socket
+// at path, as served with -listen-unix, instead of over TCP. The WebSocket
+// URL then only names the daemon in requests. Call it before Connect.
+func (c *Client) UseSocket(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.socket = path
+	if u, err := url.Parse(c.url); err == nil {
+		u.Host = "localhost"
+		c.url = u.String()
+	}
+}
+
+// dialSocket connects to the daemon's Unix socket, whatever address is asked for
+func (c *Client) dialSocket(ctx context.Context, _, _ string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "unix", c.socket)
+}
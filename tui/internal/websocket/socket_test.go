@@ -0,0 +1,56 @@
+package websocket
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestUseSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "netty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "netty.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upgrader := websocket.Upgrader{}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Write([]byte(`{"status":"healthy","version":"1.4.0","protocol":1}`))
+		case "/ws":
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			conn.ReadMessage()
+		default:
+			http.NotFound(w, r)
+		}
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	c := NewClient("router.lan", 8080)
+	c.UseSocket(path)
+	if c.URL() != "unix:"+path {
+		t.Errorf("Expected the socket as the daemon's address, got %s", c.URL())
+	}
+	if health, err := c.FetchHealth(); err != nil || health.Version != "1.4.0" {
+		t.Errorf("Expected the health report over the socket, got %+v (%v)", health, err)
+	}
+	status, ok := c.Connect()().(ConnectionStatusMsg)
+	if !ok || !status.Connected {
+		t.Errorf("Expected to connect over the socket, got %+v", status)
+	}
+	c.Close()
+}
@@ -0,0 +1,97 @@
+package websocket
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// EnableTLS makes the client connect with wss and reach the HTTP API over
+// https. caFile names a PEM file of certificates to trust on top of the
+// system's, for a daemon with a self-signed certificate; "" trusts only the
+// system's.
+func (c *Client) EnableTLS(caFile string) error {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA certificates: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+
+	u, err := url.Parse(c.url)
+	if err != nil {
+		return fmt.Errorf("invalid daemon URL: %w", err)
+	}
+	u.Scheme = "wss"
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.url = u.String()
+	c.tlsConfig = config
+	return nil
+}
+
+// UsesTLS reports whether the client connects to the daemon over TLS
+func (c *Client) UsesTLS() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tlsConfig != nil
+}
+
+// dialer returns the WebSocket dialer for the client's connection
+func (c *Client) dialer() *websocket.Dialer {
+	dialer := *websocket.DefaultDialer
+	dialer.TLSClientConfig = c.tlsConfig
+	if c.socket != "" {
+		dialer.NetDialContext = c.dialSocket
+	}
+	if c.msgpack {
+		dialer.Subprotocols = []string{MsgpackSubprotocol}
+	}
+	return &dialer
+}
+
+// apiURL returns the URL of a path on the daemon's HTTP API, which shares
+// the WebSocket's address and, with wss, is served over https
+func (c *Client) apiURL(path string, query url.Values) (string, error) {
+	u, err := url.Parse(c.url)
+	if err != nil {
+		return "", fmt.Errorf("invalid daemon URL: %w", err)
+	}
+	if u.Scheme == "wss" {
+		u.Scheme = "https"
+	} else {
+		u.Scheme = "http"
+	}
+	u.Path = path
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// httpClient returns an HTTP client for the daemon's API
+func (c *Client) httpClient(timeout time.Duration) *http.Client {
+	client := &http.Client{Timeout: timeout}
+	if c.tlsConfig != nil || c.socket != "" {
+		transport := &http.Transport{TLSClientConfig: c.tlsConfig}
+		if c.socket != "" {
+			transport.DialContext = c.dialSocket
+		}
+		client.Transport = transport
+	}
+	return client
+}
@@ -0,0 +1,82 @@
+package websocket
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestNewClient_Address(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+		tls  bool
+	}{
+		{"localhost", "ws://localhost:8080/ws", false},
+		{"::1", "ws://[::1]:8080/ws", false},
+		{"ws://router.lan", "ws://router.lan:8080/ws", false},
+		{"wss://router.lan", "wss://router.lan:8080/ws", true},
+		{"wss://router.lan:8443/", "wss://router.lan:8443/ws", true},
+	}
+	for _, tt := range tests {
+		c := NewClient(tt.host, 8080)
+		if c.URL() != tt.want || c.UsesTLS() != tt.tls {
+			t.Errorf("NewClient(%q) = %s (TLS %v), want %s (TLS %v)", tt.host, c.URL(), c.UsesTLS(), tt.want, tt.tls)
+		}
+	}
+}
+
+func TestEnableTLS(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Write([]byte(`{"status":"healthy","version":"1.4.0","protocol":1}`))
+		case "/ws":
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			conn.ReadMessage()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "https://")
+
+	// The test server's certificate is self-signed, so nothing trusts it by default
+	c := NewClient("wss://"+address, 0)
+	if _, err := c.FetchHealth(); err == nil {
+		t.Fatal("Expected an untrusted certificate to be refused")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o644)
+	c = NewClient(address, 0)
+	if err := c.EnableTLS(caFile); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(c.URL(), "wss://") {
+		t.Errorf("Expected a wss URL, got %s", c.URL())
+	}
+	if health, err := c.FetchHealth(); err != nil || health.Version != "1.4.0" {
+		t.Errorf("Expected the health report over https, got %+v (%v)", health, err)
+	}
+	status, ok := c.Connect()().(ConnectionStatusMsg)
+	if !ok || !status.Connected {
+		t.Errorf("Expected to connect over wss, got %+v", status)
+	}
+	c.Close()
+
+	if err := NewClient("localhost", 8080).EnableTLS(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("Expected an error for a missing CA file")
+	}
+}